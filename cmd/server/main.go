@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"os/signal"
@@ -19,6 +20,11 @@ import (
 	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/infra/database"
 	"github.com/lugondev/m3-storage/internal/infra/tracer"
+	mediaDomain "github.com/lugondev/m3-storage/internal/modules/media/domain"
+	mediaService "github.com/lugondev/m3-storage/internal/modules/media/service"
+	storageFactory "github.com/lugondev/m3-storage/internal/modules/storage/factory"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
 
 	// External Libs
 	"github.com/BurntSushi/toml"
@@ -30,6 +36,12 @@ import (
 	_ "github.com/lugondev/m3-storage/docs"
 )
 
+// defaultShutdownTimeout is how long shutdown waits for requests and uploads in flight
+const defaultShutdownTimeout = 30 * time.Second
+
+// defaultS3GatewayPort is the port of the S3-compatible API when s3Gateway.port is not set
+const defaultS3GatewayPort = "8084"
+
 // @title M3 Storage API
 // @version 1.0
 // @description This is the core API for M3 Storage platform
@@ -65,6 +77,9 @@ func main() {
 		case "seed:prod":
 			runSeeder("production")
 			return
+		case "verify":
+			runVerify(os.Args[2:])
+			return
 		}
 	}
 
@@ -74,6 +89,10 @@ func main() {
 		fmt.Printf("Failed to load configuration: %v\n", err)
 		os.Exit(1)
 	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
 
 	// --- Initialize i18n Bundle ---
 	i18nBundle, err := initI18n()
@@ -133,12 +152,17 @@ func main() {
 	cache.ExitOnError(log, "Redis initialization failed", err)
 	defer cache.CloseRedisClient(redisClient, log) // Close the Redis client wrapper
 
+	// Cancelled on a second shutdown signal to stop without waiting for uploads in flight
+	baseCtx, hardStop := context.WithCancel(context.Background())
+	defer hardStop()
+
 	// --- Build Infrastructure Struct ---
 	infra := &application.Infrastructure{
 		Config:      &cfg,
 		Logger:      log,
 		DB:          db,
 		RedisClient: redisClient,
+		BaseContext: baseCtx,
 	}
 
 	// --- Build Application Dependencies ---
@@ -147,6 +171,14 @@ func main() {
 		log.Fatalf(context.Background(), "Failed to build application dependencies: %v", err)
 	}
 
+	// --- Start Background Workers ---
+	appDeps.HealthMon.Start()
+	defer appDeps.HealthMon.Stop()
+	appDeps.UsageSched.Start()
+	defer appDeps.UsageSched.Stop()
+	appDeps.Webhooks.Start()
+	defer appDeps.Webhooks.Stop()
+
 	// --- Initialize Fiber App ---
 	app := fiber.New(fiber.Config{
 		AppName:           fmt.Sprintf("%s API", cfg.App.Name),
@@ -161,43 +193,33 @@ func main() {
 	router.RegisterRoutes(app, &router.RouterConfig{
 		AuthMw:         appDeps.AuthMiddleware,
 		AuthHandler:    appDeps.AuthDependencies.AuthHandler,
+		AdminHandler:   appDeps.AdminHandler,
+		AuditHandler:   appDeps.AuditHandler,
+		AvatarHandler:  appDeps.AvatarHandler,
+		AccountHandler: appDeps.AccountHandler,
 		MediaHandler:   appDeps.MediaHandler,
 		StorageHandler: appDeps.StorageHandler,
-	})
-
-	app.Get("/health", func(c *fiber.Ctx) error {
-		// Basic DB Ping check (more detailed checks are in HealthService)
-		if err := sqlDB.Ping(); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":    "unhealthy",
-				"message":   "Database connection failed",
-				"error":     err.Error(),
-				"timestamp": time.Now().Format(time.RFC3339),
-			})
-		}
 
-		// Basic Redis Ping check
-		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-		defer cancel()
-		// Use the underlying client from the wrapper for Ping
-		if err := redisClient.Client().Ping(ctx).Err(); err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"status":    "unhealthy",
-				"message":   "Redis connection failed",
-				"error":     err.Error(),
-				"timestamp": time.Now().Format(time.RFC3339),
-			})
-		}
+		LocalFiles: appDeps.LocalFiles,
+	})
 
-		// All basic systems operational
-		return c.JSON(fiber.Map{
-			"status":      "healthy",
-			"database":    "ok",
-			"redis":       "ok",
-			"environment": cfg.App.Env,
-			"timestamp":   time.Now(),
+	// Probes live outside /api/v1; /health/live never checks dependencies, so only a hung
+	// process fails it, while /health/ready takes the instance out of rotation
+	app.Get("/health", appDeps.HealthHandler.Health)
+	app.Get("/health/live", appDeps.HealthHandler.Live)
+	app.Get("/health/ready", appDeps.HealthHandler.Ready)
+
+	// The S3-compatible API has a listener of its own: its routes take the whole path
+	// space and its errors are S3 XML documents
+	var s3App *fiber.App
+	if appDeps.S3Handler != nil {
+		s3App = fiber.New(fiber.Config{
+			AppName:           fmt.Sprintf("%s S3 Gateway", cfg.App.Name),
+			ErrorHandler:      appDeps.S3Handler.ErrorHandler,
+			StreamRequestBody: true,
 		})
-	})
+		router.RegisterS3Routes(s3App, appDeps.S3Handler)
+	}
 
 	// --- Graceful Shutdown Setup ---
 	shutdownChan := make(chan os.Signal, 1)
@@ -216,16 +238,71 @@ func main() {
 		}
 	}()
 
+	if s3App != nil {
+		s3Port := cfg.S3Gateway.Port
+		if s3Port == "" {
+			s3Port = defaultS3GatewayPort
+		}
+		log.Info(context.Background(), "S3 gateway starting", map[string]any{
+			"port": s3Port,
+		})
+		go func() {
+			if err := s3App.Listen(":" + s3Port); err != nil {
+				log.Error(context.Background(), "S3 gateway error", map[string]any{
+					"error": err,
+				})
+			}
+		}()
+	}
+
 	// --- Wait for Interrupt Signal ---
 	<-shutdownChan
 
 	// --- Graceful Shutdown ---
+	// Stop accepting connections and wait for requests and uploads in flight, up to the
+	// shutdown timeout. A second signal cancels them at once.
 	log.Info(context.Background(), "Shutting down server...")
-	if err := app.Shutdown(); err != nil {
+	go func() {
+		<-shutdownChan
+		log.Warn(context.Background(), "Second shutdown signal received, cancelling uploads in flight")
+		hardStop()
+	}()
+
+	timeout := time.Duration(cfg.App.ShutdownTimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(baseCtx, timeout)
+	defer cancel()
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- appDeps.MediaSvc.DrainUploads(shutdownCtx)
+	}()
+	s3Stopped := make(chan struct{})
+	go func() {
+		defer close(s3Stopped)
+		if s3App == nil {
+			return
+		}
+		if err := s3App.ShutdownWithContext(shutdownCtx); err != nil {
+			log.Error(context.Background(), "Failed to shutdown S3 gateway gracefully", map[string]any{
+				"error": err,
+			})
+		}
+	}()
+	if err := app.ShutdownWithContext(shutdownCtx); err != nil {
 		log.Error(context.Background(), "Failed to shutdown server gracefully", map[string]any{
 			"error": err,
 		})
 	}
+	<-s3Stopped
+	if err := <-drained; err != nil {
+		log.Warn(context.Background(), "Uploads still in flight were cancelled", map[string]any{
+			"error": err,
+		})
+	}
+	hardStop()
 
 	log.Info(context.Background(), "Server gracefully stopped")
 }
@@ -313,8 +390,20 @@ func runSeeder(seedType string) {
 	}
 	defer database.CloseSqlDB(sqlDB)
 
+	// Sample media is stored in the local backend
+	storage, err := storageFactory.NewStorageFactory(&cfg, log, nil, clock.New())
+	if err != nil {
+		fmt.Printf("Failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+	localStorage, err := storage.CreateProviderByName(string(storagePort.ProviderLocal))
+	if err != nil {
+		fmt.Printf("Failed to initialize local storage: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Initialize seeder manager
-	seederManager := seeders.NewSeederManager(db)
+	seederManager := seeders.NewSeederManager(db, localStorage)
 
 	// Run appropriate seeder based on type
 	switch seedType {
@@ -344,3 +433,81 @@ func runSeeder(seedType string) {
 
 	fmt.Printf("Database seeding (%s) completed successfully!\n", seedType)
 }
+
+// runVerify checks media records against the storage providers and reports dangling
+// records and, with --orphans, objects without a record. It exits with status 1 when
+// anything was found, so it can run from cron.
+func runVerify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "mark media whose object is missing as missing, hiding it from listings")
+	orphans := flags.Bool("orphans", false, "also list provider objects to find those without a media record")
+	provider := flags.String("provider", "", "only check this storage backend")
+	_ = flags.Parse(args)
+
+	fmt.Println("Verifying media records against storage...")
+
+	// Load configuration
+	cfg, err := config.LoadConfig("./config")
+	if err != nil {
+		fmt.Printf("Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Initialize logger
+	log, otelErr := logger.NewLogger(&logger.Option{
+		ScopeName:    cfg.App.Name,
+		ScopeVersion: cfg.App.Env,
+		Format:       cfg.Log.Format,
+	})
+	if otelErr != nil {
+		fmt.Printf("Failed to create OpenTelemetry logger: %v\n", otelErr)
+		os.Exit(1)
+	}
+
+	db, sqlDB, err := database.InitializeDatabase(cfg, log)
+	if err != nil {
+		fmt.Printf("Failed to initialize database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.CloseSqlDB(sqlDB)
+
+	// Providers that cannot look objects up by key (Telegram) keep their index in Redis
+	redisClient, err := cache.InitializeRedisClient(cfg, log)
+	if err != nil {
+		fmt.Printf("Failed to initialize Redis: %v\n", err)
+		os.Exit(1)
+	}
+	defer cache.CloseRedisClient(redisClient, log)
+
+	storage, err := storageFactory.NewStorageFactory(&cfg, log, cache.NewRedisObjectIndex(redisClient), clock.New())
+	if err != nil {
+		fmt.Printf("Failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+
+	checker := mediaService.NewConsistencyChecker(db, storage, log)
+	report, err := checker.Verify(context.Background(), &mediaDomain.VerifyOptions{
+		Provider: storage.ResolveBackendName(*provider),
+		Orphans:  *orphans,
+		Fix:      *fix,
+	})
+	if err != nil {
+		fmt.Printf("Verification failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, media := range report.Dangling {
+		fmt.Printf("dangling  %s  user=%s  %s:%s\n", media.MediaID, media.UserID, media.Provider, media.FilePath)
+	}
+	for _, object := range report.Orphaned {
+		fmt.Printf("orphaned  %s:%s  (%d bytes)\n", object.Provider, object.Key, object.Size)
+	}
+	for _, message := range report.Errors {
+		fmt.Printf("error     %s\n", message)
+	}
+	fmt.Printf("Checked %d media: %d dangling (%d marked missing), %d orphaned objects, %d errors\n",
+		report.Checked, len(report.Dangling), report.Fixed, len(report.Orphaned), len(report.Errors))
+	if !report.Consistent() {
+		os.Exit(1)
+	}
+}
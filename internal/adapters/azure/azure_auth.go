@@ -0,0 +1,179 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+)
+
+// azureAuthMode is how the provider authenticates to the storage account.
+type azureAuthMode string
+
+const (
+	azureAuthSharedKey azureAuthMode = "shared_key" // Account key, directly or from a connection string
+	azureAuthSASToken  azureAuthMode = "sas_token"  // A fixed SAS token, directly or from a connection string
+	azureAuthIdentity  azureAuthMode = "identity"   // DefaultAzureCredential: managed identity, workload identity, Azure CLI, ...
+)
+
+const (
+	// azureDelegationKeyLifetime is how long a requested user delegation key stays valid,
+	// so one key signs many URLs.
+	azureDelegationKeyLifetime = 24 * time.Hour
+	// azureMaxDelegationKeyLifetime is the longest validity Azure grants a user delegation key.
+	azureMaxDelegationKeyLifetime = 7 * 24 * time.Hour
+)
+
+// errAzureCannotSign is returned for signed URLs when the provider only holds a SAS token,
+// which cannot be used to sign new ones.
+var errAzureCannotSign = errors.New("azure provider authenticated with a SAS token cannot sign URLs; configure an account key or an identity")
+
+// newAzureClients builds the blob and service clients from the first credential found in
+// cfg: a connection string, an account key, a SAS token, and otherwise DefaultAzureCredential.
+func newAzureClients(cfg *config.AzureConfig, serviceURL string) (*azblob.Client, *service.Client, azureAuthMode, error) {
+	switch {
+	case cfg.ConnectionString != "":
+		client, err := azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob client from connection string: %w", err)
+		}
+		serviceClient, err := service.NewClientFromConnectionString(cfg.ConnectionString, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob service client from connection string: %w", err)
+		}
+		if strings.Contains(strings.ToLower(cfg.ConnectionString), "accountkey=") {
+			return client, serviceClient, azureAuthSharedKey, nil
+		}
+		return client, serviceClient, azureAuthSASToken, nil
+
+	case cfg.AccountKey != "":
+		cred, err := azblob.NewSharedKeyCredential(cfg.AccountName, cfg.AccountKey)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure shared key credential: %w", err)
+		}
+		client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob client: %w", err)
+		}
+		// Service client for service-level operations such as listing containers
+		serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob service client: %w", err)
+		}
+		return client, serviceClient, azureAuthSharedKey, nil
+
+	case cfg.SASToken != "":
+		// Container and blob clients keep the query of the service URL, so every request carries the token
+		sasURL := strings.TrimSuffix(serviceURL, "?") + "?" + strings.TrimPrefix(cfg.SASToken, "?")
+		client, err := azblob.NewClientWithNoCredential(sasURL, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob client with SAS token: %w", err)
+		}
+		serviceClient, err := service.NewClientWithNoCredential(sasURL, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob service client with SAS token: %w", err)
+		}
+		return client, serviceClient, azureAuthSASToken, nil
+
+	default:
+		// Reads AZURE_CLIENT_ID and friends, so a user-assigned identity is chosen through the environment
+		cred, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure default credential: %w", err)
+		}
+		client, err := azblob.NewClient(serviceURL, cred, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob client: %w", err)
+		}
+		serviceClient, err := service.NewClient(serviceURL, cred, nil)
+		if err != nil {
+			return nil, nil, "", fmt.Errorf("failed to create Azure Blob service client: %w", err)
+		}
+		return client, serviceClient, azureAuthIdentity, nil
+	}
+}
+
+// blobURL returns the URL of key without a query, so a configured SAS token never leaks
+// into the URLs handed out to clients.
+func (p *azureProvider) blobURL(key string) string {
+	u := p.getBlobClient(key).URL()
+	if i := strings.IndexByte(u, '?'); i >= 0 {
+		u = u[:i]
+	}
+	return u
+}
+
+// signBlobURL returns the URL of key with a SAS granting permissions until expiry: an
+// account key SAS with shared key auth, and a user delegation SAS with an identity.
+func (p *azureProvider) signBlobURL(ctx context.Context, key string, permissions sas.BlobPermissions, expiry time.Time) (string, error) {
+	startTime := time.Now().Add(-10 * time.Minute) // SAS start time, slightly in the past
+
+	switch p.authMode {
+	case azureAuthSharedKey:
+		return p.getBlobClient(key).GetSASURL(permissions, expiry, &blob.GetSASURLOptions{
+			StartTime: &startTime,
+		})
+	case azureAuthIdentity:
+		credential, err := p.userDelegationCredential(ctx, expiry)
+		if err != nil {
+			return "", err
+		}
+		values := sas.BlobSignatureValues{
+			StartTime:     startTime.UTC(),
+			ExpiryTime:    expiry.UTC(),
+			Permissions:   permissions.String(),
+			ContainerName: p.containerName,
+			BlobName:      key,
+		}
+		params, err := values.SignWithUserDelegation(credential)
+		if err != nil {
+			return "", fmt.Errorf("failed to sign user delegation SAS: %w", err)
+		}
+		return p.blobURL(key) + "?" + params.Encode(), nil
+	default:
+		return "", errAzureCannotSign
+	}
+}
+
+// userDelegationCredential returns a user delegation key valid at least until expiry. Keys
+// are requested for a day at a time and reused, so signing URLs rarely costs a round trip.
+func (p *azureProvider) userDelegationCredential(ctx context.Context, expiry time.Time) (*service.UserDelegationCredential, error) {
+	p.delegationMu.Lock()
+	defer p.delegationMu.Unlock()
+
+	if p.delegation != nil && !p.delegationExpiry.Before(expiry) {
+		return p.delegation, nil
+	}
+
+	now := time.Now().UTC()
+	if expiry.After(now.Add(azureMaxDelegationKeyLifetime)) {
+		return nil, fmt.Errorf("user delegation SAS cannot be valid for more than %s", azureMaxDelegationKeyLifetime)
+	}
+	keyExpiry := now.Add(azureDelegationKeyLifetime)
+	if expiry.After(keyExpiry) {
+		keyExpiry = expiry.UTC()
+	}
+	if maxExpiry := now.Add(azureMaxDelegationKeyLifetime); keyExpiry.After(maxExpiry) {
+		keyExpiry = maxExpiry
+	}
+
+	start := now.Add(-10 * time.Minute).Format(sas.TimeFormat)
+	end := keyExpiry.Format(sas.TimeFormat)
+	credential, err := p.serviceClient.GetUserDelegationCredential(ctx, service.KeyInfo{Start: &start, Expiry: &end}, nil)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to get Azure user delegation key", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to get Azure user delegation key: %w", err)
+	}
+	p.delegation = credential
+	p.delegationExpiry = keyExpiry
+	return credential, nil
+}
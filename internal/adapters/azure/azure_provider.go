@@ -7,6 +7,9 @@ import (
 	"io"
 	"mime"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
@@ -22,25 +25,36 @@ import (
 	"github.com/lugondev/m3-storage/internal/modules/storage/port"
 )
 
+const (
+	// azureCopySASExpiry bounds how long the source SAS used by Copy stays valid.
+	azureCopySASExpiry = time.Hour
+	// azureCopyPollInterval is how often Copy checks the status of a pending copy.
+	azureCopyPollInterval = 500 * time.Millisecond
+)
+
 // azureProvider implements the port.StorageProvider interface for Azure Blob Storage.
 type azureProvider struct {
 	client        *azblob.Client  // Client for service, container, and blob operations
 	serviceClient *service.Client // More specific client for service-level operations
 	containerName string
 	accountName   string
+	authMode      azureAuthMode
 	logger        logger.Logger
+
+	delegationMu     sync.Mutex                        // Guards the cached user delegation key
+	delegation       *service.UserDelegationCredential // Signs SAS URLs in identity mode
+	delegationExpiry time.Time
 }
 
-// NewAzureProvider creates a new instance of azureProvider.
+// NewAzureProvider creates a new instance of azureProvider. The credential is picked from
+// the config: a connection string, an account key, a SAS token, or else the
+// DefaultAzureCredential chain (managed identity, workload identity, environment).
 func NewAzureProvider(config *config.AzureConfig, logger logger.Logger) (port.StorageProvider, error) {
 	log := logger.WithFields(map[string]any{"component": "AzureProvider"})
 
-	if config.AccountName == "" {
+	if config.AccountName == "" && config.ConnectionString == "" {
 		return nil, fmt.Errorf("account_name is required for AzureProvider")
 	}
-	if config.AccountKey == "" {
-		return nil, fmt.Errorf("account_key is required for AzureProvider")
-	}
 	if config.ContainerName == "" {
 		return nil, fmt.Errorf("container_name is required for AzureProvider")
 	}
@@ -50,32 +64,19 @@ func NewAzureProvider(config *config.AzureConfig, logger logger.Logger) (port.St
 		serviceURL = fmt.Sprintf("https://%s.blob.core.windows.net/", config.AccountName)
 	}
 
-	cred, err := azblob.NewSharedKeyCredential(config.AccountName, config.AccountKey)
-	if err != nil {
-		log.Errorf(context.Background(), "Failed to create Azure shared key credential", map[string]any{"error": err})
-		return nil, fmt.Errorf("failed to create Azure shared key credential: %w", err)
-	}
-
-	// Client for all operations
-	client, err := azblob.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
-	if err != nil {
-		log.Errorf(context.Background(), "Failed to create Azure Blob client", map[string]any{"error": err})
-		return nil, fmt.Errorf("failed to create Azure Blob client: %w", err)
-	}
-
-	// Service client specifically for generating user delegation keys for SAS if needed, or service level properties
-	serviceClient, err := service.NewClientWithSharedKeyCredential(serviceURL, cred, nil)
+	client, serviceClient, authMode, err := newAzureClients(config, serviceURL)
 	if err != nil {
-		log.Errorf(context.Background(), "Failed to create Azure Blob service client", map[string]any{"error": err})
-		return nil, fmt.Errorf("failed to create Azure Blob service client: %w", err)
+		log.Errorf(context.Background(), "Failed to create Azure Blob clients", map[string]any{"error": err})
+		return nil, err
 	}
 
-	log.Infof(context.Background(), "AzureProvider initialized", map[string]any{"account": config.AccountName, "container": config.ContainerName})
+	log.Infof(context.Background(), "AzureProvider initialized", map[string]any{"account": config.AccountName, "container": config.ContainerName, "auth": string(authMode)})
 	return &azureProvider{
 		client:        client,
 		serviceClient: serviceClient,
 		containerName: config.ContainerName,
 		accountName:   config.AccountName,
+		authMode:      authMode,
 		logger:        log,
 	}, nil
 }
@@ -95,6 +96,10 @@ func (p *azureProvider) Upload(ctx context.Context, key string, reader io.Reader
 	if key == "" {
 		return nil, fmt.Errorf("upload key cannot be empty")
 	}
+	if opts.RequestsEncryption() {
+		// Azure always encrypts at rest with the account's key; per-upload settings do not apply.
+		p.logger.Warnf(ctx, "Ignoring server-side encryption options for Azure upload", map[string]any{"key": key})
+	}
 
 	blobClient := p.getBlobClient(key)
 
@@ -130,7 +135,7 @@ func (p *azureProvider) Upload(ctx context.Context, key string, reader io.Reader
 	p.logger.Infof(ctx, "Attempting to upload file to Azure Blob Storage", map[string]any{"key": key, "container": p.containerName, "contentType": contentType})
 
 	blockBlobClient := p.getContainerClient().NewBlockBlobClient(key)
-	_, err := blockBlobClient.UploadStream(ctx, reader, uploadOpts)
+	_, err := blockBlobClient.UploadStream(ctx, opts.TrackProgress(reader, size), uploadOpts)
 
 	if err != nil {
 		p.logger.Errorf(ctx, "Failed to upload file to Azure Blob Storage", map[string]any{"key": key, "error": err})
@@ -146,7 +151,7 @@ func (p *azureProvider) Upload(ctx context.Context, key string, reader io.Reader
 	p.logger.Infof(ctx, "File uploaded successfully to Azure Blob Storage", map[string]any{"key": key})
 	return &port.FileObject{
 		Key:          key,
-		URL:          blobClient.URL(), // This is the direct blob URL
+		URL:          p.blobURL(key), // This is the direct blob URL
 		Size:         *properties.ContentLength,
 		ContentType:  *properties.ContentType,
 		LastModified: *properties.LastModified,
@@ -158,37 +163,34 @@ func (p *azureProvider) Upload(ctx context.Context, key string, reader io.Reader
 // GetURL returns a publicly accessible URL for the given key.
 // This URL is accessible if the container/blob has public access enabled.
 func (p *azureProvider) GetURL(ctx context.Context, key string) (string, error) {
-	blobClient := p.getBlobClient(key)
-	// Check if blob exists
-	_, err := blobClient.GetProperties(ctx, nil)
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "Azure blob not found, cannot get URL", map[string]any{"key": key})
+		return "", fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
+	}
+	return p.blobURL(key), nil
+}
+
+// Exists checks for a blob with GetProperties.
+func (p *azureProvider) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := p.getBlobClient(key).GetProperties(ctx, nil)
 	if err != nil {
 		if bloberror.HasCode(err, bloberror.BlobNotFound) {
-			p.logger.Warnf(ctx, "Azure blob not found, cannot get URL", map[string]any{"key": key})
-			return "", fmt.Errorf("azure blob %s not found: %w", key, err)
+			return false, nil
 		}
-		p.logger.Errorf(ctx, "Failed to get Azure blob properties for URL", map[string]any{"key": key, "error": err})
-		return "", fmt.Errorf("failed to check Azure blob %s: %w", key, err)
+		p.logger.Errorf(ctx, "Failed to get Azure blob properties", map[string]any{"key": key, "error": err})
+		return false, fmt.Errorf("failed to check Azure blob %s: %w", key, err)
 	}
-	return blobClient.URL(), nil
+	return true, nil
 }
 
-// GetSignedURL generates a time-limited SAS URL for accessing a private blob.
+// GetSignedURL generates a time-limited SAS URL for accessing a private blob. With an
+// identity the URL carries a user delegation SAS, so no account key is needed.
 func (p *azureProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
-	blobClient := p.getBlobClient(key)
-
-	// User delegation SAS is more secure if Azure AD is set up.
-	// For simplicity, using account key SAS here.
-	// To use User Delegation SAS, you'd get a user delegation key from serviceClient.GetUserDelegationKey()
-	// and then use blob.GetSASURL (with UserDelegationCredential).
-
-	permissions := sas.BlobPermissions{Read: true}
-	startTime := time.Now().Add(-10 * time.Minute) // SAS start time, slightly in the past
-	expiryTime := time.Now().Add(duration)
-
-	sasOptions := &blob.GetSASURLOptions{
-		StartTime: &startTime,
-	}
-	sasURL, err := blobClient.GetSASURL(permissions, expiryTime, sasOptions)
+	sasURL, err := p.signBlobURL(ctx, key, sas.BlobPermissions{Read: true}, time.Now().Add(duration))
 	if err != nil {
 		p.logger.Errorf(ctx, "Failed to generate Azure Blob SAS URL", map[string]any{"key": key, "error": err})
 		return "", fmt.Errorf("failed to generate Azure SAS URL for key %s: %w", key, err)
@@ -198,6 +200,20 @@ func (p *azureProvider) GetSignedURL(ctx context.Context, key string, duration t
 	return sasURL, nil
 }
 
+var _ port.PresignedUploadProvider = (*azureProvider)(nil)
+
+// GetSignedUploadURL generates a SAS URL with write and create permissions for the blob.
+// Clients must send the x-ms-blob-type: BlockBlob header with their PUT request.
+func (p *azureProvider) GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *port.UploadOptions) (string, error) {
+	sasURL, err := p.signBlobURL(ctx, key, sas.BlobPermissions{Write: true, Create: true}, time.Now().Add(duration))
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate Azure Blob upload SAS URL", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to generate Azure upload SAS URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated Azure Blob upload SAS URL", map[string]any{"key": key, "duration": duration})
+	return sasURL, nil
+}
+
 // Delete removes a file from Azure Blob Storage.
 func (p *azureProvider) Delete(ctx context.Context, key string) error {
 	blobClient := p.getBlobClient(key)
@@ -221,7 +237,7 @@ func (p *azureProvider) GetObject(ctx context.Context, key string) (*port.FileOb
 	if err != nil {
 		if bloberror.HasCode(err, bloberror.BlobNotFound) {
 			p.logger.Warnf(ctx, "Azure blob not found for GetObject", map[string]any{"key": key})
-			return nil, fmt.Errorf("azure blob %s not found: %w", key, err)
+			return nil, fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to get Azure blob properties", map[string]any{"key": key, "error": err})
 		return nil, fmt.Errorf("failed to get Azure blob properties for %s: %w", key, err)
@@ -229,7 +245,7 @@ func (p *azureProvider) GetObject(ctx context.Context, key string) (*port.FileOb
 
 	return &port.FileObject{
 		Key:          key,
-		URL:          blobClient.URL(),
+		URL:          p.blobURL(key),
 		Size:         *properties.ContentLength,
 		ContentType:  *properties.ContentType,
 		LastModified: *properties.LastModified,
@@ -245,51 +261,202 @@ func (p *azureProvider) Download(ctx context.Context, key string) (io.ReadCloser
 	if err != nil {
 		if bloberror.HasCode(err, bloberror.BlobNotFound) {
 			p.logger.Warnf(ctx, "Azure blob not found for Download", map[string]any{"key": key})
-			return nil, nil, fmt.Errorf("azure blob %s not found for download: %w", key, err)
+			return nil, nil, fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to download Azure blob stream", map[string]any{"key": key, "error": err})
 		return nil, nil, fmt.Errorf("failed to download Azure blob %s: %w", key, err)
 	}
 
-	// Get properties to populate FileObject
-	properties, propErr := blobClient.GetProperties(ctx, nil)
-	if propErr != nil {
-		// Log error but proceed with download if stream was obtained
-		p.logger.Errorf(ctx, "Failed to get properties during Azure download, but stream obtained", map[string]any{"key": key, "error": propErr})
-		// Try to close the body if properties failed, to avoid resource leak from DownloadStream
-		if downloadResponse.Body != nil {
-			downloadResponse.Body.Close()
+	// The download response carries the blob's properties; no GetProperties round trip is needed
+	fileObject := p.downloadFileObject(key, p.blobURL(key), downloadResponse.DownloadResponse)
+	p.logger.Infof(ctx, "Prepared Azure blob for download", map[string]any{"key": key})
+	return downloadResponse.Body, fileObject, nil
+}
+
+// DownloadRange downloads a byte range of a blob; a zero Count asks Azure for the rest of the blob.
+func (p *azureProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	if _, err := port.HTTPRange(start, end); err != nil {
+		return nil, nil, err
+	}
+	httpRange := blob.HTTPRange{Offset: start}
+	if end >= 0 {
+		httpRange.Count = end - start + 1
+	}
+	blobClient := p.getBlobClient(key)
+	downloadResponse, err := blobClient.DownloadStream(ctx, &blob.DownloadStreamOptions{Range: httpRange})
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			p.logger.Warnf(ctx, "Azure blob not found for DownloadRange", map[string]any{"key": key})
+			return nil, nil, fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
 		}
-		return nil, nil, fmt.Errorf("failed to get properties for Azure blob %s during download: %w", key, propErr)
+		p.logger.Errorf(ctx, "Failed to download Azure blob range", map[string]any{"key": key, "start": start, "end": end, "error": err})
+		return nil, nil, fmt.Errorf("failed to download Azure blob %s range %d-%d: %w", key, start, end, err)
 	}
+	return downloadResponse.Body, p.downloadFileObject(key, p.blobURL(key), downloadResponse.DownloadResponse), nil
+}
 
+// downloadFileObject describes the whole blob from the headers of a download response.
+// For ranged downloads Content-Length covers only the range, so the size is taken from
+// the total in Content-Range.
+func (p *azureProvider) downloadFileObject(key, url string, resp blob.DownloadResponse) *port.FileObject {
 	fileObject := &port.FileObject{
-		Key:          key,
-		URL:          blobClient.URL(),
-		Size:         *properties.ContentLength,
-		ContentType:  *properties.ContentType,
-		LastModified: *properties.LastModified,
-		ETag:         string(*properties.ETag),
-		Provider:     p.ProviderType(),
+		Key:      key,
+		URL:      url,
+		Provider: p.ProviderType(),
 	}
-	p.logger.Infof(ctx, "Prepared Azure blob for download", map[string]any{"key": key})
-	return downloadResponse.Body, fileObject, nil
+	if resp.ContentLength != nil {
+		fileObject.Size = *resp.ContentLength
+	}
+	if resp.ContentRange != nil {
+		if _, total, ok := strings.Cut(*resp.ContentRange, "/"); ok {
+			if size, err := strconv.ParseInt(total, 10, 64); err == nil {
+				fileObject.Size = size
+			}
+		}
+	}
+	if resp.ContentType != nil {
+		fileObject.ContentType = *resp.ContentType
+	}
+	if resp.LastModified != nil {
+		fileObject.LastModified = *resp.LastModified
+	}
+	if resp.ETag != nil {
+		fileObject.ETag = string(*resp.ETag)
+	}
+	return fileObject
 }
 
-// CheckHealth checks if the storage provider is healthy and accessible.
-func (p *azureProvider) CheckHealth(ctx context.Context) error {
+// Copy duplicates a blob with a server-side copy and waits for it to finish.
+// The source is read through a short-lived SAS so the copy works regardless of container access level.
+func (p *azureProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	var srcURL string
 	var err error
+	if p.authMode == azureAuthSASToken {
+		// The configured token already grants read access to the source
+		srcURL = p.getBlobClient(srcKey).URL()
+	} else {
+		srcURL, err = p.signBlobURL(ctx, srcKey, sas.BlobPermissions{Read: true}, time.Now().Add(azureCopySASExpiry))
+	}
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to sign Azure copy source", map[string]any{"srcKey": srcKey, "error": err})
+		return nil, fmt.Errorf("failed to sign Azure copy source %s: %w", srcKey, err)
+	}
+
+	dstClient := p.getContainerClient().NewBlockBlobClient(dstKey)
+	resp, err := dstClient.StartCopyFromURL(ctx, srcURL, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.CannotVerifyCopySource, bloberror.BlobNotFound) {
+			p.logger.Warnf(ctx, "Azure copy source not found", map[string]any{"srcKey": srcKey})
+			return nil, fmt.Errorf("azure blob %s %w", srcKey, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to start Azure blob copy", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, fmt.Errorf("failed to copy Azure blob %s to %s: %w", srcKey, dstKey, err)
+	}
+
+	status := blob.CopyStatusTypePending
+	if resp.CopyStatus != nil {
+		status = *resp.CopyStatus
+	}
+	ticker := time.NewTicker(azureCopyPollInterval)
+	defer ticker.Stop()
+	for status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("copying Azure blob %s to %s: %w", srcKey, dstKey, ctx.Err())
+		case <-ticker.C:
+		}
+		props, err := dstClient.GetProperties(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check Azure copy status for %s: %w", dstKey, err)
+		}
+		if props.CopyStatus != nil {
+			status = *props.CopyStatus
+		}
+	}
+	if status != blob.CopyStatusTypeSuccess {
+		p.logger.Errorf(ctx, "Azure blob copy did not succeed", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "status": string(status)})
+		return nil, fmt.Errorf("azure copy of %s to %s ended with status %s", srcKey, dstKey, status)
+	}
+
+	p.logger.Infof(ctx, "Azure blob copied successfully", map[string]any{"srcKey": srcKey, "dstKey": dstKey})
+	return p.GetObject(ctx, dstKey)
+}
+
+// Move copies the blob server-side and then deletes the source.
+func (p *azureProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists one page of blobs under prefix using a flat listing.
+func (p *azureProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	maxResults := int32(opts.MaxKeysOrDefault())
+	listOpts := &container.ListBlobsFlatOptions{
+		Prefix:     &prefix,
+		MaxResults: &maxResults,
+	}
+	if token := opts.Token(); token != "" {
+		listOpts.Marker = &token
+	}
+
+	pager := p.getContainerClient().NewListBlobsFlatPager(listOpts)
+	page, err := pager.NextPage(ctx)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to list Azure blobs", map[string]any{"prefix": prefix, "error": err})
+		return nil, "", fmt.Errorf("failed to list Azure blobs with prefix %s: %w", prefix, err)
+	}
+
+	var objects []*port.FileObject
+	if page.Segment != nil {
+		objects = make([]*port.FileObject, 0, len(page.Segment.BlobItems))
+		for _, item := range page.Segment.BlobItems {
+			if item.Name == nil {
+				continue
+			}
+			obj := &port.FileObject{
+				Key:      *item.Name,
+				URL:      p.blobURL(*item.Name),
+				Provider: p.ProviderType(),
+			}
+			if props := item.Properties; props != nil {
+				if props.ContentLength != nil {
+					obj.Size = *props.ContentLength
+				}
+				if props.ContentType != nil {
+					obj.ContentType = *props.ContentType
+				}
+				if props.LastModified != nil {
+					obj.LastModified = *props.LastModified
+				}
+				if props.ETag != nil {
+					obj.ETag = string(*props.ETag)
+				}
+			}
+			objects = append(objects, obj)
+		}
+	}
 
-	// First verify service-level access by listing containers
-	pager := p.serviceClient.NewListContainersPager(nil)
-	if _, err = pager.NextPage(ctx); err != nil {
-		p.logger.Errorf(ctx, "Azure health check failed: service access error", map[string]any{"error": err})
-		return fmt.Errorf("azure health check failed: service access error: %w", err)
+	nextToken := ""
+	if page.NextMarker != nil {
+		nextToken = *page.NextMarker
+	}
+	return objects, nextToken, nil
+}
+
+// CheckHealth checks if the storage provider is healthy and accessible.
+func (p *azureProvider) CheckHealth(ctx context.Context) error {
+	// First verify service-level access by listing containers. SAS tokens and identities are
+	// often scoped to the container, so only the account key is expected to allow this
+	if p.authMode == azureAuthSharedKey {
+		pager := p.serviceClient.NewListContainersPager(nil)
+		if _, err := pager.NextPage(ctx); err != nil {
+			p.logger.Errorf(ctx, "Azure health check failed: service access error", map[string]any{"error": err})
+			return fmt.Errorf("azure health check failed: service access error: %w", err)
+		}
 	}
 
 	// Then verify container access by getting its properties
 	containerClient := p.getContainerClient()
-	_, err = containerClient.GetProperties(ctx, nil)
+	_, err := containerClient.GetProperties(ctx, nil)
 	if err != nil {
 		p.logger.Errorf(ctx, "Azure health check failed: container access error", map[string]any{"error": err})
 		return fmt.Errorf("azure health check failed: container access error: %w", err)
@@ -0,0 +1,124 @@
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+
+	logger "github.com/lugondev/go-log"
+)
+
+// fakeBlobLatency stands in for the round trip to Azure, which dominates small downloads
+const fakeBlobLatency = time.Millisecond
+
+// fakeBlobService serves GET and HEAD of every blob in a container with the same small
+// content, after fakeBlobLatency, and counts the requests it gets.
+type fakeBlobService struct {
+	content  []byte
+	modified time.Time
+	requests atomic.Int64
+}
+
+func (f *fakeBlobService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.requests.Add(1)
+	time.Sleep(fakeBlobLatency)
+
+	header := w.Header()
+	header.Set("Content-Length", fmt.Sprint(len(f.content)))
+	header.Set("Content-Type", "text/plain")
+	header.Set("Last-Modified", f.modified.Format(http.TimeFormat))
+	header.Set("ETag", `"0x8DC0FFEE"`)
+	header.Set("x-ms-blob-type", "BlockBlob")
+	header.Set("x-ms-version", "2023-11-03")
+	switch r.Method {
+	case http.MethodGet:
+		w.Write(f.content)
+	case http.MethodHead:
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newBenchProvider returns a provider with an account key whose service URL points at fake
+func newBenchProvider(b *testing.B, fake *fakeBlobService) *azureProvider {
+	b.Helper()
+	server := httptest.NewServer(fake)
+	b.Cleanup(server.Close)
+
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		b.Fatalf("NewLogger: %v", err)
+	}
+	provider, err := NewAzureProvider(&config.AzureConfig{
+		AccountName:   "devstoreaccount1",
+		AccountKey:    base64.StdEncoding.EncodeToString([]byte("bench-account-key")),
+		ContainerName: "media",
+		ServiceURL:    server.URL + "/devstoreaccount1/",
+	}, log)
+	if err != nil {
+		b.Fatalf("NewAzureProvider: %v", err)
+	}
+	return provider.(*azureProvider)
+}
+
+// downloadWithProperties is Download as it was before the file object was built from
+// the download response: a GetProperties call follows the stream
+func (p *azureProvider) downloadWithProperties(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
+	body, _, err := p.Download(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	return body, fileObject, nil
+}
+
+// BenchmarkDownload reads a 1 KiB blob with Download, which takes the blob's properties
+// from the download response, and with the former extra GetProperties round trip.
+func BenchmarkDownload(b *testing.B) {
+	downloads := []struct {
+		name     string
+		download func(p *azureProvider, ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error)
+	}{
+		{"response headers", (*azureProvider).Download},
+		{"with GetProperties", (*azureProvider).downloadWithProperties},
+	}
+
+	content := []byte(strings.Repeat("m3", 512))
+	for _, d := range downloads {
+		b.Run(d.name, func(b *testing.B) {
+			fake := &fakeBlobService{content: content, modified: time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)}
+			provider := newBenchProvider(b, fake)
+			ctx := context.Background()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				body, fileObject, err := d.download(provider, ctx, "user/image/20260302/small.txt")
+				if err != nil {
+					b.Fatalf("download: %v", err)
+				}
+				n, err := io.Copy(io.Discard, body)
+				body.Close()
+				if err != nil || n != int64(len(content)) || fileObject.Size != n {
+					b.Fatalf("read %d bytes (file object size %d, err %v), want %d", n, fileObject.Size, err, len(content))
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(fake.requests.Load())/float64(b.N), "requests/op")
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.TaggableProvider = (*azureProvider)(nil)
+
+// SetTags replaces the blob index tags of the blob, which Azure can also query
+// across a container with FindBlobsByTags.
+func (p *azureProvider) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	if _, err := p.getBlobClient(key).SetTags(ctx, tags, nil); err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to set Azure blob tags", map[string]any{"key": key, "error": err})
+		return fmt.Errorf("failed to set tags of Azure blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetTags returns the blob index tags of the blob.
+func (p *azureProvider) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	resp, err := p.getBlobClient(key).GetTags(ctx, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, fmt.Errorf("azure blob %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get Azure blob tags", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to get tags of Azure blob %s: %w", key, err)
+	}
+
+	tags := make(map[string]string, len(resp.BlobTagSet))
+	for _, tag := range resp.BlobTagSet {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,275 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	// defaultDiscordChunkSize keeps each part under the smallest attachment limit Discord applies.
+	defaultDiscordChunkSize = 8 << 20
+	// discordMaxParts bounds the part count accepted from message content.
+	discordMaxParts = 1000
+	// discordLookupPages bounds how many pages of messages are scanned to find a file.
+	discordLookupPages = 10
+)
+
+// discordPartPattern matches the key suffix of a message holding one part of a file.
+var discordPartPattern = regexp.MustCompile(`^(.+) part (\d+)/(\d+)$`)
+
+// discordTag is the parsed content of a message storing a file, "File: <key>", or one
+// part of a file split across messages, "File: <key> part N/M".
+type discordTag struct {
+	key   string
+	part  int // 1-based; 0 for a file stored in a single message
+	total int
+}
+
+// parseDiscordTag parses message content written by Upload.
+func parseDiscordTag(content string) (discordTag, bool) {
+	rest, ok := strings.CutPrefix(content, discordKeyPrefix)
+	if !ok || rest == "" {
+		return discordTag{}, false
+	}
+	if m := discordPartPattern.FindStringSubmatch(rest); m != nil {
+		part, _ := strconv.Atoi(m[2])
+		total, _ := strconv.Atoi(m[3])
+		if part >= 1 && part <= total && total <= discordMaxParts {
+			return discordTag{key: m[1], part: part, total: total}, true
+		}
+	}
+	return discordTag{key: rest}, true
+}
+
+// content formats the tag as message content.
+func (t discordTag) content() string {
+	if t.part == 0 {
+		return discordKeyPrefix + t.key
+	}
+	return fmt.Sprintf("%s%s part %d/%d", discordKeyPrefix, t.key, t.part, t.total)
+}
+
+// discordFile is a stored file: one message, or the messages of its parts in order.
+type discordFile struct {
+	key   string
+	parts []*discordMessage
+}
+
+// chunked reports whether the file is split across several messages.
+func (f *discordFile) chunked() bool {
+	return len(f.parts) > 1
+}
+
+// discordPartSet collects the part messages of one file while scanning the channel.
+// Messages are scanned newest first, so the newest copy of each part is kept.
+type discordPartSet struct {
+	parts []*discordMessage
+	found int
+}
+
+func newDiscordPartSet(total int) *discordPartSet {
+	return &discordPartSet{parts: make([]*discordMessage, total)}
+}
+
+// add records message as the given part and reports whether every part is now known.
+func (s *discordPartSet) add(tag discordTag, message *discordMessage) bool {
+	if tag.total == len(s.parts) && s.parts[tag.part-1] == nil {
+		s.parts[tag.part-1] = message
+		s.found++
+	}
+	return s.found == len(s.parts)
+}
+
+// findFile looks for the newest upload of key in the most recent messages of the channel.
+func (p *discordProvider) findFile(ctx context.Context, key string) (*discordFile, error) {
+	var set *discordPartSet
+	before := ""
+	for page := 0; page < discordLookupPages; page++ {
+		messages, err := p.getMessages(ctx, discordMessagePageSize, before)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range messages {
+			message := &messages[i]
+			before = message.ID
+
+			tag, ok := parseDiscordTag(message.Content)
+			if !ok || tag.key != key || len(message.Attachments) == 0 {
+				continue
+			}
+			if tag.part == 0 {
+				return &discordFile{key: key, parts: []*discordMessage{message}}, nil
+			}
+			if set == nil {
+				set = newDiscordPartSet(tag.total)
+			}
+			if set.add(tag, message) {
+				return &discordFile{key: key, parts: set.parts}, nil
+			}
+		}
+
+		if len(messages) < discordMessagePageSize {
+			break
+		}
+	}
+	return nil, errFileNotFound
+}
+
+// toFileObject describes file, summing the sizes of its parts.
+func (p *discordProvider) toFileObject(file *discordFile) *port.FileObject {
+	var size int64
+	for _, message := range file.parts {
+		size += int64(message.Attachments[0].Size)
+	}
+	newest := file.parts[len(file.parts)-1]
+
+	// Parse the timestamp
+	lastModified := time.Now()
+	if newest.Timestamp != "" {
+		if t, err := time.Parse(time.RFC3339, newest.Timestamp); err == nil {
+			lastModified = t
+		}
+	}
+
+	object := &port.FileObject{
+		Key:          file.key,
+		Size:         size,
+		LastModified: lastModified,
+		Provider:     p.ProviderType(),
+	}
+	if file.chunked() {
+		// Parts are uploaded as opaque chunks, so only the key tells what the file is
+		object.ContentType = mime.TypeByExtension(filepath.Ext(file.key))
+	} else {
+		object.URL = file.parts[0].Attachments[0].URL
+		object.ContentType = file.parts[0].Attachments[0].ContentType
+	}
+	return object
+}
+
+// openAttachment starts downloading an attachment.
+func (p *discordProvider) openAttachment(ctx context.Context, url string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("discord provider: failed to create download request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("discord provider: failed to download file: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("discord provider: failed to download file, status: %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// discordPartReader downloads one part when it is first read, so only one part is open at a time.
+type discordPartReader struct {
+	provider *discordProvider
+	ctx      context.Context
+	url      string
+	body     io.ReadCloser
+}
+
+func (r *discordPartReader) open() error {
+	if r.body != nil {
+		return nil
+	}
+	body, err := r.provider.openAttachment(r.ctx, r.url)
+	if err != nil {
+		return err
+	}
+	r.body = body
+	return nil
+}
+
+func (r *discordPartReader) Read(b []byte) (int, error) {
+	if err := r.open(); err != nil {
+		return 0, err
+	}
+	n, err := r.body.Read(b)
+	if err == io.EOF {
+		r.body.Close()
+	}
+	return n, err
+}
+
+// discordPartsReader reads the parts of a file one after another.
+type discordPartsReader struct {
+	io.Reader
+	parts []*discordPartReader
+}
+
+func (r *discordPartsReader) Close() error {
+	for _, part := range r.parts {
+		if part.body != nil {
+			part.body.Close()
+		}
+	}
+	return nil
+}
+
+// readParts returns a reader over the attachments of messages in order. The first part is
+// opened right away so a missing file is reported before any data is streamed.
+func (p *discordProvider) readParts(ctx context.Context, messages []*discordMessage) (io.ReadCloser, error) {
+	parts := make([]*discordPartReader, len(messages))
+	readers := make([]io.Reader, len(messages))
+	for i, message := range messages {
+		parts[i] = &discordPartReader{provider: p, ctx: ctx, url: message.Attachments[0].URL}
+		readers[i] = parts[i]
+	}
+	if err := parts[0].open(); err != nil {
+		return nil, err
+	}
+	return &discordPartsReader{Reader: io.MultiReader(readers...), parts: parts}, nil
+}
+
+// downloadChunkedRange reads the inclusive byte range of a file split across messages,
+// downloading only the parts the range overlaps.
+func (p *discordProvider) downloadChunkedRange(ctx context.Context, file *discordFile, start, end int64) (io.ReadCloser, error) {
+	object := p.toFileObject(file)
+	if start >= object.Size {
+		return nil, fmt.Errorf("%w: %d-%d of %d bytes", port.ErrInvalidRange, start, end, object.Size)
+	}
+
+	var selected []*discordMessage
+	var offset, firstOffset int64
+	for _, message := range file.parts {
+		partSize := int64(message.Attachments[0].Size)
+		if offset+partSize > start && (end < 0 || offset <= end) {
+			if selected == nil {
+				firstOffset = offset
+			}
+			selected = append(selected, message)
+		}
+		offset += partSize
+	}
+
+	reader, err := p.readParts(ctx, selected)
+	if err != nil {
+		return nil, err
+	}
+	relativeEnd := int64(-1)
+	if end >= 0 {
+		relativeEnd = end - firstOffset
+	}
+	sliced, err := port.SliceReader(reader, start-firstOffset, relativeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("discord provider: %w", err)
+	}
+	return sliced, nil
+}
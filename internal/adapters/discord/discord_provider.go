@@ -27,8 +27,15 @@ type discordProvider struct {
 // Discord API constants
 const (
 	discordAPIBaseURL = "https://discord.com/api/v10"
+	// discordMessagePageSize is the maximum number of messages Discord returns per request.
+	discordMessagePageSize = 100
+	// discordKeyPrefix marks the message content that records an uploaded file's key.
+	discordKeyPrefix = "File: "
 )
 
+// errFileNotFound is returned when no message in the channel carries the requested key.
+var errFileNotFound = fmt.Errorf("discord provider: file %w", port.ErrObjectNotFound)
+
 // Discord API response structures
 type discordMessage struct {
 	ID          string              `json:"id"`
@@ -46,52 +53,106 @@ type discordAttachment struct {
 	ContentType string `json:"content_type"`
 }
 
-// NewDiscordProvider creates a new Discord storage provider.
+// NewDiscordProvider creates a new Discord storage provider. With a bot token it uses the
+// bot API; with only a webhook URL it posts files through the webhook, which needs no bot
+// permissions but cannot look files up, list or delete them by key.
 func NewDiscordProvider(config config.DiscordConfig, logger logger.Logger) (port.StorageProvider, error) {
-	if config.BotToken == "" {
-		return nil, errors.New("discord provider: token is required")
+	if config.BotToken == "" && config.WebhookURL == "" {
+		return nil, errors.New("discord provider: token or webhook_url is required")
 	}
 
-	if config.ChannelID == "" {
+	if config.BotToken != "" && config.ChannelID == "" {
 		return nil, errors.New("discord provider: channel_id is required")
 	}
 
-	// Verify channel exists and is accessible
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/channels/%s", discordAPIBaseURL, config.ChannelID), nil)
-	if err != nil {
-		return nil, fmt.Errorf("discord provider: failed to create request: %w", err)
+	p := &discordProvider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger.WithFields(map[string]any{"component": "DiscordProvider"}),
 	}
-	req.Header.Set("Authorization", "Bot "+config.BotToken)
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("discord provider: failed to access channel: %w", err)
+	// Verify the channel or webhook exists and is accessible
+	if err := p.checkAccess(context.Background()); err != nil {
+		return nil, fmt.Errorf("discord provider: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("discord provider: failed to access channel, status: %d", resp.StatusCode)
+	if p.usesWebhook() {
+		p.logger.Info(context.Background(), "Discord provider posts files through a webhook; lookups, listing and deletes by key need a bot token", map[string]any{"mode": "webhook"})
 	}
 
-	return &discordProvider{
-		config: config,
-		client: client,
-	}, nil
+	return p, nil
 }
 
-// Upload uploads a file to Discord.
+// Upload uploads a file to Discord. Files larger than the chunk size are split into parts
+// posted as separate messages tagged "File: <key> part N/M"; if any part fails, the parts
+// already posted are deleted.
 func (p *discordProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
-	// Create a message with the file
-	filename := key
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Discord cannot encrypt uploads; ignoring server-side encryption options", map[string]any{"key": key})
+	}
 
-	// Create a buffer to read the file
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		return nil, fmt.Errorf("discord provider: failed to read file: %w", err)
+	if size <= 0 {
+		// The part count is part of every message, so the size must be known up front
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("discord provider: failed to read file: %w", err)
+		}
+		reader, size = bytes.NewReader(data), int64(len(data))
+	}
+	reader = opts.TrackProgress(reader, size)
+
+	chunkSize := p.chunkSize()
+	total := int((size + chunkSize - 1) / chunkSize)
+	if total <= 1 {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("discord provider: failed to read file: %w", err)
+		}
+		message, err := p.postFile(ctx, key, discordTag{key: key}.content(), data)
+		if err != nil {
+			return nil, err
+		}
+		return p.toFileObject(&discordFile{key: key, parts: []*discordMessage{message}}), nil
+	}
+	if total > discordMaxParts {
+		return nil, fmt.Errorf("discord provider: file of %d bytes needs more than %d parts", size, discordMaxParts)
 	}
 
+	file := &discordFile{key: key}
+	buf := make([]byte, chunkSize)
+	for part := 1; part <= total; part++ {
+		n, err := io.ReadFull(reader, buf)
+		if err != nil && !(part == total && errors.Is(err, io.ErrUnexpectedEOF)) {
+			p.deleteMessages(ctx, file.parts)
+			return nil, fmt.Errorf("discord provider: failed to read part %d/%d: %w", part, total, err)
+		}
+
+		tag := discordTag{key: key, part: part, total: total}
+		message, err := p.postFile(ctx, fmt.Sprintf("%s.part%d", key, part), tag.content(), buf[:n])
+		if err != nil {
+			p.deleteMessages(ctx, file.parts)
+			return nil, fmt.Errorf("discord provider: failed to upload part %d/%d: %w", part, total, err)
+		}
+		file.parts = append(file.parts, message)
+	}
+	if n, _ := reader.Read(buf[:1]); n > 0 {
+		p.deleteMessages(ctx, file.parts)
+		return nil, fmt.Errorf("discord provider: file is larger than the declared %d bytes", size)
+	}
+
+	p.logger.Info(ctx, "Uploaded file to Discord in parts", map[string]any{"key": key, "parts": total, "size": size})
+	return p.toFileObject(file), nil
+}
+
+// chunkSize returns the largest attachment posted in one message.
+func (p *discordProvider) chunkSize() int64 {
+	if p.config.ChunkSizeBytes > 0 {
+		return p.config.ChunkSizeBytes
+	}
+	return defaultDiscordChunkSize
+}
+
+// postFile posts data as the attachment of a new message with the given content.
+func (p *discordProvider) postFile(ctx context.Context, filename, content string, data []byte) (*discordMessage, error) {
 	// Create a multipart form for the file upload
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
@@ -106,7 +167,7 @@ func (p *discordProvider) Upload(ctx context.Context, key string, reader io.Read
 	}
 
 	// Add the message content
-	if err := writer.WriteField("content", fmt.Sprintf("File: %s", key)); err != nil {
+	if err := writer.WriteField("content", content); err != nil {
 		return nil, fmt.Errorf("discord provider: failed to write content field: %w", err)
 	}
 
@@ -114,15 +175,17 @@ func (p *discordProvider) Upload(ctx context.Context, key string, reader io.Read
 		return nil, fmt.Errorf("discord provider: failed to close multipart writer: %w", err)
 	}
 
-	// Create the HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST",
-		fmt.Sprintf("%s/channels/%s/messages", discordAPIBaseURL, p.config.ChannelID),
-		body)
+	// Create the HTTP request; a webhook answers with the same message object as the bot API
+	postURL, err := p.postURL()
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", postURL, body)
 	if err != nil {
 		return nil, fmt.Errorf("discord provider: failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bot "+p.config.BotToken)
+	p.authorize(req)
 	req.Header.Set("Content-Type", writer.FormDataContentType())
 
 	// Send the request
@@ -144,43 +207,30 @@ func (p *discordProvider) Upload(ctx context.Context, key string, reader io.Read
 		return nil, fmt.Errorf("discord provider: failed to parse response: %w", err)
 	}
 
-	// Get the URL of the uploaded file
 	if len(message.Attachments) == 0 {
 		return nil, errors.New("discord provider: no attachment URL found after upload")
 	}
+	return &message, nil
+}
 
-	fileURL := message.Attachments[0].URL
-	fileSize := int64(message.Attachments[0].Size)
-	fileContentType := message.Attachments[0].ContentType
-
-	// Parse the timestamp
-	lastModified := time.Now()
-	if message.Timestamp != "" {
-		if t, err := time.Parse(time.RFC3339, message.Timestamp); err == nil {
-			lastModified = t
-		}
+// getMessages retrieves messages from a Discord channel, newest first. It needs a bot token.
+// When before is set, only messages older than that message ID are returned.
+func (p *discordProvider) getMessages(ctx context.Context, limit int, before string) ([]discordMessage, error) {
+	if p.usesWebhook() {
+		return nil, errBotTokenRequired
 	}
 
-	return &port.FileObject{
-		Key:          key,
-		URL:          fileURL,
-		Size:         fileSize,
-		ContentType:  fileContentType,
-		LastModified: lastModified,
-		Provider:     p.ProviderType(),
-	}, nil
-}
-
-// getMessages retrieves messages from a Discord channel
-func (p *discordProvider) getMessages(ctx context.Context, limit int) ([]discordMessage, error) {
 	url := fmt.Sprintf("%s/channels/%s/messages?limit=%d", discordAPIBaseURL, p.config.ChannelID, limit)
+	if before != "" {
+		url += "&before=" + before
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("discord provider: failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bot "+p.config.BotToken)
+	p.authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.client.Do(req)
@@ -201,31 +251,28 @@ func (p *discordProvider) getMessages(ctx context.Context, limit int) ([]discord
 	return messages, nil
 }
 
-// findMessageWithFile finds a message containing a file with the given key
-func (p *discordProvider) findMessageWithFile(ctx context.Context, key string) (*discordMessage, error) {
-	messages, err := p.getMessages(ctx, 100)
+// GetURL returns the URL for a file. Files split across several messages have no single URL.
+func (p *discordProvider) GetURL(ctx context.Context, key string) (string, error) {
+	file, err := p.findFile(ctx, key)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-
-	filePrefix := fmt.Sprintf("File: %s", key)
-	for i := range messages {
-		if strings.Contains(messages[i].Content, filePrefix) && len(messages[i].Attachments) > 0 {
-			return &messages[i], nil
-		}
+	if file.chunked() {
+		return "", fmt.Errorf("discord provider: file %s is split across %d messages and has no single URL", key, len(file.parts))
 	}
 
-	return nil, errors.New("discord provider: file not found")
+	return file.parts[0].Attachments[0].URL, nil
 }
 
-// GetURL returns the URL for a file.
-func (p *discordProvider) GetURL(ctx context.Context, key string) (string, error) {
-	message, err := p.findMessageWithFile(ctx, key)
-	if err != nil {
-		return "", err
+// Exists reports whether a message carrying the file is in the channel.
+func (p *discordProvider) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := p.findFile(ctx, key); err != nil {
+		if errors.Is(err, errFileNotFound) {
+			return false, nil
+		}
+		return false, err
 	}
-
-	return message.Attachments[0].URL, nil
+	return true, nil
 }
 
 // GetSignedURL returns a signed URL for a file.
@@ -235,26 +282,47 @@ func (p *discordProvider) GetSignedURL(ctx context.Context, key string, duration
 	return p.GetURL(ctx, key)
 }
 
-// Delete removes a file from Discord.
+// Delete removes a file from Discord, with every message holding a part of it.
 func (p *discordProvider) Delete(ctx context.Context, key string) error {
-	message, err := p.findMessageWithFile(ctx, key)
+	file, err := p.findFile(ctx, key)
 	if err != nil {
 		// If the file is not found, consider it already deleted
-		if err.Error() == "discord provider: file not found" {
+		if errors.Is(err, errFileNotFound) {
 			return nil
 		}
 		return err
 	}
 
-	// Delete the message
-	url := fmt.Sprintf("%s/channels/%s/messages/%s", discordAPIBaseURL, p.config.ChannelID, message.ID)
+	for _, message := range file.parts {
+		if err := p.deleteMessage(ctx, message.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMessages removes the messages of a failed upload, logging any that remain.
+func (p *discordProvider) deleteMessages(ctx context.Context, messages []*discordMessage) {
+	for _, message := range messages {
+		if err := p.deleteMessage(ctx, message.ID); err != nil {
+			p.logger.Warn(ctx, "Failed to delete Discord message of incomplete upload", map[string]any{"messageID": message.ID, "error": err})
+		}
+	}
+}
+
+// deleteMessage removes one message from the channel. Through a webhook only messages it posted can be removed.
+func (p *discordProvider) deleteMessage(ctx context.Context, messageID string) error {
+	url, err := p.messageURL(messageID)
+	if err != nil {
+		return err
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
 	if err != nil {
 		return fmt.Errorf("discord provider: failed to create delete request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bot "+p.config.BotToken)
+	p.authorize(req)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
@@ -262,86 +330,173 @@ func (p *discordProvider) Delete(ctx context.Context, key string) error {
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusNoContent {
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotFound {
 		return fmt.Errorf("discord provider: failed to delete message, status: %d", resp.StatusCode)
 	}
 
 	return nil
 }
 
-// GetObject retrieves file information.
+// GetObject retrieves file information. The size of a file split across messages is the sum of its parts.
 func (p *discordProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
-	message, err := p.findMessageWithFile(ctx, key)
+	file, err := p.findFile(ctx, key)
 	if err != nil {
 		return nil, err
 	}
 
-	attachment := message.Attachments[0]
+	return p.toFileObject(file), nil
+}
+
+// Copy re-uploads srcKey under dstKey; Discord has no server-side copy.
+func (p *discordProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	return port.CopyViaDownload(ctx, p, srcKey, dstKey)
+}
 
-	// Parse the timestamp
-	lastModified := time.Now()
-	if message.Timestamp != "" {
-		if t, err := time.Parse(time.RFC3339, message.Timestamp); err == nil {
-			lastModified = t
+// Move re-uploads the file under dstKey and then deletes the original message.
+func (p *discordProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects scans channel messages, newest first, for uploads whose key starts with prefix.
+// A file split across messages is listed once all of its parts are scanned. The
+// continuation token is the ID of the last message scanned.
+func (p *discordProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	maxKeys := opts.MaxKeysOrDefault()
+	before := opts.Token()
+
+	var objects []*port.FileObject
+	pending := make(map[string]*discordPartSet)
+	for {
+		messages, err := p.getMessages(ctx, discordMessagePageSize, before)
+		if err != nil {
+			return nil, "", err
+		}
+		lastPage := len(messages) < discordMessagePageSize
+
+		for i := range messages {
+			message := &messages[i]
+			before = message.ID
+
+			tag, ok := parseDiscordTag(message.Content)
+			if !ok || len(message.Attachments) == 0 || !strings.HasPrefix(tag.key, prefix) {
+				continue
+			}
+			file := &discordFile{key: tag.key, parts: []*discordMessage{message}}
+			if tag.part > 0 {
+				set, ok := pending[tag.key]
+				if !ok {
+					set = newDiscordPartSet(tag.total)
+					pending[tag.key] = set
+				}
+				if !set.add(tag, message) {
+					continue
+				}
+				delete(pending, tag.key)
+				file.parts = set.parts
+			}
+			objects = append(objects, p.toFileObject(file))
+
+			if len(objects) == maxKeys {
+				if lastPage && i == len(messages)-1 {
+					return objects, "", nil
+				}
+				return objects, message.ID, nil
+			}
 		}
-	}
 
-	return &port.FileObject{
-		Key:          key,
-		URL:          attachment.URL,
-		Size:         int64(attachment.Size),
-		ContentType:  attachment.ContentType,
-		LastModified: lastModified,
-		Provider:     p.ProviderType(),
-	}, nil
+		if lastPage {
+			return objects, "", nil
+		}
+	}
 }
 
-// Download downloads a file from Discord.
+// Download downloads a file from Discord, reading the parts of a split file in order.
 func (p *discordProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
-	// Get the file object first
-	fileObj, err := p.GetObject(ctx, key)
+	file, err := p.findFile(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reader, err := p.readParts(ctx, file.parts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return reader, p.toFileObject(file), nil
+}
+
+// DownloadRange downloads a byte range of a file. The attachment CDN normally honors the
+// Range header; if it returns the whole file, the range is sliced out of the stream. For a
+// split file only the parts the range overlaps are downloaded.
+func (p *discordProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	byteRange, err := port.HTTPRange(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := p.findFile(ctx, key)
 	if err != nil {
 		return nil, nil, err
 	}
+	fileObj := p.toFileObject(file)
+	if file.chunked() {
+		reader, err := p.downloadChunkedRange(ctx, file, start, end)
+		if err != nil {
+			return nil, nil, err
+		}
+		return reader, fileObj, nil
+	}
 
-	// Download the file from the URL
 	req, err := http.NewRequestWithContext(ctx, "GET", fileObj.URL, nil)
 	if err != nil {
 		return nil, nil, fmt.Errorf("discord provider: failed to create download request: %w", err)
 	}
+	req.Header.Set("Range", byteRange)
 
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, nil, fmt.Errorf("discord provider: failed to download file: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, fileObj, nil
+	case http.StatusOK:
+		reader, err := port.SliceReader(resp.Body, start, end)
+		if err != nil {
+			return nil, nil, fmt.Errorf("discord provider: %w", err)
+		}
+		return reader, fileObj, nil
+	default:
 		resp.Body.Close()
-		return nil, nil, fmt.Errorf("discord provider: failed to download file, status: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("discord provider: failed to download file range, status: %d", resp.StatusCode)
 	}
-
-	return resp.Body, fileObj, nil
 }
 
 // CheckHealth checks if the storage provider is healthy and accessible.
 func (p *discordProvider) CheckHealth(ctx context.Context) error {
-	// Verify channel exists and is accessible by attempting to get channel info
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/channels/%s", discordAPIBaseURL, p.config.ChannelID), nil)
+	if err := p.checkAccess(ctx); err != nil {
+		return fmt.Errorf("discord health check failed: %w", err)
+	}
+	return nil
+}
+
+// checkAccess verifies the channel, or the webhook when there is no bot token, exists and is accessible.
+func (p *discordProvider) checkAccess(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.accessURL(), nil)
 	if err != nil {
-		return fmt.Errorf("discord health check failed: failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bot "+p.config.BotToken)
+	p.authorize(req)
 	req.Header.Set("Content-Type", "application/json")
 
 	resp, err := p.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("discord health check failed: failed to access channel: %w", err)
+		return fmt.Errorf("failed to access channel: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("discord health check failed: unexpected status code: %d", resp.StatusCode)
+		return fmt.Errorf("failed to access channel, status: %d", resp.StatusCode)
 	}
 
 	return nil
@@ -0,0 +1,229 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// fakeDiscord serves the channel message endpoints of the bot API and the attachment
+// CDN from memory. Attachments larger than maxAttachment are refused like Discord does.
+type fakeDiscord struct {
+	channelID     string
+	maxAttachment int64
+
+	mu       sync.Mutex
+	nextID   int
+	messages []discordMessage // Oldest first
+	files    map[string][]byte
+}
+
+func newFakeDiscord(channelID string, maxAttachment int64) *fakeDiscord {
+	return &fakeDiscord{channelID: channelID, maxAttachment: maxAttachment, nextID: 1000, files: map[string][]byte{}}
+}
+
+func (f *fakeDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	messagesPath := "/api/v10/channels/" + f.channelID + "/messages"
+
+	switch {
+	case r.URL.Host == "cdn.discordapp.com" && r.Method == http.MethodGet:
+		f.attachment(w, r)
+	case r.URL.Path == messagesPath && r.Method == http.MethodPost:
+		f.post(w, r)
+	case r.URL.Path == messagesPath && r.Method == http.MethodGet:
+		f.list(w, r)
+	case strings.HasPrefix(r.URL.Path, messagesPath+"/") && r.Method == http.MethodDelete:
+		f.delete(w, strings.TrimPrefix(r.URL.Path, messagesPath+"/"))
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusNotImplemented)
+	}
+	return w.Result(), nil
+}
+
+func (f *fakeDiscord) post(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(f.maxAttachment + 1<<20); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	if header.Size > f.maxAttachment {
+		http.Error(w, `{"message": "Request entity too large", "code": 40005}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	f.nextID++
+	id := strconv.Itoa(f.nextID)
+	url := fmt.Sprintf("https://cdn.discordapp.com/attachments/%s/%s/%s", f.channelID, id, header.Filename)
+	message := discordMessage{
+		ID:        id,
+		Content:   r.FormValue("content"),
+		Timestamp: "2026-03-02T15:00:00Z",
+		Attachments: []discordAttachment{{
+			ID:          id,
+			Filename:    header.Filename,
+			Size:        len(data),
+			URL:         url,
+			ContentType: "application/octet-stream",
+		}},
+	}
+	f.messages = append(f.messages, message)
+	f.files[url] = data
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(message)
+}
+
+// list returns messages newest first, older than the before query parameter if set
+func (f *fakeDiscord) list(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	before, _ := strconv.Atoi(r.URL.Query().Get("before"))
+
+	f.mu.Lock()
+	page := []discordMessage{}
+	for i := len(f.messages) - 1; i >= 0 && len(page) < limit; i-- {
+		if id, _ := strconv.Atoi(f.messages[i].ID); before == 0 || id < before {
+			page = append(page, f.messages[i])
+		}
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+func (f *fakeDiscord) delete(w http.ResponseWriter, id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := slices.IndexFunc(f.messages, func(m discordMessage) bool { return m.ID == id })
+	if i < 0 {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	delete(f.files, f.messages[i].Attachments[0].URL)
+	f.messages = slices.Delete(f.messages, i, i+1)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeDiscord) attachment(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	data, ok := f.files[r.URL.String()]
+	f.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write(data)
+}
+
+// newTestProvider returns a bot token provider whose requests are served by fake
+func newTestProvider(t *testing.T, fake *fakeDiscord, chunkSize int64) *discordProvider {
+	t.Helper()
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	return &discordProvider{
+		config: config.DiscordConfig{BotToken: "test-token", ChannelID: fake.channelID, ChunkSizeBytes: chunkSize},
+		client: &http.Client{Transport: fake},
+		logger: log,
+	}
+}
+
+func TestChunkedUploadRoundTrip(t *testing.T) {
+	const limit = 1024
+	ctx := context.Background()
+	fake := newFakeDiscord("42", limit)
+	provider := newTestProvider(t, fake, limit)
+
+	// Two and a half times the single message limit needs three parts
+	key := "user/video/20260302/clip.mp4"
+	content := make([]byte, limit*5/2)
+	rand.New(rand.NewSource(1)).Read(content)
+
+	uploaded, err := provider.Upload(ctx, key, bytes.NewReader(content), int64(len(content)), nil)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if uploaded.Size != int64(len(content)) {
+		t.Errorf("uploaded size = %d, want %d", uploaded.Size, len(content))
+	}
+	if len(fake.messages) != 3 {
+		t.Fatalf("posted %d messages, want 3", len(fake.messages))
+	}
+	for i, message := range fake.messages {
+		if want := fmt.Sprintf("File: %s part %d/3", key, i+1); message.Content != want {
+			t.Errorf("message %d content = %q, want %q", i+1, message.Content, want)
+		}
+	}
+
+	object, err := provider.GetObject(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if object.Size != int64(len(content)) || object.ContentType != "video/mp4" {
+		t.Errorf("GetObject = size %d type %q, want size %d type video/mp4", object.Size, object.ContentType, len(content))
+	}
+
+	reader, _, err := provider.Download(ctx, key)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	downloaded, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if !bytes.Equal(downloaded, content) {
+		t.Errorf("downloaded %d bytes that differ from the %d uploaded", len(downloaded), len(content))
+	}
+
+	// A range across the boundary of the first two parts
+	reader, _, err = provider.DownloadRange(ctx, key, limit-10, limit+9)
+	if err != nil {
+		t.Fatalf("DownloadRange: %v", err)
+	}
+	ranged, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil || !bytes.Equal(ranged, content[limit-10:limit+10]) {
+		t.Errorf("DownloadRange across parts = %d bytes (err %v), want the 20 bytes around the boundary", len(ranged), err)
+	}
+
+	if err := provider.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if len(fake.messages) != 0 {
+		t.Errorf("%d part messages left after Delete", len(fake.messages))
+	}
+	if _, _, err := provider.Download(ctx, key); !errors.Is(err, port.ErrObjectNotFound) {
+		t.Errorf("Download after Delete error = %v, want ErrObjectNotFound", err)
+	}
+}
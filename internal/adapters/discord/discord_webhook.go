@@ -0,0 +1,61 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// errBotTokenRequired is returned by operations that read the channel, which a webhook cannot do.
+var errBotTokenRequired = errors.New("discord provider: this operation needs a bot token; a webhook can only post and delete its own messages")
+
+// usesWebhook reports whether messages are posted through the webhook, which is the case
+// when no bot token is configured.
+func (p *discordProvider) usesWebhook() bool {
+	return p.config.BotToken == ""
+}
+
+// authorize sets the bot token on req. Webhook URLs carry their own token.
+func (p *discordProvider) authorize(req *http.Request) {
+	if !p.usesWebhook() {
+		req.Header.Set("Authorization", "Bot "+p.config.BotToken)
+	}
+}
+
+// accessURL returns the endpoint that proves Discord is reachable: the channel with a bot
+// token, or the webhook itself.
+func (p *discordProvider) accessURL() string {
+	if p.usesWebhook() {
+		return p.config.WebhookURL
+	}
+	return fmt.Sprintf("%s/channels/%s", discordAPIBaseURL, p.config.ChannelID)
+}
+
+// postURL returns the endpoint new messages are posted to.
+func (p *discordProvider) postURL() (string, error) {
+	if !p.usesWebhook() {
+		return fmt.Sprintf("%s/channels/%s/messages", discordAPIBaseURL, p.config.ChannelID), nil
+	}
+	u, err := url.Parse(p.config.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("discord provider: invalid webhook URL: %w", err)
+	}
+	// Without wait Discord answers 204 No Content and the attachment URL is lost
+	query := u.Query()
+	query.Set("wait", "true")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// messageURL returns the endpoint of a posted message. A webhook may delete the messages it posted.
+func (p *discordProvider) messageURL(messageID string) (string, error) {
+	if !p.usesWebhook() {
+		return fmt.Sprintf("%s/channels/%s/messages/%s", discordAPIBaseURL, p.config.ChannelID, messageID), nil
+	}
+	u, err := url.Parse(p.config.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("discord provider: invalid webhook URL: %w", err)
+	}
+	return u.JoinPath("messages", messageID).String(), nil
+}
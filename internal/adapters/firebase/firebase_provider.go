@@ -64,8 +64,9 @@ func (p *firebaseProvider) Upload(ctx context.Context, key string, reader io.Rea
 	if key == "" {
 		p.logger.Error(ctx, "Upload key cannot be empty", nil)
 		return nil, fmt.Errorf("upload key cannot be empty")
-	} else {
-		key = fmt.Sprintf("%s/%s", "m3-storage", key)
+	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Ignoring server-side encryption options for Firebase upload", map[string]any{"key": key})
 	}
 
 	// If key doesn't have an extension, try to infer or use a default.
@@ -84,13 +85,9 @@ func (p *firebaseProvider) Upload(ctx context.Context, key string, reader io.Rea
 		contentType = "application/octet-stream" // Default
 	}
 
-	// Generate a unique ID if key is meant to be a folder path or if we want to ensure uniqueness.
-	// For this provider, 'key' is treated as the final object path.
-	// If key needs to be dynamic:
-	// finalKey := filepath.Join(key, uuid.New().String()+filepath.Ext(originalFileNameFromOptsOrContext))
-	finalKey := key
-
-	obj := p.bucket.Object(finalKey)
+	// The key is used verbatim as the object name so that GetObject, Download and
+	// Delete address the same object the caller uploaded.
+	obj := p.bucket.Object(key)
 	wc := obj.NewWriter(ctx)
 	wc.ContentType = contentType
 	wc.Size = size // Set the size for resumable uploads or progress tracking
@@ -102,28 +99,28 @@ func (p *firebaseProvider) Upload(ctx context.Context, key string, reader io.Rea
 	// or predefined ACLs like "publicRead".
 	// wc.ACL = ... (if direct ACL setting is needed and supported by the writer)
 
-	p.logger.Infof(ctx, "Attempting to upload file", map[string]any{"key": finalKey, "contentType": contentType, "size": size})
+	p.logger.Infof(ctx, "Attempting to upload file", map[string]any{"key": key, "contentType": contentType, "size": size})
 
-	if _, err := io.Copy(wc, reader); err != nil {
-		p.logger.Errorf(ctx, "Failed to copy file to Firebase Storage", map[string]any{"key": finalKey, "error": err})
-		return nil, fmt.Errorf("failed to copy file to Firebase Storage for key %s: %w", finalKey, err)
+	if _, err := io.Copy(wc, opts.TrackProgress(reader, size)); err != nil {
+		p.logger.Errorf(ctx, "Failed to copy file to Firebase Storage", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to copy file to Firebase Storage for key %s: %w", key, err)
 	}
 	if err := wc.Close(); err != nil {
-		p.logger.Errorf(ctx, "Failed to close Firebase Storage writer", map[string]any{"key": finalKey, "error": err})
-		return nil, fmt.Errorf("failed to close Firebase Storage writer for key %s: %w", finalKey, err)
+		p.logger.Errorf(ctx, "Failed to close Firebase Storage writer", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to close Firebase Storage writer for key %s: %w", key, err)
 	}
 
 	attrs, err := obj.Attrs(ctx)
 	if err != nil {
-		p.logger.Errorf(ctx, "Failed to get attributes after upload", map[string]any{"key": finalKey, "error": err})
-		return nil, fmt.Errorf("failed to get attributes for key %s after upload: %w", finalKey, err)
+		p.logger.Errorf(ctx, "Failed to get attributes after upload", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to get attributes for key %s after upload: %w", key, err)
 	}
 
-	fileURL := p.generatePublicURL(finalKey)
-	p.logger.Infof(ctx, "File uploaded successfully", map[string]any{"key": finalKey, "url": fileURL})
+	fileURL := p.generatePublicURL(key)
+	p.logger.Infof(ctx, "File uploaded successfully", map[string]any{"key": key, "url": fileURL})
 
 	return &port.FileObject{
-		Key:          finalKey,
+		Key:          key,
 		URL:          fileURL, // This URL might not be publicly accessible by default
 		Size:         attrs.Size,
 		ContentType:  attrs.ContentType,
@@ -147,19 +144,29 @@ func (p *firebaseProvider) generatePublicURL(key string) string {
 // GetURL returns a publicly accessible URL for the given key.
 // For Firebase, this usually means the object must be publicly readable.
 func (p *firebaseProvider) GetURL(ctx context.Context, key string) (string, error) {
-	// Check if object exists first, though not strictly necessary for URL generation
-	// _, err := p.bucket.Object(key).Attrs(ctx)
-	// if err != nil {
-	// 	if err == adapters.ErrObjectNotExist {
-	// 		p.logger.Warnf(ctx, "Object does not exist, cannot get URL", map[string]any{"key": key})
-	// 		return "", fmt.Errorf("object %s not found: %w", key, err)
-	// 	}
-	// 	p.logger.Errorf(ctx, "Failed to get object attributes for URL", map[string]any{"key": key, "error": err})
-	// 	return "", fmt.Errorf("failed to get attributes for %s: %w", key, err)
-	// }
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "Object does not exist, cannot get URL", map[string]any{"key": key})
+		return "", fmt.Errorf("firebase object %s %w", key, port.ErrObjectNotFound)
+	}
 	return p.generatePublicURL(key), nil
 }
 
+// Exists checks for an object by reading its attributes.
+func (p *firebaseProvider) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := p.bucket.Object(key).Attrs(ctx); err != nil {
+		if err == storage.ErrObjectNotExist {
+			return false, nil
+		}
+		p.logger.Errorf(ctx, "Failed to get object attributes", map[string]any{"key": key, "error": err})
+		return false, fmt.Errorf("failed to check object %s: %w", key, err)
+	}
+	return true, nil
+}
+
 // GetSignedURL generates a time-limited signed URL for accessing a private object.
 func (p *firebaseProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
 	opts := &storage.SignedURLOptions{
@@ -178,6 +185,28 @@ func (p *firebaseProvider) GetSignedURL(ctx context.Context, key string, duratio
 	return signedURL, nil
 }
 
+var _ port.PresignedUploadProvider = (*firebaseProvider)(nil)
+
+// GetSignedUploadURL generates a V4 signed URL for uploading an object with a PUT request.
+func (p *firebaseProvider) GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *port.UploadOptions) (string, error) {
+	signOpts := &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "PUT",
+		Expires: time.Now().Add(duration),
+	}
+	if opts != nil && opts.ContentType != "" {
+		signOpts.ContentType = opts.ContentType
+	}
+
+	signedURL, err := p.bucket.SignedURL(key, signOpts)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate signed upload URL", map[string]any{"key": key, "duration": duration, "error": err})
+		return "", fmt.Errorf("failed to generate signed upload URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated signed upload URL", map[string]any{"key": key, "duration": duration})
+	return signedURL, nil
+}
+
 // Delete removes a file from Firebase Cloud Storage.
 func (p *firebaseProvider) Delete(ctx context.Context, key string) error {
 	obj := p.bucket.Object(key)
@@ -199,7 +228,7 @@ func (p *firebaseProvider) GetObject(ctx context.Context, key string) (*port.Fil
 	if err != nil {
 		if err == storage.ErrObjectNotExist {
 			p.logger.Warnf(ctx, "Object not found for GetObject", map[string]any{"key": key})
-			return nil, fmt.Errorf("object %s not found: %w", key, err) // Consider a custom error like port.ErrObjectNotFound
+			return nil, fmt.Errorf("firebase object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to get object attributes", map[string]any{"key": key, "error": err})
 		return nil, fmt.Errorf("failed to get attributes for object %s: %w", key, err)
@@ -228,7 +257,7 @@ func (p *firebaseProvider) Download(ctx context.Context, key string) (io.ReadClo
 	if err != nil {
 		if err == storage.ErrObjectNotExist {
 			p.logger.Warnf(ctx, "Object not found for Download", map[string]any{"key": key})
-			return nil, nil, fmt.Errorf("object %s not found for download: %w", key, err)
+			return nil, nil, fmt.Errorf("firebase object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to get object attributes before download", map[string]any{"key": key, "error": err})
 		return nil, nil, fmt.Errorf("failed to get attributes for %s before download: %w", key, err)
@@ -253,6 +282,78 @@ func (p *firebaseProvider) Download(ctx context.Context, key string) (io.ReadClo
 	return reader, fileObject, nil
 }
 
+// DownloadRange downloads a byte range of a file with a range reader.
+func (p *firebaseProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	if _, err := port.HTTPRange(start, end); err != nil {
+		return nil, nil, err
+	}
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	length := int64(-1) // Read to the end of the object
+	if end >= 0 {
+		length = end - start + 1
+	}
+	reader, err := p.bucket.Object(key).NewRangeReader(ctx, start, length)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to create range reader for object", map[string]any{"key": key, "start": start, "end": end, "error": err})
+		return nil, nil, fmt.Errorf("failed to create range reader for object %s: %w", key, err)
+	}
+	return reader, fileObject, nil
+}
+
+// Copy duplicates an object inside the bucket with a server-side rewrite.
+func (p *firebaseProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	attrs, err := p.bucket.Object(dstKey).CopierFrom(p.bucket.Object(srcKey)).Run(ctx)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to copy object", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, fmt.Errorf("failed to copy object %s to %s: %w", srcKey, dstKey, err)
+	}
+	p.logger.Infof(ctx, "Object copied successfully", map[string]any{"srcKey": srcKey, "dstKey": dstKey})
+	return &port.FileObject{
+		Key:          attrs.Name,
+		URL:          p.generatePublicURL(attrs.Name),
+		Size:         attrs.Size,
+		ContentType:  attrs.ContentType,
+		LastModified: attrs.Updated,
+		ETag:         attrs.Etag,
+		Provider:     p.ProviderType(),
+	}, nil
+}
+
+// Move copies the object server-side and then deletes the source.
+func (p *firebaseProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists one page of objects under prefix.
+func (p *firebaseProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	it := p.bucket.Objects(ctx, &storage.Query{Prefix: prefix})
+	pager := iterator.NewPager(it, opts.MaxKeysOrDefault(), opts.Token())
+
+	var attrsPage []*storage.ObjectAttrs
+	nextToken, err := pager.NextPage(&attrsPage)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to list objects", map[string]any{"prefix": prefix, "error": err})
+		return nil, "", fmt.Errorf("failed to list objects with prefix %s: %w", prefix, err)
+	}
+
+	objects := make([]*port.FileObject, 0, len(attrsPage))
+	for _, attrs := range attrsPage {
+		objects = append(objects, &port.FileObject{
+			Key:          attrs.Name,
+			URL:          p.generatePublicURL(attrs.Name),
+			Size:         attrs.Size,
+			ContentType:  attrs.ContentType,
+			LastModified: attrs.Updated,
+			ETag:         attrs.Etag,
+			Provider:     p.ProviderType(),
+		})
+	}
+	return objects, nextToken, nil
+}
+
 // CheckHealth checks if the storage provider is healthy and accessible.
 func (p *firebaseProvider) CheckHealth(ctx context.Context) error {
 	// Try to list objects to verify bucket access
@@ -0,0 +1,228 @@
+package firebase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// fakeObject is an object held by fakeGCS
+type fakeObject struct {
+	data        []byte
+	contentType string
+	generation  int64
+	updated     time.Time
+}
+
+// fakeGCS serves the parts of the Cloud Storage JSON API the provider uses: multipart
+// uploads, object metadata, media downloads and deletes, for a single bucket.
+type fakeGCS struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]*fakeObject
+}
+
+func (f *fakeGCS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Object names are path segments, so they arrive escaped and may contain slashes
+	path := r.URL.EscapedPath()
+	uploadPrefix := "/upload/storage/v1/b/" + f.bucket + "/o"
+	objectPrefix := "/storage/v1/b/" + f.bucket + "/o/"
+
+	switch {
+	case r.Method == http.MethodPost && path == uploadPrefix:
+		f.upload(w, r)
+	case strings.HasPrefix(path, objectPrefix):
+		name, err := url.PathUnescape(strings.TrimPrefix(path, objectPrefix))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.object(w, r, name)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+path, http.StatusNotImplemented)
+	}
+}
+
+// upload stores the object of a multipart/related upload: JSON metadata, then the data
+func (f *fakeGCS) upload(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("uploadType") != "multipart" {
+		http.Error(w, "only multipart uploads are supported", http.StatusNotImplemented)
+		return
+	}
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	reader := multipart.NewReader(r.Body, params["boundary"])
+
+	var meta struct {
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+	}
+	part, err := reader.NextPart()
+	if err == nil {
+		err = json.NewDecoder(part).Decode(&meta)
+	}
+	if err != nil {
+		http.Error(w, "bad metadata part: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	part, err = reader.NextPart()
+	if err != nil {
+		http.Error(w, "missing media part: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	data, err := io.ReadAll(part)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	obj := &fakeObject{data: data, contentType: meta.ContentType, generation: time.Now().UnixNano(), updated: time.Now().UTC()}
+	f.objects[meta.Name] = obj
+	f.mu.Unlock()
+	f.writeAttrs(w, meta.Name, obj)
+}
+
+// object serves the metadata or data of an object, or deletes it
+func (f *fakeGCS) object(w http.ResponseWriter, r *http.Request, name string) {
+	f.mu.Lock()
+	obj, ok := f.objects[name]
+	if ok && r.Method == http.MethodDelete {
+		delete(f.objects, name)
+	}
+	f.mu.Unlock()
+
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, `{"error":{"code":404,"message":"No such object: %s/%s"}}`, f.bucket, name)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodDelete:
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodGet && r.URL.Query().Get("alt") == "media":
+		w.Header().Set("Content-Type", obj.contentType)
+		w.Header().Set("X-Goog-Generation", fmt.Sprint(obj.generation))
+		w.Write(obj.data)
+	case r.Method == http.MethodGet:
+		f.writeAttrs(w, name, obj)
+	default:
+		http.Error(w, "unexpected method "+r.Method, http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeGCS) writeAttrs(w http.ResponseWriter, name string, obj *fakeObject) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"kind":        "storage#object",
+		"bucket":      f.bucket,
+		"name":        name,
+		"size":        fmt.Sprint(len(obj.data)),
+		"contentType": obj.contentType,
+		"generation":  fmt.Sprint(obj.generation),
+		"etag":        fmt.Sprintf("etag-%d", obj.generation),
+		"updated":     obj.updated.Format(time.RFC3339Nano),
+	})
+}
+
+// newTestProvider returns a provider whose bucket is served by a fakeGCS
+func newTestProvider(t *testing.T) (*firebaseProvider, *fakeGCS) {
+	t.Helper()
+	fake := &fakeGCS{bucket: "test-bucket", objects: map[string]*fakeObject{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	client, err := storage.NewClient(context.Background(),
+		option.WithEndpoint(server.URL+"/storage/v1/"),
+		option.WithoutAuthentication(),
+		storage.WithJSONReads(),
+	)
+	if err != nil {
+		t.Fatalf("storage.NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	return &firebaseProvider{bucket: client.Bucket(fake.bucket), bucketName: fake.bucket, logger: log}, fake
+}
+
+func TestUploadRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	provider, fake := newTestProvider(t)
+
+	// A nested key must address the same object in every call
+	key := "6f1c0b9e-2f44-4c55-9a0e-0d9e3d1c7a21/document/20260302/report final.txt"
+	content := "quarterly numbers\n"
+
+	uploaded, err := provider.Upload(ctx, key, strings.NewReader(content), int64(len(content)), &port.UploadOptions{ContentType: "text/plain"})
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if uploaded.Key != key || uploaded.Size != int64(len(content)) {
+		t.Errorf("Upload = key %q size %d, want %q size %d", uploaded.Key, uploaded.Size, key, len(content))
+	}
+	if _, ok := fake.objects[key]; !ok {
+		t.Fatalf("bucket has no object named %q", key)
+	}
+
+	object, err := provider.GetObject(ctx, key)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	if object.Key != key || object.Size != int64(len(content)) || object.ContentType != "text/plain" {
+		t.Errorf("GetObject = %+v", object)
+	}
+
+	reader, downloaded, err := provider.Download(ctx, key)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		t.Fatalf("read download: %v", err)
+	}
+	if string(data) != content || downloaded.Key != key {
+		t.Errorf("Download = %q (key %q), want %q", data, downloaded.Key, content)
+	}
+
+	if exists, err := provider.Exists(ctx, key); err != nil || !exists {
+		t.Fatalf("Exists before delete = %v, %v", exists, err)
+	}
+	if err := provider.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if exists, err := provider.Exists(ctx, key); err != nil || exists {
+		t.Errorf("Exists after delete = %v, %v; want false", exists, err)
+	}
+	if _, err := provider.GetObject(ctx, key); !errors.Is(err, port.ErrObjectNotFound) {
+		t.Errorf("GetObject after delete error = %v, want ErrObjectNotFound", err)
+	}
+}
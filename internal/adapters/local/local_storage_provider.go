@@ -1,28 +1,48 @@
 package local
 
 import (
+	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	logger "github.com/lugondev/go-log"
 	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+const (
+	// healthCheckFile is the probe file CheckHealth writes under the base path.
+	healthCheckFile = ".health_check"
+	// sniffLen is how many leading bytes http.DetectContentType considers.
+	sniffLen = 512
 )
 
 // LocalStorageProvider implements the StorageProvider interface for local file system.
 type LocalStorageProvider struct {
 	config config.LocalStorageConfig
+	clock  clock.Clock // Dates signed URLs and checks their expiry
+	logger logger.Logger
 }
 
 // NewLocalStorageProvider creates a new LocalStorageProvider.
 // It expects a config map that can be unmarshalled into LocalStorageConfig.
-func NewLocalStorageProvider(cfg config.LocalStorageConfig) (port.StorageProvider, error) {
+func NewLocalStorageProvider(cfg config.LocalStorageConfig, log logger.Logger, clk clock.Clock) (port.StorageProvider, error) {
 	// A more robust way would be to use a library like mapstructure to convert map to struct
 	// For simplicity, we'll do direct type assertion here, but this is not production-ready.
 	basePath := cfg.Path
@@ -51,26 +71,52 @@ func NewLocalStorageProvider(cfg config.LocalStorageConfig) (port.StorageProvide
 
 	return &LocalStorageProvider{
 		config: cfg,
+		clock:  clk,
+		logger: log.WithFields(map[string]any{"component": "LocalStorageProvider"}),
 	}, nil
 }
 
-func (p *LocalStorageProvider) resolvePath(key string) string {
-	// Sanitize key to prevent directory traversal issues
-	cleanKey := filepath.Clean(key)
-	// Ensure the key is relative and doesn't try to escape the base path
-	if strings.HasPrefix(cleanKey, "..") || filepath.IsAbs(cleanKey) {
-		// Handle potentially malicious key, e.g., return an error or a default safe path
-		// For now, we'll just join it, but this needs careful consideration for security.
-		// A better approach might be to ensure the key doesn't contain ".."
-		// or to use a more robust path joining/cleaning mechanism.
-		cleanKey = strings.ReplaceAll(cleanKey, "..", "") // Basic sanitization
+// resolvePath maps key to a file path under the base path. Absolute keys, keys with ".."
+// segments (also when percent-encoded) and keys naming the base path itself are rejected
+// rather than rewritten, so a crafted key can never read or write outside the storage root.
+func (p *LocalStorageProvider) resolvePath(key string) (string, error) {
+	decoded, err := url.PathUnescape(key)
+	if err != nil {
+		decoded = key
 	}
-	return filepath.Join(p.config.Path, cleanKey)
+	for _, candidate := range []string{key, decoded} {
+		if filepath.IsAbs(candidate) || strings.HasPrefix(candidate, "/") || strings.HasPrefix(candidate, `\`) {
+			return "", fmt.Errorf("%w: %q is absolute", port.ErrInvalidKey, key)
+		}
+		for _, segment := range strings.FieldsFunc(candidate, isPathSeparator) {
+			if segment == ".." {
+				return "", fmt.Errorf("%w: %q contains a parent directory reference", port.ErrInvalidKey, key)
+			}
+		}
+	}
+
+	base := filepath.Clean(p.config.Path)
+	resolved := filepath.Join(base, filepath.Clean("/"+key))
+	if !strings.HasPrefix(resolved, base+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%w: %q does not name a file under the storage root", port.ErrInvalidKey, key)
+	}
+	return resolved, nil
+}
+
+// isPathSeparator splits keys on both slash styles, since either may reach the filesystem.
+func isPathSeparator(r rune) bool {
+	return r == '/' || r == '\\'
 }
 
 // Upload uploads a file to the local file system.
 func (p *LocalStorageProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
-	filePath := p.resolvePath(key)
+	filePath, err := p.resolvePath(key)
+	if err != nil {
+		return nil, err
+	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Local storage writes files unencrypted; ignoring server-side encryption options", map[string]any{"key": key})
+	}
 	dir := filepath.Dir(filePath)
 
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -83,11 +129,32 @@ func (p *LocalStorageProvider) Upload(ctx context.Context, key string, reader io
 	}
 	defer dst.Close()
 
-	written, err := io.Copy(dst, reader)
+	// Local files carry no metadata, so without a declared type sniff one from the first bytes
+	contentType := ""
+	if opts != nil && opts.ContentType != "" {
+		contentType = opts.ContentType
+	} else {
+		head := make([]byte, sniffLen)
+		n, err := io.ReadFull(reader, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			dst.Close()
+			os.Remove(filePath)
+			return nil, fmt.Errorf("failed to read upload for %s: %w", filePath, err)
+		}
+		contentType = detectContentType(head[:n], key)
+		reader = io.MultiReader(bytes.NewReader(head[:n]), reader)
+	}
+
+	// A partial file is never left behind, whether the copy fails or the request is cancelled
+	written, err := io.Copy(dst, &contextReader{ctx: ctx, r: opts.TrackProgress(reader, size)})
 	if err != nil {
+		dst.Close()
+		os.Remove(filePath)
 		return nil, fmt.Errorf("failed to write to file %s: %w", filePath, err)
 	}
 	if written != size && size != -1 { // size == -1 can mean unknown size for chunked transfer
+		dst.Close()
+		os.Remove(filePath)
 		return nil, fmt.Errorf("file size mismatch: expected %d, wrote %d", size, written)
 	}
 
@@ -96,11 +163,6 @@ func (p *LocalStorageProvider) Upload(ctx context.Context, key string, reader io
 		return nil, fmt.Errorf("failed to get file info for %s: %w", filePath, err)
 	}
 
-	contentType := ""
-	if opts != nil && opts.ContentType != "" {
-		contentType = opts.ContentType
-	}
-
 	return &port.FileObject{
 		Key:          key,
 		URL:          p.buildPublicURL(key),
@@ -123,31 +185,47 @@ func (p *LocalStorageProvider) buildPublicURL(key string) string {
 
 // GetURL returns a publicly accessible URL for the given key.
 func (p *LocalStorageProvider) GetURL(ctx context.Context, key string) (string, error) {
-	filePath := p.resolvePath(key)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", errors.New("file not found")
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("file %s %w", key, port.ErrObjectNotFound)
 	}
 	return p.buildPublicURL(key), nil
 }
 
-// GetSignedURL generates a time-limited "signed" URL (simulated for local).
-// This is a basic simulation and not cryptographically secure for production without more work.
+// Exists checks for a file under the base path.
+func (p *LocalStorageProvider) Exists(ctx context.Context, key string) (bool, error) {
+	filePath, err := p.resolvePath(key)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to stat file %s: %w", filePath, err)
+	}
+	return true, nil
+}
+
+// GetSignedURL generates a time-limited URL signed with an HMAC of the key and expiry.
+// The URL is served by this service, which checks it with VerifySignedURL.
 func (p *LocalStorageProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
-	filePath := p.resolvePath(key)
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return "", errors.New("file not found")
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		return "", fmt.Errorf("file %s %w", key, port.ErrObjectNotFound)
 	}
 
 	if p.config.BaseURL == "" {
 		return "", errors.New("base_url not configured, cannot generate signed URL")
 	}
 
-	// Basic "signing": append expiry and a simple hash/token (not secure for production)
-	// In a real scenario, you'd use HMAC or a proper JWT-like mechanism.
-	expiry := time.Now().Add(duration).Unix()
-	tokenPayload := fmt.Sprintf("%s:%d:%s", key, expiry, p.config.SignedURLSecret)
-	// In a real app, use crypto/hmac and sha256
-	simulatedSignature := fmt.Sprintf("%x", []byte(tokenPayload)) // Very basic, NOT SECURE
+	expiry := p.clock.Now().Add(duration).Unix()
 
 	signedURL, err := url.Parse(p.buildPublicURL(key))
 	if err != nil {
@@ -155,16 +233,55 @@ func (p *LocalStorageProvider) GetSignedURL(ctx context.Context, key string, dur
 	}
 
 	q := signedURL.Query()
-	q.Set("expires", fmt.Sprintf("%d", expiry))
-	q.Set("signature", simulatedSignature) // This signature is trivial to forge
+	q.Set("expires", strconv.FormatInt(expiry, 10))
+	q.Set("signature", p.sign(key, expiry))
 	signedURL.RawQuery = q.Encode()
 
 	return signedURL.String(), nil
 }
 
+var _ port.LocalFileServer = (*LocalStorageProvider)(nil)
+
+// VerifySignedURL checks a signature issued by GetSignedURL, comparing MACs in constant time.
+func (p *LocalStorageProvider) VerifySignedURL(key string, expires int64, signature string) error {
+	given, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, p.mac(key, expires)) {
+		return port.ErrSignedURLInvalid
+	}
+	if p.clock.Now().Unix() > expires {
+		return port.ErrSignedURLExpired
+	}
+	return nil
+}
+
+// SignedURLBase returns the configured base URL that signed URLs are issued under.
+func (p *LocalStorageProvider) SignedURLBase() string {
+	return p.config.BaseURL
+}
+
+// LocalPath returns the path of the file holding key under this provider's base path.
+func (p *LocalStorageProvider) LocalPath(key string) (string, error) {
+	return p.resolvePath(key)
+}
+
+// sign returns the URL-safe signature for key and expires.
+func (p *LocalStorageProvider) sign(key string, expires int64) string {
+	return base64.RawURLEncoding.EncodeToString(p.mac(key, expires))
+}
+
+// mac computes HMAC-SHA256 over "key|expires" with the configured secret.
+func (p *LocalStorageProvider) mac(key string, expires int64) []byte {
+	h := hmac.New(sha256.New, []byte(p.config.SignedURLSecret))
+	h.Write([]byte(key + "|" + strconv.FormatInt(expires, 10)))
+	return h.Sum(nil)
+}
+
 // Delete removes a file from the local file system.
 func (p *LocalStorageProvider) Delete(ctx context.Context, key string) error {
-	filePath := p.resolvePath(key)
+	filePath, err := p.resolvePath(key)
+	if err != nil {
+		return err
+	}
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
 			return nil // Already deleted or never existed, treat as success
@@ -174,37 +291,36 @@ func (p *LocalStorageProvider) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
-// GetObject retrieves file information.
+// GetObject retrieves file information. The content type is detected from the file's
+// first bytes, since local files have no stored metadata.
 func (p *LocalStorageProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
-	filePath := p.resolvePath(key)
-	fileInfo, err := os.Stat(filePath)
+	file, objInfo, err := p.openFile(key)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, errors.New("file not found")
-		}
-		return nil, fmt.Errorf("failed to get file info for %s: %w", filePath, err)
+		return nil, err
 	}
-
-	// Determine content type (can be improved, e.g., by http.DetectContentType on a small portion of the file)
-	contentType := "" // Placeholder, ideally detect or retrieve from metadata if stored
-
-	return &port.FileObject{
-		Key:          key,
-		URL:          p.buildPublicURL(key),
-		Size:         fileInfo.Size(),
-		ContentType:  contentType,
-		LastModified: fileInfo.ModTime(),
-		Provider:     p.ProviderType(),
-	}, nil
+	file.Close()
+	return objInfo, nil
 }
 
-// Download retrieves a file from local adapters.
+// Download retrieves a file from local adapters. Reads fail with ctx's error once ctx is done.
 func (p *LocalStorageProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
-	filePath := p.resolvePath(key)
+	file, objInfo, err := p.openFile(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &readCloser{Reader: &contextReader{ctx: ctx, r: file}, Closer: file}, objInfo, nil
+}
+
+// openFile opens the file for key and describes it.
+func (p *LocalStorageProvider) openFile(key string) (*os.File, *port.FileObject, error) {
+	filePath, err := p.resolvePath(key)
+	if err != nil {
+		return nil, nil, err
+	}
 	file, err := os.Open(filePath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil, errors.New("file not found")
+			return nil, nil, fmt.Errorf("file %s %w", key, port.ErrObjectNotFound)
 		}
 		return nil, nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
 	}
@@ -214,19 +330,221 @@ func (p *LocalStorageProvider) Download(ctx context.Context, key string) (io.Rea
 		file.Close()
 		return nil, nil, fmt.Errorf("failed to get file info for %s: %w", filePath, err)
 	}
+	// A directory holds objects under the key as a prefix but is not one itself
+	if fileInfo.IsDir() {
+		file.Close()
+		return nil, nil, fmt.Errorf("file %s %w", key, port.ErrObjectNotFound)
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := file.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to read file header for %s: %w", filePath, err)
+	}
 
 	objInfo := &port.FileObject{
 		Key:          key,
 		URL:          p.buildPublicURL(key),
 		Size:         fileInfo.Size(),
+		ContentType:  detectContentType(head[:n], key),
 		LastModified: fileInfo.ModTime(),
 		Provider:     p.ProviderType(),
-		// ContentType should be determined here as well
 	}
 
 	return file, objInfo, nil
 }
 
+// DownloadRange opens the file at start and limits reads to the requested range.
+func (p *LocalStorageProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	if _, err := port.HTTPRange(start, end); err != nil {
+		return nil, nil, err
+	}
+	file, objInfo, err := p.openFile(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := file.Seek(start, io.SeekStart); err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to seek to offset %d in %s: %w", start, key, err)
+	}
+	var reader io.Reader = file
+	if end >= 0 {
+		reader = io.LimitReader(file, end-start+1)
+	}
+	return &readCloser{Reader: &contextReader{ctx: ctx, r: reader}, Closer: file}, objInfo, nil
+}
+
+// detectContentType sniffs head with http.DetectContentType, falling back to the key's
+// extension when the bytes are not recognised.
+func detectContentType(head []byte, key string) string {
+	detected := http.DetectContentType(head)
+	if detected == "application/octet-stream" {
+		if byExtension := mime.TypeByExtension(filepath.Ext(key)); byExtension != "" {
+			return byExtension
+		}
+	}
+	return detected
+}
+
+// readCloser pairs a wrapped reader with the file it reads from, which Close closes.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// contextReader fails reads with ctx's error once ctx is done, so a cancelled request
+// stops copying between the client and disk at the next chunk.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// Copy duplicates a file under the base path.
+func (p *LocalStorageProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	srcPath, err := p.resolvePath(srcKey)
+	if err != nil {
+		return nil, err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %s %w", srcKey, port.ErrObjectNotFound)
+		}
+		return nil, fmt.Errorf("failed to open file %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	dstPath, err := p.resolvePath(dstKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dstPath), err)
+	}
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file %s: %w", dstPath, err)
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, &contextReader{ctx: ctx, r: src}); err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		return nil, fmt.Errorf("failed to copy %s to %s: %w", srcPath, dstPath, err)
+	}
+
+	return p.GetObject(ctx, dstKey)
+}
+
+// Move renames a file under the base path. os.Rename is atomic within one filesystem,
+// so there is no partially-moved state.
+func (p *LocalStorageProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	srcPath, err := p.resolvePath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := p.resolvePath(dstKey)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(dstPath), err)
+	}
+	if err := os.Rename(srcPath, dstPath); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file %s %w", srcKey, port.ErrObjectNotFound)
+		}
+		return fmt.Errorf("failed to move %s to %s: %w", srcKey, dstKey, err)
+	}
+	return nil
+}
+
+// ListObjects returns files under the base path whose keys start with prefix, in key order.
+// The continuation token is the last key of the previous page.
+func (p *LocalStorageProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	// Only walk the deepest directory the prefix names.
+	start := p.config.Path
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		dir, err := p.resolvePath(prefix[:i])
+		if err != nil {
+			return nil, "", err
+		}
+		start = dir
+	}
+
+	var keys []string
+	err := filepath.WalkDir(start, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == start && os.IsNotExist(err) {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(p.config.Path, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if key != healthCheckFile && strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list files with prefix %s: %w", prefix, err)
+	}
+
+	// WalkDir order differs from byte order across directories ("a/b" vs "a.txt"), so sort for stable tokens.
+	sort.Strings(keys)
+	if token := opts.Token(); token != "" {
+		keys = keys[sort.Search(len(keys), func(i int) bool { return keys[i] > token }):]
+	}
+
+	nextToken := ""
+	if maxKeys := opts.MaxKeysOrDefault(); len(keys) > maxKeys {
+		keys = keys[:maxKeys]
+		nextToken = keys[len(keys)-1]
+	}
+
+	objects := make([]*port.FileObject, 0, len(keys))
+	for _, key := range keys {
+		filePath, err := p.resolvePath(key)
+		if err != nil {
+			return nil, "", err
+		}
+		info, err := os.Stat(filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue // Removed while listing
+			}
+			return nil, "", fmt.Errorf("failed to get file info for %s: %w", key, err)
+		}
+		objects = append(objects, &port.FileObject{
+			Key:          key,
+			URL:          p.buildPublicURL(key),
+			Size:         info.Size(),
+			ContentType:  mime.TypeByExtension(filepath.Ext(key)),
+			LastModified: info.ModTime(),
+			Provider:     p.ProviderType(),
+		})
+	}
+	return objects, nextToken, nil
+}
+
 // CheckHealth checks if the storage provider is healthy and accessible.
 func (p *LocalStorageProvider) CheckHealth(ctx context.Context) error {
 	// Check if base directory exists and is accessible
@@ -235,7 +553,7 @@ func (p *LocalStorageProvider) CheckHealth(ctx context.Context) error {
 	}
 
 	// Try to create a temporary file to verify write permissions
-	tmpFile := filepath.Join(p.config.Path, ".health_check")
+	tmpFile := filepath.Join(p.config.Path, healthCheckFile)
 	f, err := os.Create(tmpFile)
 	if err != nil {
 		return fmt.Errorf("local storage health check failed: write permission error: %w", err)
@@ -0,0 +1,141 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+func newTestProvider(t *testing.T, root string) *LocalStorageProvider {
+	t.Helper()
+	return newTestProviderWithClock(t, root, clock.New())
+}
+
+// newTestProviderWithClock returns a provider under root that signs URLs for
+// http://localhost/files with clk
+func newTestProviderWithClock(t *testing.T, root string, clk clock.Clock) *LocalStorageProvider {
+	t.Helper()
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	provider, err := NewLocalStorageProvider(config.LocalStorageConfig{Path: root, BaseURL: "http://localhost/files", SignedURLSecret: "test-secret"}, log, clk)
+	if err != nil {
+		t.Fatalf("NewLocalStorageProvider: %v", err)
+	}
+	return provider.(*LocalStorageProvider)
+}
+
+func TestResolvePathRejectsEscapingKeys(t *testing.T) {
+	// The storage root sits one level down so writes next to it would be noticed
+	outside := t.TempDir()
+	root := filepath.Join(outside, "storage")
+	provider := newTestProvider(t, root)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{name: "parent traversal", key: "../../etc/passwd"},
+		{name: "parent of the root", key: "../escaped.txt"},
+		{name: "traversal after a directory", key: "user/../../escaped.txt"},
+		{name: "encoded slash", key: "..%2fescaped.txt"},
+		{name: "encoded dots and slash", key: "%2e%2e%2fescaped.txt"},
+		{name: "backslash traversal", key: `..\escaped.txt`},
+		{name: "absolute", key: "/etc/passwd"},
+		{name: "absolute under the root", key: filepath.Join(root, "file.txt")},
+		{name: "encoded absolute", key: "%2fetc%2fpasswd"},
+		{name: "backslash absolute", key: `\escaped.txt`},
+		{name: "the root itself", key: "."},
+		{name: "empty", key: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if path, err := provider.resolvePath(tt.key); !errors.Is(err, port.ErrInvalidKey) {
+				t.Fatalf("resolvePath(%q) = %q, %v; want ErrInvalidKey", tt.key, path, err)
+			}
+			_, err := provider.Upload(context.Background(), tt.key, strings.NewReader("payload"), 7, &port.UploadOptions{})
+			if !errors.Is(err, port.ErrInvalidKey) {
+				t.Fatalf("Upload(%q) error = %v, want ErrInvalidKey", tt.key, err)
+			}
+		})
+	}
+
+	entries, err := os.ReadDir(outside)
+	if err != nil {
+		t.Fatalf("read %s: %v", outside, err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "storage" {
+			t.Errorf("file written outside the storage root: %s", entry.Name())
+		}
+	}
+	if entries, err := os.ReadDir(root); err != nil || len(entries) != 0 {
+		t.Errorf("storage root holds %d entries (err %v), want none", len(entries), err)
+	}
+}
+
+func TestResolvePathKeepsKeysUnderRoot(t *testing.T) {
+	root := t.TempDir()
+	provider := newTestProvider(t, root)
+
+	for _, key := range []string{"file.txt", "user/image/20260302/photo.png", "a..b/c..d.txt", "dir/./file.txt"} {
+		path, err := provider.resolvePath(key)
+		if err != nil {
+			t.Errorf("resolvePath(%q): %v", key, err)
+			continue
+		}
+		if !strings.HasPrefix(path, root+string(os.PathSeparator)) {
+			t.Errorf("resolvePath(%q) = %q, outside %s", key, path, root)
+		}
+	}
+}
+
+func TestSignedURLExpires(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewMock(time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC))
+	provider := newTestProviderWithClock(t, t.TempDir(), clk)
+	if _, err := provider.Upload(ctx, "user/file.txt", strings.NewReader("hello"), 5, &port.UploadOptions{ContentType: "text/plain"}); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+
+	signed, err := provider.GetSignedURL(ctx, "user/file.txt", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("GetSignedURL: %v", err)
+	}
+	parsed, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse signed URL: %v", err)
+	}
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatalf("expires of %s: %v", signed, err)
+	}
+	if want := clk.Now().Add(10 * time.Minute).Unix(); expires != want {
+		t.Errorf("expires = %d, want %d", expires, want)
+	}
+	signature := parsed.Query().Get("signature")
+
+	if err := provider.VerifySignedURL("user/file.txt", expires, signature); err != nil {
+		t.Fatalf("VerifySignedURL before expiry: %v", err)
+	}
+	if err := provider.VerifySignedURL("user/other.txt", expires, signature); !errors.Is(err, port.ErrSignedURLInvalid) {
+		t.Errorf("VerifySignedURL for another key = %v, want ErrSignedURLInvalid", err)
+	}
+	clk.Advance(10*time.Minute + time.Second)
+	if err := provider.VerifySignedURL("user/file.txt", expires, signature); !errors.Is(err, port.ErrSignedURLExpired) {
+		t.Errorf("VerifySignedURL after expiry = %v, want ErrSignedURLExpired", err)
+	}
+}
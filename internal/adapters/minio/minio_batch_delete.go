@@ -0,0 +1,32 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.BatchDeleteProvider = (*minioProvider)(nil)
+
+// DeleteMany removes keys with RemoveObjects, which batches them into multi-object
+// delete requests and reports failures on its error channel.
+func (p *minioProvider) DeleteMany(ctx context.Context, keys []string) (map[string]error, error) {
+	objects := make(chan minio.ObjectInfo, len(keys))
+	for _, key := range keys {
+		objects <- minio.ObjectInfo{Key: key}
+	}
+	close(objects)
+
+	failed := make(map[string]error)
+	for removeErr := range p.client.RemoveObjects(ctx, p.bucketName, objects, minio.RemoveObjectsOptions{}) {
+		failed[removeErr.ObjectName] = fmt.Errorf("failed to delete MinIO object %s: %w", removeErr.ObjectName, removeErr.Err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to delete MinIO objects: %w", err)
+	}
+	p.logger.Infof(ctx, "MinIO objects deleted", map[string]any{"count": len(keys), "failed": len(failed)})
+	return failed, nil
+}
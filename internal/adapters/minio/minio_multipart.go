@@ -0,0 +1,92 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.MultipartProvider = (*minioProvider)(nil)
+
+// core exposes the low-level multipart API that minio.Client hides behind PutObject.
+func (p *minioProvider) core() minio.Core {
+	return minio.Core{Client: p.client}
+}
+
+// InitiateMultipartUpload starts a multipart upload and returns its upload ID.
+func (p *minioProvider) InitiateMultipartUpload(ctx context.Context, key string, opts *port.UploadOptions) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("upload key cannot be empty")
+	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Ignoring server-side encryption options for MinIO multipart upload", map[string]any{"key": key})
+	}
+
+	contentType := ""
+	if opts != nil && opts.ContentType != "" {
+		contentType = opts.ContentType
+	} else if ext := filepath.Ext(key); ext != "" {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	putObjectOpts := minio.PutObjectOptions{ContentType: contentType}
+	if opts != nil && opts.Metadata != nil {
+		putObjectOpts.UserMetadata = opts.Metadata
+	}
+
+	uploadID, err := p.core().NewMultipartUpload(ctx, p.bucketName, key, putObjectOpts)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to initiate MinIO multipart upload", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to initiate MinIO multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "MinIO multipart upload initiated", map[string]any{"key": key, "uploadId": uploadID})
+	return uploadID, nil
+}
+
+// UploadPart uploads one part of a multipart upload.
+func (p *minioProvider) UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (*port.CompletedPart, error) {
+	part, err := p.core().PutObjectPart(ctx, p.bucketName, key, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to upload MinIO part", map[string]any{"key": key, "uploadId": uploadID, "partNumber": partNumber, "error": err})
+		return nil, fmt.Errorf("failed to upload part %d of MinIO key %s: %w", partNumber, key, err)
+	}
+	return &port.CompletedPart{
+		PartNumber: part.PartNumber,
+		ETag:       strings.Trim(part.ETag, "\""),
+	}, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the final object.
+func (p *minioProvider) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []port.CompletedPart) (*port.FileObject, error) {
+	completed := make([]minio.CompletePart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+
+	if _, err := p.core().CompleteMultipartUpload(ctx, p.bucketName, key, uploadID, completed, minio.PutObjectOptions{}); err != nil {
+		p.logger.Errorf(ctx, "Failed to complete MinIO multipart upload", map[string]any{"key": key, "uploadId": uploadID, "error": err})
+		return nil, fmt.Errorf("failed to complete MinIO multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "MinIO multipart upload completed", map[string]any{"key": key, "uploadId": uploadID, "parts": len(parts)})
+	return p.GetObject(ctx, key)
+}
+
+// AbortMultipartUpload discards a multipart upload and its stored parts.
+func (p *minioProvider) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	if err := p.core().AbortMultipartUpload(ctx, p.bucketName, key, uploadID); err != nil {
+		p.logger.Errorf(ctx, "Failed to abort MinIO multipart upload", map[string]any{"key": key, "uploadId": uploadID, "error": err})
+		return fmt.Errorf("failed to abort MinIO multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "MinIO multipart upload aborted", map[string]any{"key": key, "uploadId": uploadID})
+	return nil
+}
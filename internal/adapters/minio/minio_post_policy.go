@@ -0,0 +1,56 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.PresignedPostProvider = (*minioProvider)(nil)
+
+// GetPresignedPostPolicy builds a presigned POST form for key with MinIO's PostPolicy.
+func (p *minioProvider) GetPresignedPostPolicy(ctx context.Context, key string, conditions port.PostConditions) (*port.PostPolicy, error) {
+	expiresAt := time.Now().UTC().Add(conditions.Expires)
+
+	policy := minio.NewPostPolicy()
+	if err := policy.SetBucket(p.bucketName); err != nil {
+		return nil, fmt.Errorf("invalid MinIO post policy bucket: %w", err)
+	}
+	if err := policy.SetKey(key); err != nil {
+		return nil, fmt.Errorf("invalid MinIO post policy key %s: %w", key, err)
+	}
+	if err := policy.SetExpires(expiresAt); err != nil {
+		return nil, fmt.Errorf("invalid MinIO post policy expiry: %w", err)
+	}
+	if conditions.MaxContentLength > 0 {
+		if err := policy.SetContentLengthRange(conditions.MinContentLength, conditions.MaxContentLength); err != nil {
+			return nil, fmt.Errorf("invalid MinIO post policy content length range: %w", err)
+		}
+	}
+	switch {
+	case conditions.ContentType != "":
+		if err := policy.SetContentType(conditions.ContentType); err != nil {
+			return nil, fmt.Errorf("invalid MinIO post policy content type: %w", err)
+		}
+	case conditions.ContentTypePrefix != "":
+		if err := policy.SetContentTypeStartsWith(conditions.ContentTypePrefix); err != nil {
+			return nil, fmt.Errorf("invalid MinIO post policy content type prefix: %w", err)
+		}
+	}
+
+	postURL, formData, err := p.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate MinIO post policy", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to generate MinIO post policy for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated MinIO post policy", map[string]any{"key": key, "expires": conditions.Expires})
+	return &port.PostPolicy{
+		URL:       postURL.String(),
+		Fields:    formData,
+		ExpiresAt: expiresAt,
+	}, nil
+}
@@ -182,6 +182,9 @@ func (p *minioProvider) Upload(ctx context.Context, key string, reader io.Reader
 	if key == "" {
 		return nil, fmt.Errorf("upload key cannot be empty")
 	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Ignoring server-side encryption options for MinIO upload", map[string]any{"key": key})
+	}
 
 	contentType := ""
 	if opts != nil && opts.ContentType != "" {
@@ -208,7 +211,7 @@ func (p *minioProvider) Upload(ctx context.Context, key string, reader io.Reader
 
 	p.logger.Infof(ctx, "Attempting to upload file to MinIO", map[string]any{"key": key, "bucket": p.bucketName, "contentType": contentType})
 
-	info, err := p.client.PutObject(ctx, p.bucketName, key, reader, size, putObjectOpts)
+	info, err := p.client.PutObject(ctx, p.bucketName, key, opts.TrackProgress(reader, size), size, putObjectOpts)
 	if err != nil {
 		p.logger.Errorf(ctx, "Failed to upload file to MinIO", map[string]any{"key": key, "error": err})
 		return nil, fmt.Errorf("failed to upload to MinIO key %s: %w", key, err)
@@ -237,18 +240,29 @@ func (p *minioProvider) generateObjectURL(ctx context.Context, key string) strin
 
 // GetURL returns a publicly accessible URL for the given key.
 func (p *minioProvider) GetURL(ctx context.Context, key string) (string, error) {
-	// First check if object exists
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "Object not found, cannot get URL", map[string]any{"key": key})
+		return "", fmt.Errorf("minio object %s %w", key, port.ErrObjectNotFound)
+	}
+	return p.generateObjectURL(ctx, key), nil
+}
+
+// Exists checks for an object with StatObject.
+func (p *minioProvider) Exists(ctx context.Context, key string) (bool, error) {
 	_, err := p.client.StatObject(ctx, p.bucketName, key, minio.StatObjectOptions{})
 	if err != nil {
 		errResponse := minio.ToErrorResponse(err)
 		if errResponse.Code == "NoSuchKey" || errResponse.Code == "NotFound" {
-			p.logger.Warnf(ctx, "Object not found, cannot get URL", map[string]any{"key": key})
-			return "", fmt.Errorf("object %s not found: %w", key, err)
+			return false, nil
 		}
-		p.logger.Errorf(ctx, "Failed to check object existence for GetURL", map[string]any{"key": key, "error": err})
-		return "", fmt.Errorf("failed to check object %s: %w", key, err)
+		p.logger.Errorf(ctx, "Failed to check object existence", map[string]any{"key": key, "error": err})
+		return false, fmt.Errorf("failed to check object %s: %w", key, err)
 	}
-	return p.generateObjectURL(ctx, key), nil
+	return true, nil
 }
 
 // GetSignedURL generates a time-limited signed URL for accessing a private object.
@@ -263,6 +277,20 @@ func (p *minioProvider) GetSignedURL(ctx context.Context, key string, duration t
 	return presignedURL.String(), nil
 }
 
+var _ port.PresignedUploadProvider = (*minioProvider)(nil)
+
+// GetSignedUploadURL generates a time-limited URL for uploading an object with a PUT request.
+// MinIO does not bind the content type to the signature, so opts is not enforced.
+func (p *minioProvider) GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *port.UploadOptions) (string, error) {
+	presignedURL, err := p.client.PresignedPutObject(ctx, p.bucketName, key, duration)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate MinIO signed upload URL", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to generate MinIO signed upload URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated MinIO signed upload URL", map[string]any{"key": key, "duration": duration})
+	return presignedURL.String(), nil
+}
+
 // Delete removes a file from MinIO.
 func (p *minioProvider) Delete(ctx context.Context, key string) error {
 	err := p.client.RemoveObject(ctx, p.bucketName, key, minio.RemoveObjectOptions{})
@@ -281,7 +309,7 @@ func (p *minioProvider) GetObject(ctx context.Context, key string) (*port.FileOb
 		errResponse := minio.ToErrorResponse(err)
 		if errResponse.Code == "NoSuchKey" || errResponse.Code == "NotFound" {
 			p.logger.Warnf(ctx, "MinIO object not found for GetObject", map[string]any{"key": key})
-			return nil, fmt.Errorf("minio object %s not found: %w", key, err)
+			return nil, fmt.Errorf("minio object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to get MinIO object info for GetObject", map[string]any{"key": key, "error": err})
 		return nil, fmt.Errorf("failed to get MinIO object metadata for %s: %w", key, err)
@@ -305,7 +333,7 @@ func (p *minioProvider) Download(ctx context.Context, key string) (io.ReadCloser
 		errResponse := minio.ToErrorResponse(err)
 		if errResponse.Code == "NoSuchKey" || errResponse.Code == "NotFound" {
 			p.logger.Warnf(ctx, "MinIO object not found for Download", map[string]any{"key": key})
-			return nil, nil, fmt.Errorf("minio object %s not found for download: %w", key, err)
+			return nil, nil, fmt.Errorf("minio object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to get MinIO object for Download", map[string]any{"key": key, "error": err})
 		return nil, nil, fmt.Errorf("failed to get MinIO object %s for download: %w", key, err)
@@ -332,6 +360,87 @@ func (p *minioProvider) Download(ctx context.Context, key string) (io.ReadCloser
 	return object, fileObject, nil
 }
 
+// DownloadRange downloads a byte range of a file from MinIO using the Range header.
+func (p *minioProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	byteRange, err := port.HTTPRange(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts := minio.GetObjectOptions{}
+	opts.Set("Range", byteRange)
+	object, err := p.client.GetObject(ctx, p.bucketName, key, opts)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to get MinIO object range for Download", map[string]any{"key": key, "range": byteRange, "error": err})
+		return nil, nil, fmt.Errorf("failed to get MinIO object %s range %s: %w", key, byteRange, err)
+	}
+	return object, fileObject, nil
+}
+
+// Copy duplicates an object inside the bucket with a server-side CopyObject.
+func (p *minioProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	_, err := p.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: p.bucketName, Object: dstKey},
+		minio.CopySrcOptions{Bucket: p.bucketName, Object: srcKey},
+	)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to copy MinIO object", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, fmt.Errorf("failed to copy MinIO object %s to %s: %w", srcKey, dstKey, err)
+	}
+	p.logger.Infof(ctx, "MinIO object copied successfully", map[string]any{"srcKey": srcKey, "dstKey": dstKey})
+	return p.GetObject(ctx, dstKey)
+}
+
+// Move copies the object server-side and then deletes the source.
+func (p *minioProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists one page of objects under prefix. MinIO streams results over a
+// channel, so the continuation token is the last key returned and listing resumes after it.
+func (p *minioProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	maxKeys := opts.MaxKeysOrDefault()
+
+	// Stop the listing goroutine once the page is full.
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	objects := make([]*port.FileObject, 0, maxKeys)
+	hasMore := false
+	for info := range p.client.ListObjects(listCtx, p.bucketName, minio.ListObjectsOptions{
+		Prefix:     prefix,
+		Recursive:  true,
+		StartAfter: opts.Token(),
+	}) {
+		if info.Err != nil {
+			p.logger.Errorf(ctx, "Failed to list MinIO objects", map[string]any{"prefix": prefix, "error": info.Err})
+			return nil, "", fmt.Errorf("failed to list MinIO objects with prefix %s: %w", prefix, info.Err)
+		}
+		if len(objects) == maxKeys {
+			hasMore = true
+			break
+		}
+		objects = append(objects, &port.FileObject{
+			Key:          info.Key,
+			URL:          p.generateObjectURL(ctx, info.Key),
+			Size:         info.Size,
+			ContentType:  info.ContentType,
+			LastModified: info.LastModified,
+			ETag:         strings.Trim(info.ETag, "\""),
+			Provider:     p.ProviderType(),
+		})
+	}
+
+	nextToken := ""
+	if hasMore {
+		nextToken = objects[len(objects)-1].Key
+	}
+	return objects, nextToken, nil
+}
+
 // CheckHealth checks if the MinIO storage provider is healthy and accessible.
 func (p *minioProvider) CheckHealth(ctx context.Context) error {
 	// First, check if we can list buckets (basic connectivity test)
@@ -0,0 +1,42 @@
+package minio
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.TaggableProvider = (*minioProvider)(nil)
+
+// SetTags replaces the object's tag set with PutObjectTagging.
+func (p *minioProvider) SetTags(ctx context.Context, key string, objectTags map[string]string) error {
+	tagSet, err := tags.NewTags(objectTags, true)
+	if err != nil {
+		return fmt.Errorf("invalid tags for MinIO object %s: %w", key, err)
+	}
+	if err := p.client.PutObjectTagging(ctx, p.bucketName, key, tagSet, minio.PutObjectTaggingOptions{}); err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return fmt.Errorf("minio object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to tag MinIO object", map[string]any{"key": key, "error": err})
+		return fmt.Errorf("failed to tag MinIO object %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetTags returns the object's tag set with GetObjectTagging.
+func (p *minioProvider) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	tagSet, err := p.client.GetObjectTagging(ctx, p.bucketName, key, minio.GetObjectTaggingOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("minio object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get MinIO object tags", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to get tags of MinIO object %s: %w", key, err)
+	}
+	return tagSet.ToMap(), nil
+}
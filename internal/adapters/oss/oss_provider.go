@@ -0,0 +1,330 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+
+	logger "github.com/lugondev/go-log"
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// ossProvider implements the port.StorageProvider interface for Alibaba Cloud OSS.
+type ossProvider struct {
+	client     *oss.Client
+	bucket     *oss.Bucket
+	bucketName string
+	publicBase string // scheme://<bucket>.<endpoint host>, used for public object URLs
+	logger     logger.Logger
+}
+
+// NewOSSProvider creates a new instance of ossProvider.
+func NewOSSProvider(cfg config.OSSConfig, log logger.Logger) (port.StorageProvider, error) {
+	log = log.WithFields(map[string]any{"component": "OSSProvider"})
+
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required for OSSProvider")
+	}
+	if cfg.BucketName == "" {
+		return nil, fmt.Errorf("bucket_name is required for OSSProvider")
+	}
+	if cfg.AccessKeyID == "" {
+		return nil, fmt.Errorf("access_key_id is required for OSSProvider")
+	}
+	if cfg.AccessKeySecret == "" {
+		return nil, fmt.Errorf("access_key_secret is required for OSSProvider")
+	}
+
+	endpoint := cfg.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	parsedURL, err := url.Parse(endpoint)
+	if err != nil || parsedURL.Host == "" {
+		log.Errorf(context.Background(), "Invalid OSS endpoint", map[string]any{"endpoint": cfg.Endpoint, "error": err})
+		return nil, fmt.Errorf("invalid OSS endpoint %q", cfg.Endpoint)
+	}
+
+	client, err := oss.New(endpoint, cfg.AccessKeyID, cfg.AccessKeySecret)
+	if err != nil {
+		log.Errorf(context.Background(), "Failed to create OSS client", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to create OSS client: %w", err)
+	}
+	bucket, err := client.Bucket(cfg.BucketName)
+	if err != nil {
+		log.Errorf(context.Background(), "Failed to get OSS bucket handle", map[string]any{"bucket": cfg.BucketName, "error": err})
+		return nil, fmt.Errorf("failed to get OSS bucket handle for %s: %w", cfg.BucketName, err)
+	}
+
+	log.Infof(context.Background(), "OSSProvider initialized", map[string]any{"bucket": cfg.BucketName, "endpoint": endpoint})
+
+	return &ossProvider{
+		client:     client,
+		bucket:     bucket,
+		bucketName: cfg.BucketName,
+		publicBase: fmt.Sprintf("%s://%s.%s", parsedURL.Scheme, cfg.BucketName, parsedURL.Host),
+		logger:     log,
+	}, nil
+}
+
+// isNotFound reports whether err is an OSS 404 response.
+func isNotFound(err error) bool {
+	var serviceErr oss.ServiceError
+	return errors.As(err, &serviceErr) && serviceErr.StatusCode == http.StatusNotFound
+}
+
+// generateObjectURL builds the virtual-hosted URL of an object. It is only
+// reachable without a signature when the bucket or object is public-read.
+func (p *ossProvider) generateObjectURL(key string) string {
+	return p.publicBase + "/" + strings.TrimPrefix((&url.URL{Path: key}).EscapedPath(), "/")
+}
+
+// fileObjectFromHeader maps the response headers of a HEAD or GET request to a FileObject.
+func (p *ossProvider) fileObjectFromHeader(key string, header http.Header) *port.FileObject {
+	size, _ := strconv.ParseInt(header.Get(oss.HTTPHeaderContentLength), 10, 64)
+	lastModified, _ := http.ParseTime(header.Get(oss.HTTPHeaderLastModified))
+	return &port.FileObject{
+		Key:          key,
+		URL:          p.generateObjectURL(key),
+		Size:         size,
+		ContentType:  header.Get(oss.HTTPHeaderContentType),
+		LastModified: lastModified,
+		ETag:         strings.Trim(header.Get(oss.HTTPHeaderEtag), "\""),
+		Provider:     p.ProviderType(),
+	}
+}
+
+// Upload uploads a file to OSS with PutObject.
+func (p *ossProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
+	if key == "" {
+		return nil, fmt.Errorf("upload key cannot be empty")
+	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Ignoring server-side encryption options for OSS upload", map[string]any{"key": key})
+	}
+
+	contentType := ""
+	if opts != nil && opts.ContentType != "" {
+		contentType = opts.ContentType
+	} else if ext := filepath.Ext(key); ext != "" {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream" // Default
+	}
+
+	options := []oss.Option{oss.WithContext(ctx), oss.ContentType(contentType)}
+	if size >= 0 {
+		options = append(options, oss.ContentLength(size))
+	}
+	if opts != nil {
+		for k, v := range opts.Metadata {
+			options = append(options, oss.Meta(k, v))
+		}
+		if opts.ACL != "" {
+			options = append(options, oss.ObjectACL(oss.ACLType(opts.ACL)))
+		}
+	}
+
+	p.logger.Infof(ctx, "Attempting to upload file to OSS", map[string]any{"key": key, "bucket": p.bucketName, "contentType": contentType})
+
+	if err := p.bucket.PutObject(key, opts.TrackProgress(reader, size), options...); err != nil {
+		p.logger.Errorf(ctx, "Failed to upload file to OSS", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to upload to OSS key %s: %w", key, err)
+	}
+
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Infof(ctx, "File uploaded successfully to OSS", map[string]any{"key": key, "size": fileObject.Size, "etag": fileObject.ETag})
+	return fileObject, nil
+}
+
+// GetURL returns a publicly accessible URL for the given key.
+func (p *ossProvider) GetURL(ctx context.Context, key string) (string, error) {
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "Object not found, cannot get URL", map[string]any{"key": key})
+		return "", fmt.Errorf("oss object %s %w", key, port.ErrObjectNotFound)
+	}
+	return p.generateObjectURL(key), nil
+}
+
+// Exists checks for an object with a HEAD request.
+func (p *ossProvider) Exists(ctx context.Context, key string) (bool, error) {
+	exists, err := p.bucket.IsObjectExist(key, oss.WithContext(ctx))
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to check object existence", map[string]any{"key": key, "error": err})
+		return false, fmt.Errorf("failed to check object %s: %w", key, err)
+	}
+	return exists, nil
+}
+
+// GetSignedURL generates a time-limited signed URL for accessing a private object.
+func (p *ossProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
+	signedURL, err := p.bucket.SignURL(key, oss.HTTPGet, int64(duration.Seconds()))
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate OSS signed URL", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to generate OSS signed URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated OSS signed URL", map[string]any{"key": key, "duration": duration})
+	return signedURL, nil
+}
+
+var _ port.PresignedUploadProvider = (*ossProvider)(nil)
+
+// GetSignedUploadURL generates a time-limited URL for uploading an object with a PUT request.
+// When opts sets a content type, the client must send the same Content-Type header.
+func (p *ossProvider) GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *port.UploadOptions) (string, error) {
+	var options []oss.Option
+	if opts != nil && opts.ContentType != "" {
+		options = append(options, oss.ContentType(opts.ContentType))
+	}
+	signedURL, err := p.bucket.SignURL(key, oss.HTTPPut, int64(duration.Seconds()), options...)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate OSS signed upload URL", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to generate OSS signed upload URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated OSS signed upload URL", map[string]any{"key": key, "duration": duration})
+	return signedURL, nil
+}
+
+// Delete removes a file from OSS. Deleting a missing object succeeds.
+func (p *ossProvider) Delete(ctx context.Context, key string) error {
+	if err := p.bucket.DeleteObject(key, oss.WithContext(ctx)); err != nil {
+		p.logger.Errorf(ctx, "Failed to delete OSS object", map[string]any{"key": key, "error": err})
+		return fmt.Errorf("failed to delete OSS object %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "OSS object deleted successfully", map[string]any{"key": key})
+	return nil
+}
+
+// GetObject retrieves file information (metadata) with GetObjectDetailedMeta.
+func (p *ossProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
+	header, err := p.bucket.GetObjectDetailedMeta(key, oss.WithContext(ctx))
+	if err != nil {
+		if isNotFound(err) {
+			p.logger.Warnf(ctx, "OSS object not found for GetObject", map[string]any{"key": key})
+			return nil, fmt.Errorf("oss object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get OSS object metadata", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to get OSS object metadata for %s: %w", key, err)
+	}
+	return p.fileObjectFromHeader(key, header), nil
+}
+
+// Download downloads a file from OSS.
+func (p *ossProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
+	result, err := p.bucket.DoGetObject(&oss.GetObjectRequest{ObjectKey: key}, []oss.Option{oss.WithContext(ctx)})
+	if err != nil {
+		if isNotFound(err) {
+			p.logger.Warnf(ctx, "OSS object not found for Download", map[string]any{"key": key})
+			return nil, nil, fmt.Errorf("oss object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get OSS object for Download", map[string]any{"key": key, "error": err})
+		return nil, nil, fmt.Errorf("failed to get OSS object %s for download: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Prepared OSS file for download", map[string]any{"key": key})
+	return result.Response.Body, p.fileObjectFromHeader(key, result.Response.Headers), nil
+}
+
+// DownloadRange downloads a byte range of a file from OSS using the Range header.
+func (p *ossProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	byteRange, err := port.HTTPRange(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := p.bucket.GetObject(key, oss.WithContext(ctx), oss.NormalizedRange(strings.TrimPrefix(byteRange, "bytes=")))
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to get OSS object range for Download", map[string]any{"key": key, "range": byteRange, "error": err})
+		return nil, nil, fmt.Errorf("failed to get OSS object %s range %s: %w", key, byteRange, err)
+	}
+	return body, fileObject, nil
+}
+
+// Copy duplicates an object inside the bucket with a server-side CopyObject.
+func (p *ossProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	if _, err := p.bucket.CopyObject(srcKey, dstKey, oss.WithContext(ctx)); err != nil {
+		if isNotFound(err) {
+			return nil, fmt.Errorf("oss object %s %w", srcKey, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to copy OSS object", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, fmt.Errorf("failed to copy OSS object %s to %s: %w", srcKey, dstKey, err)
+	}
+	p.logger.Infof(ctx, "OSS object copied successfully", map[string]any{"srcKey": srcKey, "dstKey": dstKey})
+	return p.GetObject(ctx, dstKey)
+}
+
+// Move copies the object server-side and then deletes the source.
+func (p *ossProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists one page of objects under prefix with ListObjectsV2.
+func (p *ossProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	options := []oss.Option{oss.WithContext(ctx), oss.Prefix(prefix), oss.MaxKeys(opts.MaxKeysOrDefault())}
+	if token := opts.Token(); token != "" {
+		options = append(options, oss.ContinuationToken(token))
+	}
+	result, err := p.bucket.ListObjectsV2(options...)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to list OSS objects", map[string]any{"prefix": prefix, "error": err})
+		return nil, "", fmt.Errorf("failed to list OSS objects with prefix %s: %w", prefix, err)
+	}
+
+	objects := make([]*port.FileObject, 0, len(result.Objects))
+	for _, object := range result.Objects {
+		objects = append(objects, &port.FileObject{
+			Key:          object.Key,
+			URL:          p.generateObjectURL(object.Key),
+			Size:         object.Size,
+			LastModified: object.LastModified,
+			ETag:         strings.Trim(object.ETag, "\""),
+			Provider:     p.ProviderType(),
+		})
+	}
+
+	nextToken := ""
+	if result.IsTruncated {
+		nextToken = result.NextContinuationToken
+	}
+	return objects, nextToken, nil
+}
+
+// CheckHealth checks that the configured bucket exists and is reachable.
+func (p *ossProvider) CheckHealth(ctx context.Context) error {
+	exists, err := p.client.IsBucketExist(p.bucketName)
+	if err != nil {
+		p.logger.Errorf(ctx, "OSS health check failed - cannot check bucket existence", map[string]any{"error": err, "bucket": p.bucketName})
+		return fmt.Errorf("oss health check failed - cannot check bucket '%s' existence: %w", p.bucketName, err)
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "OSS bucket does not exist", map[string]any{"bucket": p.bucketName})
+		return fmt.Errorf("oss bucket '%s' does not exist", p.bucketName)
+	}
+	return nil
+}
+
+// ProviderType returns the type of the storage provider.
+func (p *ossProvider) ProviderType() port.StorageProviderType {
+	return port.ProviderOSS
+}
@@ -0,0 +1,51 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.BatchDeleteProvider = (*s3Provider)(nil)
+
+// maxDeleteObjectsKeys is the most keys a single DeleteObjects request accepts.
+const maxDeleteObjectsKeys = 1000
+
+// DeleteMany removes keys with DeleteObjects in quiet mode, which only reports failures.
+func (p *s3Provider) DeleteMany(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for start := 0; start < len(keys); start += maxDeleteObjectsKeys {
+		chunk := keys[start:min(start+maxDeleteObjectsKeys, len(keys))]
+		objects := make([]types.ObjectIdentifier, len(chunk))
+		for i, key := range chunk {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		output, err := p.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(p.bucketName),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			p.logger.Errorf(ctx, "Failed to delete S3 objects", map[string]any{"count": len(chunk), "error": err})
+			if start == 0 {
+				return nil, fmt.Errorf("failed to delete S3 objects: %w", err)
+			}
+			// Earlier chunks are gone already; report this chunk per key
+			for _, key := range chunk {
+				failed[key] = fmt.Errorf("failed to delete S3 object %s: %w", key, err)
+			}
+			continue
+		}
+		for _, objectErr := range output.Errors {
+			key := aws.ToString(objectErr.Key)
+			failed[key] = fmt.Errorf("failed to delete S3 object %s: %s: %s", key, aws.ToString(objectErr.Code), aws.ToString(objectErr.Message))
+		}
+	}
+	p.logger.Infof(ctx, "S3 objects deleted", map[string]any{"count": len(keys), "failed": len(failed)})
+	return failed, nil
+}
@@ -0,0 +1,122 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.MultipartProvider = (*s3Provider)(nil)
+
+// InitiateMultipartUpload starts a multipart upload with CreateMultipartUpload.
+func (p *s3Provider) InitiateMultipartUpload(ctx context.Context, key string, opts *port.UploadOptions) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("upload key cannot be empty")
+	}
+
+	contentType := ""
+	if opts != nil && opts.ContentType != "" {
+		contentType = opts.ContentType
+	} else if ext := filepath.Ext(key); ext != "" {
+		contentType = mime.TypeByExtension(ext)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(p.bucketName),
+		Key:         aws.String(key),
+		ContentType: aws.String(contentType),
+	}
+	input.ServerSideEncryption, input.SSEKMSKeyId = p.encryption(opts)
+	if opts != nil {
+		storageClass, err := parseStorageClass(opts.StorageClass)
+		if err != nil {
+			return "", err
+		}
+		input.StorageClass = storageClass
+		if opts.ACL != "" {
+			input.ACL = types.ObjectCannedACL(opts.ACL)
+		}
+		if opts.Metadata != nil {
+			input.Metadata = opts.Metadata
+		}
+	}
+
+	output, err := p.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to initiate S3 multipart upload", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to initiate S3 multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "S3 multipart upload initiated", map[string]any{"key": key, "uploadId": aws.ToString(output.UploadId)})
+	return aws.ToString(output.UploadId), nil
+}
+
+// UploadPart uploads one part of a multipart upload.
+func (p *s3Provider) UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (*port.CompletedPart, error) {
+	output, err := p.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(p.bucketName),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(int32(partNumber)),
+		Body:          reader,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to upload S3 part", map[string]any{"key": key, "uploadId": uploadID, "partNumber": partNumber, "error": err})
+		return nil, fmt.Errorf("failed to upload part %d of S3 key %s: %w", partNumber, key, err)
+	}
+	return &port.CompletedPart{
+		PartNumber: partNumber,
+		ETag:       strings.Trim(aws.ToString(output.ETag), "\""),
+	}, nil
+}
+
+// CompleteMultipartUpload assembles the uploaded parts into the final object.
+func (p *s3Provider) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []port.CompletedPart) (*port.FileObject, error) {
+	completed := make([]types.CompletedPart, 0, len(parts))
+	for _, part := range parts {
+		completed = append(completed, types.CompletedPart{
+			PartNumber: aws.Int32(int32(part.PartNumber)),
+			ETag:       aws.String("\"" + part.ETag + "\""),
+		})
+	}
+
+	_, err := p.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(p.bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to complete S3 multipart upload", map[string]any{"key": key, "uploadId": uploadID, "error": err})
+		return nil, fmt.Errorf("failed to complete S3 multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "S3 multipart upload completed", map[string]any{"key": key, "uploadId": uploadID, "parts": len(parts)})
+	return p.GetObject(ctx, key)
+}
+
+// AbortMultipartUpload discards a multipart upload and its stored parts.
+func (p *s3Provider) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := p.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(p.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to abort S3 multipart upload", map[string]any{"key": key, "uploadId": uploadID, "error": err})
+		return fmt.Errorf("failed to abort S3 multipart upload for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "S3 multipart upload aborted", map[string]any{"key": key, "uploadId": uploadID})
+	return nil
+}
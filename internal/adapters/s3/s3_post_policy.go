@@ -0,0 +1,100 @@
+package s3
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.PresignedPostProvider = (*s3Provider)(nil)
+
+const (
+	s3PostAlgorithm = "AWS4-HMAC-SHA256"
+	// s3DefaultSigningRegion is used when the provider has no region, which S3-compatible
+	// services generally accept.
+	s3DefaultSigningRegion = "us-east-1"
+)
+
+// GetPresignedPostPolicy builds a SigV4-signed POST policy for key. The AWS SDK has no
+// POST policy support, so the policy document is assembled and signed here.
+func (p *s3Provider) GetPresignedPostPolicy(ctx context.Context, key string, conditions port.PostConditions) (*port.PostPolicy, error) {
+	creds, err := p.client.Options().Credentials.Retrieve(ctx)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to retrieve S3 credentials for post policy", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to retrieve S3 credentials: %w", err)
+	}
+	region := p.client.Options().Region
+	if region == "" {
+		region = s3DefaultSigningRegion
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(conditions.Expires)
+	date := now.Format("20060102")
+	amzDate := now.Format("20060102T150405Z")
+	credential := fmt.Sprintf("%s/%s/%s/s3/aws4_request", creds.AccessKeyID, date, region)
+
+	fields := map[string]string{
+		"key":              key,
+		"x-amz-algorithm":  s3PostAlgorithm,
+		"x-amz-credential": credential,
+		"x-amz-date":       amzDate,
+	}
+	policyConditions := []any{
+		map[string]string{"bucket": p.bucketName},
+		[]string{"eq", "$key", key},
+		map[string]string{"x-amz-algorithm": s3PostAlgorithm},
+		map[string]string{"x-amz-credential": credential},
+		map[string]string{"x-amz-date": amzDate},
+	}
+	if creds.SessionToken != "" {
+		fields["x-amz-security-token"] = creds.SessionToken
+		policyConditions = append(policyConditions, map[string]string{"x-amz-security-token": creds.SessionToken})
+	}
+	if conditions.MaxContentLength > 0 {
+		policyConditions = append(policyConditions, []any{"content-length-range", conditions.MinContentLength, conditions.MaxContentLength})
+	}
+	switch {
+	case conditions.ContentType != "":
+		fields["Content-Type"] = conditions.ContentType
+		policyConditions = append(policyConditions, []string{"eq", "$Content-Type", conditions.ContentType})
+	case conditions.ContentTypePrefix != "":
+		policyConditions = append(policyConditions, []string{"starts-with", "$Content-Type", conditions.ContentTypePrefix})
+	}
+
+	document, err := json.Marshal(map[string]any{
+		"expiration": expiresAt.Format("2006-01-02T15:04:05.000Z"),
+		"conditions": policyConditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode S3 post policy: %w", err)
+	}
+	policy := base64.StdEncoding.EncodeToString(document)
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	fields["policy"] = policy
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSHA256(signingKey, policy))
+
+	p.logger.Infof(ctx, "Generated S3 post policy", map[string]any{"key": key, "expires": conditions.Expires})
+	return &port.PostPolicy{
+		URL:       p.generateObjectURL(ctx, ""),
+		Fields:    fields,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
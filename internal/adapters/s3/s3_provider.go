@@ -14,9 +14,11 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awsConfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	logger "github.com/lugondev/go-log"
 	"github.com/lugondev/m3-storage/internal/infra/config"
@@ -30,8 +32,12 @@ type s3Provider struct {
 	uploader       *manager.Uploader
 	bucketName     string
 	region         string
-	endpointURL    string // Optional: for S3-compatible services like MinIO or Cloudflare R2
-	forcePathStyle bool   // Optional: for S3-compatible services
+	endpointURL    string                   // Optional: for S3-compatible services like MinIO or Cloudflare R2
+	forcePathStyle bool                     // Optional: for S3-compatible services
+	sse            string                   // Default server-side encryption for uploads
+	sseKMSKeyID    string                   // Default KMS key for "aws:kms" encryption
+	publicBaseURL  string                   // Optional: base URL (e.g. a CDN) for object URLs
+	providerType   port.StorageProviderType // Explicit provider type from the config; guessed from the endpoint when empty
 	logger         logger.Logger
 }
 
@@ -39,36 +45,37 @@ type s3Provider struct {
 func NewS3Provider(cfg config.S3Config, log logger.Logger) (port.StorageProvider, error) {
 	log = log.WithFields(map[string]any{"component": "S3Provider"})
 
-	if cfg.Endpoint == "" {
-		return nil, fmt.Errorf("endpoint is required for S3Provider")
+	if cfg.Endpoint == "" && cfg.Region == "" {
+		return nil, fmt.Errorf("endpoint or region is required for S3Provider")
 	}
 	if cfg.BucketName == "" {
 		return nil, fmt.Errorf("bucket_name is required for S3Provider")
 	}
-	if cfg.AccessKeyID == "" {
-		return nil, fmt.Errorf("access_key_id is required for S3Provider")
+	providerType := port.StorageProviderType(cfg.ProviderLabel)
+	if providerType != "" && !port.IsSupportedProviderType(providerType) {
+		return nil, fmt.Errorf("provider_label %q is not a supported provider type", cfg.ProviderLabel)
 	}
-	if cfg.SecretAccessKey == "" {
-		return nil, fmt.Errorf("secret_access_key is required for S3Provider")
+	if (cfg.AccessKeyID == "") != (cfg.SecretAccessKey == "") {
+		return nil, fmt.Errorf("access_key_id and secret_access_key must be set together for S3Provider")
+	}
+	if cfg.AccessKeyID == "" && cfg.Endpoint != "" && cfg.AssumeRoleARN == "" {
+		// The default chain only yields AWS credentials, which S3-compatible services reject
+		return nil, fmt.Errorf("access_key_id is required for S3Provider with a custom endpoint")
 	}
 
 	// Use config struct fields
 	bucketName := cfg.BucketName
 	region := cfg.Region
-	accessKeyID := cfg.AccessKeyID
-	secretAccessKey := cfg.SecretAccessKey
 	endpointURL := cfg.Endpoint
 	forcePathStyle := cfg.ForcePathStyle
 
-	if region == "" && endpointURL == "" {
-		// Region is typically required for AWS S3.
-		// For S3-compatible services, if endpoint is given, region might be dummy or specific to that service.
-		log.Warn(context.Background(), "S3 region is not set and no custom endpoint provided. This might lead to issues.", nil)
-		// return nil, fmt.Errorf("region is required for S3Provider if endpoint is not specified")
-	}
-
-	cfgLoadOpts := []func(*awsConfig.LoadOptions) error{
-		awsConfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")),
+	var cfgLoadOpts []func(*awsConfig.LoadOptions) error
+	credentialSource := "default chain"
+	if cfg.AccessKeyID != "" {
+		// Static keys, as S3-compatible services need; without them the default AWS chain
+		// (environment, shared config, IRSA and instance or task roles) is used
+		cfgLoadOpts = append(cfgLoadOpts, awsConfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AccessKeyID, cfg.SecretAccessKey, "")))
+		credentialSource = "static"
 	}
 	if region != "" {
 		cfgLoadOpts = append(cfgLoadOpts, awsConfig.WithRegion(region))
@@ -84,8 +91,21 @@ func NewS3Provider(cfg config.S3Config, log logger.Logger) (port.StorageProvider
 		log.Errorf(context.Background(), "Failed to load AWS SDK config", map[string]any{"error": err})
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
-	if strings.Contains(endpointURL, "backblaze") {
-		// Backblaze B2 S3-compatible storage requires specific settings
+	if cfg.AssumeRoleARN != "" {
+		// STS must be reached on its own endpoint, not the custom S3 one
+		stsCfg := awsCfg.Copy()
+		stsCfg.BaseEndpoint = nil
+		roleProvider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(stsCfg), cfg.AssumeRoleARN, func(o *stscreds.AssumeRoleOptions) {
+			if cfg.AssumeRoleExternalID != "" {
+				o.ExternalID = aws.String(cfg.AssumeRoleExternalID)
+			}
+		})
+		awsCfg.Credentials = aws.NewCredentialsCache(roleProvider)
+		credentialSource = "assumed role (" + credentialSource + ")"
+	}
+	if providerType == port.ProviderBackBlaze || providerType == port.ProviderWasabi ||
+		strings.Contains(endpointURL, "backblaze") || strings.Contains(endpointURL, "wasabisys.com") {
+		// Backblaze B2 and Wasabi reject the SDK's default request checksums
 		awsCfg.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
 		awsCfg.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
 	}
@@ -98,7 +118,7 @@ func NewS3Provider(cfg config.S3Config, log logger.Logger) (port.StorageProvider
 	presignClient := s3.NewPresignClient(s3Client)
 	uploader := manager.NewUploader(s3Client)
 
-	log.Infof(context.Background(), "S3Provider initialized", map[string]any{"bucket": bucketName, "region": region, "endpoint": endpointURL})
+	log.Infof(context.Background(), "S3Provider initialized", map[string]any{"bucket": bucketName, "region": region, "endpoint": endpointURL, "credentials": credentialSource})
 	return &s3Provider{
 		client:         s3Client,
 		presignClient:  presignClient,
@@ -107,10 +127,30 @@ func NewS3Provider(cfg config.S3Config, log logger.Logger) (port.StorageProvider
 		region:         region,
 		endpointURL:    endpointURL,
 		forcePathStyle: forcePathStyle,
+		sse:            cfg.ServerSideEncryption,
+		sseKMSKeyID:    cfg.SSEKMSKeyID,
+		publicBaseURL:  strings.TrimSuffix(cfg.PublicBaseURL, "/"),
+		providerType:   providerType,
 		logger:         log,
 	}, nil
 }
 
+// encryption returns the server-side encryption settings for an upload: those requested
+// in opts, or the bucket defaults from the config when opts does not ask for encryption.
+func (p *s3Provider) encryption(opts *port.UploadOptions) (types.ServerSideEncryption, *string) {
+	algorithm, kmsKeyID := p.sse, p.sseKMSKeyID
+	if opts.RequestsEncryption() {
+		algorithm, kmsKeyID = opts.ServerSideEncryption, opts.SSEKMSKeyID
+	}
+	if kmsKeyID != "" && algorithm == "" {
+		algorithm = string(types.ServerSideEncryptionAwsKms) // A KMS key implies KMS encryption
+	}
+	if kmsKeyID == "" {
+		return types.ServerSideEncryption(algorithm), nil
+	}
+	return types.ServerSideEncryption(algorithm), aws.String(kmsKeyID)
+}
+
 // Upload uploads a file to S3.
 func (p *s3Provider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
 	if key == "" {
@@ -133,12 +173,18 @@ func (p *s3Provider) Upload(ctx context.Context, key string, reader io.Reader, s
 	uploadInput := &s3.PutObjectInput{
 		Bucket:      aws.String(p.bucketName),
 		Key:         aws.String(key),
-		Body:        reader,
+		Body:        opts.TrackProgress(reader, size),
 		ContentType: aws.String(contentType),
 		// ContentLength: aws.Int64(size), // manager.Uploader handles this, but can be set.
 	}
+	uploadInput.ServerSideEncryption, uploadInput.SSEKMSKeyId = p.encryption(opts)
 
 	if opts != nil {
+		storageClass, err := parseStorageClass(opts.StorageClass)
+		if err != nil {
+			return nil, err
+		}
+		uploadInput.StorageClass = storageClass
 		if opts.ACL != "" {
 			uploadInput.ACL = types.ObjectCannedACL(opts.ACL)
 		}
@@ -186,6 +232,10 @@ func (p *s3Provider) generateObjectURL(ctx context.Context, key string) string {
 	// Standard S3 URL format: https://<bucket-name>.s3.<region>.amazonaws.com/<key>
 	// Or path-style: https://s3.<region>.amazonaws.com/<bucket-name>/<key>
 	// If a custom endpoint is used, it might be different.
+	if p.publicBaseURL != "" {
+		// A CDN or public domain in front of the bucket serves the object keys directly
+		return fmt.Sprintf("%s/%s", p.publicBaseURL, strings.TrimPrefix(key, "/"))
+	}
 	if p.endpointURL != "" {
 		if p.forcePathStyle {
 			return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(p.endpointURL, "/"), p.bucketName, strings.TrimPrefix(key, "/"))
@@ -213,7 +263,19 @@ func (p *s3Provider) generateObjectURL(ctx context.Context, key string) string {
 func (p *s3Provider) GetURL(ctx context.Context, key string) (string, error) {
 	// This typically returns the same as generateObjectURL if the object is public.
 	// For S3, ACLs determine public accessibility.
-	// We can check if object exists first.
+	exists, err := p.Exists(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if !exists {
+		p.logger.Warnf(ctx, "Object not found, cannot get URL", map[string]any{"key": key})
+		return "", fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
+	}
+	return p.generateObjectURL(ctx, key), nil
+}
+
+// Exists checks for an object with HeadObject.
+func (p *s3Provider) Exists(ctx context.Context, key string) (bool, error) {
 	_, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Bucket: aws.String(p.bucketName),
 		Key:    aws.String(key),
@@ -222,13 +284,12 @@ func (p *s3Provider) GetURL(ctx context.Context, key string) (string, error) {
 		var nsk *types.NoSuchKey
 		var nf *types.NotFound
 		if errors.As(err, &nsk) || errors.As(err, &nf) {
-			p.logger.Warnf(ctx, "Object not found, cannot get URL", map[string]any{"key": key})
-			return "", fmt.Errorf("object %s not found: %w", key, err)
+			return false, nil
 		}
-		p.logger.Errorf(ctx, "Failed to HeadObject for GetURL", map[string]any{"key": key, "error": err})
-		return "", fmt.Errorf("failed to check object %s: %w", key, err)
+		p.logger.Errorf(ctx, "Failed to HeadObject for Exists", map[string]any{"key": key, "error": err})
+		return false, fmt.Errorf("failed to check object %s: %w", key, err)
 	}
-	return p.generateObjectURL(ctx, key), nil
+	return true, nil
 }
 
 // GetSignedURL generates a time-limited signed URL for accessing a private object.
@@ -247,6 +308,28 @@ func (p *s3Provider) GetSignedURL(ctx context.Context, key string, duration time
 	return request.URL, nil
 }
 
+var _ port.PresignedUploadProvider = (*s3Provider)(nil)
+
+// GetSignedUploadURL generates a time-limited URL for uploading an object with a PUT request.
+func (p *s3Provider) GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *port.UploadOptions) (string, error) {
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(key),
+	}
+	if opts != nil && opts.ContentType != "" {
+		input.ContentType = aws.String(opts.ContentType)
+	}
+	request, err := p.presignClient.PresignPutObject(ctx, input, func(o *s3.PresignOptions) {
+		o.Expires = duration
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to generate S3 signed upload URL", map[string]any{"key": key, "error": err})
+		return "", fmt.Errorf("failed to generate S3 signed upload URL for key %s: %w", key, err)
+	}
+	p.logger.Infof(ctx, "Generated S3 signed upload URL", map[string]any{"key": key, "duration": duration})
+	return request.URL, nil
+}
+
 // Delete removes a file from S3.
 func (p *s3Provider) Delete(ctx context.Context, key string) error {
 	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -275,7 +358,7 @@ func (p *s3Provider) GetObject(ctx context.Context, key string) (*port.FileObjec
 		var nf *types.NotFound
 		if errors.As(err, &nsk) || errors.As(err, &nf) {
 			p.logger.Warnf(ctx, "S3 object not found for GetObject", map[string]any{"key": key})
-			return nil, fmt.Errorf("s3 object %s not found: %w", key, err)
+			return nil, fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to HeadObject for S3 GetObject", map[string]any{"key": key, "error": err})
 		return nil, fmt.Errorf("failed to get S3 object metadata for %s: %w", key, err)
@@ -303,7 +386,7 @@ func (p *s3Provider) Download(ctx context.Context, key string) (io.ReadCloser, *
 		var nf *types.NotFound
 		if errors.As(err, &nsk) || errors.As(err, &nf) {
 			p.logger.Warnf(ctx, "S3 object not found for Download", map[string]any{"key": key})
-			return nil, nil, fmt.Errorf("s3 object %s not found for download: %w", key, err)
+			return nil, nil, fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
 		}
 		p.logger.Errorf(ctx, "Failed to GetObject for S3 Download", map[string]any{"key": key, "error": err})
 		return nil, nil, fmt.Errorf("failed to get S3 object %s for download: %w", key, err)
@@ -322,6 +405,88 @@ func (p *s3Provider) Download(ctx context.Context, key string) (io.ReadCloser, *
 	return getObjectOutput.Body, fileObject, nil
 }
 
+// DownloadRange downloads a byte range of a file from S3 using the Range header.
+func (p *s3Provider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	byteRange, err := port.HTTPRange(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileObject, err := p.GetObject(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	getObjectOutput, err := p.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(key),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to GetObject range for S3 Download", map[string]any{"key": key, "range": byteRange, "error": err})
+		return nil, nil, fmt.Errorf("failed to get S3 object %s range %s: %w", key, byteRange, err)
+	}
+	return getObjectOutput.Body, fileObject, nil
+}
+
+// Copy duplicates an object inside the bucket with a server-side CopyObject.
+func (p *s3Provider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(p.bucketName),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(p.bucketName + "/" + url.PathEscape(srcKey)),
+	}
+	// S3 does not carry the source's encryption over to the copy.
+	input.ServerSideEncryption, input.SSEKMSKeyId = p.encryption(nil)
+	_, err := p.client.CopyObject(ctx, input)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to copy S3 object", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, fmt.Errorf("failed to copy S3 object %s to %s: %w", srcKey, dstKey, err)
+	}
+	p.logger.Infof(ctx, "S3 object copied successfully", map[string]any{"srcKey": srcKey, "dstKey": dstKey})
+	return p.GetObject(ctx, dstKey)
+}
+
+// Move copies the object server-side and then deletes the source.
+func (p *s3Provider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists one page of objects under prefix using ListObjectsV2.
+func (p *s3Provider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	input := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(p.bucketName),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(int32(opts.MaxKeysOrDefault())),
+	}
+	if token := opts.Token(); token != "" {
+		input.ContinuationToken = aws.String(token)
+	}
+
+	output, err := p.client.ListObjectsV2(ctx, input)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to list S3 objects", map[string]any{"prefix": prefix, "error": err})
+		return nil, "", fmt.Errorf("failed to list S3 objects with prefix %s: %w", prefix, err)
+	}
+
+	objects := make([]*port.FileObject, 0, len(output.Contents))
+	for _, item := range output.Contents {
+		key := aws.ToString(item.Key)
+		objects = append(objects, &port.FileObject{
+			Key:          key,
+			URL:          p.generateObjectURL(ctx, key),
+			Size:         aws.ToInt64(item.Size),
+			LastModified: aws.ToTime(item.LastModified),
+			ETag:         strings.Trim(aws.ToString(item.ETag), "\""),
+			Provider:     p.ProviderType(),
+		})
+	}
+
+	nextToken := ""
+	if aws.ToBool(output.IsTruncated) {
+		nextToken = aws.ToString(output.NextContinuationToken)
+	}
+	return objects, nextToken, nil
+}
+
 // CheckHealth checks if the storage provider is healthy and accessible.
 func (p *s3Provider) CheckHealth(ctx context.Context) error {
 	var err error
@@ -341,10 +506,12 @@ func (p *s3Provider) CheckHealth(ctx context.Context) error {
 	return nil
 }
 
-// ProviderType returns the type of the adapters provider.
+// ProviderType returns the type of the adapters provider: the configured label, or else a
+// guess from the endpoint, which custom domains defeat.
 func (p *s3Provider) ProviderType() port.StorageProviderType {
-	// If this provider is also used for Cloudflare R2, this might need adjustment
-	// or Cloudflare R2 could have its own type.
+	if p.providerType != "" {
+		return p.providerType
+	}
 	if strings.Contains(p.endpointURL, "r2.cloudflarestorage.com") {
 		return port.ProviderCloudflareR2
 	}
@@ -355,6 +522,12 @@ func (p *s3Provider) ProviderType() port.StorageProviderType {
 	if strings.Contains(p.endpointURL, "scw.cloud") || strings.Contains(p.endpointURL, "scaleway.com") {
 		return port.ProviderScaleway
 	}
+	if strings.Contains(p.endpointURL, "digitaloceanspaces.com") {
+		return port.ProviderDigitalOcean
+	}
+	if strings.Contains(p.endpointURL, "wasabisys.com") {
+		return port.ProviderWasabi
+	}
 	// Note: MinIO detection removed because MinIO has its own dedicated provider
 	// MinIO instances should use the dedicated MinIO provider instead of S3 provider
 	return port.ProviderS3
@@ -0,0 +1,80 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.StorageClassProvider = (*s3Provider)(nil)
+
+// validStorageClasses lists the classes accepted by PutObject and CopyObject: STANDARD,
+// REDUCED_REDUNDANCY, STANDARD_IA, ONEZONE_IA, INTELLIGENT_TIERING, GLACIER,
+// DEEP_ARCHIVE, OUTPOSTS, GLACIER_IR, SNOW and EXPRESS_ONEZONE.
+var validStorageClasses = types.StorageClass("").Values()
+
+// parseStorageClass validates class so callers get a clear error instead of an opaque
+// InvalidStorageClass response. An empty class leaves the bucket default.
+func parseStorageClass(class string) (types.StorageClass, error) {
+	if class == "" {
+		return "", nil
+	}
+	names := make([]string, len(validStorageClasses))
+	for i, valid := range validStorageClasses {
+		if string(valid) == class {
+			return valid, nil
+		}
+		names[i] = string(valid)
+	}
+	return "", fmt.Errorf("invalid S3 storage class %q, expected one of %s", class, strings.Join(names, ", "))
+}
+
+// ChangeStorageClass copies the object onto itself with the new storage class.
+// Metadata and tags are carried over by the copy; encryption is not, so the object's
+// current settings are read first and reapplied.
+func (p *s3Provider) ChangeStorageClass(ctx context.Context, key, class string) error {
+	if class == "" {
+		return fmt.Errorf("storage class cannot be empty")
+	}
+	storageClass, err := parseStorageClass(class)
+	if err != nil {
+		return err
+	}
+
+	head, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var nsk *types.NoSuchKey
+		var nf *types.NotFound
+		if errors.As(err, &nsk) || errors.As(err, &nf) {
+			return fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get S3 object metadata for storage class change", map[string]any{"key": key, "error": err})
+		return fmt.Errorf("failed to get metadata for S3 key %s: %w", key, err)
+	}
+
+	_, err = p.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(p.bucketName),
+		Key:                  aws.String(key),
+		CopySource:           aws.String(p.bucketName + "/" + url.PathEscape(key)),
+		StorageClass:         storageClass,
+		ServerSideEncryption: head.ServerSideEncryption,
+		SSEKMSKeyId:          head.SSEKMSKeyId,
+	})
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to change S3 object storage class", map[string]any{"key": key, "storageClass": class, "error": err})
+		return fmt.Errorf("failed to change storage class of S3 object %s to %s: %w", key, class, err)
+	}
+	p.logger.Infof(ctx, "S3 object storage class changed", map[string]any{"key": key, "storageClass": class})
+	return nil
+}
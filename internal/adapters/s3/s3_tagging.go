@@ -0,0 +1,66 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+var _ port.TaggableProvider = (*s3Provider)(nil)
+
+// isNoSuchKey reports whether err is a missing-object error from an operation whose
+// errors are not modeled as types.NoSuchKey, such as the tagging calls.
+func isNoSuchKey(err error) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey"
+}
+
+// SetTags replaces the object's tag set with PutObjectTagging.
+func (p *s3Provider) SetTags(ctx context.Context, key string, tags map[string]string) error {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for k, v := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(v)})
+	}
+
+	_, err := p.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(p.bucketName),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: tagSet},
+	})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to tag S3 object", map[string]any{"key": key, "error": err})
+		return fmt.Errorf("failed to tag S3 object %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetTags returns the object's tag set with GetObjectTagging.
+func (p *s3Provider) GetTags(ctx context.Context, key string) (map[string]string, error) {
+	output, err := p.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(p.bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		if isNoSuchKey(err) {
+			return nil, fmt.Errorf("s3 object %s %w", key, port.ErrObjectNotFound)
+		}
+		p.logger.Errorf(ctx, "Failed to get S3 object tags", map[string]any{"key": key, "error": err})
+		return nil, fmt.Errorf("failed to get tags of S3 object %s: %w", key, err)
+	}
+
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
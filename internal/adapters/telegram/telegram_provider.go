@@ -0,0 +1,482 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+	sendConfig "github.com/lugondev/send-sen/config"
+
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// telegramProvider implements the port.StorageProvider interface by posting files as
+// documents to a Telegram chat. The Bot API cannot search a chat, so every upload is
+// recorded in an ObjectIndex under its key.
+type telegramProvider struct {
+	config sendConfig.TelegramConfig
+	index  port.ObjectIndex
+	client *http.Client
+	logger logger.Logger
+}
+
+// Telegram Bot API constants
+const (
+	telegramAPIBaseURL = "https://api.telegram.org"
+	// telegramMaxUploadSize is the largest document a bot can send.
+	telegramMaxUploadSize = 50 << 20
+	// telegramKeyPrefix marks the caption that records an uploaded file's key.
+	telegramKeyPrefix = "File: "
+
+	// Refs recorded in the object index for each upload.
+	refMessageID = "message_id"
+	refFileID    = "file_id"
+)
+
+// errFileNotFound is returned when no upload is indexed under the requested key.
+var errFileNotFound = fmt.Errorf("telegram provider: file %w", port.ErrObjectNotFound)
+
+// Telegram Bot API response structures
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+type telegramMessage struct {
+	MessageID int64            `json:"message_id"`
+	Date      int64            `json:"date"`
+	Document  telegramDocument `json:"document"`
+}
+
+type telegramDocument struct {
+	FileID       string `json:"file_id"`
+	FileUniqueID string `json:"file_unique_id"`
+	FileName     string `json:"file_name"`
+	MimeType     string `json:"mime_type"`
+	FileSize     int64  `json:"file_size"`
+}
+
+type telegramFile struct {
+	FileID   string `json:"file_id"`
+	FilePath string `json:"file_path"`
+}
+
+// telegramAPIError is a request the Bot API answered with ok=false.
+type telegramAPIError struct {
+	Method      string
+	Code        int
+	Description string
+}
+
+func (e *telegramAPIError) Error() string {
+	return fmt.Sprintf("telegram provider: %s failed with code %d: %s", e.Method, e.Code, e.Description)
+}
+
+// HTTPStatusCode returns the error code, which the Bot API takes from HTTP statuses,
+// so retry logic can recognise rate limiting (429) and server errors.
+func (e *telegramAPIError) HTTPStatusCode() int {
+	return e.Code
+}
+
+// NewTelegramProvider creates a new Telegram storage provider.
+func NewTelegramProvider(config sendConfig.TelegramConfig, index port.ObjectIndex, logger logger.Logger) (port.StorageProvider, error) {
+	if config.BotToken == "" {
+		return nil, errors.New("telegram provider: bot token is required")
+	}
+	if config.ChatID == "" {
+		return nil, errors.New("telegram provider: chat_id is required")
+	}
+	if index == nil {
+		return nil, errors.New("telegram provider: an object index is required")
+	}
+
+	return &telegramProvider{
+		config: config,
+		index:  index,
+		// Uploads and downloads can take minutes; callers bound requests with their context.
+		client: &http.Client{},
+		logger: logger.WithFields(map[string]any{"component": "TelegramProvider"}),
+	}, nil
+}
+
+// call sends a Bot API request and decodes its result into out, which may be nil.
+func (p *telegramProvider) call(ctx context.Context, method string, body io.Reader, contentType string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/bot%s/%s", telegramAPIBaseURL, p.config.BotToken, method), body)
+	if err != nil {
+		return fmt.Errorf("telegram provider: failed to create %s request: %w", method, err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		// The request URL contains the bot token, so only the cause is reported.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("telegram provider: %s request failed: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var result telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("telegram provider: failed to parse %s response, status: %d: %w", method, resp.StatusCode, err)
+	}
+	if !result.OK {
+		return &telegramAPIError{Method: method, Code: result.ErrorCode, Description: result.Description}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(result.Result, out); err != nil {
+		return fmt.Errorf("telegram provider: failed to parse %s result: %w", method, err)
+	}
+	return nil
+}
+
+// callForm sends a Bot API request with URL-encoded parameters.
+func (p *telegramProvider) callForm(ctx context.Context, method string, params url.Values, out any) error {
+	return p.call(ctx, method, strings.NewReader(params.Encode()), "application/x-www-form-urlencoded", out)
+}
+
+// sendDocument posts a document captioned with key. The document is either the
+// file_id of a file already on Telegram's servers or, when reader is set, new content.
+func (p *telegramProvider) sendDocument(ctx context.Context, key, fileID string, reader io.Reader) (*telegramMessage, error) {
+	var message telegramMessage
+	if reader == nil {
+		params := url.Values{
+			"chat_id":  {p.config.ChatID},
+			"caption":  {telegramKeyPrefix + key},
+			"document": {fileID},
+		}
+		if err := p.callForm(ctx, "sendDocument", params, &message); err != nil {
+			return nil, err
+		}
+		return &message, nil
+	}
+
+	// Stream the multipart body so the file is never held in memory.
+	bodyReader, bodyWriter := io.Pipe()
+	writer := multipart.NewWriter(bodyWriter)
+	go func() {
+		err := writer.WriteField("chat_id", p.config.ChatID)
+		if err == nil {
+			err = writer.WriteField("caption", telegramKeyPrefix+key)
+		}
+		if err == nil {
+			var part io.Writer
+			if part, err = writer.CreateFormFile("document", path.Base(key)); err == nil {
+				_, err = io.Copy(part, reader)
+			}
+		}
+		if err == nil {
+			err = writer.Close()
+		}
+		bodyWriter.CloseWithError(err)
+	}()
+
+	err := p.call(ctx, "sendDocument", bodyReader, writer.FormDataContentType(), &message)
+	bodyReader.Close() // Unblocks the writer if the request ended early
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// deleteMessage removes an uploaded document from the chat.
+func (p *telegramProvider) deleteMessage(ctx context.Context, messageID string) error {
+	return p.callForm(ctx, "deleteMessage", url.Values{
+		"chat_id":    {p.config.ChatID},
+		"message_id": {messageID},
+	}, nil)
+}
+
+// record indexes message as the object stored under key. If another upload was
+// indexed under key, its message is deleted so the chat does not keep stale copies.
+func (p *telegramProvider) record(ctx context.Context, key string, message *telegramMessage, contentType string) (*port.FileObject, error) {
+	if contentType == "" {
+		contentType = message.Document.MimeType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	messageID := strconv.FormatInt(message.MessageID, 10)
+	obj := &port.IndexedObject{
+		FileObject: port.FileObject{
+			Key:          key,
+			Size:         message.Document.FileSize,
+			ContentType:  contentType,
+			LastModified: time.Unix(message.Date, 0).UTC(),
+			ETag:         message.Document.FileUniqueID,
+			Provider:     p.ProviderType(),
+		},
+		Refs: map[string]string{
+			refMessageID: messageID,
+			refFileID:    message.Document.FileID,
+		},
+	}
+
+	previous, err := p.index.Get(ctx, p.ProviderType(), key)
+	if err != nil {
+		p.logger.Warnf(ctx, "Failed to look up previous upload", map[string]any{"key": key, "error": err})
+	}
+	if err := p.index.Put(ctx, p.ProviderType(), obj); err != nil {
+		if delErr := p.deleteMessage(ctx, messageID); delErr != nil {
+			p.logger.Warnf(ctx, "Failed to delete unindexed upload", map[string]any{"key": key, "messageID": messageID, "error": delErr})
+		}
+		return nil, fmt.Errorf("telegram provider: %w", err)
+	}
+	if previous != nil && previous.Refs[refMessageID] != messageID {
+		if err := p.deleteMessage(ctx, previous.Refs[refMessageID]); err != nil {
+			p.logger.Warnf(ctx, "Failed to delete replaced upload", map[string]any{"key": key, "messageID": previous.Refs[refMessageID], "error": err})
+		}
+	}
+
+	fileObject := obj.FileObject
+	return &fileObject, nil
+}
+
+// Upload posts the file to the chat as a document.
+func (p *telegramProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
+	if key == "" {
+		return nil, errors.New("telegram provider: upload key cannot be empty")
+	}
+	if opts.RequestsEncryption() {
+		p.logger.Warnf(ctx, "Telegram cannot encrypt uploads; ignoring server-side encryption options", map[string]any{"key": key})
+	}
+	if size > telegramMaxUploadSize {
+		return nil, fmt.Errorf("telegram provider: file is %d bytes, bots can send at most %d", size, telegramMaxUploadSize)
+	}
+
+	message, err := p.sendDocument(ctx, key, "", opts.TrackProgress(reader, size))
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to upload file to Telegram", map[string]any{"key": key, "error": err})
+		return nil, err
+	}
+
+	contentType := ""
+	if opts != nil {
+		contentType = opts.ContentType
+	}
+	fileObject, err := p.record(ctx, key, message, contentType)
+	if err != nil {
+		return nil, err
+	}
+	p.logger.Infof(ctx, "File uploaded successfully to Telegram", map[string]any{"key": key, "messageID": message.MessageID, "size": fileObject.Size})
+	return fileObject, nil
+}
+
+// lookup returns the index record of key.
+func (p *telegramProvider) lookup(ctx context.Context, key string) (*port.IndexedObject, error) {
+	obj, err := p.index.Get(ctx, p.ProviderType(), key)
+	if err != nil {
+		return nil, fmt.Errorf("telegram provider: %w", err)
+	}
+	if obj == nil {
+		return nil, errFileNotFound
+	}
+	return obj, nil
+}
+
+// GetURL returns an empty URL for an existing file: Telegram file links embed the
+// bot token, so files are only served through Download.
+func (p *telegramProvider) GetURL(ctx context.Context, key string) (string, error) {
+	if _, err := p.lookup(ctx, key); err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+// GetSignedURL is not supported for the same reason as GetURL.
+func (p *telegramProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
+	if _, err := p.lookup(ctx, key); err != nil {
+		return "", err
+	}
+	return "", errors.New("telegram provider: file links embed the bot token and cannot be shared")
+}
+
+// Exists reports whether an upload is indexed under key.
+func (p *telegramProvider) Exists(ctx context.Context, key string) (bool, error) {
+	if _, err := p.lookup(ctx, key); err != nil {
+		if errors.Is(err, errFileNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete removes the document message and its index record. Bots cannot delete
+// messages older than 48 hours in some chats; the record is removed regardless so
+// the key is free again, leaving the message behind.
+func (p *telegramProvider) Delete(ctx context.Context, key string) error {
+	obj, err := p.lookup(ctx, key)
+	if err != nil {
+		// If the file is not found, consider it already deleted
+		if errors.Is(err, errFileNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if err := p.deleteMessage(ctx, obj.Refs[refMessageID]); err != nil {
+		var apiErr *telegramAPIError
+		if !errors.As(err, &apiErr) {
+			return err
+		}
+		p.logger.Warnf(ctx, "Telegram refused to delete the file message", map[string]any{"key": key, "messageID": obj.Refs[refMessageID], "error": err})
+	}
+	if err := p.index.Delete(ctx, p.ProviderType(), key); err != nil {
+		return fmt.Errorf("telegram provider: %w", err)
+	}
+	return nil
+}
+
+// GetObject retrieves file information from the index.
+func (p *telegramProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
+	obj, err := p.lookup(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &obj.FileObject, nil
+}
+
+// open resolves the file's download path with getFile and starts downloading it.
+// Bots can only download files of up to 20 MB.
+func (p *telegramProvider) open(ctx context.Context, key, byteRange string) (*http.Response, *port.FileObject, error) {
+	obj, err := p.lookup(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var file telegramFile
+	if err := p.callForm(ctx, "getFile", url.Values{"file_id": {obj.Refs[refFileID]}}, &file); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("%s/file/bot%s/%s", telegramAPIBaseURL, p.config.BotToken, file.FilePath), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("telegram provider: failed to create download request: %w", err)
+	}
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, nil, fmt.Errorf("telegram provider: failed to download file: %w", err)
+	}
+	return resp, &obj.FileObject, nil
+}
+
+// Download downloads a file from Telegram.
+func (p *telegramProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
+	resp, fileObj, err := p.open(ctx, key, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("telegram provider: failed to download file, status: %d", resp.StatusCode)
+	}
+	return resp.Body, fileObj, nil
+}
+
+// DownloadRange downloads a byte range of a file. If the file server ignores the
+// Range header, the range is sliced out of the full stream.
+func (p *telegramProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	byteRange, err := port.HTTPRange(start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, fileObj, err := p.open(ctx, key, byteRange)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, fileObj, nil
+	case http.StatusOK:
+		reader, err := port.SliceReader(resp.Body, start, end)
+		if err != nil {
+			return nil, nil, fmt.Errorf("telegram provider: %w", err)
+		}
+		return reader, fileObj, nil
+	default:
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("telegram provider: failed to download file range, status: %d", resp.StatusCode)
+	}
+}
+
+// Copy re-sends the document by its file_id, which Telegram serves without a re-upload.
+func (p *telegramProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	src, err := p.lookup(ctx, srcKey)
+	if err != nil {
+		return nil, err
+	}
+	message, err := p.sendDocument(ctx, dstKey, src.Refs[refFileID], nil)
+	if err != nil {
+		p.logger.Errorf(ctx, "Failed to copy file on Telegram", map[string]any{"srcKey": srcKey, "dstKey": dstKey, "error": err})
+		return nil, err
+	}
+	return p.record(ctx, dstKey, message, src.ContentType)
+}
+
+// Move re-sends the document under dstKey and then deletes the original message.
+func (p *telegramProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	return port.MoveViaCopy(ctx, p, srcKey, dstKey)
+}
+
+// ListObjects lists indexed uploads whose key starts with prefix, in key order.
+// The continuation token is the last key returned.
+func (p *telegramProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	maxKeys := opts.MaxKeysOrDefault()
+	// One extra record tells whether another page follows.
+	records, err := p.index.List(ctx, p.ProviderType(), prefix, opts.Token(), maxKeys+1)
+	if err != nil {
+		return nil, "", fmt.Errorf("telegram provider: %w", err)
+	}
+
+	nextToken := ""
+	if len(records) > maxKeys {
+		records = records[:maxKeys]
+		nextToken = records[maxKeys-1].Key
+	}
+	objects := make([]*port.FileObject, 0, len(records))
+	for _, record := range records {
+		objects = append(objects, &record.FileObject)
+	}
+	return objects, nextToken, nil
+}
+
+// CheckHealth checks that the bot can access the configured chat.
+func (p *telegramProvider) CheckHealth(ctx context.Context) error {
+	if err := p.callForm(ctx, "getChat", url.Values{"chat_id": {p.config.ChatID}}, nil); err != nil {
+		return fmt.Errorf("telegram health check failed: %w", err)
+	}
+	return nil
+}
+
+// ProviderType returns the type of the storage provider.
+func (p *telegramProvider) ProviderType() port.StorageProviderType {
+	return port.ProviderTelegram
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
 	"github.com/lugondev/m3-storage/internal/shared/validator"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/lugondev/m3-storage/internal/infra/cache"
@@ -19,19 +23,33 @@ import (
 	senConfig "github.com/lugondev/send-sen/config"
 
 	// App Ports & Services (Health, Storage)
+	appHandler "github.com/lugondev/m3-storage/internal/modules/app/handler"
 	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	appService "github.com/lugondev/m3-storage/internal/modules/app/service"
 
 	// Auth Module
 	"github.com/lugondev/m3-storage/internal/modules/auth"
+	authHandler "github.com/lugondev/m3-storage/internal/modules/auth/handler"
 
 	// Media Module
+	mediaDomain "github.com/lugondev/m3-storage/internal/modules/media/domain"
 	mediaHandler "github.com/lugondev/m3-storage/internal/modules/media/handler"
 	mediaPort "github.com/lugondev/m3-storage/internal/modules/media/port"
 	mediaService "github.com/lugondev/m3-storage/internal/modules/media/service"
 
+	// S3 API Module
+	s3Domain "github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	s3Handler "github.com/lugondev/m3-storage/internal/modules/s3api/handler"
+	s3Port "github.com/lugondev/m3-storage/internal/modules/s3api/port"
+	s3Service "github.com/lugondev/m3-storage/internal/modules/s3api/service"
+
+	// Webhook Module
+	webhookService "github.com/lugondev/m3-storage/internal/modules/webhook/service"
+
 	// Storage Module - DDD compliant
 	storageFactory "github.com/lugondev/m3-storage/internal/modules/storage/factory"
 	storageHandler "github.com/lugondev/m3-storage/internal/modules/storage/handler"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
 	storageService "github.com/lugondev/m3-storage/internal/modules/storage/service"
 )
 
@@ -41,20 +59,33 @@ type Infrastructure struct {
 	Logger      logger.Logger
 	DB          *gorm.DB
 	RedisClient *cache.RedisClient
+	// BaseContext is cancelled to stop at once on shutdown; uploads in flight run under it
+	BaseContext context.Context
 }
 
 // Application holds the initialized application components (services, handlers, etc.).
 type Application struct {
 	// Services
 	CacheSvc   appPort.CacheService
+	AuditSvc   appPort.AuditService
 	StorageSvc storageService.StorageService // DDD-compliant storage service
+	HealthMon  *storageService.HealthMonitor // Background provider health checks backing the upload gate
 	JWTSvc     *infraJWT.JWTService
 	NotifySvc  sen.NotifyService
+	EmailSvc   sen.EmailService // Emails users; prints them to stdout when adapter.email names no provider
 	MediaSvc   mediaPort.MediaService
+	UsageSched *mediaService.UsageReconcileScheduler // Periodic recount of users' storage usage
+	Webhooks   *webhookService.Dispatcher            // Sends storage events to webhook subscribers
 
 	// Handlers
 	MediaHandler   *mediaHandler.MediaHandler
 	StorageHandler *storageHandler.StorageHandler
+	AdminHandler   *authHandler.AdminHandler // Admin user management, which spans the auth and media modules
+	AuditHandler   *appHandler.AuditHandler
+	HealthHandler  *appHandler.HealthHandler   // Health, liveness and readiness probes
+	AvatarHandler  *authHandler.AvatarHandler  // Avatar uploads, stored by the media module
+	AccountHandler *authHandler.AccountHandler // Account deletion, purging the user's media
+	S3Handler      *s3Handler.S3Handler        // S3-compatible API; nil when s3Gateway is disabled
 
 	// Auth Module
 	AuthDependencies *auth.Dependencies
@@ -62,8 +93,12 @@ type Application struct {
 	// Middleware
 	AuthMiddleware *middleware.AuthMiddleware
 
+	// LocalFiles verifies and serves local storage signed URLs; nil when local storage is unavailable
+	LocalFiles storagePort.LocalFileServer
+
 	// Shared Services
 	Validator validator.Validator
+	Clock     clock.Clock
 }
 
 // BuildDependencies initializes and wires up all application dependencies.
@@ -82,6 +117,8 @@ func BuildDependencies(infra *Infrastructure) (*Application, error) {
 	app.Validator = validator.New()
 	log.Info(ctx, "Validator service initialized")
 
+	app.Clock = clock.New()
+
 	// --- Initialize Repositories ---
 	// userRepo := userService.NewUserRepository(infra.DB, log)
 
@@ -89,7 +126,7 @@ func BuildDependencies(infra *Infrastructure) (*Application, error) {
 	app.CacheSvc = cache.NewRedisCacheService(redisClient) // Pass the wrapper
 
 	// --- Initialize JWT Service ---
-	jwtSvc, err := infraJWT.NewJWTService(cfg.App.Secret)
+	jwtSvc, err := infraJWT.NewJWTServiceFromConfig(cfg.Auth.JWT, cfg.App.Secret, app.Clock)
 	if err != nil {
 		log.Errorf(ctx, "Failed to initialize JWT service: %v", err)
 		return nil, fmt.Errorf("failed to initialize JWT service: %w", err)
@@ -97,10 +134,6 @@ func BuildDependencies(infra *Infrastructure) (*Application, error) {
 	app.JWTSvc = jwtSvc
 	log.Info(ctx, "JWT Service initialized successfully")
 
-	// --- Initialize Auth Module ---
-	app.AuthDependencies = auth.NewDependencies(infra.DB, app.JWTSvc, app.Validator)
-	log.Info(ctx, "Auth module initialized")
-
 	// Initialize Notify Service
 	app.NotifySvc, err = sen.NewNotifyService(senConfig.Config{
 		Adapter:  cfg.Adapter,
@@ -112,30 +145,117 @@ func BuildDependencies(infra *Infrastructure) (*Application, error) {
 		log.Info(ctx, "Notification service initialized successfully")
 	}
 
+	// Initialize Email Service
+	app.EmailSvc, err = sen.NewEmailService(senConfig.Config{
+		Adapter:  cfg.Adapter,
+		Brevo:    cfg.Brevo,
+		SendGrid: cfg.SendGrid,
+	}, log)
+	if err != nil {
+		log.Warnf(ctx, "Failed to initialize email service (continuing without it?): %v", err)
+	} else {
+		log.Info(ctx, "Email service initialized successfully")
+	}
+
+	// --- Initialize Auth Module ---
+	loginAttempts := cache.NewRedisLoginAttemptTracker(redisClient, time.Duration(cfg.Auth.FailedLoginWindowSeconds)*time.Second)
+	app.AuditSvc = appService.NewAuditService(appService.NewAuditRepository(infra.DB), log)
+	app.AuditHandler = appHandler.NewAuditHandler(app.AuditSvc)
+	tokenRevocations := cache.NewRedisTokenRevocationStore(redisClient, time.Duration(cfg.Auth.AccessTokenTTLSeconds)*time.Second)
+	authCfg := cfg.Auth
+	if authCfg.PasswordResetURL == "" {
+		authCfg.PasswordResetURL = strings.TrimSuffix(cfg.App.ClientURL, "/") + "/reset-password"
+	}
+	app.AuthDependencies = auth.NewDependencies(infra.DB, app.JWTSvc, app.Validator, app.EmailSvc, app.NotifySvc, loginAttempts, tokenRevocations, app.AuditSvc, authCfg, app.Clock)
+	log.Info(ctx, "Auth module initialized")
+
 	// --- Initialize Module Services ---
 	log.Info(ctx, "Module services initialized")
 
 	// --- Initialize Middleware ---
-	app.AuthMiddleware = middleware.NewAuthMiddleware(app.JWTSvc)
+	app.AuthMiddleware = middleware.NewAuthMiddleware(app.JWTSvc, tokenRevocations)
 	log.Info(ctx, "Custom middleware initialized")
 
 	// --- Initialize Storage Module (DDD-compliant) ---
 	// Initialize Storage Factory
-	sFactory := storageFactory.NewStorageFactory(infra.Config, log)
+	sFactory, err := storageFactory.NewStorageFactory(infra.Config, log, cache.NewRedisObjectIndex(redisClient), app.Clock)
+	if err != nil {
+		log.Errorf(ctx, "Failed to initialize storage factory: %v", err)
+		return nil, fmt.Errorf("failed to initialize storage factory: %w", err)
+	}
 
 	// Initialize Storage Service (Application Layer)
-	app.StorageSvc = storageService.NewStorageService(sFactory, log)
+	app.StorageSvc = storageService.NewStorageService(sFactory, log, cfg.Storage)
 	log.Info(ctx, "Storage service initialized")
 
+	// Provider health monitor; started by the server once dependencies are built
+	app.HealthMon = storageService.NewHealthMonitor(sFactory, log, cfg.Storage.HealthGate)
+
+	// Local signed URLs point back at this service, which verifies them and reads the file
+	// through the local provider that issued them
+	if localProvider, err := sFactory.CreateProviderByName(string(storagePort.ProviderLocal)); err != nil {
+		log.Warn(ctx, "Local storage unavailable; signed local file URLs will not be served", map[string]any{"error": err})
+	} else if files, ok := storagePort.As[storagePort.LocalFileServer](localProvider); ok {
+		app.LocalFiles = files
+	}
+
+	// Readiness also checks the default storage backend
+	app.HealthHandler = appHandler.NewHealthHandler(infra.DB, redisClient, sFactory, cfg.App.Env, cfg.Storage.HealthCacheTTLSeconds)
+
 	// Initialize Storage Handler (Presentation Layer)
 	app.StorageHandler = storageHandler.NewStorageHandler(app.StorageSvc, log)
 	log.Info(ctx, "Storage handler initialized")
 
+	// --- Initialize Webhooks ---
+	app.Webhooks, err = webhookService.NewDispatcher(infra.DB, log, cfg.Webhooks, mediaDomain.Events, app.Clock)
+	if err != nil {
+		return nil, fmt.Errorf("invalid webhook configuration: %w", err)
+	}
+
+	// The S3 gateway's objects count towards the storage usage the media module reconciles
+	var s3Gateway s3Port.Gateway
+	var usageSources []mediaPort.UsageSource
+	if cfg.S3Gateway.Enabled {
+		s3Gateway = s3Service.NewGateway(sFactory, app.AuthDependencies.UserService, cfg.S3Gateway, log)
+		usageSources = append(usageSources, s3Gateway)
+	}
+
 	// --- Initialize Media Module ---
-	app.MediaSvc = mediaService.NewMediaService(infra.DB, log, sFactory)
-	app.MediaHandler = mediaHandler.NewMediaHandler(log, app.MediaSvc, infra.Config)
+	app.MediaSvc = mediaService.NewMediaService(infra.BaseContext, infra.DB, log, sFactory, app.CacheSvc, cache.NewRedisMultipartSessionStore(redisClient), cache.NewRedisTusUploadStore(redisClient), app.AuthDependencies.UserService, app.Webhooks, usageSources, cfg.Media)
+	app.UsageSched = mediaService.NewUsageReconcileScheduler(app.MediaSvc, log, cfg.Media.UsageReconcile)
+	app.MediaHandler = mediaHandler.NewMediaHandler(log, app.MediaSvc, app.AuditSvc, app.HealthMon, infra.Config)
 	log.Info(ctx, "Media module initialized")
 
+	// Admin user management counts what users own in the media module before deleting them
+	app.AdminHandler = authHandler.NewAdminHandler(app.AuthDependencies.AuthService, app.AuthDependencies.UserService, app.MediaSvc, app.AuditSvc, app.Validator, log)
+	app.AvatarHandler = authHandler.NewAvatarHandler(app.AuthDependencies.AuthService, app.MediaSvc, app.AuditSvc, cfg.Auth.AvatarMaxBytes, log)
+	app.AccountHandler = authHandler.NewAccountHandler(app.AuthDependencies.AuthService, app.AuthDependencies.UserService, app.MediaSvc, app.MediaSvc, app.AuditSvc, log)
+
+	// --- Initialize S3 Gateway ---
+	if cfg.S3Gateway.Enabled {
+		credentials := make([]*s3Domain.Credential, 0, len(cfg.S3Gateway.Credentials))
+		for _, credential := range cfg.S3Gateway.Credentials {
+			userID, err := uuid.Parse(credential.UserID)
+			if err != nil {
+				return nil, fmt.Errorf("invalid S3 gateway credential %s: %w", credential.AccessKeyID, err)
+			}
+			credentials = append(credentials, &s3Domain.Credential{
+				AccessKeyID:     credential.AccessKeyID,
+				SecretAccessKey: credential.SecretAccessKey,
+				UserID:          userID,
+			})
+		}
+		region := cfg.S3Gateway.Region
+		if region == "" {
+			region = s3Service.DefaultRegion
+		}
+		app.S3Handler = s3Handler.NewS3Handler(
+			s3Gateway,
+			s3Service.NewSigV4Verifier(region, credentials, app.Clock),
+			region, log)
+		log.Info(ctx, "S3 gateway initialized", map[string]any{"buckets": len(cfg.S3Gateway.Buckets), "credentials": len(credentials)})
+	}
+
 	log.Info(ctx, "Handlers initialized")
 
 	return app, nil
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+)
+
+// DefaultFailedLoginWindow is how far back failed logins are counted when no window is configured.
+const DefaultFailedLoginWindow = 15 * time.Minute
+
+// RedisLoginAttemptTracker implements authPort.LoginAttemptTracker.
+// The failures of each email are members of a sorted set scored by their time, so
+// failures older than the window can be trimmed before counting.
+type RedisLoginAttemptTracker struct {
+	client *RedisClient
+	window time.Duration
+}
+
+// NewRedisLoginAttemptTracker creates a Redis-backed tracker counting failures within window.
+func NewRedisLoginAttemptTracker(client *RedisClient, window time.Duration) authPort.LoginAttemptTracker {
+	if window <= 0 {
+		window = DefaultFailedLoginWindow
+	}
+	return &RedisLoginAttemptTracker{client: client, window: window}
+}
+
+func loginFailuresKey(email string) string {
+	return "auth:login_failures:" + strings.ToLower(email)
+}
+
+// windowStart returns the score of the exclusive lower bound of the window ending at now.
+func (t *RedisLoginAttemptTracker) windowStart(now time.Time) string {
+	return strconv.FormatInt(now.Add(-t.window).UnixNano(), 10)
+}
+
+// RecordFailure records a failure at now and returns the failures within the window.
+func (t *RedisLoginAttemptTracker) RecordFailure(ctx context.Context, email string, now time.Time) (int, error) {
+	key := loginFailuresKey(email)
+
+	pipe := t.client.Client().TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: uuid.NewString()})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", t.windowStart(now))
+	count := pipe.ZCard(ctx, key)
+	pipe.Expire(ctx, key, t.window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return int(count.Val()), nil
+}
+
+// Failures returns the failures within the window ending at now.
+func (t *RedisLoginAttemptTracker) Failures(ctx context.Context, email string, now time.Time) (int, error) {
+	count, err := t.client.Client().ZCount(ctx, loginFailuresKey(email), "("+t.windowStart(now), "+inf").Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count login failures: %w", err)
+	}
+	return int(count), nil
+}
+
+// Reset forgets the failures of email.
+func (t *RedisLoginAttemptTracker) Reset(ctx context.Context, email string) error {
+	if err := t.client.Client().Del(ctx, loginFailuresKey(email)).Err(); err != nil {
+		return fmt.Errorf("failed to reset login failures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	mediaPort "github.com/lugondev/m3-storage/internal/modules/media/port"
+)
+
+// RedisMultipartSessionStore implements mediaPort.MultipartSessionStore.
+// Each upload is a JSON document with its parts in a separate hash, so parts
+// uploaded concurrently do not overwrite each other; a per-user set indexes the uploads.
+type RedisMultipartSessionStore struct {
+	client *RedisClient
+}
+
+// NewRedisMultipartSessionStore creates a Redis-backed multipart session store.
+func NewRedisMultipartSessionStore(client *RedisClient) mediaPort.MultipartSessionStore {
+	return &RedisMultipartSessionStore{client: client}
+}
+
+func multipartUserKey(userID uuid.UUID) string {
+	return "media:multipart:" + userID.String()
+}
+
+func multipartSessionKey(userID, id uuid.UUID) string {
+	return multipartUserKey(userID) + ":" + id.String()
+}
+
+func multipartPartsKey(userID, id uuid.UUID) string {
+	return multipartSessionKey(userID, id) + ":parts"
+}
+
+// Save stores the upload until its ExpiresAt.
+func (s *RedisMultipartSessionStore) Save(ctx context.Context, upload *domain.MultipartUpload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to encode multipart upload: %w", err)
+	}
+	ttl := time.Until(upload.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("multipart upload %s has already expired", upload.ID)
+	}
+
+	userKey := multipartUserKey(upload.UserID)
+	pipe := s.client.Client().TxPipeline()
+	pipe.Set(ctx, multipartSessionKey(upload.UserID, upload.ID), data, ttl)
+	pipe.SAdd(ctx, userKey, upload.ID.String())
+	// Uploads share one TTL, so the newest upload always expires last; stale members are pruned by List.
+	pipe.Expire(ctx, userKey, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to save multipart upload: %w", err)
+	}
+	return nil
+}
+
+// Get returns the upload with its recorded parts, or nil if it does not exist.
+func (s *RedisMultipartSessionStore) Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*domain.MultipartUpload, error) {
+	data, err := s.client.Client().Get(ctx, multipartSessionKey(userID, id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load multipart upload: %w", err)
+	}
+
+	var upload domain.MultipartUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to decode multipart upload: %w", err)
+	}
+
+	rawParts, err := s.client.Client().HGetAll(ctx, multipartPartsKey(userID, id)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load multipart upload parts: %w", err)
+	}
+	upload.Parts = make([]domain.UploadedPart, 0, len(rawParts))
+	for _, raw := range rawParts {
+		var part domain.UploadedPart
+		if err := json.Unmarshal([]byte(raw), &part); err != nil {
+			return nil, fmt.Errorf("failed to decode multipart upload part: %w", err)
+		}
+		upload.Parts = append(upload.Parts, part)
+	}
+	upload.RefreshProgress()
+	return &upload, nil
+}
+
+// List returns the user's unexpired uploads, dropping index entries whose upload has expired.
+func (s *RedisMultipartSessionStore) List(ctx context.Context, userID uuid.UUID) ([]*domain.MultipartUpload, error) {
+	members, err := s.client.Client().SMembers(ctx, multipartUserKey(userID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list multipart uploads: %w", err)
+	}
+
+	uploads := make([]*domain.MultipartUpload, 0, len(members))
+	for _, member := range members {
+		id, err := uuid.Parse(member)
+		if err != nil {
+			continue
+		}
+		upload, err := s.Get(ctx, userID, id)
+		if err != nil {
+			return nil, err
+		}
+		if upload == nil {
+			s.client.Client().SRem(ctx, multipartUserKey(userID), member)
+			continue
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, nil
+}
+
+// AddPart records a completed part; the parts hash expires together with the upload.
+func (s *RedisMultipartSessionStore) AddPart(ctx context.Context, upload *domain.MultipartUpload, part domain.UploadedPart) error {
+	data, err := json.Marshal(part)
+	if err != nil {
+		return fmt.Errorf("failed to encode multipart upload part: %w", err)
+	}
+
+	partsKey := multipartPartsKey(upload.UserID, upload.ID)
+	pipe := s.client.Client().TxPipeline()
+	pipe.HSet(ctx, partsKey, strconv.Itoa(part.PartNumber), data)
+	pipe.ExpireAt(ctx, partsKey, upload.ExpiresAt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to record multipart upload part: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the upload and its recorded parts.
+func (s *RedisMultipartSessionStore) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	pipe := s.client.Client().TxPipeline()
+	pipe.Del(ctx, multipartSessionKey(userID, id), multipartPartsKey(userID, id))
+	pipe.SRem(ctx, multipartUserKey(userID), id.String())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to delete multipart upload: %w", err)
+	}
+	return nil
+}
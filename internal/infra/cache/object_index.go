@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// RedisObjectIndex implements storagePort.ObjectIndex.
+// Records of each provider are JSON documents in one hash, and a sorted set with
+// equal scores keeps the keys in lexicographic order for prefix listing.
+type RedisObjectIndex struct {
+	client *RedisClient
+}
+
+// NewRedisObjectIndex creates a Redis-backed object index.
+func NewRedisObjectIndex(client *RedisClient) storagePort.ObjectIndex {
+	return &RedisObjectIndex{client: client}
+}
+
+func objectIndexKey(providerType storagePort.StorageProviderType) string {
+	return "storage:index:" + string(providerType)
+}
+
+func objectIndexKeysKey(providerType storagePort.StorageProviderType) string {
+	return objectIndexKey(providerType) + ":keys"
+}
+
+// Put records obj under obj.Key.
+func (s *RedisObjectIndex) Put(ctx context.Context, providerType storagePort.StorageProviderType, obj *storagePort.IndexedObject) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return fmt.Errorf("failed to encode indexed object: %w", err)
+	}
+
+	pipe := s.client.Client().TxPipeline()
+	pipe.HSet(ctx, objectIndexKey(providerType), obj.Key, data)
+	pipe.ZAdd(ctx, objectIndexKeysKey(providerType), redis.Z{Member: obj.Key})
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to index object %s: %w", obj.Key, err)
+	}
+	return nil
+}
+
+// Get returns the record for key, or nil if it is not indexed.
+func (s *RedisObjectIndex) Get(ctx context.Context, providerType storagePort.StorageProviderType, key string) (*storagePort.IndexedObject, error) {
+	data, err := s.client.Client().HGet(ctx, objectIndexKey(providerType), key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load indexed object %s: %w", key, err)
+	}
+
+	var obj storagePort.IndexedObject
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("failed to decode indexed object %s: %w", key, err)
+	}
+	return &obj, nil
+}
+
+// Delete removes the record for key.
+func (s *RedisObjectIndex) Delete(ctx context.Context, providerType storagePort.StorageProviderType, key string) error {
+	pipe := s.client.Client().TxPipeline()
+	pipe.HDel(ctx, objectIndexKey(providerType), key)
+	pipe.ZRem(ctx, objectIndexKeysKey(providerType), key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove indexed object %s: %w", key, err)
+	}
+	return nil
+}
+
+// List returns up to limit records under prefix in key order, starting after startAfter.
+func (s *RedisObjectIndex) List(ctx context.Context, providerType storagePort.StorageProviderType, prefix, startAfter string, limit int) ([]*storagePort.IndexedObject, error) {
+	lower := "-"
+	if prefix != "" {
+		lower = "[" + prefix
+	}
+	if startAfter != "" && startAfter >= prefix {
+		lower = "(" + startAfter
+	}
+
+	keys, err := s.client.Client().ZRangeByLex(ctx, objectIndexKeysKey(providerType), &redis.ZRangeBy{
+		Min:   lower,
+		Max:   "+",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexed objects: %w", err)
+	}
+	// Keys are sorted, so the first key outside the prefix ends the listing.
+	for i, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			keys = keys[:i]
+			break
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	values, err := s.client.Client().HMGet(ctx, objectIndexKey(providerType), keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load indexed objects: %w", err)
+	}
+	objects := make([]*storagePort.IndexedObject, 0, len(values))
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue // The record was deleted between the two reads
+		}
+		var obj storagePort.IndexedObject
+		if err := json.Unmarshal([]byte(data), &obj); err != nil {
+			return nil, fmt.Errorf("failed to decode indexed object %s: %w", keys[i], err)
+		}
+		objects = append(objects, &obj)
+	}
+	return objects, nil
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+)
+
+// DefaultTokenRevocationTTL is how long revocations are kept when no access token lifetime
+// is configured; it matches the auth service's default access token lifetime.
+const DefaultTokenRevocationTTL = 15 * time.Minute
+
+// RedisTokenRevocationStore implements authPort.TokenRevocationStore.
+// Each user with revoked tokens has a key holding the revocation time, kept for as long
+// as an access token issued before it can still be valid.
+type RedisTokenRevocationStore struct {
+	client   *RedisClient
+	tokenTTL time.Duration
+}
+
+// NewRedisTokenRevocationStore creates a Redis-backed store for access tokens living tokenTTL.
+func NewRedisTokenRevocationStore(client *RedisClient, tokenTTL time.Duration) authPort.TokenRevocationStore {
+	if tokenTTL <= 0 {
+		tokenTTL = DefaultTokenRevocationTTL
+	}
+	return &RedisTokenRevocationStore{client: client, tokenTTL: tokenTTL}
+}
+
+func tokenRevocationKey(userID uuid.UUID) string {
+	return "auth:tokens_revoked:" + userID.String()
+}
+
+// RevokeUserTokens records that tokens of userID issued up to at are no longer valid.
+func (s *RedisTokenRevocationStore) RevokeUserTokens(ctx context.Context, userID uuid.UUID, at time.Time) error {
+	value := strconv.FormatInt(at.Unix(), 10)
+	if err := s.client.Client().Set(ctx, tokenRevocationKey(userID), value, s.tokenTTL).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// RevokedAt returns when the tokens of userID were last revoked, if that can still matter.
+func (s *RedisTokenRevocationStore) RevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, bool, error) {
+	value, err := s.client.Client().Get(ctx, tokenRevocationKey(userID)).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read token revocation: %w", err)
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid token revocation value %q: %w", value, err)
+	}
+	return time.Unix(seconds, 0), true, nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	mediaPort "github.com/lugondev/m3-storage/internal/modules/media/port"
+)
+
+// RedisTusUploadStore implements mediaPort.TusUploadStore with one JSON document per upload.
+type RedisTusUploadStore struct {
+	client *RedisClient
+}
+
+// NewRedisTusUploadStore creates a Redis-backed tus upload store.
+func NewRedisTusUploadStore(client *RedisClient) mediaPort.TusUploadStore {
+	return &RedisTusUploadStore{client: client}
+}
+
+func tusUploadKey(userID, id uuid.UUID) string {
+	return "media:tus:" + userID.String() + ":" + id.String()
+}
+
+// Save stores the upload until its ExpiresAt.
+func (s *RedisTusUploadStore) Save(ctx context.Context, upload *domain.TusUpload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return fmt.Errorf("failed to encode tus upload: %w", err)
+	}
+	ttl := time.Until(upload.ExpiresAt)
+	if ttl <= 0 {
+		return fmt.Errorf("tus upload %s has already expired", upload.ID)
+	}
+	if err := s.client.Client().Set(ctx, tusUploadKey(upload.UserID, upload.ID), data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save tus upload: %w", err)
+	}
+	return nil
+}
+
+// Get returns the upload, or nil if it does not exist.
+func (s *RedisTusUploadStore) Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*domain.TusUpload, error) {
+	data, err := s.client.Client().Get(ctx, tusUploadKey(userID, id)).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load tus upload: %w", err)
+	}
+
+	var upload domain.TusUpload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return nil, fmt.Errorf("failed to decode tus upload: %w", err)
+	}
+	return &upload, nil
+}
+
+// Delete removes the upload.
+func (s *RedisTusUploadStore) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	if err := s.client.Client().Del(ctx, tusUploadKey(userID, id)).Err(); err != nil {
+		return fmt.Errorf("failed to delete tus upload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// Package clamav scans files for malware with a ClamAV clamd daemon.
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// chunkSize is the largest chunk streamed to clamd in one INSTREAM frame.
+const chunkSize = 64 * 1024
+
+// Scanner sends files to clamd over TCP with the INSTREAM command. Each scan uses
+// its own connection, so a Scanner is safe for concurrent use.
+type Scanner struct {
+	address string
+	timeout time.Duration
+}
+
+// NewScanner returns a Scanner for the clamd daemon listening at address (host:port).
+// timeout bounds a whole scan, from connecting to reading the verdict.
+func NewScanner(address string, timeout time.Duration) *Scanner {
+	return &Scanner{address: address, timeout: timeout}
+}
+
+// Scan streams r to clamd. It reports clean == false with the signature name in details
+// when clamd finds malware. Files larger than clamd's StreamMaxLength fail with an error.
+func (s *Scanner) Scan(ctx context.Context, r io.Reader) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	// Unblock reads and writes when the caller gives up
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if err := stream(conn, r); err != nil {
+		return false, "", err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return false, "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseReply(reply)
+}
+
+// stream writes r as a zINSTREAM command: length-prefixed chunks ending with an empty one.
+func stream(w io.Writer, r io.Reader) error {
+	if _, err := io.WriteString(w, "zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("failed to send clamd command: %w", err)
+	}
+	buf := make([]byte, 4+chunkSize)
+	for {
+		n, err := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("failed to stream file to clamd: %w", werr)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read file for scanning: %w", err)
+		}
+	}
+	if _, err := w.Write([]byte{0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("failed to finish clamd stream: %w", err)
+	}
+	return nil
+}
+
+// parseReply interprets "stream: OK", "stream: <signature> FOUND" and "<message> ERROR".
+func parseReply(reply string) (bool, string, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return true, "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return false, strings.TrimSuffix(result, " FOUND"), nil
+	case strings.HasSuffix(result, " ERROR"):
+		return false, "", fmt.Errorf("clamd: %s", strings.TrimSuffix(result, " ERROR"))
+	default:
+		return false, "", fmt.Errorf("unexpected clamd reply %q", reply)
+	}
+}
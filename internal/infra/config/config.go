@@ -19,6 +19,8 @@ type AppConfig struct {
 	Secret    string `mapstructure:"secret"`
 	ClientURL string `mapstructure:"clientUrl"`
 	Origins   string `mapstructure:"origins"`
+	// Seconds shutdown waits for requests and uploads in flight before cancelling them (default: 30)
+	ShutdownTimeoutSeconds int `mapstructure:"shutdownTimeoutSeconds"`
 }
 
 // DBConfig stores database-specific configuration.
@@ -30,6 +32,10 @@ type DBConfig struct {
 	Name     string `mapstructure:"name"`
 	SslMode  string `mapstructure:"sslMode"`
 	LogLevel string `mapstructure:"logLevel"`
+
+	MaxIdleConns           int `mapstructure:"maxIdleConns"`           // Idle connections kept in the pool (default: 10)
+	MaxOpenConns           int `mapstructure:"maxOpenConns"`           // Open connections allowed at once (default: 100)
+	ConnMaxLifetimeSeconds int `mapstructure:"connMaxLifetimeSeconds"` // Seconds a connection may be reused before it is replaced (default: 3600)
 }
 
 // RedisConfig stores Redis-specific configuration.
@@ -48,22 +54,41 @@ type LogConfig struct {
 	Format string `mapstructure:"format"`
 }
 
+// SignedURLConfig sets how long signed URLs issued by a provider stay valid.
+type SignedURLConfig struct {
+	DefaultExpiry time.Duration `mapstructure:"defaultExpiry"` // Validity when the caller does not ask for one (default: 1h)
+	MaxExpiry     time.Duration `mapstructure:"maxExpiry"`     // Longer requests are shortened to this (default: 168h)
+}
+
 // FireStoreConfig holds Firestore specific configuration.
 type FireStoreConfig struct {
 	ProjectID       string `mapstructure:"projectID"`
 	CredentialsFile string `mapstructure:"credentialsFile"`
 	BucketName      string `mapstructure:"bucketName"`
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // S3Config holds S3 specific configuration.
 type S3Config struct {
-	AccessKeyID     string `mapstructure:"accessKeyID"`
+	AccessKeyID     string `mapstructure:"accessKeyID"` // Leave both keys empty to use the default AWS credential chain
 	SecretAccessKey string `mapstructure:"secretAccessKey"`
 	Region          string `mapstructure:"region"`
 	BucketName      string `mapstructure:"bucketName"`
 	Endpoint        string `mapstructure:"endpoint"`
 	DisableSSL      bool   `mapstructure:"disableSSL"`
 	ForcePathStyle  bool   `mapstructure:"forcePathStyle"`
+
+	ServerSideEncryption string `mapstructure:"serverSideEncryption"` // Default encryption for uploads ("AES256" or "aws:kms"); UploadOptions override it
+	SSEKMSKeyID          string `mapstructure:"sseKMSKeyID"`          // Default KMS key for "aws:kms" encryption
+
+	PublicBaseURL string `mapstructure:"publicBaseURL"` // Optional: base URL (e.g. a CDN) for object URLs instead of the endpoint
+	ProviderLabel string `mapstructure:"providerLabel"` // Optional: provider type reported for this bucket (e.g. "s3"); guessed from the endpoint when empty
+
+	AssumeRoleARN        string `mapstructure:"assumeRoleARN"`        // Optional: role assumed through STS on top of the base credentials
+	AssumeRoleExternalID string `mapstructure:"assumeRoleExternalID"` // Optional: external ID required by the role's trust policy
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // CloudflareConfig holds Cloudflare R2 specific configuration.
@@ -73,6 +98,8 @@ type CloudflareConfig struct {
 	SecretAccessKey string `mapstructure:"secretAccessKey"`
 	BucketName      string `mapstructure:"bucketName"`
 	PublicDomain    string `mapstructure:"publicDomain"`
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // ToS3Config converts CloudflareConfig to S3Config for use with S3-compatible APIs
@@ -84,29 +111,42 @@ func (c CloudflareConfig) ToS3Config() S3Config {
 		Endpoint:        fmt.Sprintf("https://%s.r2.cloudflarestorage.com", c.AccountID),
 		Region:          "auto", // R2 uses "auto" as region
 		ForcePathStyle:  true,   // R2 requires path-style addressing
+		ProviderLabel:   "cloudflare_r2",
 	}
 }
 
 // DiscordConfig holds Discord specific configuration.
 type DiscordConfig struct {
-	BotToken   string `mapstructure:"botToken"`
-	ChannelID  string `mapstructure:"channelID"`
-	WebhookURL string `mapstructure:"webhookURL"`
+	BotToken       string `mapstructure:"botToken"`
+	ChannelID      string `mapstructure:"channelID"`
+	WebhookURL     string `mapstructure:"webhookURL"`     // Uploads go through the webhook when BotToken is empty
+	ChunkSizeBytes int64  `mapstructure:"chunkSizeBytes"` // Largest attachment per message; bigger files are split (default 8 MiB)
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // LocalStorageConfig holds local adapters specific configuration.
 type LocalStorageConfig struct {
 	Path            string        `mapstructure:"path"`
 	BaseURL         string        `mapstructure:"baseURL"`
-	SignedURLExpiry time.Duration `mapstructure:"signedUrlExpiry"` // Default expiry for signed URLs
-	SignedURLSecret string        `mapstructure:"signedUrlSecret"` // Secret key for signing URLs (basic example)
+	SignedURLExpiry time.Duration `mapstructure:"signedUrlExpiry"` // Default expiry for signed URLs when signedURL.defaultExpiry is unset
+	SignedURLSecret string        `mapstructure:"signedUrlSecret"` // HMAC key for signed URLs, served under the path of BaseURL
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
+// AzureConfig holds Azure Blob Storage specific configuration. The first credential set is
+// used: ConnectionString, AccountKey, SASToken, and otherwise DefaultAzureCredential
+// (managed identity, workload identity or AZURE_* environment variables).
 type AzureConfig struct {
-	AccountName   string `mapstructure:"accountName"`
-	AccountKey    string `mapstructure:"accountKey"`
-	ContainerName string `mapstructure:"containerName"`
-	ServiceURL    string `mapstructure:"serviceUrl"`
+	AccountName      string `mapstructure:"accountName"`
+	AccountKey       string `mapstructure:"accountKey"`
+	ContainerName    string `mapstructure:"containerName"`
+	ServiceURL       string `mapstructure:"serviceUrl"`
+	ConnectionString string `mapstructure:"connectionString"` // Optional: replaces accountName, accountKey and serviceUrl
+	SASToken         string `mapstructure:"sasToken"`         // Optional: account or container SAS token used instead of a key
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // ScalewayConfig holds Scaleway Object Storage specific configuration
@@ -116,6 +156,29 @@ type ScalewayConfig struct {
 	Region          string `mapstructure:"region"`          // Region (e.g., fr-par, nl-ams)
 	BucketName      string `mapstructure:"bucketName"`      // The bucket name
 	Endpoint        string `mapstructure:"endpoint"`        // Optional: Custom endpoint URL
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
+}
+
+// DigitalOceanConfig holds DigitalOcean Spaces specific configuration
+type DigitalOceanConfig struct {
+	AccessKeyID     string `mapstructure:"accessKeyID"`     // Spaces Access Key ID
+	SecretAccessKey string `mapstructure:"secretAccessKey"` // Spaces Secret Access Key
+	Region          string `mapstructure:"region"`          // Region (e.g., nyc3, ams3, sgp1)
+	BucketName      string `mapstructure:"bucketName"`      // The Space name
+	CDNEndpoint     string `mapstructure:"cdnEndpoint"`     // Optional: CDN endpoint used for object URLs (e.g., https://my-space.nyc3.cdn.digitaloceanspaces.com)
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
+}
+
+// WasabiConfig holds Wasabi Hot Cloud Storage specific configuration
+type WasabiConfig struct {
+	AccessKeyID     string `mapstructure:"accessKeyID"`     // Wasabi Access Key
+	SecretAccessKey string `mapstructure:"secretAccessKey"` // Wasabi Secret Key
+	Region          string `mapstructure:"region"`          // Region (e.g., us-east-1, eu-central-1); defaults to us-east-1
+	BucketName      string `mapstructure:"bucketName"`      // The bucket name
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // BackBlazeConfig holds Backblaze B2 specific configuration
@@ -126,6 +189,8 @@ type BackBlazeConfig struct {
 	BucketName     string `mapstructure:"bucketName"`     // Bucket Name
 	Region         string `mapstructure:"region"`         // Optional: Region (e.g., us-west-002)
 	Endpoint       string `mapstructure:"endpoint"`       // Optional: Custom endpoint URL
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // MinIOConfig holds MinIO specific configuration
@@ -136,6 +201,18 @@ type MinIOConfig struct {
 	Endpoint        string `mapstructure:"endpoint"`        // MinIO Server Endpoint URL
 	Region          string `mapstructure:"region"`          // Optional: MinIO Region
 	UseSSL          bool   `mapstructure:"useSSL"`          // Whether to use SSL/TLS
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
+}
+
+// OSSConfig holds Alibaba Cloud OSS specific configuration
+type OSSConfig struct {
+	Endpoint        string `mapstructure:"endpoint"`        // Region endpoint (e.g., oss-cn-hangzhou.aliyuncs.com); https is assumed without a scheme
+	AccessKeyID     string `mapstructure:"accessKeyID"`     // RAM Access Key ID
+	AccessKeySecret string `mapstructure:"accessKeySecret"` // RAM Access Key Secret
+	BucketName      string `mapstructure:"bucketName"`      // Bucket Name
+
+	SignedURL SignedURLConfig `mapstructure:"signedURL"` // Optional: default and maximum validity of signed URLs
 }
 
 // ToS3Config converts BackBlazeConfig to S3Config for use with S3-compatible API
@@ -151,6 +228,7 @@ func (c BackBlazeConfig) ToS3Config() S3Config {
 		BucketName:      c.BucketName,
 		Endpoint:        endpoint,
 		ForcePathStyle:  true, // BackBlaze requires path-style addressing
+		ProviderLabel:   "backblaze",
 	}
 }
 
@@ -168,6 +246,39 @@ func (c ScalewayConfig) ToS3Config() S3Config {
 		BucketName:      c.BucketName,
 		Endpoint:        endpoint,
 		ForcePathStyle:  true, // Scaleway requires path-style addressing
+		ProviderLabel:   "scaleway",
+	}
+}
+
+// ToS3Config converts DigitalOceanConfig to S3Config for use with S3-compatible API
+func (c DigitalOceanConfig) ToS3Config() S3Config {
+	return S3Config{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		Region:          c.Region,
+		BucketName:      c.BucketName,
+		Endpoint:        fmt.Sprintf("https://%s.digitaloceanspaces.com", c.Region),
+		ForcePathStyle:  false, // Spaces serves buckets as subdomains of the regional endpoint
+		PublicBaseURL:   c.CDNEndpoint,
+		ProviderLabel:   "digitalocean",
+	}
+}
+
+// ToS3Config converts WasabiConfig to S3Config for use with S3-compatible API
+func (c WasabiConfig) ToS3Config() S3Config {
+	region := c.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return S3Config{
+		AccessKeyID:     c.AccessKeyID,
+		SecretAccessKey: c.SecretAccessKey,
+		Region:          region,
+		BucketName:      c.BucketName,
+		Endpoint:        fmt.Sprintf("https://s3.%s.wasabisys.com", region),
+		ForcePathStyle:  true, // Path-style works for every bucket name, including ones with dots
+		ProviderLabel:   "wasabi",
 	}
 }
 
@@ -187,19 +298,290 @@ func (c MinIOConfig) ToS3Config() S3Config {
 		Endpoint:        endpoint,
 		ForcePathStyle:  true,      // MinIO requires path-style addressing
 		DisableSSL:      !c.UseSSL, // Convert UseSSL to DisableSSL
+		ProviderLabel:   "minio",
 	}
 }
 
+// AuthConfig holds authentication configuration.
+type AuthConfig struct {
+	RequireVerifiedEmail     bool      `mapstructure:"requireVerifiedEmail"`     // Reject logins from accounts whose email is not verified
+	AccessTokenTTLSeconds    int       `mapstructure:"accessTokenTTLSeconds"`    // Access token lifetime (default: 900)
+	RefreshTokenTTLSeconds   int       `mapstructure:"refreshTokenTTLSeconds"`   // Refresh token lifetime (default: 604800, 7 days)
+	MaxFailedAttempts        int       `mapstructure:"maxFailedAttempts"`        // Failed logins within the window that lock the account (default: 5)
+	FailedLoginWindowSeconds int       `mapstructure:"failedLoginWindowSeconds"` // Sliding window failed logins are counted over, per email (default: 900)
+	AccountLockSeconds       int       `mapstructure:"accountLockSeconds"`       // How long a locked account stays locked (default: 1800)
+	BcryptCost               int       `mapstructure:"bcryptCost"`               // bcrypt cost for new password hashes, 4-31 (default: 10)
+	AvatarMaxBytes           int64     `mapstructure:"avatarMaxBytes"`           // Largest avatar image accepted by POST /auth/avatar (default: 2097152, 2 MiB)
+	PasswordResetURL         string    `mapstructure:"passwordResetUrl"`         // Page linked from reset emails, given the token as its token query parameter (default: app.clientUrl + "/reset-password")
+	JWT                      JWTConfig `mapstructure:"jwt"`
+
+	PasswordPolicy PasswordPolicyConfig `mapstructure:"passwordPolicy"`
+}
+
+// PasswordPolicyConfig sets the rules for passwords chosen at registration, password
+// change and reset. Existing passwords are not rechecked.
+type PasswordPolicyConfig struct {
+	MinLength     int  `mapstructure:"minLength"`     // Minimum length in characters (default: 8)
+	RequireUpper  bool `mapstructure:"requireUpper"`  // Require an upper case letter
+	RequireLower  bool `mapstructure:"requireLower"`  // Require a lower case letter
+	RequireDigit  bool `mapstructure:"requireDigit"`  // Require a digit
+	RequireSymbol bool `mapstructure:"requireSymbol"` // Require a character that is not a letter, digit or space
+	AllowCommon   bool `mapstructure:"allowCommon"`   // Accept passwords from the built-in common password list
+}
+
+// JWTConfig holds the RS256 signing keys. When no keys are configured, tokens are
+// signed with HS256 using app.secret.
+type JWTConfig struct {
+	ActiveKeyID string         `mapstructure:"activeKeyId"` // kid of the key that signs new tokens; it needs a private key
+	Keys        []JWTKeyConfig `mapstructure:"keys"`
+}
+
+// JWTKeyConfig points to the PEM files of one RS256 key.
+type JWTKeyConfig struct {
+	ID             string `mapstructure:"id"`             // kid placed in token headers and the JWKS
+	PrivateKeyFile string `mapstructure:"privateKeyFile"` // PKCS#1 or PKCS#8 PEM; only needed while the key signs tokens
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`  // PKIX or PKCS#1 PEM; derived from the private key when empty
+}
+
+// StorageConfig holds provider-independent storage configuration.
+type StorageConfig struct {
+	Aliases   map[string]string `mapstructure:"aliases"`   // Client-facing alias -> backend name (e.g., primary: s3)
+	Providers map[string]bool   `mapstructure:"providers"` // Backend name -> enabled; unlisted backends are enabled when their required settings are present
+	// Named backends in addition to the default backend of each provider type, which is
+	// named after the type and configured by its top-level section
+	Backends       []StorageBackendConfig `mapstructure:"backends"`
+	HealthGate     HealthGateConfig       `mapstructure:"healthGate"`
+	Retry          RetryConfig            `mapstructure:"retry"`
+	CircuitBreaker CircuitBreakerConfig   `mapstructure:"circuitBreaker"`
+	// Seconds /storage/health/all serves cached results before checking providers again (default: 30, negative disables)
+	HealthCacheTTLSeconds int `mapstructure:"healthCacheTTLSeconds"`
+}
+
+// CircuitBreakerConfig controls the per-provider circuit breaker. After FailureThreshold
+// consecutive failures, calls to the provider fail fast until the cooldown has passed.
+type CircuitBreakerConfig struct {
+	Enabled             bool `mapstructure:"enabled"`
+	FailureThreshold    int  `mapstructure:"failureThreshold"`    // Consecutive failures that open the circuit (default: 5)
+	CooldownSeconds     int  `mapstructure:"cooldownSeconds"`     // Seconds the circuit stays open before a trial call (default: 30)
+	HalfOpenMaxRequests int  `mapstructure:"halfOpenMaxRequests"` // Trial calls allowed while half-open (default: 1)
+}
+
+// RetryConfig controls retries of provider calls that fail with transient errors
+// (timeouts, 429 and 5xx responses). Upload, Download, Delete and GetObject are retried.
+type RetryConfig struct {
+	MaxAttempts int `mapstructure:"maxAttempts"` // Total attempts per call; 0 or 1 disables retries
+	BaseDelayMs int `mapstructure:"baseDelayMs"` // Backoff before the second attempt, doubled for each further attempt (default: 200)
+	MaxDelayMs  int `mapstructure:"maxDelayMs"`  // Upper bound of a single backoff (default: 5000)
+}
+
+// HealthGateConfig controls the background provider health monitor and the
+// pre-upload gate that rejects uploads to providers it reports unhealthy.
+type HealthGateConfig struct {
+	Enabled           bool            `mapstructure:"enabled"`           // Reject uploads to unhealthy providers with 503
+	IntervalSeconds   int             `mapstructure:"intervalSeconds"`   // Seconds between background health checks (default: 30)
+	TimeoutSeconds    int             `mapstructure:"timeoutSeconds"`    // Timeout for a single provider check (default: 10)
+	RetryAfterSeconds int             `mapstructure:"retryAfterSeconds"` // Retry-After sent with rejected uploads (default: the check interval)
+	Providers         map[string]bool `mapstructure:"providers"`         // Per-backend override of Enabled (e.g., discord: false to let a flaky provider through)
+}
+
+// MediaConfig holds media processing configuration.
+type MediaConfig struct {
+	FFmpegPath        string               `mapstructure:"ffmpegPath"`        // Path to the ffmpeg binary (default: "ffmpeg" on PATH)
+	FFprobePath       string               `mapstructure:"ffprobePath"`       // Path to the ffprobe binary (default: "ffprobe" on PATH)
+	StrictContentType bool                 `mapstructure:"strictContentType"` // Reject uploads whose bytes contradict the extension/declared type instead of storing the detected type
+	StripEXIF         bool                 `mapstructure:"stripExif"`         // Re-encode JPEG uploads without EXIF (camera, GPS) before storing them
+	DuplicateUploads  string               `mapstructure:"duplicateUploads"`  // Re-upload of content the user already has: "flag" (default) stores it with duplicate_of set, "dedupe" returns the existing media
+	MaxImageSize      string               `mapstructure:"maxImageSize"`      // Largest image file MediaValidator accepts, e.g. "5MB" (default: 5MB)
+	MaxVideoSize      string               `mapstructure:"maxVideoSize"`      // Largest video file (default: 50MB)
+	MaxAudioSize      string               `mapstructure:"maxAudioSize"`      // Largest audio file (default: 10MB)
+	MaxDocumentSize   string               `mapstructure:"maxDocumentSize"`   // Largest document: PDF, Word, text or Markdown (default: 10MB)
+	VideoSprite       VideoSpriteConfig    `mapstructure:"videoSprite"`
+	VideoPoster       VideoPosterConfig    `mapstructure:"videoPoster"`
+	Transcode         TranscodeConfig      `mapstructure:"transcode"`
+	Thumbnail         ThumbnailConfig      `mapstructure:"thumbnail"`
+	ImageResize       ImageResizeConfig    `mapstructure:"imageResize"`
+	Migration         JobConfig            `mapstructure:"migration"` // Moving media between providers
+	ZipDownload       ZipDownloadConfig    `mapstructure:"zipDownload"`
+	Share             ShareConfig          `mapstructure:"share"`
+	VirusScan         VirusScanConfig      `mapstructure:"virusScan"`
+	Tus               TusConfig            `mapstructure:"tus"`
+	Bandwidth         BandwidthConfig      `mapstructure:"bandwidth"`
+	SignedURLCache    SignedURLCacheConfig `mapstructure:"signedURLCache"`
+
+	UsageReconcile UsageReconcileConfig `mapstructure:"usageReconcile"` // Recounting users' storage usage from their media
+}
+
+// SignedURLCacheConfig controls reuse of signed URLs through Redis. Without it, only
+// concurrent requests in one instance share a provider call.
+type SignedURLCacheConfig struct {
+	Enabled             bool `mapstructure:"enabled"`             // Reuse the signed URL of an object and validity until shortly before it expires
+	MinRemainingPercent int  `mapstructure:"minRemainingPercent"` // Share of the requested validity a reused URL must still have (default: 50; never less than a minute)
+}
+
+// TusConfig controls resumable uploads over the tus protocol. Received bytes are kept on
+// local disk until the upload completes, so every request of an upload must reach an
+// instance that shares TempDir.
+type TusConfig struct {
+	TempDir       string `mapstructure:"tempDir"`       // Directory for incomplete uploads (default: <os temp dir>/m3-storage-tus)
+	MaxSize       int64  `mapstructure:"maxSize"`       // Largest Upload-Length accepted (default: 10 GiB)
+	ExpirySeconds int    `mapstructure:"expirySeconds"` // How long an unfinished upload can be resumed (default: 86400)
+}
+
+// VirusScanConfig selects the malware scanner run on uploads before they are stored.
+type VirusScanConfig struct {
+	Provider       string `mapstructure:"provider"`       // "clamav" scans with clamd; empty disables scanning
+	Address        string `mapstructure:"address"`        // clamd TCP address (default: "localhost:3310")
+	TimeoutSeconds int    `mapstructure:"timeoutSeconds"` // Limit on one scan, including the connection (default: 60)
+	FailOpen       bool   `mapstructure:"failOpen"`       // Store uploads when the scanner cannot be reached instead of rejecting them
+}
+
+// ShareConfig bounds the lifetime of public share links.
+type ShareConfig struct {
+	DefaultExpirySeconds int `mapstructure:"defaultExpirySeconds"` // Lifetime of a link created without expires_in (default: 604800, 7 days)
+	MaxExpirySeconds     int `mapstructure:"maxExpirySeconds"`     // Longest lifetime a link may be given (default: 2592000, 30 days)
+}
+
+// ZipDownloadConfig caps multi-file ZIP downloads.
+type ZipDownloadConfig struct {
+	MaxFiles      int   `mapstructure:"maxFiles"`      // Most media files per archive (default: 100)
+	MaxTotalBytes int64 `mapstructure:"maxTotalBytes"` // Largest combined size of the files in an archive (default: 1 GiB)
+}
+
+// BandwidthConfig paces the byte streams between clients and providers: uploads, part
+// uploads, downloads and ZIP downloads. Both limits apply at once; 0 means unlimited.
+type BandwidthConfig struct {
+	BytesPerSecond        int64 `mapstructure:"bytesPerSecond"`        // Shared by every transfer on this instance
+	PerUserBytesPerSecond int64 `mapstructure:"perUserBytesPerSecond"` // Shared by each user's transfers, unless the user has a limit of their own
+}
+
+// UsageReconcileConfig schedules the job that recounts every user's storage usage from
+// their media records. The job reads no objects, so the bandwidth limit does not apply.
+type UsageReconcileConfig struct {
+	JobConfig       `mapstructure:",squash"`
+	IntervalSeconds int `mapstructure:"intervalSeconds"` // Seconds between scheduled runs (default: 86400); negative disables the schedule
+}
+
+// JobConfig tunes a long-running background job so it does not overwhelm providers or the database.
+type JobConfig struct {
+	Workers                 int   `mapstructure:"workers"`                 // Items processed at once (default: 4)
+	BatchSize               int   `mapstructure:"batchSize"`               // Items loaded and committed per batch (default: 100)
+	BandwidthBytesPerSecond int64 `mapstructure:"bandwidthBytesPerSecond"` // Transfer limit shared by all workers; 0 means unlimited
+}
+
+// ImageResizeConfig limits on-the-fly image resizing.
+type ImageResizeConfig struct {
+	MaxDimension    int `mapstructure:"maxDimension"`    // Largest width or height a client may request (default: 2048)
+	MaxSourcePixels int `mapstructure:"maxSourcePixels"` // Larger source images are not decoded, also for thumbnails (default: 40000000)
+	Quality         int `mapstructure:"quality"`         // JPEG quality, 1-100 (default: 85)
+}
+
+// ThumbnailConfig controls thumbnail generation for uploaded images.
+type ThumbnailConfig struct {
+	Enabled       bool `mapstructure:"enabled"`       // Generate a JPEG thumbnail for image uploads, and on demand when one is missing
+	Size          int  `mapstructure:"size"`          // Longest edge of the thumbnail in pixels (default: 300)
+	Quality       int  `mapstructure:"quality"`       // JPEG quality, 1-100 (default: 80)
+	MaxConcurrent int  `mapstructure:"maxConcurrent"` // Max background thumbnail jobs running at once (default: 4)
+}
+
+// VideoSpriteConfig controls scrubbing-preview sprite generation for uploaded videos.
+type VideoSpriteConfig struct {
+	Enabled            bool `mapstructure:"enabled"`            // Generate a sprite + WebVTT for video uploads (requires ffmpeg)
+	IntervalSeconds    int  `mapstructure:"intervalSeconds"`    // Seconds between sampled frames (default: 10)
+	Columns            int  `mapstructure:"columns"`            // Frames per sprite row (default: 10)
+	ThumbWidth         int  `mapstructure:"thumbWidth"`         // Width of each frame in pixels (default: 160)
+	ThumbHeight        int  `mapstructure:"thumbHeight"`        // Height of each frame in pixels (default: 90)
+	MaxDurationSeconds int  `mapstructure:"maxDurationSeconds"` // Only the first N seconds are sampled (default: 600)
+	MaxConcurrent      int  `mapstructure:"maxConcurrent"`      // Max sprite jobs running at once (default: 2)
+}
+
+// VideoPosterConfig controls poster frame extraction for uploaded videos. The poster is
+// stored and served as the video's thumbnail.
+type VideoPosterConfig struct {
+	Enabled       bool `mapstructure:"enabled"`       // Extract a JPEG poster frame for video uploads (requires ffmpeg)
+	OffsetSeconds int  `mapstructure:"offsetSeconds"` // Time of the frame in the video; half the duration for shorter videos (default: 3)
+	Width         int  `mapstructure:"width"`         // Largest poster width in pixels; the aspect ratio is kept (default: 640)
+	MaxConcurrent int  `mapstructure:"maxConcurrent"` // Max poster jobs running at once (default: 2)
+}
+
+// TranscodeConfig controls the background transcoding of uploaded videos into
+// web-friendly renditions.
+type TranscodeConfig struct {
+	Enabled           bool   `mapstructure:"enabled"`           // Transcode video uploads to H.264/AAC MP4 (requires ffmpeg)
+	HLS               bool   `mapstructure:"hls"`               // Also segment the MP4 into an HLS rendition
+	Workers           int    `mapstructure:"workers"`           // Videos transcoded at once (default: 1)
+	QueueSize         int    `mapstructure:"queueSize"`         // Videos waiting for a worker; uploads beyond it are marked failed (default: 100)
+	MaxHeight         int    `mapstructure:"maxHeight"`         // Taller videos are scaled down to this height (default: 1080)
+	CRF               int    `mapstructure:"crf"`               // x264 constant rate factor; lower is better and larger (default: 23)
+	Preset            string `mapstructure:"preset"`            // x264 preset (default: "veryfast")
+	HLSSegmentSeconds int    `mapstructure:"hlsSegmentSeconds"` // Target HLS segment length (default: 6)
+	TimeoutSeconds    int    `mapstructure:"timeoutSeconds"`    // Longest a single video may take, including download and upload (default: 3600)
+}
+
+// S3GatewayConfig controls the S3-compatible API, served on a port of its own so S3
+// clients such as the AWS CLI and rclone can use the storage backends directly.
+type S3GatewayConfig struct {
+	Enabled       bool                  `mapstructure:"enabled"`
+	Port          string                `mapstructure:"port"`          // Port of the S3 listener (default: "8084")
+	Region        string                `mapstructure:"region"`        // Region clients sign requests for (default: "us-east-1")
+	Buckets       map[string]string     `mapstructure:"buckets"`       // Bucket name -> storage backend or alias holding its objects
+	MaxObjectSize int64                 `mapstructure:"maxObjectSize"` // Largest object a PUT may store (default: 5 GiB)
+	Credentials   []S3GatewayCredential `mapstructure:"credentials"`
+}
+
+// S3GatewayCredential is an access key of the S3 gateway. Each key acts as one user,
+// whose upload quota applies and whose objects are kept apart from other users'.
+type S3GatewayCredential struct {
+	AccessKeyID     string `mapstructure:"accessKeyID"`
+	SecretAccessKey string `mapstructure:"secretAccessKey"`
+	UserID          string `mapstructure:"userID"` // ID of the user the key acts as
+}
+
+// WebhookConfig controls the webhooks that notify other services of storage events.
+// Deliveries are kept in the database until they succeed or run out of attempts.
+type WebhookConfig struct {
+	Enabled             bool                `mapstructure:"enabled"`
+	Subscribers         []WebhookSubscriber `mapstructure:"subscribers"`
+	MaxAttempts         int                 `mapstructure:"maxAttempts"`         // Attempts before a delivery is given up (default: 10)
+	BaseDelaySeconds    int                 `mapstructure:"baseDelaySeconds"`    // Delay before the first retry, doubled after each failure (default: 30)
+	MaxDelaySeconds     int                 `mapstructure:"maxDelaySeconds"`     // Longest delay between attempts (default: 3600)
+	TimeoutSeconds      int                 `mapstructure:"timeoutSeconds"`      // Time a subscriber has to answer a delivery (default: 10)
+	Workers             int                 `mapstructure:"workers"`             // Deliveries sent at once (default: 4)
+	PollIntervalSeconds int                 `mapstructure:"pollIntervalSeconds"` // Seconds between checks for due retries (default: 5)
+	RetentionSeconds    int                 `mapstructure:"retentionSeconds"`    // Seconds finished deliveries are kept (default: 604800)
+}
+
+// WebhookSubscriber is a URL that receives storage events, signed with its secret.
+type WebhookSubscriber struct {
+	URL    string   `mapstructure:"url"`
+	Secret string   `mapstructure:"secret"` // Key of the HMAC-SHA256 signature of each delivery
+	Events []string `mapstructure:"events"` // Event types to deliver; all when empty
+}
+
 // Config stores all configuration of the application.
 type Config struct {
-	App          AppConfig             `mapstructure:"app"`
-	DB           DBConfig              `mapstructure:"db"`
-	Redis        RedisConfig           `mapstructure:"redis"`
-	Log          LogConfig             `mapstructure:"log"`
+	App         AppConfig             `mapstructure:"app"`
+	DB          DBConfig              `mapstructure:"db"`
+	Redis       RedisConfig           `mapstructure:"redis"`
+	Log         LogConfig             `mapstructure:"log"`
+	Adapter     config.AdapterConfig  `mapstructure:"adapter"`
+	RateLimiter RateLimiterConfig     `mapstructure:"rateLimiter"`
+	Signoz      SignozConfig          `mapstructure:"signoz"`
+	Auth        AuthConfig            `mapstructure:"auth"`
+	Storage     StorageConfig         `mapstructure:"storage"`
+	Media       MediaConfig           `mapstructure:"media"`
+	S3Gateway   S3GatewayConfig       `mapstructure:"s3Gateway"`
+	Webhooks    WebhookConfig         `mapstructure:"webhooks"`
+	Brevo       config.BrevoConfig    `mapstructure:"brevo"`    // Email delivery when adapter.email is brevo
+	SendGrid    config.SendGridConfig `mapstructure:"sendgrid"` // Email delivery when adapter.email is sendgrid
+
+	// Settings of the default backend of each provider type, in top-level sections
+	ProviderSettings `mapstructure:",squash"`
+}
+
+// ProviderSettings holds the settings of every storage provider type, each in the
+// section named after it. The telegram section also configures notifications.
+type ProviderSettings struct {
 	Telegram     config.TelegramConfig `mapstructure:"telegram"`
-	Adapter      config.AdapterConfig  `mapstructure:"adapter"`
-	RateLimiter  RateLimiterConfig     `mapstructure:"rateLimiter"`
-	Signoz       SignozConfig          `mapstructure:"signoz"`
 	FireStore    FireStoreConfig       `mapstructure:"firestore"`
 	S3           S3Config              `mapstructure:"s3"`
 	Cloudflare   CloudflareConfig      `mapstructure:"cloudflare"`
@@ -208,7 +590,20 @@ type Config struct {
 	Azure        AzureConfig           `mapstructure:"azure"`
 	Scaleway     ScalewayConfig        `mapstructure:"scaleway"`
 	BackBlaze    BackBlazeConfig       `mapstructure:"backblaze"`
+	DigitalOcean DigitalOceanConfig    `mapstructure:"digitalocean"`
+	Wasabi       WasabiConfig          `mapstructure:"wasabi"`
 	MinIO        MinIOConfig           `mapstructure:"minio"`
+	OSS          OSSConfig             `mapstructure:"oss"`
+}
+
+// StorageBackendConfig is a named storage backend: a provider type and its settings,
+// given in the section of the type (e.g. s3 or azure). Named backends allow several
+// buckets of one type; media records refer to backends by name.
+type StorageBackendConfig struct {
+	Name string `mapstructure:"name"` // Used as the upload provider field and stored with media
+	Type string `mapstructure:"type"` // Provider type, e.g. s3
+
+	ProviderSettings `mapstructure:",squash"`
 }
 
 // RateLimiterConfig holds rate limiter specific configuration.
@@ -0,0 +1,359 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// s3BucketName matches the bucket names S3 clients accept in path-style requests
+var s3BucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// placeholderSecrets are app secrets from examples and docs that must not reach production
+var placeholderSecrets = []string{"", "your_strong_secret_key", "your-secret-key", "changeme"}
+
+// ValidationError lists every problem found by Config.Validate.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate checks the settings the application cannot run without: the database and
+// Redis connections, the app secret in production, the names and types of
+// storage.backends, the buckets and credentials of an enabled S3 gateway, the URLs and
+// secrets of webhook subscribers, the media size limits, and the required fields of the storage backends in
+// use, which are the default (local), the targets of storage.aliases and
+// s3Gateway.buckets, the named backends and those enabled in storage.providers. It returns a *ValidationError listing all problems at once.
+func (c *Config) Validate() error {
+	var problems []string
+	missing := func(keys ...string) {
+		for _, key := range keys {
+			problems = append(problems, key+" is required")
+		}
+	}
+
+	if c.DB.Host == "" {
+		missing("db.host")
+	}
+	if c.DB.Port == "" {
+		missing("db.port")
+	}
+	if c.DB.User == "" {
+		missing("db.user")
+	}
+	if c.DB.Name == "" {
+		missing("db.name")
+	}
+	if c.Redis.Url == "" && c.Redis.Host == "" {
+		problems = append(problems, "redis.url or redis.host is required")
+	}
+	if c.App.Env == "production" && slices.Contains(placeholderSecrets, c.App.Secret) {
+		problems = append(problems, "app.secret must be set to a strong, unique value in production (APP_SECRET)")
+	}
+
+	selected := map[string]bool{"local": true}
+	names := map[string]bool{}
+	for i, backend := range c.Storage.Backends {
+		key := fmt.Sprintf("storage.backends[%d]", i)
+		valid := true
+		switch {
+		case backend.Name == "":
+			missing(key + ".name")
+			continue
+		case slices.Contains(storageProviderOrder, backend.Name):
+			problems = append(problems, key+".name "+backend.Name+" is reserved for the default "+backend.Name+" backend")
+			valid = false
+		case names[backend.Name]:
+			problems = append(problems, key+".name "+backend.Name+" is used by another backend")
+		}
+		names[backend.Name] = true
+		switch {
+		case backend.Type == "local":
+			problems = append(problems, key+".type local is not supported; local storage has a single backend, configured under localStorage")
+			valid = false
+		case !slices.Contains(storageProviderOrder, backend.Type):
+			problems = append(problems, key+".type "+backend.Type+" is not a supported provider type")
+			valid = false
+		}
+		// Settings are only checked once the backend itself is well formed
+		if enabled, ok := c.Storage.Providers[backend.Name]; valid && (!ok || enabled) {
+			selected[backend.Name] = true
+		}
+	}
+
+	if enabled, ok := c.Storage.Providers["local"]; ok && !enabled {
+		problems = append(problems, "storage.providers.local cannot be disabled; local storage is the default provider")
+	}
+	for alias, target := range c.Storage.Aliases {
+		target = strings.ToLower(strings.TrimSpace(target))
+		if names[alias] {
+			problems = append(problems, "storage.aliases."+alias+" has the name of a backend")
+		}
+		if _, ok := c.Backend(target); !ok {
+			problems = append(problems, "storage.aliases."+alias+" points to unknown backend "+target)
+			continue
+		}
+		if enabled, ok := c.Storage.Providers[target]; ok && !enabled {
+			problems = append(problems, "storage.aliases."+alias+" points to disabled backend "+target)
+		}
+		selected[target] = true
+	}
+	if c.S3Gateway.Enabled {
+		problems = append(problems, c.checkS3Gateway(selected)...)
+	}
+	if c.Webhooks.Enabled {
+		problems = append(problems, c.checkWebhooks()...)
+	}
+	for _, limit := range [][2]string{
+		field("media.maxImageSize", c.Media.MaxImageSize),
+		field("media.maxVideoSize", c.Media.MaxVideoSize),
+		field("media.maxAudioSize", c.Media.MaxAudioSize),
+		field("media.maxDocumentSize", c.Media.MaxDocumentSize),
+	} {
+		if limit[1] == "" {
+			continue
+		}
+		if size, err := ParseByteSize(limit[1]); err != nil {
+			problems = append(problems, limit[0]+": "+err.Error())
+		} else if size <= 0 {
+			problems = append(problems, limit[0]+" must be greater than 0")
+		}
+	}
+	for name, enabled := range c.Storage.Providers {
+		if enabled {
+			selected[name] = true
+		}
+	}
+	for _, backend := range c.Backends() {
+		if selected[backend.Name] {
+			problems = append(problems, backend.check()...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// checkS3Gateway returns the problems of the S3 gateway's buckets and credentials and
+// marks the backends holding its buckets in selected.
+func (c *Config) checkS3Gateway(selected map[string]bool) []string {
+	var problems []string
+	if len(c.S3Gateway.Buckets) == 0 {
+		problems = append(problems, "s3Gateway.buckets is required when the S3 gateway is enabled")
+	}
+	for bucket, target := range c.S3Gateway.Buckets {
+		key := "s3Gateway.buckets." + bucket
+		if !s3BucketName.MatchString(bucket) {
+			problems = append(problems, key+" is not a valid bucket name (3-63 lower case letters, digits, dots and hyphens)")
+		}
+		target = strings.ToLower(strings.TrimSpace(target))
+		if alias, ok := c.Storage.Aliases[target]; ok {
+			target = strings.ToLower(strings.TrimSpace(alias))
+		}
+		if _, ok := c.Backend(target); !ok {
+			problems = append(problems, key+" points to unknown backend "+target)
+			continue
+		}
+		if enabled, ok := c.Storage.Providers[target]; ok && !enabled {
+			problems = append(problems, key+" points to disabled backend "+target)
+		}
+		selected[target] = true
+	}
+
+	accessKeys := map[string]bool{}
+	for i, credential := range c.S3Gateway.Credentials {
+		key := fmt.Sprintf("s3Gateway.credentials[%d]", i)
+		switch {
+		case credential.AccessKeyID == "":
+			problems = append(problems, key+".accessKeyID is required")
+		case accessKeys[credential.AccessKeyID]:
+			problems = append(problems, key+".accessKeyID "+credential.AccessKeyID+" is used by another credential")
+		}
+		accessKeys[credential.AccessKeyID] = true
+		if credential.SecretAccessKey == "" {
+			problems = append(problems, key+".secretAccessKey is required")
+		}
+		if _, err := uuid.Parse(credential.UserID); err != nil {
+			problems = append(problems, key+".userID must be the ID of a user")
+		}
+	}
+	return problems
+}
+
+// checkWebhooks returns the problems of the webhook subscribers.
+func (c *Config) checkWebhooks() []string {
+	var problems []string
+	urls := map[string]bool{}
+	for i, subscriber := range c.Webhooks.Subscribers {
+		key := fmt.Sprintf("webhooks.subscribers[%d]", i)
+		target, err := url.Parse(subscriber.URL)
+		switch {
+		case err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "":
+			problems = append(problems, key+".url must be an http or https URL")
+		case urls[subscriber.URL]:
+			problems = append(problems, key+".url "+subscriber.URL+" is used by another subscriber")
+		}
+		urls[subscriber.URL] = true
+		if subscriber.Secret == "" {
+			problems = append(problems, key+".secret is required")
+		}
+	}
+	return problems
+}
+
+// Backends returns every storage backend: the default backend of each provider type,
+// named after the type and configured by its top-level section, then storage.backends.
+func (c *Config) Backends() []StorageBackendConfig {
+	backends := make([]StorageBackendConfig, 0, len(storageProviderOrder)+len(c.Storage.Backends))
+	for _, providerType := range storageProviderOrder {
+		backends = append(backends, c.defaultBackend(providerType))
+	}
+	return append(backends, c.Storage.Backends...)
+}
+
+// Backend returns the storage backend with the given name.
+func (c *Config) Backend(name string) (StorageBackendConfig, bool) {
+	if slices.Contains(storageProviderOrder, name) {
+		return c.defaultBackend(name), true
+	}
+	for _, backend := range c.Storage.Backends {
+		if backend.Name == name {
+			return backend, true
+		}
+	}
+	return StorageBackendConfig{}, false
+}
+
+func (c *Config) defaultBackend(providerType string) StorageBackendConfig {
+	return StorageBackendConfig{Name: providerType, Type: providerType, ProviderSettings: c.ProviderSettings}
+}
+
+// ProviderEnabled reports whether a storage backend is usable in this deployment: as set
+// in storage.providers, or otherwise whether its required settings are present.
+func (c *Config) ProviderEnabled(name string) bool {
+	if enabled, ok := c.Storage.Providers[name]; ok {
+		return enabled
+	}
+	backend, ok := c.Backend(name)
+	return ok && len(backend.check()) == 0
+}
+
+// storageProviderOrder is the order providers are checked and reported in
+var storageProviderOrder = []string{
+	"local", "s3", "cloudflare_r2", "firebase", "azure", "discord", "scaleway",
+	"backblaze", "digitalocean", "wasabi", "minio", "oss", "telegram",
+}
+
+// providerFields collects the problems of one provider section while it is checked
+type providerFields struct {
+	section  string
+	problems []string
+}
+
+// require records the keys among fields whose value is empty.
+func (p *providerFields) require(fields ...[2]string) {
+	for _, field := range fields {
+		if field[1] == "" {
+			p.problems = append(p.problems, p.section+"."+field[0]+" is required")
+		}
+	}
+}
+
+// either records a problem unless at least one of the two fields is set.
+func (p *providerFields) either(a, b [2]string) {
+	if a[1] != "" || b[1] != "" {
+		return
+	}
+	p.problems = append(p.problems, p.section+"."+a[0]+" or "+p.section+"."+b[0]+" is required")
+}
+
+func field(key, value string) [2]string {
+	return [2]string{key, value}
+}
+
+// check returns what the backend's adapter would reject when it is built. Keys of
+// named backends are reported under storage.backends.
+func (b StorageBackendConfig) check() []string {
+	p := &providerFields{}
+	section := func(key string) {
+		p.section = key
+		if b.Name != b.Type {
+			p.section = "storage.backends[" + b.Name + "]." + key
+		}
+	}
+
+	switch b.Type {
+	case "local":
+		section("localStorage")
+		p.require(field("path", b.LocalStorage.Path), field("signedUrlSecret", b.LocalStorage.SignedURLSecret))
+	case "s3":
+		section("s3")
+		p.require(field("bucketName", b.S3.BucketName))
+		p.either(field("endpoint", b.S3.Endpoint), field("region", b.S3.Region))
+		if (b.S3.AccessKeyID == "") != (b.S3.SecretAccessKey == "") {
+			p.problems = append(p.problems, p.section+".accessKeyID and "+p.section+".secretAccessKey must be set together")
+		}
+		if b.S3.AccessKeyID == "" && b.S3.Endpoint != "" && b.S3.AssumeRoleARN == "" {
+			p.problems = append(p.problems, p.section+".accessKeyID is required with a custom "+p.section+".endpoint")
+		}
+	case "cloudflare_r2":
+		section("cloudflare")
+		p.require(field("accountID", b.Cloudflare.AccountID), field("accessKeyID", b.Cloudflare.AccessKeyID),
+			field("secretAccessKey", b.Cloudflare.SecretAccessKey), field("bucketName", b.Cloudflare.BucketName))
+	case "firebase":
+		section("firestore")
+		p.require(field("projectID", b.FireStore.ProjectID), field("credentialsFile", b.FireStore.CredentialsFile),
+			field("bucketName", b.FireStore.BucketName))
+	case "azure":
+		section("azure")
+		p.require(field("containerName", b.Azure.ContainerName))
+		p.either(field("accountName", b.Azure.AccountName), field("connectionString", b.Azure.ConnectionString))
+	case "discord":
+		section("discord")
+		p.either(field("botToken", b.Discord.BotToken), field("webhookURL", b.Discord.WebhookURL))
+		if b.Discord.BotToken != "" && b.Discord.ChannelID == "" {
+			p.problems = append(p.problems, p.section+".channelID is required with "+p.section+".botToken")
+		}
+	case "scaleway":
+		section("scaleway")
+		p.require(field("accessKeyID", b.Scaleway.AccessKeyID), field("secretAccessKey", b.Scaleway.SecretAccessKey),
+			field("bucketName", b.Scaleway.BucketName))
+		p.either(field("endpoint", b.Scaleway.Endpoint), field("region", b.Scaleway.Region))
+	case "backblaze":
+		section("backblaze")
+		p.require(field("keyID", b.BackBlaze.KeyID), field("applicationKey", b.BackBlaze.ApplicationKey),
+			field("bucketName", b.BackBlaze.BucketName))
+		p.either(field("endpoint", b.BackBlaze.Endpoint), field("region", b.BackBlaze.Region))
+	case "digitalocean":
+		section("digitalocean")
+		p.require(field("accessKeyID", b.DigitalOcean.AccessKeyID), field("secretAccessKey", b.DigitalOcean.SecretAccessKey),
+			field("region", b.DigitalOcean.Region), field("bucketName", b.DigitalOcean.BucketName))
+	case "wasabi":
+		section("wasabi")
+		p.require(field("accessKeyID", b.Wasabi.AccessKeyID), field("secretAccessKey", b.Wasabi.SecretAccessKey),
+			field("bucketName", b.Wasabi.BucketName))
+	case "minio":
+		section("minio")
+		p.require(field("endpoint", b.MinIO.Endpoint), field("accessKeyID", b.MinIO.AccessKeyID),
+			field("secretAccessKey", b.MinIO.SecretAccessKey), field("bucketName", b.MinIO.BucketName))
+	case "oss":
+		section("oss")
+		p.require(field("endpoint", b.OSS.Endpoint), field("accessKeyID", b.OSS.AccessKeyID),
+			field("accessKeySecret", b.OSS.AccessKeySecret), field("bucketName", b.OSS.BucketName))
+	case "telegram":
+		section("telegram")
+		p.require(field("botToken", b.Telegram.BotToken), field("chatId", b.Telegram.ChatID))
+	default:
+		return nil
+	}
+	return p.problems
+}
@@ -14,6 +14,13 @@ import (
 	gormLogger "gorm.io/gorm/logger"
 )
 
+// Connection pool defaults, used when the db section leaves a setting unset
+const (
+	defaultMaxIdleConns    = 10
+	defaultMaxOpenConns    = 100
+	defaultConnMaxLifetime = time.Hour
+)
+
 // NewDatabaseConnection creates a new GORM database instance based on the provided configuration, instrumented with OpenTelemetry.
 func NewDatabaseConnection(cfg config.DBConfig) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=Asia/Ho_Chi_Minh",
@@ -73,14 +80,27 @@ func NewDatabaseConnection(cfg config.DBConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	// Unset pool settings keep their defaults; negative values are passed on, which
+	// database/sql reads as no idle connections, no open limit and no lifetime limit
+	maxIdleConns, maxOpenConns, connMaxLifetime := defaultMaxIdleConns, defaultMaxOpenConns, defaultConnMaxLifetime
+	if cfg.MaxIdleConns != 0 {
+		maxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxOpenConns != 0 {
+		maxOpenConns = cfg.MaxOpenConns
+	}
+	if cfg.ConnMaxLifetimeSeconds != 0 {
+		connMaxLifetime = time.Duration(cfg.ConnMaxLifetimeSeconds) * time.Second
+	}
+
 	// SetMaxIdleConns sets the maximum number of connections in the idle connection pool.
-	sqlDB.SetMaxIdleConns(10)
+	sqlDB.SetMaxIdleConns(maxIdleConns)
 
 	// SetMaxOpenConns sets the maximum number of open connections to the database.
-	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetMaxOpenConns(maxOpenConns)
 
 	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
-	sqlDB.SetConnMaxLifetime(time.Hour)
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
 
 	// Auto-migrate database schemas
 	if err := autoMigrate(db); err != nil {
@@ -99,11 +119,38 @@ func autoMigrate(db *gorm.DB) error {
 		return fmt.Errorf("failed to handle existing users table: %w", err)
 	}
 
-	return db.AutoMigrate(
+	if err := db.AutoMigrate(
 		&User{},
 		&UserProfile{},
+		&PasswordResetToken{},
+		&EmailVerificationToken{},
+		&RefreshToken{},
 		&AuditLog{},
-	)
+	); err != nil {
+		return err
+	}
+
+	return migrateUserRoles(db)
+}
+
+// migrateUserRoles gives every user a role. The role column replaced the is_admin flag,
+// so users that had the flag become admins and the flag is dropped.
+func migrateUserRoles(db *gorm.DB) error {
+	if err := db.Exec("UPDATE users SET role = 'user' WHERE role IS NULL OR role = ''").Error; err != nil {
+		return fmt.Errorf("failed to set default user role: %w", err)
+	}
+
+	if !db.Migrator().HasColumn(&User{}, "is_admin") {
+		return nil
+	}
+	log.Println("Migrating is_admin flags to roles")
+	if err := db.Exec("UPDATE users SET role = 'admin' WHERE is_admin").Error; err != nil {
+		return fmt.Errorf("failed to migrate admin flags: %w", err)
+	}
+	if err := db.Migrator().DropColumn(&User{}, "is_admin"); err != nil {
+		return fmt.Errorf("failed to drop is_admin column: %w", err)
+	}
+	return nil
 }
 
 // handleExistingUsersTable handles migration of existing users table
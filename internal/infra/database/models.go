@@ -84,17 +84,66 @@ type User struct {
 	FailedAttempts int        `gorm:"not null;default:0"`
 	LockedUntil    *time.Time
 	Metadata       JSONB `gorm:"type:jsonb"`
+
+	UsedStorageBytes int64      `gorm:"not null;default:0"`
+	MaxStorageBytes  int64      `gorm:"not null;default:0"` // 0 means unlimited
+	MaxFilesPerDay   int        `gorm:"not null;default:0"` // 0 means unlimited
+	DailyFileCount   int        `gorm:"not null;default:0"`
+	DailyCountDate   *time.Time `gorm:"type:date"`
+
+	MaxBandwidthBytesPerSecond int64 `gorm:"not null;default:0"` // 0 falls back to the configured per-user limit
+
+	Role string `gorm:"type:varchar(20);not null;default:'user';index:idx_users_role"`
 }
 
 // UserProfile represents additional user profile information
 type UserProfile struct {
 	Base
-	UserID      uuid.UUID `gorm:"type:uuid;uniqueIndex;not null"`
-	Avatar      string    `gorm:"type:text"`
-	PhoneNumber string    `gorm:"type:varchar(20)"`
-	DateOfBirth *time.Time
-	Timezone    string `gorm:"type:varchar(50);default:'UTC'"`
-	Language    string `gorm:"type:varchar(10);default:'en'"`
+	UserID        uuid.UUID  `gorm:"type:uuid;uniqueIndex;not null"`
+	Avatar        string     `gorm:"type:text"`
+	AvatarMediaID *uuid.UUID `gorm:"type:uuid"` // Media record holding an uploaded avatar
+	PhoneNumber   string     `gorm:"type:varchar(20)"`
+	DateOfBirth   *time.Time
+	Timezone      string `gorm:"type:varchar(50);default:'UTC'"`
+	Language      string `gorm:"type:varchar(10);default:'en'"`
+
+	// Foreign key relationship
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// PasswordResetToken stores the hash of an issued password reset token
+type PasswordResetToken struct {
+	Base
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_password_reset_tokens_user_id"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Used      bool      `gorm:"not null;default:false"`
+
+	// Foreign key relationship
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// EmailVerificationToken stores the hash of an issued email verification token
+type EmailVerificationToken struct {
+	Base
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_email_verification_tokens_user_id"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null"`
+	NewEmail  string    `gorm:"type:varchar(255)"` // Set for email change tokens
+	ExpiresAt time.Time `gorm:"not null"`
+	Used      bool      `gorm:"not null;default:false"`
+
+	// Foreign key relationship
+	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+}
+
+// RefreshToken records an issued refresh token; ID is the token's jti claim
+type RefreshToken struct {
+	Base
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index:idx_refresh_tokens_user_id"`
+	FamilyID  uuid.UUID `gorm:"type:uuid;not null;index:idx_refresh_tokens_family_id"`
+	ExpiresAt time.Time `gorm:"not null"`
+	Used      bool      `gorm:"not null;default:false"`
+	Revoked   bool      `gorm:"not null;default:false"`
 
 	// Foreign key relationship
 	User User `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
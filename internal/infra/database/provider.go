@@ -9,6 +9,7 @@ import (
 	logger "github.com/lugondev/go-log"
 	"github.com/lugondev/m3-storage/internal/infra/config"
 	mediadomain "github.com/lugondev/m3-storage/internal/modules/media/domain"
+	webhookdomain "github.com/lugondev/m3-storage/internal/modules/webhook/domain"
 
 	"gorm.io/gorm"
 )
@@ -31,9 +32,21 @@ func InitializeDatabase(cfg config.Config, log logger.Logger) (*gorm.DB, *sql.DB
 	}
 
 	// Auto-migrate the models
-	if err := db.AutoMigrate(&mediadomain.Media{}); err != nil {
-		log.Errorf(ctx, "Failed to auto-migrate Media model: %v", err)
-		return nil, nil, fmt.Errorf("failed to auto-migrate Media model: %w", err)
+	if err := db.AutoMigrate(&mediadomain.Media{}, &mediadomain.Folder{}, &mediadomain.Tag{}, &mediadomain.MediaTag{}, &mediadomain.MediaShare{}); err != nil {
+		log.Errorf(ctx, "Failed to auto-migrate Media models: %v", err)
+		return nil, nil, fmt.Errorf("failed to auto-migrate Media models: %w", err)
+	}
+
+	// Image dimensions used to be kept only in the metadata; copy them to their own columns
+	if err := db.Exec("UPDATE media SET width = (metadata->>'width')::int, height = (metadata->>'height')::int " +
+		"WHERE width = 0 AND metadata->>'width' IS NOT NULL AND metadata->>'height' IS NOT NULL").Error; err != nil {
+		log.Errorf(ctx, "Failed to backfill image dimensions: %v", err)
+		return nil, nil, fmt.Errorf("failed to backfill image dimensions: %w", err)
+	}
+
+	if err := db.AutoMigrate(&webhookdomain.Delivery{}); err != nil {
+		log.Errorf(ctx, "Failed to auto-migrate Webhook models: %v", err)
+		return nil, nil, fmt.Errorf("failed to auto-migrate Webhook models: %w", err)
 	}
 
 	// The User, UserProfile, and AuditLog models are auto-migrated in database.go autoMigrate function
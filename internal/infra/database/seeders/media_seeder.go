@@ -0,0 +1,122 @@
+package seeders
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the decoders for the sample image dimensions
+	_ "image/png"
+	"log"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/infra/database"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"gorm.io/gorm"
+)
+
+//go:embed samples
+var sampleFiles embed.FS
+
+// sampleMedia is a bundled file given to each seeded user
+type sampleMedia struct {
+	name        string
+	mediaType   string
+	contentType string
+}
+
+var sampleMediaFiles = []sampleMedia{
+	{name: "sunset.png", mediaType: "image", contentType: "image/png"},
+	{name: "forest.jpg", mediaType: "image", contentType: "image/jpeg"},
+	{name: "getting-started.pdf", mediaType: "document", contentType: "application/pdf"},
+	{name: "welcome.txt", mediaType: "document", contentType: "text/plain; charset=utf-8"},
+}
+
+// seededUserEmails are the users created by UserSeeder.Seed that get sample media
+var seededUserEmails = []string{"admin@example.com", "user@example.com", "test@example.com"}
+
+// MediaSeeder handles seeding sample media for the seeded users
+type MediaSeeder struct {
+	db      *gorm.DB
+	storage storagePort.StorageProvider
+}
+
+// NewMediaSeeder creates a new media seeder storing files in the given provider
+func NewMediaSeeder(db *gorm.DB, storage storagePort.StorageProvider) *MediaSeeder {
+	return &MediaSeeder{db: db, storage: storage}
+}
+
+// SeedTestData uploads the bundled sample files for each seeded user. Users who already
+// have media are skipped, so running it again does not add duplicates.
+func (s *MediaSeeder) SeedTestData() error {
+	log.Println("Seeding sample media...")
+
+	var users []database.User
+	if err := s.db.Where("email IN ?", seededUserEmails).Find(&users).Error; err != nil {
+		return err
+	}
+
+	seeded := 0
+	for _, user := range users {
+		var count int64
+		if err := s.db.Model(&domain.Media{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			log.Printf("User %s already has media, skipping media seeding...", user.Email)
+			continue
+		}
+
+		if err := s.seedUser(&user); err != nil {
+			return fmt.Errorf("failed to seed media for %s: %w", user.Email, err)
+		}
+		seeded++
+	}
+
+	log.Printf("Successfully seeded sample media for %d users", seeded)
+	return nil
+}
+
+// seedUser stores every sample file for user and records it as media and storage usage
+func (s *MediaSeeder) seedUser(user *database.User) error {
+	ctx := context.Background()
+	provider := storagePort.BackendName(s.storage)
+
+	var usedBytes int64
+	for _, sample := range sampleMediaFiles {
+		data, err := sampleFiles.ReadFile("samples/" + sample.name)
+		if err != nil {
+			return err
+		}
+
+		// Same layout as uploads: {userID}/{mediaType}/{date}/{fileName}
+		key := fmt.Sprintf("%s/%s/%s/%s", user.ID.String(), sample.mediaType, time.Now().Format("20060102"), sample.name)
+		fileObject, err := s.storage.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), &storagePort.UploadOptions{ContentType: sample.contentType})
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		media := domain.NewMedia(user.ID, sample.name, key, int64(len(data)), sample.mediaType, provider, fileObject.URL)
+		media.ContentType = sample.contentType
+		media.ETag = fileObject.ETag
+		media.SHA256 = hex.EncodeToString(sum[:])
+		if sample.mediaType == "image" {
+			if header, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+				media.Width, media.Height = header.Width, header.Height
+				media.Metadata = &domain.ImageMetadata{Width: header.Width, Height: header.Height}
+			}
+		}
+		if err := s.db.Create(media).Error; err != nil {
+			return err
+		}
+		usedBytes += media.FileSize
+	}
+
+	return s.db.Model(&database.User{}).Where("id = ?", user.ID).
+		Update("used_storage_bytes", gorm.Expr("used_storage_bytes + ?", usedBytes)).Error
+}
@@ -3,20 +3,23 @@ package seeders
 import (
 	"log"
 
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
 	"gorm.io/gorm"
 )
 
 // SeederManager manages all seeders
 type SeederManager struct {
-	db         *gorm.DB
-	userSeeder *UserSeeder
+	db          *gorm.DB
+	userSeeder  *UserSeeder
+	mediaSeeder *MediaSeeder
 }
 
-// NewSeederManager creates a new seeder manager
-func NewSeederManager(db *gorm.DB) *SeederManager {
+// NewSeederManager creates a new seeder manager. Sample media is stored in storage.
+func NewSeederManager(db *gorm.DB, storage storagePort.StorageProvider) *SeederManager {
 	return &SeederManager{
-		db:         db,
-		userSeeder: NewUserSeeder(db),
+		db:          db,
+		userSeeder:  NewUserSeeder(db),
+		mediaSeeder: NewMediaSeeder(db, storage),
 	}
 }
 
@@ -42,6 +45,11 @@ func (sm *SeederManager) SeedTestData() error {
 		return err
 	}
 
+	// Seed sample media for the gallery
+	if err := sm.mediaSeeder.SeedTestData(); err != nil {
+		return err
+	}
+
 	log.Println("Test data seeding completed successfully!")
 	return nil
 }
@@ -56,6 +56,7 @@ func (s *UserSeeder) Seed() error {
 			Status:         "active",
 			EmailVerified:  true,
 			FailedAttempts: 0,
+			Role:           "admin",
 		},
 		{
 			Base: database.Base{
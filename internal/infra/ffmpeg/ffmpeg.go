@@ -0,0 +1,101 @@
+// Package ffmpeg wraps the ffmpeg and ffprobe command-line tools used for
+// server-side media processing.
+package ffmpeg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStderrBytes limits how much tool output is echoed back in errors.
+const maxStderrBytes = 512
+
+// Runner executes ffmpeg/ffprobe. A Runner whose binaries could not be found
+// reports Available() == false and every command returns ErrUnavailable.
+type Runner struct {
+	ffmpegPath  string
+	ffprobePath string
+}
+
+// ErrUnavailable is returned when ffmpeg or ffprobe is not installed.
+var ErrUnavailable = errors.New("ffmpeg is not available")
+
+// NewRunner resolves the ffmpeg and ffprobe binaries, falling back to $PATH lookups
+// of "ffmpeg" and "ffprobe" when the configured paths are empty.
+func NewRunner(ffmpegPath, ffprobePath string) *Runner {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	if ffprobePath == "" {
+		ffprobePath = "ffprobe"
+	}
+
+	r := &Runner{}
+	if path, err := exec.LookPath(ffmpegPath); err == nil {
+		r.ffmpegPath = path
+	}
+	if path, err := exec.LookPath(ffprobePath); err == nil {
+		r.ffprobePath = path
+	}
+	return r
+}
+
+// Available reports whether both ffmpeg and ffprobe were found.
+func (r *Runner) Available() bool {
+	return r != nil && r.ffmpegPath != "" && r.ffprobePath != ""
+}
+
+// Duration returns the container duration of the media file at input.
+func (r *Runner) Duration(ctx context.Context, input string) (time.Duration, error) {
+	if !r.Available() {
+		return 0, ErrUnavailable
+	}
+
+	out, err := run(ctx, r.ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		input,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(out), 64)
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe returned invalid duration %q: %w", strings.TrimSpace(out), err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// Run invokes ffmpeg with the given arguments.
+func (r *Runner) Run(ctx context.Context, args ...string) error {
+	if !r.Available() {
+		return ErrUnavailable
+	}
+	_, err := run(ctx, r.ffmpegPath, append([]string{"-hide_banner", "-v", "error", "-y"}, args...)...)
+	return err
+}
+
+// run executes a command and returns its stdout, folding stderr into the error on failure.
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > maxStderrBytes {
+			msg = msg[len(msg)-maxStderrBytes:]
+		}
+		return "", fmt.Errorf("%s failed: %w: %s", name, err, msg)
+	}
+	return stdout.String(), nil
+}
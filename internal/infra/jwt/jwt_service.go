@@ -2,8 +2,12 @@ package jwt
 
 import (
 	"context"
+	"crypto/rsa"
 	"fmt"
+	"sort"
 
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
 	"github.com/lugondev/m3-storage/internal/shared/errors"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,14 +15,20 @@ import (
 )
 
 // JWTService is an implementation of the JWTService interface.
+// It signs with HS256 and a shared secret, or with RS256 and the active key of a key
+// set, in which case tokens carry a kid header naming the key that validates them.
 type JWTService struct {
 	secretKey     []byte
 	signingMethod jwt.SigningMethod
+	activeKeyID   string
+	signingKey    *rsa.PrivateKey
+	publicKeys    map[string]*rsa.PublicKey
+	clock         clock.Clock
 }
 
 // NewJWTService creates a new instance of jwtService.
 // In a real application, secretKey, issuer, and TTLs should come from configuration.
-func NewJWTService(secretKey string) (*JWTService, error) {
+func NewJWTService(secretKey string, clk clock.Clock) (*JWTService, error) {
 	if secretKey == "" {
 		return nil, errors.NewValidationError("jwt secret key cannot be empty")
 	}
@@ -26,20 +36,74 @@ func NewJWTService(secretKey string) (*JWTService, error) {
 	return &JWTService{
 		secretKey:     []byte(secretKey),
 		signingMethod: jwt.SigningMethodHS256, // Using HS256, ensure secret is strong
+		clock:         clk,
 	}, nil
 }
 
+// NewRS256JWTService creates a JWTService that signs new tokens with the key activeKeyID
+// and validates tokens signed by any key in keys. Keep retired keys in the set until the
+// tokens they signed have expired.
+func NewRS256JWTService(keys []RSAKey, activeKeyID string, clk clock.Clock) (*JWTService, error) {
+	publicKeys := make(map[string]*rsa.PublicKey, len(keys))
+	var signingKey *rsa.PrivateKey
+	for _, key := range keys {
+		if _, ok := publicKeys[key.ID]; ok {
+			return nil, errors.NewValidationError(fmt.Sprintf("duplicate jwt key id %q", key.ID))
+		}
+		publicKeys[key.ID] = key.PublicKey
+		if key.ID == activeKeyID {
+			signingKey = key.PrivateKey
+		}
+	}
+	if signingKey == nil {
+		return nil, errors.NewValidationError(fmt.Sprintf("active jwt key %q has no private key", activeKeyID))
+	}
+
+	return &JWTService{
+		signingMethod: jwt.SigningMethodRS256,
+		activeKeyID:   activeKeyID,
+		signingKey:    signingKey,
+		publicKeys:    publicKeys,
+		clock:         clk,
+	}, nil
+}
+
+// NewJWTServiceFromConfig creates an RS256 JWTService when cfg lists keys, and falls
+// back to HS256 with secretKey otherwise.
+func NewJWTServiceFromConfig(cfg config.JWTConfig, secretKey string, clk clock.Clock) (*JWTService, error) {
+	if len(cfg.Keys) == 0 {
+		return NewJWTService(secretKey, clk)
+	}
+	keys, err := LoadRSAKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewRS256JWTService(keys, cfg.ActiveKeyID, clk)
+}
+
+// keyFunc returns the key that validates token, rejecting tokens signed with another
+// algorithm than the service's, or with an unknown kid.
+func (s *JWTService) keyFunc(token *jwt.Token) (any, error) {
+	if token.Method.Alg() != s.signingMethod.Alg() {
+		return nil, errors.NewValidationError(fmt.Sprintf("unexpected signing method: %v", token.Header["alg"]))
+	}
+	if s.publicKeys == nil {
+		return s.secretKey, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.publicKeys[kid]
+	if !ok {
+		return nil, errors.NewValidationError(fmt.Sprintf("unknown signing key: %q", kid))
+	}
+	return key, nil
+}
+
 // ValidateToken parses and validates a JWT token string.
 func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*JWTClaims, error) {
 	claims := &JWTClaims{}
 
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
-		// Validate the alg is what you expect:
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.NewValidationError(fmt.Sprintf("unexpected signing method: %v", token.Header["alg"]))
-		}
-		return s.secretKey, nil
-	})
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.keyFunc, jwt.WithTimeFunc(s.clock.Now))
 
 	if err != nil {
 		if err == jwt.ErrTokenMalformed {
@@ -69,9 +133,24 @@ func (s *JWTService) ValidateToken(ctx context.Context, tokenString string) (*JW
 // GenerateToken creates and signs a JWT token with the given claims
 func (s *JWTService) GenerateToken(ctx context.Context, claims *JWTClaims) (string, error) {
 	token := jwt.NewWithClaims(s.signingMethod, claims)
+	if s.signingKey != nil {
+		token.Header["kid"] = s.activeKeyID
+		return token.SignedString(s.signingKey)
+	}
 	return token.SignedString(s.secretKey)
 }
 
+// JWKS returns the public keys that validate tokens, for other services to verify them.
+// The set is empty with HS256, whose secret must never be published.
+func (s *JWTService) JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0, len(s.publicKeys))}
+	for kid, key := range s.publicKeys {
+		set.Keys = append(set.Keys, newJWK(kid, key))
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
 // GenerateJTI creates a new unique identifier for a JWT token.
 func (s *JWTService) GenerateJTI() uuid.UUID {
 	return uuid.New()
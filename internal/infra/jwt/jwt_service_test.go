@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+func TestValidateTokenExpiry(t *testing.T) {
+	ctx := context.Background()
+	issued := time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+	clk := clock.NewMock(issued)
+	svc, err := NewJWTService("test-secret", clk)
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+
+	token, err := svc.GenerateToken(ctx, &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{
+		Subject:   uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(issued.Add(15 * time.Minute)),
+		NotBefore: jwt.NewNumericDate(issued),
+		IssuedAt:  jwt.NewNumericDate(issued),
+	}})
+	if err != nil {
+		t.Fatalf("GenerateToken: %v", err)
+	}
+
+	clk.Advance(15*time.Minute - time.Second)
+	if _, err := svc.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("token rejected before expiry: %v", err)
+	}
+
+	clk.Advance(time.Second)
+	if _, err := svc.ValidateToken(ctx, token); err == nil {
+		t.Fatal("token accepted at expiry")
+	}
+
+	// A clock set before the token was issued sees it as not valid yet
+	clk.Set(issued.Add(-time.Minute))
+	if _, err := svc.ValidateToken(ctx, token); err == nil {
+		t.Fatal("token accepted before it was issued")
+	}
+}
@@ -0,0 +1,88 @@
+package jwt
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+)
+
+// RSAKey is an RSA key identified by its kid. PrivateKey is only needed for the key that
+// signs new tokens; retired keys keep just the public half to validate tokens they signed.
+type RSAKey struct {
+	ID         string
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+}
+
+// LoadRSAKeys reads the PEM key files listed in cfg. A key with only a private key file
+// uses its public half for validation.
+func LoadRSAKeys(cfg config.JWTConfig) ([]RSAKey, error) {
+	keys := make([]RSAKey, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		if keyCfg.ID == "" {
+			return nil, fmt.Errorf("jwt key id cannot be empty")
+		}
+		key := RSAKey{ID: keyCfg.ID}
+
+		if keyCfg.PrivateKeyFile != "" {
+			pemBytes, err := os.ReadFile(keyCfg.PrivateKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read private key of jwt key %s: %w", keyCfg.ID, err)
+			}
+			key.PrivateKey, err = jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse private key of jwt key %s: %w", keyCfg.ID, err)
+			}
+			key.PublicKey = &key.PrivateKey.PublicKey
+		}
+
+		if keyCfg.PublicKeyFile != "" {
+			pemBytes, err := os.ReadFile(keyCfg.PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key of jwt key %s: %w", keyCfg.ID, err)
+			}
+			key.PublicKey, err = jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse public key of jwt key %s: %w", keyCfg.ID, err)
+			}
+		}
+
+		if key.PublicKey == nil {
+			return nil, fmt.Errorf("jwt key %s needs a private or public key file", keyCfg.ID)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// JWK is an RSA public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKSet is a JSON Web Key Set, as served at /.well-known/jwks.json.
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+func newJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: jwt.SigningMethodRS256.Alg(),
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
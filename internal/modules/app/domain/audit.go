@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,6 +17,9 @@ const (
 	ActionTypeDelete ActionType = "delete"
 	ActionTypeLogin  ActionType = "login"
 	ActionTypeLogout ActionType = "logout"
+
+	ActionTypePasswordChange ActionType = "password_change"
+	ActionTypeEmailChange    ActionType = "email_change"
 )
 
 // ResourceType represents the type of resource being acted upon
@@ -38,6 +42,10 @@ const (
 	ResourceTypeUserSettings       ResourceType = "user_settings"
 	ResourceTypeUserActivity       ResourceType = "user_activity"
 	ResourceTypeUserPreferences    ResourceType = "user_preferences"
+	ResourceTypeUserQuota          ResourceType = "user_quota"
+
+	// Media resource types
+	ResourceTypeMedia ResourceType = "media"
 
 	// Other resource types
 	ResourceTypeAPIKey       ResourceType = "api_key"
@@ -59,3 +67,32 @@ type AuditLog struct {
 	UserAgent    string       `json:"user_agent"`
 	CreatedAt    time.Time    `json:"created_at"`
 }
+
+// AuditLogFilter narrows a listing of audit logs. Unset fields match every entry.
+type AuditLogFilter struct {
+	UserID       *uuid.UUID
+	ActionType   ActionType
+	ResourceType ResourceType
+	From         *time.Time // Inclusive
+	To           *time.Time // Exclusive
+}
+
+// AuditClient identifies where an audited request came from
+type AuditClient struct {
+	IPAddress string
+	UserAgent string
+}
+
+type auditClientKey struct{}
+
+// WithAuditClient returns a copy of ctx carrying the client of the request it serves,
+// which audit entries recorded with the returned context are attributed to
+func WithAuditClient(ctx context.Context, client AuditClient) context.Context {
+	return context.WithValue(ctx, auditClientKey{}, client)
+}
+
+// AuditClientFromContext returns the client set by WithAuditClient
+func AuditClientFromContext(ctx context.Context) (AuditClient, bool) {
+	client, ok := ctx.Value(auditClientKey{}).(AuditClient)
+	return client, ok
+}
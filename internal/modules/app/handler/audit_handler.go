@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"github.com/lugondev/m3-storage/internal/modules/app/domain"
+	"github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AuditHandler serves the audit log to admins
+type AuditHandler struct {
+	auditService port.AuditService
+}
+
+// NewAuditHandler creates a new audit log handler
+func NewAuditHandler(auditService port.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditLogs handles listing audit log entries
+// @Summary List audit logs
+// @Description Get a page of audit log entries, newest first, optionally filtered by the user who acted, the action, the resource type and a time range
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Entries per page" default(10)
+// @Param user_id query string false "User who performed the action"
+// @Param action query string false "Action type, e.g. login, logout, password_change, create, update, delete"
+// @Param resource_type query string false "Resource type, e.g. media, users, user_authentication"
+// @Param from query string false "Earliest entry, RFC 3339 time or YYYY-MM-DD date (inclusive)"
+// @Param to query string false "Latest entry, RFC 3339 time or YYYY-MM-DD date (exclusive for times, inclusive for dates)"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Router /api/v1/admin/audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	query := &utils.PaginationQuery{}
+	if err := c.QueryParser(query); err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	filter := &domain.AuditLogFilter{
+		ActionType:   domain.ActionType(c.Query("action")),
+		ResourceType: domain.ResourceType(c.Query("resource_type")),
+	}
+	if value := c.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			return errors.NewBadRequestError("invalid user_id")
+		}
+		filter.UserID = &userID
+	}
+
+	var err error
+	if filter.From, err = utils.ParseFilterTime(c.Query("from"), false); err != nil {
+		return errors.NewBadRequestError("from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.To, err = utils.ParseFilterTime(c.Query("to"), true); err != nil {
+		return errors.NewBadRequestError("to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return errors.NewBadRequestError("from must be before to")
+	}
+
+	pagination, logs, err := h.auditService.List(c.Context(), query, filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"audit_logs": logs,
+			"pagination": pagination,
+		},
+		"message": "Audit logs retrieved successfully",
+	})
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/cache"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	// healthCheckTimeout bounds each dependency check of a probe
+	healthCheckTimeout = 2 * time.Second
+	// defaultStorageHealthTTL is how long the default storage backend's check is reused
+	defaultStorageHealthTTL = 30 * time.Second
+)
+
+// DependencyStatus is the result of checking one dependency.
+type DependencyStatus struct {
+	Status    string     `json:"status" example:"ok"` // ok or error
+	Error     string     `json:"error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at,omitempty"` // When a cached result was checked
+}
+
+// ReadinessResponse reports whether the service can take traffic and why not.
+type ReadinessResponse struct {
+	Status       string                      `json:"status" example:"ready"` // ready or not_ready
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Timestamp    time.Time                   `json:"timestamp"`
+}
+
+// HealthHandler serves the health, liveness and readiness probes
+type HealthHandler struct {
+	db      *gorm.DB
+	redis   *cache.RedisClient
+	storage storagePort.StorageFactory
+	env     string
+
+	storageTTL   time.Duration // Zero disables caching of the storage check
+	storageMu    sync.RWMutex
+	storageCheck DependencyStatus
+	storageGroup singleflight.Group
+}
+
+// NewHealthHandler creates a health handler. The readiness probe checks the database,
+// Redis and the default storage backend; storageTTL follows storage.healthCacheTTLSeconds,
+// where 0 uses the default and negative values disable caching.
+func NewHealthHandler(db *gorm.DB, redis *cache.RedisClient, storage storagePort.StorageFactory, env string, storageTTLSeconds int) *HealthHandler {
+	storageTTL := time.Duration(storageTTLSeconds) * time.Second
+	if storageTTLSeconds == 0 {
+		storageTTL = defaultStorageHealthTTL
+	} else if storageTTL < 0 {
+		storageTTL = 0
+	}
+
+	return &HealthHandler{
+		db:         db,
+		redis:      redis,
+		storage:    storage,
+		env:        env,
+		storageTTL: storageTTL,
+	}
+}
+
+// Health handles the basic health check of the database and Redis
+// @Summary Health check
+// @Description Ping the database and Redis
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /health [get]
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	if err := h.pingDB(c.Context()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":    "unhealthy",
+			"message":   "Database connection failed",
+			"error":     err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+	if err := h.pingRedis(c.Context()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":    "unhealthy",
+			"message":   "Redis connection failed",
+			"error":     err.Error(),
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":      "healthy",
+		"database":    "ok",
+		"redis":       "ok",
+		"environment": h.env,
+		"timestamp":   time.Now(),
+	})
+}
+
+// Live handles the liveness probe. It only confirms the process serves requests, so a
+// failing dependency does not get the container restarted.
+// @Summary Liveness probe
+// @Description Confirm the process is up without checking any dependency
+// @Tags Health
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /health/live [get]
+func (h *HealthHandler) Live(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":    "alive",
+		"timestamp": time.Now(),
+	})
+}
+
+// Ready handles the readiness probe
+// @Summary Readiness probe
+// @Description Check the database, Redis and the default storage backend, whose result is cached for storage.healthCacheTTLSeconds. Responds 503 when any of them is down.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} ReadinessResponse
+// @Failure 503 {object} ReadinessResponse
+// @Router /health/ready [get]
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	response := &ReadinessResponse{
+		Status: "ready",
+		Dependencies: map[string]DependencyStatus{
+			"database": dependencyStatus(h.pingDB(c.Context())),
+			"redis":    dependencyStatus(h.pingRedis(c.Context())),
+			"storage":  h.checkStorage(c.Context()),
+		},
+		Timestamp: time.Now(),
+	}
+
+	for _, dependency := range response.Dependencies {
+		if dependency.Status != "ok" {
+			response.Status = "not_ready"
+			return c.Status(fiber.StatusServiceUnavailable).JSON(response)
+		}
+	}
+	return c.JSON(response)
+}
+
+func (h *HealthHandler) pingDB(ctx context.Context) error {
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+func (h *HealthHandler) pingRedis(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	return h.redis.Client().Ping(ctx).Err()
+}
+
+// checkStorage checks the default storage backend, reusing a result younger than the
+// TTL so frequent probes do not hit the provider on each request.
+func (h *HealthHandler) checkStorage(ctx context.Context) DependencyStatus {
+	if h.storageTTL > 0 {
+		h.storageMu.RLock()
+		cached := h.storageCheck
+		h.storageMu.RUnlock()
+		if cached.CheckedAt != nil && time.Since(*cached.CheckedAt) < h.storageTTL {
+			return cached
+		}
+	}
+
+	result, _, _ := h.storageGroup.Do("default", func() (any, error) {
+		// The check is shared with concurrent probes, so one leaving must not cancel it
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), healthCheckTimeout)
+		defer cancel()
+
+		checkedAt := time.Now()
+		status := dependencyStatus(h.checkDefaultBackend(ctx))
+		status.CheckedAt = &checkedAt
+		h.storageMu.Lock()
+		h.storageCheck = status
+		h.storageMu.Unlock()
+		return status, nil
+	})
+	return result.(DependencyStatus)
+}
+
+// checkDefaultBackend checks local storage, which uploads use when no provider is named.
+func (h *HealthHandler) checkDefaultBackend(ctx context.Context) error {
+	provider, err := h.storage.CreateProviderByName(string(storagePort.ProviderLocal))
+	if err != nil {
+		return err
+	}
+	return provider.CheckHealth(ctx)
+}
+
+func dependencyStatus(err error) DependencyStatus {
+	if err != nil {
+		return DependencyStatus{Status: "error", Error: err.Error()}
+	}
+	return DependencyStatus{Status: "ok"}
+}
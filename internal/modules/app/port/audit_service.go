@@ -4,6 +4,7 @@ import (
 	"context"
 
 	domains "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
 
 	"github.com/google/uuid"
 )
@@ -13,6 +14,13 @@ type AuditService interface {
 	// Log creates a new audit log entry
 	Log(ctx context.Context, log *domains.AuditLog) error
 
+	// Record logs an action of a user, attributed to the client carried by ctx (see
+	// domains.WithAuditClient). It never fails the action: errors are only logged.
+	Record(ctx context.Context, userID uuid.UUID, action domains.ActionType, resourceType domains.ResourceType, resourceID string, metadata map[string]any)
+
+	// List retrieves a page of the audit logs matching filter, newest first
+	List(ctx context.Context, query *utils.PaginationQuery, filter *domains.AuditLogFilter) (*utils.Pagination, []domains.AuditLog, error)
+
 	// GetUserLogs retrieves audit logs for a specific user
 	GetUserLogs(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domains.AuditLog, error)
 
@@ -42,4 +50,7 @@ type AuditRepository interface {
 
 	// Search searches audit logs based on various criteria
 	Search(ctx context.Context, params map[string]any, limit, offset int) ([]domains.AuditLog, error)
+
+	// List retrieves the audit logs matching filter, newest first, with their total count
+	List(ctx context.Context, filter *domains.AuditLogFilter, offset, limit int) ([]domains.AuditLog, int64, error)
 }
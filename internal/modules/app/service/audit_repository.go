@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lugondev/m3-storage/internal/infra/database"
+	domains "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	ports "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// auditSearchColumns are the criteria Search accepts, mapped to their columns
+var auditSearchColumns = map[string]string{
+	"user_id":       "user_id",
+	"action_type":   "action_type",
+	"resource_type": "resource_type",
+	"resource_id":   "resource_id",
+}
+
+// AuditRepositoryImpl implements the AuditRepository interface
+type AuditRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit log repository
+func NewAuditRepository(db *gorm.DB) ports.AuditRepository {
+	return &AuditRepositoryImpl{db: db}
+}
+
+// Create creates a new audit log entry
+func (r *AuditRepositoryImpl) Create(ctx context.Context, log *domains.AuditLog) error {
+	if err := r.db.WithContext(ctx).Create(r.domainToDBAuditLog(log)).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to create audit log")
+	}
+
+	return nil
+}
+
+// GetByUser retrieves the newest audit logs of actions performed by a user
+func (r *AuditRepositoryImpl) GetByUser(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domains.AuditLog, error) {
+	return r.find(ctx, r.db.Where("user_id = ?", userID), limit, offset)
+}
+
+// GetByResource retrieves the newest audit logs of a resource
+func (r *AuditRepositoryImpl) GetByResource(ctx context.Context, resourceType domains.ResourceType, resourceID string, limit, offset int) ([]domains.AuditLog, error) {
+	return r.find(ctx, r.db.Where("resource_type = ? AND resource_id = ?", resourceType, resourceID), limit, offset)
+}
+
+// GetByAction retrieves the newest audit logs of an action type
+func (r *AuditRepositoryImpl) GetByAction(ctx context.Context, actionType domains.ActionType, limit, offset int) ([]domains.AuditLog, error) {
+	return r.find(ctx, r.db.Where("action_type = ?", actionType), limit, offset)
+}
+
+// Search retrieves the newest audit logs matching every given criterion
+func (r *AuditRepositoryImpl) Search(ctx context.Context, params map[string]any, limit, offset int) ([]domains.AuditLog, error) {
+	query := r.db
+	for key, value := range params {
+		column, ok := auditSearchColumns[key]
+		if !ok {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("unsupported audit log search criterion %q", key))
+		}
+		query = query.Where(column+" = ?", value)
+	}
+	return r.find(ctx, query, limit, offset)
+}
+
+// List retrieves the audit logs matching filter, newest first, with their total count
+func (r *AuditRepositoryImpl) List(ctx context.Context, filter *domains.AuditLogFilter, offset, limit int) ([]domains.AuditLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.AuditLog{})
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ActionType != "" {
+		query = query.Where("action_type = ?", filter.ActionType)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.WrapError(err, 500, "failed to count audit logs")
+	}
+
+	logs, err := r.find(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// find loads a page of the audit logs matching query, newest first
+func (r *AuditRepositoryImpl) find(ctx context.Context, query *gorm.DB, limit, offset int) ([]domains.AuditLog, error) {
+	var dbLogs []database.AuditLog
+	if err := query.WithContext(ctx).Order("created_at DESC").Limit(limit).Offset(offset).Find(&dbLogs).Error; err != nil {
+		return nil, errors.WrapError(err, 500, "failed to get audit logs")
+	}
+
+	logs := make([]domains.AuditLog, len(dbLogs))
+	for i := range dbLogs {
+		logs[i] = r.dbToDomainAuditLog(&dbLogs[i])
+	}
+	return logs, nil
+}
+
+// domainToDBAuditLog converts domain audit log to database audit log
+func (r *AuditRepositoryImpl) domainToDBAuditLog(log *domains.AuditLog) *database.AuditLog {
+	dbLog := &database.AuditLog{
+		Base: database.Base{
+			ID:        log.ID,
+			CreatedAt: log.CreatedAt,
+			UpdatedAt: log.CreatedAt,
+		},
+		ActionType:   string(log.ActionType),
+		ResourceType: string(log.ResourceType),
+		ResourceID:   log.ResourceID,
+		Description:  log.Description,
+		IPAddress:    log.IPAddress,
+		UserAgent:    log.UserAgent,
+	}
+	if log.UserID != uuid.Nil {
+		userID := log.UserID
+		dbLog.UserID = &userID
+	}
+	if log.Metadata != "" {
+		dbLog.Metadata = database.JSONB(log.Metadata)
+	}
+	return dbLog
+}
+
+// dbToDomainAuditLog converts database audit log to domain audit log
+func (r *AuditRepositoryImpl) dbToDomainAuditLog(dbLog *database.AuditLog) domains.AuditLog {
+	log := domains.AuditLog{
+		ID:           dbLog.ID,
+		ActionType:   domains.ActionType(dbLog.ActionType),
+		ResourceType: domains.ResourceType(dbLog.ResourceType),
+		ResourceID:   dbLog.ResourceID,
+		Description:  dbLog.Description,
+		Metadata:     string(dbLog.Metadata),
+		IPAddress:    dbLog.IPAddress,
+		UserAgent:    dbLog.UserAgent,
+		CreatedAt:    dbLog.CreatedAt,
+	}
+	if dbLog.UserID != nil {
+		log.UserID = *dbLog.UserID
+	}
+	return log
+}
@@ -2,21 +2,27 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"time"
 
+	logger "github.com/lugondev/go-log"
+
 	domains "github.com/lugondev/m3-storage/internal/modules/app/domain"
 	ports "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
 
 	"github.com/google/uuid"
 )
 
 type auditService struct {
 	auditRepo ports.AuditRepository
+	logger    logger.Logger
 }
 
-func NewAuditService(auditRepo ports.AuditRepository) ports.AuditService {
+func NewAuditService(auditRepo ports.AuditRepository, appLogger logger.Logger) ports.AuditService {
 	return &auditService{
 		auditRepo: auditRepo,
+		logger:    appLogger.WithFields(map[string]any{"component": "AuditService"}),
 	}
 }
 
@@ -34,6 +40,43 @@ func (s *auditService) Log(ctx context.Context, log *domains.AuditLog) error {
 	return s.auditRepo.Create(ctx, log)
 }
 
+// Record logs an action of a user. The action has already happened, so a failure to
+// record it is logged rather than returned.
+func (s *auditService) Record(ctx context.Context, userID uuid.UUID, action domains.ActionType, resourceType domains.ResourceType, resourceID string, metadata map[string]any) {
+	entry := &domains.AuditLog{
+		UserID:       userID,
+		ActionType:   action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+	if client, ok := domains.AuditClientFromContext(ctx); ok {
+		entry.IPAddress = client.IPAddress
+		entry.UserAgent = client.UserAgent
+	}
+	if len(metadata) > 0 {
+		if raw, err := json.Marshal(metadata); err == nil {
+			entry.Metadata = string(raw)
+		}
+	}
+
+	if err := s.Log(ctx, entry); err != nil {
+		s.logger.Error(ctx, "Failed to record action in audit log", map[string]any{
+			"error": err, "userID": userID.String(), "action": string(action), "resourceType": string(resourceType), "resourceID": resourceID,
+		})
+	}
+}
+
+// List retrieves a page of the audit logs matching filter, newest first
+func (s *auditService) List(ctx context.Context, query *utils.PaginationQuery, filter *domains.AuditLogFilter) (*utils.Pagination, []domains.AuditLog, error) {
+	query.ValidateAndSetDefaults()
+	logs, total, err := s.auditRepo.List(ctx, filter, query.GetOffset(), query.GetLimit())
+	if err != nil {
+		return nil, nil, err
+	}
+	pagination := utils.NewPagination(*query, total)
+	return &pagination, logs, nil
+}
+
 // GetUserLogs retrieves audit logs for a specific user
 func (s *auditService) GetUserLogs(ctx context.Context, userID uuid.UUID, limit, offset int) ([]domains.AuditLog, error) {
 	return s.auditRepo.GetByUser(ctx, userID, limit, offset)
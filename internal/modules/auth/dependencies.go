@@ -1,12 +1,16 @@
 package auth
 
 import (
+	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/infra/jwt"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
 	"github.com/lugondev/m3-storage/internal/modules/auth/handler"
 	"github.com/lugondev/m3-storage/internal/modules/auth/port"
 	"github.com/lugondev/m3-storage/internal/modules/auth/service"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
 	"github.com/lugondev/m3-storage/internal/shared/validator"
 
+	sen "github.com/lugondev/send-sen"
 	"gorm.io/gorm"
 )
 
@@ -14,26 +18,43 @@ import (
 type Dependencies struct {
 	UserRepo        port.UserRepository
 	UserProfileRepo port.UserProfileRepository
+	ResetTokenRepo  port.PasswordResetTokenRepository
+	VerifyTokenRepo port.EmailVerificationTokenRepository
+	RefreshRepo     port.RefreshTokenRepository
 	AuthService     port.AuthService
+	UserService     port.UserService
 	AuthHandler     *handler.AuthHandler
 }
 
-// NewDependencies creates and wires all authentication dependencies
-func NewDependencies(db *gorm.DB, jwtService *jwt.JWTService, validator validator.Validator) *Dependencies {
+// NewDependencies creates and wires all authentication dependencies.
+// emails delivers reset and verification tokens and may be nil; notifier tells
+// operators about password resets and may be nil. attempts counts failed logins per
+// email and may be nil to count them in the database only;
+// revocations invalidates access tokens when sessions are revoked and may be nil.
+// Logins, logouts and password changes are recorded through audit.
+func NewDependencies(db *gorm.DB, jwtService *jwt.JWTService, validator validator.Validator, emails sen.EmailService, notifier sen.NotifyService, attempts port.LoginAttemptTracker, revocations port.TokenRevocationStore, audit appPort.AuditService, cfg config.AuthConfig, clk clock.Clock) *Dependencies {
 	// Repositories
 	userRepo := service.NewUserRepository(db)
 	userProfileRepo := service.NewUserProfileRepository(db)
+	resetTokenRepo := service.NewPasswordResetTokenRepository(db)
+	verifyTokenRepo := service.NewEmailVerificationTokenRepository(db)
+	refreshRepo := service.NewRefreshTokenRepository(db)
 
 	// Services
-	authService := service.NewAuthService(userRepo, userProfileRepo, jwtService)
+	authService := service.NewAuthService(userRepo, userProfileRepo, resetTokenRepo, verifyTokenRepo, refreshRepo, jwtService, emails, notifier, attempts, revocations, cfg, clk)
+	userService := service.NewUserService(userRepo, clk)
 
 	// Handlers
-	authHandler := handler.NewAuthHandler(authService, validator)
+	authHandler := handler.NewAuthHandler(authService, audit, jwtService, validator)
 
 	return &Dependencies{
 		UserRepo:        userRepo,
 		UserProfileRepo: userProfileRepo,
+		ResetTokenRepo:  resetTokenRepo,
+		VerifyTokenRepo: verifyTokenRepo,
+		RefreshRepo:     refreshRepo,
 		AuthService:     authService,
+		UserService:     userService,
 		AuthHandler:     authHandler,
 	}
 }
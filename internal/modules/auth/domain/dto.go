@@ -1,5 +1,13 @@
 package domain
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
 // LoginRequest represents a login request
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
@@ -34,6 +42,12 @@ type ChangePasswordRequest struct {
 	NewPassword     string `json:"new_password" validate:"required,min=6"`
 }
 
+// ChangeEmailRequest requests a change of the login email, confirmed with the password
+type ChangeEmailRequest struct {
+	NewEmail string `json:"new_email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
 // ForgotPasswordRequest represents a forgot password request
 type ForgotPasswordRequest struct {
 	Email string `json:"email" validate:"required,email"`
@@ -45,6 +59,16 @@ type ResetPasswordRequest struct {
 	NewPassword string `json:"new_password" validate:"required,min=6"`
 }
 
+// VerifyEmailRequest represents an email verification request
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest represents a request for a new email verification token
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
 // UpdateProfileRequest represents a profile update request
 type UpdateProfileRequest struct {
 	FirstName   string `json:"first_name,omitempty" validate:"omitempty,min=1,max=50"`
@@ -53,3 +77,80 @@ type UpdateProfileRequest struct {
 	Timezone    string `json:"timezone,omitempty"`
 	Language    string `json:"language,omitempty"`
 }
+
+// AccountStatusResponse reports the failed login attempts and lock state of an account
+type AccountStatusResponse struct {
+	FailedAttempts       int        `json:"failed_attempts"`        // Failed logins since the last successful one
+	RecentFailedAttempts int        `json:"recent_failed_attempts"` // Failed logins within the tracking window
+	MaxFailedAttempts    int        `json:"max_failed_attempts"`    // Failed logins within the window that lock the account
+	Locked               bool       `json:"locked"`
+	LockedUntil          *time.Time `json:"locked_until,omitempty"`
+}
+
+// UploadQuota reports a user's storage quota and daily file limit with their current
+// usage; a zero limit means unlimited
+type UploadQuota struct {
+	UsedStorageBytes   int64 `json:"used_storage_bytes"`
+	MaxStorageBytes    int64 `json:"max_storage_bytes"`
+	MaxFilesPerDay     int   `json:"max_files_per_day"`
+	FilesUploadedToday int   `json:"files_uploaded_today"`
+}
+
+// UpdateRoleRequest changes a user's role
+type UpdateRoleRequest struct {
+	Role UserRole `json:"role" validate:"required,oneof=user admin"`
+}
+
+// UpdateQuotaRequest replaces a user's quota limits; 0 means unlimited
+type UpdateQuotaRequest struct {
+	MaxStorageBytes *int64 `json:"max_storage_bytes" validate:"required,min=0"`
+	MaxFilesPerDay  *int   `json:"max_files_per_day" validate:"required,min=0"`
+}
+
+// UserFilter narrows an admin listing of users
+type UserFilter struct {
+	Email  string     // Part of the email, matched case-insensitively
+	Status UserStatus // Exact status
+}
+
+// UpdateUserRequest changes the state of a user's account. "active" reactivates the
+// account and lifts any lock, "suspended" suspends it and "locked" keeps the user from
+// logging in until LockedUntil.
+type UpdateUserRequest struct {
+	Status      string     `json:"status" validate:"required,oneof=active suspended locked"`
+	LockedUntil *time.Time `json:"locked_until,omitempty"` // Required with "locked"
+}
+
+// DeleteUserRequest confirms deleting one's own account with its password
+type DeleteUserRequest struct {
+	Password string `json:"password"`
+}
+
+// UserDeleteSummary reports what deleting a user leaves behind, or would leave behind on a
+// dry run. Deleting is soft, so the resources stay in place for a restored account.
+type UserDeleteSummary struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	Email     string           `json:"email"`
+	Resources map[string]int64 `json:"resources"` // What the user still owns, by kind
+	DryRun    bool             `json:"dry_run"`
+}
+
+// Blocking returns the kinds of resource the user still owns, with their counts
+func (s *UserDeleteSummary) Blocking() map[string]any {
+	blocking := map[string]any{}
+	for kind, count := range s.Resources {
+		if count > 0 {
+			blocking[kind] = count
+		}
+	}
+	return blocking
+}
+
+// AccountDeleteSummary reports the deletion of the current user's own account. The
+// account is only deleted once every media file is gone; when some fail, Deleted is
+// false and the request can be repeated.
+type AccountDeleteSummary struct {
+	UserDeleteSummary
+	Deleted bool                          `json:"deleted"`
+	Media   *utils.BatchResult[uuid.UUID] `json:"media,omitempty"` // Outcome of each media file, by media ID
+}
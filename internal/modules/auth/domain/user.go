@@ -16,6 +16,19 @@ const (
 	UserStatusPending   UserStatus = "pending"
 )
 
+// UserRole decides what a user is authorized to do beyond their own data
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "user"
+	UserRoleAdmin UserRole = "admin" // May use the /admin routes
+)
+
+// Valid reports whether r is a known role
+func (r UserRole) Valid() bool {
+	return r == UserRoleUser || r == UserRoleAdmin
+}
+
 // User represents a user in the authentication system
 type User struct {
 	ID             uuid.UUID  `json:"id"`
@@ -30,18 +43,77 @@ type User struct {
 	LockedUntil    *time.Time `json:"locked_until,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Upload quota; a zero limit means unlimited
+	UsedStorageBytes int64      `json:"used_storage_bytes"`
+	MaxStorageBytes  int64      `json:"max_storage_bytes"`
+	MaxFilesPerDay   int        `json:"max_files_per_day"`
+	DailyFileCount   int        `json:"daily_file_count"`
+	DailyCountDate   *time.Time `json:"-"` // UTC day DailyFileCount belongs to
+
+	// Transfer rate of the user's uploads and downloads; zero uses media.bandwidth.perUserBytesPerSecond
+	MaxBandwidthBytesPerSecond int64 `json:"max_bandwidth_bytes_per_second"`
+
+	Role UserRole `json:"role"`
 }
 
 // UserProfile represents additional user profile information
 type UserProfile struct {
-	UserID      uuid.UUID  `json:"user_id"`
-	Avatar      string     `json:"avatar,omitempty"`
-	PhoneNumber string     `json:"phone_number,omitempty"`
-	DateOfBirth *time.Time `json:"date_of_birth,omitempty"`
-	Timezone    string     `json:"timezone,omitempty"`
-	Language    string     `json:"language,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Avatar        string     `json:"avatar,omitempty"`          // URL of the avatar image
+	AvatarMediaID *uuid.UUID `json:"avatar_media_id,omitempty"` // Media record of an uploaded avatar
+	PhoneNumber   string     `json:"phone_number,omitempty"`
+	DateOfBirth   *time.Time `json:"date_of_birth,omitempty"`
+	Timezone      string     `json:"timezone,omitempty"`
+	Language      string     `json:"language,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// PasswordResetToken is a single-use password reset token. Only the SHA-256 hash of
+// the token is stored, so a database leak does not expose usable tokens.
+type PasswordResetToken struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"-"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsExpired checks if the token has expired at the given time
+func (t *PasswordResetToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// EmailVerificationToken is a single-use token that proves ownership of a user's email
+// address. Like password reset tokens, only the SHA-256 hash is stored.
+type EmailVerificationToken struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	TokenHash string    `json:"-"`
+	NewEmail  string    `json:"new_email,omitempty"` // Address the user is changing to; empty to verify the current one
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsExpired checks if the token has expired at the given time
+func (t *EmailVerificationToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// RefreshToken records an issued refresh token by its JWT ID. Tokens obtained from one
+// login form a family: each refresh uses up the presented token and issues the next one
+// in the same family, so presenting a used token reveals theft and revokes the family.
+type RefreshToken struct {
+	ID        uuid.UUID `json:"id"` // The token's jti claim
+	UserID    uuid.UUID `json:"user_id"`
+	FamilyID  uuid.UUID `json:"family_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Used      bool      `json:"used"`
+	Revoked   bool      `json:"revoked"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // IsActive checks if the user account is active
@@ -49,17 +121,31 @@ func (u *User) IsActive() bool {
 	return u.Status == UserStatusActive
 }
 
-// IsLocked checks if the user account is currently locked
-func (u *User) IsLocked() bool {
+// IsLocked checks if the user account is locked at the given time
+func (u *User) IsLocked(now time.Time) bool {
 	if u.LockedUntil == nil {
 		return false
 	}
-	return time.Now().Before(*u.LockedUntil)
+	return now.Before(*u.LockedUntil)
+}
+
+// CanLogin checks if the user can login (active and not locked) at the given time
+func (u *User) CanLogin(now time.Time) bool {
+	return u.IsActive() && !u.IsLocked(now)
 }
 
-// CanLogin checks if the user can login (active and not locked)
-func (u *User) CanLogin() bool {
-	return u.IsActive() && !u.IsLocked()
+// QuotaDay returns the UTC calendar day that daily upload limits are counted against
+func QuotaDay(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour)
+}
+
+// FilesUploadedOn returns the number of files uploaded on now's quota day; a count
+// recorded on an earlier day has been reset by midnight
+func (u *User) FilesUploadedOn(now time.Time) int {
+	if u.DailyCountDate == nil || !QuotaDay(*u.DailyCountDate).Equal(QuotaDay(now)) {
+		return 0
+	}
+	return u.DailyFileCount
 }
 
 // GetFullName returns the user's full name
@@ -81,14 +167,13 @@ func (u *User) ResetFailedAttempts() {
 	u.LockedUntil = nil
 }
 
-// LockAccount locks the user account for the specified duration
-func (u *User) LockAccount(duration time.Duration) {
-	lockUntil := time.Now().Add(duration)
+// LockAccount locks the user account for the specified duration starting at now
+func (u *User) LockAccount(now time.Time, duration time.Duration) {
+	lockUntil := now.Add(duration)
 	u.LockedUntil = &lockUntil
 }
 
 // UpdateLastLogin updates the last login timestamp
-func (u *User) UpdateLastLogin() {
-	now := time.Now()
+func (u *User) UpdateLastLogin(now time.Time) {
 	u.LastLoginAt = &now
 }
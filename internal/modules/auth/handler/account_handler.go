@@ -0,0 +1,126 @@
+package handler
+
+import (
+	logger "github.com/lugondev/go-log"
+
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AccountHandler handles deleting the current user's account together with their media
+type AccountHandler struct {
+	authService  port.AuthService
+	userService  port.UserService
+	resources    port.UserResourceCounter
+	media        port.UserMediaPurger
+	auditService appPort.AuditService
+	logger       logger.Logger
+}
+
+// NewAccountHandler creates a new account handler. resources and media count and delete
+// what the user owns outside the auth module.
+func NewAccountHandler(authService port.AuthService, userService port.UserService, resources port.UserResourceCounter, media port.UserMediaPurger, auditService appPort.AuditService, appLogger logger.Logger) *AccountHandler {
+	return &AccountHandler{
+		authService:  authService,
+		userService:  userService,
+		resources:    resources,
+		media:        media,
+		auditService: auditService,
+		logger:       appLogger.WithFields(map[string]any{"component": "AccountHandler"}),
+	}
+}
+
+// DeleteAccount handles deleting the current user's account
+// @Summary Delete account
+// @Description Permanently delete the current user's media from their storage providers, then their profile, and soft delete the account. Files that cannot be deleted are reported and keep the account in place so the request can be repeated. An account that still owns media, folders or active shares is only deleted with force=true; dry_run=true reports what it owns without deleting. The password is required unless dry_run is set.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param force query bool false "Delete the account's media, folders and shares with it"
+// @Param dry_run query bool false "Only report what the account owns"
+// @Param request body domain.DeleteUserRequest false "Password of the account"
+// @Success 200 {object} domain.AccountDeleteSummary
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse "Account still owns resources"
+// @Router /api/v1/auth/account [delete]
+func (h *AccountHandler) DeleteAccount(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	force, dryRun := c.QueryBool("force"), c.QueryBool("dry_run")
+
+	if !dryRun {
+		var req domain.DeleteUserRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return errors.NewBadRequestError("invalid request body")
+			}
+		}
+		if req.Password == "" {
+			return errors.NewBadRequestError("password is required to delete your account")
+		}
+		if err := h.authService.VerifyPassword(c.Context(), userID, req.Password); err != nil {
+			return err
+		}
+	}
+
+	resources, err := h.resources.CountUserResources(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	// Only reports; the account is deleted below, after its media
+	summary, err := h.userService.DeleteUser(c.Context(), userID, resources, force, true)
+	if err != nil {
+		return err
+	}
+	result := &domain.AccountDeleteSummary{UserDeleteSummary: *summary}
+	if dryRun {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    result,
+			"message": "Dry run; account not deleted",
+		})
+	}
+	result.DryRun = false
+	if blocking := summary.Blocking(); !force && len(blocking) > 0 {
+		return errors.NewConflictError("account still owns resources; delete with force=true to delete them with the account").
+			WithDetails(blocking)
+	}
+
+	result.Media, err = h.media.PurgeUserMedia(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	if result.Media.Summary.Failed > 0 {
+		h.logger.Warn(c.Context(), "Account kept; some media could not be deleted", map[string]any{
+			"userID": userID.String(), "failed": result.Media.Summary.Failed,
+		})
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    result,
+			"message": "Some media could not be deleted; the account was kept, retry to finish deleting it",
+		})
+	}
+
+	if err := h.authService.DeleteAccount(c.Context(), userID); err != nil {
+		return err
+	}
+	result.Deleted = true
+	h.auditService.Record(middleware.AuditContext(c), userID, appDomain.ActionTypeDelete,
+		appDomain.ResourceTypeUser, userID.String(), map[string]any{"force": force, "resources": resources})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+		"message": "Account deleted successfully",
+	})
+}
@@ -0,0 +1,411 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	logger "github.com/lugondev/go-log"
+
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+	"github.com/lugondev/m3-storage/internal/shared/validator"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AdminHandler handles user management requests of admins. Every change is recorded
+// in the audit log.
+type AdminHandler struct {
+	authService  port.AuthService
+	userService  port.UserService
+	resources    port.UserResourceCounter
+	auditService appPort.AuditService
+	validator    validator.Validator
+	logger       logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler. resources counts what a user owns in
+// other modules, which keeps users from being deleted while they still own anything.
+func NewAdminHandler(authService port.AuthService, userService port.UserService, resources port.UserResourceCounter, auditService appPort.AuditService, validator validator.Validator, appLogger logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		authService:  authService,
+		userService:  userService,
+		resources:    resources,
+		auditService: auditService,
+		validator:    validator,
+		logger:       appLogger.WithFields(map[string]any{"component": "AdminHandler"}),
+	}
+}
+
+// ListUsers handles listing users
+// @Summary List users
+// @Description Get a page of users, newest first, optionally filtered by part of their email and by status
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Users per page" default(10)
+// @Param email query string false "Part of the email, case-insensitive"
+// @Param status query string false "Account status" Enums(active, inactive, suspended, pending)
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Router /api/v1/admin/users [get]
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	query := &utils.PaginationQuery{}
+	if err := c.QueryParser(query); err != nil {
+		return errors.ErrInvalidInput
+	}
+	filter := &domain.UserFilter{
+		Email:  c.Query("email"),
+		Status: domain.UserStatus(c.Query("status")),
+	}
+	switch filter.Status {
+	case "", domain.UserStatusActive, domain.UserStatusInactive, domain.UserStatusSuspended, domain.UserStatusPending:
+	default:
+		return errors.NewBadRequestError(fmt.Sprintf("unknown status %q", filter.Status))
+	}
+
+	pagination, users, err := h.userService.ListUsers(c.Context(), query, filter)
+	if err != nil {
+		return err
+	}
+
+	h.audit(c, adminID, appDomain.ActionTypeRead, appDomain.ResourceTypeUser, "", "Listed users",
+		map[string]any{"email": filter.Email, "status": filter.Status, "page": query.Page})
+
+	// Remove sensitive data before response
+	for _, user := range users {
+		user.PasswordHash = ""
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"users":      users,
+			"pagination": pagination,
+		},
+		"message": "Users retrieved successfully",
+	})
+}
+
+// UpdateUser handles changing the state of another user's account
+// @Summary Activate, suspend or lock a user
+// @Description Set a user's account to active, suspended or locked until a given time. Suspending or locking a user also signs them out of every session. Admins cannot suspend or lock themselves.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param request body domain.UpdateUserRequest true "New account state"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/admin/users/{id} [patch]
+func (h *AdminHandler) UpdateUser(c *fiber.Ctx) error {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return errors.NewBadRequestError("invalid user ID")
+	}
+
+	var req domain.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	if userID == adminID && req.Status != string(domain.UserStatusActive) {
+		return errors.NewBadRequestError("admins cannot suspend or lock themselves")
+	}
+
+	user, err := h.userService.UpdateUser(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]any{"status": req.Status}
+	if req.LockedUntil != nil {
+		metadata["locked_until"] = req.LockedUntil
+	}
+	if req.Status != string(domain.UserStatusActive) {
+		// Already issued tokens would otherwise keep working until they expire
+		if err := h.authService.RevokeSessions(c.Context(), userID); err != nil {
+			h.logger.Error(c.Context(), "Failed to revoke sessions of user", map[string]any{"error": err, "userID": userID.String()})
+			metadata["sessions_revoked"] = false
+		} else {
+			metadata["sessions_revoked"] = true
+		}
+	}
+	h.audit(c, adminID, appDomain.ActionTypeUpdate, appDomain.ResourceTypeUser, userID.String(),
+		fmt.Sprintf("Set account status to %s", req.Status), metadata)
+
+	// Remove sensitive data before response
+	user.PasswordHash = ""
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+		"message": "User updated successfully",
+	})
+}
+
+// DeleteUser handles soft deleting a user
+// @Summary Delete a user
+// @Description Soft delete a user and sign them out of every session. A user who still owns media, folders or active shares is only deleted with force=true; dry_run=true reports what the user owns without deleting. Admins deleting their own account must confirm it with their password.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param force query bool false "Delete even if the user still owns resources"
+// @Param dry_run query bool false "Only report what the user owns"
+// @Param request body domain.DeleteUserRequest false "Password, required to delete one's own account"
+// @Success 200 {object} domain.UserDeleteSummary
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse "User still owns resources"
+// @Router /api/v1/admin/users/{id} [delete]
+func (h *AdminHandler) DeleteUser(c *fiber.Ctx) error {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return errors.NewBadRequestError("invalid user ID")
+	}
+	force, dryRun := c.QueryBool("force"), c.QueryBool("dry_run")
+
+	if userID == adminID && !dryRun {
+		var req domain.DeleteUserRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return errors.NewBadRequestError("invalid request body")
+			}
+		}
+		if req.Password == "" {
+			return errors.NewBadRequestError("password is required to delete your own account")
+		}
+		if err := h.authService.VerifyPassword(c.Context(), userID, req.Password); err != nil {
+			return err
+		}
+	}
+
+	resources, err := h.resources.CountUserResources(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	summary, err := h.userService.DeleteUser(c.Context(), userID, resources, force, dryRun)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    summary,
+			"message": "Dry run; user not deleted",
+		})
+	}
+
+	if err := h.authService.RevokeSessions(c.Context(), userID); err != nil {
+		h.logger.Error(c.Context(), "Failed to revoke sessions of deleted user", map[string]any{"error": err, "userID": userID.String()})
+	}
+	h.audit(c, adminID, appDomain.ActionTypeDelete, appDomain.ResourceTypeUser, userID.String(),
+		fmt.Sprintf("Deleted user %s", summary.Email), map[string]any{"force": force, "resources": resources})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    summary,
+		"message": "User deleted successfully",
+	})
+}
+
+// UpdateUserRole handles changing another user's role
+// @Summary Set a user's role
+// @Description Change the role of a user. A user whose role changes is signed out of every session, so the new role applies at once. Admins cannot remove their own admin role.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param request body domain.UpdateRoleRequest true "New role"
+// @Success 200 {object} domain.User
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/admin/users/{id}/role [put]
+func (h *AdminHandler) UpdateUserRole(c *fiber.Ctx) error {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return errors.NewBadRequestError("invalid user ID")
+	}
+
+	var req domain.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	if userID == adminID && req.Role != domain.UserRoleAdmin {
+		// Leaving no admin behind would need database surgery to undo
+		return errors.NewBadRequestError("admins cannot remove their own admin role")
+	}
+
+	user, previous, err := h.userService.SetRole(c.Context(), userID, req.Role)
+	if err != nil {
+		return err
+	}
+
+	metadata := map[string]any{"role": req.Role, "previous_role": previous}
+	if previous != req.Role {
+		// Roles are read from the access token, so a demoted user would otherwise keep
+		// the old role's rights until the token expires
+		if err := h.authService.RevokeSessions(c.Context(), userID); err != nil {
+			h.logger.Error(c.Context(), "Failed to revoke sessions of user", map[string]any{"error": err, "userID": userID.String()})
+			metadata["sessions_revoked"] = false
+		} else {
+			metadata["sessions_revoked"] = true
+		}
+	}
+	h.audit(c, adminID, appDomain.ActionTypeUpdate, appDomain.ResourceTypeUser, userID.String(),
+		fmt.Sprintf("Set role to %s", req.Role), metadata)
+
+	// Remove sensitive data before response
+	user.PasswordHash = ""
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    user,
+		"message": "Role updated successfully",
+	})
+}
+
+// GetUserQuota handles getting another user's quota
+// @Summary Get a user's quota
+// @Description Get the storage quota and daily file limit of a user with their current usage
+// @Tags Admin
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Success 200 {object} domain.UploadQuota
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/admin/users/{id}/quota [get]
+func (h *AdminHandler) GetUserQuota(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return errors.NewBadRequestError("invalid user ID")
+	}
+
+	quota, err := h.userService.GetQuota(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    quota,
+		"message": "Quota retrieved successfully",
+	})
+}
+
+// UpdateUserQuota handles replacing another user's quota limits
+// @Summary Set a user's quota
+// @Description Replace the storage quota and daily file limit of a user; 0 means unlimited
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "User ID"
+// @Param request body domain.UpdateQuotaRequest true "New quota limits"
+// @Success 200 {object} domain.UploadQuota
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 403 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Router /api/v1/admin/users/{id}/quota [put]
+func (h *AdminHandler) UpdateUserQuota(c *fiber.Ctx) error {
+	adminID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return errors.NewBadRequestError("invalid user ID")
+	}
+
+	var req domain.UpdateQuotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	previous, err := h.userService.GetQuota(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	quota, err := h.userService.SetQuota(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+
+	h.audit(c, adminID, appDomain.ActionTypeUpdate, appDomain.ResourceTypeUserQuota, userID.String(),
+		fmt.Sprintf("Set quota to %d bytes and %d files per day", quota.MaxStorageBytes, quota.MaxFilesPerDay),
+		map[string]any{"before": previous, "after": quota})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    quota,
+		"message": "Quota updated successfully",
+	})
+}
+
+// audit records an action an admin took on users; resourceID is empty for actions on
+// no single user. The action has already happened, so a failure to record it is logged
+// rather than returned.
+func (h *AdminHandler) audit(c *fiber.Ctx, adminID uuid.UUID, action appDomain.ActionType, resourceType appDomain.ResourceType, resourceID string, description string, metadata map[string]any) {
+	entry := &appDomain.AuditLog{
+		UserID:       adminID,
+		ActionType:   action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Description:  description,
+		IPAddress:    c.IP(),
+		UserAgent:    c.Get(fiber.HeaderUserAgent),
+	}
+	if raw, err := json.Marshal(metadata); err == nil {
+		entry.Metadata = string(raw)
+	}
+
+	if err := h.auditService.Log(c.Context(), entry); err != nil {
+		h.logger.Error(c.Context(), "Failed to record admin action in audit log", map[string]any{
+			"error": err, "adminID": adminID.String(), "action": string(action), "resourceType": string(resourceType), "resourceID": resourceID,
+		})
+	}
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"github.com/lugondev/m3-storage/internal/infra/jwt"
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
 	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
 	"github.com/lugondev/m3-storage/internal/modules/auth/port"
 	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
@@ -10,20 +13,36 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-// AuthHandler handles authentication related HTTP requests
+// AuthHandler handles authentication related HTTP requests. Logins, logouts and
+// password changes are recorded in the audit log.
 type AuthHandler struct {
-	authService port.AuthService
-	validator   validator.Validator
+	authService  port.AuthService
+	auditService appPort.AuditService
+	jwtService   *jwt.JWTService
+	validator    validator.Validator
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService port.AuthService, validator validator.Validator) *AuthHandler {
+func NewAuthHandler(authService port.AuthService, auditService appPort.AuditService, jwtService *jwt.JWTService, validator validator.Validator) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		validator:   validator,
+		authService:  authService,
+		auditService: auditService,
+		jwtService:   jwtService,
+		validator:    validator,
 	}
 }
 
+// JWKS serves the public keys that validate issued tokens
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying RS256 access tokens; empty when tokens are signed with HS256
+// @Tags Authentication
+// @Produce json
+// @Success 200 {object} jwt.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *fiber.Ctx) error {
+	return c.JSON(h.jwtService.JWKS())
+}
+
 // Register handles user registration
 // @Summary Register a new user
 // @Description Register a new user account
@@ -90,6 +109,9 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return err
 	}
 
+	h.auditService.Record(middleware.AuditContext(c), response.User.ID, appDomain.ActionTypeLogin,
+		appDomain.ResourceTypeUserAuthentication, response.User.ID.String(), nil)
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    response,
@@ -132,6 +154,39 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	})
 }
 
+// RevokeRefreshToken handles refresh token revocation
+// @Summary Revoke refresh token
+// @Description Revoke a refresh token together with every token rotated from the same login
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.RefreshTokenRequest true "Refresh token to revoke"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/revoke [post]
+func (h *AuthHandler) RevokeRefreshToken(c *fiber.Ctx) error {
+	var req domain.RefreshTokenRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	if err := h.authService.RevokeRefreshToken(c.Context(), &req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Refresh token revoked successfully",
+	})
+}
+
 // GetProfile handles getting user profile
 // @Summary Get user profile
 // @Description Get current user profile information
@@ -169,6 +224,35 @@ func (h *AuthHandler) GetProfile(c *fiber.Ctx) error {
 	})
 }
 
+// AccountStatus handles getting the failed login attempts and lock state of the current user
+// @Summary Get account status
+// @Description Get failed login attempts and lock state of the current user
+// @Tags Authentication
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} domain.AccountStatusResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 404 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/account-status [get]
+func (h *AuthHandler) AccountStatus(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	status, err := h.authService.GetAccountStatus(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    status,
+		"message": "Account status retrieved successfully",
+	})
+}
+
 // UpdateProfile handles updating user profile
 // @Summary Update user profile
 // @Description Update current user profile information
@@ -243,12 +327,58 @@ func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
 		return err
 	}
 
+	h.auditService.Record(middleware.AuditContext(c), userID, appDomain.ActionTypePasswordChange,
+		appDomain.ResourceTypeUserAuthentication, userID.String(), nil)
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Password changed successfully",
 	})
 }
 
+// ChangeEmail handles requests to change the login email
+// @Summary Change email
+// @Description Send a verification token to a new email address. The login email changes to it once the token is passed to verify-email; until then the current email keeps working.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body domain.ChangeEmailRequest true "Change email request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 409 {object} errors.ErrorResponse "Email taken"
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/change-email [post]
+func (h *AuthHandler) ChangeEmail(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.ChangeEmailRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	if err := h.authService.ChangeEmail(c.Context(), userID, &req); err != nil {
+		return err
+	}
+
+	h.auditService.Record(middleware.AuditContext(c), userID, appDomain.ActionTypeEmailChange,
+		appDomain.ResourceTypeUserAuthentication, userID.String(), map[string]any{"new_email": req.NewEmail, "verified": false})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Verification token sent to the new email; the email changes once it is verified",
+	})
+}
+
 // ForgotPassword handles forgot password request
 // @Summary Forgot password
 // @Description Initiate password reset process
@@ -281,6 +411,102 @@ func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
 	})
 }
 
+// ResetPassword handles password reset with a reset token
+// @Summary Reset password
+// @Description Set a new password using a token issued by forgot-password
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/reset-password [post]
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req domain.ResetPasswordRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	if err := h.authService.ResetPassword(c.Context(), &req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password reset successfully",
+	})
+}
+
+// VerifyEmail handles email verification with a verification token
+// @Summary Verify email
+// @Description Mark the account's email as verified using a token issued at registration or by resend-verification. A token issued by change-email switches the account to the new email.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.VerifyEmailRequest true "Verify email request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/verify-email [post]
+func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req domain.VerifyEmailRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	if err := h.authService.VerifyEmail(c.Context(), &req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendVerification handles requests for a new email verification token
+// @Summary Resend verification
+// @Description Issue a new email verification token for an unverified account
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param request body domain.ResendVerificationRequest true "Resend verification request"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 500 {object} errors.ErrorResponse
+// @Router /api/v1/auth/resend-verification [post]
+func (h *AuthHandler) ResendVerification(c *fiber.Ctx) error {
+	var req domain.ResendVerificationRequest
+
+	if err := c.BodyParser(&req); err != nil {
+		return errors.NewBadRequestError("invalid request body")
+	}
+
+	if err := h.validator.Validate(&req); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+
+	if err := h.authService.SendVerificationEmail(c.Context(), &req); err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Verification instructions sent if the account exists and is unverified",
+	})
+}
+
 // Logout handles user logout (token invalidation would be handled by client or Redis blacklist)
 // @Summary User logout
 // @Description Logout user (client should discard tokens)
@@ -291,10 +517,16 @@ func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
 // @Failure 401 {object} errors.ErrorResponse
 // @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
 	// In a production system, you might want to:
 	// 1. Add token to blacklist in Redis
-	// 2. Log the logout event
-	// 3. Invalidate all refresh tokens for the user
+	// 2. Invalidate all refresh tokens for the user
+	h.auditService.Record(middleware.AuditContext(c), userID, appDomain.ActionTypeLogout,
+		appDomain.ResourceTypeUserAuthentication, userID.String(), nil)
 
 	return c.JSON(fiber.Map{
 		"success": true,
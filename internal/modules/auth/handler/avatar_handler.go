@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"fmt"
+
+	logger "github.com/lugondev/go-log"
+
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultAvatarMaxBytes is the largest avatar image accepted when no limit is configured
+const DefaultAvatarMaxBytes int64 = 2 << 20
+
+// AvatarHandler handles uploading the current user's avatar
+type AvatarHandler struct {
+	authService  port.AuthService
+	avatars      port.AvatarStore
+	auditService appPort.AuditService
+	maxBytes     int64
+	logger       logger.Logger
+}
+
+// NewAvatarHandler creates a new avatar handler. avatars stores the images; maxBytes
+// caps their size, with 0 meaning DefaultAvatarMaxBytes.
+func NewAvatarHandler(authService port.AuthService, avatars port.AvatarStore, auditService appPort.AuditService, maxBytes int64, appLogger logger.Logger) *AvatarHandler {
+	if maxBytes <= 0 {
+		maxBytes = DefaultAvatarMaxBytes
+	}
+	return &AvatarHandler{
+		authService:  authService,
+		avatars:      avatars,
+		auditService: auditService,
+		maxBytes:     maxBytes,
+		logger:       appLogger.WithFields(map[string]any{"component": "AvatarHandler"}),
+	}
+}
+
+// UploadAvatar handles replacing the current user's avatar
+// @Summary Upload avatar
+// @Description Upload a JPEG, PNG, GIF or WebP image as the current user's avatar. The image is stored like other media, with a thumbnail, and its URL is set as the profile's avatar; the previous uploaded avatar is deleted.
+// @Tags Authentication
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "Avatar image"
+// @Success 200 {object} domain.UserProfile
+// @Failure 400 {object} errors.ErrorResponse
+// @Failure 401 {object} errors.ErrorResponse
+// @Failure 413 {object} errors.ErrorResponse
+// @Router /api/v1/auth/avatar [post]
+func (h *AvatarHandler) UploadAvatar(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return errors.NewBadRequestError("file is required")
+	}
+	if fileHeader.Size > h.maxBytes {
+		return errors.NewError(fiber.StatusRequestEntityTooLarge, "avatar_too_large",
+			fmt.Sprintf("avatar must be at most %d bytes", h.maxBytes))
+	}
+
+	mediaID, url, err := h.avatars.UploadAvatar(c.Context(), userID, fileHeader)
+	if err != nil {
+		return err
+	}
+	profile, previous, err := h.authService.SetAvatar(c.Context(), userID, mediaID, url)
+	if err != nil {
+		// The new image is not referenced anywhere; do not leave it behind
+		if delErr := h.avatars.DeleteAvatar(c.Context(), userID, mediaID); delErr != nil {
+			h.logger.Warn(c.Context(), "Failed to delete unused avatar", map[string]any{"error": delErr, "mediaID": mediaID.String()})
+		}
+		return err
+	}
+	if previous != nil && *previous != mediaID {
+		if err := h.avatars.DeleteAvatar(c.Context(), userID, *previous); err != nil {
+			h.logger.Warn(c.Context(), "Failed to delete replaced avatar", map[string]any{"error": err, "mediaID": previous.String()})
+		}
+	}
+
+	h.auditService.Record(middleware.AuditContext(c), userID, appDomain.ActionTypeUpdate,
+		appDomain.ResourceTypeUserProfile, userID.String(), map[string]any{"avatar_media_id": mediaID})
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    profile,
+		"message": "Avatar updated successfully",
+	})
+}
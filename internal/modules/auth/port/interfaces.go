@@ -2,10 +2,12 @@ package port
 
 import (
 	"context"
+	"mime/multipart"
 	"time"
 
 	"github.com/lugondev/m3-storage/internal/infra/jwt"
 	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
 
 	"github.com/google/uuid"
 )
@@ -21,6 +23,10 @@ type UserRepository interface {
 	// GetByEmail retrieves a user by email
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 
+	// EmailInUse reports whether any user has the email, including deleted users, who
+	// keep their address
+	EmailInUse(ctx context.Context, email string) (bool, error)
+
 	// Update updates an existing user
 	Update(ctx context.Context, user *domain.User) error
 
@@ -35,6 +41,37 @@ type UserRepository interface {
 
 	// LockUser locks a user account until the specified time
 	LockUser(ctx context.Context, id uuid.UUID, lockedUntil *time.Time) error
+
+	// RecordUpload adds an uploaded file to the user's storage usage and daily file count
+	RecordUpload(ctx context.Context, id uuid.UUID, size int64, day time.Time) error
+
+	// ReserveStorage adds size bytes to the user's storage usage if the result stays within
+	// their storage quota, and reports whether it did
+	ReserveStorage(ctx context.Context, id uuid.UUID, size int64) (bool, error)
+
+	// ReleaseStorage subtracts size bytes from the user's storage usage, not going below zero
+	ReleaseStorage(ctx context.Context, id uuid.UUID, size int64) error
+
+	// SetUsedStorage overwrites the user's storage usage
+	SetUsedStorage(ctx context.Context, id uuid.UUID, bytes int64) error
+
+	// SetQuota overwrites the user's storage quota and daily file limit
+	SetQuota(ctx context.Context, id uuid.UUID, maxStorageBytes int64, maxFilesPerDay int) error
+
+	// SetRole overwrites the user's role
+	SetRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error
+
+	// SetStatus overwrites the user's status
+	SetStatus(ctx context.Context, id uuid.UUID, status domain.UserStatus) error
+
+	// List returns a page of the users matching filter, newest first, and how many match
+	List(ctx context.Context, filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error)
+
+	// ListIDs returns up to limit user IDs greater than after, in ascending order
+	ListIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+
+	// CountAfter counts the users with an ID greater than after
+	CountAfter(ctx context.Context, after uuid.UUID) (int64, error)
 }
 
 // UserProfileRepository defines the contract for user profile data persistence
@@ -52,6 +89,95 @@ type UserProfileRepository interface {
 	Delete(ctx context.Context, userID uuid.UUID) error
 }
 
+// PasswordResetTokenRepository defines the contract for password reset token persistence
+type PasswordResetTokenRepository interface {
+	// Create stores a new password reset token
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+
+	// GetByTokenHash retrieves a password reset token by the hash of its value
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+
+	// MarkUsed marks an unused token as used and reports whether it was unused, so
+	// concurrent resets with the same token cannot both succeed
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// EmailVerificationTokenRepository defines the contract for email verification token persistence
+type EmailVerificationTokenRepository interface {
+	// Create stores a new email verification token
+	Create(ctx context.Context, token *domain.EmailVerificationToken) error
+
+	// GetByTokenHash retrieves an email verification token by the hash of its value
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error)
+
+	// MarkUsed marks an unused token as used and reports whether it was unused
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// RefreshTokenRepository defines the contract for issued refresh token persistence
+type RefreshTokenRepository interface {
+	// Create records a newly issued refresh token
+	Create(ctx context.Context, token *domain.RefreshToken) error
+
+	// GetByID retrieves a refresh token by its JWT ID
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error)
+
+	// MarkUsed marks an unused, unrevoked token as used and reports whether it was,
+	// so concurrent refreshes with the same token cannot both succeed
+	MarkUsed(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// RevokeFamily revokes every token of a family
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+
+	// RevokeUser revokes every token of a user
+	RevokeUser(ctx context.Context, userID uuid.UUID) error
+}
+
+// TokenRevocationStore remembers when a user's tokens were revoked, so access tokens
+// issued before then are rejected although their signature is still valid
+type TokenRevocationStore interface {
+	// RevokeUserTokens invalidates the user's tokens issued up to at
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID, at time.Time) error
+
+	// RevokedAt returns when the user's tokens were last revoked, and false when no
+	// still-valid token can be affected
+	RevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, bool, error)
+}
+
+// AvatarStore stores avatar images outside the auth module
+type AvatarStore interface {
+	// UploadAvatar stores an image as the user's avatar and returns its media ID and URL
+	UploadAvatar(ctx context.Context, userID uuid.UUID, file *multipart.FileHeader) (uuid.UUID, string, error)
+
+	// DeleteAvatar removes an avatar image that has been replaced
+	DeleteAvatar(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) error
+}
+
+// UserResourceCounter reports what a user still owns outside the auth module, by kind of
+// resource, so deleting the user can be refused while anything would be left behind
+type UserResourceCounter interface {
+	CountUserResources(ctx context.Context, userID uuid.UUID) (map[string]int64, error)
+}
+
+// UserMediaPurger deletes everything a user stores outside the auth module
+type UserMediaPurger interface {
+	// PurgeUserMedia deletes every media file of the user, continuing past files that
+	// fail, and returns the outcome of each by media ID
+	PurgeUserMedia(ctx context.Context, userID uuid.UUID) (*utils.BatchResult[uuid.UUID], error)
+}
+
+// LoginAttemptTracker counts failed logins per email over a sliding window
+type LoginAttemptTracker interface {
+	// RecordFailure records a failed login at now and returns the failures within the window
+	RecordFailure(ctx context.Context, email string, now time.Time) (int, error)
+
+	// Failures returns the failed logins within the window ending at now
+	Failures(ctx context.Context, email string, now time.Time) (int, error)
+
+	// Reset forgets the failed logins of email
+	Reset(ctx context.Context, email string) error
+}
+
 // AuthService defines the contract for authentication operations
 type AuthService interface {
 	// Register creates a new user account
@@ -63,6 +189,9 @@ type AuthService interface {
 	// RefreshToken generates new tokens using refresh token
 	RefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) (*domain.LoginResponse, error)
 
+	// RevokeRefreshToken revokes the presented refresh token and every token rotated from the same login
+	RevokeRefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) error
+
 	// ChangePassword changes user's password
 	ChangePassword(ctx context.Context, userID uuid.UUID, req *domain.ChangePasswordRequest) error
 
@@ -72,12 +201,102 @@ type AuthService interface {
 	// ResetPassword resets password using reset token
 	ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) error
 
+	// SendVerificationEmail issues a new email verification token for an unverified account
+	SendVerificationEmail(ctx context.Context, req *domain.ResendVerificationRequest) error
+
+	// VerifyEmail marks the email of the token's user as verified, or switches the user to
+	// the new email of an email change token
+	VerifyEmail(ctx context.Context, req *domain.VerifyEmailRequest) error
+
+	// ChangeEmail sends a token to the new email; the login email changes once the token
+	// is passed to VerifyEmail
+	ChangeEmail(ctx context.Context, userID uuid.UUID, req *domain.ChangeEmailRequest) error
+
 	// GetProfile retrieves user profile
 	GetProfile(ctx context.Context, userID uuid.UUID) (*domain.User, *domain.UserProfile, error)
 
 	// UpdateProfile updates user profile
 	UpdateProfile(ctx context.Context, userID uuid.UUID, req *domain.UpdateProfileRequest) error
 
+	// SetAvatar makes the uploaded image mediaID, served at url, the user's avatar and
+	// returns the profile with the media ID of the avatar it replaced, if any
+	SetAvatar(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, url string) (*domain.UserProfile, *uuid.UUID, error)
+
+	// GetAccountStatus returns the user's failed login attempts and lock state
+	GetAccountStatus(ctx context.Context, userID uuid.UUID) (*domain.AccountStatusResponse, error)
+
 	// ValidateToken validates JWT token and returns claims
 	ValidateToken(ctx context.Context, tokenString string) (*jwt.JWTClaims, error)
+
+	// VerifyPassword returns an unauthorized error unless password is the user's, e.g. to
+	// confirm a destructive action of a logged in user
+	VerifyPassword(ctx context.Context, userID uuid.UUID, password string) error
+
+	// RevokeSessions invalidates every refresh and access token issued to the user
+	RevokeSessions(ctx context.Context, userID uuid.UUID) error
+
+	// DeleteAccount deletes the user's profile, soft deletes the user and signs them out
+	// everywhere. What the user owns elsewhere has to be removed first.
+	DeleteAccount(ctx context.Context, userID uuid.UUID) error
+}
+
+// UserService enforces per-user upload quotas
+type UserService interface {
+	// CanUpload returns a forbidden error if storing another file of size bytes would
+	// exceed the user's storage quota or daily file limit
+	CanUpload(ctx context.Context, userID uuid.UUID, size int64) error
+
+	// CanUploadFiles is CanUpload for count files of size bytes in total, e.g. a batch
+	// upload that must fit the quotas as a whole before any file is stored
+	CanUploadFiles(ctx context.Context, userID uuid.UUID, count int, size int64) error
+
+	// ReserveStorage adds size bytes for count files to the user's storage usage in one
+	// atomic step, returning a forbidden error instead if they would exceed the storage
+	// quota. Concurrent reservations cannot overshoot the quota together, unlike a
+	// CanUploadFiles check followed by RecordUpload. The bytes are returned with
+	// ReleaseStorage, or kept by passing size less the reservation to RecordUpload.
+	ReserveStorage(ctx context.Context, userID uuid.UUID, count int, size int64) error
+
+	// RecordUpload counts a stored file of size bytes against the user's quotas
+	RecordUpload(ctx context.Context, userID uuid.UUID, size int64) error
+
+	// ReleaseStorage returns size bytes of deleted files or unused reservations to the
+	// user's storage quota. The daily file count is unchanged.
+	ReleaseStorage(ctx context.Context, userID uuid.UUID, size int64) error
+
+	// GetQuota returns the user's quota limits and how much of them is used
+	GetQuota(ctx context.Context, userID uuid.UUID) (*domain.UploadQuota, error)
+
+	// BandwidthLimit returns the transfer rate set on the user in bytes per second, or 0
+	// when the user has none of their own
+	BandwidthLimit(ctx context.Context, userID uuid.UUID) (int64, error)
+
+	// SetUsedStorage replaces the user's recorded storage usage with bytes, e.g. after
+	// recounting it from their stored files, and returns the value it replaced
+	SetUsedStorage(ctx context.Context, userID uuid.UUID, bytes int64) (int64, error)
+
+	// ListUserIDs pages through all users in ID order: up to limit IDs greater than after
+	ListUserIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+
+	// CountUsers counts the users ListUserIDs would return after after; uuid.Nil counts all
+	CountUsers(ctx context.Context, after uuid.UUID) (int64, error)
+
+	// SetQuota replaces the user's storage quota and daily file limit, 0 meaning unlimited,
+	// and returns the updated quota
+	SetQuota(ctx context.Context, userID uuid.UUID, req *domain.UpdateQuotaRequest) (*domain.UploadQuota, error)
+
+	// SetRole changes the user's role and returns the updated user and the previous
+	// role. The new role is carried by the user's next access token.
+	SetRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) (*domain.User, domain.UserRole, error)
+
+	// ListUsers returns a page of the users matching filter, newest first
+	ListUsers(ctx context.Context, query *utils.PaginationQuery, filter *domain.UserFilter) (*utils.Pagination, []*domain.User, error)
+
+	// UpdateUser changes the state of the user's account and returns the updated user
+	UpdateUser(ctx context.Context, userID uuid.UUID, req *domain.UpdateUserRequest) (*domain.User, error)
+
+	// DeleteUser soft deletes the user. resources is what the user still owns elsewhere;
+	// unless force is set, a user owning anything is not deleted and a conflict error
+	// lists it. A dry run only reports what deleting would leave behind.
+	DeleteUser(ctx context.Context, userID uuid.UUID, resources map[string]int64, force, dryRun bool) (*domain.UserDeleteSummary, error)
 }
@@ -2,48 +2,138 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/infra/jwt"
 	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
 	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
 	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/validator"
 
 	jwtLib "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	sen "github.com/lugondev/send-sen"
+	"github.com/lugondev/send-sen/dto"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
-	// MaxFailedAttempts before locking account
+	// MaxFailedAttempts before locking account, unless auth.maxFailedAttempts is set
 	MaxFailedAttempts = 5
-	// AccountLockDuration for locked accounts
+	// AccountLockDuration for locked accounts, unless auth.accountLockSeconds is set
 	AccountLockDuration = 30 * time.Minute
-	// AccessTokenDuration for access tokens
+	// AccessTokenDuration for access tokens, unless auth.accessTokenTTLSeconds is set
 	AccessTokenDuration = 15 * time.Minute
-	// RefreshTokenDuration for refresh tokens
+	// RefreshTokenDuration for refresh tokens, unless auth.refreshTokenTTLSeconds is set
 	RefreshTokenDuration = 7 * 24 * time.Hour // 7 days
+	// PasswordResetTokenDuration for password reset tokens
+	PasswordResetTokenDuration = time.Hour
+	// EmailVerificationTokenDuration for email verification tokens
+	EmailVerificationTokenDuration = 24 * time.Hour
 )
 
+// ErrEmailNotVerified is returned by Login when verified emails are required and the
+// account's email has not been verified yet
+var ErrEmailNotVerified = errors.NewError(http.StatusForbidden, "email_not_verified", "email address is not verified")
+
 // AuthServiceImpl implements the AuthService interface
 type AuthServiceImpl struct {
 	userRepo        port.UserRepository
 	userProfileRepo port.UserProfileRepository
+	resetTokenRepo  port.PasswordResetTokenRepository
+	verifyTokenRepo port.EmailVerificationTokenRepository
+	refreshRepo     port.RefreshTokenRepository
 	jwtService      *jwt.JWTService
+	emails          sen.EmailService          // Emails reset and verification tokens to users; nil when email delivery is unavailable
+	notifier        sen.NotifyService         // Tells operators about password reset requests; may be nil
+	attempts        port.LoginAttemptTracker  // Counts failed logins over a sliding window; nil counts them in the database only
+	revocations     port.TokenRevocationStore // Invalidates issued access tokens; nil leaves them valid until they expire
+	cfg             config.AuthConfig
+	clock           clock.Clock
+
+	// Settings from cfg with the package defaults applied
+	accessTokenTTL    time.Duration
+	refreshTokenTTL   time.Duration
+	maxFailedAttempts int
+	accountLock       time.Duration
+	bcryptCost        int
+	passwordPolicy    validator.PasswordPolicy
 }
 
 // NewAuthService creates a new authentication service
 func NewAuthService(
 	userRepo port.UserRepository,
 	userProfileRepo port.UserProfileRepository,
+	resetTokenRepo port.PasswordResetTokenRepository,
+	verifyTokenRepo port.EmailVerificationTokenRepository,
+	refreshRepo port.RefreshTokenRepository,
 	jwtService *jwt.JWTService,
+	emails sen.EmailService,
+	notifier sen.NotifyService,
+	attempts port.LoginAttemptTracker,
+	revocations port.TokenRevocationStore,
+	cfg config.AuthConfig,
+	clk clock.Clock,
 ) port.AuthService {
+	bcryptCost := cfg.BcryptCost
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
 	return &AuthServiceImpl{
 		userRepo:        userRepo,
 		userProfileRepo: userProfileRepo,
+		resetTokenRepo:  resetTokenRepo,
+		verifyTokenRepo: verifyTokenRepo,
+		refreshRepo:     refreshRepo,
 		jwtService:      jwtService,
+		emails:          emails,
+		notifier:        notifier,
+		attempts:        attempts,
+		revocations:     revocations,
+		cfg:             cfg,
+		clock:           clk,
+
+		accessTokenTTL:    secondsOrDefault(cfg.AccessTokenTTLSeconds, AccessTokenDuration),
+		refreshTokenTTL:   secondsOrDefault(cfg.RefreshTokenTTLSeconds, RefreshTokenDuration),
+		maxFailedAttempts: intOrDefault(cfg.MaxFailedAttempts, MaxFailedAttempts),
+		accountLock:       secondsOrDefault(cfg.AccountLockSeconds, AccountLockDuration),
+		bcryptCost:        bcryptCost,
+		passwordPolicy: validator.PasswordPolicy{
+			MinLength:     cfg.PasswordPolicy.MinLength,
+			RequireUpper:  cfg.PasswordPolicy.RequireUpper,
+			RequireLower:  cfg.PasswordPolicy.RequireLower,
+			RequireDigit:  cfg.PasswordPolicy.RequireDigit,
+			RequireSymbol: cfg.PasswordPolicy.RequireSymbol,
+			AllowCommon:   cfg.PasswordPolicy.AllowCommon,
+		},
+	}
+}
+
+// secondsOrDefault returns seconds as a duration, or def when it is not positive
+func secondsOrDefault(seconds int, def time.Duration) time.Duration {
+	if seconds <= 0 {
+		return def
 	}
+	return time.Duration(seconds) * time.Second
+}
+
+// intOrDefault returns n, or def when it is not positive
+func intOrDefault(n, def int) int {
+	if n <= 0 {
+		return def
+	}
+	return n
 }
 
 // Register creates a new user account
@@ -53,13 +143,18 @@ func (s *AuthServiceImpl) Register(ctx context.Context, req *domain.RegisterRequ
 	if err == nil && existingUser != nil {
 		return nil, errors.NewConflictError("user with this email already exists")
 	}
+	if err := s.passwordPolicy.Check(req.Password); err != nil {
+		return nil, err
+	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.bcryptCost)
 	if err != nil {
 		return nil, errors.NewInternalServerError("failed to hash password")
 	}
 
+	now := s.clock.Now()
+
 	// Create user entity
 	user := &domain.User{
 		ID:            uuid.New(),
@@ -68,9 +163,10 @@ func (s *AuthServiceImpl) Register(ctx context.Context, req *domain.RegisterRequ
 		FirstName:     req.FirstName,
 		LastName:      req.LastName,
 		Status:        domain.UserStatusActive,
+		Role:          domain.UserRoleUser,
 		EmailVerified: false, // In production, require email verification
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		CreatedAt:     now,
+		UpdatedAt:     now,
 	}
 
 	// Save user to database
@@ -82,8 +178,8 @@ func (s *AuthServiceImpl) Register(ctx context.Context, req *domain.RegisterRequ
 	profile := &domain.UserProfile{
 		UserID:    user.ID,
 		Language:  "en", // Default language
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	if err := s.userProfileRepo.Create(ctx, profile); err != nil {
@@ -91,6 +187,11 @@ func (s *AuthServiceImpl) Register(ctx context.Context, req *domain.RegisterRequ
 		// In production, consider using transactions or saga pattern
 	}
 
+	// The account exists either way; a lost token can be requested again via resend-verification
+	if s.emails != nil {
+		_ = s.sendVerificationToken(ctx, user)
+	}
+
 	return user, nil
 }
 
@@ -102,10 +203,12 @@ func (s *AuthServiceImpl) Login(ctx context.Context, req *domain.LoginRequest) (
 		return nil, errors.NewUnauthorizedError("invalid credentials")
 	}
 
+	now := s.clock.Now()
+
 	// Check if account can login
-	if !user.CanLogin() {
-		if user.IsLocked() {
-			return nil, errors.NewUnauthorizedError("account is temporarily locked")
+	if !user.CanLogin(now) {
+		if user.IsLocked(now) {
+			return nil, accountLockedError(*user.LockedUntil)
 		}
 		return nil, errors.NewUnauthorizedError("account is not active")
 	}
@@ -115,33 +218,49 @@ func (s *AuthServiceImpl) Login(ctx context.Context, req *domain.LoginRequest) (
 		// Increment failed attempts
 		user.IncrementFailedAttempts()
 
-		// Lock account if max attempts reached
-		if user.FailedAttempts >= MaxFailedAttempts {
-			user.LockAccount(AccountLockDuration)
+		// Lock account if max attempts reached within the window; without a working
+		// tracker, fall back to the failures counted since the last successful login
+		failures := user.FailedAttempts
+		if s.attempts != nil {
+			if recent, err := s.attempts.RecordFailure(ctx, user.Email, now); err == nil {
+				failures = recent
+			}
+		}
+		if failures >= s.maxFailedAttempts {
+			user.LockAccount(now, s.accountLock)
 		}
 
 		// Update failed attempts in database
 		s.userRepo.UpdateFailedAttempts(ctx, user.ID, user.FailedAttempts)
-		if user.LockedUntil != nil {
+		if user.IsLocked(now) {
 			s.userRepo.LockUser(ctx, user.ID, user.LockedUntil)
+			return nil, accountLockedError(*user.LockedUntil)
 		}
 
 		return nil, errors.NewUnauthorizedError("invalid credentials")
 	}
 
+	// Checked only after the password so the verification state is not revealed to guessers
+	if s.cfg.RequireVerifiedEmail && !user.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
 	// Reset failed attempts on successful login
 	if user.FailedAttempts > 0 {
 		user.ResetFailedAttempts()
 		s.userRepo.UpdateFailedAttempts(ctx, user.ID, 0)
 		s.userRepo.LockUser(ctx, user.ID, nil)
 	}
+	if s.attempts != nil {
+		_ = s.attempts.Reset(ctx, user.Email)
+	}
 
 	// Update last login
-	user.UpdateLastLogin()
+	user.UpdateLastLogin(now)
 	s.userRepo.UpdateLastLogin(ctx, user.ID)
 
-	// Generate tokens
-	tokens, err := s.generateTokens(user)
+	// Generate tokens; each login starts a new refresh token family
+	tokens, err := s.generateTokens(ctx, user, uuid.New())
 	if err != nil {
 		return nil, errors.NewInternalServerError("failed to generate tokens")
 	}
@@ -153,44 +272,53 @@ func (s *AuthServiceImpl) Login(ctx context.Context, req *domain.LoginRequest) (
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(AccessTokenDuration.Seconds()),
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
 		User:         user,
 	}, nil
 }
 
-// RefreshToken generates new tokens using refresh token
+// accountLockedError reports a locked account together with when the lock ends
+func accountLockedError(lockedUntil time.Time) error {
+	return errors.NewError(http.StatusUnauthorized, "account_locked", "account is temporarily locked").
+		WithDetails(map[string]any{"locked_until": lockedUntil})
+}
+
+// RefreshToken rotates a refresh token: the presented token is used up and a new pair is
+// issued in the same family. Presenting a token that was already used means it leaked,
+// so the whole family is revoked and the legitimate holder has to log in again.
 func (s *AuthServiceImpl) RefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) (*domain.LoginResponse, error) {
-	// Validate refresh token
-	claims, err := s.jwtService.ValidateToken(ctx, req.RefreshToken)
+	stored, err := s.validateRefreshToken(ctx, req.RefreshToken)
 	if err != nil {
-		return nil, errors.NewUnauthorizedError("invalid refresh token")
+		return nil, err
+	}
+	if stored.Revoked {
+		return nil, errors.NewUnauthorizedError("refresh token has been revoked")
 	}
 
-	// Check if it's a refresh token
-	isRefreshToken := false
-	for _, aud := range claims.Audience {
-		if aud == "refresh" {
-			isRefreshToken = true
-			break
-		}
+	// Claim the token; failing to do so means it was used before, by us or by a thief
+	unused, err := s.refreshRepo.MarkUsed(ctx, stored.ID)
+	if err != nil {
+		return nil, errors.NewInternalServerError("failed to rotate refresh token")
 	}
-	if !isRefreshToken {
-		return nil, errors.NewUnauthorizedError("invalid token type")
+	if !unused {
+		if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+			return nil, errors.NewInternalServerError("failed to revoke refresh tokens")
+		}
+		return nil, errors.NewUnauthorizedError("refresh token reuse detected")
 	}
 
 	// Get user
-	userID := uuid.MustParse(claims.Subject)
-	user, err := s.userRepo.GetByID(ctx, userID)
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
 	if err != nil {
 		return nil, errors.NewUnauthorizedError("user not found")
 	}
 
-	if !user.CanLogin() {
+	if !user.CanLogin(s.clock.Now()) {
 		return nil, errors.NewUnauthorizedError("account not active")
 	}
 
 	// Generate new tokens
-	tokens, err := s.generateTokens(user)
+	tokens, err := s.generateTokens(ctx, user, stored.FamilyID)
 	if err != nil {
 		return nil, errors.NewInternalServerError("failed to generate tokens")
 	}
@@ -202,11 +330,62 @@ func (s *AuthServiceImpl) RefreshToken(ctx context.Context, req *domain.RefreshT
 		AccessToken:  tokens.AccessToken,
 		RefreshToken: tokens.RefreshToken,
 		TokenType:    "Bearer",
-		ExpiresIn:    int64(AccessTokenDuration.Seconds()),
+		ExpiresIn:    int64(s.accessTokenTTL.Seconds()),
 		User:         user,
 	}, nil
 }
 
+// RevokeRefreshToken revokes the family of the presented refresh token, ending the
+// session it belongs to on every device that shares it
+func (s *AuthServiceImpl) RevokeRefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) error {
+	stored, err := s.validateRefreshToken(ctx, req.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	if err := s.refreshRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return errors.NewInternalServerError("failed to revoke refresh tokens")
+	}
+	return nil
+}
+
+// validateRefreshToken checks the signature and audience of a refresh token and returns
+// its stored record. Tokens without a record were never issued by the rotating scheme.
+func (s *AuthServiceImpl) validateRefreshToken(ctx context.Context, tokenString string) (*domain.RefreshToken, error) {
+	claims, err := s.jwtService.ValidateToken(ctx, tokenString)
+	if err != nil {
+		return nil, errors.NewUnauthorizedError("invalid refresh token")
+	}
+
+	// Check if it's a refresh token
+	isRefreshToken := false
+	for _, aud := range claims.Audience {
+		if aud == "refresh" {
+			isRefreshToken = true
+			break
+		}
+	}
+	if !isRefreshToken {
+		return nil, errors.NewUnauthorizedError("invalid token type")
+	}
+
+	jti, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, errors.NewUnauthorizedError("invalid refresh token")
+	}
+	stored, err := s.refreshRepo.GetByID(ctx, jti)
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return nil, errors.NewUnauthorizedError("invalid refresh token")
+		}
+		return nil, errors.NewInternalServerError("failed to load refresh token")
+	}
+	if claims.Subject != stored.UserID.String() {
+		return nil, errors.NewUnauthorizedError("invalid refresh token")
+	}
+	return stored, nil
+}
+
 // ChangePassword changes user's password
 func (s *AuthServiceImpl) ChangePassword(ctx context.Context, userID uuid.UUID, req *domain.ChangePasswordRequest) error {
 	// Get user
@@ -219,42 +398,320 @@ func (s *AuthServiceImpl) ChangePassword(ctx context.Context, userID uuid.UUID,
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.CurrentPassword)); err != nil {
 		return errors.NewUnauthorizedError("current password is incorrect")
 	}
+	if err := s.passwordPolicy.Check(req.NewPassword); err != nil {
+		return err
+	}
 
 	// Hash new password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), s.bcryptCost)
 	if err != nil {
 		return errors.NewInternalServerError("failed to hash password")
 	}
 
 	// Update user password
 	user.PasswordHash = string(hashedPassword)
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = s.clock.Now()
 
 	return s.userRepo.Update(ctx, user)
 }
 
-// ForgotPassword initiates password reset process
+// VerifyPassword checks the user's current password, for actions that need the user to
+// authenticate again
+func (s *AuthServiceImpl) VerifyPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.NewNotFoundError("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return errors.NewUnauthorizedError("password is incorrect")
+	}
+	return nil
+}
+
+// RevokeSessions signs the user out everywhere: every refresh token is revoked and the
+// access tokens issued so far are rejected
+func (s *AuthServiceImpl) RevokeSessions(ctx context.Context, userID uuid.UUID) error {
+	if err := s.refreshRepo.RevokeUser(ctx, userID); err != nil {
+		return err
+	}
+	if s.revocations == nil {
+		return nil
+	}
+	return s.revocations.RevokeUserTokens(ctx, userID, s.clock.Now())
+}
+
+// DeleteAccount signs the user out everywhere, then removes their profile and soft
+// deletes the user. Sessions go first so a failure leaves no signed in deleted user.
+func (s *AuthServiceImpl) DeleteAccount(ctx context.Context, userID uuid.UUID) error {
+	if err := s.RevokeSessions(ctx, userID); err != nil {
+		return err
+	}
+	if err := s.userProfileRepo.Delete(ctx, userID); err != nil {
+		return err
+	}
+	return s.userRepo.Delete(ctx, userID)
+}
+
+// ForgotPassword issues a single-use password reset token and emails a link with it to
+// the account's address. Operators are told a reset was requested, but never see the
+// token. Unknown emails succeed silently so callers cannot probe for accounts.
 func (s *AuthServiceImpl) ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest) error {
-	// In production, you would:
-	// 1. Generate a secure reset token
-	// 2. Store it in database with expiration
-	// 3. Send email with reset link
+	if s.emails == nil {
+		return errors.NewInternalServerError("password reset delivery is not configured")
+	}
 
-	// For now, just check if user exists
-	_, err := s.userRepo.GetByEmail(ctx, req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		// Don't reveal if email exists or not for security
 		return nil
 	}
 
-	// TODO: Implement email service integration
+	token, err := generateOneTimeToken()
+	if err != nil {
+		return errors.NewInternalServerError("failed to generate reset token")
+	}
+
+	now := s.clock.Now()
+	resetToken := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashOneTimeToken(token),
+		ExpiresAt: now.Add(PasswordResetTokenDuration),
+		CreatedAt: now,
+	}
+	if err := s.resetTokenRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	if err := s.emails.SendPasswordReset(ctx, user.Email, s.passwordResetLink(token)); err != nil {
+		return errors.WrapError(err, 500, "failed to send password reset email")
+	}
+
+	if s.notifier != nil {
+		message := fmt.Sprintf("Password reset requested for user %s; the emailed token expires at %s",
+			user.ID, resetToken.ExpiresAt.Format(time.RFC3339))
+		_ = s.notifier.Info(ctx, "Password reset requested", message)
+	}
+
 	return nil
 }
 
-// ResetPassword resets password using reset token
+// passwordResetLink returns the link emailed for a reset token: auth.passwordResetUrl
+// with the token as its token query parameter
+func (s *AuthServiceImpl) passwordResetLink(token string) string {
+	separator := "?"
+	if strings.Contains(s.cfg.PasswordResetURL, "?") {
+		separator = "&"
+	}
+	return s.cfg.PasswordResetURL + separator + "token=" + url.QueryEscape(token)
+}
+
+// ResetPassword sets a new password using a token issued by ForgotPassword. Each token
+// works once and only until it expires.
 func (s *AuthServiceImpl) ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) error {
-	// TODO: Implement password reset with token validation
-	return errors.NewNotImplementedError("password reset not implemented")
+	resetToken, err := s.resetTokenRepo.GetByTokenHash(ctx, hashOneTimeToken(req.Token))
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return errors.NewBadRequestError("invalid or expired reset token")
+		}
+		return err
+	}
+
+	if resetToken.Used || resetToken.IsExpired(s.clock.Now()) {
+		return errors.NewBadRequestError("invalid or expired reset token")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, resetToken.UserID)
+	if err != nil {
+		return errors.NewBadRequestError("invalid or expired reset token")
+	}
+	if err := s.passwordPolicy.Check(req.NewPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), s.bcryptCost)
+	if err != nil {
+		return errors.NewInternalServerError("failed to hash password")
+	}
+
+	// Claim the token before changing the password so a concurrent reset with the same token fails
+	unused, err := s.resetTokenRepo.MarkUsed(ctx, resetToken.ID)
+	if err != nil {
+		return err
+	}
+	if !unused {
+		return errors.NewBadRequestError("invalid or expired reset token")
+	}
+
+	// Proving ownership of the account also clears a lockout from failed logins
+	user.PasswordHash = string(hashedPassword)
+	user.ResetFailedAttempts()
+	user.UpdatedAt = s.clock.Now()
+
+	return s.userRepo.Update(ctx, user)
+}
+
+// SendVerificationEmail issues a new email verification token and emails it to the
+// account's address. Unknown and already verified emails succeed silently.
+func (s *AuthServiceImpl) SendVerificationEmail(ctx context.Context, req *domain.ResendVerificationRequest) error {
+	if s.emails == nil {
+		return errors.NewInternalServerError("email verification delivery is not configured")
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil || user.EmailVerified {
+		// Don't reveal if email exists or not for security
+		return nil
+	}
+
+	return s.sendVerificationToken(ctx, user)
+}
+
+// sendVerificationToken stores a new verification token for user and delivers it
+func (s *AuthServiceImpl) sendVerificationToken(ctx context.Context, user *domain.User) error {
+	return s.sendEmailToken(ctx, user, "")
+}
+
+// ChangeEmail sends a verification token to the new email, after telling the current
+// email about the change so its owner notices one they did not ask for. The current
+// email keeps working for login until the token is verified, so a mistyped address
+// cannot lock the user out.
+func (s *AuthServiceImpl) ChangeEmail(ctx context.Context, userID uuid.UUID, req *domain.ChangeEmailRequest) error {
+	if s.emails == nil {
+		return errors.NewInternalServerError("email verification delivery is not configured")
+	}
+
+	if err := s.VerifyPassword(ctx, userID, req.Password); err != nil {
+		return err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return errors.NewNotFoundError("user not found")
+	}
+	if req.NewEmail == user.Email {
+		return errors.NewBadRequestError("new email is the current email")
+	}
+	if err := s.checkEmailAvailable(ctx, req.NewEmail); err != nil {
+		return err
+	}
+
+	notice := dto.Email{
+		To:      []string{user.Email},
+		Subject: "Login email change requested",
+		Body: fmt.Sprintf("A change of your login email to %s was requested. It takes effect once the new address is verified. "+
+			"If you did not ask for it, change your password to stop it.", req.NewEmail),
+	}
+	if err := s.emails.SendEmail(ctx, notice); err != nil {
+		return errors.WrapError(err, 500, "failed to send email change notice")
+	}
+
+	return s.sendEmailToken(ctx, user, req.NewEmail)
+}
+
+// checkEmailAvailable returns ErrEmailTaken when another user has the email
+func (s *AuthServiceImpl) checkEmailAvailable(ctx context.Context, email string) error {
+	taken, err := s.userRepo.EmailInUse(ctx, email)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return errors.ErrEmailTaken
+	}
+	return nil
+}
+
+// sendEmailToken stores a new verification token for user and emails it to the address
+// it verifies: user's email, or newEmail when the token confirms a change to it.
+func (s *AuthServiceImpl) sendEmailToken(ctx context.Context, user *domain.User, newEmail string) error {
+	token, err := generateOneTimeToken()
+	if err != nil {
+		return errors.NewInternalServerError("failed to generate verification token")
+	}
+
+	now := s.clock.Now()
+	verifyToken := &domain.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashOneTimeToken(token),
+		NewEmail:  newEmail,
+		ExpiresAt: now.Add(EmailVerificationTokenDuration),
+		CreatedAt: now,
+	}
+	if err := s.verifyTokenRepo.Create(ctx, verifyToken); err != nil {
+		return err
+	}
+
+	to := user.Email
+	if newEmail != "" {
+		to = newEmail
+	}
+	if err := s.emails.SendVerificationCode(ctx, to, token); err != nil {
+		return errors.WrapError(err, 500, "failed to send verification email")
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email of the token's user as verified. A token issued by
+// ChangeEmail first makes its new email the user's login email; the address counts as
+// verified since the token was delivered there. Each token works once and only until
+// it expires.
+func (s *AuthServiceImpl) VerifyEmail(ctx context.Context, req *domain.VerifyEmailRequest) error {
+	verifyToken, err := s.verifyTokenRepo.GetByTokenHash(ctx, hashOneTimeToken(req.Token))
+	if err != nil {
+		if errors.IsNotFoundError(err) {
+			return errors.NewBadRequestError("invalid or expired verification token")
+		}
+		return err
+	}
+
+	if verifyToken.Used || verifyToken.IsExpired(s.clock.Now()) {
+		return errors.NewBadRequestError("invalid or expired verification token")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, verifyToken.UserID)
+	if err != nil {
+		return errors.NewBadRequestError("invalid or expired verification token")
+	}
+
+	// The address may have been taken since the change was requested
+	if verifyToken.NewEmail != "" && verifyToken.NewEmail != user.Email {
+		if err := s.checkEmailAvailable(ctx, verifyToken.NewEmail); err != nil {
+			return err
+		}
+	}
+
+	unused, err := s.verifyTokenRepo.MarkUsed(ctx, verifyToken.ID)
+	if err != nil {
+		return err
+	}
+	if !unused {
+		return errors.NewBadRequestError("invalid or expired verification token")
+	}
+
+	if verifyToken.NewEmail != "" {
+		user.Email = verifyToken.NewEmail
+	}
+	user.EmailVerified = true
+	user.UpdatedAt = s.clock.Now()
+
+	return s.userRepo.Update(ctx, user)
+}
+
+// generateOneTimeToken returns a URL-safe token with 256 bits of randomness for reset and verification links
+func generateOneTimeToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashOneTimeToken returns the hex SHA-256 of a one-time token, the form it is stored and looked up in
+func hashOneTimeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // GetProfile retrieves user profile
@@ -283,6 +740,8 @@ func (s *AuthServiceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, r
 		return errors.NewNotFoundError("user not found")
 	}
 
+	now := s.clock.Now()
+
 	// Update user basic info
 	if req.FirstName != "" {
 		user.FirstName = req.FirstName
@@ -290,7 +749,7 @@ func (s *AuthServiceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, r
 	if req.LastName != "" {
 		user.LastName = req.LastName
 	}
-	user.UpdatedAt = time.Now()
+	user.UpdatedAt = now
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return errors.WrapError(err, 500, "failed to update user")
@@ -299,10 +758,9 @@ func (s *AuthServiceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, r
 	// Get or create profile
 	profile, err := s.userProfileRepo.GetByUserID(ctx, userID)
 	if err != nil {
-		// Create new profile
+		// Create new profile; it is stored with Create below
 		profile = &domain.UserProfile{
-			UserID:    userID,
-			CreatedAt: time.Now(),
+			UserID: userID,
 		}
 	}
 
@@ -316,16 +774,72 @@ func (s *AuthServiceImpl) UpdateProfile(ctx context.Context, userID uuid.UUID, r
 	if req.Language != "" {
 		profile.Language = req.Language
 	}
-	profile.UpdatedAt = time.Now()
+	profile.UpdatedAt = now
 
 	if profile.CreatedAt.IsZero() {
-		profile.CreatedAt = time.Now()
+		profile.CreatedAt = now
 		return s.userProfileRepo.Create(ctx, profile)
 	} else {
 		return s.userProfileRepo.Update(ctx, profile)
 	}
 }
 
+// SetAvatar makes an uploaded image the user's avatar, creating the profile if needed
+func (s *AuthServiceImpl) SetAvatar(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, url string) (*domain.UserProfile, *uuid.UUID, error) {
+	now := s.clock.Now()
+
+	profile, err := s.userProfileRepo.GetByUserID(ctx, userID)
+	if errors.IsNotFoundError(err) {
+		profile = &domain.UserProfile{UserID: userID}
+	} else if err != nil {
+		return nil, nil, err
+	}
+	previous := profile.AvatarMediaID
+
+	profile.Avatar = url
+	profile.AvatarMediaID = &mediaID
+	profile.UpdatedAt = now
+
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = now
+		err = s.userProfileRepo.Create(ctx, profile)
+	} else {
+		err = s.userProfileRepo.Update(ctx, profile)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return profile, previous, nil
+}
+
+// GetAccountStatus returns the user's failed login attempts and lock state
+func (s *AuthServiceImpl) GetAccountStatus(ctx context.Context, userID uuid.UUID) (*domain.AccountStatusResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, errors.NewNotFoundError("user not found")
+	}
+
+	now := s.clock.Now()
+	status := &domain.AccountStatusResponse{
+		FailedAttempts:       user.FailedAttempts,
+		RecentFailedAttempts: user.FailedAttempts,
+		MaxFailedAttempts:    s.maxFailedAttempts,
+		Locked:               user.IsLocked(now),
+	}
+	if status.Locked {
+		status.LockedUntil = user.LockedUntil
+	}
+	if s.attempts != nil {
+		recent, err := s.attempts.Failures(ctx, user.Email, now)
+		if err != nil {
+			return nil, errors.WrapError(err, 500, "failed to load failed login attempts")
+		}
+		status.RecentFailedAttempts = recent
+	}
+
+	return status, nil
+}
+
 // ValidateToken validates JWT token and returns claims
 func (s *AuthServiceImpl) ValidateToken(ctx context.Context, tokenString string) (*jwt.JWTClaims, error) {
 	return s.jwtService.ValidateToken(ctx, tokenString)
@@ -337,18 +851,20 @@ type TokenPair struct {
 	RefreshToken string
 }
 
-// generateTokens creates access and refresh tokens for a user
-func (s *AuthServiceImpl) generateTokens(user *domain.User) (*TokenPair, error) {
-	now := time.Now()
+// generateTokens creates access and refresh tokens for a user and records the refresh
+// token in familyID
+func (s *AuthServiceImpl) generateTokens(ctx context.Context, user *domain.User, familyID uuid.UUID) (*TokenPair, error) {
+	now := s.clock.Now()
 
 	// Create access token claims
 	accessClaims := &jwt.JWTClaims{
 		Email: user.Email,
+		Roles: []string{string(user.Role)},
 		RegisteredClaims: jwtLib.RegisteredClaims{
 			Subject:   user.ID.String(),
 			Issuer:    "m3-storage",
 			Audience:  []string{"access"},
-			ExpiresAt: jwtLib.NewNumericDate(now.Add(AccessTokenDuration)),
+			ExpiresAt: jwtLib.NewNumericDate(now.Add(s.accessTokenTTL)),
 			NotBefore: jwtLib.NewNumericDate(now),
 			IssuedAt:  jwtLib.NewNumericDate(now),
 			ID:        s.jwtService.GenerateJTI().String(),
@@ -356,16 +872,17 @@ func (s *AuthServiceImpl) generateTokens(user *domain.User) (*TokenPair, error)
 	}
 
 	// Create refresh token claims
+	refreshJTI := s.jwtService.GenerateJTI()
 	refreshClaims := &jwt.JWTClaims{
 		Email: user.Email,
 		RegisteredClaims: jwtLib.RegisteredClaims{
 			Subject:   user.ID.String(),
 			Issuer:    "m3-storage",
 			Audience:  []string{"refresh"},
-			ExpiresAt: jwtLib.NewNumericDate(now.Add(RefreshTokenDuration)),
+			ExpiresAt: jwtLib.NewNumericDate(now.Add(s.refreshTokenTTL)),
 			NotBefore: jwtLib.NewNumericDate(now),
 			IssuedAt:  jwtLib.NewNumericDate(now),
-			ID:        s.jwtService.GenerateJTI().String(),
+			ID:        refreshJTI.String(),
 		},
 	}
 
@@ -380,6 +897,16 @@ func (s *AuthServiceImpl) generateTokens(user *domain.User) (*TokenPair, error)
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	if err := s.refreshRepo.Create(ctx, &domain.RefreshToken{
+		ID:        refreshJTI,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ExpiresAt: refreshClaims.ExpiresAt.Time,
+		CreatedAt: now,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to record refresh token: %w", err)
+	}
+
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginLockoutExpires(t *testing.T) {
+	ctx := context.Background()
+	ta := newTestAuth(t, config.AuthConfig{MaxFailedAttempts: 3, AccountLockSeconds: 600})
+	user := ta.addUser(t, "alice@example.com", "correct-password")
+
+	for i := 0; i < 3; i++ {
+		if _, err := ta.svc.Login(ctx, &domain.LoginRequest{Email: user.Email, Password: "wrong-password"}); err == nil {
+			t.Fatalf("login %d with a wrong password succeeded", i+1)
+		}
+	}
+	if !ta.users.get(user.ID).IsLocked(ta.clock.Now()) {
+		t.Fatal("account is not locked after reaching the failed attempt limit")
+	}
+
+	// The right password does not help while the lock lasts
+	ta.clock.Advance(599 * time.Second)
+	_, err := ta.svc.Login(ctx, &domain.LoginRequest{Email: user.Email, Password: "correct-password"})
+	if appErr, ok := errors.As(err); !ok || appErr.Code != "account_locked" {
+		t.Fatalf("login during lock: got %v, want account_locked", err)
+	}
+
+	ta.clock.Advance(time.Second)
+	if _, err := ta.svc.Login(ctx, &domain.LoginRequest{Email: user.Email, Password: "correct-password"}); err != nil {
+		t.Fatalf("login after lock expired: %v", err)
+	}
+	if attempts := ta.users.get(user.ID).FailedAttempts; attempts != 0 {
+		t.Errorf("failed attempts after successful login = %d, want 0", attempts)
+	}
+}
+
+func TestForgotPasswordEmailsTokenToUser(t *testing.T) {
+	ctx := context.Background()
+	ta := newTestAuth(t, config.AuthConfig{})
+	user := ta.addUser(t, "alice@example.com", "correct-password")
+
+	if err := ta.svc.ForgotPassword(ctx, &domain.ForgotPasswordRequest{Email: user.Email}); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+
+	email := ta.emails.last(t)
+	if email.to != user.Email {
+		t.Errorf("reset email sent to %q, want %q", email.to, user.Email)
+	}
+	if !strings.HasPrefix(email.link, "https://app.example.com/reset-password?token=") {
+		t.Errorf("reset link = %q, want it on auth.passwordResetUrl", email.link)
+	}
+
+	// Operators hear about the request, but the token stays with the user
+	token := ta.emails.resetToken(t)
+	if len(ta.notifier.messages) != 1 {
+		t.Fatalf("notifications = %q, want one", ta.notifier.messages)
+	}
+	if message := ta.notifier.messages[0]; strings.Contains(message, token) || !strings.Contains(message, user.ID.String()) {
+		t.Errorf("notification = %q, want the user ID and no token", message)
+	}
+}
+
+func TestResetPasswordToken(t *testing.T) {
+	const newPassword = "N3w-Passw0rd!"
+
+	tests := []struct {
+		name string
+		// use runs between issuing the token and resetting the password with it
+		use     func(t *testing.T, ta *testAuth, token string)
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			use: func(t *testing.T, ta *testAuth, token string) {
+				ta.clock.Advance(PasswordResetTokenDuration - time.Second)
+			},
+		},
+		{
+			name: "expired",
+			use: func(t *testing.T, ta *testAuth, token string) {
+				ta.clock.Advance(PasswordResetTokenDuration + time.Second)
+			},
+			wantErr: true,
+		},
+		{
+			name: "reused",
+			use: func(t *testing.T, ta *testAuth, token string) {
+				req := &domain.ResetPasswordRequest{Token: token, NewPassword: "Earl1er-Passw0rd!"}
+				if err := ta.svc.ResetPassword(context.Background(), req); err != nil {
+					t.Fatalf("first reset: %v", err)
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			ta := newTestAuth(t, config.AuthConfig{})
+			user := ta.addUser(t, "alice@example.com", "correct-password")
+			if err := ta.svc.ForgotPassword(ctx, &domain.ForgotPasswordRequest{Email: user.Email}); err != nil {
+				t.Fatalf("ForgotPassword: %v", err)
+			}
+			token := ta.emails.resetToken(t)
+			tt.use(t, ta, token)
+			before := ta.users.get(user.ID).PasswordHash
+
+			err := ta.svc.ResetPassword(ctx, &domain.ResetPasswordRequest{Token: token, NewPassword: newPassword})
+			after := ta.users.get(user.ID).PasswordHash
+
+			if tt.wantErr {
+				if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusBadRequest {
+					t.Fatalf("ResetPassword error = %v, want 400", err)
+				}
+				if after != before {
+					t.Error("password changed by a rejected token")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResetPassword: %v", err)
+			}
+			if bcrypt.CompareHashAndPassword([]byte(after), []byte(newPassword)) != nil {
+				t.Error("password was not changed to the new one")
+			}
+		})
+	}
+}
+
+func TestSendVerificationEmailEmailsTokenToUser(t *testing.T) {
+	ctx := context.Background()
+	ta := newTestAuth(t, config.AuthConfig{})
+	user := ta.addUser(t, "alice@example.com", "correct-password")
+
+	if err := ta.svc.SendVerificationEmail(ctx, &domain.ResendVerificationRequest{Email: user.Email}); err != nil {
+		t.Fatalf("SendVerificationEmail: %v", err)
+	}
+
+	email := ta.emails.last(t)
+	if email.to != user.Email || email.code == "" {
+		t.Fatalf("verification email = %+v, want a token sent to %q", email, user.Email)
+	}
+	for _, message := range ta.notifier.messages {
+		if strings.Contains(message, email.code) {
+			t.Errorf("notification %q contains the verification token", message)
+		}
+	}
+
+	if err := ta.svc.VerifyEmail(ctx, &domain.VerifyEmailRequest{Token: email.code}); err != nil {
+		t.Fatalf("VerifyEmail with the emailed token: %v", err)
+	}
+	if !ta.users.get(user.ID).EmailVerified {
+		t.Error("email is not verified")
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	ctx := context.Background()
+	ta := newTestAuth(t, config.AuthConfig{})
+	user := ta.addUser(t, "alice@example.com", "correct-password")
+
+	req := &domain.ChangeEmailRequest{NewEmail: "alice@example.org", Password: "correct-password"}
+	if err := ta.svc.ChangeEmail(ctx, user.ID, req); err != nil {
+		t.Fatalf("ChangeEmail: %v", err)
+	}
+
+	// The old address hears about the change, the new one gets the token
+	if len(ta.emails.sent) != 2 {
+		t.Fatalf("sent %d emails, want a notice and a verification email", len(ta.emails.sent))
+	}
+	notice, verification := ta.emails.sent[0], ta.emails.sent[1]
+	if notice.to != user.Email || notice.subject == "" {
+		t.Errorf("change notice = %+v, want it sent to %q", notice, user.Email)
+	}
+	if verification.to != req.NewEmail || verification.code == "" {
+		t.Errorf("verification email = %+v, want a token sent to %q", verification, req.NewEmail)
+	}
+	if email := ta.users.get(user.ID).Email; email != user.Email {
+		t.Errorf("login email before verification = %q, want %q", email, user.Email)
+	}
+
+	if err := ta.svc.VerifyEmail(ctx, &domain.VerifyEmailRequest{Token: verification.code}); err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if changed := ta.users.get(user.ID); changed.Email != req.NewEmail || !changed.EmailVerified {
+		t.Errorf("user after verification = %q verified %v, want %q verified", changed.Email, changed.EmailVerified, req.NewEmail)
+	}
+}
+
+func TestSetAvatar(t *testing.T) {
+	ctx := context.Background()
+	ta := newTestAuth(t, config.AuthConfig{})
+	user := ta.addUser(t, "alice@example.com", "correct-password")
+
+	// The first avatar creates the profile and replaces nothing
+	first, second := uuid.New(), uuid.New()
+	profile, previous, err := ta.svc.SetAvatar(ctx, user.ID, first, "https://cdn.example.com/first.png")
+	if err != nil {
+		t.Fatalf("SetAvatar: %v", err)
+	}
+	if previous != nil || profile.AvatarMediaID == nil || *profile.AvatarMediaID != first {
+		t.Fatalf("first avatar = %v replacing %v, want %s replacing nothing", profile.AvatarMediaID, previous, first)
+	}
+
+	_, previous, err = ta.svc.SetAvatar(ctx, user.ID, second, "https://cdn.example.com/second.png")
+	if err != nil {
+		t.Fatalf("SetAvatar: %v", err)
+	}
+	if previous == nil || *previous != first {
+		t.Errorf("replaced avatar = %v, want %s", previous, first)
+	}
+
+	// A failed lookup is not a missing profile, so the stored avatar is kept
+	ta.profiles.getErr = errors.WrapError(context.DeadlineExceeded, http.StatusInternalServerError, "failed to get user profile")
+	if _, _, err := ta.svc.SetAvatar(ctx, user.ID, uuid.New(), "https://cdn.example.com/third.png"); err == nil {
+		t.Fatal("SetAvatar succeeded although the profile could not be read")
+	}
+	ta.profiles.getErr = nil
+	if stored, _ := ta.profiles.GetByUserID(ctx, user.ID); *stored.AvatarMediaID != second {
+		t.Errorf("stored avatar = %s, want %s", stored.AvatarMediaID, second)
+	}
+}
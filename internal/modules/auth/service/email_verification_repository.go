@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lugondev/m3-storage/internal/infra/database"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailVerificationTokenRepositoryImpl implements the EmailVerificationTokenRepository interface
+type EmailVerificationTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewEmailVerificationTokenRepository creates a new email verification token repository
+func NewEmailVerificationTokenRepository(db *gorm.DB) port.EmailVerificationTokenRepository {
+	return &EmailVerificationTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new email verification token
+func (r *EmailVerificationTokenRepositoryImpl) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	dbToken := &database.EmailVerificationToken{
+		Base: database.Base{
+			ID:        token.ID,
+			CreatedAt: token.CreatedAt,
+		},
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		NewEmail:  token.NewEmail,
+		ExpiresAt: token.ExpiresAt,
+		Used:      token.Used,
+	}
+
+	if err := r.db.WithContext(ctx).Create(dbToken).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to create email verification token")
+	}
+
+	token.ID = dbToken.ID
+	token.CreatedAt = dbToken.CreatedAt
+	return nil
+}
+
+// GetByTokenHash retrieves a email verification token by the hash of its value
+func (r *EmailVerificationTokenRepositoryImpl) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	var dbToken database.EmailVerificationToken
+
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&dbToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("email verification token not found")
+		}
+		return nil, errors.WrapError(err, 500, "failed to get email verification token")
+	}
+
+	return &domain.EmailVerificationToken{
+		ID:        dbToken.ID,
+		UserID:    dbToken.UserID,
+		TokenHash: dbToken.TokenHash,
+		NewEmail:  dbToken.NewEmail,
+		ExpiresAt: dbToken.ExpiresAt,
+		Used:      dbToken.Used,
+		CreatedAt: dbToken.CreatedAt,
+	}, nil
+}
+
+// MarkUsed marks an unused token as used and reports whether it was unused
+func (r *EmailVerificationTokenRepositoryImpl) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&database.EmailVerificationToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+	if result.Error != nil {
+		return false, errors.WrapError(result.Error, 500, "failed to mark email verification token used")
+	}
+
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/infra/jwt"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	sen "github.com/lugondev/send-sen"
+	"github.com/lugondev/send-sen/dto"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testNow is a Monday afternoon, far enough from midnight for the quota day tests
+var testNow = time.Date(2026, 3, 2, 15, 0, 0, 0, time.UTC)
+
+// memUserRepo keeps users in memory. Methods the tests do not use are left to the
+// embedded interface and panic when called.
+type memUserRepo struct {
+	port.UserRepository
+
+	mu    sync.Mutex
+	users map[uuid.UUID]*domain.User
+}
+
+func newMemUserRepo() *memUserRepo {
+	return &memUserRepo{users: map[uuid.UUID]*domain.User{}}
+}
+
+// add stores a copy of user
+func (r *memUserRepo) add(user *domain.User) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *user
+	r.users[user.ID] = &stored
+}
+
+// get returns a copy of the stored user, so tests see what the service persisted
+func (r *memUserRepo) get(id uuid.UUID) *domain.User {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := *r.users[id]
+	return &user
+}
+
+func (r *memUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.NewNotFoundError("user not found")
+	}
+	found := *user
+	return &found, nil
+}
+
+func (r *memUserRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("user not found")
+}
+
+func (r *memUserRepo) EmailInUse(ctx context.Context, email string) (bool, error) {
+	_, err := r.GetByEmail(ctx, email)
+	return err == nil, nil
+}
+
+func (r *memUserRepo) Update(ctx context.Context, user *domain.User) error {
+	r.add(user)
+	return nil
+}
+
+func (r *memUserRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func (r *memUserRepo) UpdateFailedAttempts(ctx context.Context, id uuid.UUID, attempts int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[id].FailedAttempts = attempts
+	return nil
+}
+
+func (r *memUserRepo) LockUser(ctx context.Context, id uuid.UUID, lockedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[id].LockedUntil = lockedUntil
+	return nil
+}
+
+func (r *memUserRepo) RecordUpload(ctx context.Context, id uuid.UUID, size int64, day time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[id]
+	user.UsedStorageBytes += size
+	if user.DailyCountDate != nil && user.DailyCountDate.Equal(day) {
+		user.DailyFileCount++
+	} else {
+		user.DailyFileCount = 1
+	}
+	user.DailyCountDate = &day
+	return nil
+}
+
+func (r *memUserRepo) ReserveStorage(ctx context.Context, id uuid.UUID, size int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[id]
+	if user.MaxStorageBytes > 0 && user.UsedStorageBytes+size > user.MaxStorageBytes {
+		return false, nil
+	}
+	user.UsedStorageBytes += size
+	return true, nil
+}
+
+func (r *memUserRepo) SetRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[id].Role = role
+	return nil
+}
+
+func (r *memUserRepo) ReleaseStorage(ctx context.Context, id uuid.UUID, size int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user := r.users[id]
+	user.UsedStorageBytes = max(user.UsedStorageBytes-size, 0)
+	return nil
+}
+
+// memProfileRepo keeps user profiles in memory. getErr, when set, is returned by
+// GetByUserID in place of a lookup.
+type memProfileRepo struct {
+	port.UserProfileRepository
+
+	mu       sync.Mutex
+	profiles map[uuid.UUID]*domain.UserProfile
+	getErr   error
+}
+
+func newMemProfileRepo() *memProfileRepo {
+	return &memProfileRepo{profiles: map[uuid.UUID]*domain.UserProfile{}}
+}
+
+func (r *memProfileRepo) Create(ctx context.Context, profile *domain.UserProfile) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *profile
+	r.profiles[profile.UserID] = &stored
+	return nil
+}
+
+func (r *memProfileRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*domain.UserProfile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	profile, ok := r.profiles[userID]
+	if !ok {
+		return nil, errors.NewNotFoundError("user profile not found")
+	}
+	found := *profile
+	return &found, nil
+}
+
+func (r *memProfileRepo) Update(ctx context.Context, profile *domain.UserProfile) error {
+	return r.Create(ctx, profile)
+}
+
+// memResetTokenRepo keeps password reset tokens in memory
+type memResetTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*domain.PasswordResetToken
+}
+
+func newMemResetTokenRepo() *memResetTokenRepo {
+	return &memResetTokenRepo{tokens: map[uuid.UUID]*domain.PasswordResetToken{}}
+}
+
+func (r *memResetTokenRepo) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *memResetTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("password reset token not found")
+}
+
+func (r *memResetTokenRepo) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.Used {
+		return false, nil
+	}
+	token.Used = true
+	return true, nil
+}
+
+// memVerifyTokenRepo keeps email verification tokens in memory
+type memVerifyTokenRepo struct {
+	mu     sync.Mutex
+	tokens map[uuid.UUID]*domain.EmailVerificationToken
+}
+
+func newMemVerifyTokenRepo() *memVerifyTokenRepo {
+	return &memVerifyTokenRepo{tokens: map[uuid.UUID]*domain.EmailVerificationToken{}}
+}
+
+func (r *memVerifyTokenRepo) Create(ctx context.Context, token *domain.EmailVerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *token
+	r.tokens[token.ID] = &stored
+	return nil
+}
+
+func (r *memVerifyTokenRepo) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, errors.NewNotFoundError("email verification token not found")
+}
+
+func (r *memVerifyTokenRepo) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[id]
+	if !ok || token.Used {
+		return false, nil
+	}
+	token.Used = true
+	return true, nil
+}
+
+// memRefreshRepo accepts issued refresh tokens; the tests do not rotate them
+type memRefreshRepo struct {
+	port.RefreshTokenRepository
+}
+
+func (memRefreshRepo) Create(ctx context.Context, token *domain.RefreshToken) error {
+	return nil
+}
+
+// sentEmail is an email captured by fakeEmails
+type sentEmail struct {
+	to      string
+	subject string // Subject of an email sent with SendEmail
+	link    string // Link of a password reset email
+	code    string // Token of a verification email
+}
+
+// fakeEmails captures emails instead of sending them
+type fakeEmails struct {
+	sen.EmailService
+
+	mu   sync.Mutex
+	sent []sentEmail
+}
+
+func (e *fakeEmails) SendPasswordReset(ctx context.Context, to string, link string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sent = append(e.sent, sentEmail{to: to, link: link})
+	return nil
+}
+
+func (e *fakeEmails) SendEmail(ctx context.Context, email dto.Email) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, to := range email.To {
+		e.sent = append(e.sent, sentEmail{to: to, subject: email.Subject})
+	}
+	return nil
+}
+
+func (e *fakeEmails) SendVerificationCode(ctx context.Context, to string, code string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sent = append(e.sent, sentEmail{to: to, code: code})
+	return nil
+}
+
+// last returns the most recent email, failing the test when none was sent
+func (e *fakeEmails) last(t *testing.T) sentEmail {
+	t.Helper()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.sent) == 0 {
+		t.Fatal("no email was sent")
+	}
+	return e.sent[len(e.sent)-1]
+}
+
+// resetToken returns the token in the link of the most recent email
+func (e *fakeEmails) resetToken(t *testing.T) string {
+	t.Helper()
+	link, err := url.Parse(e.last(t).link)
+	if err != nil {
+		t.Fatalf("parse reset link: %v", err)
+	}
+	token := link.Query().Get("token")
+	if token == "" {
+		t.Fatalf("reset link %q has no token", link)
+	}
+	return token
+}
+
+// fakeNotifier captures operator notifications
+type fakeNotifier struct {
+	sen.NotifyService
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (n *fakeNotifier) Info(ctx context.Context, subject, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.messages = append(n.messages, subject+": "+message)
+	return nil
+}
+
+// testAuth bundles an AuthServiceImpl with its in-memory repositories, mock clock and
+// captured emails and notifications
+type testAuth struct {
+	svc      *AuthServiceImpl
+	users    *memUserRepo
+	profiles *memProfileRepo
+	resets   *memResetTokenRepo
+	emails   *fakeEmails
+	notifier *fakeNotifier
+	clock    *clock.Mock
+}
+
+func newTestAuth(t *testing.T, cfg config.AuthConfig) *testAuth {
+	t.Helper()
+	clk := clock.NewMock(testNow)
+	jwtService, err := jwt.NewJWTService("test-secret", clk)
+	if err != nil {
+		t.Fatalf("NewJWTService: %v", err)
+	}
+	cfg.BcryptCost = bcrypt.MinCost
+	cfg.PasswordResetURL = "https://app.example.com/reset-password"
+
+	ta := &testAuth{
+		users:    newMemUserRepo(),
+		profiles: newMemProfileRepo(),
+		resets:   newMemResetTokenRepo(),
+		emails:   &fakeEmails{},
+		notifier: &fakeNotifier{},
+		clock:    clk,
+	}
+	ta.svc = NewAuthService(ta.users, ta.profiles, ta.resets, newMemVerifyTokenRepo(), memRefreshRepo{},
+		jwtService, ta.emails, ta.notifier, nil, nil, cfg, clk).(*AuthServiceImpl)
+	return ta
+}
+
+// addUser stores an active user with the given email and password
+func (ta *testAuth) addUser(t *testing.T, email, password string) *domain.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := &domain.User{
+		ID:           uuid.New(),
+		Email:        email,
+		PasswordHash: string(hash),
+		Status:       domain.UserStatusActive,
+		Role:         domain.UserRoleUser,
+		CreatedAt:    ta.clock.Now(),
+	}
+	ta.users.add(user)
+	return user
+}
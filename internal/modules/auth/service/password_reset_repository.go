@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lugondev/m3-storage/internal/infra/database"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PasswordResetTokenRepositoryImpl implements the PasswordResetTokenRepository interface
+type PasswordResetTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *gorm.DB) port.PasswordResetTokenRepository {
+	return &PasswordResetTokenRepositoryImpl{db: db}
+}
+
+// Create stores a new password reset token
+func (r *PasswordResetTokenRepositoryImpl) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	dbToken := &database.PasswordResetToken{
+		Base: database.Base{
+			ID:        token.ID,
+			CreatedAt: token.CreatedAt,
+		},
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+		Used:      token.Used,
+	}
+
+	if err := r.db.WithContext(ctx).Create(dbToken).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to create password reset token")
+	}
+
+	token.ID = dbToken.ID
+	token.CreatedAt = dbToken.CreatedAt
+	return nil
+}
+
+// GetByTokenHash retrieves a password reset token by the hash of its value
+func (r *PasswordResetTokenRepositoryImpl) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	var dbToken database.PasswordResetToken
+
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&dbToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("password reset token not found")
+		}
+		return nil, errors.WrapError(err, 500, "failed to get password reset token")
+	}
+
+	return &domain.PasswordResetToken{
+		ID:        dbToken.ID,
+		UserID:    dbToken.UserID,
+		TokenHash: dbToken.TokenHash,
+		ExpiresAt: dbToken.ExpiresAt,
+		Used:      dbToken.Used,
+		CreatedAt: dbToken.CreatedAt,
+	}, nil
+}
+
+// MarkUsed marks an unused token as used and reports whether it was unused
+func (r *PasswordResetTokenRepositoryImpl) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&database.PasswordResetToken{}).
+		Where("id = ? AND used = ?", id, false).
+		Update("used", true)
+	if result.Error != nil {
+		return false, errors.WrapError(result.Error, 500, "failed to mark password reset token used")
+	}
+
+	return result.RowsAffected == 1, nil
+}
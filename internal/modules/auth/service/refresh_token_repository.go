@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lugondev/m3-storage/internal/infra/database"
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepositoryImpl implements the RefreshTokenRepository interface
+type RefreshTokenRepositoryImpl struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) port.RefreshTokenRepository {
+	return &RefreshTokenRepositoryImpl{db: db}
+}
+
+// Create records a newly issued refresh token
+func (r *RefreshTokenRepositoryImpl) Create(ctx context.Context, token *domain.RefreshToken) error {
+	dbToken := &database.RefreshToken{
+		Base: database.Base{
+			ID:        token.ID,
+			CreatedAt: token.CreatedAt,
+		},
+		UserID:    token.UserID,
+		FamilyID:  token.FamilyID,
+		ExpiresAt: token.ExpiresAt,
+		Used:      token.Used,
+		Revoked:   token.Revoked,
+	}
+
+	if err := r.db.WithContext(ctx).Create(dbToken).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to create refresh token")
+	}
+
+	token.CreatedAt = dbToken.CreatedAt
+	return nil
+}
+
+// GetByID retrieves a refresh token by its JWT ID
+func (r *RefreshTokenRepositoryImpl) GetByID(ctx context.Context, id uuid.UUID) (*domain.RefreshToken, error) {
+	var dbToken database.RefreshToken
+
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&dbToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.NewNotFoundError("refresh token not found")
+		}
+		return nil, errors.WrapError(err, 500, "failed to get refresh token")
+	}
+
+	return &domain.RefreshToken{
+		ID:        dbToken.ID,
+		UserID:    dbToken.UserID,
+		FamilyID:  dbToken.FamilyID,
+		ExpiresAt: dbToken.ExpiresAt,
+		Used:      dbToken.Used,
+		Revoked:   dbToken.Revoked,
+		CreatedAt: dbToken.CreatedAt,
+	}, nil
+}
+
+// MarkUsed marks an unused, unrevoked token as used and reports whether it was
+func (r *RefreshTokenRepositoryImpl) MarkUsed(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&database.RefreshToken{}).
+		Where("id = ? AND used = ? AND revoked = ?", id, false, false).
+		Update("used", true)
+	if result.Error != nil {
+		return false, errors.WrapError(result.Error, 500, "failed to mark refresh token used")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token of a family
+func (r *RefreshTokenRepositoryImpl) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Model(&database.RefreshToken{}).Where("family_id = ?", familyID).Update("revoked", true).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to revoke refresh tokens")
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every token of a user
+func (r *RefreshTokenRepositoryImpl) RevokeUser(ctx context.Context, userID uuid.UUID) error {
+	if err := r.db.WithContext(ctx).Model(&database.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).Update("revoked", true).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to revoke refresh tokens")
+	}
+
+	return nil
+}
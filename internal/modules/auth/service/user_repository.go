@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/lugondev/m3-storage/internal/infra/database"
@@ -67,6 +68,16 @@ func (r *UserRepositoryImpl) GetByEmail(ctx context.Context, email string) (*dom
 	return r.dbToDomainUser(&dbUser), nil
 }
 
+// EmailInUse reports whether any user, deleted or not, has the email
+func (r *UserRepositoryImpl) EmailInUse(ctx context.Context, email string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&database.User{}).Where("email = ?", email).Count(&count).Error; err != nil {
+		return false, errors.WrapError(err, 500, "failed to check email")
+	}
+
+	return count > 0, nil
+}
+
 // Update updates an existing user
 func (r *UserRepositoryImpl) Update(ctx context.Context, user *domain.User) error {
 	dbUser := r.domainToDBUser(user)
@@ -118,6 +129,143 @@ func (r *UserRepositoryImpl) LockUser(ctx context.Context, id uuid.UUID, lockedU
 	return nil
 }
 
+// RecordUpload adds size bytes to the user's storage usage and counts one file against
+// day, restarting the daily count when day differs from the stored one. The update is a
+// single statement so concurrent uploads do not lose increments.
+func (r *UserRepositoryImpl) RecordUpload(ctx context.Context, id uuid.UUID, size int64, day time.Time) error {
+	err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).Updates(map[string]any{
+		"used_storage_bytes": gorm.Expr("used_storage_bytes + ?", size),
+		"daily_file_count":   gorm.Expr("CASE WHEN daily_count_date = ? THEN daily_file_count + 1 ELSE 1 END", day),
+		"daily_count_date":   day,
+	}).Error
+	if err != nil {
+		return errors.WrapError(err, 500, "failed to record upload")
+	}
+
+	return nil
+}
+
+// ReserveStorage adds size bytes to the user's storage usage with a single conditional
+// statement, so concurrent reservations are checked against each other's usage. A
+// quota that is not positive is unlimited.
+func (r *UserRepositoryImpl) ReserveStorage(ctx context.Context, id uuid.UUID, size int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&database.User{}).
+		Where("id = ? AND (max_storage_bytes <= 0 OR used_storage_bytes + ? <= max_storage_bytes)", id, size).
+		Update("used_storage_bytes", gorm.Expr("used_storage_bytes + ?", size))
+	if result.Error != nil {
+		return false, errors.WrapError(result.Error, 500, "failed to reserve storage")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// ReleaseStorage subtracts size bytes from the user's storage usage in a single
+// statement, clamped at zero in case the usage drifted below what the files hold
+func (r *UserRepositoryImpl) ReleaseStorage(ctx context.Context, id uuid.UUID, size int64) error {
+	err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).
+		Update("used_storage_bytes", gorm.Expr("GREATEST(used_storage_bytes - ?, 0)", size)).Error
+	if err != nil {
+		return errors.WrapError(err, 500, "failed to release storage usage")
+	}
+
+	return nil
+}
+
+// SetUsedStorage overwrites the user's storage usage
+func (r *UserRepositoryImpl) SetUsedStorage(ctx context.Context, id uuid.UUID, bytes int64) error {
+	err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).
+		Update("used_storage_bytes", bytes).Error
+	if err != nil {
+		return errors.WrapError(err, 500, "failed to set storage usage")
+	}
+
+	return nil
+}
+
+// SetQuota overwrites the user's storage quota and daily file limit
+func (r *UserRepositoryImpl) SetQuota(ctx context.Context, id uuid.UUID, maxStorageBytes int64, maxFilesPerDay int) error {
+	err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).Updates(map[string]any{
+		"max_storage_bytes": maxStorageBytes,
+		"max_files_per_day": maxFilesPerDay,
+	}).Error
+	if err != nil {
+		return errors.WrapError(err, 500, "failed to set quota")
+	}
+
+	return nil
+}
+
+// SetRole overwrites the user's role
+func (r *UserRepositoryImpl) SetRole(ctx context.Context, id uuid.UUID, role domain.UserRole) error {
+	if err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).Update("role", string(role)).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to set role")
+	}
+
+	return nil
+}
+
+// SetStatus overwrites the user's status
+func (r *UserRepositoryImpl) SetStatus(ctx context.Context, id uuid.UUID, status domain.UserStatus) error {
+	if err := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).Update("status", string(status)).Error; err != nil {
+		return errors.WrapError(err, 500, "failed to set status")
+	}
+
+	return nil
+}
+
+// List returns a page of the users matching filter, newest first
+func (r *UserRepositoryImpl) List(ctx context.Context, filter *domain.UserFilter, offset, limit int) ([]*domain.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.User{})
+	if filter.Email != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Email)
+		query = query.Where("email ILIKE ?", "%"+escaped+"%")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", string(filter.Status))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, errors.WrapError(err, 500, "failed to count users")
+	}
+
+	var dbUsers []database.User
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&dbUsers).Error; err != nil {
+		return nil, 0, errors.WrapError(err, 500, "failed to list users")
+	}
+
+	users := make([]*domain.User, len(dbUsers))
+	for i := range dbUsers {
+		users[i] = r.dbToDomainUser(&dbUsers[i])
+	}
+	return users, total, nil
+}
+
+// ListIDs pages through user IDs in ascending order
+func (r *UserRepositoryImpl) ListIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&database.User{}).
+		Where("id > ?", after).
+		Order("id").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, errors.WrapError(err, 500, "failed to list users")
+	}
+
+	return ids, nil
+}
+
+// CountAfter counts the users with an ID greater than after
+func (r *UserRepositoryImpl) CountAfter(ctx context.Context, after uuid.UUID) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&database.User{}).Where("id > ?", after).Count(&count).Error; err != nil {
+		return 0, errors.WrapError(err, 500, "failed to count users")
+	}
+
+	return count, nil
+}
+
 // domainToDBUser converts domain user to database user
 func (r *UserRepositoryImpl) domainToDBUser(user *domain.User) *database.User {
 	return &database.User{
@@ -135,6 +283,16 @@ func (r *UserRepositoryImpl) domainToDBUser(user *domain.User) *database.User {
 		LastLoginAt:    user.LastLoginAt,
 		FailedAttempts: user.FailedAttempts,
 		LockedUntil:    user.LockedUntil,
+
+		UsedStorageBytes: user.UsedStorageBytes,
+		MaxStorageBytes:  user.MaxStorageBytes,
+		MaxFilesPerDay:   user.MaxFilesPerDay,
+		DailyFileCount:   user.DailyFileCount,
+		DailyCountDate:   user.DailyCountDate,
+
+		MaxBandwidthBytesPerSecond: user.MaxBandwidthBytesPerSecond,
+
+		Role: string(user.Role),
 	}
 }
 
@@ -153,6 +311,16 @@ func (r *UserRepositoryImpl) dbToDomainUser(dbUser *database.User) *domain.User
 		LockedUntil:    dbUser.LockedUntil,
 		CreatedAt:      dbUser.CreatedAt,
 		UpdatedAt:      dbUser.UpdatedAt,
+
+		UsedStorageBytes: dbUser.UsedStorageBytes,
+		MaxStorageBytes:  dbUser.MaxStorageBytes,
+		MaxFilesPerDay:   dbUser.MaxFilesPerDay,
+		DailyFileCount:   dbUser.DailyFileCount,
+		DailyCountDate:   dbUser.DailyCountDate,
+
+		MaxBandwidthBytesPerSecond: dbUser.MaxBandwidthBytesPerSecond,
+
+		Role: domain.UserRole(dbUser.Role),
 	}
 }
 
@@ -197,20 +365,28 @@ func (r *UserProfileRepositoryImpl) GetByUserID(ctx context.Context, userID uuid
 
 // Update updates an existing user profile
 func (r *UserProfileRepositoryImpl) Update(ctx context.Context, profile *domain.UserProfile) error {
-	dbProfile := r.domainToDBUserProfile(profile)
-	dbProfile.UpdatedAt = time.Now()
-
-	if err := r.db.WithContext(ctx).Save(dbProfile).Error; err != nil {
+	// Profiles are addressed by user, as the domain profile does not carry the row ID
+	updatedAt := time.Now()
+	err := r.db.WithContext(ctx).Model(&database.UserProfile{}).Where("user_id = ?", profile.UserID).Updates(map[string]any{
+		"avatar":          profile.Avatar,
+		"avatar_media_id": profile.AvatarMediaID,
+		"phone_number":    profile.PhoneNumber,
+		"date_of_birth":   profile.DateOfBirth,
+		"timezone":        profile.Timezone,
+		"language":        profile.Language,
+		"updated_at":      updatedAt,
+	}).Error
+	if err != nil {
 		return errors.WrapError(err, 500, "failed to update user profile")
 	}
 
-	profile.UpdatedAt = dbProfile.UpdatedAt
+	profile.UpdatedAt = updatedAt
 	return nil
 }
 
-// Delete deletes a user profile
+// Delete permanently deletes a user profile; it holds personal data only
 func (r *UserProfileRepositoryImpl) Delete(ctx context.Context, userID uuid.UUID) error {
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&database.UserProfile{}).Error; err != nil {
+	if err := r.db.WithContext(ctx).Unscoped().Where("user_id = ?", userID).Delete(&database.UserProfile{}).Error; err != nil {
 		return errors.WrapError(err, 500, "failed to delete user profile")
 	}
 
@@ -224,25 +400,27 @@ func (r *UserProfileRepositoryImpl) domainToDBUserProfile(profile *domain.UserPr
 			CreatedAt: profile.CreatedAt,
 			UpdatedAt: profile.UpdatedAt,
 		},
-		UserID:      profile.UserID,
-		Avatar:      profile.Avatar,
-		PhoneNumber: profile.PhoneNumber,
-		DateOfBirth: profile.DateOfBirth,
-		Timezone:    profile.Timezone,
-		Language:    profile.Language,
+		UserID:        profile.UserID,
+		Avatar:        profile.Avatar,
+		AvatarMediaID: profile.AvatarMediaID,
+		PhoneNumber:   profile.PhoneNumber,
+		DateOfBirth:   profile.DateOfBirth,
+		Timezone:      profile.Timezone,
+		Language:      profile.Language,
 	}
 }
 
 // dbToDomainUserProfile converts database user profile to domain user profile
 func (r *UserProfileRepositoryImpl) dbToDomainUserProfile(dbProfile *database.UserProfile) *domain.UserProfile {
 	return &domain.UserProfile{
-		UserID:      dbProfile.UserID,
-		Avatar:      dbProfile.Avatar,
-		PhoneNumber: dbProfile.PhoneNumber,
-		DateOfBirth: dbProfile.DateOfBirth,
-		Timezone:    dbProfile.Timezone,
-		Language:    dbProfile.Language,
-		CreatedAt:   dbProfile.CreatedAt,
-		UpdatedAt:   dbProfile.UpdatedAt,
+		UserID:        dbProfile.UserID,
+		Avatar:        dbProfile.Avatar,
+		AvatarMediaID: dbProfile.AvatarMediaID,
+		PhoneNumber:   dbProfile.PhoneNumber,
+		DateOfBirth:   dbProfile.DateOfBirth,
+		Timezone:      dbProfile.Timezone,
+		Language:      dbProfile.Language,
+		CreatedAt:     dbProfile.CreatedAt,
+		UpdatedAt:     dbProfile.UpdatedAt,
 	}
 }
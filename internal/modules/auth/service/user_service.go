@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+
+	"github.com/google/uuid"
+)
+
+// UserServiceImpl implements the UserService interface
+type UserServiceImpl struct {
+	userRepo port.UserRepository
+	clock    clock.Clock
+}
+
+// NewUserService creates a new user service
+func NewUserService(userRepo port.UserRepository, clk clock.Clock) port.UserService {
+	return &UserServiceImpl{
+		userRepo: userRepo,
+		clock:    clk,
+	}
+}
+
+// CanUpload checks the user's storage quota and daily file limit
+func (s *UserServiceImpl) CanUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	return s.CanUploadFiles(ctx, userID, 1, size)
+}
+
+// CanUploadFiles checks the user's storage quota and daily file limit for several files at once
+func (s *UserServiceImpl) CanUploadFiles(ctx context.Context, userID uuid.UUID, count int, size int64) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkStorageQuota(user, count, size); err != nil {
+		return err
+	}
+
+	if uploaded := user.FilesUploadedOn(s.clock.Now()); user.MaxFilesPerDay > 0 && uploaded+count > user.MaxFilesPerDay {
+		if uploaded >= user.MaxFilesPerDay {
+			return errors.NewForbiddenError(fmt.Sprintf(
+				"daily file limit reached: %d files per day", user.MaxFilesPerDay,
+			))
+		}
+		return errors.NewForbiddenError(fmt.Sprintf(
+			"daily file limit exceeded: %d files per day, %d left today, %d requested",
+			user.MaxFilesPerDay, user.MaxFilesPerDay-uploaded, count,
+		))
+	}
+
+	return nil
+}
+
+// checkStorageQuota returns a forbidden error if count files of size bytes in total do
+// not fit the user's storage quota
+func checkStorageQuota(user *domain.User, count int, size int64) error {
+	if user.MaxStorageBytes <= 0 || user.UsedStorageBytes+size <= user.MaxStorageBytes {
+		return nil
+	}
+	needs := "file needs"
+	if count != 1 {
+		needs = fmt.Sprintf("%d files need", count)
+	}
+	return errors.NewForbiddenError(fmt.Sprintf(
+		"storage quota exceeded: %d of %d bytes used, %s %d",
+		user.UsedStorageBytes, user.MaxStorageBytes, needs, size,
+	))
+}
+
+// ReserveStorage adds size bytes to the user's storage usage if they fit the quota
+func (s *UserServiceImpl) ReserveStorage(ctx context.Context, userID uuid.UUID, count int, size int64) error {
+	reserved, err := s.userRepo.ReserveStorage(ctx, userID, size)
+	if err != nil || reserved {
+		return err
+	}
+
+	// Reload the user to say by how much the quota is exceeded
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := checkStorageQuota(user, count, size); err != nil {
+		return err
+	}
+	// Other files were deleted since the reservation failed
+	return errors.NewForbiddenError("storage quota exceeded, please retry")
+}
+
+// RecordUpload counts a stored file against today's quota day
+func (s *UserServiceImpl) RecordUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	return s.userRepo.RecordUpload(ctx, userID, size, domain.QuotaDay(s.clock.Now()))
+}
+
+// ReleaseStorage returns the bytes of deleted files to the user's storage quota
+func (s *UserServiceImpl) ReleaseStorage(ctx context.Context, userID uuid.UUID, size int64) error {
+	if size <= 0 {
+		return nil
+	}
+	return s.userRepo.ReleaseStorage(ctx, userID, size)
+}
+
+// GetQuota returns the user's quota limits with their usage on the current quota day
+func (s *UserServiceImpl) GetQuota(ctx context.Context, userID uuid.UUID) (*domain.UploadQuota, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UploadQuota{
+		UsedStorageBytes:   user.UsedStorageBytes,
+		MaxStorageBytes:    user.MaxStorageBytes,
+		MaxFilesPerDay:     user.MaxFilesPerDay,
+		FilesUploadedToday: user.FilesUploadedOn(s.clock.Now()),
+	}, nil
+}
+
+// BandwidthLimit returns the user's own transfer rate limit
+func (s *UserServiceImpl) BandwidthLimit(ctx context.Context, userID uuid.UUID) (int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return user.MaxBandwidthBytesPerSecond, nil
+}
+
+// SetUsedStorage overwrites the user's storage usage and returns the previous value
+func (s *UserServiceImpl) SetUsedStorage(ctx context.Context, userID uuid.UUID, bytes int64) (int64, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.userRepo.SetUsedStorage(ctx, userID, bytes); err != nil {
+		return 0, err
+	}
+	return user.UsedStorageBytes, nil
+}
+
+// ListUserIDs returns the next page of user IDs after the given one
+func (s *UserServiceImpl) ListUserIDs(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	return s.userRepo.ListIDs(ctx, after, limit)
+}
+
+// CountUsers counts the users with an ID after the given one
+func (s *UserServiceImpl) CountUsers(ctx context.Context, after uuid.UUID) (int64, error) {
+	return s.userRepo.CountAfter(ctx, after)
+}
+
+// SetQuota replaces the user's quota limits and returns the updated quota
+func (s *UserServiceImpl) SetQuota(ctx context.Context, userID uuid.UUID, req *domain.UpdateQuotaRequest) (*domain.UploadQuota, error) {
+	if req.MaxStorageBytes == nil || req.MaxFilesPerDay == nil {
+		return nil, errors.NewBadRequestError("max_storage_bytes and max_files_per_day are required")
+	}
+	if *req.MaxStorageBytes < 0 || *req.MaxFilesPerDay < 0 {
+		return nil, errors.NewBadRequestError("quota limits must not be negative")
+	}
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetQuota(ctx, userID, *req.MaxStorageBytes, *req.MaxFilesPerDay); err != nil {
+		return nil, err
+	}
+	return s.GetQuota(ctx, userID)
+}
+
+// SetRole changes the user's role and returns the updated user and the previous role
+func (s *UserServiceImpl) SetRole(ctx context.Context, userID uuid.UUID, role domain.UserRole) (*domain.User, domain.UserRole, error) {
+	if !role.Valid() {
+		return nil, "", errors.NewBadRequestError(fmt.Sprintf("unknown role %q", role))
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := s.userRepo.SetRole(ctx, userID, role); err != nil {
+		return nil, "", err
+	}
+	previous := user.Role
+	user.Role = role
+	return user, previous, nil
+}
+
+// ListUsers returns a page of the users matching filter
+func (s *UserServiceImpl) ListUsers(ctx context.Context, query *utils.PaginationQuery, filter *domain.UserFilter) (*utils.Pagination, []*domain.User, error) {
+	query.ValidateAndSetDefaults()
+	users, total, err := s.userRepo.List(ctx, filter, query.GetOffset(), query.GetLimit())
+	if err != nil {
+		return nil, nil, err
+	}
+	pagination := utils.NewPagination(*query, total)
+	return &pagination, users, nil
+}
+
+// UpdateUser activates, suspends or locks the user's account
+func (s *UserServiceImpl) UpdateUser(ctx context.Context, userID uuid.UUID, req *domain.UpdateUserRequest) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Status {
+	case "active":
+		if err := s.userRepo.SetStatus(ctx, userID, domain.UserStatusActive); err != nil {
+			return nil, err
+		}
+		if err := s.userRepo.UpdateFailedAttempts(ctx, userID, 0); err != nil {
+			return nil, err
+		}
+		if err := s.userRepo.LockUser(ctx, userID, nil); err != nil {
+			return nil, err
+		}
+		user.Status = domain.UserStatusActive
+		user.ResetFailedAttempts()
+	case "suspended":
+		if err := s.userRepo.SetStatus(ctx, userID, domain.UserStatusSuspended); err != nil {
+			return nil, err
+		}
+		user.Status = domain.UserStatusSuspended
+	case "locked":
+		if req.LockedUntil == nil || !req.LockedUntil.After(s.clock.Now()) {
+			return nil, errors.NewBadRequestError("locked_until must be in the future")
+		}
+		if err := s.userRepo.LockUser(ctx, userID, req.LockedUntil); err != nil {
+			return nil, err
+		}
+		user.LockedUntil = req.LockedUntil
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("unknown status %q", req.Status))
+	}
+	return user, nil
+}
+
+// DeleteUser soft deletes a user that owns nothing else, or anything with force
+func (s *UserServiceImpl) DeleteUser(ctx context.Context, userID uuid.UUID, resources map[string]int64, force, dryRun bool) (*domain.UserDeleteSummary, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &domain.UserDeleteSummary{UserID: userID, Email: user.Email, Resources: resources, DryRun: dryRun}
+	if dryRun {
+		return summary, nil
+	}
+	if blocking := summary.Blocking(); !force && len(blocking) > 0 {
+		return nil, errors.NewConflictError("user still owns resources; delete with force=true to delete the user anyway").
+			WithDetails(blocking)
+	}
+
+	if err := s.userRepo.Delete(ctx, userID); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/modules/auth/domain"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+
+	"github.com/google/uuid"
+)
+
+func TestDailyFileLimitResetsAtMidnight(t *testing.T) {
+	ctx := context.Background()
+	users := newMemUserRepo()
+	clk := clock.NewMock(time.Date(2026, 3, 2, 23, 59, 0, 0, time.UTC))
+	svc := NewUserService(users, clk)
+
+	user := &domain.User{ID: uuid.New(), Status: domain.UserStatusActive, MaxFilesPerDay: 2}
+	users.add(user)
+	for i := 0; i < 2; i++ {
+		if err := svc.CanUpload(ctx, user.ID, 1); err != nil {
+			t.Fatalf("upload %d: %v", i+1, err)
+		}
+		if err := svc.RecordUpload(ctx, user.ID, 1); err != nil {
+			t.Fatalf("record upload %d: %v", i+1, err)
+		}
+	}
+
+	err := svc.CanUpload(ctx, user.ID, 1)
+	if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("third upload of the day: got %v, want 403", err)
+	}
+
+	// One second before midnight the limit still holds, at midnight UTC it starts over
+	clk.Advance(59 * time.Second)
+	if err := svc.CanUpload(ctx, user.ID, 1); err == nil {
+		t.Fatal("upload at 23:59:59 passed the daily limit")
+	}
+	clk.Advance(time.Second)
+	if err := svc.CanUpload(ctx, user.ID, 1); err != nil {
+		t.Fatalf("upload after midnight: %v", err)
+	}
+	quota, err := svc.GetQuota(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("GetQuota: %v", err)
+	}
+	if quota.FilesUploadedToday != 0 {
+		t.Errorf("files uploaded today after midnight = %d, want 0", quota.FilesUploadedToday)
+	}
+}
+
+func TestReleaseStorageReopensQuota(t *testing.T) {
+	ctx := context.Background()
+	users := newMemUserRepo()
+	svc := NewUserService(users, clock.NewMock(testNow))
+
+	user := &domain.User{ID: uuid.New(), Status: domain.UserStatusActive, MaxStorageBytes: 100, UsedStorageBytes: 90}
+	users.add(user)
+	if err := svc.CanUpload(ctx, user.ID, 20); err == nil {
+		t.Fatal("upload over the storage quota passed")
+	}
+
+	if err := svc.ReleaseStorage(ctx, user.ID, 40); err != nil {
+		t.Fatalf("ReleaseStorage: %v", err)
+	}
+	if err := svc.CanUpload(ctx, user.ID, 20); err != nil {
+		t.Fatalf("upload after deleting files: %v", err)
+	}
+
+	// Usage that drifted below the released size stops at zero
+	if err := svc.ReleaseStorage(ctx, user.ID, 1000); err != nil {
+		t.Fatalf("ReleaseStorage: %v", err)
+	}
+	if used := users.get(user.ID).UsedStorageBytes; used != 0 {
+		t.Errorf("used storage = %d, want 0", used)
+	}
+}
+
+func TestReserveStorageStaysWithinQuota(t *testing.T) {
+	ctx := context.Background()
+	users := newMemUserRepo()
+	svc := NewUserService(users, clock.NewMock(testNow))
+
+	user := &domain.User{ID: uuid.New(), Status: domain.UserStatusActive, MaxStorageBytes: 100}
+	users.add(user)
+
+	// Ten batches of 30 bytes race for 100 bytes of quota; only three fit
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	reserved, rejected := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := svc.ReserveStorage(ctx, user.ID, 3, 30)
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				reserved++
+				return
+			}
+			if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusForbidden {
+				t.Errorf("ReserveStorage error = %v, want 403", err)
+			}
+			rejected++
+		}()
+	}
+	wg.Wait()
+
+	if reserved != 3 || rejected != 7 {
+		t.Errorf("reserved %d and rejected %d batches, want 3 and 7", reserved, rejected)
+	}
+	if used := users.get(user.ID).UsedStorageBytes; used != 90 {
+		t.Errorf("used storage = %d, want 90", used)
+	}
+}
+
+func TestSetRoleReturnsPreviousRole(t *testing.T) {
+	ctx := context.Background()
+	users := newMemUserRepo()
+	svc := NewUserService(users, clock.NewMock(testNow))
+
+	user := &domain.User{ID: uuid.New(), Status: domain.UserStatusActive, Role: domain.UserRoleAdmin}
+	users.add(user)
+
+	// The admin handler signs the user out when the role changes, so it must learn the old one
+	updated, previous, err := svc.SetRole(ctx, user.ID, domain.UserRoleUser)
+	if err != nil {
+		t.Fatalf("SetRole: %v", err)
+	}
+	if previous != domain.UserRoleAdmin || updated.Role != domain.UserRoleUser {
+		t.Errorf("SetRole = %s from %s, want %s from %s", updated.Role, previous, domain.UserRoleUser, domain.UserRoleAdmin)
+	}
+	if stored := users.get(user.ID).Role; stored != domain.UserRoleUser {
+		t.Errorf("stored role = %s, want %s", stored, domain.UserRoleUser)
+	}
+
+	if _, _, err := svc.SetRole(ctx, user.ID, domain.UserRole("owner")); err == nil {
+		t.Error("SetRole accepted an unknown role")
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "github.com/google/uuid"
+
+// BatchDeleteRequest lists media files to delete at once.
+type BatchDeleteRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// MediaDeleteResult reports a media file removed by a batch delete. IDs that are not
+// found or fail are reported as failed batch items instead.
+type MediaDeleteResult struct {
+	MediaID uuid.UUID `json:"media_id"`
+	Status  string    `json:"status"` // Always "deleted"
+}
@@ -0,0 +1,40 @@
+package domain
+
+import "github.com/google/uuid"
+
+// VerifyOptions controls a consistency check between media records and the storage providers.
+type VerifyOptions struct {
+	Provider string // Only check this backend; empty checks every backend holding media
+	Orphans  bool   // Also list the providers' objects to find those without a media record
+	Fix      bool   // Mark media whose object is gone as StatusMissing
+}
+
+// DanglingMedia is a media record whose object is not stored by its provider.
+type DanglingMedia struct {
+	MediaID  uuid.UUID `json:"media_id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Provider string    `json:"provider"`
+	FilePath string    `json:"file_path"`
+}
+
+// OrphanedObject is an object in a media key of a provider that no media record refers to.
+type OrphanedObject struct {
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+	Size     int64  `json:"size"`
+}
+
+// VerifyReport is the outcome of a consistency check. Errors lists the records and
+// providers that could not be checked, so an empty report with errors is not a clean one.
+type VerifyReport struct {
+	Checked  int64            `json:"checked"`
+	Dangling []DanglingMedia  `json:"dangling"`
+	Fixed    int              `json:"fixed"`
+	Orphaned []OrphanedObject `json:"orphaned,omitempty"`
+	Errors   []string         `json:"errors,omitempty"`
+}
+
+// Consistent reports whether the check found nothing to clean up and nothing it could not check.
+func (r *VerifyReport) Consistent() bool {
+	return len(r.Dangling) == 0 && len(r.Orphaned) == 0 && len(r.Errors) == 0
+}
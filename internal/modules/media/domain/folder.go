@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Folder groups a user's media. Folders nest through ParentID; top-level folders have none.
+type Folder struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	ParentID  *uuid.UUID `json:"parent_id,omitempty" gorm:"type:uuid;index"`
+	Name      string     `json:"name" gorm:"type:varchar(255)"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Folder model.
+func (Folder) TableName() string {
+	return "media_folders"
+}
+
+// CreateFolderRequest describes a new folder.
+type CreateFolderRequest struct {
+	Name     string     `json:"name"`
+	ParentID *uuid.UUID `json:"parent_id,omitempty"` // Top-level folder when empty
+}
+
+// RenameFolderRequest gives a folder a new name.
+type RenameFolderRequest struct {
+	Name string `json:"name"`
+}
+
+// MoveFolderRequest reparents a folder.
+type MoveFolderRequest struct {
+	ParentID *uuid.UUID `json:"parent_id"` // Null moves the folder to the top level
+}
+
+// MoveMediaRequest moves a media file into a folder.
+type MoveMediaRequest struct {
+	FolderID *uuid.UUID `json:"folder_id"` // Null takes the file out of any folder
+}
+
+// FolderDeleteSummary reports what deleting a folder removes, or would remove on a dry run.
+type FolderDeleteSummary struct {
+	FolderID uuid.UUID `json:"folder_id"`
+	Folders  int       `json:"folders"` // The folder and all folders nested in it
+	Media    int       `json:"media"`   // Media files in those folders
+	DryRun   bool      `json:"dry_run"`
+}
@@ -0,0 +1,24 @@
+package domain
+
+// ImageMetadata describes an uploaded image: its dimensions plus what its EXIF data
+// reports. Fields the image does not carry are left empty.
+type ImageMetadata struct {
+	Width        int             `json:"width,omitempty"`
+	Height       int             `json:"height,omitempty"`
+	CameraMake   string          `json:"camera_make,omitempty"`
+	CameraModel  string          `json:"camera_model,omitempty"`
+	Orientation  int             `json:"orientation,omitempty"` // EXIF orientation, 1-8; empty once stripped since it was applied to the pixels
+	GPS          *GPSCoordinates `json:"gps,omitempty"`
+	EXIFStripped bool            `json:"exif_stripped,omitempty"` // EXIF was removed from the stored file
+}
+
+// GPSCoordinates is a location in decimal degrees.
+type GPSCoordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// IsEmpty reports whether nothing is known about the image.
+func (m *ImageMetadata) IsEmpty() bool {
+	return *m == ImageMetadata{}
+}
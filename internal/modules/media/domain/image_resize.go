@@ -0,0 +1,49 @@
+package domain
+
+import "fmt"
+
+// ImageFit selects how a resized image fills the requested box.
+type ImageFit string
+
+const (
+	ImageFitContain ImageFit = "contain" // Scale down to fit inside the box, keeping the aspect ratio
+	ImageFitCover   ImageFit = "cover"   // Scale and crop to fill the box exactly
+)
+
+// IsValid reports whether the fit mode is supported.
+func (f ImageFit) IsValid() bool {
+	return f == ImageFitContain || f == ImageFitCover
+}
+
+// ImageFormat is the encoding of a resized image. WebP sources can be resized, but
+// there is no WebP encoder available, so variants are written as JPEG or PNG.
+type ImageFormat string
+
+const (
+	ImageFormatJPEG ImageFormat = "jpeg"
+	ImageFormatPNG  ImageFormat = "png"
+)
+
+// IsValid reports whether the output format is supported.
+func (f ImageFormat) IsValid() bool {
+	return f == ImageFormatJPEG || f == ImageFormatPNG
+}
+
+// ContentType returns the MIME type of the format.
+func (f ImageFormat) ContentType() string {
+	return "image/" + string(f)
+}
+
+// ResizeOptions describes a resized variant of an image. A zero Width or Height
+// leaves that dimension to the aspect ratio; cover needs both.
+type ResizeOptions struct {
+	Width  int
+	Height int
+	Fit    ImageFit
+	Format ImageFormat
+}
+
+// VariantKey returns the key the variant of the image stored at key is cached under.
+func (o ResizeOptions) VariantKey(key string) string {
+	return fmt.Sprintf("%s.%dx%d-%s.%s", key, o.Width, o.Height, o.Fit, o.Format)
+}
@@ -8,19 +8,83 @@ import (
 
 // Media represents the metadata for an uploaded file.
 type Media struct {
-	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
-	UserID     uuid.UUID `json:"user_id" gorm:"type:uuid;index"`
-	FileName   string    `json:"file_name" gorm:"type:varchar(255)"`
-	FilePath   string    `json:"file_path" gorm:"type:varchar(500)"` // Path in the adapters provider
-	FileSize   int64     `json:"file_size"`
-	MediaType  string    `json:"media_type" gorm:"type:varchar(50)"` // e.g., image, video, document
-	Provider   string    `json:"provider" gorm:"type:varchar(50)"`   // e.g., local, s3, azure, firebase
-	PublicURL  string    `json:"public_url" gorm:"type:varchar(500)"`
-	UploadedAt time.Time `json:"uploaded_at"`
-	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	ID          uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;index:idx_media_user_created,priority:3"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"type:uuid;index;index:idx_media_user_created,priority:1"`
+	FolderID    *uuid.UUID `json:"folder_id,omitempty" gorm:"type:uuid;index"` // Folder the file is filed in, if any
+	FileName    string     `json:"file_name" gorm:"type:varchar(255)"`         // Display name; renaming does not move the object
+	FilePath    string     `json:"file_path" gorm:"type:varchar(500)"`         // Path in the adapters provider
+	FileSize    int64      `json:"file_size"`
+	MediaType   string     `json:"media_type" gorm:"type:varchar(50)"` // e.g., image, video, document
+	Provider    string     `json:"provider" gorm:"type:varchar(50)"`   // e.g., local, s3, azure, firebase
+	PublicURL   string     `json:"public_url" gorm:"type:varchar(500)"`
+	Status      Status     `json:"status" gorm:"type:varchar(20);default:ready;index"`
+	Description string     `json:"description,omitempty" gorm:"type:text"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index:idx_media_user_created,priority:2"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+
+	// Object metadata as last reported by the storage provider
+	ContentType  string     `json:"content_type,omitempty" gorm:"type:varchar(255)"`
+	ETag         string     `json:"etag,omitempty" gorm:"type:varchar(255)"`
+	LastModified *time.Time `json:"last_modified,omitempty"`
+
+	// SHA-256 of the stored content, computed for direct uploads while they stream to the provider
+	SHA256      string     `json:"sha256,omitempty" gorm:"column:sha256;type:varchar(64);index"`
+	DuplicateOf *uuid.UUID `json:"duplicate_of,omitempty" gorm:"type:uuid"` // Earlier media of the same user with identical content
+
+	// Dimensions and EXIF data of images, read on upload
+	Metadata *ImageMetadata `json:"metadata,omitempty" gorm:"serializer:json;type:jsonb"`
+	Width    int            `json:"width,omitempty" gorm:"default:0"`  // Displayed width of images in pixels; 0 when unknown
+	Height   int            `json:"height,omitempty" gorm:"default:0"` // Displayed height of images in pixels; 0 when unknown
+
+	// Scrubbing-preview assets generated for videos, stored next to the video in the same provider
+	SpritePath    string `json:"-" gorm:"type:varchar(500)"`
+	SpriteVTTPath string `json:"-" gorm:"type:varchar(500)"`
+	SpriteURL     string `json:"sprite_url,omitempty" gorm:"-"`
+	SpriteVTTURL  string `json:"sprite_vtt_url,omitempty" gorm:"-"`
+
+	// Downscaled JPEG generated for images, or poster frame of videos, stored next to the file in the same provider
+	ThumbnailPath string `json:"-" gorm:"type:varchar(500)"`
+	ThumbnailURL  string `json:"thumbnail_url,omitempty" gorm:"type:varchar(500)"`
+
+	// Time-limited URL issued on request, for media in private buckets
+	SignedURL          string     `json:"signed_url,omitempty" gorm:"-"`
+	SignedURLExpiresAt *time.Time `json:"signed_url_expires_at,omitempty" gorm:"-"`
+
+	// Tags attached to the file, loaded by GetMedia
+	Tags []string `json:"tags,omitempty" gorm:"-"`
+
+	// Keys of the resized variants cached next to the image, removed together with it
+	ResizedKeys []string `json:"-" gorm:"serializer:json;type:text"`
+
+	// Web-friendly renditions transcoded from videos, stored next to the video in the same provider
+	TranscodeStatus TranscodeStatus `json:"transcode_status,omitempty" gorm:"type:varchar(20);index"`
+	TranscodeError  string          `json:"transcode_error,omitempty" gorm:"type:text"`
+	TranscodedPath  string          `json:"-" gorm:"type:varchar(500)"` // H.264/MP4 rendition
+	TranscodedURL   string          `json:"transcoded_url,omitempty" gorm:"type:varchar(500)"`
+	HLSPath         string          `json:"-" gorm:"column:hls_path;type:varchar(500)"` // HLS playlist, next to its segments
+	HLSURL          string          `json:"hls_url,omitempty" gorm:"column:hls_url;type:varchar(500)"`
+	HLSSegmentKeys  []string        `json:"-" gorm:"column:hls_segment_keys;serializer:json;type:text"`
 }
 
+// MaxDescriptionLength is the longest media description accepted, in characters.
+const MaxDescriptionLength = 2000
+
+// UpdateMediaRequest changes the editable metadata of a media file. Omitted fields are left unchanged.
+type UpdateMediaRequest struct {
+	FileName    *string `json:"file_name,omitempty"`
+	Description *string `json:"description,omitempty"` // An empty string clears the description
+}
+
+// Status tracks whether a media file's object has been stored by the provider.
+type Status string
+
+const (
+	StatusReady   Status = "ready"   // Object is stored and its metadata recorded
+	StatusPending Status = "pending" // Client was given a presigned upload URL; awaiting confirmation
+	StatusMissing Status = "missing" // Object is gone from the provider; hidden from listings until deleted
+)
+
 // TableName specifies the table name for the Media model.
 func (Media) TableName() string {
 	return "media"
@@ -37,6 +101,87 @@ func NewMedia(userID uuid.UUID, fileName, filePath string, fileSize int64, media
 		MediaType:  mediaType,
 		Provider:   provider,
 		PublicURL:  publicURL,
+		Status:     StatusReady,
 		UploadedAt: time.Now(),
 	}
 }
+
+// SpriteAsset identifies one of the generated video preview files.
+type SpriteAsset string
+
+const (
+	SpriteAssetImage SpriteAsset = "sprite.jpg"
+	SpriteAssetVTT   SpriteAsset = "sprite.vtt"
+)
+
+// ThumbnailSuffix is appended to the key of an image or video to form the key of its
+// thumbnail or poster.
+const ThumbnailSuffix = ".thumb.jpg"
+
+// HasThumbnail reports whether a thumbnail has been generated for the media.
+func (m *Media) HasThumbnail() bool {
+	return m.ThumbnailPath != ""
+}
+
+// TranscodeStatus tracks the transcoding of a video into its web-friendly renditions.
+type TranscodeStatus string
+
+const (
+	TranscodePending    TranscodeStatus = "pending"    // Queued for a transcoding worker
+	TranscodeProcessing TranscodeStatus = "processing" // Being transcoded
+	TranscodeDone       TranscodeStatus = "done"       // Renditions are stored
+	TranscodeFailed     TranscodeStatus = "failed"     // Transcoding failed; see TranscodeError
+)
+
+// Names of the transcoded renditions, also used in their URLs. HLS segments are named
+// HLSSegmentPrefix plus a number and the .ts extension.
+const (
+	TranscodedVideoName = "video.mp4"
+	HLSPlaylistName     = "index.m3u8"
+	HLSSegmentPrefix    = "seg_"
+)
+
+// TranscodedKeyFor returns the key of the MP4 rendition of the video at filePath.
+func TranscodedKeyFor(filePath string) string {
+	return filePath + ".transcoded.mp4"
+}
+
+// HLSDirFor returns the key prefix, ending in a slash, of the HLS rendition of the video
+// at filePath.
+func HLSDirFor(filePath string) string {
+	return filePath + ".hls/"
+}
+
+// HasTranscoded reports whether an MP4 rendition has been stored for the media.
+func (m *Media) HasTranscoded() bool {
+	return m.TranscodedPath != ""
+}
+
+// HasHLS reports whether an HLS rendition has been stored for the media.
+func (m *Media) HasHLS() bool {
+	return m.HLSPath != ""
+}
+
+// HasSprite reports whether preview sprite assets have been generated for the media.
+func (m *Media) HasSprite() bool {
+	return m.SpritePath != "" && m.SpriteVTTPath != ""
+}
+
+// DerivedKeys returns the keys of the objects generated from the media (sprites,
+// thumbnail, transcoded renditions and resized variants), which are stored next to it in the same provider.
+func (m *Media) DerivedKeys() []string {
+	var keys []string
+	if m.HasSprite() {
+		keys = append(keys, m.SpritePath, m.SpriteVTTPath)
+	}
+	if m.HasThumbnail() {
+		keys = append(keys, m.ThumbnailPath)
+	}
+	if m.HasTranscoded() {
+		keys = append(keys, m.TranscodedPath)
+	}
+	if m.HasHLS() {
+		keys = append(append(keys, m.HLSPath), m.HLSSegmentKeys...)
+	}
+	return append(keys, m.ResizedKeys...)
+}
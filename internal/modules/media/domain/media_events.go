@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+)
+
+// Storage events published to subscribers such as webhooks.
+const (
+	EventMediaUploaded = "media.uploaded" // A file was stored and its media record created
+	EventMediaDeleted  = "media.deleted"  // A file and its media record were deleted
+	EventUploadFailed  = "upload.failed"  // An upload was rejected or could not be stored
+)
+
+// Events lists every event type that can be subscribed to.
+var Events = []string{EventMediaUploaded, EventMediaDeleted, EventUploadFailed}
+
+// MediaEvent is the data of media.uploaded and media.deleted events.
+type MediaEvent struct {
+	MediaID     uuid.UUID  `json:"media_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	FolderID    *uuid.UUID `json:"folder_id,omitempty"`
+	FileName    string     `json:"file_name"`
+	FilePath    string     `json:"file_path"`
+	FileSize    int64      `json:"file_size"`
+	MediaType   string     `json:"media_type"`
+	ContentType string     `json:"content_type,omitempty"`
+	Provider    string     `json:"provider"`
+	SHA256      string     `json:"sha256,omitempty"`
+}
+
+// NewMediaEvent returns the event data describing media.
+func NewMediaEvent(media *Media) *MediaEvent {
+	return &MediaEvent{
+		MediaID:     media.ID,
+		UserID:      media.UserID,
+		FolderID:    media.FolderID,
+		FileName:    media.FileName,
+		FilePath:    media.FilePath,
+		FileSize:    media.FileSize,
+		MediaType:   media.MediaType,
+		ContentType: media.ContentType,
+		Provider:    media.Provider,
+		SHA256:      media.SHA256,
+	}
+}
+
+// UploadFailedEvent is the data of upload.failed events.
+type UploadFailedEvent struct {
+	UserID   uuid.UUID `json:"user_id"`
+	FileName string    `json:"file_name"`
+	FileSize int64     `json:"file_size"`
+	Provider string    `json:"provider,omitempty"` // Provider the upload was meant for; empty for the default
+	Error    string    `json:"error"`
+}
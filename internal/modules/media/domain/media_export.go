@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+// ExportFormat is the serialisation used when exporting a media catalog.
+type ExportFormat string
+
+const (
+	ExportFormatCSV   ExportFormat = "csv"
+	ExportFormatJSONL ExportFormat = "jsonl"
+)
+
+// IsValid reports whether the export format is supported.
+func (f ExportFormat) IsValid() bool {
+	return f == ExportFormatCSV || f == ExportFormatJSONL
+}
+
+// MediaExportRecord is one row of a media catalog export.
+type MediaExportRecord struct {
+	ID          string    `json:"id"`
+	FileName    string    `json:"file_name"`
+	MediaType   string    `json:"media_type"`
+	ContentType string    `json:"content_type,omitempty"`
+	FileSize    int64     `json:"file_size"`
+	Provider    string    `json:"provider"`
+	CreatedAt   time.Time `json:"created_at"`
+	Hash        string    `json:"hash,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	SignedURL   string    `json:"signed_url,omitempty"`
+}
+
+// MediaExportCSVHeader lists the CSV columns in the order written by CSVRow.
+var MediaExportCSVHeader = []string{"id", "file_name", "media_type", "content_type", "file_size", "provider", "created_at", "hash", "url", "signed_url"}
+
+// CSVRow renders the record in MediaExportCSVHeader order.
+func (r *MediaExportRecord) CSVRow() []string {
+	return []string{
+		r.ID,
+		r.FileName,
+		r.MediaType,
+		r.ContentType,
+		strconv.FormatInt(r.FileSize, 10),
+		r.Provider,
+		r.CreatedAt.UTC().Format(time.RFC3339),
+		r.Hash,
+		r.URL,
+		r.SignedURL,
+	}
+}
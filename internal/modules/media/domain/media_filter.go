@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MediaSortField is a field media listings can be ordered by.
+type MediaSortField string
+
+const (
+	MediaSortCreatedAt MediaSortField = "created_at"
+	MediaSortSize      MediaSortField = "size"
+	MediaSortName      MediaSortField = "name"
+)
+
+// Column returns the media column the field orders by.
+func (f MediaSortField) Column() string {
+	switch f {
+	case MediaSortSize:
+		return "file_size"
+	case MediaSortName:
+		return "file_name"
+	default:
+		return "created_at"
+	}
+}
+
+// MediaListFilter narrows and orders a media listing. Empty fields do not filter.
+type MediaListFilter struct {
+	Folder      *uuid.UUID // Only media in this folder; uuid.Nil selects media outside any folder
+	MediaType   string
+	Provider    string
+	CreatedFrom *time.Time // Inclusive
+	CreatedTo   *time.Time // Exclusive
+	Query       string     // Case-insensitive substring of the file name
+	Tags        []string   // Only media carrying all of these normalized tags
+	Sort        MediaSortField
+	Descending  bool
+}
+
+// ParseMediaSort parses a sort parameter of the form field[:asc|desc]. An empty value
+// sorts by created_at, newest first; a field without a direction sorts ascending.
+func ParseMediaSort(value string) (MediaSortField, bool, error) {
+	if value == "" {
+		return MediaSortCreatedAt, true, nil
+	}
+
+	name, direction, _ := strings.Cut(value, ":")
+	field := MediaSortField(name)
+	switch field {
+	case MediaSortCreatedAt, MediaSortSize, MediaSortName:
+	default:
+		return "", false, fmt.Errorf("sort field must be created_at, size or name")
+	}
+
+	switch direction {
+	case "", "asc":
+		return field, false, nil
+	case "desc":
+		return field, true, nil
+	default:
+		return "", false, fmt.Errorf("sort direction must be asc or desc")
+	}
+}
+
+// MediaCursor is the position of the last media of a page listed by cursor, in
+// (created_at, id) order. Clients get it as an opaque token.
+type MediaCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        uuid.UUID `json:"i"`
+}
+
+// NewMediaCursor returns the token of the position just after media.
+func NewMediaCursor(media *Media) string {
+	data, _ := json.Marshal(MediaCursor{CreatedAt: media.CreatedAt, ID: media.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseMediaCursor decodes a token returned by NewMediaCursor.
+func ParseMediaCursor(token string) (*MediaCursor, error) {
+	var cursor MediaCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.CreatedAt.IsZero() || cursor.ID == uuid.Nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &cursor, nil
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "strings"
+
 // MediaType represents the type of media.
 type MediaType string
 
@@ -31,6 +33,24 @@ const (
 	MediaTypeDOCX MediaType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
 )
 
+// Category returns the coarse kind of media t describes, as used in storage keys and
+// Media.MediaType: "image", "video", "audio" or "document". It is empty for types that
+// are not any of these, such as archives and executables.
+func (t MediaType) Category() string {
+	switch {
+	case strings.HasPrefix(string(t), "image/"):
+		return "image"
+	case strings.HasPrefix(string(t), "video/"):
+		return "video"
+	case strings.HasPrefix(string(t), "audio/"):
+		return "audio"
+	case strings.HasPrefix(string(t), "text/"), t == MediaTypePDF, t == MediaTypeDOC, t == MediaTypeDOCX:
+		return "document"
+	default:
+		return ""
+	}
+}
+
 // SupportedImageExtensions lists all supported image file extensions.
 var SupportedImageExtensions = map[string]MediaType{
 	".jpg":  MediaTypeJPEG,
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UsageGroup is the storage used by the media files sharing one media type or provider.
+type UsageGroup struct {
+	Name      string `json:"name"`
+	Bytes     int64  `json:"bytes"`
+	FileCount int64  `json:"file_count"`
+}
+
+// MediaUsage reports the storage a user's media files take up, largest groups first,
+// next to the user's quota. Quota fields are zero, and RemainingBytes is omitted, when
+// the user has no limit.
+type MediaUsage struct {
+	UserID      uuid.UUID    `json:"user_id"`
+	TotalBytes  int64        `json:"total_bytes"`
+	FileCount   int64        `json:"file_count"`
+	ByMediaType []UsageGroup `json:"by_media_type"`
+	ByProvider  []UsageGroup `json:"by_provider"`
+
+	QuotaBytes         int64  `json:"quota_bytes"`
+	UsedQuotaBytes     int64  `json:"used_quota_bytes"` // Bytes counted against the quota
+	RemainingBytes     *int64 `json:"remaining_bytes,omitempty"`
+	MaxFilesPerDay     int    `json:"max_files_per_day"`
+	FilesUploadedToday int    `json:"files_uploaded_today"`
+
+	ComputedAt time.Time `json:"computed_at"`
+}
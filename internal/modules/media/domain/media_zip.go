@@ -0,0 +1,35 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ZipManifestName is the name of the archive entry listing what a ZIP download contains.
+// Media files never take this name.
+const ZipManifestName = "manifest.json"
+
+// ZipDownloadRequest lists media files to download as one ZIP archive.
+type ZipDownloadRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// MediaZip is a validated ZIP download: the media to include, in request order, with
+// the unique entry name each gets in the archive.
+type MediaZip struct {
+	UserID     uuid.UUID
+	Entries    []MediaZipEntry
+	Missing    []uuid.UUID // Requested IDs with no media file of the caller
+	TotalBytes int64
+}
+
+// MediaZipEntry is one media file of a ZIP download.
+type MediaZipEntry struct {
+	Media *Media
+	Name  string
+}
+
+// ZipManifestEntry describes a media file written to a ZIP download. Files that could
+// not be included are reported as failed manifest items instead.
+type ZipManifestEntry struct {
+	MediaID uuid.UUID `json:"media_id"`
+	Name    string    `json:"name"` // Entry name in the archive
+	Bytes   int64     `json:"bytes"`
+}
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MetadataRefreshStatus describes the outcome of refreshing one media row.
+type MetadataRefreshStatus string
+
+const (
+	MetadataRefreshUpdated   MetadataRefreshStatus = "updated"   // Row changed to match the provider
+	MetadataRefreshUnchanged MetadataRefreshStatus = "unchanged" // Row already matched the provider
+	MetadataRefreshMissing   MetadataRefreshStatus = "missing"   // Object no longer exists in the provider
+	MetadataRefreshNotFound  MetadataRefreshStatus = "not_found" // No media row with this ID
+	MetadataRefreshFailed    MetadataRefreshStatus = "failed"    // Provider or database error
+)
+
+// MetadataRefreshResult reports what happened to a single media row during a refresh.
+type MetadataRefreshResult struct {
+	MediaID      uuid.UUID             `json:"media_id"`
+	Status       MetadataRefreshStatus `json:"status"`
+	FileSize     int64                 `json:"file_size,omitempty"`
+	ContentType  string                `json:"content_type,omitempty"`
+	ETag         string                `json:"etag,omitempty"`
+	LastModified *time.Time            `json:"last_modified,omitempty"`
+	Error        string                `json:"error,omitempty"`
+}
@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// StartMigrationRequest asks for a user's media to be moved from one provider to another.
+type StartMigrationRequest struct {
+	UserID       uuid.UUID `json:"user_id"`
+	FromProvider string    `json:"from_provider"` // Backend or alias the media is stored in now
+	ToProvider   string    `json:"to_provider"`
+}
+
+// MigratedMedia describes one media file moved by a migration.
+type MigratedMedia struct {
+	MediaID  uuid.UUID `json:"media_id"`
+	FilePath string    `json:"file_path"` // Key in the destination provider
+	Bytes    int64     `json:"bytes"`
+}
+
+// MigrationReport is the progress and outcome of a media migration job. Results hold
+// one item per file attempted so far; files that fail stay in the source provider and
+// are picked up again by the next migration between the same providers.
+type MigrationReport struct {
+	JobID        uuid.UUID `json:"job_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	FromProvider string    `json:"from_provider"`
+	ToProvider   string    `json:"to_provider"`
+	utils.JobProgress
+	BytesMoved int64                             `json:"bytes_moved"`
+	Results    *utils.BatchResult[MigratedMedia] `json:"results"`
+}
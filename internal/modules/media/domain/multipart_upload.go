@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InitiateMultipartUploadRequest describes a file the client is about to upload in parts.
+type InitiateMultipartUploadRequest struct {
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size"`
+	ContentType string `json:"content_type,omitempty"` // Defaults to the type implied by the file extension
+	MediaType   string `json:"media_type,omitempty"`   // Media type hint; derived from the content type when empty
+	Provider    string `json:"provider,omitempty"`     // Provider or alias; must support multipart uploads
+	PartSize    int64  `json:"part_size,omitempty"`    // Bytes per part (default 8 MiB, min 5 MiB)
+}
+
+// UploadedPart is a part of a multipart upload already accepted by the provider.
+type UploadedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+	Size       int64  `json:"size"`
+}
+
+// MultipartUpload tracks an in-progress multipart upload so an interrupted client can resume it.
+type MultipartUpload struct {
+	ID           uuid.UUID      `json:"id"`
+	UserID       uuid.UUID      `json:"user_id"`
+	Provider     string         `json:"provider"`
+	Key          string         `json:"key"`
+	UploadID     string         `json:"upload_id"` // Upload ID issued by the provider
+	FileName     string         `json:"file_name"`
+	ContentType  string         `json:"content_type"`
+	MediaType    string         `json:"media_type"`
+	FileSize     int64          `json:"file_size"`
+	PartSize     int64          `json:"part_size"`
+	TotalParts   int            `json:"total_parts"`
+	Parts        []UploadedPart `json:"parts"`
+	MissingParts []int          `json:"missing_parts"` // Filled in by RefreshProgress for API responses
+	CreatedAt    time.Time      `json:"created_at"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+}
+
+// PartLength returns the expected size of a part; only the last part may be shorter than PartSize.
+func (u *MultipartUpload) PartLength(partNumber int) int64 {
+	if partNumber == u.TotalParts {
+		return u.FileSize - int64(u.TotalParts-1)*u.PartSize
+	}
+	return u.PartSize
+}
+
+// PendingParts returns the part numbers that have not been uploaded yet, in ascending order.
+func (u *MultipartUpload) PendingParts() []int {
+	uploaded := make(map[int]bool, len(u.Parts))
+	for _, part := range u.Parts {
+		uploaded[part.PartNumber] = true
+	}
+	missing := make([]int, 0, u.TotalParts-len(uploaded))
+	for n := 1; n <= u.TotalParts; n++ {
+		if !uploaded[n] {
+			missing = append(missing, n)
+		}
+	}
+	return missing
+}
+
+// RefreshProgress orders Parts by part number, as providers require when completing
+// an upload, and recomputes MissingParts.
+func (u *MultipartUpload) RefreshProgress() {
+	sort.Slice(u.Parts, func(i, j int) bool { return u.Parts[i].PartNumber < u.Parts[j].PartNumber })
+	u.MissingParts = u.PendingParts()
+}
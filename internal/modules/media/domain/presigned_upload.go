@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Upload methods for presigned uploads.
+const (
+	PresignMethodPut  = "PUT"  // Single PUT of the raw file
+	PresignMethodPost = "POST" // HTML form upload restricted by a signed policy
+)
+
+// PresignUploadRequest describes a file the client will upload directly to the storage provider.
+type PresignUploadRequest struct {
+	FileName    string `json:"file_name"`
+	FileSize    int64  `json:"file_size,omitempty"`     // Expected size; replaced by the stored size on confirmation
+	ContentType string `json:"content_type,omitempty"`  // Defaults to the type implied by the file extension
+	MediaType   string `json:"media_type,omitempty"`    // Media type hint; derived from the content type when empty
+	Provider    string `json:"provider,omitempty"`      // Provider or alias; must support presigned uploads
+	ExpiresIn   int    `json:"expires_in,omitempty"`    // URL validity in seconds (default 900)
+	Method      string `json:"method,omitempty"`        // PUT (default) or POST
+	MaxFileSize int64  `json:"max_file_size,omitempty"` // POST only: largest upload the provider will accept
+}
+
+// PresignedUpload tells the client how to upload the file directly to the provider, and
+// identifies the pending media record to confirm once the upload has finished.
+type PresignedUpload struct {
+	MediaID   uuid.UUID         `json:"media_id"`
+	Method    string            `json:"method"`
+	URL       string            `json:"url"`
+	Headers   map[string]string `json:"headers,omitempty"` // PUT: headers the client must send with the upload
+	Fields    map[string]string `json:"fields,omitempty"`  // POST: form fields to send before the "file" field
+	ExpiresAt time.Time         `json:"expires_at"`
+}
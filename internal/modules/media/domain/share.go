@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareLinkPrefix is the path under which share links are served, outside the versioned API.
+const ShareLinkPrefix = "/s/"
+
+// MediaShare is a link that grants anyone holding its token access to one media file,
+// until it expires, runs out of downloads or is revoked.
+type MediaShare struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;"`
+	Token         string     `json:"token" gorm:"type:varchar(64);uniqueIndex"`
+	MediaID       uuid.UUID  `json:"media_id" gorm:"type:uuid;index"`
+	UserID        uuid.UUID  `json:"user_id" gorm:"type:uuid;index"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	MaxDownloads  *int       `json:"max_downloads,omitempty"` // Unlimited when empty
+	DownloadCount int        `json:"download_count" gorm:"default:0"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	// Path of the share link, relative to the server root
+	URL string `json:"url" gorm:"-"`
+}
+
+// TableName specifies the table name for the MediaShare model.
+func (MediaShare) TableName() string {
+	return "media_shares"
+}
+
+// Usable reports whether the share still grants access at now.
+func (s *MediaShare) Usable(now time.Time) bool {
+	if s.RevokedAt != nil || !now.Before(s.ExpiresAt) {
+		return false
+	}
+	return s.MaxDownloads == nil || s.DownloadCount < *s.MaxDownloads
+}
+
+// CreateShareRequest describes a new share link.
+type CreateShareRequest struct {
+	ExpiresIn    int  `json:"expires_in,omitempty"`    // Seconds the link stays valid (default from media.share)
+	MaxDownloads *int `json:"max_downloads,omitempty"` // Downloads allowed before the link stops working; unlimited when empty
+}
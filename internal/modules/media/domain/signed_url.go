@@ -0,0 +1,9 @@
+package domain
+
+import "time"
+
+// SignedURL is a time-limited URL granting access to a media object.
+type SignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
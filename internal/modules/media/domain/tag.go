@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// MaxTagLength is the longest tag accepted, in characters.
+const MaxTagLength = 64
+
+// Tag is a label a user attaches to media. Names are unique per user and stored lowercase.
+type Tag struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;uniqueIndex:idx_tags_user_name"`
+	Name      string    `json:"name" gorm:"type:varchar(64);uniqueIndex:idx_tags_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the Tag model.
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// MediaTag links a media file to a tag. The primary key serves lookups by media, and the
+// (tag_id, media_id) index serves filtering media by tag.
+type MediaTag struct {
+	MediaID uuid.UUID `gorm:"type:uuid;primaryKey;index:idx_media_tags_tag_media,priority:2"`
+	TagID   uuid.UUID `gorm:"type:uuid;primaryKey;index:idx_media_tags_tag_media,priority:1"`
+}
+
+// TableName specifies the table name for the MediaTag model.
+func (MediaTag) TableName() string {
+	return "media_tags"
+}
+
+// AddTagsRequest lists tags to attach to a media file.
+type AddTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// NormalizeTag trims and lowercases a tag name and checks that it is usable.
+// Commas are rejected because tag filters are comma-separated.
+func NormalizeTag(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "":
+		return "", fmt.Errorf("tag must not be empty")
+	case utf8.RuneCountInString(name) > MaxTagLength:
+		return "", fmt.Errorf("tag must be at most %d characters", MaxTagLength)
+	case strings.Contains(name, ","):
+		return "", fmt.Errorf("tag must not contain commas")
+	}
+	return name, nil
+}
+
+// NormalizeTags normalizes each name and drops duplicates, keeping the first occurrence.
+func NormalizeTags(names []string) ([]string, error) {
+	tags := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		tag, err := NormalizeTag(name)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TusVersion is the version of the tus resumable upload protocol the server speaks.
+const TusVersion = "1.0.0"
+
+// CreateTusUploadRequest describes a file announced with a tus creation request.
+// The names are taken from the Upload-Length header and the Upload-Metadata pairs.
+type CreateTusUploadRequest struct {
+	Length      int64  // Upload-Length
+	FileName    string // filename metadata
+	ContentType string // filetype metadata; defaults to the type implied by the file extension
+	MediaType   string // mediatype metadata; derived from the content type when empty
+	Provider    string // provider metadata; provider or alias the file is stored in (default local)
+}
+
+// TusUpload tracks a tus upload. Received bytes are kept in a temporary file until
+// Offset reaches Length, when the file is stored in Provider and MediaID is set.
+type TusUpload struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Key         string     `json:"key"` // Object key in Provider
+	FileName    string     `json:"file_name"`
+	ContentType string     `json:"content_type"`
+	MediaType   string     `json:"media_type"`
+	Length      int64      `json:"length"`
+	Offset      int64      `json:"offset"`
+	MediaID     *uuid.UUID `json:"media_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+}
+
+// Complete reports whether every byte has been received.
+func (u *TusUpload) Complete() bool {
+	return u.Offset == u.Length
+}
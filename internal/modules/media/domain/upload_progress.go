@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// UploadProgress reports how much of an upload has reached the storage provider. Status
+// is running until the upload completes, or cancelled once it is aborted.
+type UploadProgress struct {
+	UploadID     uuid.UUID       `json:"upload_id"`
+	Status       utils.JobStatus `json:"status"`
+	BytesWritten int64           `json:"bytes_written"`
+	Total        int64           `json:"total"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
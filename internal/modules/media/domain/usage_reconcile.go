@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// UsageRecalculation is the outcome of recounting one user's storage usage from their
+// stored media. DriftBytes is how far the recorded usage was off: positive when the
+// user had been charged for more than they store.
+type UsageRecalculation struct {
+	UserID        uuid.UUID `json:"user_id"`
+	PreviousBytes int64     `json:"previous_bytes"`
+	UsedBytes     int64     `json:"used_bytes"`
+	DriftBytes    int64     `json:"drift_bytes"`
+}
+
+// UsageReconcileReport is the progress and outcome of a job recounting every user's
+// storage usage. Results only hold the users whose usage was corrected and those that
+// failed; users already in line just count as processed. A cancelled or failed job is
+// resumed by the next one after LastUserID, the end of its last finished batch.
+type UsageReconcileReport struct {
+	JobID        uuid.UUID  `json:"job_id"`
+	Scheduled    bool       `json:"scheduled"`
+	ResumedAfter *uuid.UUID `json:"resumed_after,omitempty"`
+	LastUserID   *uuid.UUID `json:"last_user_id,omitempty"`
+	utils.JobProgress
+	Results *utils.BatchResult[UsageRecalculation] `json:"results"`
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// rootFolderParam selects top-level folders, or media outside any folder, in folder query parameters.
+const rootFolderParam = "root"
+
+// CreateFolder godoc
+// @Summary Create a media folder
+// @Description Create a folder for organizing media, at the top level or inside another folder
+// @Tags Media Folders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.CreateFolderRequest true "Folder name and optional parent"
+// @Success 201 {object} domain.Folder "Created folder"
+// @Failure 409 {object} errors.Error "A folder with this name already exists in the parent"
+// @Failure default {object} errors.Error
+// @Router /media/folders [post]
+func (h *MediaHandler) CreateFolder(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	var req domain.CreateFolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse create folder request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	folder, err := h.mediaService.CreateFolder(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(folder)
+}
+
+// ListFolders godoc
+// @Summary List media folders
+// @Description List the authenticated user's folders, optionally only the children of one folder
+// @Tags Media Folders
+// @Produce json
+// @Security BearerAuth
+// @Param parent_id query string false "Only children of this folder; 'root' for top-level folders"
+// @Success 200 {array} domain.Folder "Folders ordered by name"
+// @Failure default {object} errors.Error
+// @Router /media/folders [get]
+func (h *MediaHandler) ListFolders(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	parent, err := parseFolderQuery(c.Query("parent_id"))
+	if err != nil {
+		return errors.NewBadRequestError("parent_id must be a folder ID or 'root'")
+	}
+
+	folders, err := h.mediaService.ListFolders(c.Context(), userID, parent)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(folders)
+}
+
+// GetFolder godoc
+// @Summary Get a media folder
+// @Tags Media Folders
+// @Produce json
+// @Security BearerAuth
+// @Param folderId path string true "Folder ID"
+// @Success 200 {object} domain.Folder "Folder"
+// @Failure default {object} errors.Error
+// @Router /media/folders/{folderId} [get]
+func (h *MediaHandler) GetFolder(c *fiber.Ctx) error {
+	userID, folderID, err := h.folderParams(c)
+	if err != nil {
+		return err
+	}
+
+	folder, err := h.mediaService.GetFolder(c.Context(), userID, folderID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(folder)
+}
+
+// RenameFolder godoc
+// @Summary Rename a media folder
+// @Tags Media Folders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param folderId path string true "Folder ID"
+// @Param request body domain.RenameFolderRequest true "New name"
+// @Success 200 {object} domain.Folder "Renamed folder"
+// @Failure 409 {object} errors.Error "A folder with this name already exists in the parent"
+// @Failure default {object} errors.Error
+// @Router /media/folders/{folderId} [put]
+func (h *MediaHandler) RenameFolder(c *fiber.Ctx) error {
+	userID, folderID, err := h.folderParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.RenameFolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse rename folder request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	folder, err := h.mediaService.RenameFolder(c.Context(), userID, folderID, &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(folder)
+}
+
+// MoveFolder godoc
+// @Summary Move a media folder
+// @Description Move a folder, with everything in it, under another folder or to the top level. A folder cannot be moved into itself or one of its subfolders.
+// @Tags Media Folders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param folderId path string true "Folder ID"
+// @Param request body domain.MoveFolderRequest true "New parent; null for the top level"
+// @Success 200 {object} domain.Folder "Moved folder"
+// @Failure 400 {object} errors.Error "The move would create a cycle"
+// @Failure 409 {object} errors.Error "A folder with this name already exists in the new parent"
+// @Failure default {object} errors.Error
+// @Router /media/folders/{folderId}/move [post]
+func (h *MediaHandler) MoveFolder(c *fiber.Ctx) error {
+	userID, folderID, err := h.folderParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.MoveFolderRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse move folder request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	folder, err := h.mediaService.MoveFolder(c.Context(), userID, folderID, &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(folder)
+}
+
+// DeleteFolder godoc
+// @Summary Delete a media folder
+// @Description Delete a folder. A folder that still holds subfolders or media is only deleted with force=true, which also deletes its contents; otherwise the response is 409 with the counts in details. dry_run=true reports what would be deleted without deleting anything.
+// @Tags Media Folders
+// @Produce json
+// @Security BearerAuth
+// @Param folderId path string true "Folder ID"
+// @Param force query bool false "Delete the folder together with its subfolders and media"
+// @Param dry_run query bool false "Only report what would be deleted"
+// @Success 200 {object} domain.FolderDeleteSummary "What was, or would be, deleted"
+// @Failure 409 {object} errors.Error "Folder is not empty"
+// @Failure default {object} errors.Error
+// @Router /media/folders/{folderId} [delete]
+func (h *MediaHandler) DeleteFolder(c *fiber.Ctx) error {
+	userID, folderID, err := h.folderParams(c)
+	if err != nil {
+		return err
+	}
+
+	summary, err := h.mediaService.DeleteFolder(c.Context(), userID, folderID, c.QueryBool("force"), c.QueryBool("dry_run"))
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(summary)
+}
+
+// MoveMedia godoc
+// @Summary Move a media file into a folder
+// @Tags Media Folders
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param request body domain.MoveMediaRequest true "Target folder; null to take the file out of its folder"
+// @Success 200 {object} domain.Media "Moved media file"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/folder [put]
+func (h *MediaHandler) MoveMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	var req domain.MoveMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse move media request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	media, err := h.mediaService.MoveMedia(c.Context(), userID, mediaID, &req)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(media)
+}
+
+// folderParams extracts the caller and the folder ID path parameter.
+func (h *MediaHandler) folderParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return uuid.Nil, uuid.Nil, err
+	}
+	folderID, err := uuid.Parse(c.Params("folderId"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid folder ID format", map[string]any{"folderID": c.Params("folderId")})
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidInput
+	}
+	return userID, folderID, nil
+}
+
+// parseFolderQuery reads a folder query parameter: nil when absent, uuid.Nil for 'root'.
+func parseFolderQuery(value string) (*uuid.UUID, error) {
+	switch value {
+	case "":
+		return nil, nil
+	case rootFolderParam:
+		id := uuid.Nil
+		return &id, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
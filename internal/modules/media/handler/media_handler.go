@@ -1,37 +1,89 @@
 package handler
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	logger "github.com/lugondev/go-log" // Import custom logger
 	"github.com/lugondev/m3-storage/internal/infra/config"
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
 	"github.com/lugondev/m3-storage/internal/modules/media/domain"
 	"github.com/lugondev/m3-storage/internal/modules/media/port"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
 	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
 	"github.com/lugondev/m3-storage/internal/shared/errors"
 	"github.com/lugondev/m3-storage/internal/shared/utils"
 )
 
-var _ domain.Media
-
 type MediaHandler struct {
 	logger       logger.Logger
 	mediaService port.MediaService
+	auditService appPort.AuditService
+	uploadGate   storagePort.UploadGate
 	config       *config.Config
 }
 
-// NewMediaHandler creates a new MediaHandler.
-func NewMediaHandler(appLogger logger.Logger, mediaService port.MediaService, cfg *config.Config) *MediaHandler {
+// NewMediaHandler creates a new MediaHandler. Uploads and deletes are recorded through auditService.
+func NewMediaHandler(appLogger logger.Logger, mediaService port.MediaService, auditService appPort.AuditService, uploadGate storagePort.UploadGate, cfg *config.Config) *MediaHandler {
 	return &MediaHandler{
 		logger:       appLogger.WithFields(map[string]any{"component": "MediaHandler"}),
 		mediaService: mediaService,
+		auditService: auditService,
+		uploadGate:   uploadGate,
 		config:       cfg,
 	}
 }
 
+// audit records an action of the user on one of their media files in the audit log.
+func (h *MediaHandler) audit(c *fiber.Ctx, userID uuid.UUID, action appDomain.ActionType, mediaID uuid.UUID, metadata map[string]any) {
+	h.auditService.Record(middleware.AuditContext(c), userID, action, appDomain.ResourceTypeMedia, mediaID.String(), metadata)
+}
+
+// auditUpload records a finished upload of media; method tells which upload API was used.
+func (h *MediaHandler) auditUpload(c *fiber.Ctx, userID uuid.UUID, media *domain.Media, method string) {
+	h.audit(c, userID, appDomain.ActionTypeCreate, media.ID, map[string]any{
+		"method": method, "file_name": media.FileName, "file_size": media.FileSize, "provider": media.Provider,
+	})
+}
+
+// healthOverrideHeader lets a client upload to a provider the health gate reports as down,
+// e.g. to confirm it has recovered.
+const healthOverrideHeader = "X-Health-Override"
+
+// checkUploadGate rejects the upload with 503 and Retry-After when the target
+// provider is known to be unhealthy.
+func (h *MediaHandler) checkUploadGate(c *fiber.Ctx, providerName string) error {
+	if h.uploadGate == nil {
+		return nil
+	}
+	if override, _ := strconv.ParseBool(c.Get(healthOverrideHeader)); override {
+		return nil
+	}
+
+	allowed, retryAfter := h.uploadGate.AllowUpload(providerName)
+	if allowed {
+		return nil
+	}
+
+	h.logger.Warn(c.Context(), "Rejecting upload to unhealthy provider", map[string]any{"providerName": providerName})
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(retryAfter.Seconds())))
+	return errors.NewError(http.StatusServiceUnavailable, "provider_unavailable", "Storage provider is currently unavailable, please retry later")
+}
+
 // UploadFile godoc
 // @Summary Upload a file
 // @Description Upload a file to the specified provider with optional media type hint
@@ -40,8 +92,13 @@ func NewMediaHandler(appLogger logger.Logger, mediaService port.MediaService, cf
 // @Produce json
 // @Security BearerAuth
 // @Param file formData file true "File to upload"
-// @Param provider formData string false "Storage provider (e.g., s3, azure, firebase, discord). If not specified, default provider will be used."
-// @Param media_type formData string false "Media type hint (e.g., image/jpeg, video/mp4). If not specified, it will be determined from the file."
+// @Param provider formData string false "Storage backend or configured alias (e.g., s3, azure, s3-archive, primary); the default backend of a provider type is named after it. If not specified, default provider will be used."
+// @Param provider query string false "Storage backend or alias; when set here, uploads to an unhealthy backend are rejected before the body is read"
+// @Param X-Health-Override header bool false "Upload even if the provider health gate reports the provider as unhealthy"
+// @Param media_type formData string false "Expected media type, as a category (image, video, audio, document) or content type (e.g. image/jpeg). The type is always determined from the file content; a hint that does not match it is rejected with 400."
+// @Success 200 {object} domain.Media "Uploaded media; content_type is the type detected from the file content"
+// @Failure 403 {object} errors.Error "Storage quota or daily file limit exceeded"
+// @Failure 503 {object} errors.Error "Provider is unhealthy; see Retry-After"
 // @Failure default {object} errors.Error
 // @Router /media/upload [post]
 func (h *MediaHandler) UploadFile(c *fiber.Ctx) error {
@@ -53,6 +110,14 @@ func (h *MediaHandler) UploadFile(c *fiber.Ctx) error {
 
 	h.logger.Info(c.Context(), "Handling file upload request", map[string]any{"userID": userID.String()})
 
+	// A provider in the query string is gated before the multipart body is read.
+	queryProvider := c.Query("provider")
+	if queryProvider != "" {
+		if err := h.checkUploadGate(c, queryProvider); err != nil {
+			return err
+		}
+	}
+
 	// 1. Get file from form
 	fileHeader, err := c.FormFile("file")
 	if err != nil {
@@ -64,7 +129,15 @@ func (h *MediaHandler) UploadFile(c *fiber.Ctx) error {
 
 	// 2. Get optional provider and media_type from form
 	providerName := c.FormValue("provider")    // Empty if not provided, service will use default
-	mediaTypeHint := c.FormValue("media_type") // Empty if not provided, service will attempt to determine
+	mediaTypeHint := c.FormValue("media_type") // Empty if not provided; the service checks it against the content
+	if providerName == "" {
+		providerName = queryProvider
+	}
+	if queryProvider == "" || providerName != queryProvider {
+		if err := h.checkUploadGate(c, providerName); err != nil {
+			return err
+		}
+	}
 
 	h.logger.Info(c.Context(), "Upload parameters", map[string]any{
 		"fileName":      fileHeader.Filename,
@@ -78,226 +151,1317 @@ func (h *MediaHandler) UploadFile(c *fiber.Ctx) error {
 	mediaEntity, err := h.mediaService.UploadFile(c.Context(), userID, fileHeader, providerName, mediaTypeHint)
 	if err != nil {
 		h.logger.Error(c.Context(), "Failed to upload file via media service", map[string]any{"error": err})
-		// Consider more specific error handling based on err type if needed
+		// Client errors (e.g. rejected content) carry their own status
+		if _, ok := err.(*errors.Error); ok {
+			return err
+		}
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": fmt.Sprintf("Failed to upload file: %v", err),
 		})
 	}
 
 	h.logger.Info(c.Context(), "File uploaded successfully", map[string]any{"mediaID": mediaEntity.ID.String(), "publicURL": mediaEntity.PublicURL})
+	h.auditUpload(c, userID, mediaEntity, "direct")
 
 	// 4. Return the public URL or other relevant metadata
 	return c.Status(http.StatusOK).JSON(mediaEntity)
 }
 
-// ListMedia godoc
-// @Summary List media files for the authenticated user with pagination
-// @Description Get a paginated list of media files owned by the authenticated user
+// BatchUploadFiles godoc
+// @Summary Upload several files at once
+// @Description Upload up to 50 files, sent as repeated "file" parts, to one provider. The batch must fit the storage quota and daily file limit as a whole; after that each file succeeds or fails on its own. Results are in request order with the file name as id.
 // @Tags Media
+// @Accept multipart/form-data
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "Page number (default: 1)"
-// @Param page_size query int false "Number of items per page (default: 10, max: 100)"
-// @Success 200 {object} map[string]interface{} "Paginated list of media files"
+// @Param file formData file true "Files to upload; repeat the part for each file"
+// @Param provider formData string false "Storage backend or configured alias. If not specified, default provider will be used."
+// @Param provider query string false "Storage backend or alias; when set here, uploads to an unhealthy backend are rejected before the body is read"
+// @Param X-Health-Override header bool false "Upload even if the provider health gate reports the provider as unhealthy"
+// @Param media_type formData string false "Expected media type of every file, as a category or content type; a file whose content does not match fails"
+// @Success 200 {object} utils.BatchResult[domain.Media] "Per-file results"
+// @Failure 403 {object} errors.Error "Storage quota or daily file limit exceeded by the batch"
+// @Failure 503 {object} errors.Error "Provider is unhealthy; see Retry-After"
 // @Failure default {object} errors.Error
-// @Router /media [get]
-func (h *MediaHandler) ListMedia(c *fiber.Ctx) error {
+// @Router /media/upload/batch [post]
+func (h *MediaHandler) BatchUploadFiles(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
 		return err
 	}
 
-	// Parse pagination query
-	paginationQuery := &utils.PaginationQuery{}
-	if err = c.QueryParser(paginationQuery); err != nil {
-		h.logger.Warn(c.Context(), "Failed to parse pagination query", map[string]any{"error": err})
-		return errors.ErrInvalidInput
+	// A provider in the query string is gated before the multipart body is read.
+	queryProvider := c.Query("provider")
+	if queryProvider != "" {
+		if err := h.checkUploadGate(c, queryProvider); err != nil {
+			return err
+		}
 	}
 
-	// Get paginated media files
-	pagination, mediaFiles, err := h.mediaService.ListMedia(c.Context(), userID, paginationQuery)
+	form, err := c.MultipartForm()
 	if err != nil {
-		h.logger.Error(c.Context(), "Failed to list media files", map[string]any{"error": err})
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Failed to list media files: %v", err),
-		})
+		h.logger.Warn(c.Context(), "Failed to parse batch upload form", map[string]any{"error": err})
+		return errors.NewBadRequestError("request must be multipart/form-data with one or more file parts")
+	}
+	providerName := c.FormValue("provider")
+	mediaTypeHint := c.FormValue("media_type")
+	if providerName == "" {
+		providerName = queryProvider
+	}
+	if queryProvider == "" || providerName != queryProvider {
+		if err := h.checkUploadGate(c, providerName); err != nil {
+			return err
+		}
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"pagination": pagination,
-		"data":       mediaFiles,
-	})
+	result, err := h.mediaService.BatchUploadFiles(c.Context(), userID, form.File["file"], providerName, mediaTypeHint)
+	if err != nil {
+		return err
+	}
+	for _, item := range result.Results {
+		if item.Data != nil {
+			h.auditUpload(c, userID, *item.Data, "batch")
+		}
+	}
+	return c.Status(http.StatusOK).JSON(result)
 }
 
-// GetMedia godoc
-// @Summary Get a specific media file
-// @Description Get details of a specific media file by ID
+// PresignUpload godoc
+// @Summary Get a presigned upload URL
+// @Description Get a URL the client can upload the file to directly, bypassing this server. With method PUT (s3, minio, azure, firebase) the raw file is PUT with the returned headers. With method POST (s3, minio) the browser submits a multipart form with the returned fields followed by a "file" field; the provider enforces max_file_size and the content type. A pending media record is created; call POST /media/{id}/confirm once the upload has finished.
 // @Tags Media
+// @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path string true "Media ID"
-// @Success 200 {object} domain.Media "Media file details"
+// @Param request body domain.PresignUploadRequest true "File to upload"
+// @Param X-Health-Override header bool false "Upload even if the provider health gate reports the provider as unhealthy"
+// @Success 201 {object} domain.PresignedUpload "Upload URL, method and required headers or form fields"
+// @Failure 403 {object} errors.Error "Storage quota or daily file limit exceeded"
+// @Failure 503 {object} errors.Error "Provider is unhealthy; see Retry-After"
 // @Failure default {object} errors.Error
-// @Router /media/{id} [get]
-func (h *MediaHandler) GetMedia(c *fiber.Ctx) error {
+// @Router /media/upload/presign [post]
+func (h *MediaHandler) PresignUpload(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
 		return err
 	}
 
-	// Parse media ID from path parameter
-	mediaID, err := uuid.Parse(c.Params("id"))
-	if err != nil {
-		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+	var req domain.PresignUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse presign upload request", map[string]any{"error": err})
 		return errors.ErrInvalidInput
 	}
+	if err := h.checkUploadGate(c, req.Provider); err != nil {
+		return err
+	}
 
-	// Get the media file
-	media, err := h.mediaService.GetMedia(c.Context(), userID, mediaID)
+	presigned, err := h.mediaService.PresignUpload(c.Context(), userID, &req)
 	if err != nil {
-		if err.Error() == "media file not found" {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": "Media file not found",
-			})
-		}
-		h.logger.Error(c.Context(), "Failed to get media file", map[string]any{"error": err})
 		return err
 	}
-
-	return c.Status(http.StatusOK).JSON(media)
+	return c.Status(http.StatusCreated).JSON(presigned)
 }
 
-// DeleteMedia godoc
-// @Summary Delete a specific media file
-// @Description Delete a specific media file by ID
+// ConfirmUpload godoc
+// @Summary Confirm a presigned upload
+// @Description Check that the object for a pending media record exists at the provider, record its size and ETag, and mark it ready
 // @Tags Media
+// @Produce json
 // @Security BearerAuth
 // @Param id path string true "Media ID"
-// @Success 200 {object} map[string]string "Media file deleted successfully"
+// @Success 200 {object} domain.Media "Uploaded media"
+// @Failure 409 {object} errors.Error "The object has not been uploaded yet"
 // @Failure default {object} errors.Error
-// @Router /media/{id} [delete]
-func (h *MediaHandler) DeleteMedia(c *fiber.Ctx) error {
+// @Router /media/{id}/confirm [post]
+func (h *MediaHandler) ConfirmUpload(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
 		return err
 	}
 
-	// Parse media ID from path parameter
 	mediaID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid media ID format",
-		})
+		return errors.ErrInvalidInput
 	}
 
-	// Delete the media file
-	if err := h.mediaService.DeleteMedia(c.Context(), userID, mediaID); err != nil {
-		if err.Error() == "media file not found" {
+	media, err := h.mediaService.ConfirmUpload(c.Context(), userID, mediaID)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
 			return c.Status(http.StatusNotFound).JSON(fiber.Map{
 				"error": "Media file not found",
 			})
 		}
-		h.logger.Error(c.Context(), "Failed to delete media file", map[string]any{"error": err})
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Failed to delete media file: %v", err),
-		})
+		return err
+	}
+	h.auditUpload(c, userID, media, "presigned")
+	return c.Status(http.StatusOK).JSON(media)
+}
+
+// InitiateMultipartUpload godoc
+// @Summary Start a multipart upload
+// @Description Start a resumable upload of a large file in parts (default 8 MiB, min 5 MiB). Upload each part with PUT .../parts/{partNumber}, then call complete. Only providers with multipart support (s3, minio and S3-compatible) are accepted.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.InitiateMultipartUploadRequest true "File to upload"
+// @Param X-Health-Override header bool false "Upload even if the provider health gate reports the provider as unhealthy"
+// @Success 201 {object} domain.MultipartUpload "Upload session with part size and part count"
+// @Failure 403 {object} errors.Error "Storage quota or daily file limit exceeded"
+// @Failure 503 {object} errors.Error "Provider is unhealthy; see Retry-After"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart [post]
+func (h *MediaHandler) InitiateMultipartUpload(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
 	}
 
-	return c.Status(http.StatusOK).JSON(fiber.Map{
-		"message": "Media file deleted successfully",
-	})
+	var req domain.InitiateMultipartUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse multipart upload request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+	if err := h.checkUploadGate(c, req.Provider); err != nil {
+		return err
+	}
+
+	upload, err := h.mediaService.InitiateMultipartUpload(c.Context(), userID, &req)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusCreated).JSON(upload)
 }
 
-// ServeLocalFile godoc
-// @Summary Serve a local media file
-// @Description Serve a local media file by ID for authenticated users
+// ListMultipartUploads godoc
+// @Summary List unfinished multipart uploads
+// @Description List the authenticated user's multipart uploads that can still be resumed
 // @Tags Media
-// @Produce application/octet-stream
+// @Produce json
 // @Security BearerAuth
-// @Param id path string true "Media ID"
-// @Success 200 {file} file "Media file content"
-// @Failure 404 {object} fiber.Map "Media file not found"
-// @Failure 403 {object} fiber.Map "Access denied"
-// @Failure 500 {object} fiber.Map "Internal server error"
-// @Router /media/{id}/file [get]
-func (h *MediaHandler) ServeLocalFile(c *fiber.Ctx) error {
+// @Success 200 {array} domain.MultipartUpload "Unfinished uploads"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart [get]
+func (h *MediaHandler) ListMultipartUploads(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
 		return err
 	}
 
-	// Parse media ID from path parameter
-	mediaID, err := uuid.Parse(c.Params("id"))
+	uploads, err := h.mediaService.ListMultipartUploads(c.Context(), userID)
 	if err != nil {
-		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
-		return errors.ErrInvalidInput
+		return err
 	}
+	return c.Status(http.StatusOK).JSON(uploads)
+}
 
-	// Get the media file
-	media, err := h.mediaService.GetMedia(c.Context(), userID, mediaID)
+// GetMultipartUpload godoc
+// @Summary Get a multipart upload
+// @Description Get the state of a multipart upload, including uploaded and missing parts, to resume it
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param uploadId path string true "Multipart upload ID"
+// @Success 200 {object} domain.MultipartUpload "Upload session"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart/{uploadId} [get]
+func (h *MediaHandler) GetMultipartUpload(c *fiber.Ctx) error {
+	userID, uploadID, err := h.multipartUploadParams(c)
 	if err != nil {
-		if err.Error() == "media file not found" {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": "Media file not found",
-			})
-		}
-		h.logger.Error(c.Context(), "Failed to get media file", map[string]any{"error": err})
 		return err
 	}
 
-	// Check if this is a local storage file
-	if media.Provider != "local" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "This endpoint only serves local storage files",
-		})
+	upload, err := h.mediaService.GetMultipartUpload(c.Context(), userID, uploadID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(upload)
+}
+
+// UploadPart godoc
+// @Summary Upload one part of a multipart upload
+// @Description Upload the raw bytes of one part. Every part except the last must be exactly part_size bytes. Re-uploading a part replaces it.
+// @Tags Media
+// @Accept application/octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Param uploadId path string true "Multipart upload ID"
+// @Param partNumber path int true "Part number, starting at 1"
+// @Success 200 {object} domain.UploadedPart "Uploaded part"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart/{uploadId}/parts/{partNumber} [put]
+func (h *MediaHandler) UploadPart(c *fiber.Ctx) error {
+	userID, uploadID, err := h.multipartUploadParams(c)
+	if err != nil {
+		return err
+	}
+	partNumber, err := strconv.Atoi(c.Params("partNumber"))
+	if err != nil {
+		return errors.NewBadRequestError("part number must be an integer")
 	}
 
-	// Serve the file directly from the local file system
-	return c.SendFile(h.config.LocalStorage.Path + "/" + media.FilePath)
+	size := int64(c.Request().Header.ContentLength())
+	if size <= 0 {
+		return errors.NewBadRequestError("Content-Length is required")
+	}
+	// Large bodies are streamed by the server; smaller ones are already buffered.
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	part, err := h.mediaService.UploadPart(c.Context(), userID, uploadID, partNumber, body, size)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(part)
 }
 
-// ServePublicLocalFile godoc
-// @Summary Serve a public local media file
-// @Description Serve a local media file without authentication
+// CompleteMultipartUpload godoc
+// @Summary Complete a multipart upload
+// @Description Assemble the uploaded parts into the final file and create its media record
 // @Tags Media
-// @Produce application/octet-stream
-// @Param id path string true "Media ID"
-// @Success 200 {file} file "Media file content"
-// @Failure 404 {object} fiber.Map "Media file not found"
-// @Failure 500 {object} fiber.Map "Internal server error"
-// @Router /media/public/{id}/file [get]
-func (h *MediaHandler) ServePublicLocalFile(c *fiber.Ctx) error {
-	// Parse media ID from path parameter
-	mediaID, err := uuid.Parse(c.Params("id"))
+// @Produce json
+// @Security BearerAuth
+// @Param uploadId path string true "Multipart upload ID"
+// @Success 200 {object} domain.Media "Uploaded media"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart/{uploadId}/complete [post]
+func (h *MediaHandler) CompleteMultipartUpload(c *fiber.Ctx) error {
+	userID, uploadID, err := h.multipartUploadParams(c)
 	if err != nil {
-		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
-		return errors.ErrInvalidInput
+		return err
 	}
 
-	// Get the media file without user authentication
-	media, err := h.mediaService.GetPublicMedia(c.Context(), mediaID)
+	mediaEntity, err := h.mediaService.CompleteMultipartUpload(c.Context(), userID, uploadID)
 	if err != nil {
-		if err.Error() == "media file not found" {
-			return c.Status(http.StatusNotFound).JSON(fiber.Map{
-				"error": "Media file not found",
-			})
-		}
-		h.logger.Error(c.Context(), "Failed to get public media file", map[string]any{"error": err})
 		return err
 	}
+	h.auditUpload(c, userID, mediaEntity, "multipart")
+	return c.Status(http.StatusOK).JSON(mediaEntity)
+}
 
-	// Check if this is a local storage file
-	if media.Provider != "local" {
-		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
-			"error": "This endpoint only serves local storage files",
+// AbortMultipartUpload godoc
+// @Summary Abort a multipart upload
+// @Description Cancel a multipart upload and discard its uploaded parts
+// @Tags Media
+// @Security BearerAuth
+// @Param uploadId path string true "Multipart upload ID"
+// @Success 200 {object} map[string]string "Multipart upload aborted"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart/{uploadId} [delete]
+func (h *MediaHandler) AbortMultipartUpload(c *fiber.Ctx) error {
+	userID, uploadID, err := h.multipartUploadParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.mediaService.AbortMultipartUpload(c.Context(), userID, uploadID); err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "Multipart upload aborted",
+	})
+}
+
+// multipartUploadParams extracts the caller and the upload ID path parameter.
+func (h *MediaHandler) multipartUploadParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return uuid.Nil, uuid.Nil, err
+	}
+	uploadID, err := uuid.Parse(c.Params("uploadId"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid multipart upload ID format", map[string]any{"uploadID": c.Params("uploadId")})
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidInput
+	}
+	return userID, uploadID, nil
+}
+
+// ListMedia godoc
+// @Summary List media files for the authenticated user with pagination
+// @Description Get a paginated list of media files owned by the authenticated user. Pages are numbered by default; passing cursor (empty for the first page) switches to cursor pagination, which returns next_cursor for the following page instead of page counts and does not skip or repeat files while others are uploaded. Cursor pagination requires sorting by created_at.
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number (default: 1)"
+// @Param cursor query string false "next_cursor of the previous page, or empty for the first page, to paginate by cursor"
+// @Param page_size query int false "Number of items per page (default: 10, max: 100)"
+// @Param media_type query string false "Only media of this type, e.g. image, video, document"
+// @Param provider query string false "Only media stored in this backend, e.g. local, s3, s3-archive"
+// @Param created_from query string false "Only media created at or after this time (RFC 3339 or YYYY-MM-DD)"
+// @Param created_to query string false "Only media created before this time (RFC 3339, or YYYY-MM-DD to include that whole day)"
+// @Param folder_id query string false "Only media in this folder; 'root' for media outside any folder"
+// @Param q query string false "Only media whose file name contains this text (case-insensitive)"
+// @Param tags query string false "Only media carrying all of these comma-separated tags"
+// @Param sort query string false "Order as field[:asc|desc] with field created_at, size or name (default: created_at:desc)"
+// @Success 200 {object} map[string]interface{} "Paginated list of media files"
+// @Failure 400 {object} errors.Error "Malformed filter"
+// @Failure default {object} errors.Error
+// @Router /media [get]
+func (h *MediaHandler) ListMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	filter, err := parseMediaListFilter(c)
+	if err != nil {
+		return err
+	}
+
+	if c.Context().QueryArgs().Has("cursor") {
+		return h.listMediaByCursor(c, userID, filter)
+	}
+
+	// Parse pagination query
+	paginationQuery := &utils.PaginationQuery{}
+	if err = c.QueryParser(paginationQuery); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse pagination query", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	// Get paginated media files
+	pagination, mediaFiles, err := h.mediaService.ListMedia(c.Context(), userID, paginationQuery, filter)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to list media files", map[string]any{"error": err})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to list media files: %v", err),
 		})
 	}
 
-	// Serve the file directly from the local file system
-	return c.SendFile(h.config.LocalStorage.Path + "/" + media.FilePath)
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"pagination": pagination,
+		"data":       mediaFiles,
+	})
+}
+
+// listMediaByCursor serves ListMedia in cursor pagination mode
+func (h *MediaHandler) listMediaByCursor(c *fiber.Ctx, userID uuid.UUID, filter *domain.MediaListFilter) error {
+	cursorQuery := &utils.CursorQuery{}
+	if err := c.QueryParser(cursorQuery); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse cursor query", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	pagination, mediaFiles, err := h.mediaService.ListMediaByCursor(c.Context(), userID, cursorQuery, filter)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to list media files", map[string]any{"error": err})
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"pagination": pagination,
+		"data":       mediaFiles,
+	})
+}
+
+// parseMediaListFilter reads the filter and sort query parameters of ListMedia
+func parseMediaListFilter(c *fiber.Ctx) (*domain.MediaListFilter, error) {
+	filter := &domain.MediaListFilter{
+		MediaType: c.Query("media_type"),
+		Provider:  c.Query("provider"),
+		Query:     c.Query("q"),
+	}
+
+	var err error
+	if filter.Folder, err = parseFolderQuery(c.Query("folder_id")); err != nil {
+		return nil, errors.NewBadRequestError("folder_id must be a folder ID or 'root'")
+	}
+	if tags := c.Query("tags"); tags != "" {
+		if filter.Tags, err = domain.NormalizeTags(strings.Split(tags, ",")); err != nil {
+			return nil, errors.NewBadRequestError("tags: " + err.Error())
+		}
+	}
+	if filter.Sort, filter.Descending, err = domain.ParseMediaSort(c.Query("sort")); err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+	if filter.CreatedFrom, err = utils.ParseFilterTime(c.Query("created_from"), false); err != nil {
+		return nil, errors.NewBadRequestError("created_from must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.CreatedTo, err = utils.ParseFilterTime(c.Query("created_to"), true); err != nil {
+		return nil, errors.NewBadRequestError("created_to must be an RFC 3339 time or a YYYY-MM-DD date")
+	}
+	if filter.CreatedFrom != nil && filter.CreatedTo != nil && !filter.CreatedFrom.Before(*filter.CreatedTo) {
+		return nil, errors.NewBadRequestError("created_from must be before created_to")
+	}
+	return filter, nil
+}
+
+// GetMediaUsage godoc
+// @Summary Get storage usage
+// @Description Get the total size and count of the authenticated user's media files, broken down by media type and by provider (largest first), with the user's quota and remaining space. Reports are cached for up to 30 seconds; computed_at tells when it was computed.
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} domain.MediaUsage "Storage usage"
+// @Failure default {object} errors.Error
+// @Router /media/usage [get]
+func (h *MediaHandler) GetMediaUsage(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	usage, err := h.mediaService.GetMediaUsage(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(usage)
+}
+
+// ExportMedia godoc
+// @Summary Export the media catalog
+// @Description Stream all media records owned by the authenticated user as CSV or JSON lines (id, file name, type, size, provider, created_at, hash, URL)
+// @Tags Media
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "Export format: csv or jsonl (default: csv)"
+// @Param signed query bool false "Include time-limited signed URLs"
+// @Success 200 {file} file "Media catalog export"
+// @Failure default {object} errors.Error
+// @Router /media/export [get]
+func (h *MediaHandler) ExportMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	format := domain.ExportFormat(strings.ToLower(c.Query("format", string(domain.ExportFormatCSV))))
+	if format == "json" {
+		format = domain.ExportFormatJSONL
+	}
+	if !format.IsValid() {
+		return errors.NewBadRequestError("format must be csv or jsonl")
+	}
+	withSignedURLs := c.QueryBool("signed", false)
+
+	contentType := "text/csv; charset=utf-8"
+	if format == domain.ExportFormatJSONL {
+		contentType = "application/x-ndjson"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="media-export-%s.%s"`, time.Now().Format("20060102"), format))
+
+	// The body is written after the handler returns, so the request context must not be used inside.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+		if err := h.mediaService.ExportMedia(ctx, userID, format, withSignedURLs, w); err != nil {
+			h.logger.Error(ctx, "Media export aborted", map[string]any{"error": err, "userID": userID.String()})
+		}
+		if err := w.Flush(); err != nil {
+			h.logger.Warn(ctx, "Failed to flush media export", map[string]any{"error": err})
+		}
+	})
+	return nil
+}
+
+// DownloadZip godoc
+// @Summary Download several media files as a ZIP archive
+// @Description Stream a ZIP archive of the given media files, named by their file names (repeated names are numbered). The archive is assembled while it is sent. Files that cannot be downloaded are left out, and a closing manifest.json entry lists the outcome for every requested ID. The number of files and their combined size are capped by media.zipDownload.
+// @Tags Media
+// @Accept json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param request body domain.ZipDownloadRequest true "Media IDs to include"
+// @Success 200 {file} file "ZIP archive"
+// @Failure 400 {object} errors.Error "Too many files or too large in total"
+// @Failure default {object} errors.Error
+// @Router /media/download-zip [post]
+func (h *MediaHandler) DownloadZip(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	var req domain.ZipDownloadRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse ZIP download request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	archive, err := h.mediaService.PrepareMediaZip(c.Context(), userID, req.IDs)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="media-%s.zip"`, time.Now().Format("20060102-150405")))
+
+	// The body is written after the handler returns, so the request context must not be used inside.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+		if err := h.mediaService.WriteMediaZip(ctx, archive, w); err != nil {
+			h.logger.Error(ctx, "ZIP download aborted", map[string]any{"error": err, "userID": userID.String()})
+		}
+		if err := w.Flush(); err != nil {
+			h.logger.Warn(ctx, "Failed to flush ZIP download", map[string]any{"error": err})
+		}
+	})
+	return nil
+}
+
+// GetMedia godoc
+// @Summary Get a specific media file
+// @Description Get details of a specific media file by ID
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param signed query bool false "Include a fresh signed_url, for media in private buckets"
+// @Param expiry query int false "Validity of signed_url in seconds; defaults to and is capped by the provider's signedURL config (1h and 7 days unless configured)"
+// @Success 200 {object} domain.Media "Media file details"
+// @Failure default {object} errors.Error
+// @Router /media/{id} [get]
+func (h *MediaHandler) GetMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	// Parse media ID from path parameter
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	signed := c.QueryBool("signed", false)
+	expiry := c.QueryInt("expiry") // 0 uses the provider's default
+	if signed && expiry < 0 {
+		return errors.NewBadRequestError("expiry must be a positive number of seconds")
+	}
+
+	// Get the media file
+	media, err := h.mediaService.GetMedia(c.Context(), userID, mediaID)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get media file", map[string]any{"error": err})
+		return err
+	}
+
+	// A provider that cannot sign (e.g. Telegram) should not hide the rest of the details
+	if signed {
+		if err := h.mediaService.SignMedia(c.Context(), media, time.Duration(expiry)*time.Second); err != nil {
+			h.logger.Warn(c.Context(), "Failed to sign media URL", map[string]any{"error": err, "mediaID": mediaID.String()})
+		}
+	}
+
+	return c.Status(http.StatusOK).JSON(media)
+}
+
+// UpdateMedia godoc
+// @Summary Update a media file's metadata
+// @Description Rename a media file or change its description. Only the record changes; the stored object and its URLs stay the same.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param request body domain.UpdateMediaRequest true "Fields to change"
+// @Success 200 {object} domain.Media "Updated media file"
+// @Failure 400 {object} errors.Error "Invalid file name or description"
+// @Failure default {object} errors.Error
+// @Router /media/{id} [patch]
+func (h *MediaHandler) UpdateMedia(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.UpdateMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse update media request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	media, err := h.mediaService.UpdateMedia(c.Context(), userID, mediaID, &req)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(media)
+}
+
+// DeleteMedia godoc
+// @Summary Delete a specific media file
+// @Description Delete a specific media file by ID
+// @Tags Media
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Success 200 {object} map[string]string "Media file deleted successfully"
+// @Failure default {object} errors.Error
+// @Router /media/{id} [delete]
+func (h *MediaHandler) DeleteMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	// Parse media ID from path parameter
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid media ID format",
+		})
+	}
+
+	// Delete the media file
+	if err := h.mediaService.DeleteMedia(c.Context(), userID, mediaID); err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to delete media file", map[string]any{"error": err})
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to delete media file: %v", err),
+		})
+	}
+	h.audit(c, userID, appDomain.ActionTypeDelete, mediaID, nil)
+
+	return c.Status(http.StatusOK).JSON(fiber.Map{
+		"message": "Media file deleted successfully",
+	})
+}
+
+// maxBatchDeleteIDs caps how many media files a single batch delete may remove.
+const maxBatchDeleteIDs = 100
+
+// BatchDeleteMedia godoc
+// @Summary Delete several media files
+// @Description Delete up to 100 media files in one request. Each ID gets its own result; IDs that are not found or whose object cannot be removed are reported as failed items without stopping the others.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.BatchDeleteRequest true "Media IDs to delete"
+// @Success 200 {object} utils.BatchResult[domain.MediaDeleteResult] "Per-item delete results with a success/failure summary"
+// @Failure default {object} errors.Error
+// @Router /media/batch-delete [post]
+func (h *MediaHandler) BatchDeleteMedia(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	var req domain.BatchDeleteRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse batch delete request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+	if len(req.IDs) == 0 {
+		return errors.NewBadRequestError("ids is required")
+	}
+	if len(req.IDs) > maxBatchDeleteIDs {
+		return errors.NewBadRequestError(fmt.Sprintf("at most %d media files can be deleted per request", maxBatchDeleteIDs))
+	}
+
+	result, err := h.mediaService.BatchDeleteMedia(c.Context(), userID, req.IDs)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to delete media batch", map[string]any{"error": err})
+		return err
+	}
+	for _, item := range result.Results {
+		if item.Data != nil {
+			h.audit(c, userID, appDomain.ActionTypeDelete, item.Data.MediaID, map[string]any{"method": "batch"})
+		}
+	}
+
+	// Mixed outcomes are still a 200; clients read per-item status from the results.
+	return c.Status(http.StatusOK).JSON(result)
+}
+
+// GetSignedURL godoc
+// @Summary Get a signed URL for a media file
+// @Description Get a time-limited URL for a media file. Validity longer than the provider's maximum is shortened to it. URLs for the same file and expiry are reused for a few minutes, so expires_at may be slightly earlier than now + expires_in.
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param expires_in query int false "URL validity in seconds; defaults to and is capped by the provider's signedURL config (1h and 7 days unless configured)"
+// @Success 200 {object} domain.SignedURL "Signed URL"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/signed-url [get]
+func (h *MediaHandler) GetSignedURL(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	expiresIn := c.QueryInt("expires_in") // 0 uses the provider's default
+	if expiresIn < 0 {
+		return errors.NewBadRequestError("expires_in must be a positive number of seconds")
+	}
+
+	signed, err := h.mediaService.GetSignedURL(c.Context(), userID, mediaID, time.Duration(expiresIn)*time.Second)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get signed URL", map[string]any{"error": err})
+		return err
+	}
+
+	return c.Status(http.StatusOK).JSON(signed)
+}
+
+// DownloadMedia godoc
+// @Summary Download a media file
+// @Description Stream a media file from whichever provider stores it, so clients never see the provider URL. Honors a single-range Range header and If-None-Match against the ETag.
+// @Tags Media
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param inline query bool false "Send Content-Disposition: inline instead of attachment"
+// @Param Range header string false "Byte range, e.g. bytes=0-1048575"
+// @Param If-None-Match header string false "Answer 304 if the ETag matches"
+// @Success 200 {file} file "Media file content"
+// @Success 206 {file} file "Requested byte range"
+// @Success 304 "Not modified"
+// @Failure 404 {object} fiber.Map "Media file not found"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/download [get]
+func (h *MediaHandler) DownloadMedia(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	media, err := h.mediaService.GetMedia(c.Context(), userID, mediaID)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+
+	contentType := media.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	if c.QueryBool("inline", false) {
+		disposition = "inline"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": media.FileName}))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	if notModified(c, media) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
+	if c.Get(fiber.HeaderRange) == "" || media.FileSize <= 0 {
+		reader, size, err := h.mediaService.DownloadMedia(c.Context(), media)
+		if err != nil {
+			return h.tagError(c, err)
+		}
+		// SendStream closes the reader once the body has been written
+		return c.Status(http.StatusOK).SendStream(reader, int(size))
+	}
+
+	ranges, err := c.Range(int(media.FileSize))
+	if err != nil || ranges.Type != "bytes" {
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", media.FileSize))
+		return errors.NewError(http.StatusRequestedRangeNotSatisfiable, "range_not_satisfiable", "Requested range is not satisfiable")
+	}
+	// Only the first range is served; multipart/byteranges responses are not supported.
+	start, end := int64(ranges.Ranges[0].Start), int64(ranges.Ranges[0].End)
+	reader, err := h.mediaService.DownloadMediaRange(c.Context(), media, start, end)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, media.FileSize))
+	return c.Status(http.StatusPartialContent).SendStream(reader, int(end-start+1))
+}
+
+// ServeLocalFile godoc
+// @Summary Serve a local media file
+// @Description Serve a local media file by ID for authenticated users with its stored content type. Honors a single-range Range header, If-None-Match against the ETag, and If-Modified-Since.
+// @Tags Media
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param download query bool false "Send Content-Disposition: attachment instead of inline"
+// @Param Range header string false "Byte range, e.g. bytes=0-1048575"
+// @Param If-None-Match header string false "Answer 304 if the ETag matches"
+// @Param If-Modified-Since header string false "Answer 304 if the file has not changed since"
+// @Success 200 {file} file "Media file content"
+// @Success 206 {file} file "Requested byte range"
+// @Success 304 "Not modified"
+// @Failure 404 {object} fiber.Map "Media file not found"
+// @Failure 403 {object} fiber.Map "Access denied"
+// @Failure 500 {object} fiber.Map "Internal server error"
+// @Router /media/{id}/file [get]
+func (h *MediaHandler) ServeLocalFile(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	// Parse media ID from path parameter
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	// Get the media file
+	media, err := h.mediaService.GetMedia(c.Context(), userID, mediaID)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get media file", map[string]any{"error": err})
+		return err
+	}
+
+	// Check if this is a local storage file
+	if media.Provider != "local" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "This endpoint only serves local storage files",
+		})
+	}
+
+	return h.serveLocalMedia(c, media)
+}
+
+// ServePublicLocalFile godoc
+// @Summary Serve a public local media file
+// @Description Serve a local media file without authentication with its stored content type. Honors a single-range Range header, If-None-Match against the ETag, and If-Modified-Since.
+// @Tags Media
+// @Produce application/octet-stream
+// @Param id path string true "Media ID"
+// @Param download query bool false "Send Content-Disposition: attachment instead of inline"
+// @Param Range header string false "Byte range, e.g. bytes=0-1048575"
+// @Param If-None-Match header string false "Answer 304 if the ETag matches"
+// @Param If-Modified-Since header string false "Answer 304 if the file has not changed since"
+// @Success 200 {file} file "Media file content"
+// @Success 206 {file} file "Requested byte range"
+// @Success 304 "Not modified"
+// @Failure 404 {object} fiber.Map "Media file not found"
+// @Failure 500 {object} fiber.Map "Internal server error"
+// @Router /media/public/{id}/file [get]
+func (h *MediaHandler) ServePublicLocalFile(c *fiber.Ctx) error {
+	// Parse media ID from path parameter
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	// Get the media file without user authentication
+	media, err := h.mediaService.GetPublicMedia(c.Context(), mediaID)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get public media file", map[string]any{"error": err})
+		return err
+	}
+
+	// Check if this is a local storage file
+	if media.Provider != "local" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "This endpoint only serves local storage files",
+		})
+	}
+
+	return h.serveLocalMedia(c, media)
+}
+
+// ServeSignedLocalFile returns the handler for signed URLs issued by files, reading each
+// file through that provider
+// @Summary Serve a local file through a signed URL
+// @Description Serve a local storage file by key, as linked by the signed-url endpoint for local media. The URL's expires and signature are verified before the file is read; byte ranges are honored.
+// @Tags Media
+// @Produce application/octet-stream
+// @Param key path string true "Object key"
+// @Param expires query int true "Expiry as a Unix timestamp"
+// @Param signature query string true "URL signature"
+// @Success 200 {file} file "File content"
+// @Failure 403 {object} errors.Error "Signed URL expired or tampered with"
+// @Failure 404 {object} errors.Error "File not found"
+// @Router /files/{key} [get]
+func (h *MediaHandler) ServeSignedLocalFile(files storagePort.LocalFileServer) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := url.PathUnescape(c.Params("*"))
+		if err != nil {
+			return errors.ErrInvalidInput
+		}
+		path, err := files.LocalPath(key)
+		if err != nil {
+			return errors.NewNotFoundError("file not found")
+		}
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+		return c.SendFile(path)
+	}
+}
+
+// mediaETag returns the entity tag of a media file: the provider's ETag, or the content
+// hash for providers that do not report one. It is empty when neither is known.
+func mediaETag(media *domain.Media) string {
+	if tag := strings.Trim(strings.TrimPrefix(media.ETag, "W/"), `"`); tag != "" {
+		return `"` + tag + `"`
+	}
+	if media.SHA256 != "" {
+		return `"sha256-` + media.SHA256 + `"`
+	}
+	return ""
+}
+
+// notModified sets the ETag and Cache-Control headers for media and reports whether the
+// client's If-None-Match already matches, so the body can be skipped with a 304.
+// Responses are cached privately and revalidated on every use; an earlier Cache-Control,
+// such as the no-store of share links, is kept.
+func notModified(c *fiber.Ctx, media *domain.Media) bool {
+	if len(c.Response().Header.Peek(fiber.HeaderCacheControl)) == 0 {
+		c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	}
+	etag := mediaETag(media)
+	if etag == "" {
+		return false
+	}
+	c.Set(fiber.HeaderETag, etag)
+
+	for _, candidate := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// serveLocalMedia sends a local media file with its stored content type. A single-range
+// Range header is answered with 206 and only those bytes, so video elements can seek
+// without buffering the whole file; If-Modified-Since is checked against the file's
+// modification time. ?download=1 asks the browser to save the file instead of showing it.
+func (h *MediaHandler) serveLocalMedia(c *fiber.Ctx, media *domain.Media) error {
+	file, err := os.Open(filepath.Join(h.config.LocalStorage.Path, filepath.Clean("/"+media.FilePath)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to open local media file", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		h.logger.Error(c.Context(), "Failed to stat local media file", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return err
+	}
+	size := info.Size()
+	modTime := info.ModTime().UTC().Truncate(time.Second)
+
+	contentType := media.ContentType
+	if contentType == "" {
+		if contentType = mime.TypeByExtension(filepath.Ext(media.FileName)); contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	}
+	disposition := "inline"
+	if c.QueryBool("download", false) {
+		disposition = "attachment"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderContentDisposition, mime.FormatMediaType(disposition, map[string]string{"filename": media.FileName}))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderLastModified, modTime.Format(http.TimeFormat))
+
+	// If-None-Match takes precedence; If-Modified-Since only counts without it
+	if notModified(c, media) {
+		file.Close()
+		return c.SendStatus(http.StatusNotModified)
+	}
+	if c.Get(fiber.HeaderIfNoneMatch) == "" {
+		if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil && !modTime.After(since) {
+			file.Close()
+			return c.SendStatus(http.StatusNotModified)
+		}
+	}
+
+	if c.Get(fiber.HeaderRange) == "" {
+		return c.Status(http.StatusOK).SendStream(file, int(size))
+	}
+
+	ranges, err := c.Range(int(size))
+	if err != nil || ranges.Type != "bytes" {
+		file.Close()
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+		return errors.NewError(http.StatusRequestedRangeNotSatisfiable, "range_not_satisfiable", "Requested range is not satisfiable")
+	}
+	// Only the first range is served; multipart/byteranges responses are not supported.
+	start, end := int64(ranges.Ranges[0].Start), int64(ranges.Ranges[0].End)
+	length := end - start + 1
+
+	c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	// The stream is closed once sent, which closes the file
+	body := struct {
+		io.Reader
+		io.Closer
+	}{io.NewSectionReader(file, start, length), file}
+	return c.Status(http.StatusPartialContent).SendStream(body, int(length))
+}
+
+// ServeVideoSprite godoc
+// @Summary Serve a video thumbnail sprite
+// @Description Serve the generated scrubbing-preview sprite image for a video
+// @Tags Media
+// @Produce image/jpeg
+// @Param id path string true "Media ID"
+// @Success 200 {file} file "Sprite image"
+// @Failure default {object} errors.Error
+// @Router /media/public/{id}/sprite.jpg [get]
+func (h *MediaHandler) ServeVideoSprite(c *fiber.Ctx) error {
+	return h.serveSpriteAsset(c, domain.SpriteAssetImage)
+}
+
+// ServeVideoSpriteVTT godoc
+// @Summary Serve a video thumbnail WebVTT index
+// @Description Serve the WebVTT file mapping playback times to tiles in the video sprite
+// @Tags Media
+// @Produce text/vtt
+// @Param id path string true "Media ID"
+// @Success 200 {file} file "WebVTT index"
+// @Failure default {object} errors.Error
+// @Router /media/public/{id}/sprite.vtt [get]
+func (h *MediaHandler) ServeVideoSpriteVTT(c *fiber.Ctx) error {
+	return h.serveSpriteAsset(c, domain.SpriteAssetVTT)
+}
+
+// serveSpriteAsset streams a generated preview asset from the media's provider
+func (h *MediaHandler) serveSpriteAsset(c *fiber.Ctx, asset domain.SpriteAsset) error {
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	reader, contentType, err := h.mediaService.GetSpriteAsset(c.Context(), mediaID, asset)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get video sprite asset", map[string]any{"error": err})
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(reader)
+}
+
+// ServeThumbnail godoc
+// @Summary Serve an image thumbnail or video poster
+// @Description Serve the JPEG thumbnail of an image, or the poster frame of a video, owned by the authenticated user. A missing image thumbnail is generated on demand; video posters are extracted in the background after upload.
+// @Tags Media
+// @Produce image/jpeg
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Success 200 {file} file "Thumbnail image"
+// @Failure 404 {object} fiber.Map "Media file or thumbnail not found"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/thumbnail [get]
+func (h *MediaHandler) ServeThumbnail(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	reader, contentType, err := h.mediaService.GetThumbnail(c.Context(), userID, mediaID)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get thumbnail", map[string]any{"error": err})
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(reader)
+}
+
+// ServeRendition godoc
+// @Summary Serve a transcoded video rendition
+// @Description Serve a web-friendly rendition of a video owned by the authenticated user: video.mp4 (H.264/AAC), or index.m3u8 and its segments for HLS. Renditions exist once the video's transcode_status is done.
+// @Tags Media
+// @Produce video/mp4,application/vnd.apple.mpegurl,video/mp2t
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param name path string true "video.mp4, index.m3u8 or an HLS segment name"
+// @Success 200 {file} file "Rendition"
+// @Failure 404 {object} fiber.Map "Media file or rendition not found"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/renditions/{name} [get]
+func (h *MediaHandler) ServeRendition(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	reader, contentType, err := h.mediaService.GetRendition(c.Context(), userID, mediaID, c.Params("name"))
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to get video rendition", map[string]any{"error": err})
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(reader)
+}
+
+// ResizeImage godoc
+// @Summary Resize an image
+// @Description Serve a resized variant of an image owned by the authenticated user. Variants are cached in the image's provider, so repeated requests are not re-rendered. Images are never upscaled by contain.
+// @Tags Media
+// @Produce image/jpeg,image/png
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param w query int false "Width in pixels; follows the aspect ratio when omitted"
+// @Param h query int false "Height in pixels; follows the aspect ratio when omitted"
+// @Param fit query string false "contain (default) scales to fit inside w x h, cover crops to fill it" Enums(contain, cover)
+// @Param format query string false "Output format (default jpeg)" Enums(jpeg, png)
+// @Success 200 {file} file "Resized image"
+// @Failure 400 {object} errors.Error "Invalid dimensions, or the media is not an image"
+// @Failure 404 {object} fiber.Map "Media file not found"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/resize [get]
+func (h *MediaHandler) ResizeImage(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return errors.ErrInvalidInput
+	}
+
+	opts := domain.ResizeOptions{
+		Width:  c.QueryInt("w"),
+		Height: c.QueryInt("h"),
+		Fit:    domain.ImageFit(c.Query("fit")),
+		Format: domain.ImageFormat(c.Query("format")),
+	}
+
+	reader, contentType, err := h.mediaService.ResizeImage(c.Context(), userID, mediaID, opts)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "Media file not found",
+			})
+		}
+		h.logger.Error(c.Context(), "Failed to resize image", map[string]any{"error": err})
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.SendStream(reader)
+}
+
+// maxMetadataRefreshIDs caps how many media rows a single refresh request may touch.
+const maxMetadataRefreshIDs = 100
+
+// RefreshMetadata godoc
+// @Summary Refresh media metadata from storage providers
+// @Description Re-read size, content type, ETag and last-modified for each media ID from its storage provider and update the catalog. Each ID gets its own result; unknown IDs, missing objects and provider errors are reported as failed items without failing the request.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param ids query string true "Comma-separated media IDs (max 100)"
+// @Success 200 {object} utils.BatchResult[domain.MetadataRefreshResult] "Per-item refresh results with a success/failure summary"
+// @Failure default {object} errors.Error
+// @Router /admin/media/refresh-metadata [post]
+func (h *MediaHandler) RefreshMetadata(c *fiber.Ctx) error {
+	rawIDs := strings.Split(c.Query("ids"), ",")
+	mediaIDs := make([]uuid.UUID, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": raw})
+			return errors.NewBadRequestError(fmt.Sprintf("invalid media ID: %s", raw))
+		}
+		mediaIDs = append(mediaIDs, id)
+	}
+	if len(mediaIDs) == 0 {
+		return errors.NewBadRequestError("ids query parameter is required")
+	}
+	if len(mediaIDs) > maxMetadataRefreshIDs {
+		return errors.NewBadRequestError(fmt.Sprintf("at most %d media IDs can be refreshed per request", maxMetadataRefreshIDs))
+	}
+
+	result, err := h.mediaService.RefreshMetadata(c.Context(), mediaIDs)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to refresh media metadata", map[string]any{"error": err})
+		return err
+	}
+
+	// Mixed outcomes are still a 200; clients read per-item status from the results.
+	return c.Status(http.StatusOK).JSON(result)
 }
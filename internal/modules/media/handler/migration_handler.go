@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// StartMediaMigration godoc
+// @Summary Move a user's media to another storage provider
+// @Description Start a background job that copies every media file of the user from one provider to another, repoints the records and deletes the originals. Workers, batch size and bandwidth come from media.migration in the config. Files that fail stay in the source provider; starting the same migration again retries them.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body domain.StartMigrationRequest true "User and providers"
+// @Success 202 {object} domain.MigrationReport "Started job; poll its status for progress"
+// @Failure 409 {object} errors.Error "A migration of the same media is already running"
+// @Failure default {object} errors.Error
+// @Router /admin/media/migrations [post]
+func (h *MediaHandler) StartMediaMigration(c *fiber.Ctx) error {
+	var req domain.StartMigrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse migration request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+	if req.UserID == uuid.Nil || req.FromProvider == "" || req.ToProvider == "" {
+		return errors.NewBadRequestError("user_id, from_provider and to_provider are required")
+	}
+
+	report, err := h.mediaService.StartMediaMigration(c.Context(), req.UserID, req.FromProvider, req.ToProvider)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to start media migration", map[string]any{"error": err})
+		return err
+	}
+	return c.Status(http.StatusAccepted).JSON(report)
+}
+
+// GetMediaMigration godoc
+// @Summary Get the progress of a media migration
+// @Description Report processed/total, ETA and per-file results of a running or recently finished migration
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Migration job ID"
+// @Success 200 {object} domain.MigrationReport "Migration progress"
+// @Failure default {object} errors.Error
+// @Router /admin/media/migrations/{jobId} [get]
+func (h *MediaHandler) GetMediaMigration(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	report, err := h.mediaService.GetMediaMigration(c.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
+
+// CancelMediaMigration godoc
+// @Summary Cancel a media migration
+// @Description Stop a running migration. Files already moved stay moved; the rest stay in the source provider.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Migration job ID"
+// @Success 202 {object} domain.MigrationReport "Migration progress at the time of cancelling"
+// @Failure default {object} errors.Error
+// @Router /admin/media/migrations/{jobId} [delete]
+func (h *MediaHandler) CancelMediaMigration(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	report, err := h.mediaService.CancelMediaMigration(c.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusAccepted).JSON(report)
+}
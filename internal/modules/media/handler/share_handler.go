@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// shareRedirectExpiry is how long the provider URL a share link redirects to stays valid.
+// It only needs to outlive the redirect; every visit to the share link issues a new one.
+const shareRedirectExpiry = 5 * time.Minute
+
+// CreateMediaShare godoc
+// @Summary Create a public share link
+// @Description Create a link that serves the media file without authentication until it expires, reaches max_downloads or is revoked. It works for private buckets too.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param request body domain.CreateShareRequest false "Expiry and download limit"
+// @Success 201 {object} domain.MediaShare "Share link; url is relative to the server root"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/share [post]
+func (h *MediaHandler) CreateMediaShare(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.CreateShareRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			h.logger.Warn(c.Context(), "Failed to parse share request", map[string]any{"error": err})
+			return errors.ErrInvalidInput
+		}
+	}
+
+	share, err := h.mediaService.CreateMediaShare(c.Context(), userID, mediaID, &req)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	return c.Status(http.StatusCreated).JSON(share)
+}
+
+// ListMediaShares godoc
+// @Summary List share links of a media file
+// @Description List every share link of a media file, including revoked and expired ones, newest first.
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Success 200 {object} map[string][]domain.MediaShare "Share links"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/shares [get]
+func (h *MediaHandler) ListMediaShares(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	shares, err := h.mediaService.ListMediaShares(c.Context(), userID, mediaID)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"shares": shares})
+}
+
+// RevokeMediaShare godoc
+// @Summary Revoke a share link
+// @Description Stop a share link from working. The link stays listed with revoked_at set.
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param shareId path string true "Share ID"
+// @Success 200 {object} domain.MediaShare "Revoked share link"
+// @Failure 404 {object} errors.Error "Share link not found"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/shares/{shareId} [delete]
+func (h *MediaHandler) RevokeMediaShare(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+	shareID, err := uuid.Parse(c.Params("shareId"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid share ID format", map[string]any{"shareID": c.Params("shareId")})
+		return errors.ErrInvalidInput
+	}
+
+	share, err := h.mediaService.RevokeMediaShare(c.Context(), userID, mediaID, shareID)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(share)
+}
+
+// OpenMediaShare godoc
+// @Summary Open a share link
+// @Description Serve a shared media file without authentication. Each request counts as a download. The client is redirected to a short-lived provider URL; files whose provider cannot sign URLs are streamed instead.
+// @Tags Media
+// @Produce application/octet-stream
+// @Param token path string true "Share token"
+// @Success 200 {file} file "File content"
+// @Success 302 "Redirect to a signed provider URL"
+// @Failure 404 {object} errors.Error "Share link not found"
+// @Failure 410 {object} errors.Error "Share link expired, revoked or out of downloads"
+// @Router /s/{token} [get]
+func (h *MediaHandler) OpenMediaShare(c *fiber.Ctx) error {
+	media, err := h.mediaService.OpenMediaShare(c.Context(), c.Params("token"))
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	// The response depends on the share's remaining downloads, so it must not be reused
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if err := h.mediaService.SignMedia(c.Context(), media, shareRedirectExpiry); err == nil {
+		return c.Redirect(media.SignedURL, http.StatusFound)
+	}
+	if media.Provider == "local" {
+		return h.serveLocalMedia(c, media)
+	}
+
+	// Providers without signed URLs, e.g. Telegram whose file links embed the bot token
+	if media.ContentType != "" {
+		c.Set(fiber.HeaderContentType, media.ContentType)
+	}
+	if media.FileSize == 0 {
+		return c.SendStatus(http.StatusOK)
+	}
+	reader, err := h.mediaService.DownloadMediaRange(c.Context(), media, 0, media.FileSize-1)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to open shared media", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return err
+	}
+	return c.SendStream(reader, int(media.FileSize))
+}
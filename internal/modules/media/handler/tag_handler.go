@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// AddMediaTags godoc
+// @Summary Tag a media file
+// @Description Attach tags to a media file. Tags are per user, trimmed and lowercased; tags the file already has are ignored.
+// @Tags Media
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param request body domain.AddTagsRequest true "Tags to attach"
+// @Success 200 {object} map[string][]string "All tags of the media file"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/tags [post]
+func (h *MediaHandler) AddMediaTags(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req domain.AddTagsRequest
+	if err := c.BodyParser(&req); err != nil {
+		h.logger.Warn(c.Context(), "Failed to parse add tags request", map[string]any{"error": err})
+		return errors.ErrInvalidInput
+	}
+
+	tags, err := h.mediaService.AddMediaTags(c.Context(), userID, mediaID, &req)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"tags": tags})
+}
+
+// RemoveMediaTag godoc
+// @Summary Remove a tag from a media file
+// @Tags Media
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Media ID"
+// @Param tag path string true "Tag to remove"
+// @Success 200 {object} map[string][]string "Remaining tags of the media file"
+// @Failure 404 {object} errors.Error "Media file not found or does not have the tag"
+// @Failure default {object} errors.Error
+// @Router /media/{id}/tags/{tag} [delete]
+func (h *MediaHandler) RemoveMediaTag(c *fiber.Ctx) error {
+	userID, mediaID, err := h.mediaParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Path parameters arrive percent-encoded, and tags may contain spaces
+	tag, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	tags, err := h.mediaService.RemoveMediaTag(c.Context(), userID, mediaID, tag)
+	if err != nil {
+		return h.tagError(c, err)
+	}
+	return c.Status(http.StatusOK).JSON(fiber.Map{"tags": tags})
+}
+
+// mediaParams extracts the caller and the media ID path parameter.
+func (h *MediaHandler) mediaParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return uuid.Nil, uuid.Nil, err
+	}
+	mediaID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid media ID format", map[string]any{"mediaID": c.Params("id")})
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidInput
+	}
+	return userID, mediaID, nil
+}
+
+// tagError maps a missing media file to the usual 404 response.
+func (h *MediaHandler) tagError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, storagePort.ErrObjectNotFound) {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			"error": "Media file not found",
+		})
+	}
+	return err
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// Headers of the tus protocol, https://tus.io/protocols/resumable-upload
+const (
+	tusResumableHeader = "Tus-Resumable"
+	tusVersionHeader   = "Tus-Version"
+	tusExtensionHeader = "Tus-Extension"
+	tusMaxSizeHeader   = "Tus-Max-Size"
+	uploadLengthHeader = "Upload-Length"
+	uploadOffsetHeader = "Upload-Offset"
+	uploadMetaHeader   = "Upload-Metadata"
+	// mediaIDHeader carries the ID of the media created by the final PATCH of an upload
+	mediaIDHeader = "Media-Id"
+
+	tusOffsetContentType = "application/offset+octet-stream"
+)
+
+// TusOptions godoc
+// @Summary Discover tus upload support
+// @Description Report the tus protocol version, extensions and maximum upload size the server supports
+// @Tags Media
+// @Success 204 "Capabilities in the Tus-Version, Tus-Extension and Tus-Max-Size headers"
+// @Router /media/tus [options]
+func (h *MediaHandler) TusOptions(c *fiber.Ctx) error {
+	c.Set(tusResumableHeader, domain.TusVersion)
+	c.Set(tusVersionHeader, domain.TusVersion)
+	c.Set(tusExtensionHeader, "creation,termination")
+	c.Set(tusMaxSizeHeader, strconv.FormatInt(h.mediaService.TusMaxSize(), 10))
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// CreateTusUpload godoc
+// @Summary Create a resumable upload
+// @Description Start a tus upload. Upload-Metadata carries base64 values for filename (required), filetype, mediatype and provider. Bytes are sent with PATCH to the returned Location.
+// @Tags Media
+// @Security BearerAuth
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Length header int true "Size of the whole file in bytes"
+// @Param Upload-Metadata header string true "Comma-separated key and base64 value pairs"
+// @Success 201 "Upload created; its URL is in the Location header"
+// @Failure 403 {object} errors.Error "Storage quota or daily file limit exceeded"
+// @Failure 413 {object} errors.Error "Upload-Length exceeds Tus-Max-Size"
+// @Failure default {object} errors.Error
+// @Router /media/tus [post]
+func (h *MediaHandler) CreateTusUpload(c *fiber.Ctx) error {
+	if err := h.checkTusResumable(c); err != nil {
+		return err
+	}
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return err
+	}
+
+	length, err := strconv.ParseInt(c.Get(uploadLengthHeader), 10, 64)
+	if err != nil {
+		return errors.NewBadRequestError("Upload-Length header is required")
+	}
+	metadata, err := parseTusMetadata(c.Get(uploadMetaHeader))
+	if err != nil {
+		return errors.NewBadRequestError("Upload-Metadata is malformed")
+	}
+	if err := h.checkUploadGate(c, metadata["provider"]); err != nil {
+		return err
+	}
+
+	upload, err := h.mediaService.CreateTusUpload(c.Context(), userID, &domain.CreateTusUploadRequest{
+		Length:      length,
+		FileName:    metadata["filename"],
+		ContentType: metadata["filetype"],
+		MediaType:   metadata["mediatype"],
+		Provider:    metadata["provider"],
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderLocation, c.BaseURL()+strings.TrimSuffix(c.Path(), "/")+"/"+upload.ID.String())
+	return c.SendStatus(http.StatusCreated)
+}
+
+// HeadTusUpload godoc
+// @Summary Get the offset of a resumable upload
+// @Description Report how many bytes of a tus upload the server has, so an interrupted client knows where to resume
+// @Tags Media
+// @Security BearerAuth
+// @Param uploadId path string true "Tus upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 200 "Progress in the Upload-Offset and Upload-Length headers, and Media-Id once complete"
+// @Failure 404 {object} errors.Error "Upload not found or expired"
+// @Router /media/tus/{uploadId} [head]
+func (h *MediaHandler) HeadTusUpload(c *fiber.Ctx) error {
+	if err := h.checkTusResumable(c); err != nil {
+		return err
+	}
+	userID, uploadID, err := h.tusUploadParams(c)
+	if err != nil {
+		return err
+	}
+
+	upload, err := h.mediaService.GetTusUpload(c.Context(), userID, uploadID)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(uploadLengthHeader, strconv.FormatInt(upload.Length, 10))
+	setTusProgress(c, upload)
+	return c.SendStatus(http.StatusOK)
+}
+
+// PatchTusUpload godoc
+// @Summary Send bytes of a resumable upload
+// @Description Append the request body at Upload-Offset, which must equal the server's offset. The request that completes the file stores it and creates its media, whose ID is returned in Media-Id.
+// @Tags Media
+// @Accept application/offset+octet-stream
+// @Security BearerAuth
+// @Param uploadId path string true "Tus upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Param Upload-Offset header int true "Offset the body starts at"
+// @Success 204 "New offset in the Upload-Offset header"
+// @Failure 409 {object} errors.Error "Upload-Offset does not match, or another request is writing to the upload"
+// @Failure 415 {object} errors.Error "Content-Type is not application/offset+octet-stream"
+// @Failure 422 {object} errors.Error "Malware detected in the completed file"
+// @Failure default {object} errors.Error
+// @Router /media/tus/{uploadId} [patch]
+func (h *MediaHandler) PatchTusUpload(c *fiber.Ctx) error {
+	if err := h.checkTusResumable(c); err != nil {
+		return err
+	}
+	userID, uploadID, err := h.tusUploadParams(c)
+	if err != nil {
+		return err
+	}
+	if c.Get(fiber.HeaderContentType) != tusOffsetContentType {
+		return errors.NewError(http.StatusUnsupportedMediaType, "unsupported_media_type", "Content-Type must be "+tusOffsetContentType)
+	}
+	offset, err := strconv.ParseInt(c.Get(uploadOffsetHeader), 10, 64)
+	if err != nil || offset < 0 {
+		return errors.NewBadRequestError("Upload-Offset header is required")
+	}
+
+	// Large bodies are streamed by the server; smaller ones are already buffered.
+	body := c.Context().RequestBodyStream()
+	if body == nil {
+		body = bytes.NewReader(c.Body())
+	}
+
+	upload, err := h.mediaService.WriteTusUpload(c.Context(), userID, uploadID, offset, body)
+	if upload != nil {
+		// Bytes received before a failure still count; the client resumes from here
+		setTusProgress(c, upload)
+	}
+	if err != nil {
+		return err
+	}
+	if upload.Complete() && upload.MediaID != nil && offset < upload.Length {
+		// This request received the last bytes and created the media record
+		h.audit(c, userID, appDomain.ActionTypeCreate, *upload.MediaID, map[string]any{
+			"method": "tus", "file_name": upload.FileName, "file_size": upload.Length, "provider": upload.Provider,
+		})
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// DeleteTusUpload godoc
+// @Summary Terminate a resumable upload
+// @Description Discard an unfinished tus upload and the bytes received so far
+// @Tags Media
+// @Security BearerAuth
+// @Param uploadId path string true "Tus upload ID"
+// @Param Tus-Resumable header string true "Protocol version, 1.0.0"
+// @Success 204 "Upload terminated"
+// @Failure 404 {object} errors.Error "Upload not found or expired"
+// @Router /media/tus/{uploadId} [delete]
+func (h *MediaHandler) DeleteTusUpload(c *fiber.Ctx) error {
+	if err := h.checkTusResumable(c); err != nil {
+		return err
+	}
+	userID, uploadID, err := h.tusUploadParams(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.mediaService.DeleteTusUpload(c.Context(), userID, uploadID); err != nil {
+		return err
+	}
+	return c.SendStatus(http.StatusNoContent)
+}
+
+// checkTusResumable sets the Tus-Resumable response header and rejects requests for
+// another protocol version with 412.
+func (h *MediaHandler) checkTusResumable(c *fiber.Ctx) error {
+	c.Set(tusResumableHeader, domain.TusVersion)
+	if c.Get(tusResumableHeader) != domain.TusVersion {
+		c.Set(tusVersionHeader, domain.TusVersion)
+		return errors.NewError(http.StatusPreconditionFailed, "unsupported_tus_version", "Tus-Resumable must be "+domain.TusVersion)
+	}
+	return nil
+}
+
+// tusUploadParams extracts the caller and the upload ID path parameter.
+func (h *MediaHandler) tusUploadParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to get userID from claims", map[string]any{"error": err})
+		return uuid.Nil, uuid.Nil, err
+	}
+	uploadID, err := uuid.Parse(c.Params("uploadId"))
+	if err != nil {
+		h.logger.Warn(c.Context(), "Invalid tus upload ID format", map[string]any{"uploadID": c.Params("uploadId")})
+		return uuid.Nil, uuid.Nil, errors.ErrInvalidInput
+	}
+	return userID, uploadID, nil
+}
+
+// setTusProgress reports the upload offset, and the media ID once the upload is stored.
+func setTusProgress(c *fiber.Ctx, upload *domain.TusUpload) {
+	c.Set(uploadOffsetHeader, strconv.FormatInt(upload.Offset, 10))
+	if upload.MediaID != nil {
+		c.Set(mediaIDHeader, upload.MediaID.String())
+	}
+}
+
+// parseTusMetadata decodes Upload-Metadata: comma-separated pairs of a key and an
+// optional base64 value, separated by a space.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	for _, pair := range strings.Split(header, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, encoded, _ := strings.Cut(pair, " ")
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
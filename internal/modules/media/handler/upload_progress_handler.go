@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// uploadProgressInterval is how often the progress stream reports an upload.
+const uploadProgressInterval = 500 * time.Millisecond
+
+// StreamUploadProgress godoc
+// @Summary Stream the progress of a multipart upload
+// @Description Server-Sent Events stream with a "progress" event every 500 ms carrying the bytes of the upload that have reached the provider, counting parts still being uploaded. The stream ends after the upload completes or is aborted; an "error" event ends it early.
+// @Tags Media
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param uploadId path string true "Multipart upload ID"
+// @Success 200 {object} domain.UploadProgress "Progress events"
+// @Failure default {object} errors.Error
+// @Router /media/upload/multipart/{uploadId}/progress [get]
+func (h *MediaHandler) StreamUploadProgress(c *fiber.Ctx) error {
+	userID, uploadID, err := h.multipartUploadParams(c)
+	if err != nil {
+		return err
+	}
+
+	// Unknown uploads are answered with an error status rather than an empty stream
+	progress, err := h.mediaService.GetUploadProgress(c.Context(), userID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Keep reverse proxies from holding events back
+
+	// The body is written after the handler returns, so the request context must not be used inside.
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+		ticker := time.NewTicker(uploadProgressInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := writeUploadProgressEvent(w, "progress", progress); err != nil {
+				// The client went away
+				return
+			}
+			if progress.Status != utils.JobRunning {
+				return
+			}
+
+			<-ticker.C
+			progress, err = h.mediaService.GetUploadProgress(ctx, userID, uploadID)
+			if err != nil {
+				h.logger.Warn(ctx, "Upload progress stream ended", map[string]any{"error": err, "uploadID": uploadID.String()})
+				_ = writeUploadProgressEvent(w, "error", fiber.Map{"error": err.Error()})
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// writeUploadProgressEvent writes one Server-Sent Event and flushes it to the client.
+func writeUploadProgressEvent(w *bufio.Writer, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
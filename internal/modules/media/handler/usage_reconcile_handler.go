@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// RecalculateUsage godoc
+// @Summary Recount a user's storage usage
+// @Description Sum the size of the user's stored media and make it the usage their storage quota is checked against, correcting drift left by failed deletes or manual edits
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param userId path string true "User ID"
+// @Success 200 {object} domain.UsageRecalculation "Previous and recounted usage"
+// @Failure default {object} errors.Error
+// @Router /admin/media/usage/{userId}/recalculate [post]
+func (h *MediaHandler) RecalculateUsage(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	result, err := h.mediaService.RecalculateUsage(c.Context(), userID)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to recalculate storage usage", map[string]any{"error": err, "userID": userID.String()})
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(result)
+}
+
+// StartUsageReconcile godoc
+// @Summary Recount every user's storage usage
+// @Description Start a background job that recounts the storage usage of all users, the same job media.usageReconcile runs on a schedule. Workers and batch size come from media.usageReconcile in the config. A job that was cancelled or failed is resumed after its last finished batch.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} domain.UsageReconcileReport "Started job; poll its status for progress"
+// @Failure 409 {object} errors.Error "A reconcile job is already running"
+// @Failure default {object} errors.Error
+// @Router /admin/media/usage/reconcile [post]
+func (h *MediaHandler) StartUsageReconcile(c *fiber.Ctx) error {
+	report, err := h.mediaService.StartUsageReconcile(c.Context(), false)
+	if err != nil {
+		h.logger.Error(c.Context(), "Failed to start usage reconcile", map[string]any{"error": err})
+		return err
+	}
+	return c.Status(http.StatusAccepted).JSON(report)
+}
+
+// GetUsageReconcile godoc
+// @Summary Get the progress of a usage reconcile job
+// @Description Report processed/total, ETA and the corrected users of a running or recently finished reconcile job
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Reconcile job ID"
+// @Success 200 {object} domain.UsageReconcileReport "Reconcile progress"
+// @Failure default {object} errors.Error
+// @Router /admin/media/usage/reconcile/{jobId} [get]
+func (h *MediaHandler) GetUsageReconcile(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	report, err := h.mediaService.GetUsageReconcile(c.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusOK).JSON(report)
+}
+
+// CancelUsageReconcile godoc
+// @Summary Cancel a usage reconcile job
+// @Description Stop a running reconcile job. The next job, scheduled or started by hand, continues after the last batch this one finished.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param jobId path string true "Reconcile job ID"
+// @Success 202 {object} domain.UsageReconcileReport "Reconcile progress at the time of cancelling"
+// @Failure default {object} errors.Error
+// @Router /admin/media/usage/reconcile/{jobId} [delete]
+func (h *MediaHandler) CancelUsageReconcile(c *fiber.Ctx) error {
+	jobID, err := uuid.Parse(c.Params("jobId"))
+	if err != nil {
+		return errors.ErrInvalidInput
+	}
+
+	report, err := h.mediaService.CancelUsageReconcile(c.Context(), jobID)
+	if err != nil {
+		return err
+	}
+	return c.Status(http.StatusAccepted).JSON(report)
+}
@@ -2,7 +2,9 @@ package port
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,8 +15,120 @@ import (
 // MediaService defines the interface for media services.
 type MediaService interface {
 	UploadFile(ctx context.Context, userID uuid.UUID, fileHeader *multipart.FileHeader, providerName string, mediaTypeHint string) (*domain.Media, error)
-	ListMedia(ctx context.Context, userID uuid.UUID, query *utils.PaginationQuery) (*utils.Pagination, []*domain.Media, error)
+	BatchUploadFiles(ctx context.Context, userID uuid.UUID, files []*multipart.FileHeader, providerName string, mediaTypeHint string) (*utils.BatchResult[*domain.Media], error)
+	ListMedia(ctx context.Context, userID uuid.UUID, query *utils.PaginationQuery, filter *domain.MediaListFilter) (*utils.Pagination, []*domain.Media, error)
+	ListMediaByCursor(ctx context.Context, userID uuid.UUID, query *utils.CursorQuery, filter *domain.MediaListFilter) (*utils.CursorPagination, []*domain.Media, error)
+	GetMediaUsage(ctx context.Context, userID uuid.UUID) (*domain.MediaUsage, error)
+	CountUserResources(ctx context.Context, userID uuid.UUID) (map[string]int64, error)
+	UploadAvatar(ctx context.Context, userID uuid.UUID, fileHeader *multipart.FileHeader) (uuid.UUID, string, error)
+	DeleteAvatar(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) error
 	GetMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (*domain.Media, error)
 	GetPublicMedia(ctx context.Context, mediaID uuid.UUID) (*domain.Media, error)
+	UpdateMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.UpdateMediaRequest) (*domain.Media, error)
 	DeleteMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) error
+	BatchDeleteMedia(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) (*utils.BatchResult[domain.MediaDeleteResult], error)
+	PurgeUserMedia(ctx context.Context, userID uuid.UUID) (*utils.BatchResult[uuid.UUID], error)
+	RefreshMetadata(ctx context.Context, mediaIDs []uuid.UUID) (*utils.BatchResult[domain.MetadataRefreshResult], error)
+	MigrateMedia(ctx context.Context, userID uuid.UUID, fromProvider, toProvider string) (*domain.MigrationReport, error)
+	StartMediaMigration(ctx context.Context, userID uuid.UUID, fromProvider, toProvider string) (*domain.MigrationReport, error)
+	GetMediaMigration(ctx context.Context, jobID uuid.UUID) (*domain.MigrationReport, error)
+	CancelMediaMigration(ctx context.Context, jobID uuid.UUID) (*domain.MigrationReport, error)
+	RecalculateUsage(ctx context.Context, userID uuid.UUID) (*domain.UsageRecalculation, error)
+	StartUsageReconcile(ctx context.Context, scheduled bool) (*domain.UsageReconcileReport, error)
+	GetUsageReconcile(ctx context.Context, jobID uuid.UUID) (*domain.UsageReconcileReport, error)
+	CancelUsageReconcile(ctx context.Context, jobID uuid.UUID) (*domain.UsageReconcileReport, error)
+	DownloadMedia(ctx context.Context, media *domain.Media) (io.ReadCloser, int64, error)
+	DownloadMediaRange(ctx context.Context, media *domain.Media, start, end int64) (io.ReadCloser, error)
+	GetSpriteAsset(ctx context.Context, mediaID uuid.UUID, asset domain.SpriteAsset) (io.ReadCloser, string, error)
+	GetThumbnail(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (io.ReadCloser, string, error)
+	GetRendition(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, name string) (io.ReadCloser, string, error)
+	ResizeImage(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, opts domain.ResizeOptions) (io.ReadCloser, string, error)
+	PrepareMediaZip(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) (*domain.MediaZip, error)
+	WriteMediaZip(ctx context.Context, archive *domain.MediaZip, w io.Writer) error
+	ExportMedia(ctx context.Context, userID uuid.UUID, format domain.ExportFormat, withSignedURLs bool, w io.Writer) error
+	GetSignedURL(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, expiry time.Duration) (*domain.SignedURL, error)
+	SignMedia(ctx context.Context, media *domain.Media, expiry time.Duration) error
+
+	CreateFolder(ctx context.Context, userID uuid.UUID, req *domain.CreateFolderRequest) (*domain.Folder, error)
+	ListFolders(ctx context.Context, userID uuid.UUID, parent *uuid.UUID) ([]*domain.Folder, error)
+	GetFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID) (*domain.Folder, error)
+	RenameFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, req *domain.RenameFolderRequest) (*domain.Folder, error)
+	MoveFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, req *domain.MoveFolderRequest) (*domain.Folder, error)
+	DeleteFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, force, dryRun bool) (*domain.FolderDeleteSummary, error)
+	MoveMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.MoveMediaRequest) (*domain.Media, error)
+
+	AddMediaTags(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.AddTagsRequest) ([]string, error)
+	RemoveMediaTag(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, tag string) ([]string, error)
+
+	CreateMediaShare(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.CreateShareRequest) (*domain.MediaShare, error)
+	ListMediaShares(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) ([]*domain.MediaShare, error)
+	RevokeMediaShare(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, shareID uuid.UUID) (*domain.MediaShare, error)
+	OpenMediaShare(ctx context.Context, token string) (*domain.Media, error)
+
+	PresignUpload(ctx context.Context, userID uuid.UUID, req *domain.PresignUploadRequest) (*domain.PresignedUpload, error)
+	ConfirmUpload(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (*domain.Media, error)
+
+	InitiateMultipartUpload(ctx context.Context, userID uuid.UUID, req *domain.InitiateMultipartUploadRequest) (*domain.MultipartUpload, error)
+	ListMultipartUploads(ctx context.Context, userID uuid.UUID) ([]*domain.MultipartUpload, error)
+	GetMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.MultipartUpload, error)
+	UploadPart(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID, partNumber int, reader io.Reader, size int64) (*domain.UploadedPart, error)
+	CompleteMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.Media, error)
+	AbortMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) error
+	GetUploadProgress(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.UploadProgress, error)
+
+	TusMaxSize() int64
+	CreateTusUpload(ctx context.Context, userID uuid.UUID, req *domain.CreateTusUploadRequest) (*domain.TusUpload, error)
+	GetTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.TusUpload, error)
+	WriteTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID, offset int64, reader io.Reader) (*domain.TusUpload, error)
+	DeleteTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) error
+
+	// DrainUploads rejects new uploads and blocks until the uploads in flight have
+	// finished or ctx is done. It is called on shutdown.
+	DrainUploads(ctx context.Context) error
+}
+
+// EventPublisher hands storage events (domain.Event*) to their subscribers, such as
+// webhooks. Publish only queues the event; delivery happens in the background.
+type EventPublisher interface {
+	Publish(ctx context.Context, eventType string, data any) error
+}
+
+// UsageSource counts storage a user holds outside their media records, such as objects
+// stored through the S3 gateway, so the usage reconcile does not drop it.
+type UsageSource interface {
+	// UsedBytes returns the total size of the user's stored objects
+	UsedBytes(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// Scanner checks uploaded content for malware before it is stored.
+type Scanner interface {
+	// Scan reads r to the end. It returns clean == false with details naming the
+	// detected signature, or an error when the content could not be scanned.
+	Scan(ctx context.Context, r io.Reader) (clean bool, details string, err error)
+}
+
+// MultipartSessionStore persists in-progress multipart uploads per user so they survive
+// dropped connections and server restarts.
+type MultipartSessionStore interface {
+	// Save stores the upload until its ExpiresAt.
+	Save(ctx context.Context, upload *domain.MultipartUpload) error
+	// Get returns the upload with its recorded parts, or nil if it does not exist or has expired.
+	Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*domain.MultipartUpload, error)
+	// List returns the user's unexpired uploads.
+	List(ctx context.Context, userID uuid.UUID) ([]*domain.MultipartUpload, error)
+	// AddPart records a completed part, replacing any earlier attempt with the same number.
+	AddPart(ctx context.Context, upload *domain.MultipartUpload, part domain.UploadedPart) error
+	// Delete removes the upload and its recorded parts.
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+}
+
+// TusUploadStore persists the state of tus uploads so an upload can be resumed from
+// another request, or after a restart.
+type TusUploadStore interface {
+	// Save stores the upload until its ExpiresAt, replacing any earlier state.
+	Save(ctx context.Context, upload *domain.TusUpload) error
+	// Get returns the upload, or nil if it does not exist or has expired.
+	Get(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*domain.TusUpload, error)
+	// Delete removes the upload.
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
 }
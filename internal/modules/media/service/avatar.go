@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// avatarContentTypes are the image formats accepted as avatars. SVG is left out: it is
+// served publicly and can carry scripts.
+var avatarContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+// UploadAvatar implements port.MediaService. The image goes through the regular upload
+// pipeline on the default provider, including the quota check, malware scan and
+// thumbnail generation, and is served from its public URL.
+func (s *mediaService) UploadAvatar(ctx context.Context, userID uuid.UUID, fileHeader *multipart.FileHeader) (uuid.UUID, string, error) {
+	ctx, done, err := s.uploads.begin(ctx)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	defer done()
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to open file: %w", err)
+	}
+	// Judged by the bytes, so a renamed file of another kind is refused
+	typeCheck, err := resolveContentType(file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+	file.Close()
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("failed to detect content type: %w", err)
+	}
+	if !slices.Contains(avatarContentTypes, typeCheck.Detected) {
+		return uuid.Nil, "", errors.NewBadRequestError("avatar must be a JPEG, PNG, GIF or WebP image")
+	}
+
+	// A replaced avatar is deleted, so it must never be media the user already had: the
+	// upload is stored as a new record under a name no other upload shares
+	avatarHeader := *fileHeader
+	avatarHeader.Filename = "avatar-" + uuid.NewString() + strings.ToLower(filepath.Ext(fileHeader.Filename))
+	media, err := s.uploadFile(ctx, userID, &avatarHeader, "", "image", uploadSettings{keepDuplicate: true})
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+	s.handleLocalMediaURL(media)
+	if media.PublicURL == "" {
+		s.logger.Error(ctx, "Avatar stored without a public URL", map[string]any{"mediaID": media.ID.String(), "provider": media.Provider})
+		if err := s.DeleteMedia(ctx, userID, media.ID); err != nil {
+			s.logger.Warn(ctx, "Failed to delete unusable avatar", map[string]any{"error": err, "mediaID": media.ID.String()})
+		}
+		return uuid.Nil, "", fmt.Errorf("provider %s gives avatar no public URL", media.Provider)
+	}
+	return media.ID, media.PublicURL, nil
+}
+
+// DeleteAvatar implements port.MediaService. It deletes a replaced avatar like any other
+// media file of the user; UploadAvatar stores every avatar as media of its own, so no
+// other upload goes with it.
+func (s *mediaService) DeleteAvatar(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) error {
+	return s.DeleteMedia(ctx, userID, mediaID)
+}
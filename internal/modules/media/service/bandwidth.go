@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"golang.org/x/time/rate"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+const (
+	// userBandwidthRefresh is how long a user's limit is reused before it is read again,
+	// so a changed limit applies to transfers started after that.
+	userBandwidthRefresh = time.Minute
+	// userBandwidthIdle is how long the limiter of a user who starts no transfers is kept.
+	userBandwidthIdle = 30 * time.Minute
+)
+
+// bandwidthThrottle paces the streams between clients and providers with a token bucket
+// shared by every transfer, and one per user shared by that user's transfers. It does
+// nothing when neither media.bandwidth nor the user sets a limit.
+type bandwidthThrottle struct {
+	global  *rate.Limiter // nil when unlimited
+	perUser int64
+	users   authPort.UserService
+	logger  logger.Logger
+
+	mu       sync.Mutex
+	limiters map[uuid.UUID]*userBandwidth
+}
+
+type userBandwidth struct {
+	limiter        *rate.Limiter // nil when the user is unlimited
+	bytesPerSecond int64
+	checkedAt      time.Time
+}
+
+func newBandwidthThrottle(cfg config.BandwidthConfig, users authPort.UserService, appLogger logger.Logger) *bandwidthThrottle {
+	return &bandwidthThrottle{
+		global:   utils.NewBandwidthLimiter(cfg.BytesPerSecond),
+		perUser:  cfg.PerUserBytesPerSecond,
+		users:    users,
+		logger:   appLogger.WithFields(map[string]any{"component": "BandwidthThrottle"}),
+		limiters: make(map[uuid.UUID]*userBandwidth),
+	}
+}
+
+// userLimiter returns the limiter shared by the transfers of userID: the user's own limit
+// when set, and the configured per-user limit otherwise.
+func (t *bandwidthThrottle) userLimiter(ctx context.Context, userID uuid.UUID) *rate.Limiter {
+	now := time.Now()
+	t.mu.Lock()
+	if entry, ok := t.limiters[userID]; ok && now.Sub(entry.checkedAt) < userBandwidthRefresh {
+		t.mu.Unlock()
+		return entry.limiter
+	}
+	t.mu.Unlock()
+
+	bytesPerSecond := t.perUser
+	if own, err := t.users.BandwidthLimit(ctx, userID); err != nil {
+		t.logger.Warn(ctx, "Failed to read user bandwidth limit; using the configured default", map[string]any{"error": err, "userID": userID.String()})
+	} else if own > 0 {
+		bytesPerSecond = own
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, entry := range t.limiters {
+		if now.Sub(entry.checkedAt) > userBandwidthIdle {
+			delete(t.limiters, id)
+		}
+	}
+	entry, ok := t.limiters[userID]
+	if !ok || entry.bytesPerSecond != bytesPerSecond {
+		entry = &userBandwidth{limiter: utils.NewBandwidthLimiter(bytesPerSecond), bytesPerSecond: bytesPerSecond}
+		t.limiters[userID] = entry
+	}
+	entry.checkedAt = now
+	return entry.limiter
+}
+
+// reader paces reads from r, a transfer of userID. Seekable readers stay seekable.
+func (t *bandwidthThrottle) reader(ctx context.Context, userID uuid.UUID, r io.Reader) io.Reader {
+	return utils.ThrottleReader(ctx, utils.ThrottleReader(ctx, r, t.userLimiter(ctx, userID)), t.global)
+}
+
+// readCloser is reader for a stream that must still be closed.
+func (t *bandwidthThrottle) readCloser(ctx context.Context, userID uuid.UUID, rc io.ReadCloser) io.ReadCloser {
+	userLimiter := t.userLimiter(ctx, userID)
+	if userLimiter == nil && t.global == nil {
+		return rc
+	}
+	r := utils.ThrottleReader(ctx, utils.ThrottleReader(ctx, rc, userLimiter), t.global)
+	return throttledReadCloser{Reader: r, Closer: rc}
+}
+
+// writer paces writes to w, a transfer of userID.
+func (t *bandwidthThrottle) writer(ctx context.Context, userID uuid.UUID, w io.Writer) io.Writer {
+	return utils.ThrottleWriter(ctx, utils.ThrottleWriter(ctx, w, t.userLimiter(ctx, userID)), t.global)
+}
+
+type throttledReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// batchDeleteConcurrency bounds how many single-object deletes run at once for providers
+// without a batch delete.
+const batchDeleteConcurrency = 8
+
+// BatchDeleteMedia deletes several media files of userID. Objects are removed with the
+// provider's batch delete when it has one; a file whose object cannot be removed keeps
+// its record and is reported as failed without affecting the others. Results are
+// returned in request order.
+func (s *mediaService) BatchDeleteMedia(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) (*utils.BatchResult[domain.MediaDeleteResult], error) {
+	mediaIDs = uniqueIDs(mediaIDs)
+	s.logger.Info(ctx, "Deleting media batch", map[string]any{"userID": userID.String(), "count": len(mediaIDs)})
+
+	var rows []*domain.Media
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND id IN ?", userID, mediaIDs).Find(&rows).Error; err != nil {
+		s.logger.Error(ctx, "Failed to load media for batch delete", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to load media: %w", err)
+	}
+	byProvider := make(map[string][]*domain.Media)
+	for _, row := range rows {
+		byProvider[row.Provider] = append(byProvider[row.Provider], row)
+	}
+
+	failures := make(map[uuid.UUID]error)
+	for providerName, group := range byProvider {
+		provider, err := s.storageFactory.CreateProviderByName(providerName)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to get storage provider for batch delete", map[string]any{"error": err, "provider": providerName})
+			for _, media := range group {
+				failures[media.ID] = fmt.Errorf("storage provider unavailable: %w", err)
+			}
+			continue
+		}
+		for id, err := range s.deleteMediaObjects(ctx, provider, group) {
+			failures[id] = err
+		}
+	}
+
+	// Records go only once their objects are gone
+	var deletable []uuid.UUID
+	for _, row := range rows {
+		if _, failed := failures[row.ID]; !failed {
+			deletable = append(deletable, row.ID)
+			s.signedURLs.invalidate(ctx, row)
+		}
+	}
+	if len(deletable) > 0 {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("media_id IN ?", deletable).Delete(&domain.MediaTag{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("media_id IN ?", deletable).Delete(&domain.MediaShare{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id IN ?", deletable).Delete(&domain.Media{}).Error
+		})
+		if err != nil {
+			s.logger.Error(ctx, "Failed to delete media records", map[string]any{"error": err, "count": len(deletable)})
+			for _, id := range deletable {
+				failures[id] = fmt.Errorf("failed to delete media from database: %w", err)
+			}
+		} else {
+			var released int64
+			for _, row := range rows {
+				if _, failed := failures[row.ID]; !failed {
+					if row.Status == domain.StatusReady {
+						released += row.FileSize
+					}
+					s.publishEvent(ctx, domain.EventMediaDeleted, domain.NewMediaEvent(row))
+				}
+			}
+			s.releaseStorage(ctx, userID, released)
+		}
+	}
+
+	found := make(map[uuid.UUID]bool, len(rows))
+	for _, row := range rows {
+		found[row.ID] = true
+	}
+	batch := utils.NewBatchResult[domain.MediaDeleteResult](len(mediaIDs))
+	for _, id := range mediaIDs {
+		switch err := failures[id]; {
+		case !found[id]:
+			batch.Fail(id.String(), errMediaNotFound)
+		case err != nil:
+			batch.Fail(id.String(), err)
+		default:
+			batch.Succeed(id.String(), domain.MediaDeleteResult{MediaID: id, Status: "deleted"})
+		}
+	}
+	s.logger.Info(ctx, "Media batch deleted", map[string]any{"succeeded": batch.Summary.Succeeded, "failed": batch.Summary.Failed})
+
+	return batch, nil
+}
+
+// deleteMediaObjects removes the objects of media, all stored in provider, and returns the
+// error of each media whose own object could not be removed. Derived assets are
+// best-effort, as in DeleteMedia.
+func (s *mediaService) deleteMediaObjects(ctx context.Context, provider storagePort.StorageProvider, media []*domain.Media) map[uuid.UUID]error {
+	var keys []string
+	for _, m := range media {
+		keys = append(keys, m.FilePath)
+		keys = append(keys, m.DerivedKeys()...)
+	}
+
+	var keyErrs map[string]error
+	if batcher, ok := storagePort.As[storagePort.BatchDeleteProvider](provider); ok {
+		var err error
+		if keyErrs, err = batcher.DeleteMany(ctx, keys); err != nil {
+			failures := make(map[uuid.UUID]error, len(media))
+			for _, m := range media {
+				failures[m.ID] = fmt.Errorf("failed to delete file from storage: %w", err)
+			}
+			return failures
+		}
+	} else {
+		keyErrs = s.deleteEach(ctx, provider, keys)
+	}
+
+	failures := make(map[uuid.UUID]error)
+	for _, m := range media {
+		if err := keyErrs[m.FilePath]; err != nil {
+			failures[m.ID] = fmt.Errorf("failed to delete file from storage: %w", err)
+		}
+		for _, key := range m.DerivedKeys() {
+			if err := keyErrs[key]; err != nil {
+				s.logger.Warn(ctx, "Failed to delete derived media asset", map[string]any{"error": err, "key": key})
+			}
+		}
+	}
+	return failures
+}
+
+// deleteEach removes keys one request at a time. Keys that are already missing count as deleted.
+func (s *mediaService) deleteEach(ctx context.Context, provider storagePort.StorageProvider, keys []string) map[string]error {
+	var mu sync.Mutex
+	keyErrs := make(map[string]error)
+	sem := make(chan struct{}, batchDeleteConcurrency)
+	var wg sync.WaitGroup
+
+	for _, key := range keys {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := provider.Delete(ctx, key); err != nil && !errors.Is(err, storagePort.ErrObjectNotFound) {
+				mu.Lock()
+				keyErrs[key] = err
+				mu.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+	return keyErrs
+}
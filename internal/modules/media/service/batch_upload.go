@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mime/multipart"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+const (
+	// maxBatchUploadFiles caps how many files one batch upload may carry.
+	maxBatchUploadFiles = 50
+	// batchUploadConcurrency bounds how many files of a batch are uploaded at once.
+	batchUploadConcurrency = 8
+)
+
+// BatchUploadFiles implements port.MediaService. The whole batch must fit the user's
+// quotas before any file is stored, and its total size is reserved against the storage
+// quota up front so concurrent uploads cannot overshoot it together. After that each
+// file is uploaded as by UploadFile and a failed file does not affect the others; the
+// bytes reserved for it are released. Results are in request order, keyed by file name.
+func (s *mediaService) BatchUploadFiles(ctx context.Context, userID uuid.UUID, files []*multipart.FileHeader, providerName string, mediaTypeHint string) (*utils.BatchResult[*domain.Media], error) {
+	if len(files) == 0 {
+		return nil, errors.NewBadRequestError("at least one file is required")
+	}
+	if len(files) > maxBatchUploadFiles {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("at most %d files can be uploaded per batch", maxBatchUploadFiles))
+	}
+
+	var total int64
+	for _, file := range files {
+		total += file.Size
+	}
+	if err := s.users.CanUploadFiles(ctx, userID, len(files), total); err != nil {
+		s.logger.Warn(ctx, "Batch upload rejected by user quota", map[string]any{"error": err, "userID": userID.String(), "files": len(files), "size": total})
+		return nil, err
+	}
+	if err := s.users.ReserveStorage(ctx, userID, len(files), total); err != nil {
+		s.logger.Warn(ctx, "Batch upload rejected by storage reservation", map[string]any{"error": err, "userID": userID.String(), "files": len(files), "size": total})
+		return nil, err
+	}
+	s.logger.Info(ctx, "Uploading media batch", map[string]any{"userID": userID.String(), "files": len(files), "size": total, "providerName": providerName})
+
+	media := make([]*domain.Media, len(files))
+	errs := make([]error, len(files))
+	sem := make(chan struct{}, batchUploadConcurrency)
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func(i int, file *multipart.FileHeader) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			media[i], errs[i] = s.uploadFile(ctx, userID, file, providerName, mediaTypeHint, uploadSettings{reserved: file.Size})
+		}(i, file)
+	}
+	wg.Wait()
+
+	batch := utils.NewBatchResult[*domain.Media](len(files))
+	for i, file := range files {
+		if errs[i] != nil {
+			batch.Fail(file.Filename, errs[i])
+			continue
+		}
+		batch.Succeed(file.Filename, media[i])
+	}
+	s.logger.Info(ctx, "Media batch uploaded", map[string]any{"succeeded": batch.Summary.Succeeded, "failed": batch.Summary.Failed})
+	return batch, nil
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// verifyBatchSize is how many media records a consistency check loads at once.
+const verifyBatchSize = 500
+
+// ConsistencyChecker compares media records with the objects stored by their providers,
+// to clean up after failed uploads and objects deleted outside the API. It backs the
+// verify command, which runs it outside the server.
+type ConsistencyChecker struct {
+	db             *gorm.DB
+	storageFactory storagePort.StorageFactory
+	logger         logger.Logger
+}
+
+// NewConsistencyChecker creates a consistency checker.
+func NewConsistencyChecker(db *gorm.DB, storageFactory storagePort.StorageFactory, appLogger logger.Logger) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		db:             db,
+		storageFactory: storageFactory,
+		logger:         appLogger.WithFields(map[string]any{"component": "ConsistencyChecker"}),
+	}
+}
+
+// Verify checks that the object of every stored media exists on its provider and, with
+// opts.Orphans, that every object in a media key has a record. Pending presigned uploads
+// have no object yet and are not checked. With opts.Fix, dangling records are marked
+// missing, which hides them from listings and usage; orphaned objects are only reported.
+func (c *ConsistencyChecker) Verify(ctx context.Context, opts *domain.VerifyOptions) (*domain.VerifyReport, error) {
+	started := time.Now()
+	report := &domain.VerifyReport{Dangling: []domain.DanglingMedia{}}
+
+	query := c.db.WithContext(ctx).Where("status = ?", domain.StatusReady)
+	if opts.Provider != "" {
+		query = query.Where("provider = ?", opts.Provider)
+	}
+	var batch []*domain.Media
+	result := query.FindInBatches(&batch, verifyBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, media := range batch {
+			report.Checked++
+			if err := c.checkMedia(ctx, media, opts.Fix, report); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to load media: %w", result.Error)
+	}
+
+	if opts.Orphans {
+		if err := c.findOrphans(ctx, opts.Provider, started, report); err != nil {
+			return nil, err
+		}
+	}
+	c.logger.Info(ctx, "Consistency check finished", map[string]any{
+		"checked": report.Checked, "dangling": len(report.Dangling), "fixed": report.Fixed,
+		"orphaned": len(report.Orphaned), "errors": len(report.Errors),
+	})
+	return report, nil
+}
+
+// checkMedia records media as dangling when its object is gone, and marks it missing when
+// fix is set. Only a failure to update the database is returned; the rest is reported.
+func (c *ConsistencyChecker) checkMedia(ctx context.Context, media *domain.Media, fix bool, report *domain.VerifyReport) error {
+	provider, err := c.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("media %s: provider %q unavailable: %v", media.ID, media.Provider, err))
+		return nil
+	}
+	exists, err := provider.Exists(ctx, media.FilePath)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("media %s: failed to check %s: %v", media.ID, media.FilePath, err))
+		return nil
+	}
+	if exists {
+		return nil
+	}
+
+	report.Dangling = append(report.Dangling, domain.DanglingMedia{
+		MediaID: media.ID, UserID: media.UserID, Provider: media.Provider, FilePath: media.FilePath,
+	})
+	if !fix {
+		return nil
+	}
+	// The row may have been deleted or moved since it was loaded
+	result := c.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("id = ? AND provider = ? AND status = ?", media.ID, media.Provider, domain.StatusReady).
+		Update("status", domain.StatusMissing)
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark media %s missing: %w", media.ID, result.Error)
+	}
+	report.Fixed += int(result.RowsAffected)
+	return nil
+}
+
+// findOrphans lists the objects of each provider holding media and reports those in a
+// media key, one starting with a user ID, that no record refers to. Objects written
+// after the check started may belong to uploads still in progress and are skipped.
+func (c *ConsistencyChecker) findOrphans(ctx context.Context, onlyProvider string, started time.Time, report *domain.VerifyReport) error {
+	var providers []string
+	query := c.db.WithContext(ctx).Model(&domain.Media{}).Distinct("provider")
+	if onlyProvider != "" {
+		query = query.Where("provider = ?", onlyProvider)
+	}
+	if err := query.Pluck("provider", &providers).Error; err != nil {
+		return fmt.Errorf("failed to load media providers: %w", err)
+	}
+
+	for _, name := range providers {
+		known, err := c.knownKeys(ctx, name)
+		if err != nil {
+			return err
+		}
+		provider, err := c.storageFactory.CreateProviderByName(name)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("provider %q unavailable: %v", name, err))
+			continue
+		}
+
+		token := ""
+		for {
+			objects, next, err := provider.ListObjects(ctx, "", &storagePort.ListOptions{ContinuationToken: token})
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("provider %q: failed to list objects: %v", name, err))
+				break
+			}
+			for _, object := range objects {
+				if _, ok := known[object.Key]; ok || !isMediaKey(object.Key) || object.LastModified.After(started) {
+					continue
+				}
+				report.Orphaned = append(report.Orphaned, domain.OrphanedObject{Provider: name, Key: object.Key, Size: object.Size})
+			}
+			if next == "" {
+				break
+			}
+			token = next
+		}
+	}
+	return nil
+}
+
+// knownKeys returns the keys of every object the media records of provider refer to,
+// including their previews and renditions.
+func (c *ConsistencyChecker) knownKeys(ctx context.Context, provider string) (map[string]struct{}, error) {
+	known := map[string]struct{}{}
+	var batch []*domain.Media
+	result := c.db.WithContext(ctx).Where("provider = ?", provider).
+		FindInBatches(&batch, verifyBatchSize, func(tx *gorm.DB, _ int) error {
+			for _, media := range batch {
+				known[media.FilePath] = struct{}{}
+				for _, key := range media.DerivedKeys() {
+					known[key] = struct{}{}
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to load media of provider %q: %w", provider, result.Error)
+	}
+	return known, nil
+}
+
+// isMediaKey reports whether key is laid out as a media upload, {userID}/{mediaType}/...,
+// so objects of the S3 gateway and other tenants of a shared bucket are not reported.
+func isMediaKey(key string) bool {
+	userID, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
+	}
+	_, err := uuid.Parse(userID)
+	return err == nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// duplicateUploadsDedupe makes a re-upload of identical content return the existing media.
+const duplicateUploadsDedupe = "dedupe"
+
+// hashingReader computes the SHA-256 of the content read through it while it streams
+// to the provider. It stays seekable so uploads can still be retried: bytes read again
+// after a rewind only count once, because only bytes extending the hashed prefix are hashed.
+type hashingReader struct {
+	r      io.ReadSeeker
+	hash   hash.Hash
+	offset int64 // Position of r
+	hashed int64 // Length of the hashed prefix
+}
+
+func newHashingReader(r io.ReadSeeker) *hashingReader {
+	return &hashingReader{r: r, hash: sha256.New()}
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	if start := h.offset; start <= h.hashed && start+int64(n) > h.hashed {
+		h.hash.Write(p[h.hashed-start : n])
+		h.hashed = start + int64(n)
+	}
+	h.offset += int64(n)
+	return n, err
+}
+
+func (h *hashingReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := h.r.Seek(offset, whence)
+	if err == nil {
+		h.offset = pos
+	}
+	return pos, err
+}
+
+// Sum returns the hex SHA-256 of the content, or an empty string unless exactly size
+// bytes were hashed, as when the provider did not read the whole reader.
+func (h *hashingReader) Sum(size int64) string {
+	if h.hashed != size {
+		return ""
+	}
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// findDuplicate returns the user's oldest media with the same content, or nil if there is none.
+func (s *mediaService) findDuplicate(ctx context.Context, userID uuid.UUID, sha256Sum string, size int64) (*domain.Media, error) {
+	var media domain.Media
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND sha256 = ? AND file_size = ? AND status = ?", userID, sha256Sum, size, domain.StatusReady).
+		Order("created_at ASC").
+		First(&media).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up duplicate media: %w", err)
+	}
+	return &media, nil
+}
+
+// discardDuplicateUpload deletes the object just stored for an upload that duplicates
+// existing, unless the upload was written over existing's own object.
+func (s *mediaService) discardDuplicateUpload(ctx context.Context, provider storagePort.StorageProvider, key string, existing *domain.Media) {
+	if existing.FilePath == key && existing.Provider == storagePort.BackendName(provider) {
+		return
+	}
+	if err := provider.Delete(ctx, key); err != nil {
+		s.logger.Warn(ctx, "Failed to delete duplicate upload", map[string]any{"error": err, "key": key})
+	}
+}
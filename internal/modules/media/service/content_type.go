@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+)
+
+// sniffLen is how many leading bytes are inspected for magic-number detection.
+const sniffLen = 3072
+
+// contentTypeCheck records how the stored content type of an upload was decided.
+type contentTypeCheck struct {
+	Detected      string // From the file's magic number
+	FromExtension string // From the file name
+	Declared      string // From the client's Content-Type header
+	Resolved      string // Authoritative type to store
+	Mismatch      bool   // Detected type disagrees with the extension or the declared header
+}
+
+// resolveContentType sniffs the start of file, cross-checks it against the file
+// extension and the client-declared type, and picks the authoritative type.
+// The reader is rewound to the start before returning.
+func resolveContentType(file io.ReadSeeker, fileName, declared string) (*contentTypeCheck, error) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file header: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	detected := mimetype.Detect(head[:n])
+	check := &contentTypeCheck{
+		Detected:      normalizeContentType(detected.String()),
+		FromExtension: extensionContentType(fileName),
+		Declared:      normalizeContentType(declared),
+	}
+
+	// Nothing recognisable in the bytes: fall back to what the name or client claims.
+	if detected.Is("application/octet-stream") {
+		check.Resolved = firstNonEmpty(check.FromExtension, check.Declared, check.Detected)
+		return check, nil
+	}
+
+	check.Resolved = check.Detected
+	if check.FromExtension != "" {
+		specific, ok := compatibleContentType(detected, check.FromExtension)
+		if ok {
+			check.Resolved = specific
+		} else {
+			check.Mismatch = true
+		}
+	}
+	if check.Declared != "" && check.Declared != "application/octet-stream" {
+		if _, ok := compatibleContentType(detected, check.Declared); !ok {
+			check.Mismatch = true
+		}
+	}
+
+	return check, nil
+}
+
+// compatibleContentType reports whether claimed describes the same kind of content
+// as detected, returning the more specific of the two.
+func compatibleContentType(detected *mimetype.MIME, claimed string) (string, bool) {
+	// Detected is the same as, or a specialisation of, the claimed type (e.g. docx vs zip).
+	for m := detected; m != nil; m = m.Parent() {
+		if m.Is(claimed) && !m.Is("application/octet-stream") {
+			return normalizeContentType(detected.String()), true
+		}
+	}
+	// Claimed is a specialisation of what the bytes show (e.g. text/csv vs text/plain).
+	if claimedMIME := mimetype.Lookup(claimed); claimedMIME != nil {
+		for m := claimedMIME.Parent(); m != nil; m = m.Parent() {
+			if m.Is(detected.String()) && !m.Is("application/octet-stream") {
+				return claimed, true
+			}
+		}
+	}
+	// Plain-text formats are indistinguishable by magic number; trust the more specific claim.
+	if strings.HasPrefix(claimed, "text/") && detected.Is("text/plain") {
+		return claimed, true
+	}
+	return "", false
+}
+
+// extensionContentType maps a file name to its expected content type.
+func extensionContentType(fileName string) string {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if ext == "" {
+		return ""
+	}
+	if mt := domain.GetMediaTypeFromExtension(ext); mt != "" {
+		return normalizeContentType(string(mt))
+	}
+	return normalizeContentType(mime.TypeByExtension(ext))
+}
+
+// normalizeContentType strips parameters and folds common aliases.
+func normalizeContentType(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.Split(contentType, ";")[0])
+	}
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == "image/jpg" {
+		return "image/jpeg"
+	}
+	return mediaType
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"mime/multipart"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/adapters/local"
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/modules/media/port"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+// testPNG is the smallest valid PNG file: a single transparent pixel
+var testPNG = []byte{
+	0x89, 0x50, 0x4e, 0x47, 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x48, 0x44, 0x52,
+	0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x08, 0x06, 0x00, 0x00, 0x00, 0x1f, 0x15, 0xc4,
+	0x89, 0x00, 0x00, 0x00, 0x0d, 0x49, 0x44, 0x41, 0x54, 0x78, 0x9c, 0x63, 0x00, 0x01, 0x00, 0x00,
+	0x05, 0x00, 0x01, 0x0d, 0x0a, 0x2d, 0xb4, 0x00, 0x00, 0x00, 0x00, 0x49, 0x45, 0x4e, 0x44, 0xae,
+	0x42, 0x60, 0x82,
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	return log
+}
+
+// newTestProvider returns a local provider storing under a temporary directory, and the directory
+func newTestProvider(t *testing.T, log logger.Logger) (storagePort.StorageProvider, string) {
+	t.Helper()
+	root := t.TempDir()
+	provider, err := local.NewLocalStorageProvider(config.LocalStorageConfig{Path: root, BaseURL: "http://localhost/files", SignedURLSecret: "test-secret"}, log, clock.New())
+	if err != nil {
+		t.Fatalf("NewLocalStorageProvider: %v", err)
+	}
+	return provider, root
+}
+
+// countFiles returns how many regular files are stored under root
+func countFiles(t *testing.T, root string) int {
+	t.Helper()
+	count := 0
+	err := filepath.WalkDir(root, func(_ string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			count++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", root, err)
+	}
+	return count
+}
+
+// singleProviderFactory hands out one provider for every backend name
+type singleProviderFactory struct {
+	storagePort.StorageFactory
+	provider storagePort.StorageProvider
+}
+
+func (f singleProviderFactory) CreateProviderByName(string) (storagePort.StorageProvider, error) {
+	return f.provider, nil
+}
+
+// fakeUsers answers quota checks with err and reservations with reserveErr, and records
+// what was reserved, charged and released
+type fakeUsers struct {
+	authPort.UserService
+	err        error
+	reserveErr error
+
+	mu       sync.Mutex
+	reserved int64
+	recorded int64
+	released int64
+}
+
+func (u *fakeUsers) CanUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	return u.err
+}
+
+func (u *fakeUsers) CanUploadFiles(ctx context.Context, userID uuid.UUID, count int, size int64) error {
+	return u.err
+}
+
+func (u *fakeUsers) ReserveStorage(ctx context.Context, userID uuid.UUID, count int, size int64) error {
+	if u.reserveErr != nil {
+		return u.reserveErr
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.reserved += size
+	return nil
+}
+
+func (u *fakeUsers) RecordUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.recorded += size
+	return nil
+}
+
+func (u *fakeUsers) ReleaseStorage(ctx context.Context, userID uuid.UUID, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.released += size
+	return nil
+}
+
+// discardEvents drops published events
+type discardEvents struct{}
+
+func (discardEvents) Publish(ctx context.Context, eventType string, data any) error {
+	return nil
+}
+
+var _ port.EventPublisher = discardEvents{}
+
+// newTestMediaService builds a media service without a database, for paths that fail
+// before a record is written
+func newTestMediaService(t *testing.T, provider storagePort.StorageProvider, users authPort.UserService, cfg config.MediaConfig) *mediaService {
+	t.Helper()
+	log := newTestLogger(t)
+	return NewMediaService(context.Background(), nil, log, singleProviderFactory{provider: provider}, nil, nil, nil, users, discardEvents{}, nil, cfg).(*mediaService)
+}
+
+// newFileHeader returns the multipart file header of a form upload of data named fileName
+func newFileHeader(t *testing.T, fileName string, data []byte) *multipart.FileHeader {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("CreateFormFile: %v", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		t.Fatalf("write form file: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("close form: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatalf("ParseMultipartForm: %v", err)
+	}
+	return req.MultipartForm.File["file"][0]
+}
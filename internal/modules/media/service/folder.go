@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// maxFolderNameLength is the longest folder name accepted.
+const maxFolderNameLength = 255
+
+// CreateFolder implements port.MediaService.
+func (s *mediaService) CreateFolder(ctx context.Context, userID uuid.UUID, req *domain.CreateFolderRequest) (*domain.Folder, error) {
+	name, err := validateFolderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if _, err := s.GetFolder(ctx, userID, *req.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkFolderNameFree(ctx, userID, req.ParentID, name, uuid.Nil); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	folder := &domain.Folder{
+		ID:        uuid.New(),
+		UserID:    userID,
+		ParentID:  req.ParentID,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.WithContext(ctx).Create(folder).Error; err != nil {
+		s.logger.Error(ctx, "Failed to create folder", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to create folder: %w", err)
+	}
+	return folder, nil
+}
+
+// ListFolders implements port.MediaService. A nil parent lists all of the user's folders,
+// uuid.Nil the top-level ones, and any other ID the children of that folder.
+func (s *mediaService) ListFolders(ctx context.Context, userID uuid.UUID, parent *uuid.UUID) ([]*domain.Folder, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if parent != nil {
+		if *parent == uuid.Nil {
+			query = query.Where("parent_id IS NULL")
+		} else {
+			query = query.Where("parent_id = ?", *parent)
+		}
+	}
+
+	var folders []*domain.Folder
+	if err := query.Order("name ASC").Find(&folders).Error; err != nil {
+		s.logger.Error(ctx, "Failed to list folders", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	return folders, nil
+}
+
+// GetFolder implements port.MediaService.
+func (s *mediaService) GetFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID) (*domain.Folder, error) {
+	var folder domain.Folder
+	if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", folderID, userID).First(&folder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("folder not found")
+		}
+		s.logger.Error(ctx, "Failed to get folder", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to get folder: %w", err)
+	}
+	return &folder, nil
+}
+
+// RenameFolder implements port.MediaService.
+func (s *mediaService) RenameFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, req *domain.RenameFolderRequest) (*domain.Folder, error) {
+	name, err := validateFolderName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+	folder, err := s.GetFolder(ctx, userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkFolderNameFree(ctx, userID, folder.ParentID, name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.Name = name
+	folder.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Model(folder).Updates(map[string]any{"name": folder.Name, "updated_at": folder.UpdatedAt}).Error; err != nil {
+		s.logger.Error(ctx, "Failed to rename folder", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to rename folder: %w", err)
+	}
+	return folder, nil
+}
+
+// MoveFolder implements port.MediaService. A folder cannot be moved into itself or
+// any folder nested in it.
+func (s *mediaService) MoveFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, req *domain.MoveFolderRequest) (*domain.Folder, error) {
+	folder, err := s.GetFolder(ctx, userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParentID != nil {
+		if _, err := s.GetFolder(ctx, userID, *req.ParentID); err != nil {
+			return nil, err
+		}
+		subtree, err := s.folderSubtree(ctx, userID, folder.ID)
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(subtree, *req.ParentID) {
+			return nil, errors.NewBadRequestError("a folder cannot be moved into itself or one of its subfolders")
+		}
+	}
+	if err := s.checkFolderNameFree(ctx, userID, req.ParentID, folder.Name, folder.ID); err != nil {
+		return nil, err
+	}
+
+	folder.ParentID = req.ParentID
+	folder.UpdatedAt = time.Now()
+	if err := s.db.WithContext(ctx).Model(folder).Updates(map[string]any{"parent_id": folder.ParentID, "updated_at": folder.UpdatedAt}).Error; err != nil {
+		s.logger.Error(ctx, "Failed to move folder", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to move folder: %w", err)
+	}
+	return folder, nil
+}
+
+// DeleteFolder implements port.MediaService. A folder with subfolders or media is only
+// deleted with force, which deletes its whole subtree including the media files. A dry
+// run reports what would be deleted without deleting anything.
+func (s *mediaService) DeleteFolder(ctx context.Context, userID uuid.UUID, folderID uuid.UUID, force, dryRun bool) (*domain.FolderDeleteSummary, error) {
+	if _, err := s.GetFolder(ctx, userID, folderID); err != nil {
+		return nil, err
+	}
+	subtree, err := s.folderSubtree(ctx, userID, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	var media []*domain.Media
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND folder_id IN ?", userID, subtree).Find(&media).Error; err != nil {
+		s.logger.Error(ctx, "Failed to list folder media", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to list folder media: %w", err)
+	}
+
+	summary := &domain.FolderDeleteSummary{FolderID: folderID, Folders: len(subtree), Media: len(media), DryRun: dryRun}
+	if dryRun {
+		return summary, nil
+	}
+	if !force && (len(subtree) > 1 || len(media) > 0) {
+		return nil, errors.NewConflictError("folder is not empty; delete with force=true to remove its contents too").
+			WithDetails(map[string]any{"subfolders": len(subtree) - 1, "media": len(media)})
+	}
+
+	// Media first, so a failure leaves the folders listing whatever was not deleted
+	for _, m := range media {
+		if err := s.DeleteMedia(ctx, userID, m.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete media %s in folder: %w", m.ID, err)
+		}
+	}
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND id IN ?", userID, subtree).Delete(&domain.Folder{}).Error; err != nil {
+		s.logger.Error(ctx, "Failed to delete folders", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to delete folders: %w", err)
+	}
+
+	s.logger.Info(ctx, "Folder deleted", map[string]any{"folderID": folderID.String(), "folders": summary.Folders, "media": summary.Media})
+	return summary, nil
+}
+
+// MoveMedia implements port.MediaService.
+func (s *mediaService) MoveMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.MoveMediaRequest) (*domain.Media, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if req.FolderID != nil {
+		if _, err := s.GetFolder(ctx, userID, *req.FolderID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Update("folder_id", req.FolderID).Error; err != nil {
+		s.logger.Error(ctx, "Failed to move media", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to move media: %w", err)
+	}
+	media.FolderID = req.FolderID
+	return media, nil
+}
+
+// folderSubtree returns the IDs of folderID and every folder nested in it.
+func (s *mediaService) folderSubtree(ctx context.Context, userID uuid.UUID, folderID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := s.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE subtree AS (
+			SELECT id FROM media_folders WHERE id = ? AND user_id = ?
+			UNION
+			SELECT f.id FROM media_folders f JOIN subtree t ON f.parent_id = t.id
+		)
+		SELECT id FROM subtree`, folderID, userID).Scan(&ids).Error
+	if err != nil {
+		s.logger.Error(ctx, "Failed to load folder subtree", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to load folder subtree: %w", err)
+	}
+	return ids, nil
+}
+
+// checkFolderNameFree rejects a name already used by another folder with the same parent.
+func (s *mediaService) checkFolderNameFree(ctx context.Context, userID uuid.UUID, parentID *uuid.UUID, name string, exceptID uuid.UUID) error {
+	query := s.db.WithContext(ctx).Model(&domain.Folder{}).Where("user_id = ? AND name = ? AND id <> ?", userID, name, exceptID)
+	if parentID == nil {
+		query = query.Where("parent_id IS NULL")
+	} else {
+		query = query.Where("parent_id = ?", *parentID)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check folder name: %w", err)
+	}
+	if count > 0 {
+		return errors.NewConflictError(fmt.Sprintf("a folder named %q already exists here", name))
+	}
+	return nil
+}
+
+// validateFolderName trims name and checks it is usable as a folder name.
+func validateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", errors.NewBadRequestError("folder name is required")
+	case len(name) > maxFolderNameLength:
+		return "", errors.NewBadRequestError(fmt.Sprintf("folder name must be at most %d bytes", maxFolderNameLength))
+	case strings.Contains(name, "/"):
+		return "", errors.NewBadRequestError("folder name must not contain '/'")
+	}
+	return name, nil
+}
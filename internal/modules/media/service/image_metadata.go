@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/rwcarlsen/goexif/exif"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+const (
+	// strippedImageQuality is the JPEG quality images are re-encoded with when their EXIF is removed.
+	strippedImageQuality = 95
+	// maxEXIFSegmentSize bounds the bytes read past an image header to find its EXIF orientation;
+	// the JPEG APP1 segment holding EXIF is at most 64 KiB.
+	maxEXIFSegmentSize = 64 << 10
+)
+
+// prepareImageUpload reads the dimensions and EXIF data of an image upload and, when
+// StripEXIF is set, replaces a JPEG that carries EXIF with a re-encoded copy without it.
+// It returns the content and size to store, which are file's own unless EXIF was
+// stripped, and the image's metadata, or nil when nothing could be read.
+func (s *mediaService) prepareImageUpload(ctx context.Context, file io.ReadSeeker, size int64, contentType string) (io.ReadSeeker, int64, *domain.ImageMetadata, error) {
+	metadata := &domain.ImageMetadata{}
+
+	if header, _, err := image.DecodeConfig(file); err == nil {
+		metadata.Width, metadata.Height = header.Width, header.Height
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	hasEXIF := readEXIF(file, metadata)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to rewind file: %w", err)
+	}
+
+	if !hasEXIF || !s.config.StripEXIF || contentType != string(domain.MediaTypeJPEG) {
+		if metadata.IsEmpty() {
+			return file, size, nil, nil
+		}
+		return file, size, metadata, nil
+	}
+
+	// Stripping must not silently fail, or the location would be stored after all
+	if metadata.Width*metadata.Height > s.resizer.cfg.MaxSourcePixels {
+		return nil, 0, nil, errImageTooLarge
+	}
+	img, err := imaging.Decode(file, imaging.AutoOrientation(true))
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to decode image to strip EXIF", map[string]any{"error": err})
+		return nil, 0, nil, errors.NewBadRequestError("image could not be decoded to remove its EXIF data")
+	}
+	data, err := encodeImage(img, domain.ImageFormatJPEG, strippedImageQuality)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	bounds := img.Bounds()
+	metadata.Width, metadata.Height = bounds.Dx(), bounds.Dy()
+	metadata.Orientation = 0
+	metadata.GPS = nil
+	metadata.EXIFStripped = true
+	return bytes.NewReader(data), int64(len(data)), metadata, nil
+}
+
+// readEXIF copies the camera, orientation and GPS tags of r into metadata and reports
+// whether r carries EXIF data. Files without EXIF are not an error.
+func readEXIF(r io.Reader, metadata *domain.ImageMetadata) bool {
+	x, err := exif.Decode(r)
+	if x == nil || (err != nil && exif.IsCriticalError(err)) {
+		return false
+	}
+
+	if tag, err := x.Get(exif.Make); err == nil {
+		if v, err := tag.StringVal(); err == nil {
+			metadata.CameraMake = strings.TrimSpace(v)
+		}
+	}
+	if tag, err := x.Get(exif.Model); err == nil {
+		if v, err := tag.StringVal(); err == nil {
+			metadata.CameraModel = strings.TrimSpace(v)
+		}
+	}
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if v, err := tag.Int(0); err == nil {
+			metadata.Orientation = v
+		}
+	}
+	if lat, long, err := x.LatLong(); err == nil {
+		metadata.GPS = &domain.GPSCoordinates{Latitude: lat, Longitude: long}
+	}
+
+	// Rotated orientations store the pixels transposed; report the displayed size
+	if metadata.Orientation >= 5 {
+		metadata.Width, metadata.Height = metadata.Height, metadata.Width
+	}
+	return true
+}
+
+// readImageDimensions sets the displayed width and height of an image media from the
+// header of its stored object, for uploads that went to the provider directly. Only the
+// header and, for JPEGs, the EXIF segment are downloaded. It reports whether the
+// dimensions were read; formats the image package cannot decode, such as SVG, are skipped.
+func (s *mediaService) readImageDimensions(ctx context.Context, provider storagePort.StorageProvider, media *domain.Media) bool {
+	if media.MediaType != "image" {
+		return false
+	}
+	reader, _, err := provider.Download(ctx, media.FilePath)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to download image to read its dimensions", map[string]any{"error": err, "key": media.FilePath})
+		return false
+	}
+	defer reader.Close()
+
+	// The header bytes are kept so the EXIF orientation can be read from them too
+	var head bytes.Buffer
+	header, format, err := image.DecodeConfig(io.TeeReader(reader, &head))
+	if err != nil {
+		if !errors.Is(err, image.ErrFormat) {
+			s.logger.Warn(ctx, "Failed to read image dimensions", map[string]any{"error": err, "key": media.FilePath})
+		}
+		return false
+	}
+	metadata := &domain.ImageMetadata{Width: header.Width, Height: header.Height}
+	if format == "jpeg" {
+		readEXIF(io.MultiReader(&head, io.LimitReader(reader, maxEXIFSegmentSize)), metadata)
+	}
+	media.Width, media.Height = metadata.Width, metadata.Height
+	return true
+}
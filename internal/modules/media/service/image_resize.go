@@ -0,0 +1,195 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+
+	"github.com/disintegration/imaging"
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// errImageTooLarge is returned for source images with more pixels than allowed, before
+// their pixel data is decoded.
+var errImageTooLarge = errors.NewError(http.StatusBadRequest, "image_too_large", "image dimensions exceed the processing limit")
+
+// imageResizer renders resized variants of images and caches them in the image's
+// provider, so repeated requests for the same variant are served from storage.
+type imageResizer struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	cfg            config.ImageResizeConfig
+	group          singleflight.Group // Concurrent requests for one variant share a single render
+}
+
+func newImageResizer(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, cfg config.ImageResizeConfig) *imageResizer {
+	if cfg.MaxDimension <= 0 {
+		cfg.MaxDimension = 2048
+	}
+	if cfg.MaxSourcePixels <= 0 {
+		cfg.MaxSourcePixels = 40_000_000
+	}
+	if cfg.Quality <= 0 || cfg.Quality > 100 {
+		cfg.Quality = 85
+	}
+
+	return &imageResizer{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "ImageResizer"}),
+		storageFactory: storageFactory,
+		cfg:            cfg,
+	}
+}
+
+// validate applies the defaults to opts and rejects requests outside the configured limits.
+func (r *imageResizer) validate(opts *domain.ResizeOptions) error {
+	if opts.Fit == "" {
+		opts.Fit = domain.ImageFitContain
+	}
+	if opts.Format == "" {
+		opts.Format = domain.ImageFormatJPEG
+	}
+
+	if !opts.Fit.IsValid() {
+		return errors.NewBadRequestError("fit must be cover or contain")
+	}
+	if !opts.Format.IsValid() {
+		return errors.NewBadRequestError("format must be jpeg or png")
+	}
+	if opts.Width < 0 || opts.Width > r.cfg.MaxDimension || opts.Height < 0 || opts.Height > r.cfg.MaxDimension {
+		return errors.NewBadRequestError(fmt.Sprintf("w and h must be between 0 and %d", r.cfg.MaxDimension))
+	}
+	if opts.Width == 0 && opts.Height == 0 {
+		return errors.NewBadRequestError("w or h is required")
+	}
+	if opts.Fit == domain.ImageFitCover && (opts.Width == 0 || opts.Height == 0) {
+		return errors.NewBadRequestError("cover requires both w and h")
+	}
+	return nil
+}
+
+// resize renders the variant of media described by opts, stores it under its variant
+// key and returns the encoded image.
+func (r *imageResizer) resize(ctx context.Context, provider storagePort.StorageProvider, media *domain.Media, opts domain.ResizeOptions) ([]byte, error) {
+	key := opts.VariantKey(media.FilePath)
+	result, err, _ := r.group.Do(key, func() (any, error) {
+		src, err := downloadImage(ctx, provider, media.FilePath, r.cfg.MaxSourcePixels)
+		if err != nil {
+			return nil, err
+		}
+
+		var dst image.Image
+		if opts.Fit == domain.ImageFitCover {
+			dst = imaging.Fill(src, opts.Width, opts.Height, imaging.Center, imaging.Lanczos)
+		} else {
+			// An unbounded dimension follows the aspect ratio; Fit never upscales
+			width, height := opts.Width, opts.Height
+			if width == 0 {
+				width = math.MaxInt32
+			}
+			if height == 0 {
+				height = math.MaxInt32
+			}
+			dst = imaging.Fit(src, width, height, imaging.Lanczos)
+		}
+
+		data, err := encodeImage(dst, opts.Format, r.cfg.Quality)
+		if err != nil {
+			return nil, err
+		}
+
+		// Recorded before the upload so a stored variant is always removed with its media
+		if err := r.recordVariant(ctx, media.ID, key); err != nil {
+			return nil, fmt.Errorf("failed to record resized variant: %w", err)
+		}
+		if _, err := provider.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), &storagePort.UploadOptions{ContentType: opts.Format.ContentType()}); err != nil {
+			// The variant is still served; the next request tries to cache it again
+			r.logger.Warn(ctx, "Failed to cache resized image", map[string]any{"error": err, "key": key})
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]byte), nil
+}
+
+// recordVariant adds key to the media's resized variants. The row is locked so
+// concurrent renders of different variants do not overwrite each other's keys.
+func (r *imageResizer) recordVariant(ctx context.Context, mediaID uuid.UUID, key string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var media domain.Media
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "resized_keys").First(&media, "id = ?", mediaID).Error; err != nil {
+			return err
+		}
+		if slices.Contains(media.ResizedKeys, key) {
+			return nil
+		}
+		media.ResizedKeys = append(media.ResizedKeys, key)
+		return tx.Model(&media).Select("resized_keys").Updates(&media).Error
+	})
+}
+
+// downloadImage downloads and decodes an image, honoring its EXIF orientation. Images
+// with more than maxPixels pixels are rejected from their header, before decoding.
+func downloadImage(ctx context.Context, provider storagePort.StorageProvider, key string, maxPixels int) (image.Image, error) {
+	reader, _, err := provider.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+
+	header, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if header.Width*header.Height > maxPixels {
+		return nil, errImageTooLarge
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// encodeImage encodes img in format. Transparent areas are flattened onto white for
+// JPEG, which has no alpha channel.
+func encodeImage(img image.Image, format domain.ImageFormat, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case domain.ImageFormatPNG:
+		if err := imaging.Encode(&buf, img, imaging.PNG); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	default:
+		canvas := imaging.New(img.Bounds().Dx(), img.Bounds().Dy(), color.White)
+		canvas = imaging.Overlay(canvas, img, image.Pt(0, 0), 1.0)
+		if err := imaging.Encode(&buf, canvas, imaging.JPEG, imaging.JPEGQuality(quality)); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
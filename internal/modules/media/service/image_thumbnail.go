@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/disintegration/imaging"
+	logger "github.com/lugondev/go-log"
+	_ "golang.org/x/image/webp" // Register the WebP decoder for image.Decode
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// imageThumbnailTimeout bounds a single background thumbnail job, including download and upload.
+const imageThumbnailTimeout = 2 * time.Minute
+
+// imageThumbnailGenerator renders downscaled JPEG thumbnails of uploaded images so
+// galleries do not have to download full-resolution files.
+type imageThumbnailGenerator struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	cfg            config.ThumbnailConfig
+	maxPixels      int // Larger source images are not decoded
+	sem            chan struct{}
+	group          singleflight.Group // Background and on-demand generation of one media share a single run
+}
+
+func newImageThumbnailGenerator(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, cfg config.ThumbnailConfig, maxPixels int) *imageThumbnailGenerator {
+	if cfg.Size <= 0 {
+		cfg.Size = 300
+	}
+	if cfg.Quality <= 0 || cfg.Quality > 100 {
+		cfg.Quality = 80
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 4
+	}
+
+	return &imageThumbnailGenerator{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "ImageThumbnailGenerator"}),
+		storageFactory: storageFactory,
+		cfg:            cfg,
+		maxPixels:      maxPixels,
+		sem:            make(chan struct{}, cfg.MaxConcurrent),
+	}
+}
+
+// enabled reports whether thumbnails are configured.
+func (g *imageThumbnailGenerator) enabled() bool {
+	return g.cfg.Enabled
+}
+
+// enqueue generates the thumbnail for media in the background. Jobs beyond
+// MaxConcurrent wait for a free slot.
+func (g *imageThumbnailGenerator) enqueue(media *domain.Media) {
+	if !g.enabled() {
+		return
+	}
+
+	target := *media
+	go func() {
+		g.sem <- struct{}{}
+		defer func() { <-g.sem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), imageThumbnailTimeout)
+		defer cancel()
+
+		if _, err := g.generate(ctx, &target); err != nil {
+			g.logger.Error(ctx, "Failed to generate image thumbnail", map[string]any{"error": err, "mediaID": target.ID.String()})
+			return
+		}
+		g.logger.Info(ctx, "Image thumbnail generated", map[string]any{"mediaID": target.ID.String(), "thumbnailPath": target.ThumbnailPath})
+	}()
+}
+
+// generate renders the thumbnail of media, uploads it next to the image and records
+// its key and URL on the media row. It returns the encoded JPEG. Decoding fails with
+// image.ErrFormat for formats that cannot be thumbnailed, such as SVG, and with
+// errImageTooLarge for images above the pixel limit.
+func (g *imageThumbnailGenerator) generate(ctx context.Context, media *domain.Media) ([]byte, error) {
+	result, err, _ := g.group.Do(media.ID.String(), func() (any, error) {
+		return g.render(ctx, media)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	thumb := result.(*renderedThumbnail)
+	media.ThumbnailPath = thumb.key
+	media.ThumbnailURL = thumb.url
+	return thumb.data, nil
+}
+
+// renderedThumbnail is a stored thumbnail shared by the callers of one generation.
+type renderedThumbnail struct {
+	key  string
+	url  string
+	data []byte
+}
+
+func (g *imageThumbnailGenerator) render(ctx context.Context, media *domain.Media) (*renderedThumbnail, error) {
+	provider, err := g.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	img, err := downloadImage(ctx, provider, media.FilePath, g.maxPixels)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fit never upscales
+	data, err := encodeImage(imaging.Fit(img, g.cfg.Size, g.cfg.Size, imaging.Lanczos), domain.ImageFormatJPEG, g.cfg.Quality)
+	if err != nil {
+		return nil, err
+	}
+
+	key := media.FilePath + domain.ThumbnailSuffix
+	fileObject, err := provider.Upload(ctx, key, bytes.NewReader(data), int64(len(data)), &storagePort.UploadOptions{ContentType: "image/jpeg"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload thumbnail: %w", err)
+	}
+
+	if err := g.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(map[string]any{
+		"thumbnail_path": key,
+		"thumbnail_url":  fileObject.URL,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to record thumbnail on media: %w", err)
+	}
+	return &renderedThumbnail{key: key, url: fileObject.URL, data: data}, nil
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+)
+
+// publishEvent queues a storage event for its subscribers. The change it reports has
+// already been made, so a failure is logged rather than returned.
+func (s *mediaService) publishEvent(ctx context.Context, eventType string, data any) {
+	if err := s.events.Publish(ctx, eventType, data); err != nil {
+		s.logger.Error(ctx, "Failed to publish storage event", map[string]any{"error": err, "event": eventType})
+	}
+}
+
+// publishUploadFailed publishes an upload.failed event for an upload that ended with err.
+func (s *mediaService) publishUploadFailed(ctx context.Context, userID uuid.UUID, fileName string, size int64, provider string, err error) {
+	s.publishEvent(ctx, domain.EventUploadFailed, &domain.UploadFailedEvent{
+		UserID:   userID,
+		FileName: fileName,
+		FileSize: size,
+		Provider: provider,
+		Error:    err.Error(),
+	})
+}
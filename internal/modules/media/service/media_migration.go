@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// finishedMigrationRetention is how long reports of finished migrations stay available.
+const finishedMigrationRetention = 24 * time.Hour
+
+// mediaMigrator moves media objects between storage providers. Files are loaded in
+// batches ordered by ID and copied by a bounded set of workers that share one bandwidth
+// limiter. Each file is committed on its own: its row only points at the destination
+// once the copy succeeded, so a cancelled or failed run can simply be started again and
+// continues with the files still left in the source provider.
+type mediaMigrator struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	cfg            config.JobConfig
+	limiter        *rate.Limiter
+
+	mu   sync.Mutex
+	jobs map[uuid.UUID]*migrationJob
+}
+
+// migrationJob is a migration in progress or recently finished.
+type migrationJob struct {
+	mu     sync.Mutex
+	report domain.MigrationReport
+	cancel context.CancelFunc
+}
+
+func newMediaMigrator(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, cfg config.JobConfig) *mediaMigrator {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &mediaMigrator{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "MediaMigrator"}),
+		storageFactory: storageFactory,
+		cfg:            cfg,
+		limiter:        utils.NewBandwidthLimiter(cfg.BandwidthBytesPerSecond),
+		jobs:           make(map[uuid.UUID]*migrationJob),
+	}
+}
+
+// MigrateMedia implements port.MediaService. It moves every ready media file of userID
+// stored in fromProvider to toProvider and returns once all of them were attempted or
+// ctx is cancelled.
+func (s *mediaService) MigrateMedia(ctx context.Context, userID uuid.UUID, fromProvider, toProvider string) (*domain.MigrationReport, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	job, src, dst, err := s.migrator.start(ctx, userID, fromProvider, toProvider, cancel)
+	if err != nil {
+		return nil, err
+	}
+	s.migrator.run(ctx, job, src, dst)
+	return job.snapshot(), nil
+}
+
+// StartMediaMigration implements port.MediaService. It runs MigrateMedia in the background
+// and returns the job's initial report; follow it with GetMediaMigration.
+func (s *mediaService) StartMediaMigration(ctx context.Context, userID uuid.UUID, fromProvider, toProvider string) (*domain.MigrationReport, error) {
+	// The job outlives the request that started it
+	jobCtx, cancel := context.WithCancel(context.Background())
+
+	job, src, dst, err := s.migrator.start(ctx, userID, fromProvider, toProvider, cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		defer cancel()
+		s.migrator.run(jobCtx, job, src, dst)
+	}()
+	return job.snapshot(), nil
+}
+
+// GetMediaMigration implements port.MediaService.
+func (s *mediaService) GetMediaMigration(ctx context.Context, jobID uuid.UUID) (*domain.MigrationReport, error) {
+	job, err := s.migrator.job(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return job.snapshot(), nil
+}
+
+// CancelMediaMigration implements port.MediaService. Files being copied when the job is
+// cancelled are abandoned and stay in the source provider.
+func (s *mediaService) CancelMediaMigration(ctx context.Context, jobID uuid.UUID) (*domain.MigrationReport, error) {
+	job, err := s.migrator.job(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.cancel()
+	return job.snapshot(), nil
+}
+
+// start validates a migration, counts the files to move and registers the job.
+func (m *mediaMigrator) start(ctx context.Context, userID uuid.UUID, fromProvider, toProvider string, cancel context.CancelFunc) (*migrationJob, storagePort.StorageProvider, storagePort.StorageProvider, error) {
+	src, err := m.storageFactory.CreateProviderByName(fromProvider)
+	if err != nil {
+		return nil, nil, nil, errors.NewBadRequestError(fmt.Sprintf("unknown source provider %q: %v", fromProvider, err))
+	}
+	dst, err := m.storageFactory.CreateProviderByName(toProvider)
+	if err != nil {
+		return nil, nil, nil, errors.NewBadRequestError(fmt.Sprintf("unknown destination provider %q: %v", toProvider, err))
+	}
+	// Aliases resolve to backend names, which is what media rows record
+	from, to := storagePort.BackendName(src), storagePort.BackendName(dst)
+	if from == to {
+		return nil, nil, nil, errors.NewBadRequestError("source and destination provider must differ")
+	}
+
+	var total int64
+	if err := m.pendingMedia(ctx, userID, from).Count(&total).Error; err != nil {
+		m.logger.Error(ctx, "Failed to count media to migrate", map[string]any{"error": err})
+		return nil, nil, nil, fmt.Errorf("failed to count media to migrate: %w", err)
+	}
+
+	job := &migrationJob{
+		report: domain.MigrationReport{
+			JobID:        uuid.New(),
+			UserID:       userID,
+			FromProvider: from,
+			ToProvider:   to,
+			JobProgress:  utils.NewJobProgress(total, time.Now()),
+			Results:      utils.NewBatchResult[domain.MigratedMedia](int(total)),
+		},
+		cancel: cancel,
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, other := range m.jobs {
+		other.mu.Lock()
+		running := !other.report.Done()
+		sameSource := other.report.UserID == userID && other.report.FromProvider == from
+		expired := other.report.FinishedAt != nil && time.Since(*other.report.FinishedAt) > finishedMigrationRetention
+		other.mu.Unlock()
+
+		if running && sameSource {
+			return nil, nil, nil, errors.NewConflictError("a migration of this user's media from this provider is already running").
+				WithDetails(map[string]any{"job_id": id})
+		}
+		if expired {
+			delete(m.jobs, id)
+		}
+	}
+	m.jobs[job.report.JobID] = job
+
+	m.logger.Info(ctx, "Media migration started", map[string]any{
+		"jobID": job.report.JobID.String(), "userID": userID.String(), "from": from, "to": to, "total": total,
+	})
+	return job, src, dst, nil
+}
+
+// job returns a registered job.
+func (m *mediaMigrator) job(jobID uuid.UUID) (*migrationJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	job, ok := m.jobs[jobID]
+	if !ok {
+		return nil, errors.NewNotFoundError("migration job not found")
+	}
+	return job, nil
+}
+
+// pendingMedia selects the media of userID still stored in provider.
+func (m *mediaMigrator) pendingMedia(ctx context.Context, userID uuid.UUID, provider string) *gorm.DB {
+	return m.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("user_id = ? AND provider = ? AND status = ?", userID, provider, domain.StatusReady)
+}
+
+// run migrates the job's files batch by batch until none are left or ctx is cancelled.
+func (m *mediaMigrator) run(ctx context.Context, job *migrationJob, src, dst storagePort.StorageProvider) {
+	report := job.snapshot()
+	var after uuid.UUID
+	var runErr error
+
+	for ctx.Err() == nil {
+		// Failed files keep their provider, so page by ID rather than re-querying from the start
+		var batch []*domain.Media
+		if err := m.pendingMedia(ctx, report.UserID, report.FromProvider).
+			Where("id > ?", after).
+			Order("id").
+			Limit(m.cfg.BatchSize).
+			Find(&batch).Error; err != nil {
+			if ctx.Err() == nil {
+				runErr = fmt.Errorf("failed to load media to migrate: %w", err)
+			}
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		sem := make(chan struct{}, m.cfg.Workers)
+		var wg sync.WaitGroup
+		for _, media := range batch {
+			sem <- struct{}{}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func(media *domain.Media) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				migrated, err := m.migrateOne(ctx, src, dst, media)
+				if err != nil && ctx.Err() != nil {
+					return // Abandoned by cancellation; the file stays in src for the next run
+				}
+				job.record(media.ID, migrated, err)
+			}(media)
+		}
+		wg.Wait()
+		after = batch[len(batch)-1].ID
+	}
+
+	status := utils.JobCompleted
+	switch {
+	case runErr != nil:
+		status = utils.JobFailed
+	case ctx.Err() != nil:
+		status = utils.JobCancelled
+	}
+	job.finish(status, runErr)
+
+	final := job.snapshot()
+	m.logger.Info(ctx, "Media migration finished", map[string]any{
+		"jobID": final.JobID.String(), "status": final.Status, "succeeded": final.Results.Summary.Succeeded,
+		"failed": final.Results.Summary.Failed, "bytesMoved": final.BytesMoved,
+	})
+}
+
+// migrateOne copies one media file and its preview assets to dst, points the row at the
+// copy and then removes the originals from src.
+func (m *mediaMigrator) migrateOne(ctx context.Context, src, dst storagePort.StorageProvider, media *domain.Media) (*domain.MigratedMedia, error) {
+	fileObject, err := m.copyObject(ctx, src, dst, media.FilePath, media.ContentType, media.FileSize)
+	if err != nil {
+		return nil, err
+	}
+	newKey := fileObject.Key
+	if newKey == "" {
+		newKey = media.FilePath
+	}
+	// copied lists every object written to dst in this run, so none is left behind when
+	// the row does not end up pointing at it
+	copied := []string{newKey}
+	copyPreview := func(key, contentType string) (*storagePort.FileObject, error) {
+		object, err := m.copyObject(ctx, src, dst, key, contentType, -1)
+		if err == nil {
+			copied = append(copied, key)
+		}
+		return object, err
+	}
+
+	updates := map[string]any{
+		"provider":     storagePort.BackendName(dst),
+		"file_path":    newKey,
+		"public_url":   fileObject.URL,
+		"resized_keys": nil, // Resized variants are a cache and are rebuilt on request
+		"updated_at":   time.Now(),
+	}
+	if fileObject.ETag != "" {
+		updates["etag"] = fileObject.ETag
+	}
+
+	// Previews are best-effort: a thumbnail that fails to copy is regenerated on request,
+	// a sprite is dropped so the row never points at an object dst does not have.
+	var moved []string
+	if media.HasThumbnail() {
+		if thumb, err := copyPreview(media.ThumbnailPath, "image/jpeg"); err != nil {
+			m.logger.Warn(ctx, "Failed to migrate image thumbnail", map[string]any{"error": err, "mediaID": media.ID.String()})
+			updates["thumbnail_path"], updates["thumbnail_url"] = "", ""
+		} else {
+			updates["thumbnail_url"] = thumb.URL
+			moved = append(moved, media.ThumbnailPath)
+		}
+	}
+	if media.HasSprite() {
+		_, spriteErr := copyPreview(media.SpritePath, "image/jpeg")
+		_, vttErr := copyPreview(media.SpriteVTTPath, "text/vtt")
+		if spriteErr != nil || vttErr != nil {
+			m.logger.Warn(ctx, "Failed to migrate video sprite", map[string]any{"spriteError": spriteErr, "vttError": vttErr, "mediaID": media.ID.String()})
+			updates["sprite_path"], updates["sprite_vtt_path"] = "", ""
+		} else {
+			moved = append(moved, media.SpritePath, media.SpriteVTTPath)
+		}
+	}
+	if media.HasTranscoded() {
+		if video, err := copyPreview(media.TranscodedPath, "video/mp4"); err != nil {
+			m.logger.Warn(ctx, "Failed to migrate transcoded video", map[string]any{"error": err, "mediaID": media.ID.String()})
+			updates["transcoded_path"], updates["transcoded_url"] = "", ""
+		} else {
+			updates["transcoded_url"] = video.URL
+			moved = append(moved, media.TranscodedPath)
+		}
+	}
+	if media.HasHLS() {
+		// Segments go first so the playlist never references a segment dst does not have
+		var hlsErr error
+		for _, key := range media.HLSSegmentKeys {
+			if _, hlsErr = copyPreview(key, "video/mp2t"); hlsErr != nil {
+				break
+			}
+		}
+		var playlist *storagePort.FileObject
+		if hlsErr == nil {
+			playlist, hlsErr = copyPreview(media.HLSPath, "application/vnd.apple.mpegurl")
+		}
+		if hlsErr != nil {
+			m.logger.Warn(ctx, "Failed to migrate HLS rendition", map[string]any{"error": hlsErr, "mediaID": media.ID.String()})
+			updates["hls_path"], updates["hls_url"], updates["hls_segment_keys"] = "", "", nil
+		} else {
+			updates["hls_url"] = playlist.URL
+			moved = append(append(moved, media.HLSSegmentKeys...), media.HLSPath)
+		}
+	}
+
+	// The row may have been deleted or moved while the copy ran; then every copy is orphaned
+	result := m.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("id = ? AND provider = ?", media.ID, media.Provider).
+		Updates(updates)
+	if result.Error != nil || result.RowsAffected == 0 {
+		m.removeCopies(ctx, dst, copied)
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to update media record: %w", result.Error)
+		}
+		return nil, fmt.Errorf("media record changed during migration")
+	}
+
+	// Parts of a preview that was dropped from the row are orphaned in dst as well
+	kept := append([]string{newKey}, moved...)
+	m.removeCopies(ctx, dst, slices.DeleteFunc(copied, func(key string) bool { return slices.Contains(kept, key) }))
+
+	// The row now points at dst; leftovers in src only cost space, so failures are logged
+	for _, key := range append(append(moved, media.ResizedKeys...), media.FilePath) {
+		if err := src.Delete(context.WithoutCancel(ctx), key); err != nil {
+			m.logger.Warn(ctx, "Failed to delete migrated object from source provider", map[string]any{"error": err, "key": key})
+		}
+	}
+
+	return &domain.MigratedMedia{MediaID: media.ID, FilePath: newKey, Bytes: media.FileSize}, nil
+}
+
+// removeCopies deletes objects copied to dst that no media row points at. Failures only
+// cost space, so they are logged.
+func (m *mediaMigrator) removeCopies(ctx context.Context, dst storagePort.StorageProvider, keys []string) {
+	for _, key := range keys {
+		if err := dst.Delete(context.WithoutCancel(ctx), key); err != nil {
+			m.logger.Warn(ctx, "Failed to remove orphaned migration copy", map[string]any{"error": err, "key": key})
+		}
+	}
+}
+
+// copyObject streams key from src to dst through the shared bandwidth limiter. A negative
+// size is taken from the source object.
+func (m *mediaMigrator) copyObject(ctx context.Context, src, dst storagePort.StorageProvider, key, contentType string, size int64) (*storagePort.FileObject, error) {
+	reader, obj, err := src.Download(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer reader.Close()
+
+	if size < 0 && obj != nil {
+		size = obj.Size
+	}
+	if contentType == "" && obj != nil {
+		contentType = obj.ContentType
+	}
+
+	fileObject, err := dst.Upload(ctx, key, utils.ThrottleReader(ctx, reader, m.limiter), size, &storagePort.UploadOptions{ContentType: contentType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return fileObject, nil
+}
+
+// record adds the outcome of one file to the report.
+func (j *migrationJob) record(mediaID uuid.UUID, migrated *domain.MigratedMedia, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.report.Results.Fail(mediaID.String(), err)
+	} else {
+		j.report.Results.Succeed(mediaID.String(), *migrated)
+		j.report.BytesMoved += migrated.Bytes
+	}
+	j.report.Advance(1, time.Now())
+}
+
+// finish marks the job as stopped.
+func (j *migrationJob) finish(status utils.JobStatus, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.report.Finish(status, err, time.Now())
+}
+
+// snapshot returns a copy of the report that is safe to read while the job runs.
+func (j *migrationJob) snapshot() *domain.MigrationReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	report := j.report
+	report.Results = &utils.BatchResult[domain.MigratedMedia]{
+		Results: slices.Clone(j.report.Results.Results),
+		Summary: j.report.Results.Summary,
+	}
+	return &report
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// errDatabaseDown is returned by every statement run on failingConn
+var errDatabaseDown = fmt.Errorf("database is down")
+
+// failingConn is a connection pool on which every statement fails
+type failingConn struct{}
+
+func (failingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingConn) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingConn) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errDatabaseDown
+}
+
+func (failingConn) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	panic("QueryRowContext is not supported by failingConn")
+}
+
+func TestMigrateOneRemovesCopiesWhenRowUpdateFails(t *testing.T) {
+	ctx := context.Background()
+	log := newTestLogger(t)
+	src, srcRoot := newTestProvider(t, log)
+	dst, dstRoot := newTestProvider(t, log)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: failingConn{}}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 gormLogger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("gorm.Open: %v", err)
+	}
+
+	media := &domain.Media{
+		ID:            uuid.New(),
+		FilePath:      "user/video/clip.mp4",
+		FileSize:      4,
+		ContentType:   "video/mp4",
+		Provider:      "local",
+		SpritePath:    "user/video/clip.sprite.jpg",
+		SpriteVTTPath: "user/video/clip.sprite.vtt",
+		HLSPath:       "user/video/clip/index.m3u8",
+		HLSSegmentKeys: []string{
+			"user/video/clip/segment0.ts",
+			"user/video/clip/segment1.ts",
+		},
+	}
+	keys := append([]string{media.FilePath, media.SpritePath, media.SpriteVTTPath, media.HLSPath}, media.HLSSegmentKeys...)
+	for _, key := range keys {
+		if _, err := src.Upload(ctx, key, bytes.NewReader([]byte("data")), 4, &storagePort.UploadOptions{ContentType: "application/octet-stream"}); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+
+	migrator := newMediaMigrator(db, log, singleProviderFactory{provider: dst}, config.JobConfig{})
+	if _, err := migrator.migrateOne(ctx, src, dst, media); err == nil {
+		t.Fatal("migrateOne succeeded although the media row could not be updated")
+	}
+
+	// The row still points at src, so nothing may be left in dst and nothing removed from src
+	if n := countFiles(t, dstRoot); n != 0 {
+		t.Errorf("destination holds %d orphaned copies, want 0", n)
+	}
+	if n := countFiles(t, srcRoot); n != len(keys) {
+		t.Errorf("source holds %d files, want all %d", n, len(keys))
+	}
+}
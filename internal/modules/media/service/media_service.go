@@ -1,40 +1,135 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"image"
+	"io"
+	"mime"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	logger "github.com/lugondev/go-log" // Import custom logger
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/infra/ffmpeg"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
 	"github.com/lugondev/m3-storage/internal/modules/media/domain"
 	"github.com/lugondev/m3-storage/internal/modules/media/port"
 	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
 	"github.com/lugondev/m3-storage/internal/shared/utils"
 )
 
+// errMediaNotFound is returned for media IDs with no matching record. It wraps
+// storagePort.ErrObjectNotFound so handlers treat missing records and missing objects alike.
+var errMediaNotFound = fmt.Errorf("media file %w", storagePort.ErrObjectNotFound)
+
 type mediaService struct {
 	db             *gorm.DB
 	logger         logger.Logger
 	storageFactory storagePort.StorageFactory
+	config         config.MediaConfig
+	sprites        *videoSpriteGenerator
+	posters        *videoPosterGenerator
+	transcoder     *videoTranscoder
+	thumbnails     *imageThumbnailGenerator
+	resizer        *imageResizer
+	signedURLs     *signedURLCoalescer
+	cache          appPort.CacheService
+	migrator       *mediaMigrator
+	reconciler     *usageReconciler
+	scanner        port.Scanner
+	bandwidth      *bandwidthThrottle
+	validator      *MediaValidator
+
+	multipartSessions port.MultipartSessionStore
+	uploadProgress    *uploadProgressTracker
+	uploads           *inFlightUploads // Uploads being written, waited for on shutdown
+	tusUploads        port.TusUploadStore
+	tusWriting        sync.Map     // IDs of tus uploads a PATCH is writing to
+	tusLastSweep      atomic.Int64 // Unix nanoseconds of the last temp file sweep
+	users             authPort.UserService
+	events            port.EventPublisher
 }
 
 // NewMediaService creates a new MediaService.
-func NewMediaService(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory) port.MediaService {
+func NewMediaService(baseCtx context.Context, db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, cache appPort.CacheService, multipartSessions port.MultipartSessionStore, tusUploads port.TusUploadStore, users authPort.UserService, events port.EventPublisher, usageSources []port.UsageSource, cfg config.MediaConfig) port.MediaService {
+	runner := ffmpeg.NewRunner(cfg.FFmpegPath, cfg.FFprobePath)
+	resizer := newImageResizer(db, appLogger, storageFactory, cfg.ImageResize)
 	return &mediaService{
 		db:             db,
 		logger:         appLogger.WithFields(map[string]any{"component": "MediaService"}),
 		storageFactory: storageFactory,
+		config:         cfg,
+		sprites:        newVideoSpriteGenerator(db, appLogger, storageFactory, runner, cfg.VideoSprite),
+		posters:        newVideoPosterGenerator(db, appLogger, storageFactory, runner, cfg.VideoPoster),
+		transcoder:     newVideoTranscoder(baseCtx, db, appLogger, storageFactory, runner, cfg.Transcode),
+		thumbnails:     newImageThumbnailGenerator(db, appLogger, storageFactory, cfg.Thumbnail, resizer.cfg.MaxSourcePixels),
+		resizer:        resizer,
+		signedURLs:     newSignedURLCoalescer(cache, appLogger, cfg.SignedURLCache),
+		cache:          cache,
+		migrator:       newMediaMigrator(db, appLogger, storageFactory, cfg.Migration),
+		reconciler:     newUsageReconciler(db, appLogger, users, cache, usageSources, cfg.UsageReconcile.JobConfig),
+		scanner:        newScanner(cfg.VirusScan, appLogger),
+		bandwidth:      newBandwidthThrottle(cfg.Bandwidth, users, appLogger),
+		validator:      NewMediaValidator(cfg),
+
+		multipartSessions: multipartSessions,
+		uploadProgress:    newUploadProgressTracker(),
+		uploads:           newInFlightUploads(baseCtx),
+		tusUploads:        tusUploads,
+		users:             users,
+		events:            events,
 	}
 }
 
 // UploadFile implements port.MediaService.
 func (s *mediaService) UploadFile(ctx context.Context, userID uuid.UUID, fileHeader *multipart.FileHeader, providerName string, mediaTypeHint string) (*domain.Media, error) {
+	return s.uploadFile(ctx, userID, fileHeader, providerName, mediaTypeHint, uploadSettings{})
+}
+
+// uploadSettings adjusts uploadFile for callers other than a plain single upload
+type uploadSettings struct {
+	// reserved is how many bytes the caller already added to the user's storage usage
+	// with ReserveStorage. Otherwise uploadFile reserves the file's size itself. The
+	// reservation is settled to the stored size, or released when no new media is stored.
+	reserved int64
+	// keepDuplicate stores a new media record even when duplicate uploads are deduped,
+	// for callers that delete the media they get back later
+	keepDuplicate bool
+}
+
+// uploadFile stores one uploaded file
+func (s *mediaService) uploadFile(ctx context.Context, userID uuid.UUID, fileHeader *multipart.FileHeader, providerName string, mediaTypeHint string, settings uploadSettings) (_ *domain.Media, err error) {
+	reserved := settings.reserved
+	recorded := false
+	defer func() {
+		if reserved > 0 && !recorded {
+			s.releaseStorage(ctx, userID, reserved)
+		}
+		if err != nil {
+			s.publishUploadFailed(ctx, userID, fileHeader.Filename, fileHeader.Size, providerName, err)
+		}
+	}()
+
+	ctx, done, err := s.uploads.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
 	s.logger.Info(ctx, "Starting file upload process", map[string]any{
 		"userID":        userID.String(),
 		"fileName":      fileHeader.Filename,
@@ -42,92 +137,120 @@ func (s *mediaService) UploadFile(ctx context.Context, userID uuid.UUID, fileHea
 		"mediaTypeHint": mediaTypeHint,
 	})
 
+	if reserved == 0 {
+		if err := s.reserveUpload(ctx, userID, fileHeader.Size); err != nil {
+			return nil, err
+		}
+		reserved = fileHeader.Size
+	}
+
+	// Unsupported and oversized files are rejected before a provider is involved
+	file, err := fileHeader.Open()
+	if err != nil {
+		s.logger.Error(ctx, "Failed to open file header", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	validatedType, err := s.validator.ValidateFile(fileHeader, file)
+	if err != nil {
+		s.logger.Warn(ctx, "Upload rejected by media validator", map[string]any{"error": err, "fileName": fileHeader.Filename, "fileSize": fileHeader.Size})
+		return nil, err
+	}
+	// A client hint can only confirm the validated type, never override it
+	if !hintMatchesCategory(mediaTypeHint, validatedType.Category()) {
+		s.logger.Warn(ctx, "Upload media type hint does not match its content", map[string]any{"hint": mediaTypeHint, "contentType": string(validatedType), "fileName": fileHeader.Filename})
+		return nil, errors.NewBadRequestError(fmt.Sprintf("media_type %q does not match the file content (%s)", mediaTypeHint, validatedType))
+	}
+
 	// 1. Get adapters provider
 	// Assuming StorageFactory has a GetProvider method that takes providerName string and returns StorageProvider
 	// If providerName is empty, the factory should return the default provider.
 	// The actual method name on StorageFactory might be different, e.g., CreateProvider based on a type.
 	// For now, let's assume a method GetProvider(name string) (StorageProvider, error) exists or can be added.
-	// If StorageFactory only has CreateProvider(type, config), we'd need to map providerName to a type and get config.
 	// This part might need adjustment based on the actual StorageFactory implementation.
 	var storageProvider storagePort.StorageProvider
-	var err error
 
 	// Get storage provider - if no provider specified, use default (local)
 	if providerName == "" {
-		defaultProviderType := storagePort.ProviderLocal // Default to local storage
-		s.logger.Info(ctx, "No provider specified, using default provider", map[string]any{"defaultProvider": string(defaultProviderType)})
-		storageProvider, err = s.storageFactory.CreateProvider(defaultProviderType)
+		defaultBackend := string(storagePort.ProviderLocal) // Default to local storage
+		s.logger.Info(ctx, "No provider specified, using default provider", map[string]any{"defaultProvider": defaultBackend})
+		storageProvider, err = s.storageFactory.CreateProviderByName(defaultBackend)
 	} else {
 		s.logger.Info(ctx, "Using specified provider", map[string]any{"providerName": providerName})
-		storageProvider, err = s.storageFactory.CreateProvider(storagePort.StorageProviderType(providerName))
+		storageProvider, err = s.storageFactory.CreateProviderByName(providerName)
 	}
 
 	if err != nil {
 		s.logger.Error(ctx, "Failed to get adapters provider", map[string]any{"error": err, "providerName": providerName})
 		return nil, fmt.Errorf("failed to get adapters provider '%s': %w", providerName, err)
 	}
-	actualProviderName := string(storageProvider.ProviderType())
+	actualProviderName := storagePort.BackendName(storageProvider)
 	s.logger.Info(ctx, "Using adapters provider", map[string]any{"provider": actualProviderName})
 
-	// 2. Determine media type
-	determinedMediaType := mediaTypeHint
-	if determinedMediaType == "" {
-		contentType := fileHeader.Header.Get("Content-Type")
-		if contentType != "" {
-			determinedMediaType = strings.Split(contentType, "/")[0] // "image/png" -> "image"
-		} else {
-			// Fallback: try to guess from extension
-			ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
-			switch ext {
-			case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".svg":
-				determinedMediaType = "image"
-			case ".mp4", ".mov", ".avi", ".mkv", ".webm":
-				determinedMediaType = "video"
-			case ".mp3", ".wav", ".ogg":
-				determinedMediaType = "audio"
-			case ".pdf", ".doc", ".docx", ".txt", ".csv", ".xls", ".xlsx", ".ppt", ".pptx":
-				determinedMediaType = "document"
-			default:
-				determinedMediaType = "other"
-				s.logger.Warn(ctx, "Could not determine media type from extension or content type", map[string]any{"fileName": fileHeader.Filename})
-			}
+	// 2. Resolve the authoritative content type from the magic number
+	typeCheck, err := resolveContentType(file, fileHeader.Filename, fileHeader.Header.Get("Content-Type"))
+	if err != nil {
+		s.logger.Error(ctx, "Failed to detect content type", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to detect content type: %w", err)
+	}
+	if typeCheck.Mismatch {
+		s.logger.Warn(ctx, "Uploaded content type does not match extension or declared type", map[string]any{
+			"fileName":      fileHeader.Filename,
+			"detected":      typeCheck.Detected,
+			"fromExtension": typeCheck.FromExtension,
+			"declared":      typeCheck.Declared,
+			"strict":        s.config.StrictContentType,
+		})
+		if s.config.StrictContentType {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("file content (%s) does not match its extension or declared content type", typeCheck.Detected))
 		}
 	}
-	if determinedMediaType == "" {
-		determinedMediaType = "other" // Default if still undetermined
+	contentType := typeCheck.Resolved
+
+	// Nothing is written to the provider until the file has passed the malware scan
+	if err := s.scanUpload(ctx, file, fileHeader.Filename); err != nil {
+		return nil, err
 	}
-	s.logger.Info(ctx, "Determined media type", map[string]any{"mediaType": determinedMediaType})
 
-	// 3. Create adapters path: {userID}/{mediaType}/{date}/{fileName}
+	// 3. The media type is the category of the type the validator found in the content
+	determinedMediaType := validatedType.Category()
+	s.logger.Info(ctx, "Determined media type", map[string]any{"mediaType": determinedMediaType, "contentType": contentType})
+
+	// 4. Create adapters path: {userID}/{mediaType}/{date}/{fileName}
 	// Sanitize filename to prevent path traversal or invalid characters
 	safeFileName := filepath.Base(fileHeader.Filename) // Ensures only the filename part is used
-
-	dateStr := time.Now().Format("20060102") // YYYYMMDD
-	storagePathKey := fmt.Sprintf("%s/%s/%s/%s", userID.String(), determinedMediaType, dateStr, safeFileName)
+	storagePathKey := storageKeyFor(userID, determinedMediaType, safeFileName)
 	s.logger.Info(ctx, "Generated adapters path key", map[string]any{"storagePathKey": storagePathKey})
 
-	// 4. Upload file
-	file, err := fileHeader.Open()
-	if err != nil {
-		s.logger.Error(ctx, "Failed to open file header", map[string]any{"error": err})
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	// 5. Read image metadata, stripping EXIF from the stored file when configured
+	var body io.ReadSeeker = file
+	storedSize := fileHeader.Size
+	var imageMetadata *domain.ImageMetadata
+	if determinedMediaType == "image" {
+		body, storedSize, imageMetadata, err = s.prepareImageUpload(ctx, file, fileHeader.Size, contentType)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to process image upload", map[string]any{"error": err, "fileName": safeFileName})
+			return nil, err
+		}
 	}
-	defer file.Close()
 
+	// 6. Upload file
 	uploadOpts := &storagePort.UploadOptions{
-		ContentType: fileHeader.Header.Get("Content-Type"),
+		ContentType: contentType,
 		// Metadata:    nil, // Add custom metadata if needed
 		// ACL:         "",  // Set ACL if needed, e.g., "public-read"
 	}
 
-	fileObject, err := storageProvider.Upload(ctx, storagePathKey, file, fileHeader.Size, uploadOpts)
+	hasher := newHashingReader(body)
+	fileObject, err := storageProvider.Upload(ctx, storagePathKey, s.bandwidth.reader(ctx, userID, hasher), storedSize, uploadOpts)
 	if err != nil {
 		s.logger.Error(ctx, "Failed to upload file to provider", map[string]any{"error": err, "provider": actualProviderName, "path": storagePathKey})
 		return nil, fmt.Errorf("failed to upload file to provider '%s': %w", actualProviderName, err)
 	}
 	s.logger.Info(ctx, "File uploaded successfully", map[string]any{"fileURL": fileObject.URL, "signedURL": fileObject.SignedURL})
 
-	// 5. Create media metadata
+	// 7. Create media metadata
 	// Use fileObject.URL or fileObject.SignedURL depending on whether you want public or temporary access
 	// For now, let's assume PublicURL should be the direct URL if available, otherwise SignedURL or an internal identifier.
 	// This might need adjustment based on how you want to expose URLs.
@@ -146,13 +269,39 @@ func (s *mediaService) UploadFile(ctx context.Context, userID uuid.UUID, fileHea
 		userID,
 		safeFileName,
 		storagePathKey, // Store the relative path (key)
-		fileHeader.Size,
+		storedSize,
 		determinedMediaType,
 		actualProviderName,
 		publicAccessURL, // This could be fileObject.URL or a generated signed URL
 	)
+	mediaEntity.ContentType = uploadOpts.ContentType
+	mediaEntity.ETag = fileObject.ETag
+	mediaEntity.Metadata = imageMetadata
+	if imageMetadata != nil {
+		mediaEntity.Width, mediaEntity.Height = imageMetadata.Width, imageMetadata.Height
+	}
+	mediaEntity.SHA256 = hasher.Sum(storedSize)
+
+	// 8. Return or flag an existing copy of the same content
+	if mediaEntity.SHA256 != "" {
+		existing, err := s.findDuplicate(ctx, userID, mediaEntity.SHA256, storedSize)
+		if err != nil {
+			s.logger.Warn(ctx, "Failed to check for duplicate upload", map[string]any{"error": err})
+		} else if existing != nil {
+			s.logger.Info(ctx, "Upload duplicates existing media", map[string]any{"existingID": existing.ID.String(), "mode": s.config.DuplicateUploads})
+			if s.config.DuplicateUploads == duplicateUploadsDedupe && !settings.keepDuplicate {
+				s.discardDuplicateUpload(ctx, storageProvider, storagePathKey, existing)
+				s.handleLocalMediaURL(existing)
+				s.handleSpriteURLs(existing)
+				s.handleThumbnailURL(existing)
+				s.handleTranscodeURLs(existing)
+				return existing, nil
+			}
+			mediaEntity.DuplicateOf = &existing.ID
+		}
+	}
 
-	// 6. Save metadata to database
+	// 9. Save metadata to database
 	if err := s.db.Create(mediaEntity).Error; err != nil {
 		s.logger.Error(ctx, "Failed to save media metadata to database", map[string]any{"error": err})
 		// Optional: Attempt to delete the uploaded file from adapters if DB save fails
@@ -162,29 +311,100 @@ func (s *mediaService) UploadFile(ctx context.Context, userID uuid.UUID, fileHea
 		return nil, fmt.Errorf("failed to save media metadata: %w", err)
 	}
 	s.logger.Info(ctx, "Media metadata saved to database", map[string]any{"mediaID": mediaEntity.ID.String()})
+	s.recordUpload(ctx, userID, mediaEntity.FileSize-reserved)
+	recorded = true
+	s.tagUpload(ctx, storageProvider, mediaEntity)
+	s.publishEvent(ctx, domain.EventMediaUploaded, domain.NewMediaEvent(mediaEntity))
+
+	// 10. Generate scrubbing previews, posters and renditions for videos and thumbnails for images in the background
+	s.enqueuePreviews(mediaEntity)
 
 	return mediaEntity, nil
 }
 
-// ListMedia returns paginated media files for a given user
-func (s *mediaService) ListMedia(ctx context.Context, userID uuid.UUID, query *utils.PaginationQuery) (*utils.Pagination, []*domain.Media, error) {
+// enqueuePreviews starts background generation of the preview assets and renditions for media's type
+func (s *mediaService) enqueuePreviews(media *domain.Media) {
+	switch {
+	case strings.HasPrefix(media.MediaType, "video"):
+		s.sprites.enqueue(media)
+		s.posters.enqueue(media)
+		s.transcoder.enqueue(media)
+	case strings.HasPrefix(media.MediaType, "image"):
+		s.thumbnails.enqueue(media)
+	}
+}
+
+// hintMatchesCategory reports whether a client's media type hint, a category such as
+// "image" or a content type such as "image/jpeg", agrees with category. An empty hint
+// agrees with anything.
+func hintMatchesCategory(hint, category string) bool {
+	if hint == "" {
+		return true
+	}
+	if hint == category {
+		return true
+	}
+	contentType, _, err := mime.ParseMediaType(hint)
+	return err == nil && domain.MediaType(contentType).Category() == category
+}
+
+// mediaTypeFor derives the media category (image, video, ...) from a content type,
+// falling back to the file extension when the type is missing or generic. Supported
+// types get the same category as domain.MediaType.Category gives validated uploads.
+func mediaTypeFor(contentType, fileName string) string {
+	if category := domain.MediaType(contentType).Category(); category != "" {
+		return category
+	}
+	if contentType != "" && contentType != "application/octet-stream" {
+		return strings.Split(contentType, "/")[0] // "image/png" -> "image"
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".webp", ".svg":
+		return "image"
+	case ".mp4", ".mov", ".avi", ".mkv", ".webm":
+		return "video"
+	case ".mp3", ".wav", ".ogg":
+		return "audio"
+	case ".pdf", ".doc", ".docx", ".txt", ".csv", ".xls", ".xlsx", ".ppt", ".pptx":
+		return "document"
+	default:
+		return "other"
+	}
+}
+
+// storageKeyFor builds the object key {userID}/{mediaType}/{date}/{fileName} for a new upload.
+func storageKeyFor(userID uuid.UUID, mediaType, fileName string) string {
+	dateStr := time.Now().Format("20060102") // YYYYMMDD
+	return fmt.Sprintf("%s/%s/%s/%s", userID.String(), mediaType, dateStr, fileName)
+}
+
+// ListMedia returns paginated media files for a given user, narrowed and ordered by filter
+func (s *mediaService) ListMedia(ctx context.Context, userID uuid.UUID, query *utils.PaginationQuery, filter *domain.MediaListFilter) (*utils.Pagination, []*domain.Media, error) {
 	s.logger.Info(ctx, "Listing media files for user", map[string]any{
 		"userID":   userID.String(),
 		"page":     query.Page,
 		"pageSize": query.PageSize,
+		"filter":   filter,
 	})
 
 	// Validate and set default pagination values
 	query.ValidateAndSetDefaults()
 
 	var totalItems int64
-	if err := s.db.Model(&domain.Media{}).Where("user_id = ?", userID).Count(&totalItems).Error; err != nil {
+	if err := applyMediaListFilter(s.db.Model(&domain.Media{}).Where("user_id = ?", userID), userID, filter).Count(&totalItems).Error; err != nil {
 		s.logger.Error(ctx, "Failed to count total media files", map[string]any{"error": err})
 		return nil, nil, fmt.Errorf("failed to count media files: %w", err)
 	}
 
+	// The ID breaks ties so pages do not overlap when sort values repeat
+	order := clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: filter.Sort.Column()}, Desc: filter.Descending},
+		{Column: clause.Column{Name: "id"}, Desc: filter.Descending},
+	}}
+
 	var mediaFiles []*domain.Media
-	if err := s.db.Where("user_id = ?", userID).
+	if err := applyMediaListFilter(s.db.Where("user_id = ?", userID), userID, filter).
+		Order(order).
 		Limit(query.GetLimit()).
 		Offset(query.GetOffset()).
 		Find(&mediaFiles).Error; err != nil {
@@ -195,6 +415,9 @@ func (s *mediaService) ListMedia(ctx context.Context, userID uuid.UUID, query *u
 	// Replace public_url for local storage media
 	for _, media := range mediaFiles {
 		s.handleLocalMediaURL(media)
+		s.handleSpriteURLs(media)
+		s.handleThumbnailURL(media)
+		s.handleTranscodeURLs(media)
 	}
 
 	pagination := utils.NewPagination(*query, totalItems)
@@ -202,6 +425,94 @@ func (s *mediaService) ListMedia(ctx context.Context, userID uuid.UUID, query *u
 	return &pagination, mediaFiles, nil
 }
 
+// ListMediaByCursor returns the page of media files after query's cursor, in (created_at, id)
+// order. Unlike offset pages, pages neither skip nor repeat files while others are uploaded.
+func (s *mediaService) ListMediaByCursor(ctx context.Context, userID uuid.UUID, query *utils.CursorQuery, filter *domain.MediaListFilter) (*utils.CursorPagination, []*domain.Media, error) {
+	s.logger.Info(ctx, "Listing media files for user by cursor", map[string]any{
+		"userID":   userID.String(),
+		"cursor":   query.Cursor,
+		"pageSize": query.PageSize,
+		"filter":   filter,
+	})
+
+	query.ValidateAndSetDefaults()
+	if filter.Sort != domain.MediaSortCreatedAt {
+		return nil, nil, errors.NewBadRequestError("cursor pagination only supports sorting by created_at")
+	}
+
+	db := applyMediaListFilter(s.db.Where("user_id = ?", userID), userID, filter)
+	if query.Cursor != "" {
+		cursor, err := domain.ParseMediaCursor(query.Cursor)
+		if err != nil {
+			return nil, nil, errors.NewBadRequestError("cursor is not a next_cursor returned by an earlier page")
+		}
+		if filter.Descending {
+			db = db.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		} else {
+			db = db.Where("(created_at, id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+	}
+	order := clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: "created_at"}, Desc: filter.Descending},
+		{Column: clause.Column{Name: "id"}, Desc: filter.Descending},
+	}}
+
+	// One extra row tells whether another page follows
+	var mediaFiles []*domain.Media
+	if err := db.Order(order).Limit(query.PageSize + 1).Find(&mediaFiles).Error; err != nil {
+		s.logger.Error(ctx, "Failed to list media files", map[string]any{"error": err})
+		return nil, nil, fmt.Errorf("failed to list media files: %w", err)
+	}
+
+	pagination := &utils.CursorPagination{PageSize: query.PageSize}
+	if len(mediaFiles) > query.PageSize {
+		mediaFiles = mediaFiles[:query.PageSize]
+		pagination.HasNext = true
+		pagination.NextCursor = domain.NewMediaCursor(mediaFiles[len(mediaFiles)-1])
+	}
+
+	for _, media := range mediaFiles {
+		s.handleLocalMediaURL(media)
+		s.handleSpriteURLs(media)
+		s.handleThumbnailURL(media)
+		s.handleTranscodeURLs(media)
+	}
+	return pagination, mediaFiles, nil
+}
+
+// applyMediaListFilter adds the conditions of filter to a listing of the media of userID.
+// Media whose object has gone missing is never listed.
+func applyMediaListFilter(db *gorm.DB, userID uuid.UUID, filter *domain.MediaListFilter) *gorm.DB {
+	db = db.Where("status <> ?", domain.StatusMissing)
+	if filter.Folder != nil {
+		if *filter.Folder == uuid.Nil {
+			db = db.Where("folder_id IS NULL")
+		} else {
+			db = db.Where("folder_id = ?", *filter.Folder)
+		}
+	}
+	if filter.MediaType != "" {
+		db = db.Where("media_type = ?", filter.MediaType)
+	}
+	if filter.Provider != "" {
+		db = db.Where("provider = ?", filter.Provider)
+	}
+	if filter.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		db = db.Where("created_at < ?", *filter.CreatedTo)
+	}
+	if filter.Query != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Query)
+		db = db.Where("file_name ILIKE ?", "%"+escaped+"%")
+	}
+	if len(filter.Tags) > 0 {
+		db = db.Where("id IN (?)", mediaWithAllTags(db.Session(&gorm.Session{NewDB: true}), userID, filter.Tags))
+	}
+	return db
+}
+
 // GetMedia returns a specific media file by ID for a given user
 func (s *mediaService) GetMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (*domain.Media, error) {
 	s.logger.Info(ctx, "Getting media file", map[string]any{
@@ -216,7 +527,7 @@ func (s *mediaService) GetMedia(ctx context.Context, userID uuid.UUID, mediaID u
 				"mediaID": mediaID.String(),
 				"userID":  userID.String(),
 			})
-			return nil, fmt.Errorf("media file not found")
+			return nil, errMediaNotFound
 		}
 		s.logger.Error(ctx, "Failed to get media file", map[string]any{"error": err})
 		return nil, fmt.Errorf("failed to get media file: %w", err)
@@ -224,6 +535,15 @@ func (s *mediaService) GetMedia(ctx context.Context, userID uuid.UUID, mediaID u
 
 	// Replace public_url for local storage
 	s.handleLocalMediaURL(&media)
+	s.handleSpriteURLs(&media)
+	s.handleThumbnailURL(&media)
+	s.handleTranscodeURLs(&media)
+
+	tags, err := s.mediaTags(ctx, media.ID)
+	if err != nil {
+		return nil, err
+	}
+	media.Tags = tags
 
 	return &media, nil
 }
@@ -240,7 +560,7 @@ func (s *mediaService) GetPublicMedia(ctx context.Context, mediaID uuid.UUID) (*
 			s.logger.Warn(ctx, "Public media file not found", map[string]any{
 				"mediaID": mediaID.String(),
 			})
-			return nil, fmt.Errorf("media file not found")
+			return nil, errMediaNotFound
 		}
 		s.logger.Error(ctx, "Failed to get public media file", map[string]any{"error": err})
 		return nil, fmt.Errorf("failed to get media file: %w", err)
@@ -248,10 +568,73 @@ func (s *mediaService) GetPublicMedia(ctx context.Context, mediaID uuid.UUID) (*
 
 	// Replace public_url for local storage
 	s.handleLocalMediaURL(&media)
+	s.handleSpriteURLs(&media)
+	s.handleThumbnailURL(&media)
+	s.handleTranscodeURLs(&media)
 
 	return &media, nil
 }
 
+// UpdateMedia changes the file name and description of a media file. Only the record
+// changes; the storage path stays as it is so existing URLs keep working.
+func (s *mediaService) UpdateMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.UpdateMediaRequest) (*domain.Media, error) {
+	updates := map[string]any{}
+	if req.FileName != nil {
+		name, err := validateFileName(*req.FileName)
+		if err != nil {
+			return nil, err
+		}
+		updates["file_name"] = name
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > domain.MaxDescriptionLength {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("description must be at most %d characters", domain.MaxDescriptionLength))
+		}
+		updates["description"] = description
+	}
+	if len(updates) == 0 {
+		return nil, errors.NewBadRequestError("nothing to update; set file_name or description")
+	}
+
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	updates["updated_at"] = time.Now()
+	if err := s.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(updates).Error; err != nil {
+		s.logger.Error(ctx, "Failed to update media", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to update media: %w", err)
+	}
+
+	if name, ok := updates["file_name"].(string); ok {
+		media.FileName = name
+	}
+	if description, ok := updates["description"].(string); ok {
+		media.Description = description
+	}
+	media.UpdatedAt = updates["updated_at"].(time.Time)
+	return media, nil
+}
+
+// validateFileName trims name and checks it is usable as a display file name.
+func validateFileName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", errors.NewBadRequestError("file_name must not be empty")
+	case len(name) > 255:
+		return "", errors.NewBadRequestError("file_name must be at most 255 bytes")
+	case strings.ContainsAny(name, `/\`):
+		return "", errors.NewBadRequestError("file_name must not contain path separators")
+	case name == "." || name == "..":
+		return "", errors.NewBadRequestError("file_name must not be . or ..")
+	case strings.IndexFunc(name, unicode.IsControl) >= 0:
+		return "", errors.NewBadRequestError("file_name must not contain control characters")
+	}
+	return name, nil
+}
+
 // DeleteMedia deletes a specific media file by ID for a given user
 func (s *mediaService) DeleteMedia(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) error {
 	s.logger.Info(ctx, "Deleting media file", map[string]any{
@@ -266,7 +649,7 @@ func (s *mediaService) DeleteMedia(ctx context.Context, userID uuid.UUID, mediaI
 	}
 
 	// Get the storage provider
-	storageProvider, err := s.storageFactory.CreateProvider(storagePort.StorageProviderType(media.Provider))
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
 	if err != nil {
 		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{
 			"error":    err,
@@ -275,25 +658,247 @@ func (s *mediaService) DeleteMedia(ctx context.Context, userID uuid.UUID, mediaI
 		return fmt.Errorf("failed to get storage provider: %w", err)
 	}
 
-	// Delete from storage
-	if err := storageProvider.Delete(ctx, media.FilePath); err != nil {
+	// Skip the provider delete when the object is already gone so the record can still be
+	// cleaned up; if existence cannot be determined, attempt the delete anyway.
+	exists, err := storageProvider.Exists(ctx, media.FilePath)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to check file existence before delete", map[string]any{"error": err, "filePath": media.FilePath})
+		exists = true
+	}
+	if !exists {
+		s.logger.Warn(ctx, "File already missing from storage, deleting record only", map[string]any{"filePath": media.FilePath})
+	} else if err := storageProvider.Delete(ctx, media.FilePath); err != nil {
 		s.logger.Error(ctx, "Failed to delete file from storage", map[string]any{
 			"error":    err,
 			"filePath": media.FilePath,
 		})
 		return fmt.Errorf("failed to delete file from storage: %w", err)
 	}
+	s.signedURLs.invalidate(ctx, media)
+
+	// Derived preview assets are best-effort; a leftover sprite should not block deletion
+	if media.HasSprite() {
+		for _, key := range []string{media.SpritePath, media.SpriteVTTPath} {
+			if err := storageProvider.Delete(ctx, key); err != nil {
+				s.logger.Warn(ctx, "Failed to delete video sprite asset", map[string]any{"error": err, "key": key})
+			}
+		}
+	}
+	if media.HasThumbnail() {
+		if err := storageProvider.Delete(ctx, media.ThumbnailPath); err != nil {
+			s.logger.Warn(ctx, "Failed to delete thumbnail", map[string]any{"error": err, "key": media.ThumbnailPath})
+		}
+	}
+	for _, key := range media.ResizedKeys {
+		if err := storageProvider.Delete(ctx, key); err != nil {
+			s.logger.Warn(ctx, "Failed to delete resized image", map[string]any{"error": err, "key": key})
+		}
+	}
 
 	// Delete from database
+	if err := s.db.WithContext(ctx).Where("media_id = ?", media.ID).Delete(&domain.MediaTag{}).Error; err != nil {
+		s.logger.Error(ctx, "Failed to delete media tags", map[string]any{"error": err})
+		return fmt.Errorf("failed to delete media tags: %w", err)
+	}
+	if err := s.db.WithContext(ctx).Where("media_id = ?", media.ID).Delete(&domain.MediaShare{}).Error; err != nil {
+		s.logger.Error(ctx, "Failed to delete media shares", map[string]any{"error": err})
+		return fmt.Errorf("failed to delete media shares: %w", err)
+	}
 	if err := s.db.Delete(media).Error; err != nil {
 		s.logger.Error(ctx, "Failed to delete media from database", map[string]any{"error": err})
 		return fmt.Errorf("failed to delete media from database: %w", err)
 	}
+	// Only stored files were counted; pending and missing ones are left to the reconcile
+	if media.Status == domain.StatusReady {
+		s.releaseStorage(ctx, userID, media.FileSize)
+	}
+	s.publishEvent(ctx, domain.EventMediaDeleted, domain.NewMediaEvent(media))
 
 	s.logger.Info(ctx, "Media file deleted successfully", map[string]any{"mediaID": mediaID.String()})
 	return nil
 }
 
+// DownloadMedia opens a media file that the caller has already looked up, from any
+// provider, and returns its size in bytes.
+func (s *mediaService) DownloadMedia(ctx context.Context, media *domain.Media) (io.ReadCloser, int64, error) {
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, 0, fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	reader, object, err := storageProvider.Download(ctx, media.FilePath)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to download media", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return nil, 0, fmt.Errorf("failed to download media: %w", err)
+	}
+	size := media.FileSize
+	if object != nil && object.Size > 0 {
+		size = object.Size
+	}
+	return s.bandwidth.readCloser(ctx, media.UserID, reader), size, nil
+}
+
+// DownloadMediaRange opens an inclusive byte range of a media file that the caller has already looked up.
+func (s *mediaService) DownloadMediaRange(ctx context.Context, media *domain.Media, start, end int64) (io.ReadCloser, error) {
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	reader, _, err := storageProvider.DownloadRange(ctx, media.FilePath, start, end)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to download media range", map[string]any{"error": err, "mediaID": media.ID.String(), "start": start, "end": end})
+		return nil, fmt.Errorf("failed to download media range: %w", err)
+	}
+	return s.bandwidth.readCloser(ctx, media.UserID, reader), nil
+}
+
+// GetSpriteAsset opens a generated video preview asset (sprite image or VTT) for streaming.
+func (s *mediaService) GetSpriteAsset(ctx context.Context, mediaID uuid.UUID, asset domain.SpriteAsset) (io.ReadCloser, string, error) {
+	media, err := s.GetPublicMedia(ctx, mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !media.HasSprite() {
+		return nil, "", errors.NewNotFoundError("video sprite not available")
+	}
+
+	key, contentType := media.SpritePath, "image/jpeg"
+	if asset == domain.SpriteAssetVTT {
+		key, contentType = media.SpriteVTTPath, "text/vtt"
+	}
+
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, "", fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	reader, _, err := storageProvider.Download(ctx, key)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to download video sprite asset", map[string]any{"error": err, "key": key})
+		return nil, "", fmt.Errorf("failed to download video sprite asset: %w", err)
+	}
+	return reader, contentType, nil
+}
+
+// GetThumbnail opens the thumbnail of an image, or the poster frame of a video, for
+// streaming. An image thumbnail that was not generated yet, or has gone missing from
+// storage, is created on demand; video posters are only extracted in the background.
+func (s *mediaService) GetThumbnail(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (io.ReadCloser, string, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	isVideo := strings.HasPrefix(media.MediaType, "video")
+	if !isVideo && !strings.HasPrefix(media.MediaType, "image") {
+		return nil, "", errors.NewNotFoundError("thumbnail not available")
+	}
+
+	if media.HasThumbnail() {
+		storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+			return nil, "", fmt.Errorf("failed to get storage provider: %w", err)
+		}
+
+		reader, _, err := storageProvider.Download(ctx, media.ThumbnailPath)
+		if err == nil {
+			return reader, "image/jpeg", nil
+		}
+		if !errors.Is(err, storagePort.ErrObjectNotFound) {
+			s.logger.Error(ctx, "Failed to download thumbnail", map[string]any{"error": err, "key": media.ThumbnailPath})
+			return nil, "", fmt.Errorf("failed to download thumbnail: %w", err)
+		}
+		s.logger.Warn(ctx, "Thumbnail missing from storage", map[string]any{"mediaID": mediaID.String(), "key": media.ThumbnailPath})
+	}
+
+	if isVideo || !s.thumbnails.enabled() {
+		return nil, "", errors.NewNotFoundError("thumbnail not available")
+	}
+	data, err := s.thumbnails.generate(ctx, media)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) || errors.Is(err, errImageTooLarge) {
+			return nil, "", errors.NewNotFoundError("thumbnail not available for this image")
+		}
+		s.logger.Error(ctx, "Failed to generate image thumbnail", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, "", fmt.Errorf("failed to generate image thumbnail: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), "image/jpeg", nil
+}
+
+// ResizeImage opens a resized variant of an image for streaming. Variants are cached in
+// the image's provider under a key derived from opts and rendered on first request.
+func (s *mediaService) ResizeImage(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, opts domain.ResizeOptions) (io.ReadCloser, string, error) {
+	if err := s.resizer.validate(&opts); err != nil {
+		return nil, "", err
+	}
+
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !strings.HasPrefix(media.MediaType, "image") {
+		return nil, "", errors.NewBadRequestError("only image media can be resized")
+	}
+
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, "", fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	key := opts.VariantKey(media.FilePath)
+	reader, _, err := storageProvider.Download(ctx, key)
+	if err == nil {
+		return reader, opts.Format.ContentType(), nil
+	}
+	if !errors.Is(err, storagePort.ErrObjectNotFound) {
+		s.logger.Warn(ctx, "Failed to read cached resized image, rendering it again", map[string]any{"error": err, "key": key})
+	}
+
+	data, err := s.resizer.resize(ctx, storageProvider, media, opts)
+	if err != nil {
+		if errors.Is(err, image.ErrFormat) {
+			return nil, "", errors.NewBadRequestError("image format cannot be resized")
+		}
+		if errors.Is(err, errImageTooLarge) {
+			return nil, "", err
+		}
+		s.logger.Error(ctx, "Failed to resize image", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, "", fmt.Errorf("failed to resize image: %w", err)
+	}
+	return io.NopCloser(bytes.NewReader(data)), opts.Format.ContentType(), nil
+}
+
+// handleThumbnailURL points the thumbnail of local media at the authenticated thumbnail route
+func (s *mediaService) handleThumbnailURL(media *domain.Media) {
+	if media.HasThumbnail() && (media.Provider == "local" || media.ThumbnailURL == "") {
+		media.ThumbnailURL = fmt.Sprintf("/api/v1/media/%s/thumbnail", media.ID.String())
+	}
+}
+
+// handleTranscodeURLs points the renditions of local media, and those the provider gives
+// no URL, at the authenticated rendition route
+func (s *mediaService) handleTranscodeURLs(media *domain.Media) {
+	if media.HasTranscoded() && (media.Provider == "local" || media.TranscodedURL == "") {
+		media.TranscodedURL = fmt.Sprintf("/api/v1/media/%s/renditions/%s", media.ID.String(), domain.TranscodedVideoName)
+	}
+	if media.HasHLS() && (media.Provider == "local" || media.HLSURL == "") {
+		media.HLSURL = fmt.Sprintf("/api/v1/media/%s/renditions/%s", media.ID.String(), domain.HLSPlaylistName)
+	}
+}
+
+// handleSpriteURLs exposes generated video preview assets through the public media routes
+func (s *mediaService) handleSpriteURLs(media *domain.Media) {
+	if media.HasSprite() {
+		media.SpriteURL = fmt.Sprintf("/api/v1/media/public/%s/%s", media.ID.String(), domain.SpriteAssetImage)
+		media.SpriteVTTURL = fmt.Sprintf("/api/v1/media/public/%s/%s", media.ID.String(), domain.SpriteAssetVTT)
+	}
+}
+
 // handleLocalMediaURL replaces the public_url for local storage media with handler URL
 func (s *mediaService) handleLocalMediaURL(media *domain.Media) {
 	if media.Provider == "local" {
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+)
+
+// mediaUsageCacheTTL is how long a usage report is served before it is computed again.
+const mediaUsageCacheTTL = 30 * time.Second
+
+// GetMediaUsage implements port.MediaService. Reports are cached briefly, so uploads and
+// deletes show up within mediaUsageCacheTTL.
+func (s *mediaService) GetMediaUsage(ctx context.Context, userID uuid.UUID) (*domain.MediaUsage, error) {
+	key := mediaUsageCacheKey(userID)
+	if usage, ok := s.cachedMediaUsage(ctx, key); ok {
+		return usage, nil
+	}
+
+	usage := &domain.MediaUsage{UserID: userID, ComputedAt: time.Now()}
+	var err error
+	if usage.ByMediaType, err = s.usageGroups(ctx, userID, "media_type"); err != nil {
+		return nil, err
+	}
+	if usage.ByProvider, err = s.usageGroups(ctx, userID, "provider"); err != nil {
+		return nil, err
+	}
+	for _, group := range usage.ByMediaType {
+		usage.TotalBytes += group.Bytes
+		usage.FileCount += group.FileCount
+	}
+
+	quota, err := s.users.GetQuota(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get user quota for usage report", map[string]any{"error": err, "userID": userID.String()})
+		return nil, err
+	}
+	usage.QuotaBytes = quota.MaxStorageBytes
+	usage.UsedQuotaBytes = quota.UsedStorageBytes
+	usage.MaxFilesPerDay = quota.MaxFilesPerDay
+	usage.FilesUploadedToday = quota.FilesUploadedToday
+	if quota.MaxStorageBytes > 0 {
+		remaining := max(quota.MaxStorageBytes-quota.UsedStorageBytes, 0)
+		usage.RemainingBytes = &remaining
+	}
+
+	if err := s.cache.Set(ctx, key, usage, mediaUsageCacheTTL); err != nil {
+		s.logger.Warn(ctx, "Failed to cache media usage", map[string]any{"error": err, "userID": userID.String()})
+	}
+	return usage, nil
+}
+
+// mediaUsageCacheKey is the cache key of a user's usage report.
+func mediaUsageCacheKey(userID uuid.UUID) string {
+	return fmt.Sprintf("media:usage:%s", userID.String())
+}
+
+// usageGroups sums the size and count of the user's stored media grouped by column,
+// largest first. Pending presigned uploads and missing objects are left out.
+func (s *mediaService) usageGroups(ctx context.Context, userID uuid.UUID, column string) ([]domain.UsageGroup, error) {
+	groups := []domain.UsageGroup{}
+	err := s.db.WithContext(ctx).Model(&domain.Media{}).
+		Select(fmt.Sprintf("%s AS name, COALESCE(SUM(file_size), 0) AS bytes, COUNT(*) AS file_count", column)).
+		Where("user_id = ? AND status = ?", userID, domain.StatusReady).
+		Group(column).
+		Order("bytes DESC").
+		Scan(&groups).Error
+	if err != nil {
+		s.logger.Error(ctx, "Failed to sum media usage", map[string]any{"error": err, "userID": userID.String(), "groupBy": column})
+		return nil, fmt.Errorf("failed to compute media usage: %w", err)
+	}
+	return groups, nil
+}
+
+// cachedMediaUsage reads a cached report, treating cache errors as a miss.
+func (s *mediaService) cachedMediaUsage(ctx context.Context, key string) (*domain.MediaUsage, bool) {
+	raw, err := s.cache.Get(ctx, key)
+	if err != nil {
+		s.logger.Warn(ctx, "Failed to read media usage cache", map[string]any{"error": err, "key": key})
+		return nil, false
+	}
+	if raw == nil {
+		return nil, false
+	}
+
+	// The cache service hands back decoded JSON; round-trip it into the typed report.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, false
+	}
+	var usage domain.MediaUsage
+	if err := json.Unmarshal(data, &usage); err != nil || usage.UserID == uuid.Nil {
+		return nil, false
+	}
+	return &usage, true
+}
@@ -1,15 +1,18 @@
 package service
 
 import (
-	"errors"
 	"fmt"
+	"io"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
 
+	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
 )
 
+// Size limits used when media.maxImageSize etc. are not configured.
 const (
 	// DefaultMaxImageSize defines the default maximum size for image files (e.g., 5MB).
 	DefaultMaxImageSize = 5 * 1024 * 1024
@@ -23,20 +26,42 @@ const (
 
 // MediaValidator provides methods to validate media files.
 type MediaValidator struct {
-	// You can add configurable max sizes here if needed, e.g.:
-	// MaxImageSize int64
-	// MaxVideoSize int64
+	MaxImageSize    int64
+	MaxVideoSize    int64
+	MaxAudioSize    int64
+	MaxDocumentSize int64
 }
 
-// NewMediaValidator creates a new MediaValidator.
-func NewMediaValidator() *MediaValidator {
-	return &MediaValidator{}
+// NewMediaValidator creates a MediaValidator with the size limits of cfg. Limits that
+// are not set fall back to the defaults above, as do invalid ones, which
+// Config.Validate reports at startup.
+func NewMediaValidator(cfg config.MediaConfig) *MediaValidator {
+	return &MediaValidator{
+		MaxImageSize:    sizeOrDefault(cfg.MaxImageSize, DefaultMaxImageSize),
+		MaxVideoSize:    sizeOrDefault(cfg.MaxVideoSize, DefaultMaxVideoSize),
+		MaxAudioSize:    sizeOrDefault(cfg.MaxAudioSize, DefaultMaxAudioSize),
+		MaxDocumentSize: sizeOrDefault(cfg.MaxDocumentSize, DefaultMaxDocumentSize),
+	}
 }
 
-// ValidateFile checks if the uploaded file is valid based on its extension and size.
-func (v *MediaValidator) ValidateFile(fileHeader *multipart.FileHeader) (domain.MediaType, error) {
+func sizeOrDefault(value string, fallback int64) int64 {
+	if size, err := config.ParseByteSize(value); err == nil && size > 0 {
+		return size
+	}
+	return fallback
+}
+
+// ValidateFile checks that the uploaded file has a supported extension, is within the
+// size limit of its kind and holds content of that kind, so a renamed executable or
+// archive is not accepted as an image. Content of another type of the same kind, such
+// as a PNG named .jpg, is left to the strictContentType check of the upload. Rejected
+// files give a bad request error. file is rewound to the start before returning.
+func (v *MediaValidator) ValidateFile(fileHeader *multipart.FileHeader, file io.ReadSeeker) (domain.MediaType, error) {
 	if fileHeader == nil {
-		return "", errors.New("file header is nil")
+		return "", fmt.Errorf("file header is nil")
+	}
+	if file == nil {
+		return "", fmt.Errorf("file is nil")
 	}
 
 	// Validate extension
@@ -44,36 +69,45 @@ func (v *MediaValidator) ValidateFile(fileHeader *multipart.FileHeader) (domain.
 	mediaType := domain.GetMediaTypeFromExtension(ext)
 
 	if mediaType == "" {
-		return "", errors.New("unsupported file type: " + ext)
+		if ext == "" {
+			return "", errors.NewBadRequestError("unsupported file type: file name has no extension")
+		}
+		return "", errors.NewBadRequestError("unsupported file type: " + ext)
 	}
 
 	// Validate size
 	fileSize := fileHeader.Size
 	var maxSize int64
 
-	switch {
-	case strings.HasPrefix(string(mediaType), "image/"):
-		maxSize = DefaultMaxImageSize // Or v.MaxImageSize if configurable
-	case strings.HasPrefix(string(mediaType), "video/"):
-		maxSize = DefaultMaxVideoSize // Or v.MaxVideoSize
-	case strings.HasPrefix(string(mediaType), "audio/"):
-		maxSize = DefaultMaxAudioSize // Or v.MaxAudioSize
-	case mediaType == domain.MediaTypePDF,
-		mediaType == domain.MediaTypeDOC,
-		mediaType == domain.MediaTypeDOCX,
-		mediaType == domain.MediaTypeTXT,
-		mediaType == domain.MediaTypeMD:
-		maxSize = DefaultMaxDocumentSize // Or v.MaxDocumentSize
+	category := mediaType.Category()
+	switch category {
+	case "image":
+		maxSize = v.MaxImageSize
+	case "video":
+		maxSize = v.MaxVideoSize
+	case "audio":
+		maxSize = v.MaxAudioSize
+	case "document":
+		maxSize = v.MaxDocumentSize
 	default:
-		return "", errors.New("cannot determine max size for media type: " + string(mediaType))
+		return "", fmt.Errorf("cannot determine max size for media type: %s", mediaType)
 	}
 
 	if fileSize == 0 {
-		return "", errors.New("file is empty")
+		return "", errors.NewBadRequestError("file is empty")
 	}
 
 	if fileSize > maxSize {
-		return "", errors.New("file size exceeds the limit of " + formatBytes(maxSize))
+		return "", errors.NewBadRequestError(fmt.Sprintf("file size exceeds the limit of %s for %s files", formatBytes(maxSize), category))
+	}
+
+	// Validate content
+	check, err := resolveContentType(file, fileHeader.Filename, "")
+	if err != nil {
+		return "", err
+	}
+	if domain.MediaType(check.Detected).Category() != category {
+		return "", errors.NewBadRequestError(fmt.Sprintf("file content (%s) does not match its extension %s", check.Detected, ext))
 	}
 
 	return mediaType, nil
@@ -92,7 +126,3 @@ func formatBytes(bytes int64) string {
 	}
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
-
-// You might want to add more specific validation logic,
-// for example, using libraries to check actual file content signatures (magic numbers)
-// instead of relying solely on extensions for better security and accuracy.
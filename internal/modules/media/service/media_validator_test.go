@@ -0,0 +1,130 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// testZIP returns a small ZIP archive holding one text file
+func testZIP(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	entry, err := archive.Create("payload.txt")
+	if err == nil {
+		_, err = entry.Write([]byte("not an image"))
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		t.Fatalf("build zip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateFileContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     func(t *testing.T) []byte
+		want     domain.MediaType
+		wantErr  bool
+	}{
+		{
+			name:     "png",
+			fileName: "photo.png",
+			data:     func(t *testing.T) []byte { return testPNG },
+			want:     domain.MediaTypePNG,
+		},
+		{
+			name:     "zip named .png",
+			fileName: "photo.png",
+			data:     testZIP,
+			wantErr:  true,
+		},
+		{
+			name:     "zip named .PNG",
+			fileName: "PHOTO.PNG",
+			data:     testZIP,
+			wantErr:  true,
+		},
+	}
+
+	validator := NewMediaValidator(config.MediaConfig{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := newFileHeader(t, tt.fileName, tt.data(t))
+			file, err := header.Open()
+			if err != nil {
+				t.Fatalf("open file: %v", err)
+			}
+			defer file.Close()
+
+			got, err := validator.ValidateFile(header, file)
+			if tt.wantErr {
+				if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusBadRequest {
+					t.Fatalf("ValidateFile error = %v, want 400", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidateFile: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateFile = %q, want %q", got, tt.want)
+			}
+
+			// The upload reads the file after validation, so it must start from the beginning
+			data, err := io.ReadAll(file)
+			if err != nil || !bytes.Equal(data, tt.data(t)) {
+				t.Errorf("file after ValidateFile is not rewound (%d bytes read, err %v)", len(data), err)
+			}
+		})
+	}
+}
+
+func TestHintMatchesCategory(t *testing.T) {
+	tests := []struct {
+		hint string
+		want bool
+	}{
+		{hint: "", want: true},
+		{hint: "image", want: true},
+		{hint: "image/jpeg", want: true},
+		{hint: "video", want: false},
+		{hint: "video/mp4", want: false},
+		{hint: "application/pdf", want: false},
+		{hint: "image/../video", want: false},
+	}
+	for _, tt := range tests {
+		if got := hintMatchesCategory(tt.hint, "image"); got != tt.want {
+			t.Errorf("hintMatchesCategory(%q, image) = %v, want %v", tt.hint, got, tt.want)
+		}
+	}
+}
+
+func TestUploadFileRejectsMismatchedHint(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	users := &fakeUsers{}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	// A PNG cannot be filed as video, which would queue video jobs on it
+	_, err := svc.UploadFile(context.Background(), uuid.New(), newFileHeader(t, "pixel.png", testPNG), "", "video")
+	if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("UploadFile with media_type video: got %v, want 400", err)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files after a rejected upload, want 0", n)
+	}
+}
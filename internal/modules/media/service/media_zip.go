@@ -0,0 +1,200 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// Defaults for media.zipDownload.
+const (
+	defaultZipMaxFiles      = 100
+	defaultZipMaxTotalBytes = 1 << 30
+)
+
+// PrepareMediaZip checks a ZIP download of mediaIDs against the configured caps and
+// assigns each file a unique entry name. It runs before anything is streamed so a
+// rejected request still gets a proper error response.
+func (s *mediaService) PrepareMediaZip(ctx context.Context, userID uuid.UUID, mediaIDs []uuid.UUID) (*domain.MediaZip, error) {
+	maxFiles := s.config.ZipDownload.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultZipMaxFiles
+	}
+	maxTotalBytes := s.config.ZipDownload.MaxTotalBytes
+	if maxTotalBytes <= 0 {
+		maxTotalBytes = defaultZipMaxTotalBytes
+	}
+
+	mediaIDs = uniqueIDs(mediaIDs)
+	if len(mediaIDs) == 0 {
+		return nil, errors.NewBadRequestError("ids is required")
+	}
+	if len(mediaIDs) > maxFiles {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("at most %d media files can be downloaded per archive", maxFiles))
+	}
+
+	var rows []*domain.Media
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND id IN ? AND status = ?", userID, mediaIDs, domain.StatusReady).Find(&rows).Error; err != nil {
+		s.logger.Error(ctx, "Failed to load media for ZIP download", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to load media: %w", err)
+	}
+	byID := make(map[uuid.UUID]*domain.Media, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	archive := &domain.MediaZip{UserID: userID}
+	names := newZipEntryNames()
+	for _, id := range mediaIDs {
+		media, ok := byID[id]
+		if !ok {
+			archive.Missing = append(archive.Missing, id)
+			continue
+		}
+		archive.TotalBytes += media.FileSize
+		archive.Entries = append(archive.Entries, domain.MediaZipEntry{Media: media, Name: names.claim(media)})
+	}
+	if archive.TotalBytes > maxTotalBytes {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("selected files total %d bytes, more than the %d bytes allowed per archive", archive.TotalBytes, maxTotalBytes)).
+			WithDetails(map[string]any{"total_bytes": archive.TotalBytes, "max_total_bytes": maxTotalBytes})
+	}
+	return archive, nil
+}
+
+// WriteMediaZip streams archive to w as a ZIP file, downloading one object at a time so
+// memory use does not depend on file sizes. Files that cannot be downloaded are left
+// out; the closing manifest.json entry lists every requested file and its outcome.
+func (s *mediaService) WriteMediaZip(ctx context.Context, archive *domain.MediaZip, w io.Writer) error {
+	zw := zip.NewWriter(s.bandwidth.writer(ctx, archive.UserID, w))
+	manifest := utils.NewBatchResult[domain.ZipManifestEntry](len(archive.Entries) + len(archive.Missing))
+	providers := make(map[string]storagePort.StorageProvider)
+
+	for _, entry := range archive.Entries {
+		written, err := s.writeZipEntry(ctx, zw, providers, entry)
+		if err != nil {
+			// Errors writing to w mean the client is gone; there is no point going on
+			if written < 0 {
+				return err
+			}
+			s.logger.Warn(ctx, "Skipping media file in ZIP download", map[string]any{"error": err, "mediaID": entry.Media.ID.String()})
+			manifest.Fail(entry.Media.ID.String(), err)
+			continue
+		}
+		manifest.Succeed(entry.Media.ID.String(), domain.ZipManifestEntry{MediaID: entry.Media.ID, Name: entry.Name, Bytes: written})
+	}
+	for _, id := range archive.Missing {
+		manifest.Fail(id.String(), errMediaNotFound)
+	}
+
+	manifestWriter, err := zw.Create(domain.ZipManifestName)
+	if err != nil {
+		return fmt.Errorf("failed to add ZIP manifest: %w", err)
+	}
+	encoder := json.NewEncoder(manifestWriter)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return fmt.Errorf("failed to write ZIP manifest: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish ZIP archive: %w", err)
+	}
+
+	s.logger.Info(ctx, "ZIP download written", map[string]any{"succeeded": manifest.Summary.Succeeded, "failed": manifest.Summary.Failed})
+	return nil
+}
+
+// writeZipEntry copies one media object into the archive. The entry is only created once
+// the download has started, so a file that cannot be fetched leaves no trace. A negative
+// byte count means writing to the archive itself failed.
+func (s *mediaService) writeZipEntry(ctx context.Context, zw *zip.Writer, providers map[string]storagePort.StorageProvider, entry domain.MediaZipEntry) (int64, error) {
+	media := entry.Media
+	provider, ok := providers[media.Provider]
+	if !ok {
+		var err error
+		if provider, err = s.storageFactory.CreateProviderByName(media.Provider); err != nil {
+			return 0, fmt.Errorf("storage provider unavailable: %w", err)
+		}
+		providers[media.Provider] = provider
+	}
+
+	reader, _, err := provider.Download(ctx, media.FilePath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer reader.Close()
+
+	header := &zip.FileHeader{Name: entry.Name, Method: zip.Deflate, Modified: media.CreatedAt}
+	// Images, video and audio are already compressed; deflating them only costs CPU
+	switch media.MediaType {
+	case "image", "video", "audio":
+		header.Method = zip.Store
+	}
+	entryWriter, err := zw.CreateHeader(header)
+	if err != nil {
+		return -1, fmt.Errorf("failed to add ZIP entry: %w", err)
+	}
+
+	out := &zipEntryWriter{w: entryWriter}
+	written, err := io.Copy(out, reader)
+	if out.err != nil {
+		return -1, fmt.Errorf("failed to write ZIP entry: %w", out.err)
+	}
+	if err != nil {
+		// The partial entry stays in the archive; the manifest marks it as failed
+		return written, fmt.Errorf("download interrupted after %d bytes, entry %s is incomplete: %w", written, entry.Name, err)
+	}
+	return written, nil
+}
+
+// zipEntryWriter remembers write errors so they can be told apart from download errors.
+type zipEntryWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *zipEntryWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	if err != nil {
+		e.err = err
+	}
+	return n, err
+}
+
+// zipEntryNames hands out archive entry names, numbering repeated file names as
+// "name (1).ext", "name (2).ext". Names are compared case-insensitively so archives
+// extract cleanly on case-insensitive file systems.
+type zipEntryNames struct {
+	taken map[string]bool
+}
+
+func newZipEntryNames() *zipEntryNames {
+	return &zipEntryNames{taken: map[string]bool{strings.ToLower(domain.ZipManifestName): true}}
+}
+
+// claim returns an unused entry name for media based on its file name.
+func (n *zipEntryNames) claim(media *domain.Media) string {
+	name := strings.ReplaceAll(filepath.Base(strings.ReplaceAll(media.FileName, `\`, "/")), "/", "")
+	if name == "" || name == "." || name == ".." {
+		name = media.ID.String()
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	candidate := name
+	for i := 1; n.taken[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+	}
+	n.taken[strings.ToLower(candidate)] = true
+	return candidate
+}
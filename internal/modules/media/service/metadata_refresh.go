@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// metadataRefreshConcurrency bounds how many provider lookups run at once during a refresh.
+const metadataRefreshConcurrency = 8
+
+// RefreshMetadata re-reads object metadata from the storage provider for each media ID
+// and updates the database row when it has drifted. Results are returned in request order;
+// missing rows, missing objects and provider errors are reported as failed items.
+func (s *mediaService) RefreshMetadata(ctx context.Context, mediaIDs []uuid.UUID) (*utils.BatchResult[domain.MetadataRefreshResult], error) {
+	mediaIDs = uniqueIDs(mediaIDs)
+	s.logger.Info(ctx, "Refreshing media metadata", map[string]any{"count": len(mediaIDs)})
+
+	var rows []*domain.Media
+	if err := s.db.WithContext(ctx).Where("id IN ?", mediaIDs).Find(&rows).Error; err != nil {
+		s.logger.Error(ctx, "Failed to load media for metadata refresh", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to load media: %w", err)
+	}
+	byID := make(map[uuid.UUID]*domain.Media, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+
+	// Resolve each provider once up front; the factory builds a new client per call.
+	providers := make(map[string]storagePort.StorageProvider)
+	providerErrs := make(map[string]error)
+	for _, row := range rows {
+		if _, seen := providers[row.Provider]; seen {
+			continue
+		}
+		if _, failed := providerErrs[row.Provider]; failed {
+			continue
+		}
+		provider, err := s.storageFactory.CreateProviderByName(row.Provider)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to get storage provider for metadata refresh", map[string]any{"error": err, "provider": row.Provider})
+			providerErrs[row.Provider] = err
+			continue
+		}
+		providers[row.Provider] = provider
+	}
+
+	results := make([]*domain.MetadataRefreshResult, len(mediaIDs))
+	sem := make(chan struct{}, metadataRefreshConcurrency)
+	var wg sync.WaitGroup
+
+	for i, id := range mediaIDs {
+		media, ok := byID[id]
+		if !ok {
+			results[i] = &domain.MetadataRefreshResult{MediaID: id, Status: domain.MetadataRefreshNotFound}
+			continue
+		}
+		if err, failed := providerErrs[media.Provider]; failed {
+			results[i] = &domain.MetadataRefreshResult{
+				MediaID: id,
+				Status:  domain.MetadataRefreshFailed,
+				Error:   fmt.Sprintf("storage provider unavailable: %v", err),
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, media *domain.Media, provider storagePort.StorageProvider) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = s.refreshMediaMetadata(ctx, provider, media)
+		}(i, media, providers[media.Provider])
+	}
+	wg.Wait()
+
+	batch := utils.NewBatchResult[domain.MetadataRefreshResult](len(results))
+	for _, result := range results {
+		id := result.MediaID.String()
+		switch result.Status {
+		case domain.MetadataRefreshNotFound:
+			batch.Fail(id, errMediaNotFound)
+		case domain.MetadataRefreshMissing:
+			batch.Fail(id, errors.New("object no longer exists in storage provider"))
+		case domain.MetadataRefreshFailed:
+			batch.Fail(id, errors.New(result.Error))
+		default:
+			batch.Succeed(id, *result)
+		}
+	}
+	s.logger.Info(ctx, "Media metadata refreshed", map[string]any{"succeeded": batch.Summary.Succeeded, "failed": batch.Summary.Failed})
+
+	return batch, nil
+}
+
+// refreshMediaMetadata syncs a single media row with what its provider reports.
+func (s *mediaService) refreshMediaMetadata(ctx context.Context, provider storagePort.StorageProvider, media *domain.Media) *domain.MetadataRefreshResult {
+	result := &domain.MetadataRefreshResult{MediaID: media.ID}
+
+	obj, err := provider.GetObject(ctx, media.FilePath)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			s.logger.Warn(ctx, "Media object missing from provider", map[string]any{"mediaID": media.ID.String(), "provider": media.Provider, "filePath": media.FilePath})
+			result.Status = domain.MetadataRefreshMissing
+			return result
+		}
+		s.logger.Error(ctx, "Failed to get object metadata from provider", map[string]any{"error": err, "mediaID": media.ID.String(), "provider": media.Provider})
+		result.Status = domain.MetadataRefreshFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	updates := map[string]any{}
+	if obj.Size != media.FileSize {
+		updates["file_size"] = obj.Size
+		media.FileSize = obj.Size
+	}
+	// Some providers don't report every field; never overwrite known values with blanks.
+	if obj.ContentType != "" && obj.ContentType != media.ContentType {
+		updates["content_type"] = obj.ContentType
+		media.ContentType = obj.ContentType
+	}
+	if obj.ETag != "" && obj.ETag != media.ETag {
+		updates["etag"] = obj.ETag
+		media.ETag = obj.ETag
+	}
+	if !obj.LastModified.IsZero() && (media.LastModified == nil || !media.LastModified.Equal(obj.LastModified)) {
+		lastModified := obj.LastModified
+		updates["last_modified"] = &lastModified
+		media.LastModified = &lastModified
+	}
+
+	result.FileSize = media.FileSize
+	result.ContentType = media.ContentType
+	result.ETag = media.ETag
+	result.LastModified = media.LastModified
+
+	if len(updates) == 0 {
+		result.Status = domain.MetadataRefreshUnchanged
+		return result
+	}
+
+	if err := s.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(updates).Error; err != nil {
+		s.logger.Error(ctx, "Failed to update media metadata", map[string]any{"error": err, "mediaID": media.ID.String()})
+		result.Status = domain.MetadataRefreshFailed
+		result.Error = fmt.Sprintf("failed to update media metadata: %v", err)
+		return result
+	}
+
+	result.Status = domain.MetadataRefreshUpdated
+	return result
+}
+
+// uniqueIDs drops duplicate IDs while keeping the original order.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+const (
+	// defaultMultipartPartSize suits browsers uploading over flaky connections: a dropped
+	// part costs at most 8 MiB of re-upload.
+	defaultMultipartPartSize = 8 << 20
+	// multipartUploadTTL is how long an unfinished upload can be resumed.
+	multipartUploadTTL = 24 * time.Hour
+)
+
+// errMultipartUploadNotFound is returned for unknown, expired or foreign upload IDs.
+var errMultipartUploadNotFound = errors.NewNotFoundError("multipart upload not found")
+
+// multipartProvider resolves providerName (local when empty) and checks that it supports multipart uploads.
+func (s *mediaService) multipartProvider(providerName string) (storagePort.StorageProvider, storagePort.MultipartProvider, error) {
+	if providerName == "" {
+		providerName = string(storagePort.ProviderLocal)
+	}
+	provider, err := s.storageFactory.CreateProviderByName(providerName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get adapters provider '%s': %w", providerName, err)
+	}
+	multipart, ok := storagePort.As[storagePort.MultipartProvider](provider)
+	if !ok {
+		return nil, nil, errors.NewBadRequestError(fmt.Sprintf("provider '%s' does not support multipart uploads", provider.ProviderType()))
+	}
+	return provider, multipart, nil
+}
+
+// multipartPartSize applies the default and minimum part size, then grows it so the
+// file fits within the provider's part-count limit.
+func multipartPartSize(fileSize, requested int64) int64 {
+	partSize := requested
+	if partSize <= 0 {
+		partSize = defaultMultipartPartSize
+	}
+	if partSize < storagePort.MultipartMinPartSize {
+		partSize = storagePort.MultipartMinPartSize
+	}
+	if minSize := (fileSize + storagePort.MultipartMaxParts - 1) / storagePort.MultipartMaxParts; partSize < minSize {
+		const mib = 1 << 20
+		partSize = (minSize + mib - 1) / mib * mib
+	}
+	return partSize
+}
+
+// InitiateMultipartUpload implements port.MediaService.
+func (s *mediaService) InitiateMultipartUpload(ctx context.Context, userID uuid.UUID, req *domain.InitiateMultipartUploadRequest) (*domain.MultipartUpload, error) {
+	safeFileName := filepath.Base(req.FileName)
+	if req.FileName == "" || safeFileName == "." || safeFileName == "/" {
+		return nil, errors.NewBadRequestError("file_name is required")
+	}
+	if req.FileSize <= 0 {
+		return nil, errors.NewBadRequestError("file_size must be greater than 0")
+	}
+	if err := s.checkUploadQuota(ctx, userID, req.FileSize); err != nil {
+		return nil, err
+	}
+
+	provider, multipart, err := s.multipartProvider(req.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to resolve multipart provider", map[string]any{"error": err, "providerName": req.Provider})
+		return nil, err
+	}
+
+	// Parts are streamed straight to the provider, so the declared type cannot be sniffed.
+	contentType := firstNonEmpty(normalizeContentType(req.ContentType), extensionContentType(safeFileName), "application/octet-stream")
+	mediaType := req.MediaType
+	if mediaType == "" {
+		mediaType = mediaTypeFor(contentType, safeFileName)
+	}
+
+	partSize := multipartPartSize(req.FileSize, req.PartSize)
+	key := storageKeyFor(userID, mediaType, safeFileName)
+
+	providerUploadID, err := multipart.InitiateMultipartUpload(ctx, key, &storagePort.UploadOptions{ContentType: contentType})
+	if err != nil {
+		s.logger.Error(ctx, "Failed to initiate multipart upload", map[string]any{"error": err, "key": key})
+		return nil, fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+
+	now := time.Now()
+	upload := &domain.MultipartUpload{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Provider:    storagePort.BackendName(provider),
+		Key:         key,
+		UploadID:    providerUploadID,
+		FileName:    safeFileName,
+		ContentType: contentType,
+		MediaType:   mediaType,
+		FileSize:    req.FileSize,
+		PartSize:    partSize,
+		TotalParts:  int((req.FileSize + partSize - 1) / partSize),
+		Parts:       []domain.UploadedPart{},
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(multipartUploadTTL),
+	}
+	if err := s.multipartSessions.Save(ctx, upload); err != nil {
+		s.logger.Error(ctx, "Failed to save multipart upload session", map[string]any{"error": err, "key": key})
+		if abortErr := multipart.AbortMultipartUpload(ctx, key, providerUploadID); abortErr != nil {
+			s.logger.Warn(ctx, "Failed to abort orphaned multipart upload", map[string]any{"error": abortErr, "key": key})
+		}
+		return nil, err
+	}
+	upload.RefreshProgress()
+
+	s.logger.Info(ctx, "Multipart upload initiated", map[string]any{
+		"uploadID":   upload.ID.String(),
+		"provider":   upload.Provider,
+		"key":        key,
+		"fileSize":   upload.FileSize,
+		"partSize":   upload.PartSize,
+		"totalParts": upload.TotalParts,
+	})
+	return upload, nil
+}
+
+// ListMultipartUploads implements port.MediaService.
+func (s *mediaService) ListMultipartUploads(ctx context.Context, userID uuid.UUID) ([]*domain.MultipartUpload, error) {
+	uploads, err := s.multipartSessions.List(ctx, userID)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to list multipart uploads", map[string]any{"error": err, "userID": userID.String()})
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// GetMultipartUpload implements port.MediaService.
+func (s *mediaService) GetMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.MultipartUpload, error) {
+	upload, err := s.multipartSessions.Get(ctx, userID, uploadID)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to load multipart upload", map[string]any{"error": err, "uploadID": uploadID.String()})
+		return nil, err
+	}
+	if upload == nil {
+		return nil, errMultipartUploadNotFound
+	}
+	return upload, nil
+}
+
+// UploadPart implements port.MediaService.
+func (s *mediaService) UploadPart(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID, partNumber int, reader io.Reader, size int64) (*domain.UploadedPart, error) {
+	ctx, done, err := s.uploads.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	upload, err := s.GetMultipartUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if partNumber < 1 || partNumber > upload.TotalParts {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("part number must be between 1 and %d", upload.TotalParts))
+	}
+	if expected := upload.PartLength(partNumber); size != expected {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("part %d must be %d bytes, got %d", partNumber, expected, size))
+	}
+
+	_, multipart, err := s.multipartProvider(upload.Provider)
+	if err != nil {
+		return nil, err
+	}
+	reader = storagePort.ProgressReader(s.bandwidth.reader(ctx, userID, reader), size, s.uploadProgress.trackPart(upload, partNumber))
+	completed, err := multipart.UploadPart(ctx, upload.Key, upload.UploadID, partNumber, reader, size)
+	if err != nil {
+		s.uploadProgress.partDone(upload, partNumber, -1)
+		s.logger.Error(ctx, "Failed to upload part", map[string]any{"error": err, "uploadID": uploadID.String(), "partNumber": partNumber})
+		return nil, fmt.Errorf("failed to upload part %d: %w", partNumber, err)
+	}
+
+	part := domain.UploadedPart{PartNumber: partNumber, ETag: completed.ETag, Size: size}
+	if err := s.multipartSessions.AddPart(ctx, upload, part); err != nil {
+		s.uploadProgress.partDone(upload, partNumber, -1)
+		s.logger.Error(ctx, "Failed to record uploaded part", map[string]any{"error": err, "uploadID": uploadID.String(), "partNumber": partNumber})
+		return nil, err
+	}
+	s.uploadProgress.partDone(upload, partNumber, size)
+	return &part, nil
+}
+
+// CompleteMultipartUpload implements port.MediaService.
+func (s *mediaService) CompleteMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.Media, error) {
+	ctx, done, err := s.uploads.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	upload, err := s.GetMultipartUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if len(upload.MissingParts) > 0 {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("%d of %d parts have not been uploaded yet", len(upload.MissingParts), upload.TotalParts))
+	}
+
+	provider, multipart, err := s.multipartProvider(upload.Provider)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]storagePort.CompletedPart, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		parts = append(parts, storagePort.CompletedPart{PartNumber: part.PartNumber, ETag: part.ETag})
+	}
+	fileObject, err := multipart.CompleteMultipartUpload(ctx, upload.Key, upload.UploadID, parts)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to complete multipart upload", map[string]any{"error": err, "uploadID": uploadID.String()})
+		err = fmt.Errorf("failed to complete multipart upload: %w", err)
+		s.publishUploadFailed(ctx, userID, upload.FileName, upload.FileSize, upload.Provider, err)
+		return nil, err
+	}
+
+	mediaEntity := domain.NewMedia(userID, upload.FileName, upload.Key, upload.FileSize, upload.MediaType, upload.Provider, fileObject.URL)
+	mediaEntity.ContentType = upload.ContentType
+	mediaEntity.ETag = fileObject.ETag
+	s.readImageDimensions(ctx, provider, mediaEntity)
+	if err := s.db.Create(mediaEntity).Error; err != nil {
+		s.logger.Error(ctx, "Failed to save media metadata to database", map[string]any{"error": err})
+		err = fmt.Errorf("failed to save media metadata: %w", err)
+		s.publishUploadFailed(ctx, userID, upload.FileName, upload.FileSize, upload.Provider, err)
+		return nil, err
+	}
+	s.recordUpload(ctx, userID, mediaEntity.FileSize)
+	s.tagUpload(ctx, provider, mediaEntity)
+	s.publishEvent(ctx, domain.EventMediaUploaded, domain.NewMediaEvent(mediaEntity))
+
+	s.uploadProgress.finish(upload, utils.JobCompleted)
+
+	if err := s.multipartSessions.Delete(ctx, userID, uploadID); err != nil {
+		s.logger.Warn(ctx, "Failed to delete completed multipart upload session", map[string]any{"error": err, "uploadID": uploadID.String()})
+	}
+	s.logger.Info(ctx, "Multipart upload completed", map[string]any{"uploadID": uploadID.String(), "mediaID": mediaEntity.ID.String()})
+
+	s.enqueuePreviews(mediaEntity)
+	return mediaEntity, nil
+}
+
+// AbortMultipartUpload implements port.MediaService.
+func (s *mediaService) AbortMultipartUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) error {
+	upload, err := s.GetMultipartUpload(ctx, userID, uploadID)
+	if err != nil {
+		return err
+	}
+
+	_, multipart, err := s.multipartProvider(upload.Provider)
+	if err != nil {
+		return err
+	}
+	if err := multipart.AbortMultipartUpload(ctx, upload.Key, upload.UploadID); err != nil {
+		s.logger.Error(ctx, "Failed to abort multipart upload", map[string]any{"error": err, "uploadID": uploadID.String()})
+		return fmt.Errorf("failed to abort multipart upload: %w", err)
+	}
+	s.uploadProgress.finish(upload, utils.JobCancelled)
+
+	if err := s.multipartSessions.Delete(ctx, userID, uploadID); err != nil {
+		s.logger.Error(ctx, "Failed to delete aborted multipart upload session", map[string]any{"error": err, "uploadID": uploadID.String()})
+		return err
+	}
+	s.logger.Info(ctx, "Multipart upload aborted", map[string]any{"uploadID": uploadID.String()})
+	return nil
+}
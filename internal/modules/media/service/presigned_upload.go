@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// Bounds for the validity of presigned upload URLs.
+const (
+	defaultPresignedUploadExpiry = 15 * time.Minute
+	maxPresignedUploadExpiry     = 7 * 24 * time.Hour // S3 SigV4 limit
+)
+
+// PresignUpload implements port.MediaService.
+func (s *mediaService) PresignUpload(ctx context.Context, userID uuid.UUID, req *domain.PresignUploadRequest) (*domain.PresignedUpload, error) {
+	safeFileName := filepath.Base(req.FileName)
+	if req.FileName == "" || safeFileName == "." || safeFileName == "/" {
+		return nil, errors.NewBadRequestError("file_name is required")
+	}
+	if req.FileSize < 0 {
+		return nil, errors.NewBadRequestError("file_size cannot be negative")
+	}
+	expiry := defaultPresignedUploadExpiry
+	if req.ExpiresIn != 0 {
+		expiry = time.Duration(req.ExpiresIn) * time.Second
+		if expiry <= 0 || expiry > maxPresignedUploadExpiry {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("expires_in must be between 1 and %d seconds", int(maxPresignedUploadExpiry.Seconds())))
+		}
+	}
+
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = domain.PresignMethodPut
+	}
+	if method != domain.PresignMethodPut && method != domain.PresignMethodPost {
+		return nil, errors.NewBadRequestError("method must be PUT or POST")
+	}
+	if req.MaxFileSize < 0 {
+		return nil, errors.NewBadRequestError("max_file_size cannot be negative")
+	}
+	if err := s.checkUploadQuota(ctx, userID, max(req.FileSize, req.MaxFileSize)); err != nil {
+		return nil, err
+	}
+
+	providerName := req.Provider
+	if providerName == "" {
+		providerName = string(storagePort.ProviderLocal)
+	}
+	provider, err := s.storageFactory.CreateProviderByName(providerName)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get adapters provider", map[string]any{"error": err, "providerName": providerName})
+		return nil, fmt.Errorf("failed to get adapters provider '%s': %w", providerName, err)
+	}
+
+	contentType := firstNonEmpty(normalizeContentType(req.ContentType), extensionContentType(safeFileName), "application/octet-stream")
+	mediaType := req.MediaType
+	if mediaType == "" {
+		mediaType = mediaTypeFor(contentType, safeFileName)
+	}
+	key := storageKeyFor(userID, mediaType, safeFileName)
+
+	presigned := &domain.PresignedUpload{Method: method, ExpiresAt: time.Now().Add(expiry)}
+	switch method {
+	case domain.PresignMethodPost:
+		signer, ok := storagePort.As[storagePort.PresignedPostProvider](provider)
+		if !ok {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("provider '%s' does not support presigned POST uploads", provider.ProviderType()))
+		}
+		policy, err := signer.GetPresignedPostPolicy(ctx, key, postConditions(req, contentType, mediaType, expiry))
+		if err != nil {
+			s.logger.Error(ctx, "Failed to generate presigned post policy", map[string]any{"error": err, "key": key})
+			return nil, fmt.Errorf("failed to generate presigned post policy: %w", err)
+		}
+		if _, ok := policy.Fields["Content-Type"]; !ok {
+			policy.Fields["Content-Type"] = contentType
+		}
+		presigned.URL = policy.URL
+		presigned.Fields = policy.Fields
+		presigned.ExpiresAt = policy.ExpiresAt
+	default:
+		signer, ok := storagePort.As[storagePort.PresignedUploadProvider](provider)
+		if !ok {
+			return nil, errors.NewBadRequestError(fmt.Sprintf("provider '%s' does not support presigned uploads", provider.ProviderType()))
+		}
+		uploadURL, err := signer.GetSignedUploadURL(ctx, key, expiry, &storagePort.UploadOptions{ContentType: contentType})
+		if err != nil {
+			s.logger.Error(ctx, "Failed to generate signed upload URL", map[string]any{"error": err, "key": key})
+			return nil, fmt.Errorf("failed to generate signed upload URL: %w", err)
+		}
+		presigned.URL = uploadURL
+		presigned.Headers = map[string]string{"Content-Type": contentType}
+		if provider.ProviderType() == storagePort.ProviderAzure {
+			presigned.Headers["x-ms-blob-type"] = "BlockBlob"
+		}
+	}
+
+	// The row is recorded before the upload so ConfirmUpload can find the object later.
+	mediaEntity := domain.NewMedia(userID, safeFileName, key, req.FileSize, mediaType, storagePort.BackendName(provider), "")
+	mediaEntity.ContentType = contentType
+	mediaEntity.Status = domain.StatusPending
+	if err := s.db.Create(mediaEntity).Error; err != nil {
+		s.logger.Error(ctx, "Failed to save pending media to database", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to save media metadata: %w", err)
+	}
+
+	presigned.MediaID = mediaEntity.ID
+
+	s.logger.Info(ctx, "Issued presigned upload", map[string]any{"mediaID": mediaEntity.ID.String(), "provider": mediaEntity.Provider, "key": key, "method": method, "expiry": expiry})
+	return presigned, nil
+}
+
+// postConditions restricts a POST upload to the declared size and, unless the client
+// named an exact content type, to any subtype of the media category (e.g. "image/").
+func postConditions(req *domain.PresignUploadRequest, contentType, mediaType string, expiry time.Duration) storagePort.PostConditions {
+	conditions := storagePort.PostConditions{Expires: expiry}
+
+	maxSize := req.MaxFileSize
+	if maxSize == 0 {
+		maxSize = req.FileSize
+	}
+	if maxSize > 0 {
+		conditions.MinContentLength = 1
+		conditions.MaxContentLength = maxSize
+	}
+
+	if prefix := mediaType + "/"; req.ContentType == "" && strings.HasPrefix(contentType, prefix) {
+		conditions.ContentTypePrefix = prefix
+	} else {
+		conditions.ContentType = contentType
+	}
+	return conditions
+}
+
+// ConfirmUpload implements port.MediaService.
+func (s *mediaService) ConfirmUpload(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) (*domain.Media, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	if media.Status != domain.StatusPending {
+		return media, nil
+	}
+
+	provider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, fmt.Errorf("failed to get storage provider: %w", err)
+	}
+	obj, err := provider.GetObject(ctx, media.FilePath)
+	if err != nil {
+		if errors.Is(err, storagePort.ErrObjectNotFound) {
+			return nil, errors.NewConflictError("the file has not been uploaded to the storage provider yet")
+		}
+		s.logger.Error(ctx, "Failed to get uploaded object from provider", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to confirm upload: %w", err)
+	}
+
+	media.Status = domain.StatusReady
+	media.FileSize = obj.Size
+	media.PublicURL = obj.URL
+	media.ETag = obj.ETag
+	if obj.ContentType != "" {
+		media.ContentType = obj.ContentType
+	}
+	updates := map[string]any{
+		"status":       media.Status,
+		"file_size":    media.FileSize,
+		"public_url":   media.PublicURL,
+		"etag":         media.ETag,
+		"content_type": media.ContentType,
+	}
+	if !obj.LastModified.IsZero() {
+		lastModified := obj.LastModified
+		media.LastModified = &lastModified
+		updates["last_modified"] = &lastModified
+	}
+	if s.readImageDimensions(ctx, provider, media) {
+		updates["width"], updates["height"] = media.Width, media.Height
+	}
+	if err := s.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(updates).Error; err != nil {
+		s.logger.Error(ctx, "Failed to mark media as uploaded", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to update media metadata: %w", err)
+	}
+	s.logger.Info(ctx, "Confirmed presigned upload", map[string]any{"mediaID": mediaID.String(), "size": media.FileSize})
+	s.recordUpload(ctx, userID, media.FileSize)
+	s.tagUpload(ctx, provider, media)
+	s.publishEvent(ctx, domain.EventMediaUploaded, domain.NewMediaEvent(media))
+
+	s.enqueuePreviews(media)
+	return media, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// Defaults for media.share.
+const (
+	defaultShareExpirySeconds = 7 * 24 * 3600
+	defaultShareMaxExpiry     = 30 * 24 * 3600
+)
+
+// errShareUnavailable is returned for share links that expired, were revoked or ran out of downloads.
+var errShareUnavailable = errors.NewError(http.StatusGone, "share_unavailable", "This share link has expired or is no longer available")
+
+// CreateMediaShare implements port.MediaService.
+func (s *mediaService) CreateMediaShare(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.CreateShareRequest) (*domain.MediaShare, error) {
+	maxExpiry := s.config.Share.MaxExpirySeconds
+	if maxExpiry <= 0 {
+		maxExpiry = defaultShareMaxExpiry
+	}
+	expiresIn := req.ExpiresIn
+	if expiresIn == 0 {
+		expiresIn = s.config.Share.DefaultExpirySeconds
+		if expiresIn <= 0 {
+			expiresIn = defaultShareExpirySeconds
+		}
+		expiresIn = min(expiresIn, maxExpiry)
+	}
+	if expiresIn < 0 || expiresIn > maxExpiry {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("expires_in must be between 1 and %d seconds", maxExpiry))
+	}
+	if req.MaxDownloads != nil && *req.MaxDownloads <= 0 {
+		return nil, errors.NewBadRequestError("max_downloads must be positive")
+	}
+
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate share token: %w", err)
+	}
+	now := time.Now()
+	share := &domain.MediaShare{
+		ID:           uuid.New(),
+		Token:        token,
+		MediaID:      media.ID,
+		UserID:       userID,
+		ExpiresAt:    now.Add(time.Duration(expiresIn) * time.Second),
+		MaxDownloads: req.MaxDownloads,
+		CreatedAt:    now,
+	}
+	if err := s.db.WithContext(ctx).Create(share).Error; err != nil {
+		s.logger.Error(ctx, "Failed to create media share", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to create media share: %w", err)
+	}
+	share.URL = domain.ShareLinkPrefix + share.Token
+
+	s.logger.Info(ctx, "Media share created", map[string]any{"mediaID": mediaID.String(), "shareID": share.ID.String()})
+	return share, nil
+}
+
+// ListMediaShares implements port.MediaService. Revoked and expired shares are included, newest first.
+func (s *mediaService) ListMediaShares(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID) ([]*domain.MediaShare, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares []*domain.MediaShare
+	if err := s.db.WithContext(ctx).Where("media_id = ?", media.ID).Order("created_at DESC").Find(&shares).Error; err != nil {
+		s.logger.Error(ctx, "Failed to list media shares", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to list media shares: %w", err)
+	}
+	for _, share := range shares {
+		share.URL = domain.ShareLinkPrefix + share.Token
+	}
+	return shares, nil
+}
+
+// RevokeMediaShare implements port.MediaService. Revoking a share twice keeps the first revocation time.
+func (s *mediaService) RevokeMediaShare(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, shareID uuid.UUID) (*domain.MediaShare, error) {
+	var share domain.MediaShare
+	if err := s.db.WithContext(ctx).Where("id = ? AND media_id = ? AND user_id = ?", shareID, mediaID, userID).First(&share).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("share link not found")
+		}
+		s.logger.Error(ctx, "Failed to get media share", map[string]any{"error": err, "shareID": shareID.String()})
+		return nil, fmt.Errorf("failed to get media share: %w", err)
+	}
+
+	if share.RevokedAt == nil {
+		now := time.Now()
+		if err := s.db.WithContext(ctx).Model(&share).Update("revoked_at", now).Error; err != nil {
+			s.logger.Error(ctx, "Failed to revoke media share", map[string]any{"error": err, "shareID": shareID.String()})
+			return nil, fmt.Errorf("failed to revoke media share: %w", err)
+		}
+		share.RevokedAt = &now
+		s.logger.Info(ctx, "Media share revoked", map[string]any{"mediaID": mediaID.String(), "shareID": shareID.String()})
+	}
+	share.URL = domain.ShareLinkPrefix + share.Token
+	return &share, nil
+}
+
+// OpenMediaShare implements port.MediaService. It counts a download against the share and
+// returns the shared media. The counter is bumped in the same statement that checks the
+// limits, so concurrent requests cannot exceed max_downloads.
+func (s *mediaService) OpenMediaShare(ctx context.Context, token string) (*domain.Media, error) {
+	var share domain.MediaShare
+	if err := s.db.WithContext(ctx).Where("token = ?", token).First(&share).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("share link not found")
+		}
+		s.logger.Error(ctx, "Failed to get media share", map[string]any{"error": err})
+		return nil, fmt.Errorf("failed to get media share: %w", err)
+	}
+	now := time.Now()
+	if !share.Usable(now) {
+		return nil, errShareUnavailable
+	}
+
+	result := s.db.WithContext(ctx).Model(&domain.MediaShare{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ? AND (max_downloads IS NULL OR download_count < max_downloads)", share.ID, now).
+		Update("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		s.logger.Error(ctx, "Failed to count share download", map[string]any{"error": result.Error, "shareID": share.ID.String()})
+		return nil, fmt.Errorf("failed to count share download: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errShareUnavailable
+	}
+
+	return s.GetMedia(ctx, share.UserID, share.MediaID)
+}
+
+// generateShareToken returns a URL-safe token with 128 bits of randomness.
+func generateShareToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
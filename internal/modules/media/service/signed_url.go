@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	// signedURLTTLBucket is the width of the validity buckets in cache keys. Requests for
+	// one object whose validities fall into the same bucket share a URL.
+	signedURLTTLBucket = time.Minute
+	// signedURLSafetyMargin is the minimum validity a reused URL must still have.
+	signedURLSafetyMargin = time.Minute
+	// defaultSignedURLMinRemainingPercent is the share of the requested validity a reused
+	// URL must still have when media.signedURLCache.minRemainingPercent is unset.
+	defaultSignedURLMinRemainingPercent = 50
+)
+
+// Sources recorded on the signed-URL request counter.
+const (
+	signedURLSourceCache    = "cache"    // Served from Redis
+	signedURLSourceShared   = "shared"   // Joined an in-flight provider call
+	signedURLSourceProvider = "provider" // Provider was called
+)
+
+// signedURLCoalescer deduplicates signed-URL generation for popular media.
+// Concurrent callers in one process share a single provider call via singleflight.
+// When media.signedURLCache is enabled, the result is also cached in Redis per
+// (provider, key, validity bucket) for other requests and instances, until shortly
+// before the URL would have less validity left than callers may be handed.
+type signedURLCoalescer struct {
+	cache    appPort.CacheService
+	logger   logger.Logger
+	group    singleflight.Group
+	requests metric.Int64Counter
+
+	enabled             bool
+	minRemainingPercent int
+}
+
+// cachedSignedURL is the Redis representation of a generated URL.
+type cachedSignedURL struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newSignedURLCoalescer(cache appPort.CacheService, appLogger logger.Logger, cfg config.SignedURLCacheConfig) *signedURLCoalescer {
+	minRemainingPercent := cfg.MinRemainingPercent
+	if minRemainingPercent <= 0 {
+		minRemainingPercent = defaultSignedURLMinRemainingPercent
+	}
+	c := &signedURLCoalescer{
+		cache:               cache,
+		logger:              appLogger.WithFields(map[string]any{"component": "SignedURLCoalescer"}),
+		enabled:             cfg.Enabled,
+		minRemainingPercent: min(minRemainingPercent, 100),
+	}
+
+	requests, err := otel.Meter("github.com/lugondev/m3-storage/media").Int64Counter(
+		"media.signed_url.requests",
+		metric.WithDescription("Signed URL requests by where the URL came from (cache, shared, provider)"),
+	)
+	if err != nil {
+		c.logger.Warn(context.Background(), "Failed to create signed URL metrics counter", map[string]any{"error": err})
+	}
+	c.requests = requests
+	return c
+}
+
+// get returns a signed URL for media valid for roughly expiry, calling generate only
+// when no reusable URL exists. A reused URL has at least minRemainingPercent of expiry,
+// and never less than the safety margin, left.
+func (c *signedURLCoalescer) get(ctx context.Context, media *domain.Media, expiry time.Duration, generate func(context.Context) (string, error)) (*domain.SignedURL, error) {
+	key := fmt.Sprintf("media:signed_url:%s:%s:%d", media.Provider, media.FilePath, int64(expiry/signedURLTTLBucket))
+	minRemaining := max(expiry*time.Duration(c.minRemainingPercent)/100, signedURLSafetyMargin)
+	// How long a new URL can be handed out; short-lived URLs are not worth caching
+	reusableFor := expiry - minRemaining
+	cached := c.enabled && reusableFor > 0
+
+	if cached {
+		if entry, ok := c.lookup(ctx, key); ok && time.Until(entry.ExpiresAt) >= minRemaining {
+			c.record(ctx, signedURLSourceCache)
+			return &domain.SignedURL{URL: entry.URL, ExpiresAt: entry.ExpiresAt}, nil
+		}
+	}
+
+	result, err, shared := c.group.Do(key, func() (any, error) {
+		signed, err := issueSignedURL(ctx, expiry, generate)
+		if err != nil {
+			return nil, err
+		}
+		if cached {
+			c.store(ctx, media, key, &cachedSignedURL{URL: signed.URL, ExpiresAt: signed.ExpiresAt}, reusableFor)
+		}
+		return signed, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if shared {
+		c.record(ctx, signedURLSourceShared)
+	} else {
+		c.record(ctx, signedURLSourceProvider)
+	}
+	signed := *result.(*domain.SignedURL)
+	return &signed, nil
+}
+
+// store caches entry under key for ttl and records key in the object's key index, so
+// invalidate can find the URLs of every validity bucket. The index is read, changed and
+// written back; a concurrent store may drop a key from it, which only leaves that URL to
+// expire on its own.
+func (c *signedURLCoalescer) store(ctx context.Context, media *domain.Media, key string, entry *cachedSignedURL, ttl time.Duration) {
+	if err := c.cache.Set(ctx, key, entry, ttl); err != nil {
+		c.logger.Warn(ctx, "Failed to cache signed URL", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return
+	}
+
+	now := time.Now()
+	indexKey := signedURLIndexKey(media)
+	index := make(map[string]time.Time)
+	c.read(ctx, indexKey, &index)
+	index[key] = now.Add(ttl)
+	indexTTL := ttl
+	for k, expiresAt := range index {
+		if !expiresAt.After(now) {
+			delete(index, k)
+			continue
+		}
+		indexTTL = max(indexTTL, expiresAt.Sub(now))
+	}
+	if err := c.cache.Set(ctx, indexKey, index, indexTTL); err != nil {
+		c.logger.Warn(ctx, "Failed to index cached signed URL", map[string]any{"error": err, "mediaID": media.ID.String()})
+	}
+}
+
+// invalidate drops the cached URLs of media's object, e.g. once the object is deleted.
+func (c *signedURLCoalescer) invalidate(ctx context.Context, media *domain.Media) {
+	if !c.enabled {
+		return
+	}
+	indexKey := signedURLIndexKey(media)
+	var index map[string]time.Time
+	if !c.read(ctx, indexKey, &index) {
+		return
+	}
+	for key := range index {
+		if err := c.cache.Delete(ctx, key); err != nil {
+			c.logger.Warn(ctx, "Failed to invalidate cached signed URL", map[string]any{"error": err, "key": key})
+		}
+	}
+	if err := c.cache.Delete(ctx, indexKey); err != nil {
+		c.logger.Warn(ctx, "Failed to invalidate cached signed URL index", map[string]any{"error": err, "key": indexKey})
+	}
+}
+
+// signedURLIndexKey is the cache key listing the cached URLs of media's object.
+func signedURLIndexKey(media *domain.Media) string {
+	return fmt.Sprintf("media:signed_url_keys:%s:%s", media.Provider, media.FilePath)
+}
+
+// lookup reads a cached URL, treating cache errors as a miss.
+func (c *signedURLCoalescer) lookup(ctx context.Context, key string) (*cachedSignedURL, bool) {
+	var entry cachedSignedURL
+	if !c.read(ctx, key, &entry) || entry.URL == "" {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// read decodes the cached value of key into v. Cache errors are logged and, like a
+// missing or undecodable value, reported as a miss.
+func (c *signedURLCoalescer) read(ctx context.Context, key string, v any) bool {
+	raw, err := c.cache.Get(ctx, key)
+	if err != nil {
+		c.logger.Warn(ctx, "Failed to read signed URL cache", map[string]any{"error": err, "key": key})
+		return false
+	}
+	if raw == nil {
+		return false
+	}
+
+	// The cache service hands back decoded JSON; round-trip it into the typed value.
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+func (c *signedURLCoalescer) record(ctx context.Context, source string) {
+	if c.requests != nil {
+		c.requests.Add(ctx, 1, metric.WithAttributes(attribute.String("source", source)))
+	}
+}
+
+// issueSignedURL calls the provider and stamps the URL with its expiry.
+func issueSignedURL(ctx context.Context, expiry time.Duration, generate func(context.Context) (string, error)) (*domain.SignedURL, error) {
+	issuedAt := time.Now()
+	url, err := generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.SignedURL{URL: url, ExpiresAt: issuedAt.Add(expiry)}, nil
+}
+
+// GetSignedURL implements port.MediaService.
+func (s *mediaService) GetSignedURL(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, expiry time.Duration) (*domain.SignedURL, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return s.signedURLFor(ctx, media, expiry)
+}
+
+// SignMedia implements port.MediaService. It fills in media.SignedURL with a URL valid for
+// expiry, or for the provider's default when expiry is zero. Providers without signed URLs,
+// such as Discord, hand back their direct URL.
+func (s *mediaService) SignMedia(ctx context.Context, media *domain.Media, expiry time.Duration) error {
+	signed, err := s.signedURLFor(ctx, media, expiry)
+	if err != nil {
+		return err
+	}
+	media.SignedURL = signed.URL
+	media.SignedURLExpiresAt = &signed.ExpiresAt
+	return nil
+}
+
+// signedURLFor issues, or reuses, a signed URL for media. A zero expiry means the provider's
+// default, and longer requests are shortened to the provider's maximum.
+func (s *mediaService) signedURLFor(ctx context.Context, media *domain.Media, expiry time.Duration) (*domain.SignedURL, error) {
+	provider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider for signed URL", map[string]any{"error": err, "provider": media.Provider})
+		return nil, fmt.Errorf("failed to get storage provider: %w", err)
+	}
+	// Resolved before caching so expires_at matches the URL actually issued
+	expiry = storagePort.SignedURLExpiry(provider, expiry)
+
+	signed, err := s.signedURLs.get(ctx, media, expiry, func(ctx context.Context) (string, error) {
+		return provider.GetSignedURL(ctx, media.FilePath, expiry)
+	})
+	if err != nil {
+		s.logger.Error(ctx, "Failed to generate signed URL", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return nil, fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+	return signed, nil
+}
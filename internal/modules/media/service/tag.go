@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// maxTagsPerRequest caps how many tags one AddMediaTags call may attach.
+const maxTagsPerRequest = 50
+
+// AddMediaTags implements port.MediaService. Tags are created for the user on first use;
+// tags the file already carries are left as they are. It returns the file's tags.
+func (s *mediaService) AddMediaTags(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, req *domain.AddTagsRequest) ([]string, error) {
+	names, err := domain.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+	if len(names) == 0 {
+		return nil, errors.NewBadRequestError("at least one tag is required")
+	}
+	if len(names) > maxTagsPerRequest {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("at most %d tags can be added at once", maxTagsPerRequest))
+	}
+
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		tags := make([]domain.Tag, len(names))
+		for i, name := range names {
+			tags[i] = domain.Tag{ID: uuid.New(), UserID: userID, Name: name, CreatedAt: now}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return fmt.Errorf("failed to create tags: %w", err)
+		}
+
+		// Existing tags kept their own IDs, so look all of them up again
+		var tagIDs []uuid.UUID
+		if err := tx.Model(&domain.Tag{}).Where("user_id = ? AND name IN ?", userID, names).Pluck("id", &tagIDs).Error; err != nil {
+			return fmt.Errorf("failed to load tags: %w", err)
+		}
+		links := make([]domain.MediaTag, len(tagIDs))
+		for i, tagID := range tagIDs {
+			links[i] = domain.MediaTag{MediaID: media.ID, TagID: tagID}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+			return fmt.Errorf("failed to tag media: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error(ctx, "Failed to add media tags", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, err
+	}
+	return s.mediaTags(ctx, media.ID)
+}
+
+// RemoveMediaTag implements port.MediaService. It returns the file's remaining tags.
+func (s *mediaService) RemoveMediaTag(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, tag string) ([]string, error) {
+	name, err := domain.NormalizeTag(tag)
+	if err != nil {
+		return nil, errors.NewBadRequestError(err.Error())
+	}
+
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.db.WithContext(ctx).
+		Where("media_id = ? AND tag_id IN (?)", media.ID, s.db.Model(&domain.Tag{}).Select("id").Where("user_id = ? AND name = ?", userID, name)).
+		Delete(&domain.MediaTag{})
+	if result.Error != nil {
+		s.logger.Error(ctx, "Failed to remove media tag", map[string]any{"error": result.Error, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to remove media tag: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.NewNotFoundError("media file does not have this tag")
+	}
+	return s.mediaTags(ctx, media.ID)
+}
+
+// mediaTags returns the names of the tags on a media file, in alphabetical order.
+func (s *mediaService) mediaTags(ctx context.Context, mediaID uuid.UUID) ([]string, error) {
+	var names []string
+	if err := s.db.WithContext(ctx).
+		Model(&domain.Tag{}).
+		Joins("JOIN media_tags ON media_tags.tag_id = tags.id").
+		Where("media_tags.media_id = ?", mediaID).
+		Order("tags.name").
+		Pluck("tags.name", &names).Error; err != nil {
+		s.logger.Error(ctx, "Failed to load media tags", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return nil, fmt.Errorf("failed to load media tags: %w", err)
+	}
+	return names, nil
+}
+
+// mediaWithAllTags builds a subquery selecting the IDs of the media of userID that carry
+// every one of tags, which must be distinct. Tag names are unique per user, so counting
+// the matching links per media is enough.
+func mediaWithAllTags(db *gorm.DB, userID uuid.UUID, tags []string) *gorm.DB {
+	return db.Table("media_tags").
+		Select("media_tags.media_id").
+		Joins("JOIN tags ON tags.id = media_tags.tag_id").
+		Where("tags.user_id = ? AND tags.name IN ?", userID, tags).
+		Group("media_tags.media_id").
+		Having("COUNT(*) = ?", len(tags))
+}
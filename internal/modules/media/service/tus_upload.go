@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// Defaults for media.tus.
+const (
+	defaultTusMaxSize = 10 << 30
+	defaultTusExpiry  = 24 * time.Hour
+	// tusSweepInterval is the least time between scans for abandoned temporary files.
+	tusSweepInterval = time.Hour
+)
+
+// errTusUploadNotFound is returned for unknown, expired or foreign tus upload IDs.
+var errTusUploadNotFound = errors.NewNotFoundError("upload not found")
+
+// TusMaxSize implements port.MediaService.
+func (s *mediaService) TusMaxSize() int64 {
+	if s.config.Tus.MaxSize > 0 {
+		return s.config.Tus.MaxSize
+	}
+	return defaultTusMaxSize
+}
+
+// tusTempDir is where received bytes wait until an upload is complete.
+func (s *mediaService) tusTempDir() string {
+	if s.config.Tus.TempDir != "" {
+		return s.config.Tus.TempDir
+	}
+	return filepath.Join(os.TempDir(), "m3-storage-tus")
+}
+
+func (s *mediaService) tusTempFile(uploadID uuid.UUID) string {
+	return filepath.Join(s.tusTempDir(), uploadID.String())
+}
+
+func (s *mediaService) tusExpiry() time.Duration {
+	if s.config.Tus.ExpirySeconds > 0 {
+		return time.Duration(s.config.Tus.ExpirySeconds) * time.Second
+	}
+	return defaultTusExpiry
+}
+
+// CreateTusUpload implements port.MediaService.
+func (s *mediaService) CreateTusUpload(ctx context.Context, userID uuid.UUID, req *domain.CreateTusUploadRequest) (*domain.TusUpload, error) {
+	safeFileName := filepath.Base(req.FileName)
+	if req.FileName == "" || safeFileName == "." || safeFileName == "/" {
+		return nil, errors.NewBadRequestError("filename metadata is required")
+	}
+	if req.Length <= 0 {
+		return nil, errors.NewBadRequestError("Upload-Length must be greater than 0")
+	}
+	if req.Length > s.TusMaxSize() {
+		return nil, errors.NewError(http.StatusRequestEntityTooLarge, "upload_too_large", fmt.Sprintf("uploads are limited to %d bytes", s.TusMaxSize()))
+	}
+	if err := s.checkUploadQuota(ctx, userID, req.Length); err != nil {
+		return nil, err
+	}
+
+	providerName := req.Provider
+	if providerName == "" {
+		providerName = string(storagePort.ProviderLocal)
+	}
+	provider, err := s.storageFactory.CreateProviderByName(providerName)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get adapters provider", map[string]any{"error": err, "providerName": providerName})
+		return nil, fmt.Errorf("failed to get adapters provider '%s': %w", providerName, err)
+	}
+
+	// The declared type is checked against the content once every byte has arrived
+	contentType := firstNonEmpty(normalizeContentType(req.ContentType), extensionContentType(safeFileName), "application/octet-stream")
+	mediaType := req.MediaType
+	if mediaType == "" {
+		mediaType = mediaTypeFor(contentType, safeFileName)
+	}
+
+	now := time.Now()
+	upload := &domain.TusUpload{
+		ID:          uuid.New(),
+		UserID:      userID,
+		Provider:    storagePort.BackendName(provider),
+		Key:         storageKeyFor(userID, mediaType, safeFileName),
+		FileName:    safeFileName,
+		ContentType: contentType,
+		MediaType:   mediaType,
+		Length:      req.Length,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.tusExpiry()),
+	}
+
+	if err := os.MkdirAll(s.tusTempDir(), 0o750); err != nil {
+		s.logger.Error(ctx, "Failed to create tus temp directory", map[string]any{"error": err, "dir": s.tusTempDir()})
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	file, err := os.OpenFile(s.tusTempFile(upload.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to create tus temp file", map[string]any{"error": err, "uploadID": upload.ID.String()})
+		return nil, fmt.Errorf("failed to create upload file: %w", err)
+	}
+	file.Close()
+
+	if err := s.tusUploads.Save(ctx, upload); err != nil {
+		s.logger.Error(ctx, "Failed to save tus upload", map[string]any{"error": err, "uploadID": upload.ID.String()})
+		os.Remove(s.tusTempFile(upload.ID))
+		return nil, err
+	}
+	s.sweepTusTempFiles()
+
+	s.logger.Info(ctx, "Tus upload created", map[string]any{
+		"uploadID": upload.ID.String(),
+		"provider": upload.Provider,
+		"key":      upload.Key,
+		"length":   upload.Length,
+	})
+	return upload, nil
+}
+
+// GetTusUpload implements port.MediaService.
+func (s *mediaService) GetTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.TusUpload, error) {
+	upload, err := s.tusUploads.Get(ctx, userID, uploadID)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to load tus upload", map[string]any{"error": err, "uploadID": uploadID.String()})
+		return nil, err
+	}
+	if upload == nil {
+		return nil, errTusUploadNotFound
+	}
+	return upload, nil
+}
+
+// WriteTusUpload implements port.MediaService. It appends reader at offset, which must be
+// the upload's current offset, and records every byte written even when the client goes
+// away mid-chunk. Once the last byte arrives the file is stored and its media created;
+// if that fails, an empty PATCH at the final offset tries again.
+func (s *mediaService) WriteTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID, offset int64, reader io.Reader) (*domain.TusUpload, error) {
+	ctx, done, err := s.uploads.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer done()
+
+	// Two writers appending at the same offset would corrupt the file
+	if _, busy := s.tusWriting.LoadOrStore(uploadID, struct{}{}); busy {
+		return nil, errors.NewConflictError("upload is being written by another request")
+	}
+	defer s.tusWriting.Delete(uploadID)
+
+	upload, err := s.GetTusUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if offset != upload.Offset {
+		return nil, errors.NewConflictError(fmt.Sprintf("Upload-Offset %d does not match the upload offset %d", offset, upload.Offset)).
+			WithDetails(map[string]any{"offset": upload.Offset})
+	}
+	if upload.MediaID != nil {
+		return upload, nil
+	}
+
+	if !upload.Complete() {
+		written, err := s.appendTusChunk(upload, reader)
+		upload.Offset += written
+		if saveErr := s.tusUploads.Save(ctx, upload); saveErr != nil {
+			s.logger.Error(ctx, "Failed to save tus upload offset", map[string]any{"error": saveErr, "uploadID": uploadID.String()})
+			return nil, saveErr
+		}
+		if err != nil {
+			s.logger.Warn(ctx, "Tus chunk interrupted", map[string]any{"error": err, "uploadID": uploadID.String(), "offset": upload.Offset})
+			return upload, err
+		}
+	}
+
+	if upload.Complete() {
+		if err := s.finishTusUpload(ctx, upload); err != nil {
+			s.publishUploadFailed(ctx, userID, upload.FileName, upload.Length, upload.Provider, err)
+			return upload, err
+		}
+	}
+	return upload, nil
+}
+
+// appendTusChunk writes reader to the upload's temporary file at its offset. Bytes past
+// a previous interrupted write that were never recorded are discarded first.
+func (s *mediaService) appendTusChunk(upload *domain.TusUpload, reader io.Reader) (int64, error) {
+	file, err := os.OpenFile(s.tusTempFile(upload.ID), os.O_WRONLY, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, errTusUploadNotFound
+		}
+		return 0, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer file.Close()
+
+	if err := file.Truncate(upload.Offset); err != nil {
+		return 0, fmt.Errorf("failed to truncate upload file: %w", err)
+	}
+	if _, err := file.Seek(upload.Offset, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to seek upload file: %w", err)
+	}
+
+	remaining := upload.Length - upload.Offset
+	written, err := io.Copy(file, io.LimitReader(reader, remaining))
+	if err != nil {
+		return written, fmt.Errorf("failed to write upload chunk: %w", err)
+	}
+	if written == remaining {
+		if n, _ := reader.Read(make([]byte, 1)); n > 0 {
+			return written, errors.NewError(http.StatusRequestEntityTooLarge, "upload_too_large", "chunk extends past Upload-Length")
+		}
+	}
+	return written, nil
+}
+
+// finishTusUpload stores a complete upload in its provider, creates the media record
+// and removes the temporary file. The upload keeps its state, with MediaID set, until it
+// expires so clients checking the offset see that it is done.
+func (s *mediaService) finishTusUpload(ctx context.Context, upload *domain.TusUpload) error {
+	file, err := os.Open(s.tusTempFile(upload.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errTusUploadNotFound
+		}
+		return fmt.Errorf("failed to open upload file: %w", err)
+	}
+	defer file.Close()
+
+	typeCheck, err := resolveContentType(file, upload.FileName, upload.ContentType)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to detect content type", map[string]any{"error": err})
+		return fmt.Errorf("failed to detect content type: %w", err)
+	}
+	if typeCheck.Mismatch {
+		s.logger.Warn(ctx, "Uploaded content type does not match extension or declared type", map[string]any{
+			"fileName": upload.FileName,
+			"detected": typeCheck.Detected,
+			"declared": typeCheck.Declared,
+			"strict":   s.config.StrictContentType,
+		})
+		if s.config.StrictContentType {
+			return errors.NewBadRequestError(fmt.Sprintf("file content (%s) does not match its extension or declared content type", typeCheck.Detected))
+		}
+	}
+	contentType := typeCheck.Resolved
+
+	if err := s.scanUpload(ctx, file, upload.FileName); err != nil {
+		return err
+	}
+
+	provider, err := s.storageFactory.CreateProviderByName(upload.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get adapters provider", map[string]any{"error": err, "provider": upload.Provider})
+		return fmt.Errorf("failed to get adapters provider '%s': %w", upload.Provider, err)
+	}
+
+	var body io.ReadSeeker = file
+	storedSize := upload.Length
+	var imageMetadata *domain.ImageMetadata
+	if upload.MediaType == "image" {
+		body, storedSize, imageMetadata, err = s.prepareImageUpload(ctx, file, upload.Length, contentType)
+		if err != nil {
+			s.logger.Error(ctx, "Failed to process image upload", map[string]any{"error": err, "fileName": upload.FileName})
+			return err
+		}
+	}
+
+	hasher := newHashingReader(body)
+	fileObject, err := provider.Upload(ctx, upload.Key, s.bandwidth.reader(ctx, upload.UserID, hasher), storedSize, &storagePort.UploadOptions{ContentType: contentType})
+	if err != nil {
+		s.logger.Error(ctx, "Failed to upload file to provider", map[string]any{"error": err, "provider": upload.Provider, "path": upload.Key})
+		return fmt.Errorf("failed to upload file to provider '%s': %w", upload.Provider, err)
+	}
+
+	mediaEntity := domain.NewMedia(upload.UserID, upload.FileName, upload.Key, storedSize, upload.MediaType, upload.Provider, fileObject.URL)
+	mediaEntity.ContentType = contentType
+	mediaEntity.ETag = fileObject.ETag
+	mediaEntity.Metadata = imageMetadata
+	if imageMetadata != nil {
+		mediaEntity.Width, mediaEntity.Height = imageMetadata.Width, imageMetadata.Height
+	}
+	mediaEntity.SHA256 = hasher.Sum(storedSize)
+	if err := s.db.Create(mediaEntity).Error; err != nil {
+		s.logger.Error(ctx, "Failed to save media metadata to database", map[string]any{"error": err})
+		if delErr := provider.Delete(ctx, upload.Key); delErr != nil {
+			s.logger.Warn(ctx, "Failed to delete uploaded file after database error", map[string]any{"error": delErr, "key": upload.Key})
+		}
+		return fmt.Errorf("failed to save media metadata: %w", err)
+	}
+	s.recordUpload(ctx, upload.UserID, mediaEntity.FileSize)
+	s.tagUpload(ctx, provider, mediaEntity)
+	s.publishEvent(ctx, domain.EventMediaUploaded, domain.NewMediaEvent(mediaEntity))
+
+	upload.MediaID = &mediaEntity.ID
+	if err := s.tusUploads.Save(ctx, upload); err != nil {
+		s.logger.Warn(ctx, "Failed to save completed tus upload", map[string]any{"error": err, "uploadID": upload.ID.String()})
+	}
+	if err := os.Remove(s.tusTempFile(upload.ID)); err != nil {
+		s.logger.Warn(ctx, "Failed to remove tus temp file", map[string]any{"error": err, "uploadID": upload.ID.String()})
+	}
+	s.logger.Info(ctx, "Tus upload completed", map[string]any{"uploadID": upload.ID.String(), "mediaID": mediaEntity.ID.String()})
+
+	s.enqueuePreviews(mediaEntity)
+	return nil
+}
+
+// DeleteTusUpload implements port.MediaService. Completed uploads keep their media.
+func (s *mediaService) DeleteTusUpload(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) error {
+	if _, err := s.GetTusUpload(ctx, userID, uploadID); err != nil {
+		return err
+	}
+	if err := os.Remove(s.tusTempFile(uploadID)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn(ctx, "Failed to remove tus temp file", map[string]any{"error": err, "uploadID": uploadID.String()})
+	}
+	if err := s.tusUploads.Delete(ctx, userID, uploadID); err != nil {
+		s.logger.Error(ctx, "Failed to delete tus upload", map[string]any{"error": err, "uploadID": uploadID.String()})
+		return err
+	}
+	s.logger.Info(ctx, "Tus upload terminated", map[string]any{"uploadID": uploadID.String()})
+	return nil
+}
+
+// sweepTusTempFiles removes, in the background, temporary files of uploads that expired
+// without completing. It runs at most once per tusSweepInterval.
+func (s *mediaService) sweepTusTempFiles() {
+	now := time.Now()
+	last := s.tusLastSweep.Load()
+	if now.Sub(time.Unix(0, last)) < tusSweepInterval || !s.tusLastSweep.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	go func() {
+		dir := s.tusTempDir()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		cutoff := now.Add(-s.tusExpiry())
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || !info.Mode().IsRegular() || info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err == nil {
+				s.logger.Info(context.Background(), "Removed abandoned tus upload file", map[string]any{"file": entry.Name()})
+			}
+		}
+	}()
+}
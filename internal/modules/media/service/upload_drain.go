@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// inFlightUploads tracks the uploads this instance is writing, so shutdown can wait for
+// them instead of leaving truncated files behind.
+type inFlightUploads struct {
+	base context.Context // Cancelling it aborts every upload in flight
+
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	draining bool
+}
+
+func newInFlightUploads(base context.Context) *inFlightUploads {
+	return &inFlightUploads{base: base}
+}
+
+// begin registers an upload and returns the context it runs under. Request contexts end
+// as soon as the server starts shutting down, so the upload context is detached from
+// ctx and only cancelled with the base context. Call done when the upload has finished.
+// Once draining has started, new uploads are rejected with 503.
+func (u *inFlightUploads) begin(ctx context.Context) (context.Context, func(), error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.draining {
+		return nil, nil, errors.NewError(http.StatusServiceUnavailable, "shutting_down", "The server is shutting down, please retry the upload")
+	}
+
+	u.wg.Add(1)
+	uploadCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(u.base, cancel)
+	return uploadCtx, func() {
+		stop()
+		cancel()
+		u.wg.Done()
+	}, nil
+}
+
+// drain rejects new uploads and waits until those in flight have finished or ctx is done.
+func (u *inFlightUploads) drain(ctx context.Context) error {
+	u.mu.Lock()
+	u.draining = true
+	u.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		u.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// DrainUploads implements port.MediaService.
+func (s *mediaService) DrainUploads(ctx context.Context) error {
+	return s.uploads.drain(ctx)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// uploadProgressRetention is how long an upload that stopped reporting is remembered, so
+// a progress stream still sees the final state of a completed or aborted upload.
+const uploadProgressRetention = 10 * time.Minute
+
+// uploadProgressTracker follows multipart uploads of this instance byte by byte. Parts may
+// be uploaded concurrently, so bytes of parts in flight are kept per part.
+type uploadProgressTracker struct {
+	mu      sync.Mutex
+	uploads map[uuid.UUID]*trackedUpload
+}
+
+type trackedUpload struct {
+	userID    uuid.UUID
+	total     int64
+	status    utils.JobStatus
+	parts     map[int]int64 // Sizes of parts the provider has accepted
+	inFlight  map[int]int64 // Bytes read so far of parts being uploaded
+	updatedAt time.Time
+}
+
+func newUploadProgressTracker() *uploadProgressTracker {
+	return &uploadProgressTracker{uploads: make(map[uuid.UUID]*trackedUpload)}
+}
+
+// entry returns the tracked state of upload, starting it from the parts recorded in the
+// session. The caller holds t.mu.
+func (t *uploadProgressTracker) entry(upload *domain.MultipartUpload, now time.Time) *trackedUpload {
+	if tracked, ok := t.uploads[upload.ID]; ok {
+		return tracked
+	}
+	for id, tracked := range t.uploads {
+		if now.Sub(tracked.updatedAt) > uploadProgressRetention {
+			delete(t.uploads, id)
+		}
+	}
+
+	tracked := &trackedUpload{
+		userID:    upload.UserID,
+		total:     upload.FileSize,
+		status:    utils.JobRunning,
+		parts:     make(map[int]int64, len(upload.Parts)),
+		inFlight:  make(map[int]int64),
+		updatedAt: now,
+	}
+	for _, part := range upload.Parts {
+		tracked.parts[part.PartNumber] = part.Size
+	}
+	t.uploads[upload.ID] = tracked
+	return tracked
+}
+
+// trackPart returns a storage progress callback for one part of upload.
+func (t *uploadProgressTracker) trackPart(upload *domain.MultipartUpload, partNumber int) func(bytesWritten, total int64) {
+	t.mu.Lock()
+	t.entry(upload, time.Now()).inFlight[partNumber] = 0
+	t.mu.Unlock()
+
+	return func(bytesWritten, _ int64) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if tracked, ok := t.uploads[upload.ID]; ok && tracked.status == utils.JobRunning {
+			tracked.inFlight[partNumber] = bytesWritten
+			tracked.updatedAt = time.Now()
+		}
+	}
+}
+
+// partDone ends tracking of a part; size is the stored size, or negative when it failed.
+func (t *uploadProgressTracker) partDone(upload *domain.MultipartUpload, partNumber int, size int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked := t.entry(upload, time.Now())
+	delete(tracked.inFlight, partNumber)
+	if size >= 0 {
+		tracked.parts[partNumber] = size
+	}
+	tracked.updatedAt = time.Now()
+}
+
+// finish records that upload has stopped with status.
+func (t *uploadProgressTracker) finish(upload *domain.MultipartUpload, status utils.JobStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked := t.entry(upload, time.Now())
+	tracked.status = status
+	clear(tracked.inFlight)
+	tracked.updatedAt = time.Now()
+}
+
+// get returns the progress of a tracked upload owned by userID.
+func (t *uploadProgressTracker) get(userID, uploadID uuid.UUID) (*domain.UploadProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tracked, ok := t.uploads[uploadID]
+	if !ok || tracked.userID != userID || time.Since(tracked.updatedAt) > uploadProgressRetention {
+		return nil, false
+	}
+
+	progress := &domain.UploadProgress{
+		UploadID:  uploadID,
+		Status:    tracked.status,
+		Total:     tracked.total,
+		UpdatedAt: tracked.updatedAt,
+	}
+	if tracked.status == utils.JobCompleted {
+		progress.BytesWritten = tracked.total
+		return progress, true
+	}
+	for _, size := range tracked.parts {
+		progress.BytesWritten += size
+	}
+	for _, read := range tracked.inFlight {
+		progress.BytesWritten += read
+	}
+	progress.BytesWritten = min(progress.BytesWritten, tracked.total)
+	return progress, true
+}
+
+// GetUploadProgress implements port.MediaService. Uploads running on another instance,
+// or from before a restart, are reported from their recorded parts only.
+func (s *mediaService) GetUploadProgress(ctx context.Context, userID uuid.UUID, uploadID uuid.UUID) (*domain.UploadProgress, error) {
+	if progress, ok := s.uploadProgress.get(userID, uploadID); ok {
+		return progress, nil
+	}
+
+	upload, err := s.GetMultipartUpload(ctx, userID, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	progress := &domain.UploadProgress{
+		UploadID:  uploadID,
+		Status:    utils.JobRunning,
+		Total:     upload.FileSize,
+		UpdatedAt: time.Now(),
+	}
+	for _, part := range upload.Parts {
+		progress.BytesWritten += part.Size
+	}
+	return progress, nil
+}
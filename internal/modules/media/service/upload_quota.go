@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// checkUploadQuota rejects an upload of size bytes that would take the user over their
+// storage quota or daily file limit. It runs before anything is written to a provider.
+func (s *mediaService) checkUploadQuota(ctx context.Context, userID uuid.UUID, size int64) error {
+	if err := s.users.CanUpload(ctx, userID, size); err != nil {
+		s.logger.Warn(ctx, "Upload rejected by user quota", map[string]any{"error": err, "userID": userID.String(), "size": size})
+		return err
+	}
+	return nil
+}
+
+// reserveUpload checks an upload of size bytes against the user's quotas and adds it to
+// their storage usage up front, so concurrent uploads cannot all pass the check and
+// together exceed the quota. The caller settles or releases the reservation.
+func (s *mediaService) reserveUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	if err := s.checkUploadQuota(ctx, userID, size); err != nil {
+		return err
+	}
+	if err := s.users.ReserveStorage(ctx, userID, 1, size); err != nil {
+		s.logger.Warn(ctx, "Upload rejected by storage reservation", map[string]any{"error": err, "userID": userID.String(), "size": size})
+		return err
+	}
+	return nil
+}
+
+// recordUpload counts a stored file against the user's quotas, with size less any bytes
+// reserved for it beforehand. The media row is already saved at this point, so a
+// failure is logged rather than failing the upload.
+func (s *mediaService) recordUpload(ctx context.Context, userID uuid.UUID, size int64) {
+	if err := s.users.RecordUpload(ctx, userID, size); err != nil {
+		s.logger.Error(ctx, "Failed to record upload against user quota", map[string]any{"error": err, "userID": userID.String(), "size": size})
+	}
+}
+
+// releaseStorage returns the bytes of deleted media or of an unused reservation to the
+// user's storage quota. A failure is logged and left to the usage reconcile.
+func (s *mediaService) releaseStorage(ctx context.Context, userID uuid.UUID, size int64) {
+	if err := s.users.ReleaseStorage(ctx, userID, size); err != nil {
+		s.logger.Error(ctx, "Failed to release storage usage", map[string]any{"error": err, "userID": userID.String(), "size": size})
+	}
+}
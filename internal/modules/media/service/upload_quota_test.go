@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+func TestUploadFileOverQuota(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	users := &fakeUsers{err: errors.NewForbiddenError("storage quota exceeded")}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	_, err := svc.UploadFile(context.Background(), uuid.New(), newFileHeader(t, "pixel.png", testPNG), "", "")
+	if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("UploadFile over quota: got %v, want 403", err)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files after a rejected upload, want 0", n)
+	}
+	if users.recorded != 0 {
+		t.Errorf("recorded %d bytes for a rejected upload", users.recorded)
+	}
+}
+
+func TestUploadFileReservesAndReleases(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	users := &fakeUsers{}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	// The file fails validation after its bytes were reserved
+	file := newFileHeader(t, "archive.png", testZIP(t))
+	if _, err := svc.UploadFile(context.Background(), uuid.New(), file, "", ""); err == nil {
+		t.Fatal("UploadFile accepted a ZIP named .png")
+	}
+	if users.reserved != file.Size {
+		t.Errorf("reserved %d bytes, want the file size %d", users.reserved, file.Size)
+	}
+	if users.released != file.Size || users.recorded != 0 {
+		t.Errorf("released %d and recorded %d bytes, want %d released", users.released, users.recorded, file.Size)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files, want 0", n)
+	}
+}
+
+func TestUploadFileReservationFails(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	// The quota check passes, but a concurrent upload took the remaining space
+	users := &fakeUsers{reserveErr: errors.NewForbiddenError("storage quota exceeded")}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	_, err := svc.UploadFile(context.Background(), uuid.New(), newFileHeader(t, "pixel.png", testPNG), "", "")
+	if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("UploadFile without a reservation: got %v, want 403", err)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files after a rejected upload, want 0", n)
+	}
+	if users.released != 0 || users.recorded != 0 {
+		t.Errorf("released %d and recorded %d bytes for an upload that reserved nothing", users.released, users.recorded)
+	}
+}
+
+func TestBatchUploadReservesAndReleases(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	users := &fakeUsers{}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	// Both files fail validation after the batch reserved their bytes
+	files := []*multipart.FileHeader{
+		newFileHeader(t, "first.png", testZIP(t)),
+		newFileHeader(t, "second.png", testZIP(t)),
+	}
+	batch, err := svc.BatchUploadFiles(context.Background(), uuid.New(), files, "", "")
+	if err != nil {
+		t.Fatalf("BatchUploadFiles: %v", err)
+	}
+	if batch.Summary.Failed != 2 {
+		t.Fatalf("failed files = %d, want 2", batch.Summary.Failed)
+	}
+
+	total := files[0].Size + files[1].Size
+	if users.reserved != total {
+		t.Errorf("reserved %d bytes, want the batch total %d", users.reserved, total)
+	}
+	if users.released != total || users.recorded != 0 {
+		t.Errorf("released %d and recorded %d bytes, want %d released", users.released, users.recorded, total)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files, want 0", n)
+	}
+}
+
+func TestBatchUploadOverQuota(t *testing.T) {
+	provider, root := newTestProvider(t, newTestLogger(t))
+	users := &fakeUsers{reserveErr: errors.NewForbiddenError("storage quota exceeded")}
+	svc := newTestMediaService(t, provider, users, config.MediaConfig{})
+
+	files := []*multipart.FileHeader{newFileHeader(t, "pixel.png", testPNG)}
+	_, err := svc.BatchUploadFiles(context.Background(), uuid.New(), files, "", "")
+	if appErr, ok := errors.As(err); !ok || appErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("BatchUploadFiles over quota: got %v, want 403", err)
+	}
+	if n := countFiles(t, root); n != 0 {
+		t.Errorf("provider holds %d files after a rejected batch, want 0", n)
+	}
+	if users.released != 0 || users.recorded != 0 {
+		t.Errorf("released %d and recorded %d bytes for a rejected batch", users.released, users.recorded)
+	}
+}
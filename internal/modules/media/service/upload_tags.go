@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// Object tags set on every upload so providers can filter objects server-side, for
+// example in lifecycle rules or blob index queries.
+const (
+	tagUserID    = "userID"
+	tagMediaType = "mediaType"
+)
+
+// tagUpload tags the stored object of media with its owner and media type when the
+// provider supports tags. The upload has already succeeded, so a failure is only logged.
+func (s *mediaService) tagUpload(ctx context.Context, provider storagePort.StorageProvider, media *domain.Media) {
+	taggable, ok := storagePort.As[storagePort.TaggableProvider](provider)
+	if !ok {
+		return
+	}
+	tags := map[string]string{
+		tagUserID:    media.UserID.String(),
+		tagMediaType: media.MediaType,
+	}
+	if err := taggable.SetTags(ctx, media.FilePath, tags); err != nil {
+		s.logger.Warn(ctx, "Failed to tag uploaded object", map[string]any{"error": err, "mediaID": media.ID.String(), "key": media.FilePath})
+	}
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	appPort "github.com/lugondev/m3-storage/internal/modules/app/port"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/modules/media/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// finishedReconcileRetention is how long reports of finished reconcile jobs stay available.
+const finishedReconcileRetention = 24 * time.Hour
+
+// usageReconciler recounts users' storage usage from their media records and the
+// objects of the usage sources, such as the S3 gateway. The counter kept by the user
+// service is updated by uploads and deletes, so failures between the two and manual
+// edits make it drift. Users are loaded in batches ordered by ID and recounted
+// by a bounded set of workers; only one reconcile job runs at a time, and one that is
+// cancelled or fails is resumed by the next after its last finished batch.
+type usageReconciler struct {
+	db      *gorm.DB
+	logger  logger.Logger
+	users   authPort.UserService
+	cache   appPort.CacheService
+	sources []port.UsageSource // Storage held outside media records
+	cfg     config.JobConfig
+
+	mu          sync.Mutex
+	jobs        map[uuid.UUID]*usageReconcileJob
+	running     *usageReconcileJob
+	resumeAfter uuid.UUID // Last finished batch of an interrupted job; uuid.Nil starts over
+}
+
+// usageReconcileJob is a reconcile job in progress or recently finished.
+type usageReconcileJob struct {
+	mu     sync.Mutex
+	report domain.UsageReconcileReport
+	cancel context.CancelFunc
+}
+
+func newUsageReconciler(db *gorm.DB, appLogger logger.Logger, users authPort.UserService, cache appPort.CacheService, sources []port.UsageSource, cfg config.JobConfig) *usageReconciler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+
+	return &usageReconciler{
+		db:      db,
+		logger:  appLogger.WithFields(map[string]any{"component": "UsageReconciler"}),
+		users:   users,
+		cache:   cache,
+		sources: sources,
+		cfg:     cfg,
+		jobs:    make(map[uuid.UUID]*usageReconcileJob),
+	}
+}
+
+// RecalculateUsage implements port.MediaService. It sums the size of the user's stored
+// media and makes that the user's recorded storage usage.
+func (s *mediaService) RecalculateUsage(ctx context.Context, userID uuid.UUID) (*domain.UsageRecalculation, error) {
+	return s.reconciler.recalculate(ctx, userID)
+}
+
+// StartUsageReconcile implements port.MediaService. It recounts the usage of every user
+// in the background and returns the job's initial report; follow it with GetUsageReconcile.
+func (s *mediaService) StartUsageReconcile(ctx context.Context, scheduled bool) (*domain.UsageReconcileReport, error) {
+	// The job outlives the request that started it
+	jobCtx, cancel := context.WithCancel(context.Background())
+
+	job, after, err := s.reconciler.start(ctx, scheduled, cancel)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		defer cancel()
+		s.reconciler.run(jobCtx, job, after)
+	}()
+	return job.snapshot(), nil
+}
+
+// GetUsageReconcile implements port.MediaService.
+func (s *mediaService) GetUsageReconcile(ctx context.Context, jobID uuid.UUID) (*domain.UsageReconcileReport, error) {
+	job, err := s.reconciler.job(jobID)
+	if err != nil {
+		return nil, err
+	}
+	return job.snapshot(), nil
+}
+
+// CancelUsageReconcile implements port.MediaService. The next job continues after the
+// last batch this one finished.
+func (s *mediaService) CancelUsageReconcile(ctx context.Context, jobID uuid.UUID) (*domain.UsageReconcileReport, error) {
+	job, err := s.reconciler.job(jobID)
+	if err != nil {
+		return nil, err
+	}
+	job.cancel()
+	return job.snapshot(), nil
+}
+
+// recalculate resets one user's storage usage to the size of their stored media plus
+// their objects in the usage sources. Pending presigned uploads and missing objects are
+// left out. When a source cannot be counted the usage is left alone rather than reset
+// too low. An upload recorded between the
+// sum and the reset can be counted twice or not at all; the next run corrects it.
+func (r *usageReconciler) recalculate(ctx context.Context, userID uuid.UUID) (*domain.UsageRecalculation, error) {
+	var used int64
+	err := r.db.WithContext(ctx).Model(&domain.Media{}).
+		Select("COALESCE(SUM(file_size), 0)").
+		Where("user_id = ? AND status = ?", userID, domain.StatusReady).
+		Scan(&used).Error
+	if err != nil {
+		r.logger.Error(ctx, "Failed to sum media size", map[string]any{"error": err, "userID": userID.String()})
+		return nil, fmt.Errorf("failed to sum media size: %w", err)
+	}
+	for _, source := range r.sources {
+		sourceBytes, err := source.UsedBytes(ctx, userID)
+		if err != nil {
+			r.logger.Error(ctx, "Failed to count storage outside media", map[string]any{"error": err, "userID": userID.String()})
+			return nil, fmt.Errorf("failed to count storage outside media: %w", err)
+		}
+		used += sourceBytes
+	}
+
+	previous, err := r.users.SetUsedStorage(ctx, userID, used)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &domain.UsageRecalculation{UserID: userID, PreviousBytes: previous, UsedBytes: used, DriftBytes: previous - used}
+	if result.DriftBytes != 0 {
+		if err := r.cache.Delete(ctx, mediaUsageCacheKey(userID)); err != nil {
+			r.logger.Warn(ctx, "Failed to invalidate media usage cache", map[string]any{"error": err, "userID": userID.String()})
+		}
+		r.logger.Info(ctx, "Storage usage corrected", map[string]any{
+			"userID": userID.String(), "previousBytes": previous, "usedBytes": used,
+		})
+	}
+	return result, nil
+}
+
+// start registers a reconcile job and returns the user ID it continues after.
+func (r *usageReconciler) start(ctx context.Context, scheduled bool, cancel context.CancelFunc) (*usageReconcileJob, uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running != nil && !r.running.done() {
+		return nil, uuid.Nil, errors.NewConflictError("a usage reconcile job is already running").
+			WithDetails(map[string]any{"job_id": r.running.report.JobID})
+	}
+
+	after := r.resumeAfter
+	total, err := r.users.CountUsers(ctx, after)
+	if err != nil {
+		r.logger.Error(ctx, "Failed to count users to reconcile", map[string]any{"error": err})
+		return nil, uuid.Nil, err
+	}
+
+	job := &usageReconcileJob{
+		report: domain.UsageReconcileReport{
+			JobID:       uuid.New(),
+			Scheduled:   scheduled,
+			JobProgress: utils.NewJobProgress(total, time.Now()),
+			Results:     utils.NewBatchResult[domain.UsageRecalculation](0),
+		},
+		cancel: cancel,
+	}
+	if after != uuid.Nil {
+		job.report.ResumedAfter = &after
+	}
+
+	for id, other := range r.jobs {
+		if other.expired() {
+			delete(r.jobs, id)
+		}
+	}
+	r.jobs[job.report.JobID] = job
+	r.running = job
+
+	r.logger.Info(ctx, "Usage reconcile started", map[string]any{
+		"jobID": job.report.JobID.String(), "scheduled": scheduled, "resumedAfter": after.String(), "total": total,
+	})
+	return job, after, nil
+}
+
+// job returns a registered job.
+func (r *usageReconciler) job(jobID uuid.UUID) (*usageReconcileJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[jobID]
+	if !ok {
+		return nil, errors.NewNotFoundError("usage reconcile job not found")
+	}
+	return job, nil
+}
+
+// run recounts the users after the given ID batch by batch until none are left or ctx
+// is cancelled. A batch only counts as finished once every user in it was attempted.
+func (r *usageReconciler) run(ctx context.Context, job *usageReconcileJob, after uuid.UUID) {
+	var runErr error
+	for ctx.Err() == nil {
+		ids, err := r.users.ListUserIDs(ctx, after, r.cfg.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				runErr = fmt.Errorf("failed to load users to reconcile: %w", err)
+			}
+			break
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		sem := make(chan struct{}, r.cfg.Workers)
+		var wg sync.WaitGroup
+		for _, userID := range ids {
+			sem <- struct{}{}
+			if ctx.Err() != nil {
+				break
+			}
+			wg.Add(1)
+			go func(userID uuid.UUID) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				result, err := r.recalculate(ctx, userID)
+				if err != nil && ctx.Err() != nil {
+					return // Abandoned by cancellation; recounted again by the next run
+				}
+				job.record(userID, result, err)
+			}(userID)
+		}
+		wg.Wait()
+		if ctx.Err() != nil {
+			break
+		}
+		after = ids[len(ids)-1]
+		job.commit(after)
+	}
+
+	status := utils.JobCompleted
+	switch {
+	case runErr != nil:
+		status = utils.JobFailed
+	case ctx.Err() != nil:
+		status = utils.JobCancelled
+	}
+	r.finish(job, status, runErr)
+
+	final := job.snapshot()
+	r.logger.Info(ctx, "Usage reconcile finished", map[string]any{
+		"jobID": final.JobID.String(), "status": final.Status, "processed": final.Processed,
+		"corrected": final.Results.Summary.Succeeded, "failed": final.Results.Summary.Failed,
+	})
+}
+
+// finish stops the job and remembers where the next job has to continue.
+func (r *usageReconciler) finish(job *usageReconcileJob, status utils.JobStatus, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job.mu.Lock()
+	job.report.Finish(status, err, time.Now())
+	lastUserID := job.report.LastUserID
+	job.mu.Unlock()
+
+	switch {
+	case status == utils.JobCompleted:
+		r.resumeAfter = uuid.Nil
+	case lastUserID != nil:
+		r.resumeAfter = *lastUserID
+	}
+}
+
+// record adds the outcome of one user to the report.
+func (j *usageReconcileJob) record(userID uuid.UUID, result *domain.UsageRecalculation, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err != nil {
+		j.report.Results.Fail(userID.String(), err)
+	} else if result.DriftBytes != 0 {
+		j.report.Results.Succeed(userID.String(), *result)
+	}
+	j.report.Advance(1, time.Now())
+}
+
+// commit marks every user up to lastUserID as done.
+func (j *usageReconcileJob) commit(lastUserID uuid.UUID) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.report.LastUserID = &lastUserID
+}
+
+// done reports whether the job has stopped.
+func (j *usageReconcileJob) done() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.report.Done()
+}
+
+// expired reports whether the job finished longer than finishedReconcileRetention ago.
+func (j *usageReconcileJob) expired() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.report.FinishedAt != nil && time.Since(*j.report.FinishedAt) > finishedReconcileRetention
+}
+
+// snapshot returns a copy of the report that is safe to read while the job runs.
+func (j *usageReconcileJob) snapshot() *domain.UsageReconcileReport {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	report := j.report
+	report.Results = &utils.BatchResult[domain.UsageRecalculation]{
+		Results: slices.Clone(j.report.Results.Results),
+		Summary: j.report.Results.Summary,
+	}
+	return &report
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/port"
+)
+
+const defaultUsageReconcileInterval = 24 * time.Hour
+
+// UsageReconcileScheduler starts a usage reconcile job at a fixed interval, so drift in
+// users' recorded storage usage is corrected without an admin having to notice it.
+type UsageReconcileScheduler struct {
+	media    port.MediaService
+	logger   logger.Logger
+	interval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewUsageReconcileScheduler creates a UsageReconcileScheduler. Call Start to begin.
+func NewUsageReconcileScheduler(media port.MediaService, appLogger logger.Logger, cfg config.UsageReconcileConfig) *UsageReconcileScheduler {
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if cfg.IntervalSeconds == 0 {
+		interval = defaultUsageReconcileInterval
+	}
+
+	return &UsageReconcileScheduler{
+		media:    media,
+		logger:   appLogger.WithFields(map[string]any{"component": "UsageReconcileScheduler"}),
+		interval: interval,
+	}
+}
+
+// Start launches the schedule. It is a no-op when the schedule is disabled. The first
+// job starts one interval after Start, so restarts do not each trigger a full run.
+func (s *UsageReconcileScheduler) Start() {
+	if s.interval <= 0 || s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.trigger()
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+	s.logger.Info(context.Background(), "Usage reconcile schedule started", map[string]any{"interval": s.interval.String()})
+}
+
+// Stop ends the schedule. A job already started keeps running in the background.
+func (s *UsageReconcileScheduler) Stop() {
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+}
+
+// trigger starts a job unless one is still running.
+func (s *UsageReconcileScheduler) trigger() {
+	ctx := context.Background()
+	report, err := s.media.StartUsageReconcile(ctx, true)
+	if err != nil {
+		s.logger.Warn(ctx, "Scheduled usage reconcile not started", map[string]any{"error": err})
+		return
+	}
+	s.logger.Info(ctx, "Scheduled usage reconcile started", map[string]any{"jobID": report.JobID.String()})
+}
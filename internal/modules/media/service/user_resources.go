@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	"github.com/lugondev/m3-storage/internal/shared/utils"
+)
+
+// userPurgeBatchSize is how many media files PurgeUserMedia deletes per batch
+const userPurgeBatchSize = 100
+
+// CountUserResources implements port.MediaService. It counts the media files, folders and
+// live share links of a user, which are left behind when the user is deleted.
+func (s *mediaService) CountUserResources(ctx context.Context, userID uuid.UUID) (map[string]int64, error) {
+	counts := map[string]int64{}
+	queries := map[string]any{
+		"media":   &domain.Media{},
+		"folders": &domain.Folder{},
+	}
+	for kind, model := range queries {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(model).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			s.logger.Error(ctx, "Failed to count user resources", map[string]any{"error": err, "userID": userID.String(), "kind": kind})
+			return nil, fmt.Errorf("failed to count %s of user: %w", kind, err)
+		}
+		counts[kind] = count
+	}
+
+	var shares int64
+	if err := s.db.WithContext(ctx).Model(&domain.MediaShare{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Count(&shares).Error; err != nil {
+		s.logger.Error(ctx, "Failed to count user resources", map[string]any{"error": err, "userID": userID.String(), "kind": "shares"})
+		return nil, fmt.Errorf("failed to count shares of user: %w", err)
+	}
+	counts["active_shares"] = shares
+
+	return counts, nil
+}
+
+// PurgeUserMedia implements port.MediaService. It deletes every media file of the user
+// from its provider and the database, batch by batch, continuing past files that fail;
+// results are keyed by media ID. Once no file is left, the user's folders and share
+// links are deleted too. Deleted files are released from the user's storage usage.
+func (s *mediaService) PurgeUserMedia(ctx context.Context, userID uuid.UUID) (*utils.BatchResult[uuid.UUID], error) {
+	s.logger.Info(ctx, "Purging user media", map[string]any{"userID": userID.String()})
+
+	result := utils.NewBatchResult[uuid.UUID](0)
+	after := uuid.Nil
+	for {
+		// Failed files keep their rows, so batches move on by ID instead of reloading them
+		var ids []uuid.UUID
+		err := s.db.WithContext(ctx).Model(&domain.Media{}).
+			Where("user_id = ? AND id > ?", userID, after).
+			Order("id").Limit(userPurgeBatchSize).
+			Pluck("id", &ids).Error
+		if err != nil {
+			s.logger.Error(ctx, "Failed to load media to purge", map[string]any{"error": err, "userID": userID.String()})
+			return nil, fmt.Errorf("failed to load media: %w", err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		batch, err := s.BatchDeleteMedia(ctx, userID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range batch.Results {
+			if item.Status == utils.BatchItemSucceeded {
+				result.Succeed(item.ID, item.Data.MediaID)
+			} else {
+				result.Fail(item.ID, errors.New(item.Error))
+			}
+		}
+		after = ids[len(ids)-1]
+	}
+
+	if result.Summary.Failed == 0 {
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("user_id = ?", userID).Delete(&domain.MediaShare{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("user_id = ?", userID).Delete(&domain.Folder{}).Error
+		})
+		if err != nil {
+			s.logger.Error(ctx, "Failed to delete folders and shares of user", map[string]any{"error": err, "userID": userID.String()})
+			return nil, fmt.Errorf("failed to delete folders and shares: %w", err)
+		}
+	}
+
+	s.logger.Info(ctx, "User media purged", map[string]any{
+		"userID": userID.String(), "succeeded": result.Summary.Succeeded, "failed": result.Summary.Failed,
+	})
+	return result, nil
+}
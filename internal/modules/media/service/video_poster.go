@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/infra/ffmpeg"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// videoPosterTimeout bounds a single poster job, including download and upload.
+const videoPosterTimeout = 10 * time.Minute
+
+// videoPosterGenerator extracts a poster frame from uploaded videos and stores it as the
+// video's thumbnail, so galleries can show videos like images.
+type videoPosterGenerator struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	runner         *ffmpeg.Runner
+	cfg            config.VideoPosterConfig
+	sem            chan struct{}
+}
+
+func newVideoPosterGenerator(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, runner *ffmpeg.Runner, cfg config.VideoPosterConfig) *videoPosterGenerator {
+	if cfg.OffsetSeconds <= 0 {
+		cfg.OffsetSeconds = 3
+	}
+	if cfg.Width <= 0 {
+		cfg.Width = 640
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 2
+	}
+
+	g := &videoPosterGenerator{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "VideoPosterGenerator"}),
+		storageFactory: storageFactory,
+		runner:         runner,
+		cfg:            cfg,
+		sem:            make(chan struct{}, cfg.MaxConcurrent),
+	}
+	if cfg.Enabled && !runner.Available() {
+		g.logger.Warn(context.Background(), "Video poster extraction enabled but ffmpeg/ffprobe not found; skipping posters")
+	}
+	return g
+}
+
+// enabled reports whether posters are configured and ffmpeg is installed.
+func (g *videoPosterGenerator) enabled() bool {
+	return g.cfg.Enabled && g.runner.Available()
+}
+
+// enqueue extracts the poster for media in the background. Jobs beyond MaxConcurrent
+// wait for a free slot.
+func (g *videoPosterGenerator) enqueue(media *domain.Media) {
+	if !g.enabled() {
+		return
+	}
+
+	target := *media
+	go func() {
+		g.sem <- struct{}{}
+		defer func() { <-g.sem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), videoPosterTimeout)
+		defer cancel()
+
+		if err := g.generate(ctx, &target); err != nil {
+			g.logger.Error(ctx, "Failed to extract video poster", map[string]any{"error": err, "mediaID": target.ID.String()})
+			return
+		}
+		g.logger.Info(ctx, "Video poster extracted", map[string]any{"mediaID": target.ID.String(), "thumbnailPath": target.ThumbnailPath})
+	}()
+}
+
+// generate downloads the video, extracts the poster frame, uploads it next to the video
+// and records it as the thumbnail of the media row.
+func (g *videoPosterGenerator) generate(ctx context.Context, media *domain.Media) error {
+	provider, err := g.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "m3-poster-*")
+	if err != nil {
+		return fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+filepath.Ext(media.FileName))
+	if err := downloadToFile(ctx, provider, media.FilePath, input); err != nil {
+		return err
+	}
+
+	duration, err := g.runner.Duration(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to probe video duration: %w", err)
+	}
+	offset := time.Duration(g.cfg.OffsetSeconds) * time.Second
+	if offset >= duration {
+		offset = duration / 2
+	}
+
+	posterFile := filepath.Join(workDir, "poster.jpg")
+	if err := g.runner.Run(ctx,
+		"-ss", strconv.FormatFloat(offset.Seconds(), 'f', 3, 64),
+		"-i", input,
+		"-vf", fmt.Sprintf("scale='min(%d,iw)':-2", g.cfg.Width),
+		"-frames:v", "1",
+		"-q:v", "3",
+		posterFile,
+	); err != nil {
+		return fmt.Errorf("failed to extract poster frame: %w", err)
+	}
+
+	key := media.FilePath + domain.ThumbnailSuffix
+	fileObject, err := uploadFile(ctx, provider, key, posterFile, "image/jpeg")
+	if err != nil {
+		return err
+	}
+
+	if err := g.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(map[string]any{
+		"thumbnail_path": key,
+		"thumbnail_url":  fileObject.URL,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record poster on media: %w", err)
+	}
+	media.ThumbnailPath = key
+	media.ThumbnailURL = fileObject.URL
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/infra/ffmpeg"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// videoSpriteTimeout bounds a single sprite job, including download and upload.
+const videoSpriteTimeout = 10 * time.Minute
+
+// videoSpriteGenerator extracts frames from uploaded videos and composes a
+// thumbnail sprite plus a WebVTT index that players use for scrubbing previews.
+type videoSpriteGenerator struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	runner         *ffmpeg.Runner
+	cfg            config.VideoSpriteConfig
+	sem            chan struct{}
+}
+
+func newVideoSpriteGenerator(db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, runner *ffmpeg.Runner, cfg config.VideoSpriteConfig) *videoSpriteGenerator {
+	if cfg.IntervalSeconds <= 0 {
+		cfg.IntervalSeconds = 10
+	}
+	if cfg.Columns <= 0 {
+		cfg.Columns = 10
+	}
+	if cfg.ThumbWidth <= 0 {
+		cfg.ThumbWidth = 160
+	}
+	if cfg.ThumbHeight <= 0 {
+		cfg.ThumbHeight = 90
+	}
+	if cfg.MaxDurationSeconds <= 0 {
+		cfg.MaxDurationSeconds = 600
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = 2
+	}
+
+	g := &videoSpriteGenerator{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "VideoSpriteGenerator"}),
+		storageFactory: storageFactory,
+		runner:         runner,
+		cfg:            cfg,
+		sem:            make(chan struct{}, cfg.MaxConcurrent),
+	}
+	if cfg.Enabled && !runner.Available() {
+		g.logger.Warn(context.Background(), "Video sprite generation enabled but ffmpeg/ffprobe not found; skipping sprites")
+	}
+	return g
+}
+
+// enabled reports whether sprites are configured and ffmpeg is installed.
+func (g *videoSpriteGenerator) enabled() bool {
+	return g.cfg.Enabled && g.runner.Available()
+}
+
+// enqueue generates the sprite for media in the background. Jobs beyond
+// MaxConcurrent wait for a free slot.
+func (g *videoSpriteGenerator) enqueue(media *domain.Media) {
+	if !g.enabled() {
+		return
+	}
+
+	target := *media
+	go func() {
+		g.sem <- struct{}{}
+		defer func() { <-g.sem }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), videoSpriteTimeout)
+		defer cancel()
+
+		if err := g.generate(ctx, &target); err != nil {
+			g.logger.Error(ctx, "Failed to generate video sprite", map[string]any{"error": err, "mediaID": target.ID.String()})
+			return
+		}
+		g.logger.Info(ctx, "Video sprite generated", map[string]any{"mediaID": target.ID.String(), "spritePath": target.SpritePath})
+	}()
+}
+
+// generate downloads the video, renders the sprite and VTT, uploads both next
+// to the video and records their keys on the media row.
+func (g *videoSpriteGenerator) generate(ctx context.Context, media *domain.Media) error {
+	provider, err := g.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "m3-sprite-*")
+	if err != nil {
+		return fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+filepath.Ext(media.FileName))
+	if err := downloadToFile(ctx, provider, media.FilePath, input); err != nil {
+		return err
+	}
+
+	duration, err := g.runner.Duration(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to probe video duration: %w", err)
+	}
+	if maxDuration := time.Duration(g.cfg.MaxDurationSeconds) * time.Second; duration > maxDuration {
+		duration = maxDuration
+	}
+
+	interval := time.Duration(g.cfg.IntervalSeconds) * time.Second
+	frames := int(math.Ceil(duration.Seconds() / interval.Seconds()))
+	if frames < 1 {
+		frames = 1
+	}
+	columns := min(g.cfg.Columns, frames)
+	rows := (frames + columns - 1) / columns
+
+	spriteFile := filepath.Join(workDir, string(domain.SpriteAssetImage))
+	filter := fmt.Sprintf(
+		"fps=1/%d,scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2,tile=%dx%d",
+		g.cfg.IntervalSeconds, g.cfg.ThumbWidth, g.cfg.ThumbHeight, g.cfg.ThumbWidth, g.cfg.ThumbHeight, columns, rows,
+	)
+	if err := g.runner.Run(ctx,
+		"-t", strconv.FormatFloat(duration.Seconds(), 'f', 3, 64),
+		"-i", input,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "5",
+		spriteFile,
+	); err != nil {
+		return fmt.Errorf("failed to render sprite: %w", err)
+	}
+
+	vtt := buildSpriteVTT(frames, columns, interval, duration, g.cfg.ThumbWidth, g.cfg.ThumbHeight)
+
+	spriteKey := media.FilePath + "." + string(domain.SpriteAssetImage)
+	vttKey := media.FilePath + "." + string(domain.SpriteAssetVTT)
+
+	if _, err := uploadFile(ctx, provider, spriteKey, spriteFile, "image/jpeg"); err != nil {
+		return err
+	}
+	if _, err := provider.Upload(ctx, vttKey, bytes.NewReader(vtt), int64(len(vtt)), &storagePort.UploadOptions{ContentType: "text/vtt"}); err != nil {
+		return fmt.Errorf("failed to upload sprite VTT: %w", err)
+	}
+
+	if err := g.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", media.ID).Updates(map[string]any{
+		"sprite_path":     spriteKey,
+		"sprite_vtt_path": vttKey,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to record sprite on media: %w", err)
+	}
+	media.SpritePath = spriteKey
+	media.SpriteVTTPath = vttKey
+	return nil
+}
+
+// buildSpriteVTT writes one cue per frame pointing at its tile in the sprite.
+// The image is referenced relatively so it resolves next to the VTT URL.
+func buildSpriteVTT(frames, columns int, interval, duration time.Duration, width, height int) []byte {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	for i := 0; i < frames; i++ {
+		start := time.Duration(i) * interval
+		end := min(start+interval, duration)
+		x := (i % columns) * width
+		y := (i / columns) * height
+		fmt.Fprintf(&b, "\n%s --> %s\n%s#xywh=%d,%d,%d,%d\n",
+			formatVTTTimestamp(start), formatVTTTimestamp(end), domain.SpriteAssetImage, x, y, width, height)
+	}
+	return []byte(b.String())
+}
+
+// formatVTTTimestamp renders d as HH:MM:SS.mmm.
+func formatVTTTimestamp(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3_600_000, (ms/60_000)%60, (ms/1000)%60, ms%1000)
+}
+
+// downloadToFile copies an object from the provider into a local file.
+func downloadToFile(ctx context.Context, provider storagePort.StorageProvider, key, dest string) error {
+	reader, _, err := provider.Download(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	defer reader.Close()
+
+	f, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dest, err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, reader); err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	return nil
+}
+
+// uploadFile uploads a local file to the provider under key.
+func uploadFile(ctx context.Context, provider storagePort.StorageProvider, key, path, contentType string) (*storagePort.FileObject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	fileObject, err := provider.Upload(ctx, key, f, info.Size(), &storagePort.UploadOptions{ContentType: contentType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return fileObject, nil
+}
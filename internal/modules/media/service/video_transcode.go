@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/infra/ffmpeg"
+	"github.com/lugondev/m3-storage/internal/modules/media/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// transcodeQueueFull is the transcode error of videos uploaded while every queue slot is taken
+const transcodeQueueFull = "transcoding queue is full"
+
+// videoTranscoder converts uploaded videos into H.264/AAC MP4 renditions, and optionally
+// HLS, that play in every browser. Videos wait in a bounded queue for a fixed pool of
+// workers; their progress is tracked in the transcode status of the media row.
+type videoTranscoder struct {
+	db             *gorm.DB
+	logger         logger.Logger
+	storageFactory storagePort.StorageFactory
+	runner         *ffmpeg.Runner
+	cfg            config.TranscodeConfig
+	timeout        time.Duration
+	queue          chan uuid.UUID
+}
+
+// newVideoTranscoder creates the transcoder and, when it is enabled, starts its workers
+// and queues the videos left pending by an earlier run. Workers stop when baseCtx is done.
+func newVideoTranscoder(baseCtx context.Context, db *gorm.DB, appLogger logger.Logger, storageFactory storagePort.StorageFactory, runner *ffmpeg.Runner, cfg config.TranscodeConfig) *videoTranscoder {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 100
+	}
+	if cfg.MaxHeight <= 0 {
+		cfg.MaxHeight = 1080
+	}
+	if cfg.CRF <= 0 {
+		cfg.CRF = 23
+	}
+	if cfg.Preset == "" {
+		cfg.Preset = "veryfast"
+	}
+	if cfg.HLSSegmentSeconds <= 0 {
+		cfg.HLSSegmentSeconds = 6
+	}
+	if cfg.TimeoutSeconds <= 0 {
+		cfg.TimeoutSeconds = 3600
+	}
+
+	t := &videoTranscoder{
+		db:             db,
+		logger:         appLogger.WithFields(map[string]any{"component": "VideoTranscoder"}),
+		storageFactory: storageFactory,
+		runner:         runner,
+		cfg:            cfg,
+		timeout:        time.Duration(cfg.TimeoutSeconds) * time.Second,
+		queue:          make(chan uuid.UUID, cfg.QueueSize),
+	}
+	if cfg.Enabled && !runner.Available() {
+		t.logger.Warn(context.Background(), "Video transcoding enabled but ffmpeg/ffprobe not found; skipping transcoding")
+	}
+	if t.enabled() {
+		for range cfg.Workers {
+			go t.work(baseCtx)
+		}
+		go t.resume(baseCtx)
+	}
+	return t
+}
+
+// enabled reports whether transcoding is configured and ffmpeg is installed.
+func (t *videoTranscoder) enabled() bool {
+	return t.cfg.Enabled && t.runner.Available()
+}
+
+// enqueue marks media pending and queues it for a worker. When the queue is full the
+// video is marked failed rather than holding up the upload.
+func (t *videoTranscoder) enqueue(media *domain.Media) {
+	if !t.enabled() {
+		return
+	}
+
+	ctx := context.Background()
+	if err := t.setStatus(ctx, media.ID, domain.TranscodePending, ""); err != nil {
+		t.logger.Error(ctx, "Failed to queue video for transcoding", map[string]any{"error": err, "mediaID": media.ID.String()})
+		return
+	}
+	media.TranscodeStatus = domain.TranscodePending
+
+	select {
+	case t.queue <- media.ID:
+	default:
+		t.logger.Warn(ctx, "Transcoding queue is full", map[string]any{"mediaID": media.ID.String(), "queueSize": t.cfg.QueueSize})
+		if err := t.setStatus(ctx, media.ID, domain.TranscodeFailed, transcodeQueueFull); err != nil {
+			t.logger.Error(ctx, "Failed to record transcode status", map[string]any{"error": err, "mediaID": media.ID.String()})
+		}
+		media.TranscodeStatus, media.TranscodeError = domain.TranscodeFailed, transcodeQueueFull
+	}
+}
+
+// resume queues the videos that were pending when the server last stopped, and those
+// whose worker has not reported back within the timeout.
+func (t *videoTranscoder) resume(ctx context.Context) {
+	stale := time.Now().Add(-t.timeout)
+	if err := t.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("transcode_status = ? AND updated_at < ?", domain.TranscodeProcessing, stale).
+		Update("transcode_status", domain.TranscodePending).Error; err != nil {
+		t.logger.Error(ctx, "Failed to reset interrupted transcoding jobs", map[string]any{"error": err})
+		return
+	}
+
+	var ids []uuid.UUID
+	if err := t.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("transcode_status = ?", domain.TranscodePending).
+		Order("updated_at").Pluck("id", &ids).Error; err != nil {
+		t.logger.Error(ctx, "Failed to load pending transcoding jobs", map[string]any{"error": err})
+		return
+	}
+	if len(ids) > 0 {
+		t.logger.Info(ctx, "Resuming pending transcoding jobs", map[string]any{"count": len(ids)})
+	}
+	for _, id := range ids {
+		select {
+		case t.queue <- id:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// work transcodes queued videos until ctx is done.
+func (t *videoTranscoder) work(ctx context.Context) {
+	for {
+		select {
+		case id := <-t.queue:
+			t.run(ctx, id)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// run claims a pending video, transcodes it and records the outcome. A video another
+// worker or server has claimed, or that was deleted meanwhile, is skipped.
+func (t *videoTranscoder) run(baseCtx context.Context, mediaID uuid.UUID) {
+	ctx, cancel := context.WithTimeout(baseCtx, t.timeout)
+	defer cancel()
+
+	claim := t.db.WithContext(ctx).Model(&domain.Media{}).
+		Where("id = ? AND transcode_status = ?", mediaID, domain.TranscodePending).
+		Updates(map[string]any{"transcode_status": domain.TranscodeProcessing, "transcode_error": ""})
+	if claim.Error != nil {
+		t.logger.Error(ctx, "Failed to claim video for transcoding", map[string]any{"error": claim.Error, "mediaID": mediaID.String()})
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	var media domain.Media
+	if err := t.db.WithContext(ctx).Where("id = ?", mediaID).First(&media).Error; err != nil {
+		t.logger.Error(ctx, "Failed to load video for transcoding", map[string]any{"error": err, "mediaID": mediaID.String()})
+		return
+	}
+
+	start := time.Now()
+	err := t.transcode(ctx, &media)
+	switch {
+	case err != nil && baseCtx.Err() != nil:
+		// Shutting down: the video is picked up again after the restart
+		if err := t.setStatus(context.WithoutCancel(ctx), mediaID, domain.TranscodePending, ""); err != nil {
+			t.logger.Error(ctx, "Failed to requeue interrupted transcoding job", map[string]any{"error": err, "mediaID": mediaID.String()})
+		}
+	case err != nil:
+		t.logger.Error(ctx, "Failed to transcode video", map[string]any{"error": err, "mediaID": mediaID.String()})
+		if err := t.setStatus(context.WithoutCancel(ctx), mediaID, domain.TranscodeFailed, err.Error()); err != nil {
+			t.logger.Error(ctx, "Failed to record transcode status", map[string]any{"error": err, "mediaID": mediaID.String()})
+		}
+	default:
+		t.logger.Info(ctx, "Video transcoded", map[string]any{
+			"mediaID": mediaID.String(), "transcodedPath": media.TranscodedPath, "hlsPath": media.HLSPath, "duration": time.Since(start).String(),
+		})
+	}
+}
+
+// transcode renders the renditions of media, uploads them next to the video and records
+// them on the media row.
+func (t *videoTranscoder) transcode(ctx context.Context, media *domain.Media) (err error) {
+	provider, err := t.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to get storage provider: %w", err)
+	}
+
+	workDir, err := os.MkdirTemp("", "m3-transcode-*")
+	if err != nil {
+		return fmt.Errorf("failed to create work dir: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	input := filepath.Join(workDir, "input"+filepath.Ext(media.FileName))
+	if err := downloadToFile(ctx, provider, media.FilePath, input); err != nil {
+		return err
+	}
+
+	output := filepath.Join(workDir, domain.TranscodedVideoName)
+	args := []string{
+		"-i", input,
+		"-map", "0:v:0", "-map", "0:a:0?",
+		"-c:v", "libx264", "-preset", t.cfg.Preset, "-crf", strconv.Itoa(t.cfg.CRF),
+		"-profile:v", "high", "-pix_fmt", "yuv420p",
+		"-vf", fmt.Sprintf("scale=-2:'trunc(min(%d,ih)/2)*2'", t.cfg.MaxHeight),
+		"-c:a", "aac", "-b:a", "128k",
+		"-movflags", "+faststart",
+	}
+	if t.cfg.HLS {
+		// Keyframes on segment boundaries, so the MP4 can be segmented without re-encoding
+		args = append(args, "-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", t.cfg.HLSSegmentSeconds))
+	}
+	if err := t.runner.Run(ctx, append(args, output)...); err != nil {
+		return fmt.Errorf("failed to transcode video: %w", err)
+	}
+
+	// Renditions uploaded before a failure are removed again
+	var uploaded []string
+	defer func() {
+		if err == nil {
+			return
+		}
+		for _, key := range uploaded {
+			if deleteErr := provider.Delete(context.WithoutCancel(ctx), key); deleteErr != nil {
+				t.logger.Warn(ctx, "Failed to remove rendition of failed transcoding job", map[string]any{"error": deleteErr, "key": key})
+			}
+		}
+	}()
+
+	mp4Key := domain.TranscodedKeyFor(media.FilePath)
+	mp4Object, err := uploadFile(ctx, provider, mp4Key, output, "video/mp4")
+	if err != nil {
+		return err
+	}
+	uploaded = append(uploaded, mp4Key)
+
+	var playlistKey, playlistURL string
+	var segmentKeys []string
+	if t.cfg.HLS {
+		hlsDir := filepath.Join(workDir, "hls")
+		if err := os.Mkdir(hlsDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create HLS dir: %w", err)
+		}
+		if err := t.runner.Run(ctx,
+			"-i", output,
+			"-c", "copy",
+			"-f", "hls",
+			"-hls_time", strconv.Itoa(t.cfg.HLSSegmentSeconds),
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(hlsDir, domain.HLSSegmentPrefix+"%03d.ts"),
+			filepath.Join(hlsDir, domain.HLSPlaylistName),
+		); err != nil {
+			return fmt.Errorf("failed to segment video for HLS: %w", err)
+		}
+
+		entries, err := os.ReadDir(hlsDir)
+		if err != nil {
+			return fmt.Errorf("failed to read HLS dir: %w", err)
+		}
+		prefix := domain.HLSDirFor(media.FilePath)
+		for _, entry := range entries {
+			if entry.Name() == domain.HLSPlaylistName {
+				continue
+			}
+			key := prefix + entry.Name()
+			if _, err := uploadFile(ctx, provider, key, filepath.Join(hlsDir, entry.Name()), "video/mp2t"); err != nil {
+				return err
+			}
+			uploaded = append(uploaded, key)
+			segmentKeys = append(segmentKeys, key)
+		}
+		// The playlist goes last, so it never refers to a segment that is not stored yet
+		playlistKey = prefix + domain.HLSPlaylistName
+		playlistObject, err := uploadFile(ctx, provider, playlistKey, filepath.Join(hlsDir, domain.HLSPlaylistName), "application/vnd.apple.mpegurl")
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, playlistKey)
+		playlistURL = playlistObject.URL
+	}
+
+	// The video may have been deleted or moved to another provider meanwhile
+	media.TranscodeStatus, media.TranscodeError = domain.TranscodeDone, ""
+	media.TranscodedPath, media.TranscodedURL = mp4Key, mp4Object.URL
+	media.HLSPath, media.HLSURL, media.HLSSegmentKeys = playlistKey, playlistURL, segmentKeys
+	result := t.db.WithContext(ctx).Model(media).
+		Where("provider = ? AND file_path = ?", media.Provider, media.FilePath).
+		Select("transcode_status", "transcode_error", "transcoded_path", "transcoded_url", "hls_path", "hls_url", "hls_segment_keys").
+		Updates(media)
+	if result.Error != nil {
+		return fmt.Errorf("failed to record renditions on media: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("media changed while it was transcoded")
+	}
+	return nil
+}
+
+// setStatus records the transcode status of a video.
+func (t *videoTranscoder) setStatus(ctx context.Context, mediaID uuid.UUID, status domain.TranscodeStatus, message string) error {
+	return t.db.WithContext(ctx).Model(&domain.Media{}).Where("id = ?", mediaID).
+		Updates(map[string]any{"transcode_status": status, "transcode_error": message}).Error
+}
+
+// renditionKey returns the key and content type of the rendition of media with the
+// given name: the MP4, the HLS playlist or one of its segments.
+func renditionKey(media *domain.Media, name string) (string, string, bool) {
+	switch {
+	case name == domain.TranscodedVideoName && media.HasTranscoded():
+		return media.TranscodedPath, "video/mp4", true
+	case name == domain.HLSPlaylistName && media.HasHLS():
+		return media.HLSPath, "application/vnd.apple.mpegurl", true
+	case strings.HasPrefix(name, domain.HLSSegmentPrefix) && media.HasHLS():
+		key := path.Dir(media.HLSPath) + "/" + name
+		if slices.Contains(media.HLSSegmentKeys, key) {
+			return key, "video/mp2t", true
+		}
+	}
+	return "", "", false
+}
+
+// GetRendition implements port.MediaService. It opens a transcoded rendition of a video
+// for streaming: the MP4, the HLS playlist or one of its segments.
+func (s *mediaService) GetRendition(ctx context.Context, userID uuid.UUID, mediaID uuid.UUID, name string) (io.ReadCloser, string, error) {
+	media, err := s.GetMedia(ctx, userID, mediaID)
+	if err != nil {
+		return nil, "", err
+	}
+	key, contentType, ok := renditionKey(media, name)
+	if !ok {
+		return nil, "", errors.NewNotFoundError("rendition not available")
+	}
+
+	storageProvider, err := s.storageFactory.CreateProviderByName(media.Provider)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to get storage provider", map[string]any{"error": err, "provider": media.Provider})
+		return nil, "", fmt.Errorf("failed to get storage provider: %w", err)
+	}
+	reader, _, err := storageProvider.Download(ctx, key)
+	if err != nil {
+		s.logger.Error(ctx, "Failed to download video rendition", map[string]any{"error": err, "key": key})
+		return nil, "", fmt.Errorf("failed to download video rendition: %w", err)
+	}
+	return s.bandwidth.readCloser(ctx, media.UserID, reader), contentType, nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/clamav"
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/media/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// Defaults for media.virusScan.
+const (
+	defaultClamdAddress     = "localhost:3310"
+	defaultVirusScanTimeout = 60 * time.Second
+)
+
+// noopScanner passes every file; it is used when scanning is disabled.
+type noopScanner struct{}
+
+func (noopScanner) Scan(context.Context, io.Reader) (bool, string, error) {
+	return true, "", nil
+}
+
+// newScanner builds the scanner selected by cfg.Provider.
+func newScanner(cfg config.VirusScanConfig, appLogger logger.Logger) port.Scanner {
+	switch cfg.Provider {
+	case "":
+		return noopScanner{}
+	case "clamav":
+		address := cfg.Address
+		if address == "" {
+			address = defaultClamdAddress
+		}
+		timeout := defaultVirusScanTimeout
+		if cfg.TimeoutSeconds > 0 {
+			timeout = time.Duration(cfg.TimeoutSeconds) * time.Second
+		}
+		return clamav.NewScanner(address, timeout)
+	default:
+		appLogger.Error(context.Background(), "Unknown virus scan provider, uploads will not be scanned", map[string]any{"provider": cfg.Provider})
+		return noopScanner{}
+	}
+}
+
+// scanUpload runs the configured scanner over file and rewinds it. Infected files are
+// rejected with 422 naming the signature. When the scanner fails the upload is rejected
+// with 503, unless media.virusScan.failOpen lets it through.
+func (s *mediaService) scanUpload(ctx context.Context, file io.ReadSeeker, fileName string) error {
+	clean, details, err := s.scanner.Scan(ctx, file)
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		return fmt.Errorf("failed to rewind file: %w", seekErr)
+	}
+	if err != nil {
+		s.logger.Error(ctx, "Failed to scan upload for malware", map[string]any{"error": err, "fileName": fileName, "failOpen": s.config.VirusScan.FailOpen})
+		if s.config.VirusScan.FailOpen {
+			return nil
+		}
+		return errors.NewError(http.StatusServiceUnavailable, "virus_scan_unavailable", "The file could not be scanned for malware, please try again later")
+	}
+	if !clean {
+		s.logger.Warn(ctx, "Malware detected in upload", map[string]any{"fileName": fileName, "signature": details})
+		return errors.NewError(http.StatusUnprocessableEntity, "malware_detected", fmt.Sprintf("File rejected: malware detected (%s)", details)).
+			WithDetails(map[string]any{"signature": details})
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// SignedRequest is what SigV4 signs of an HTTP request
+type SignedRequest struct {
+	Method        string
+	Path          string                   // Path as sent, still percent-encoded
+	Query         string                   // Raw query string
+	Header        func(name string) string // Value of a request header, empty when missing
+	ContentLength int64                    // Length of the body as sent; negative when unknown
+}
+
+// Authorization is a request whose signature has been verified
+type Authorization struct {
+	Credential  *Credential
+	PayloadHash string    // Value of x-amz-content-sha256: a SHA-256 hex digest or a payload mode
+	Date        time.Time // Signing time from x-amz-date
+	Scope       string    // Credential scope, <date>/<region>/s3/aws4_request
+	Signature   string    // Signature of the request, which seeds chunk signatures
+	SigningKey  []byte
+}
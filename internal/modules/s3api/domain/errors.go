@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// Error is an S3 error, returned to clients with its S3 code so SDKs react to it as
+// they would to S3 itself.
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// WithMessage returns a copy of the error with a more specific message
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+var (
+	ErrAccessDenied            = &Error{http.StatusForbidden, "AccessDenied", "Access Denied"}
+	ErrInvalidAccessKeyID      = &Error{http.StatusForbidden, "InvalidAccessKeyId", "The AWS access key ID you provided does not exist in our records."}
+	ErrSignatureDoesNotMatch   = &Error{http.StatusForbidden, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided."}
+	ErrRequestTimeTooSkewed    = &Error{http.StatusForbidden, "RequestTimeTooSkewed", "The difference between the request time and the server's time is too large."}
+	ErrAuthorizationMalformed  = &Error{http.StatusBadRequest, "AuthorizationHeaderMalformed", "The authorization header is malformed."}
+	ErrContentSHA256Mismatch   = &Error{http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed."}
+	ErrBadDigest               = &Error{http.StatusBadRequest, "BadDigest", "The Content-MD5 or checksum you specified did not match what we received."}
+	ErrInvalidDigest           = &Error{http.StatusBadRequest, "InvalidDigest", "The Content-MD5 or checksum you specified is not valid."}
+	ErrIncompleteBody          = &Error{http.StatusBadRequest, "IncompleteBody", "You did not provide the number of bytes specified by the Content-Length HTTP header."}
+	ErrInvalidArgument         = &Error{http.StatusBadRequest, "InvalidArgument", "Invalid Argument"}
+	ErrInvalidRequest          = &Error{http.StatusBadRequest, "InvalidRequest", "Invalid Request"}
+	ErrKeyTooLong              = &Error{http.StatusBadRequest, "KeyTooLongError", "Your key is too long."}
+	ErrEntityTooLarge          = &Error{http.StatusBadRequest, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size."}
+	ErrMissingContentLength    = &Error{http.StatusLengthRequired, "MissingContentLength", "You must provide the Content-Length HTTP header."}
+	ErrNoSuchBucket            = &Error{http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist."}
+	ErrNoSuchKey               = &Error{http.StatusNotFound, "NoSuchKey", "The specified key does not exist."}
+	ErrMethodNotAllowed        = &Error{http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource."}
+	ErrInvalidRange            = &Error{http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable."}
+	ErrNotImplemented          = &Error{http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented."}
+	ErrInternalError           = &Error{http.StatusInternalServerError, "InternalError", "We encountered an internal error. Please try again."}
+	ErrServiceUnavailable      = &Error{http.StatusServiceUnavailable, "ServiceUnavailable", "Please reduce your request rate."}
+	ErrBucketAlreadyOwnedByYou = &Error{http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it."}
+)
+
+// ErrorResponse is the XML body of an S3 error
+type ErrorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource,omitempty"`
+	RequestID string   `xml:"RequestId,omitempty"`
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// XMLNamespace is the namespace of S3 response documents
+const XMLNamespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// MaxListKeys is the most entries ListObjectsV2 returns per page, as in S3
+const MaxListKeys = 1000
+
+// Credential is an access key of the gateway and the user it acts as
+type Credential struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	UserID          uuid.UUID
+}
+
+// Object describes a stored object, keyed within its bucket
+type Object struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+	ETag         string // Quoted entity tag; empty when the backend reports none
+}
+
+// PutObjectInput is an object upload. Body yields exactly Size bytes.
+type PutObjectInput struct {
+	Bucket      string
+	Key         string
+	Size        int64
+	ContentType string
+	Metadata    map[string]string // x-amz-meta-* headers, without the prefix
+}
+
+// ListObjectsInput holds the parameters of a ListObjectsV2 request
+type ListObjectsInput struct {
+	Bucket            string
+	Prefix            string
+	Delimiter         string
+	MaxKeys           int
+	ContinuationToken string
+	StartAfter        string
+}
+
+// ListObjectsOutput is one page of a bucket listing
+type ListObjectsOutput struct {
+	Objects               []Object
+	CommonPrefixes        []string
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// ListBucketResult is the XML body of a ListObjectsV2 response
+type ListBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	KeyCount              int            `xml:"KeyCount"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	Contents              []ObjectXML    `xml:"Contents"`
+	CommonPrefixes        []CommonPrefix `xml:"CommonPrefixes"`
+}
+
+// ObjectXML is an object entry of a listing
+type ObjectXML struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag,omitempty"`
+	Size         int64  `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+// CommonPrefix is a group of keys rolled up by the delimiter
+type CommonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// ListAllMyBucketsResult is the XML body of a ListBuckets response
+type ListAllMyBucketsResult struct {
+	XMLName xml.Name    `xml:"ListAllMyBucketsResult"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Owner   Owner       `xml:"Owner"`
+	Buckets []BucketXML `xml:"Buckets>Bucket"`
+}
+
+// Owner identifies the user owning the listed buckets
+type Owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+// BucketXML is a bucket entry of ListBuckets
+type BucketXML struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+// LocationConstraint is the XML body of a GetBucketLocation response
+type LocationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Region  string   `xml:",chardata"`
+}
@@ -0,0 +1,435 @@
+package handler
+
+import (
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	"github.com/lugondev/m3-storage/internal/modules/s3api/port"
+)
+
+const (
+	// authorizationKey holds the verified *domain.Authorization in the request locals
+	authorizationKey = "s3Authorization"
+	// defaultContentType is what S3 reports for objects stored without a type
+	defaultContentType = "binary/octet-stream"
+	// metadataHeaderPrefix starts the headers carrying user metadata
+	metadataHeaderPrefix = "X-Amz-Meta-"
+)
+
+// unsupportedObjectQueries select object subresources and multipart operations, which
+// the gateway does not implement
+var unsupportedObjectQueries = []string{"uploads", "uploadId", "partNumber", "tagging", "acl", "retention", "legal-hold", "torrent", "restore", "select", "versionId"}
+
+// S3Handler serves the S3-compatible API: path-style object operations, ListObjectsV2
+// and the bucket calls clients make before using a bucket.
+type S3Handler struct {
+	gateway port.Gateway
+	auth    port.Authenticator
+	region  string
+	logger  logger.Logger
+}
+
+// NewS3Handler creates an S3 handler for buckets in region
+func NewS3Handler(gateway port.Gateway, auth port.Authenticator, region string, appLogger logger.Logger) *S3Handler {
+	return &S3Handler{
+		gateway: gateway,
+		auth:    auth,
+		region:  region,
+		logger:  appLogger.WithFields(map[string]any{"component": "S3Handler"}),
+	}
+}
+
+// Authenticate assigns the request an ID and verifies its SigV4 signature
+func (h *S3Handler) Authenticate(c *fiber.Ctx) error {
+	c.Set("x-amz-request-id", strings.ReplaceAll(uuid.NewString(), "-", ""))
+	auth, err := h.auth.Authenticate(signedRequest(c))
+	if err != nil {
+		return err
+	}
+	c.Locals(authorizationKey, auth)
+	return c.Next()
+}
+
+// ListBuckets lists the configured buckets
+func (h *S3Handler) ListBuckets(c *fiber.Ctx) error {
+	auth := authorization(c)
+	result := &domain.ListAllMyBucketsResult{
+		Xmlns: domain.XMLNamespace,
+		Owner: domain.Owner{ID: auth.Credential.UserID.String(), DisplayName: auth.Credential.AccessKeyID},
+	}
+	// Buckets are configured rather than created, so they carry no creation date of their own
+	created := time.Unix(0, 0).UTC().Format(time.RFC3339)
+	for _, name := range h.gateway.Buckets() {
+		result.Buckets = append(result.Buckets, domain.BucketXML{Name: name, CreationDate: created})
+	}
+	return writeXML(c, result)
+}
+
+// HeadBucket reports whether a bucket exists
+func (h *S3Handler) HeadBucket(c *fiber.Ctx) error {
+	if !h.gateway.HasBucket(c.Params("bucket")) {
+		return domain.ErrNoSuchBucket
+	}
+	c.Set("x-amz-bucket-region", h.region)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// PutBucket accepts creating a configured bucket, as S3 does for a bucket the caller
+// already owns in us-east-1; other buckets cannot be created through the API.
+func (h *S3Handler) PutBucket(c *fiber.Ctx) error {
+	if !h.gateway.HasBucket(c.Params("bucket")) {
+		return domain.ErrAccessDenied.WithMessage("Buckets are configured on the server and cannot be created through the API.")
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// GetBucket serves ListObjectsV2 and GetBucketLocation
+func (h *S3Handler) GetBucket(c *fiber.Ctx) error {
+	bucket := c.Params("bucket")
+	if !h.gateway.HasBucket(bucket) {
+		return domain.ErrNoSuchBucket
+	}
+	query := c.Context().QueryArgs()
+	switch {
+	case query.Has("location"):
+		location := &domain.LocationConstraint{Xmlns: domain.XMLNamespace, Region: h.region}
+		if h.region == "us-east-1" {
+			location.Region = "" // S3 reports the default region as an empty constraint
+		}
+		return writeXML(c, location)
+	case c.Query("list-type") == "2":
+		return h.listObjects(c, bucket)
+	case c.Query("list-type") == "":
+		return domain.ErrNotImplemented.WithMessage("ListObjects (V1) is not supported; use ListObjectsV2 (list-type=2).")
+	default:
+		return domain.ErrInvalidArgument.WithMessage("Unsupported list-type " + c.Query("list-type") + ".")
+	}
+}
+
+func (h *S3Handler) listObjects(c *fiber.Ctx, bucket string) error {
+	input := &domain.ListObjectsInput{
+		Bucket:            bucket,
+		Prefix:            c.Query("prefix"),
+		Delimiter:         c.Query("delimiter"),
+		MaxKeys:           domain.MaxListKeys,
+		ContinuationToken: c.Query("continuation-token"),
+		StartAfter:        c.Query("start-after"),
+	}
+	if value := c.Query("max-keys"); value != "" {
+		maxKeys, err := strconv.Atoi(value)
+		if err != nil || maxKeys < 0 {
+			return domain.ErrInvalidArgument.WithMessage("max-keys must be a non-negative integer.")
+		}
+		input.MaxKeys = min(maxKeys, domain.MaxListKeys)
+	}
+	encodingType := c.Query("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		return domain.ErrInvalidArgument.WithMessage("Invalid Encoding Method specified in Request.")
+	}
+
+	output, err := h.gateway.ListObjects(c.UserContext(), authorization(c).Credential, input)
+	if err != nil {
+		return err
+	}
+
+	// With encoding-type=url, keys are returned encoded so any byte survives the XML
+	encode := func(s string) string { return s }
+	if encodingType == "url" {
+		encode = url.QueryEscape
+	}
+	result := &domain.ListBucketResult{
+		Xmlns:                 domain.XMLNamespace,
+		Name:                  bucket,
+		Prefix:                encode(input.Prefix),
+		Delimiter:             encode(input.Delimiter),
+		MaxKeys:               input.MaxKeys,
+		KeyCount:              len(output.Objects) + len(output.CommonPrefixes),
+		IsTruncated:           output.IsTruncated,
+		ContinuationToken:     input.ContinuationToken,
+		NextContinuationToken: output.NextContinuationToken,
+		StartAfter:            encode(input.StartAfter),
+		EncodingType:          encodingType,
+	}
+	for _, object := range output.Objects {
+		result.Contents = append(result.Contents, domain.ObjectXML{
+			Key:          encode(object.Key),
+			LastModified: object.LastModified.UTC().Format("2006-01-02T15:04:05.000Z"),
+			ETag:         object.ETag,
+			Size:         object.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+	for _, prefix := range output.CommonPrefixes {
+		result.CommonPrefixes = append(result.CommonPrefixes, domain.CommonPrefix{Prefix: encode(prefix)})
+	}
+	return writeXML(c, result)
+}
+
+// PutObject stores the request body as an object
+func (h *S3Handler) PutObject(c *fiber.Ctx) error {
+	key, err := objectKey(c)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return h.PutBucket(c)
+	}
+	if err := unsupportedObjectQuery(c); err != nil {
+		return err
+	}
+	if c.Get("X-Amz-Copy-Source") != "" {
+		return domain.ErrNotImplemented.WithMessage("CopyObject is not supported.")
+	}
+
+	auth := authorization(c)
+	req := signedRequest(c)
+	body, size, verify, err := h.auth.Payload(auth, req, c.Context().RequestBodyStream())
+	if err != nil {
+		return err
+	}
+	input := &domain.PutObjectInput{
+		Bucket:      c.Params("bucket"),
+		Key:         key,
+		Size:        size,
+		ContentType: c.Get(fiber.HeaderContentType),
+		Metadata:    map[string]string{},
+	}
+	c.Request().Header.VisitAll(func(name, value []byte) {
+		if header := string(name); len(header) > len(metadataHeaderPrefix) && strings.EqualFold(header[:len(metadataHeaderPrefix)], metadataHeaderPrefix) {
+			input.Metadata[strings.ToLower(header[len(metadataHeaderPrefix):])] = string(value)
+		}
+	})
+
+	object, err := h.gateway.PutObject(c.UserContext(), auth.Credential, input, body, verify)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderETag, object.ETag)
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// HeadObject returns the metadata of an object
+func (h *S3Handler) HeadObject(c *fiber.Ctx) error {
+	key, err := objectKey(c)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return h.HeadBucket(c)
+	}
+	object, err := h.gateway.HeadObject(c.UserContext(), authorization(c).Credential, c.Params("bucket"), key)
+	if err != nil {
+		return err
+	}
+	setObjectHeaders(c, object)
+	c.Response().Header.SetContentLength(int(object.Size))
+	c.Response().SkipBody = true
+	return nil
+}
+
+// GetObject streams an object, or the byte range named in the Range header
+func (h *S3Handler) GetObject(c *fiber.Ctx) error {
+	key, err := objectKey(c)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return h.GetBucket(c)
+	}
+	if err := unsupportedObjectQuery(c); err != nil {
+		return err
+	}
+	ctx, user, bucket := c.UserContext(), authorization(c).Credential, c.Params("bucket")
+
+	start, end, suffix, ranged := parseRange(c.Get(fiber.HeaderRange))
+	if ranged && suffix > 0 {
+		object, err := h.gateway.HeadObject(ctx, user, bucket, key)
+		if err != nil {
+			return err
+		}
+		start, end = max(object.Size-suffix, 0), -1
+	}
+	if !ranged {
+		start = -1
+	}
+
+	reader, object, err := h.gateway.GetObject(ctx, user, bucket, key, start, end)
+	if err != nil {
+		return err
+	}
+	setObjectHeaders(c, object)
+	if !ranged {
+		c.Context().SetBodyStream(reader, int(object.Size))
+		return nil
+	}
+
+	if start >= object.Size {
+		reader.Close()
+		c.Set(fiber.HeaderContentRange, "bytes */"+strconv.FormatInt(object.Size, 10))
+		return domain.ErrInvalidRange
+	}
+	if end < 0 || end >= object.Size {
+		end = object.Size - 1
+	}
+	c.Set(fiber.HeaderContentRange, "bytes "+strconv.FormatInt(start, 10)+"-"+strconv.FormatInt(end, 10)+"/"+strconv.FormatInt(object.Size, 10))
+	c.Status(fiber.StatusPartialContent)
+	c.Context().SetBodyStream(reader, int(end-start+1))
+	return nil
+}
+
+// DeleteObject removes an object
+func (h *S3Handler) DeleteObject(c *fiber.Ctx) error {
+	key, err := objectKey(c)
+	if err != nil {
+		return err
+	}
+	if key == "" {
+		return domain.ErrMethodNotAllowed.WithMessage("Buckets are configured on the server and cannot be deleted through the API.")
+	}
+	if err := unsupportedObjectQuery(c); err != nil {
+		return err
+	}
+	if err := h.gateway.DeleteObject(c.UserContext(), authorization(c).Credential, c.Params("bucket"), key); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// NotImplemented answers the S3 operations the gateway does not serve, such as
+// multipart uploads and DeleteObjects
+func (h *S3Handler) NotImplemented(c *fiber.Ctx) error {
+	return domain.ErrNotImplemented
+}
+
+// ErrorHandler writes errors as S3 error documents, so S3 clients can act on their codes
+func (h *S3Handler) ErrorHandler(c *fiber.Ctx, err error) error {
+	var s3Err *domain.Error
+	var fiberErr *fiber.Error
+	switch {
+	case errors.As(err, &s3Err):
+	case errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusRequestEntityTooLarge:
+		s3Err = domain.ErrEntityTooLarge
+	case errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError:
+		s3Err = domain.ErrInvalidRequest.WithMessage(fiberErr.Message)
+	default:
+		s3Err = domain.ErrInternalError
+	}
+	if s3Err.StatusCode >= fiber.StatusInternalServerError && s3Err.StatusCode != fiber.StatusNotImplemented {
+		h.logger.Error(c.UserContext(), "S3 request failed", map[string]any{
+			"error":      err.Error(),
+			"request_id": string(c.Response().Header.Peek("x-amz-request-id")),
+			"method":     c.Method(),
+			"path":       c.Path(),
+		})
+	}
+
+	c.Status(s3Err.StatusCode)
+	if c.Method() == fiber.MethodHead {
+		return nil // HEAD responses carry no body, so clients only see the status
+	}
+	return writeXML(c, &domain.ErrorResponse{
+		Code:      s3Err.Code,
+		Message:   s3Err.Message,
+		Resource:  c.Path(),
+		RequestID: string(c.Response().Header.Peek("x-amz-request-id")),
+	})
+}
+
+// signedRequest describes the request for SigV4 verification.
+func signedRequest(c *fiber.Ctx) *domain.SignedRequest {
+	return &domain.SignedRequest{
+		Method:        c.Method(),
+		Path:          c.Path(),
+		Query:         string(c.Request().URI().QueryString()),
+		Header:        func(name string) string { return c.Get(name) },
+		ContentLength: int64(c.Request().Header.ContentLength()),
+	}
+}
+
+func authorization(c *fiber.Ctx) *domain.Authorization {
+	return c.Locals(authorizationKey).(*domain.Authorization)
+}
+
+// objectKey returns the decoded object key of the request path, empty for a bucket.
+func objectKey(c *fiber.Ctx) (string, error) {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return "", domain.ErrInvalidArgument.WithMessage("The object key is not correctly percent-encoded.")
+	}
+	return key, nil
+}
+
+func unsupportedObjectQuery(c *fiber.Ctx) error {
+	query := c.Context().QueryArgs()
+	for _, name := range unsupportedObjectQueries {
+		if query.Has(name) {
+			return domain.ErrNotImplemented.WithMessage("The " + name + " subresource is not supported.")
+		}
+	}
+	return nil
+}
+
+// parseRange parses a single byte range. A suffix range ("bytes=-N") is returned as
+// suffix N. Ranges the gateway cannot serve, such as several at once, are ignored so
+// the whole object is returned, as HTTP allows.
+func parseRange(header string) (start, end, suffix int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, 0, false
+	}
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, 0, false
+		}
+		return 0, -1, n, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, 0, false
+	}
+	if last == "" {
+		return start, -1, 0, true
+	}
+	end, err = strconv.ParseInt(last, 10, 64)
+	if err != nil || end < start {
+		return 0, 0, 0, false
+	}
+	return start, end, 0, true
+}
+
+func setObjectHeaders(c *fiber.Ctx, object *domain.Object) {
+	contentType := object.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	c.Set(fiber.HeaderLastModified, object.LastModified.UTC().Format(http.TimeFormat))
+	if object.ETag != "" {
+		c.Set(fiber.HeaderETag, object.ETag)
+	}
+}
+
+func writeXML(c *fiber.Ctx, body any) error {
+	data, err := xml.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXML)
+	return c.Send(append([]byte(xml.Header), data...))
+}
@@ -0,0 +1,52 @@
+package port
+
+import (
+	"context"
+	"io"
+
+	"github.com/google/uuid"
+
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+)
+
+// Gateway serves S3 object operations from the storage backends. Each user has a
+// namespace of their own in every bucket; errors are *domain.Error.
+type Gateway interface {
+	// Buckets returns the names of the configured buckets, sorted
+	Buckets() []string
+
+	// HasBucket reports whether bucket is configured
+	HasBucket(bucket string) bool
+
+	// PutObject stores body as an object of the user, replacing any object at the key.
+	// verify runs once the backend has stored the body and fails when it did not match
+	// the request's signature or checksums; the object is then removed again.
+	PutObject(ctx context.Context, user *domain.Credential, input *domain.PutObjectInput, body io.Reader, verify func() error) (*domain.Object, error)
+
+	// HeadObject returns the metadata of an object of the user
+	HeadObject(ctx context.Context, user *domain.Credential, bucket, key string) (*domain.Object, error)
+
+	// GetObject opens an object of the user, or its inclusive byte range start..end when
+	// start is not negative; a negative end reads to the end. The caller closes the reader.
+	GetObject(ctx context.Context, user *domain.Credential, bucket, key string, start, end int64) (io.ReadCloser, *domain.Object, error)
+
+	// DeleteObject removes an object of the user; a missing object is not an error
+	DeleteObject(ctx context.Context, user *domain.Credential, bucket, key string) error
+
+	// ListObjects returns one page of the user's objects in a bucket
+	ListObjects(ctx context.Context, user *domain.Credential, input *domain.ListObjectsInput) (*domain.ListObjectsOutput, error)
+
+	// UsedBytes returns the total size of the user's objects in every bucket
+	UsedBytes(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+// Authenticator checks SigV4 signatures of requests against the gateway's access keys
+type Authenticator interface {
+	// Authenticate returns the verified authorization of a request
+	Authenticate(req *domain.SignedRequest) (*domain.Authorization, error)
+
+	// Payload decodes the body of an authorized request, which may be aws-chunked, and
+	// returns it with its decoded size. verify reports whether the body matched its
+	// signatures, Content-MD5 and checksums; call it after reading the whole body.
+	Payload(auth *domain.Authorization, req *domain.SignedRequest, body io.Reader) (payload io.Reader, size int64, verify func() error, err error)
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	"github.com/lugondev/m3-storage/internal/modules/s3api/port"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	sharedErrors "github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+const (
+	// DefaultRegion is the region clients sign for when s3Gateway.region is not set
+	DefaultRegion = "us-east-1"
+	// objectKeyPrefix keeps gateway objects apart from media files on shared backends
+	objectKeyPrefix = "s3"
+	// stagingDir holds PUT bodies until they are verified; bucket names cannot start with a dot
+	stagingDir = ".uploads"
+	// maxKeyLength is the longest object key accepted, in bytes, as in S3
+	maxKeyLength = 1024
+	// defaultMaxObjectSize is the largest PUT accepted when s3Gateway.maxObjectSize is not set
+	defaultMaxObjectSize int64 = 5 << 30
+)
+
+type gateway struct {
+	storage       storagePort.StorageFactory
+	users         authPort.UserService
+	buckets       map[string]string // Bucket name -> backend or alias
+	maxObjectSize int64
+	logger        logger.Logger
+}
+
+// NewGateway creates the S3 gateway over the storage backends. Objects count against the
+// storage quota of the user a credential acts as.
+func NewGateway(storage storagePort.StorageFactory, users authPort.UserService, cfg config.S3GatewayConfig, log logger.Logger) port.Gateway {
+	maxObjectSize := cfg.MaxObjectSize
+	if maxObjectSize <= 0 {
+		maxObjectSize = defaultMaxObjectSize
+	}
+	return &gateway{
+		storage:       storage,
+		users:         users,
+		buckets:       cfg.Buckets,
+		maxObjectSize: maxObjectSize,
+		logger:        log.WithFields(map[string]any{"component": "S3Gateway"}),
+	}
+}
+
+// Buckets returns the configured bucket names, sorted
+func (g *gateway) Buckets() []string {
+	names := make([]string, 0, len(g.buckets))
+	for name := range g.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasBucket reports whether bucket is configured
+func (g *gateway) HasBucket(bucket string) bool {
+	_, ok := g.buckets[bucket]
+	return ok
+}
+
+// PutObject stores an object. The body is written to a staging key and only moved over
+// the object key once verify accepts it, so a rejected body leaves any object already
+// there untouched. Replacing an object charges the user's storage usage only with the
+// difference in size.
+func (g *gateway) PutObject(ctx context.Context, user *domain.Credential, input *domain.PutObjectInput, body io.Reader, verify func() error) (*domain.Object, error) {
+	provider, err := g.provider(input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	key, err := objectKey(user, input.Bucket, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	if input.Size > g.maxObjectSize {
+		return nil, domain.ErrEntityTooLarge
+	}
+	replaced, err := g.storedSize(ctx, provider, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.users.CanUpload(ctx, user.UserID, max(input.Size-replaced, 0)); err != nil {
+		return nil, g.storageError(ctx, err)
+	}
+
+	staging := stagingKey(user)
+	hash := md5.New()
+	stored, err := provider.Upload(ctx, staging, io.TeeReader(body, hash), input.Size, &storagePort.UploadOptions{
+		ContentType: input.ContentType,
+		Metadata:    input.Metadata,
+	})
+	if err != nil {
+		g.removeStaged(ctx, provider, staging)
+		return nil, g.storageError(ctx, err)
+	}
+	if err := verify(); err != nil {
+		g.removeStaged(ctx, provider, staging)
+		return nil, err
+	}
+	if err := provider.Move(ctx, staging, key); err != nil {
+		var partial *storagePort.PartialMoveError
+		if !errors.As(err, &partial) {
+			g.removeStaged(ctx, provider, staging)
+			return nil, g.storageError(ctx, err)
+		}
+		// The object is in place; only the staged copy was left behind
+		g.logger.Warn(ctx, "Failed to remove staged S3 upload", map[string]any{"key": staging, "error": err.Error()})
+	}
+
+	added := input.Size - replaced
+	if err := g.users.RecordUpload(ctx, user.UserID, max(added, 0)); err != nil {
+		g.logger.Warn(ctx, "Failed to record S3 upload against the user's quota", map[string]any{
+			"userID": user.UserID.String(), "error": err.Error(),
+		})
+	}
+	if added < 0 {
+		g.releaseStorage(ctx, user, -added)
+	}
+
+	object := toObject(input.Key, stored)
+	if object.ETag == "" {
+		object.ETag = `"` + hex.EncodeToString(hash.Sum(nil)) + `"`
+	}
+	return object, nil
+}
+
+// removeStaged deletes a staged upload that will not be moved into place
+func (g *gateway) removeStaged(ctx context.Context, provider storagePort.StorageProvider, staging string) {
+	err := provider.Delete(context.WithoutCancel(ctx), staging)
+	if err != nil && !errors.Is(err, storagePort.ErrObjectNotFound) {
+		g.logger.Error(ctx, "Failed to remove staged S3 upload", map[string]any{"key": staging, "error": err.Error()})
+	}
+}
+
+// HeadObject returns the metadata of an object
+func (g *gateway) HeadObject(ctx context.Context, user *domain.Credential, bucket, key string) (*domain.Object, error) {
+	provider, err := g.provider(bucket)
+	if err != nil {
+		return nil, err
+	}
+	backendKey, err := objectKey(user, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := provider.GetObject(ctx, backendKey)
+	if err != nil {
+		return nil, g.storageError(ctx, err)
+	}
+	return toObject(key, stored), nil
+}
+
+// GetObject opens an object or a byte range of it
+func (g *gateway) GetObject(ctx context.Context, user *domain.Credential, bucket, key string, start, end int64) (io.ReadCloser, *domain.Object, error) {
+	provider, err := g.provider(bucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	backendKey, err := objectKey(user, bucket, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var reader io.ReadCloser
+	var stored *storagePort.FileObject
+	if start < 0 {
+		reader, stored, err = provider.Download(ctx, backendKey)
+	} else {
+		reader, stored, err = provider.DownloadRange(ctx, backendKey, start, end)
+	}
+	if err != nil {
+		return nil, nil, g.storageError(ctx, err)
+	}
+	return reader, toObject(key, stored), nil
+}
+
+// DeleteObject removes an object and returns its size to the user's storage quota. As
+// in S3, deleting a missing object succeeds.
+func (g *gateway) DeleteObject(ctx context.Context, user *domain.Credential, bucket, key string) error {
+	provider, err := g.provider(bucket)
+	if err != nil {
+		return err
+	}
+	backendKey, err := objectKey(user, bucket, key)
+	if err != nil {
+		return err
+	}
+	size, err := g.storedSize(ctx, provider, backendKey)
+	if err != nil {
+		return err
+	}
+	if err := provider.Delete(ctx, backendKey); err != nil && !errors.Is(err, storagePort.ErrObjectNotFound) {
+		return g.storageError(ctx, err)
+	}
+	g.releaseStorage(ctx, user, size)
+	return nil
+}
+
+// UsedBytes sums the sizes of the user's objects in every bucket
+func (g *gateway) UsedBytes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	user := &domain.Credential{UserID: userID}
+	var used int64
+	for _, bucket := range g.Buckets() {
+		provider, err := g.provider(bucket)
+		if err != nil {
+			return 0, fmt.Errorf("bucket %s: %w", bucket, err)
+		}
+		token := ""
+		for {
+			objects, next, err := provider.ListObjects(ctx, bucketPrefix(user, bucket), &storagePort.ListOptions{
+				MaxKeys:           listPageSize,
+				ContinuationToken: token,
+			})
+			if err != nil {
+				return 0, fmt.Errorf("failed to list objects of bucket %s: %w", bucket, err)
+			}
+			for _, object := range objects {
+				used += object.Size
+			}
+			if next == "" {
+				break
+			}
+			token = next
+		}
+	}
+	return used, nil
+}
+
+// storedSize returns the size of the object at a backend key, or 0 when there is none
+func (g *gateway) storedSize(ctx context.Context, provider storagePort.StorageProvider, key string) (int64, error) {
+	stored, err := provider.GetObject(ctx, key)
+	if errors.Is(err, storagePort.ErrObjectNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, g.storageError(ctx, err)
+	}
+	return stored.Size, nil
+}
+
+// releaseStorage returns size bytes of removed objects to the user's storage quota. The
+// objects are already gone, so a failure is logged and left to the usage reconcile.
+func (g *gateway) releaseStorage(ctx context.Context, user *domain.Credential, size int64) {
+	if err := g.users.ReleaseStorage(ctx, user.UserID, size); err != nil {
+		g.logger.Warn(ctx, "Failed to release storage usage of removed S3 object", map[string]any{
+			"userID": user.UserID.String(), "size": size, "error": err.Error(),
+		})
+	}
+}
+
+// provider returns the storage provider holding bucket.
+func (g *gateway) provider(bucket string) (storagePort.StorageProvider, error) {
+	backend, ok := g.buckets[bucket]
+	if !ok {
+		return nil, domain.ErrNoSuchBucket
+	}
+	provider, err := g.storage.CreateProviderByName(backend)
+	if err != nil {
+		return nil, domain.ErrServiceUnavailable.WithMessage("The storage backend of this bucket is unavailable.")
+	}
+	return provider, nil
+}
+
+// storageError maps an error of a storage provider or the quota check to an S3 error.
+func (g *gateway) storageError(ctx context.Context, err error) error {
+	var s3Err *domain.Error
+	var appErr *sharedErrors.Error
+	switch {
+	case errors.As(err, &s3Err):
+		return s3Err // The request body failed its checks while the provider read it
+	case errors.Is(err, storagePort.ErrObjectNotFound):
+		return domain.ErrNoSuchKey
+	case errors.Is(err, storagePort.ErrInvalidKey):
+		return domain.ErrInvalidArgument.WithMessage("The object key is not supported by the storage backend.")
+	case errors.Is(err, storagePort.ErrInvalidRange):
+		return domain.ErrInvalidRange
+	case errors.Is(err, storagePort.ErrCircuitOpen):
+		return domain.ErrServiceUnavailable
+	case errors.As(err, &appErr) && appErr.StatusCode == http.StatusForbidden:
+		return domain.ErrAccessDenied.WithMessage(appErr.Message)
+	}
+	g.logger.Error(ctx, "S3 gateway storage operation failed", map[string]any{"error": err.Error()})
+	return domain.ErrInternalError
+}
+
+// objectKey returns the backend key of a user's object. Keys are kept to what every
+// backend stores as given: no empty, "." or ".." segments, which a filesystem would fold.
+func objectKey(user *domain.Credential, bucket, key string) (string, error) {
+	if len(key) > maxKeyLength {
+		return "", domain.ErrKeyTooLong
+	}
+	if key == "" || !utf8.ValidString(key) {
+		return "", domain.ErrInvalidArgument.WithMessage("The object key must be valid UTF-8 and not empty.")
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return "", domain.ErrInvalidArgument.WithMessage("Object keys with empty, '.' or '..' path segments are not supported.")
+		}
+	}
+	return bucketPrefix(user, bucket) + key, nil
+}
+
+// stagingKey returns a fresh backend key for a PUT body that has not been verified yet.
+// It sits beside the user's buckets under a name no bucket can have, so staged bodies
+// are never listed or counted as objects.
+func stagingKey(user *domain.Credential) string {
+	return objectKeyPrefix + "/" + user.UserID.String() + "/" + stagingDir + "/" + uuid.NewString()
+}
+
+// bucketPrefix returns the backend key prefix of a user's objects in bucket.
+func bucketPrefix(user *domain.Credential, bucket string) string {
+	return objectKeyPrefix + "/" + user.UserID.String() + "/" + bucket + "/"
+}
+
+func toObject(key string, stored *storagePort.FileObject) *domain.Object {
+	object := &domain.Object{
+		Key:          key,
+		Size:         stored.Size,
+		ContentType:  stored.ContentType,
+		LastModified: stored.LastModified,
+		ETag:         stored.ETag,
+	}
+	if object.ETag != "" && !strings.HasPrefix(object.ETag, `"`) {
+		object.ETag = `"` + object.ETag + `"`
+	}
+	return object
+}
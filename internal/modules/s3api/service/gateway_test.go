@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/adapters/local"
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	authPort "github.com/lugondev/m3-storage/internal/modules/auth/port"
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// singleProviderFactory hands out one provider for every backend name
+type singleProviderFactory struct {
+	storagePort.StorageFactory
+	provider storagePort.StorageProvider
+}
+
+func (f singleProviderFactory) CreateProviderByName(string) (storagePort.StorageProvider, error) {
+	return f.provider, nil
+}
+
+// quotaUsers keeps one storage usage counter per user against a shared quota
+type quotaUsers struct {
+	authPort.UserService
+	quota int64
+
+	mu   sync.Mutex
+	used map[uuid.UUID]int64
+}
+
+func (u *quotaUsers) CanUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.used[userID]+size > u.quota {
+		return errors.NewForbiddenError("storage quota exceeded")
+	}
+	return nil
+}
+
+func (u *quotaUsers) RecordUpload(ctx context.Context, userID uuid.UUID, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.used[userID] += size
+	return nil
+}
+
+func (u *quotaUsers) ReleaseStorage(ctx context.Context, userID uuid.UUID, size int64) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.used[userID] = max(u.used[userID]-size, 0)
+	return nil
+}
+
+func (u *quotaUsers) usage(userID uuid.UUID) int64 {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.used[userID]
+}
+
+func TestGatewayStorageUsage(t *testing.T) {
+	ctx := context.Background()
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	provider, err := local.NewLocalStorageProvider(config.LocalStorageConfig{Path: t.TempDir(), BaseURL: "http://localhost/files", SignedURLSecret: "test-secret"}, log, clock.New())
+	if err != nil {
+		t.Fatalf("NewLocalStorageProvider: %v", err)
+	}
+	users := &quotaUsers{quota: 120, used: map[uuid.UUID]int64{}}
+	gw := NewGateway(singleProviderFactory{provider: provider}, users, config.S3GatewayConfig{
+		Buckets: map[string]string{"photos": "local", "backups": "local"},
+	}, log)
+	user := &domain.Credential{UserID: uuid.New()}
+
+	put := func(bucket, key string, size int) error {
+		input := &domain.PutObjectInput{Bucket: bucket, Key: key, Size: int64(size), ContentType: "application/octet-stream"}
+		_, err := gw.PutObject(ctx, user, input, bytes.NewReader(make([]byte, size)), func() error { return nil })
+		return err
+	}
+	steps := []struct {
+		name     string
+		do       func() error
+		wantErr  bool
+		wantUsed int64
+	}{
+		{"put new object", func() error { return put("photos", "a.jpg", 100) }, false, 100},
+		{"overwrite with a smaller object", func() error { return put("photos", "a.jpg", 40) }, false, 40},
+		{"put into another bucket", func() error { return put("backups", "db/dump.sql", 70) }, false, 110},
+		// Only the 5 added bytes count against the 120 byte quota, not all 75
+		{"overwrite with a larger object", func() error { return put("backups", "db/dump.sql", 75) }, false, 115},
+		{"put over the quota", func() error { return put("photos", "b.jpg", 10) }, true, 115},
+		{"delete", func() error { return gw.DeleteObject(ctx, user, "photos", "a.jpg") }, false, 75},
+		{"delete a missing object", func() error { return gw.DeleteObject(ctx, user, "photos", "a.jpg") }, false, 75},
+	}
+	for _, step := range steps {
+		err := step.do()
+		if step.wantErr != (err != nil) {
+			t.Fatalf("%s: error = %v, want error %v", step.name, err, step.wantErr)
+		}
+		if used := users.usage(user.UserID); used != step.wantUsed {
+			t.Fatalf("%s: used storage = %d, want %d", step.name, used, step.wantUsed)
+		}
+	}
+
+	// The recount sees the same bytes, and none of another user's
+	other := &domain.Credential{UserID: uuid.New()}
+	if _, err := gw.PutObject(ctx, other, &domain.PutObjectInput{Bucket: "photos", Key: "x", Size: 3}, bytes.NewReader([]byte("abc")), func() error { return nil }); err != nil {
+		t.Fatalf("put for another user: %v", err)
+	}
+	used, err := gw.UsedBytes(ctx, user.UserID)
+	if err != nil {
+		t.Fatalf("UsedBytes: %v", err)
+	}
+	if used != 75 {
+		t.Errorf("UsedBytes = %d, want 75", used)
+	}
+}
+
+func TestGatewayRejectedPutKeepsObject(t *testing.T) {
+	ctx := context.Background()
+	log, err := logger.NewLogger(&logger.Option{Format: "console"})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	provider, err := local.NewLocalStorageProvider(config.LocalStorageConfig{Path: t.TempDir(), BaseURL: "http://localhost/files", SignedURLSecret: "test-secret"}, log, clock.New())
+	if err != nil {
+		t.Fatalf("NewLocalStorageProvider: %v", err)
+	}
+	users := &quotaUsers{quota: 1000, used: map[uuid.UUID]int64{}}
+	gw := NewGateway(singleProviderFactory{provider: provider}, users, config.S3GatewayConfig{Buckets: map[string]string{"photos": "local"}}, log)
+	user := &domain.Credential{UserID: uuid.New()}
+
+	original := bytes.Repeat([]byte("a"), 100)
+	input := &domain.PutObjectInput{Bucket: "photos", Key: "a.jpg", Size: 100}
+	if _, err := gw.PutObject(ctx, user, input, bytes.NewReader(original), func() error { return nil }); err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	// As in S3, a PUT whose body fails its checks leaves the object it would replace alone
+	input = &domain.PutObjectInput{Bucket: "photos", Key: "a.jpg", Size: 60}
+	_, err = gw.PutObject(ctx, user, input, bytes.NewReader(bytes.Repeat([]byte("b"), 60)), func() error { return fmt.Errorf("bad digest") })
+	if err == nil {
+		t.Fatal("PutObject with a body that did not verify succeeded")
+	}
+	reader, object, err := gw.GetObject(ctx, user, "photos", "a.jpg", -1, -1)
+	if err != nil {
+		t.Fatalf("GetObject after the rejected PUT: %v", err)
+	}
+	defer reader.Close()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("read object: %v", err)
+	}
+	if object.Size != 100 || !bytes.Equal(got, original) {
+		t.Errorf("object after the rejected PUT has %d bytes, want the original 100", len(got))
+	}
+	if used := users.usage(user.UserID); used != 100 {
+		t.Errorf("used storage after the rejected PUT = %d, want 100", used)
+	}
+
+	// Neither the object listing nor the usage recount sees the staged body
+	used, err := gw.UsedBytes(ctx, user.UserID)
+	if err != nil {
+		t.Fatalf("UsedBytes: %v", err)
+	}
+	if used != 100 {
+		t.Errorf("UsedBytes = %d, want 100", used)
+	}
+	staged, _, err := provider.ListObjects(ctx, objectKeyPrefix+"/"+user.UserID.String()+"/"+stagingDir+"/", nil)
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(staged) != 0 {
+		t.Errorf("%d staged bodies left behind", len(staged))
+	}
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// listPageSize is the page size of the backend listings behind ListObjects. It is fixed
+// so a continuation token can point into a backend page by position.
+const listPageSize = storagePort.DefaultListMaxKeys
+
+// listPosition is where a listing resumes: an entry of a backend page, and the common
+// prefix returned last so it is not returned again. Clients get it as an opaque token.
+type listPosition struct {
+	Token  string `json:"t,omitempty"` // Continuation token of the backend page
+	Skip   int    `json:"s,omitempty"` // Entries of that page already listed
+	Prefix string `json:"p,omitempty"` // Last common prefix returned
+}
+
+// ListObjects returns one page of the user's objects in a bucket, rolling keys up into
+// common prefixes at the delimiter. Keys are listed in the backend's order, which is
+// lexicographic for the local backend and the S3-compatible ones.
+func (g *gateway) ListObjects(ctx context.Context, user *domain.Credential, input *domain.ListObjectsInput) (*domain.ListObjectsOutput, error) {
+	provider, err := g.provider(input.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	position := listPosition{}
+	if input.ContinuationToken != "" {
+		if position, err = decodeListPosition(input.ContinuationToken); err != nil {
+			return nil, err
+		}
+	}
+	root := bucketPrefix(user, input.Bucket)
+
+	output := &domain.ListObjectsOutput{}
+	count := 0
+	for {
+		objects, next, err := provider.ListObjects(ctx, root+input.Prefix, &storagePort.ListOptions{
+			MaxKeys:           listPageSize,
+			ContinuationToken: position.Token,
+		})
+		if err != nil {
+			return nil, g.storageError(ctx, err)
+		}
+
+		for i := position.Skip; i < len(objects); i++ {
+			key, ok := strings.CutPrefix(objects[i].Key, root)
+			if !ok || !strings.HasPrefix(key, input.Prefix) || (input.ContinuationToken == "" && input.StartAfter != "" && key <= input.StartAfter) {
+				continue
+			}
+			commonPrefix := ""
+			if input.Delimiter != "" {
+				if j := strings.Index(key[len(input.Prefix):], input.Delimiter); j >= 0 {
+					commonPrefix = key[:len(input.Prefix)+j+len(input.Delimiter)]
+					if commonPrefix == position.Prefix {
+						continue
+					}
+				}
+			}
+
+			if count == input.MaxKeys {
+				output.IsTruncated = true
+				output.NextContinuationToken = encodeListPosition(listPosition{Token: position.Token, Skip: i, Prefix: position.Prefix})
+				return output, nil
+			}
+			count++
+			if commonPrefix != "" {
+				output.CommonPrefixes = append(output.CommonPrefixes, commonPrefix)
+				position.Prefix = commonPrefix
+				continue
+			}
+			output.Objects = append(output.Objects, *toObject(key, objects[i]))
+		}
+
+		if next == "" {
+			return output, nil
+		}
+		position.Token, position.Skip = next, 0
+	}
+}
+
+func encodeListPosition(position listPosition) string {
+	data, _ := json.Marshal(position)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListPosition(token string) (listPosition, error) {
+	var position listPosition
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err == nil {
+		err = json.Unmarshal(data, &position)
+	}
+	if err != nil || position.Skip < 0 {
+		return listPosition{}, domain.ErrInvalidArgument.WithMessage("The continuation token provided is incorrect.")
+	}
+	return position, nil
+}
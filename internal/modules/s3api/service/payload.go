@@ -0,0 +1,316 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+)
+
+const (
+	// maxChunkLine bounds an aws-chunked chunk header or trailer line
+	maxChunkLine = 4096
+	// checksumHeaderPrefix starts the headers and trailers carrying a body checksum
+	checksumHeaderPrefix = "x-amz-checksum-"
+)
+
+// checksumAlgorithms are the x-amz-checksum-* algorithms verified on upload. Others,
+// such as crc64nvme, are accepted without being checked.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+}
+
+// digest is a hash of the decoded body and the value it must have
+type digest struct {
+	hash     hash.Hash
+	expected func() string // Read once the body has been read, as trailers come last
+	encode   func([]byte) string
+	err      *domain.Error
+}
+
+// payloadReader counts and hashes the decoded body of an upload as it is read.
+type payloadReader struct {
+	r       io.Reader
+	size    int64
+	read    int64
+	digests []*digest
+	err     error // First read error, returned again by verify
+}
+
+func newPayloadReader(auth *domain.Authorization, req *domain.SignedRequest, body io.Reader) (io.Reader, int64, func() error, error) {
+	p := &payloadReader{r: body, size: req.ContentLength}
+
+	var chunked *chunkedReader
+	switch auth.PayloadHash {
+	case streamingPayload, streamingPayloadTrailer, streamingUnsignedTrailer:
+		size, err := strconv.ParseInt(req.Header("X-Amz-Decoded-Content-Length"), 10, 64)
+		if err != nil || size < 0 {
+			return nil, 0, nil, domain.ErrMissingContentLength.WithMessage("You must provide the x-amz-decoded-content-length header with an aws-chunked body.")
+		}
+		p.size = size
+		chunked = &chunkedReader{r: bufio.NewReaderSize(body, maxChunkLine), trailers: map[string]string{}}
+		if auth.PayloadHash != streamingUnsignedTrailer {
+			chunked.auth, chunked.prevSignature = auth, auth.Signature
+		}
+		chunked.trailer = auth.PayloadHash != streamingPayload
+		p.r = chunked
+	case unsignedPayload:
+	default:
+		expected := auth.PayloadHash
+		p.digests = append(p.digests, &digest{
+			hash:     sha256.New(),
+			expected: func() string { return expected },
+			encode:   hex.EncodeToString,
+			err:      domain.ErrContentSHA256Mismatch,
+		})
+	}
+	if p.size < 0 {
+		return nil, 0, nil, domain.ErrMissingContentLength
+	}
+
+	if contentMD5 := req.Header("Content-MD5"); contentMD5 != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(contentMD5); err != nil || len(decoded) != md5.Size {
+			return nil, 0, nil, domain.ErrInvalidDigest
+		}
+		p.digests = append(p.digests, &digest{
+			hash:     md5.New(),
+			expected: func() string { return contentMD5 },
+			encode:   base64.StdEncoding.EncodeToString,
+			err:      domain.ErrBadDigest,
+		})
+	}
+	for algorithm, newHash := range checksumAlgorithms {
+		name := checksumHeaderPrefix + algorithm
+		expected := func() string { return req.Header(name) }
+		if chunked != nil && strings.EqualFold(strings.TrimSpace(req.Header("X-Amz-Trailer")), name) {
+			expected = func() string { return chunked.trailers[name] }
+		} else if expected() == "" {
+			continue
+		}
+		p.digests = append(p.digests, &digest{
+			hash:     newHash(),
+			expected: expected,
+			encode:   base64.StdEncoding.EncodeToString,
+			err:      domain.ErrBadDigest.WithMessage("The " + strings.ToUpper(algorithm) + " you specified did not match the calculated checksum."),
+		})
+	}
+
+	return p, p.size, p.verify, nil
+}
+
+func (p *payloadReader) Read(b []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+	n, err := p.r.Read(b)
+	if n > 0 {
+		if p.read+int64(n) > p.size {
+			p.err = domain.ErrIncompleteBody.WithMessage("The body is longer than its declared length.")
+			return 0, p.err
+		}
+		p.read += int64(n)
+		for _, d := range p.digests {
+			d.hash.Write(b[:n])
+		}
+	}
+	if err == io.EOF && p.read < p.size {
+		err = domain.ErrIncompleteBody
+	}
+	if err != nil && err != io.EOF {
+		p.err = err
+	}
+	return n, err
+}
+
+// verify reads what is left of the body, so trailers are parsed, and checks it against
+// its declared length, signatures and digests.
+func (p *payloadReader) verify() error {
+	if _, err := io.Copy(io.Discard, p); err != nil {
+		var s3Err *domain.Error
+		if errors.As(err, &s3Err) {
+			return s3Err
+		}
+		return domain.ErrIncompleteBody.WithMessage("The body could not be read: " + err.Error())
+	}
+	for _, d := range p.digests {
+		if d.encode(d.hash.Sum(nil)) != d.expected() {
+			return d.err
+		}
+	}
+	return nil
+}
+
+// chunkedReader decodes an aws-chunked body, checking the signature of each chunk and
+// of the trailers when the body is signed.
+type chunkedReader struct {
+	r             *bufio.Reader
+	auth          *domain.Authorization // Nil for unsigned chunks
+	trailer       bool                  // Trailers follow the last chunk
+	trailers      map[string]string     // Trailer values by lower case name, once read
+	prevSignature string
+
+	remaining int64     // Unread bytes of the current chunk
+	signature string    // Signature of the current chunk
+	chunkHash hash.Hash // SHA-256 of the current chunk, for signed bodies
+	started   bool
+	done      bool
+}
+
+func (c *chunkedReader) Read(b []byte) (int, error) {
+	for c.remaining == 0 {
+		if c.done {
+			return 0, io.EOF
+		}
+		if err := c.nextChunk(); err != nil {
+			return 0, err
+		}
+	}
+	if int64(len(b)) > c.remaining {
+		b = b[:c.remaining]
+	}
+	n, err := c.r.Read(b)
+	c.remaining -= int64(n)
+	if c.chunkHash != nil {
+		c.chunkHash.Write(b[:n])
+	}
+	if err == io.EOF {
+		err = domain.ErrIncompleteBody
+	}
+	return n, err
+}
+
+// nextChunk finishes the current chunk and reads the header of the next one, or the
+// trailers after the last chunk.
+func (c *chunkedReader) nextChunk() error {
+	if c.started {
+		if line, err := c.readLine(); err != nil || line != "" {
+			return chunkMalformed(err)
+		}
+		if err := c.checkChunkSignature(); err != nil {
+			return err
+		}
+	}
+	c.started = true
+
+	line, err := c.readLine()
+	if err != nil {
+		return chunkMalformed(err)
+	}
+	sizeHex, extension, _ := strings.Cut(line, ";")
+	size, err := strconv.ParseInt(sizeHex, 16, 64)
+	if err != nil || size < 0 {
+		return chunkMalformed(nil)
+	}
+	if c.auth != nil {
+		signature, ok := strings.CutPrefix(extension, "chunk-signature=")
+		if !ok {
+			return chunkMalformed(nil)
+		}
+		c.signature, c.chunkHash = signature, sha256.New()
+	}
+	c.remaining = size
+	if size > 0 {
+		return nil
+	}
+
+	// The last chunk is empty and also signed
+	if err := c.checkChunkSignature(); err != nil {
+		return err
+	}
+	c.done = true
+	if !c.trailer {
+		if line, err := c.readLine(); err != nil || line != "" {
+			return chunkMalformed(err)
+		}
+		return nil
+	}
+	return c.readTrailers()
+}
+
+// readTrailers reads the trailer headers up to the empty line ending the body.
+func (c *chunkedReader) readTrailers() error {
+	var signed bytes.Buffer
+	trailerSignature := ""
+	for {
+		line, err := c.readLine()
+		if err == io.EOF && line == "" {
+			break // Some clients leave out the final CRLF
+		}
+		if err != nil {
+			return chunkMalformed(err)
+		}
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return chunkMalformed(nil)
+		}
+		name, value = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(value)
+		if name == "x-amz-trailer-signature" {
+			trailerSignature = value
+			continue
+		}
+		c.trailers[name] = value
+		signed.WriteString(name + ":" + value + "\n")
+	}
+
+	if c.auth == nil {
+		return nil
+	}
+	expected := hex.EncodeToString(hmacSHA256(c.auth.SigningKey, strings.Join([]string{
+		"AWS4-HMAC-SHA256-TRAILER", c.auth.Date.UTC().Format(amzDateFormat), c.auth.Scope,
+		c.prevSignature, sha256Hex(signed.Bytes()),
+	}, "\n")))
+	if trailerSignature != expected {
+		return domain.ErrSignatureDoesNotMatch.WithMessage("The trailer signature does not match.")
+	}
+	return nil
+}
+
+// checkChunkSignature checks the signature of the chunk just read, which chains on the
+// signature of the one before it.
+func (c *chunkedReader) checkChunkSignature() error {
+	if c.auth == nil {
+		return nil
+	}
+	expected := hex.EncodeToString(hmacSHA256(c.auth.SigningKey, strings.Join([]string{
+		"AWS4-HMAC-SHA256-PAYLOAD", c.auth.Date.UTC().Format(amzDateFormat), c.auth.Scope,
+		c.prevSignature, emptySHA256, hex.EncodeToString(c.chunkHash.Sum(nil)),
+	}, "\n")))
+	if c.signature != expected {
+		return domain.ErrSignatureDoesNotMatch.WithMessage("The chunk signature does not match.")
+	}
+	c.prevSignature = expected
+	return nil
+}
+
+// readLine reads a CRLF-terminated line without its line ending.
+func (c *chunkedReader) readLine() (string, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		return string(line), err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(line), "\n"), "\r"), nil
+}
+
+func chunkMalformed(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return domain.ErrIncompleteBody
+	}
+	return domain.ErrInvalidRequest.WithMessage("The aws-chunked body is malformed.")
+}
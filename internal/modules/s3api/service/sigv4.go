@@ -0,0 +1,246 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/modules/s3api/domain"
+	"github.com/lugondev/m3-storage/internal/modules/s3api/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	amzDateFormat  = "20060102T150405Z"
+	scopeDate      = "20060102"
+	scopeService   = "s3"
+	scopeTerminal  = "aws4_request"
+
+	// maxClockSkew is how far the signing time may be from the server's, as in S3
+	maxClockSkew = 15 * time.Minute
+
+	// Values of x-amz-content-sha256 other than the SHA-256 of the body
+	unsignedPayload          = "UNSIGNED-PAYLOAD"
+	streamingPayload         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	streamingPayloadTrailer  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	// emptySHA256 is the SHA-256 of no bytes
+	emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// SigV4Verifier authenticates requests signed with AWS Signature Version 4 in the
+// Authorization header. Presigned URLs and Signature Version 2 are not supported.
+type SigV4Verifier struct {
+	region      string
+	credentials map[string]*domain.Credential // By access key ID
+	clock       clock.Clock
+}
+
+var _ port.Authenticator = (*SigV4Verifier)(nil)
+
+// NewSigV4Verifier creates a verifier accepting signatures for region made with one of
+// credentials.
+func NewSigV4Verifier(region string, credentials []*domain.Credential, clk clock.Clock) *SigV4Verifier {
+	byKey := make(map[string]*domain.Credential, len(credentials))
+	for _, credential := range credentials {
+		byKey[credential.AccessKeyID] = credential
+	}
+	return &SigV4Verifier{region: region, credentials: byKey, clock: clk}
+}
+
+// Authenticate checks the signature of req and returns the credential that made it.
+func (v *SigV4Verifier) Authenticate(req *domain.SignedRequest) (*domain.Authorization, error) {
+	header := req.Header("Authorization")
+	switch {
+	case header == "" && strings.Contains(req.Query, "X-Amz-Signature="):
+		return nil, domain.ErrAccessDenied.WithMessage("Presigned URLs are not supported; sign requests in the Authorization header.")
+	case header == "":
+		return nil, domain.ErrAccessDenied.WithMessage("Anonymous access is not allowed.")
+	case strings.HasPrefix(header, "AWS "):
+		return nil, domain.ErrInvalidRequest.WithMessage("Signature Version 2 is not supported; sign requests with " + sigV4Algorithm + ".")
+	case !strings.HasPrefix(header, sigV4Algorithm+" "):
+		return nil, domain.ErrAuthorizationMalformed.WithMessage("Unsupported authorization type.")
+	}
+
+	fields := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(header, sigV4Algorithm+" "), ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return nil, domain.ErrAuthorizationMalformed
+		}
+		fields[name] = value
+	}
+	scope := strings.Split(fields["Credential"], "/")
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	signature := fields["Signature"]
+	if len(scope) != 5 || fields["SignedHeaders"] == "" || signature == "" {
+		return nil, domain.ErrAuthorizationMalformed
+	}
+	if scope[2] != v.region {
+		return nil, domain.ErrAuthorizationMalformed.WithMessage("The authorization header is malformed; the region '" + scope[2] + "' is wrong; expecting '" + v.region + "'.")
+	}
+	if scope[3] != scopeService || scope[4] != scopeTerminal {
+		return nil, domain.ErrAuthorizationMalformed.WithMessage("The authorization header is malformed; the credential scope must end in " + scopeService + "/" + scopeTerminal + ".")
+	}
+	if !slices.Contains(signedHeaders, "host") {
+		return nil, domain.ErrAccessDenied.WithMessage("The host header must be signed.")
+	}
+
+	credential, ok := v.credentials[scope[0]]
+	if !ok {
+		return nil, domain.ErrInvalidAccessKeyID
+	}
+
+	amzDate := req.Header("X-Amz-Date")
+	signedAt, err := time.Parse(amzDateFormat, amzDate)
+	if err != nil {
+		if signedAt, err = http.ParseTime(req.Header("Date")); err != nil {
+			return nil, domain.ErrAccessDenied.WithMessage("AWS authentication requires a valid Date or x-amz-date header.")
+		}
+		amzDate = signedAt.UTC().Format(amzDateFormat)
+	}
+	if signedAt.UTC().Format(scopeDate) != scope[1] {
+		return nil, domain.ErrAuthorizationMalformed.WithMessage("The authorization header is malformed; the credential date does not match the request date.")
+	}
+	if skew := v.clock.Now().Sub(signedAt); skew > maxClockSkew || skew < -maxClockSkew {
+		return nil, domain.ErrRequestTimeTooSkewed
+	}
+
+	payloadHash := req.Header("X-Amz-Content-Sha256")
+	switch payloadHash {
+	case unsignedPayload, streamingPayload, streamingPayloadTrailer, streamingUnsignedTrailer:
+	case "":
+		return nil, domain.ErrInvalidRequest.WithMessage("Missing required header for this request: x-amz-content-sha256.")
+	default:
+		if decoded, err := hex.DecodeString(payloadHash); err != nil || len(decoded) != sha256.Size {
+			return nil, domain.ErrInvalidArgument.WithMessage("x-amz-content-sha256 must be UNSIGNED-PAYLOAD, a streaming payload mode or the SHA-256 of the body.")
+		}
+	}
+
+	canonicalRequest, err := canonicalRequest(req, signedHeaders, payloadHash)
+	if err != nil {
+		return nil, err
+	}
+	credentialScope := strings.Join(scope[1:], "/")
+	signingKey := deriveSigningKey(credential.SecretAccessKey, scope[1], v.region)
+	expected := hex.EncodeToString(hmacSHA256(signingKey, strings.Join([]string{
+		sigV4Algorithm, amzDate, credentialScope, sha256Hex([]byte(canonicalRequest)),
+	}, "\n")))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, domain.ErrSignatureDoesNotMatch
+	}
+
+	return &domain.Authorization{
+		Credential:  credential,
+		PayloadHash: payloadHash,
+		Date:        signedAt,
+		Scope:       credentialScope,
+		Signature:   expected,
+		SigningKey:  signingKey,
+	}, nil
+}
+
+// Payload decodes the body of an authorized request and checks it against the request's
+// payload hash or chunk signatures, Content-MD5 and x-amz-checksum-* values.
+func (v *SigV4Verifier) Payload(auth *domain.Authorization, req *domain.SignedRequest, body io.Reader) (io.Reader, int64, func() error, error) {
+	return newPayloadReader(auth, req, body)
+}
+
+// canonicalRequest builds the SigV4 canonical request of req.
+func canonicalRequest(req *domain.SignedRequest, signedHeaders []string, payloadHash string) (string, error) {
+	path, err := url.PathUnescape(req.Path)
+	if err != nil {
+		return "", domain.ErrInvalidArgument.WithMessage("The request path is not correctly percent-encoded.")
+	}
+	if path == "" {
+		path = "/"
+	}
+
+	type param struct{ name, value string }
+	var params []param
+	for _, pair := range strings.Split(req.Query, "&") {
+		if pair == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(pair, "=")
+		name, errName := url.QueryUnescape(name)
+		value, errValue := url.QueryUnescape(value)
+		if errName != nil || errValue != nil {
+			return "", domain.ErrInvalidArgument.WithMessage("The query string is not correctly percent-encoded.")
+		}
+		params = append(params, param{uriEncode(name, false), uriEncode(value, false)})
+	}
+	slices.SortFunc(params, func(a, b param) int {
+		if c := strings.Compare(a.name, b.name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.value, b.value)
+	})
+	query := make([]string, len(params))
+	for i, p := range params {
+		query[i] = p.name + "=" + p.value
+	}
+
+	var headers strings.Builder
+	for _, name := range signedHeaders {
+		headers.WriteString(name)
+		headers.WriteByte(':')
+		headers.WriteString(strings.Join(strings.Fields(req.Header(name)), " "))
+		headers.WriteByte('\n')
+	}
+
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(path, true),
+		strings.Join(query, "&"),
+		headers.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n"), nil
+}
+
+// uriEncode percent-encodes s as SigV4 requires: every byte but the unreserved
+// characters, and '/' when encoding a path.
+func uriEncode(s string, path bool) string {
+	const upperHex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', path && c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(upperHex[c>>4])
+			b.WriteByte(upperHex[c&15])
+		}
+	}
+	return b.String()
+}
+
+func deriveSigningKey(secret, date, region string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, scopeService)
+	return hmacSHA256(key, scopeTerminal)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -19,6 +19,10 @@ const (
 	ProviderScaleway     StorageProviderType = "scaleway"
 	ProviderBackBlaze    StorageProviderType = "backblaze"
 	ProviderMinIO        StorageProviderType = "minio"
+	ProviderOSS          StorageProviderType = "oss"
+	ProviderTelegram     StorageProviderType = "telegram"
+	ProviderDigitalOcean StorageProviderType = "digitalocean"
+	ProviderWasabi       StorageProviderType = "wasabi"
 )
 
 // FileObject represents a file stored in the storage system
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // HealthCheckRequest represents the request for checking storage provider health
 type HealthCheckRequest struct {
 	ProviderType string `json:"provider_type" validate:"required" example:"s3"`
@@ -9,21 +11,25 @@ type HealthCheckRequest struct {
 type HealthCheckResponse struct {
 	Status  string `json:"status" example:"healthy"`
 	Message string `json:"message,omitempty" example:""`
+	Circuit string `json:"circuit,omitempty" example:"closed"` // Circuit breaker state (closed, open, half-open) when breakers are enabled
 }
 
 // HealthCheckAllResponse represents the response for all providers health check
 type HealthCheckAllResponse struct {
 	Providers map[string]HealthCheckResponse `json:"providers"`
+	CheckedAt time.Time                      `json:"checked_at"` // When the providers were checked; older than the request when served from cache
 }
 
-// ProviderInfo represents information about a storage provider
+// ProviderInfo represents a storage backend and its provider type
 type ProviderInfo struct {
+	Backend     string `json:"backend" example:"s3-archive"` // Backend name used as the upload provider; the default backend of a type is named after it
 	Type        string `json:"type" example:"s3"`
 	Name        string `json:"name" example:"Amazon S3"`
 	Description string `json:"description" example:"Amazon Simple Storage Service"`
 }
 
-// ListProvidersResponse represents the response for listing available providers
+// ListProvidersResponse represents the response for listing available storage backends
 type ListProvidersResponse struct {
-	Providers []ProviderInfo `json:"providers"`
+	Providers []ProviderInfo    `json:"providers"`
+	Aliases   map[string]string `json:"aliases,omitempty" example:"primary:s3"` // Client-facing alias -> backend name
 }
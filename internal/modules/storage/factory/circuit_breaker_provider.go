@@ -0,0 +1,166 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+	"github.com/sony/gobreaker"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerCooldown         = 30 * time.Second
+)
+
+// newProviderBreaker creates the circuit breaker shared by every provider instance of
+// the named backend. It opens after cfg.FailureThreshold consecutive failures.
+func newProviderBreaker(name string, cfg config.CircuitBreakerConfig, log logger.Logger) *gobreaker.CircuitBreaker {
+	threshold := uint32(defaultBreakerFailureThreshold)
+	if cfg.FailureThreshold > 0 {
+		threshold = uint32(cfg.FailureThreshold)
+	}
+	cooldown := defaultBreakerCooldown
+	if cfg.CooldownSeconds > 0 {
+		cooldown = time.Duration(cfg.CooldownSeconds) * time.Second
+	}
+
+	return gobreaker.NewCircuitBreaker(gobreaker.Settings{
+		Name:        name,
+		MaxRequests: uint32(max(cfg.HalfOpenMaxRequests, 1)),
+		Timeout:     cooldown,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= threshold
+		},
+		IsSuccessful: isBreakerSuccess,
+		OnStateChange: func(name string, from, to gobreaker.State) {
+			log.Warnf(context.Background(), "Storage provider circuit breaker changed state", map[string]any{
+				"provider": name, "from": from.String(), "to": to.String(),
+			})
+		},
+	})
+}
+
+// isBreakerSuccess reports whether err leaves the provider's health untouched: errors
+// caused by the request itself, rather than by the backend, do not count as failures.
+func isBreakerSuccess(err error) bool {
+	return err == nil ||
+		errors.Is(err, port.ErrObjectNotFound) ||
+		errors.Is(err, port.ErrInvalidRange) ||
+		errors.Is(err, context.Canceled)
+}
+
+// circuitBreakerProvider decorates a StorageProvider so calls fail fast with
+// port.ErrCircuitOpen while the provider's circuit breaker is open.
+type circuitBreakerProvider struct {
+	port.StorageProvider
+	breaker *gobreaker.CircuitBreaker
+}
+
+var _ port.ProviderWrapper = (*circuitBreakerProvider)(nil)
+
+func (p *circuitBreakerProvider) Unwrap() port.StorageProvider {
+	return p.StorageProvider
+}
+
+// guard runs call through the breaker of p.
+func guard[T any](p *circuitBreakerProvider, call func() (T, error)) (T, error) {
+	result, err := p.breaker.Execute(func() (interface{}, error) {
+		return call()
+	})
+	if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+		var zero T
+		return zero, fmt.Errorf("%s provider rejected the call: %w", p.ProviderType(), port.ErrCircuitOpen)
+	}
+	value, _ := result.(T)
+	return value, err
+}
+
+func (p *circuitBreakerProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
+	return guard(p, func() (*port.FileObject, error) {
+		return p.StorageProvider.Upload(ctx, key, reader, size, opts)
+	})
+}
+
+func (p *circuitBreakerProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
+	return guard(p, func() (string, error) {
+		return p.StorageProvider.GetSignedURL(ctx, key, duration)
+	})
+}
+
+func (p *circuitBreakerProvider) Delete(ctx context.Context, key string) error {
+	_, err := guard(p, func() (struct{}, error) {
+		return struct{}{}, p.StorageProvider.Delete(ctx, key)
+	})
+	return err
+}
+
+func (p *circuitBreakerProvider) Exists(ctx context.Context, key string) (bool, error) {
+	return guard(p, func() (bool, error) {
+		return p.StorageProvider.Exists(ctx, key)
+	})
+}
+
+func (p *circuitBreakerProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
+	return guard(p, func() (*port.FileObject, error) {
+		return p.StorageProvider.GetObject(ctx, key)
+	})
+}
+
+func (p *circuitBreakerProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
+	var obj *port.FileObject
+	body, err := guard(p, func() (io.ReadCloser, error) {
+		body, info, err := p.StorageProvider.Download(ctx, key)
+		obj = info
+		return body, err
+	})
+	return body, obj, err
+}
+
+func (p *circuitBreakerProvider) DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *port.FileObject, error) {
+	var obj *port.FileObject
+	body, err := guard(p, func() (io.ReadCloser, error) {
+		body, info, err := p.StorageProvider.DownloadRange(ctx, key, start, end)
+		obj = info
+		return body, err
+	})
+	return body, obj, err
+}
+
+func (p *circuitBreakerProvider) Copy(ctx context.Context, srcKey, dstKey string) (*port.FileObject, error) {
+	return guard(p, func() (*port.FileObject, error) {
+		return p.StorageProvider.Copy(ctx, srcKey, dstKey)
+	})
+}
+
+func (p *circuitBreakerProvider) Move(ctx context.Context, srcKey, dstKey string) error {
+	_, err := guard(p, func() (struct{}, error) {
+		return struct{}{}, p.StorageProvider.Move(ctx, srcKey, dstKey)
+	})
+	return err
+}
+
+func (p *circuitBreakerProvider) ListObjects(ctx context.Context, prefix string, opts *port.ListOptions) ([]*port.FileObject, string, error) {
+	var token string
+	objects, err := guard(p, func() ([]*port.FileObject, error) {
+		objects, next, err := p.StorageProvider.ListObjects(ctx, prefix, opts)
+		token = next
+		return objects, err
+	})
+	return objects, token, err
+}
+
+// CheckHealth goes through the breaker too, so an open circuit answers health checks
+// immediately and a successful check in the half-open state closes it again.
+func (p *circuitBreakerProvider) CheckHealth(ctx context.Context) error {
+	_, err := guard(p, func() (struct{}, error) {
+		return struct{}{}, p.StorageProvider.CheckHealth(ctx)
+	})
+	return err
+}
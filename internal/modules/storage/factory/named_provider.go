@@ -0,0 +1,26 @@
+package factory
+
+import "github.com/lugondev/m3-storage/internal/modules/storage/port"
+
+// namedProvider records the storage backend a provider was built for, so callers can
+// store the backend name with media rather than the provider type, which several
+// backends may share.
+type namedProvider struct {
+	port.StorageProvider
+	name string
+}
+
+var (
+	_ port.ProviderWrapper = (*namedProvider)(nil)
+	_ port.NamedBackend    = (*namedProvider)(nil)
+)
+
+// BackendName implements port.NamedBackend.
+func (p *namedProvider) BackendName() string {
+	return p.name
+}
+
+// Unwrap implements port.ProviderWrapper.
+func (p *namedProvider) Unwrap() port.StorageProvider {
+	return p.StorageProvider
+}
@@ -0,0 +1,196 @@
+package factory
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	alioss "github.com/aliyun/aliyun-oss-go-sdk/oss"
+	logger "github.com/lugondev/go-log"
+	miniogo "github.com/minio/minio-go/v7"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// RetryingProvider decorates a StorageProvider and retries Upload, Download, Delete and
+// GetObject when they fail with a transient error. The remaining methods are passed
+// through unchanged.
+type RetryingProvider struct {
+	port.StorageProvider
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	logger      logger.Logger
+}
+
+var _ port.ProviderWrapper = (*RetryingProvider)(nil)
+
+// NewRetryingProvider wraps provider with the retry policy in cfg.
+func NewRetryingProvider(provider port.StorageProvider, cfg config.RetryConfig, log logger.Logger) *RetryingProvider {
+	p := &RetryingProvider{
+		StorageProvider: provider,
+		maxAttempts:     max(cfg.MaxAttempts, 1),
+		baseDelay:       time.Duration(cfg.BaseDelayMs) * time.Millisecond,
+		maxDelay:        time.Duration(cfg.MaxDelayMs) * time.Millisecond,
+		logger:          log,
+	}
+	if p.baseDelay <= 0 {
+		p.baseDelay = defaultRetryBaseDelay
+	}
+	if p.maxDelay <= 0 {
+		p.maxDelay = defaultRetryMaxDelay
+	}
+	return p
+}
+
+// Unwrap returns the decorated provider.
+func (p *RetryingProvider) Unwrap() port.StorageProvider {
+	return p.StorageProvider
+}
+
+// Upload retries only when the reader can be rewound to where the first attempt
+// started; otherwise the bytes already consumed would be lost.
+func (p *RetryingProvider) Upload(ctx context.Context, key string, reader io.Reader, size int64, opts *port.UploadOptions) (*port.FileObject, error) {
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return p.StorageProvider.Upload(ctx, key, reader, size, opts)
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return p.StorageProvider.Upload(ctx, key, reader, size, opts)
+	}
+
+	var obj *port.FileObject
+	err = p.do(ctx, "Upload", key, func(attempt int) error {
+		if attempt > 1 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return err
+			}
+		}
+		var err error
+		obj, err = p.StorageProvider.Upload(ctx, key, reader, size, opts)
+		return err
+	})
+	return obj, err
+}
+
+// Download retries opening the stream; errors while reading it are the caller's.
+func (p *RetryingProvider) Download(ctx context.Context, key string) (io.ReadCloser, *port.FileObject, error) {
+	var (
+		body io.ReadCloser
+		obj  *port.FileObject
+	)
+	err := p.do(ctx, "Download", key, func(int) error {
+		var err error
+		body, obj, err = p.StorageProvider.Download(ctx, key)
+		return err
+	})
+	return body, obj, err
+}
+
+func (p *RetryingProvider) Delete(ctx context.Context, key string) error {
+	return p.do(ctx, "Delete", key, func(int) error {
+		return p.StorageProvider.Delete(ctx, key)
+	})
+}
+
+func (p *RetryingProvider) GetObject(ctx context.Context, key string) (*port.FileObject, error) {
+	var obj *port.FileObject
+	err := p.do(ctx, "GetObject", key, func(int) error {
+		var err error
+		obj, err = p.StorageProvider.GetObject(ctx, key)
+		return err
+	})
+	return obj, err
+}
+
+// do runs call until it succeeds, fails with a permanent error, runs out of attempts
+// or the next backoff would outlive ctx. The last error is returned as is.
+func (p *RetryingProvider) do(ctx context.Context, op, key string, call func(attempt int) error) error {
+	for attempt := 1; ; attempt++ {
+		err := call(attempt)
+		if err == nil || attempt >= p.maxAttempts || ctx.Err() != nil || !isRetryable(err) {
+			return err
+		}
+
+		delay := p.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+		p.logger.Warnf(ctx, "Retrying storage provider call after transient error", map[string]any{
+			"provider": string(p.ProviderType()), "operation": op, "key": key,
+			"attempt": attempt, "delay": delay.String(), "error": err,
+		})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns the delay after the given failed attempt: exponential growth from
+// baseDelay, capped at maxDelay, with full jitter so concurrent callers spread out.
+func (p *RetryingProvider) backoff(attempt int) time.Duration {
+	delay := p.maxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := p.baseDelay << shift; d > 0 && d < p.maxDelay {
+			delay = d
+		}
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// isRetryable reports whether err is worth another attempt: network timeouts, dropped
+// connections, and 429 or 5xx responses from any of the SDKs the adapters use.
+func isRetryable(err error) bool {
+	if errors.Is(err, port.ErrObjectNotFound) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// AWS SDK response errors (S3 and the S3-compatible providers) and Telegram API errors
+	var statusErr interface{ HTTPStatusCode() int }
+	if errors.As(err, &statusErr) {
+		return isRetryableStatus(statusErr.HTTPStatusCode())
+	}
+	var azureErr *azcore.ResponseError
+	if errors.As(err, &azureErr) {
+		return isRetryableStatus(azureErr.StatusCode)
+	}
+	var minioErr miniogo.ErrorResponse
+	if errors.As(err, &minioErr) {
+		return isRetryableStatus(minioErr.StatusCode)
+	}
+	var ossErr alioss.ServiceError
+	if errors.As(err, &ossErr) {
+		return isRetryableStatus(ossErr.StatusCode)
+	}
+	return false
+}
+
+func isRetryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
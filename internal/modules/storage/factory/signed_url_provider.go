@@ -0,0 +1,62 @@
+package factory
+
+import (
+	"context"
+	"time"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+// signedURLProvider decorates a StorageProvider with the signed URL policy from its
+// config, so requests without a validity get the default and long ones are clamped.
+type signedURLProvider struct {
+	port.StorageProvider
+	defaultExpiry time.Duration
+	maxExpiry     time.Duration
+}
+
+var (
+	_ port.ProviderWrapper    = (*signedURLProvider)(nil)
+	_ port.SignedURLDefaulter = (*signedURLProvider)(nil)
+)
+
+// newSignedURLProvider wraps provider with cfg, filling unset values with the port defaults.
+// A default above the maximum is lowered to it.
+func newSignedURLProvider(provider port.StorageProvider, cfg config.SignedURLConfig) *signedURLProvider {
+	maxExpiry := cfg.MaxExpiry
+	if maxExpiry <= 0 {
+		maxExpiry = port.DefaultMaxSignedURLExpiry
+	}
+	defaultExpiry := cfg.DefaultExpiry
+	if defaultExpiry <= 0 {
+		defaultExpiry = port.DefaultSignedURLExpiry
+	}
+	return &signedURLProvider{
+		StorageProvider: provider,
+		defaultExpiry:   min(defaultExpiry, maxExpiry),
+		maxExpiry:       maxExpiry,
+	}
+}
+
+func (p *signedURLProvider) Unwrap() port.StorageProvider {
+	return p.StorageProvider
+}
+
+// SignedURLExpiry implements port.SignedURLDefaulter.
+func (p *signedURLProvider) SignedURLExpiry(requested time.Duration) time.Duration {
+	if requested <= 0 {
+		return p.defaultExpiry
+	}
+	return min(requested, p.maxExpiry)
+}
+
+// GetSignedURL signs key for duration, clamped to the configured maximum.
+func (p *signedURLProvider) GetSignedURL(ctx context.Context, key string, duration time.Duration) (string, error) {
+	return p.StorageProvider.GetSignedURL(ctx, key, p.SignedURLExpiry(duration))
+}
+
+// GetSignedURLDefault implements port.SignedURLDefaulter.
+func (p *signedURLProvider) GetSignedURLDefault(ctx context.Context, key string) (string, error) {
+	return p.StorageProvider.GetSignedURL(ctx, key, p.defaultExpiry)
+}
@@ -2,6 +2,9 @@ package factory
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"sync"
 
 	logger "github.com/lugondev/go-log"
 	"github.com/lugondev/m3-storage/internal/adapters/azure"
@@ -9,53 +12,261 @@ import (
 	"github.com/lugondev/m3-storage/internal/adapters/firebase"
 	"github.com/lugondev/m3-storage/internal/adapters/local"
 	"github.com/lugondev/m3-storage/internal/adapters/minio"
+	"github.com/lugondev/m3-storage/internal/adapters/oss"
 	"github.com/lugondev/m3-storage/internal/adapters/s3"
+	"github.com/lugondev/m3-storage/internal/adapters/telegram"
 	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+	"github.com/sony/gobreaker"
 )
 
 type storageFactory struct {
-	config *config.Config
-	logger logger.Logger
+	config   *config.Config
+	logger   logger.Logger
+	clock    clock.Clock
+	index    port.ObjectIndex
+	aliases  map[string]string
+	breakers map[string]*gobreaker.CircuitBreaker // nil when circuit breakers are disabled
+	// providers caches built providers as *providerEntry keyed by backend name
+	providers sync.Map
+}
+
+// providerEntry builds its provider at most once, however many callers ask for it concurrently.
+type providerEntry struct {
+	once     sync.Once
+	provider port.StorageProvider
+	err      error
 }
 
 // NewStorageFactory creates a new instance of StorageFactory.
-func NewStorageFactory(cfg *config.Config, log logger.Logger) port.StorageFactory {
+// Configured provider aliases are validated here so misconfiguration fails at startup.
+// index records uploads for providers that cannot look objects up by key; clk dates the
+// signed URLs of providers that sign them themselves.
+func NewStorageFactory(cfg *config.Config, log logger.Logger, index port.ObjectIndex, clk clock.Clock) (port.StorageFactory, error) {
+	aliases, err := buildProviderAliases(cfg, cfg.Storage.Aliases)
+	if err != nil {
+		return nil, err
+	}
+
+	// Providers are created per call, so breakers live here to keep state across calls
+	var breakers map[string]*gobreaker.CircuitBreaker
+	if cfg.Storage.CircuitBreaker.Enabled {
+		backends := cfg.Backends()
+		breakers = make(map[string]*gobreaker.CircuitBreaker, len(backends))
+		for _, backend := range backends {
+			breakers[backend.Name] = newProviderBreaker(backend.Name, cfg.Storage.CircuitBreaker, log)
+		}
+	}
+
 	return &storageFactory{
-		config: cfg,
-		logger: log,
+		config:   cfg,
+		logger:   log,
+		clock:    clk,
+		index:    index,
+		aliases:  aliases,
+		breakers: breakers,
+	}, nil
+}
+
+// buildProviderAliases validates the alias config and normalises it into backend names.
+func buildProviderAliases(cfg *config.Config, raw map[string]string) (map[string]string, error) {
+	aliases := make(map[string]string, len(raw))
+	for alias, target := range raw {
+		name := strings.ToLower(strings.TrimSpace(alias))
+		backendName := strings.ToLower(strings.TrimSpace(target))
+
+		if name == "" {
+			return nil, errors.New("storage alias name cannot be empty")
+		}
+		if _, ok := cfg.Backend(name); ok {
+			return nil, fmt.Errorf("storage alias %q shadows a storage backend", alias)
+		}
+		if _, ok := cfg.Backend(backendName); !ok {
+			return nil, fmt.Errorf("storage alias %q points to unknown storage backend %q", alias, target)
+		}
+		aliases[name] = backendName
+	}
+	return aliases, nil
+}
+
+// ResolveBackendName maps a configured alias to its backend name.
+func (f *storageFactory) ResolveBackendName(name string) string {
+	if backendName, ok := f.aliases[strings.ToLower(name)]; ok {
+		return backendName
+	}
+	return name
+}
+
+// BackendType returns the provider type of a backend or alias.
+func (f *storageFactory) BackendType(name string) (port.StorageProviderType, bool) {
+	backend, ok := f.config.Backend(f.ResolveBackendName(name))
+	if !ok {
+		return "", false
+	}
+	return port.StorageProviderType(backend.Type), true
+}
+
+// Aliases returns a copy of the configured provider aliases.
+func (f *storageFactory) Aliases() map[string]string {
+	aliases := make(map[string]string, len(f.aliases))
+	for alias, backendName := range f.aliases {
+		aliases[alias] = backendName
+	}
+	return aliases
+}
+
+// Enabled reports whether a backend or alias is usable in this deployment, as set in
+// storage.providers or inferred from its required settings.
+func (f *storageFactory) Enabled(name string) bool {
+	return f.config.ProviderEnabled(f.ResolveBackendName(name))
+}
+
+// EnabledBackends returns the names of the usable backends.
+func (f *storageFactory) EnabledBackends() []string {
+	var names []string
+	for _, backend := range f.config.Backends() {
+		if f.Enabled(backend.Name) {
+			names = append(names, backend.Name)
+		}
+	}
+	return names
+}
+
+// CreateProviderByName returns the storage provider of a backend or configured alias,
+// building it on first use. Providers hold clients and connection pools, so each is
+// built once and shared; failed builds are not cached and are attempted again on the
+// next call. Backends that are unknown, disabled or not configured are refused.
+func (f *storageFactory) CreateProviderByName(name string) (port.StorageProvider, error) {
+	name = f.ResolveBackendName(name)
+	backend, ok := f.config.Backend(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q", name)
+	}
+	if !f.Enabled(name) {
+		return nil, fmt.Errorf("storage backend %q is disabled or not configured", name)
+	}
+
+	value, _ := f.providers.LoadOrStore(name, &providerEntry{})
+	entry := value.(*providerEntry)
+	entry.once.Do(func() {
+		entry.provider, entry.err = f.buildProvider(backend)
+	})
+	if entry.err != nil {
+		f.providers.CompareAndDelete(name, entry)
+		return nil, entry.err
+	}
+	return entry.provider, nil
+}
+
+// Invalidate drops the cached provider of a backend or alias, so the next
+// CreateProviderByName call builds it again from the current config.
+func (f *storageFactory) Invalidate(name string) {
+	f.providers.Delete(f.ResolveBackendName(name))
+}
+
+// buildProvider creates a backend's provider from the config. When storage.retry is
+// configured, the provider is wrapped so transient failures are retried; when circuit
+// breakers are enabled, the result is wrapped again so a failing backend fails fast.
+// The signed URL wrapper applies the backend's signed URL default and maximum expiry,
+// and the outermost wrapper records the backend name.
+func (f *storageFactory) buildProvider(backend config.StorageBackendConfig) (port.StorageProvider, error) {
+	provider, err := f.createProvider(backend)
+	if err != nil {
+		return nil, err
+	}
+	if f.config.Storage.Retry.MaxAttempts > 1 {
+		provider = NewRetryingProvider(provider, f.config.Storage.Retry, f.logger)
+	}
+	if breaker, ok := f.breakers[backend.Name]; ok {
+		provider = &circuitBreakerProvider{StorageProvider: provider, breaker: breaker}
+	}
+	provider = newSignedURLProvider(provider, signedURLConfig(backend))
+	return &namedProvider{StorageProvider: provider, name: backend.Name}, nil
+}
+
+// signedURLConfig returns the signed URL policy configured for a backend. Local storage
+// falls back to its older signedUrlExpiry setting for the default.
+func signedURLConfig(backend config.StorageBackendConfig) config.SignedURLConfig {
+	switch port.StorageProviderType(backend.Type) {
+	case port.ProviderLocal:
+		cfg := backend.LocalStorage.SignedURL
+		if cfg.DefaultExpiry == 0 {
+			cfg.DefaultExpiry = backend.LocalStorage.SignedURLExpiry
+		}
+		return cfg
+	case port.ProviderS3:
+		return backend.S3.SignedURL
+	case port.ProviderCloudflareR2:
+		return backend.Cloudflare.SignedURL
+	case port.ProviderFirebase:
+		return backend.FireStore.SignedURL
+	case port.ProviderAzure:
+		return backend.Azure.SignedURL
+	case port.ProviderDiscord:
+		return backend.Discord.SignedURL
+	case port.ProviderScaleway:
+		return backend.Scaleway.SignedURL
+	case port.ProviderBackBlaze:
+		return backend.BackBlaze.SignedURL
+	case port.ProviderDigitalOcean:
+		return backend.DigitalOcean.SignedURL
+	case port.ProviderWasabi:
+		return backend.Wasabi.SignedURL
+	case port.ProviderMinIO:
+		return backend.MinIO.SignedURL
+	case port.ProviderOSS:
+		return backend.OSS.SignedURL
+	default:
+		// Telegram serves files through the bot API and has no signing of its own
+		return config.SignedURLConfig{}
+	}
+}
+
+// CircuitState returns the state of the backend's circuit breaker, or "" when disabled.
+func (f *storageFactory) CircuitState(name string) string {
+	breaker, ok := f.breakers[f.ResolveBackendName(name)]
+	if !ok {
+		return ""
 	}
+	return breaker.State().String()
 }
 
-// CreateProvider creates a specific storage provider based on the type and config.
-// If config parameter is nil, it will use the factory's internal config for the provider.
-func (f *storageFactory) CreateProvider(providerType port.StorageProviderType) (port.StorageProvider, error) {
-	switch providerType {
+func (f *storageFactory) createProvider(backend config.StorageBackendConfig) (port.StorageProvider, error) {
+	switch port.StorageProviderType(backend.Type) {
 	case port.ProviderLocal:
-		return local.NewLocalStorageProvider(f.config.LocalStorage)
+		return local.NewLocalStorageProvider(backend.LocalStorage, f.logger, f.clock)
 	case port.ProviderS3:
-		return s3.NewS3Provider(f.config.S3, f.logger)
+		return s3.NewS3Provider(backend.S3, f.logger)
 	case port.ProviderCloudflareR2:
-		return s3.NewS3Provider(f.config.Cloudflare.ToS3Config(), f.logger)
+		return s3.NewS3Provider(backend.Cloudflare.ToS3Config(), f.logger)
 	case port.ProviderFirebase:
-		return firebase.NewFirebaseProvider(f.config.FireStore, f.logger)
+		return firebase.NewFirebaseProvider(backend.FireStore, f.logger)
 	case port.ProviderAzure:
-		return azure.NewAzureProvider(&f.config.Azure, f.logger)
+		return azure.NewAzureProvider(&backend.Azure, f.logger)
 	case port.ProviderDiscord:
-		return discord.NewDiscordProvider(f.config.Discord, f.logger)
+		return discord.NewDiscordProvider(backend.Discord, f.logger)
 	case port.ProviderScaleway:
-		return s3.NewS3Provider(f.config.Scaleway.ToS3Config(), f.logger)
+		return s3.NewS3Provider(backend.Scaleway.ToS3Config(), f.logger)
 	case port.ProviderBackBlaze:
-		return s3.NewS3Provider(f.config.BackBlaze.ToS3Config(), f.logger)
+		return s3.NewS3Provider(backend.BackBlaze.ToS3Config(), f.logger)
+	case port.ProviderDigitalOcean:
+		return s3.NewS3Provider(backend.DigitalOcean.ToS3Config(), f.logger)
+	case port.ProviderWasabi:
+		return s3.NewS3Provider(backend.Wasabi.ToS3Config(), f.logger)
 	case port.ProviderMinIO:
-		return minio.NewMinIOProvider(f.config.MinIO, f.logger)
+		return minio.NewMinIOProvider(backend.MinIO, f.logger)
+	case port.ProviderOSS:
+		return oss.NewOSSProvider(backend.OSS, f.logger)
+	case port.ProviderTelegram:
+		return telegram.NewTelegramProvider(backend.Telegram, f.index, f.logger)
 	default:
-		return nil, errors.New("unsupported storage provider type for default config: " + string(providerType))
+		return nil, errors.New("unsupported storage provider type for default config: " + backend.Type)
 	}
 }
 
 // GetDefaultProvider returns the default storage provider based on configuration
 func (f *storageFactory) GetDefaultProvider() (port.StorageProvider, error) {
 	// Default to local storage if no specific provider is configured
-	return f.CreateProvider(port.ProviderLocal)
+	return f.CreateProviderByName(string(port.ProviderLocal))
 }
@@ -26,7 +26,7 @@ func NewStorageHandler(storageService service.StorageService, logger logger.Logg
 // @Tags storage
 // @Accept json
 // @Produce json
-// @Param provider_type query string true "Storage provider type"
+// @Param provider_type query string true "Storage backend or alias"
 // @Success 200 {object} dto.HealthCheckResponse
 // @Failure default {object} errors.Error
 // @Router /storage/health [get]
@@ -50,15 +50,16 @@ func (h *StorageHandler) CheckHealth(c *fiber.Ctx) error {
 
 // CheckHealthAll godoc
 // @Summary Check all storage providers health
-// @Description Check if all configured storage providers are healthy and accessible
+// @Description Check if all configured storage providers are healthy and accessible. Results are cached for a short TTL.
 // @Tags storage
 // @Accept json
 // @Produce json
+// @Param fresh query bool false "Bypass the cached results and check every provider now"
 // @Success 200 {object} dto.HealthCheckAllResponse
 // @Failure default {object} errors.Error
 // @Router /storage/health/all [get]
 func (h *StorageHandler) CheckHealthAll(c *fiber.Ctx) error {
-	response, err := h.storageService.CheckHealthAll(c.Context())
+	response, err := h.storageService.CheckHealthAll(c.Context(), c.QueryBool("fresh"))
 	if err != nil {
 		h.logger.Errorf(c.Context(), "Health check all failed", map[string]any{"error": err})
 		return err
@@ -69,7 +70,7 @@ func (h *StorageHandler) CheckHealthAll(c *fiber.Ctx) error {
 
 // ListProviders godoc
 // @Summary List all available storage providers
-// @Description Get a list of all supported storage provider types with their information
+// @Description Get the storage backends usable in this deployment with their provider type information
 // @Tags storage
 // @Accept json
 // @Produce json
@@ -0,0 +1,12 @@
+package port
+
+import "context"
+
+// BatchDeleteProvider is implemented by storage providers that can delete many objects
+// in one request, saving a round-trip per object.
+type BatchDeleteProvider interface {
+	// DeleteMany removes keys and returns the error of each key that could not be deleted;
+	// keys that do not exist count as deleted. A non-nil error means the request itself
+	// failed and none of the keys can be assumed deleted.
+	DeleteMany(ctx context.Context, keys []string) (map[string]error, error)
+}
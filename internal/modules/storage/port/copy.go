@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+	"fmt"
+)
+
+// CopyViaDownload copies srcKey to dstKey by streaming the object through the service.
+// Providers without a server-side copy use it to implement StorageProvider.Copy.
+func CopyViaDownload(ctx context.Context, provider StorageProvider, srcKey, dstKey string) (*FileObject, error) {
+	reader, src, err := provider.Download(ctx, srcKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download copy source %s: %w", srcKey, err)
+	}
+	defer reader.Close()
+
+	obj, err := provider.Upload(ctx, dstKey, reader, src.Size, &UploadOptions{ContentType: src.ContentType})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload copy destination %s: %w", dstKey, err)
+	}
+	return obj, nil
+}
+
+// PartialMoveError reports a move whose copy succeeded but whose source could not be
+// deleted. Both keys exist afterwards; callers can retry deleting SrcKey or remove DstKey.
+type PartialMoveError struct {
+	SrcKey string
+	DstKey string
+	Err    error
+}
+
+func (e *PartialMoveError) Error() string {
+	return fmt.Sprintf("copied %s to %s but failed to delete source: %v", e.SrcKey, e.DstKey, e.Err)
+}
+
+func (e *PartialMoveError) Unwrap() error {
+	return e.Err
+}
+
+// MoveViaCopy moves srcKey to dstKey with the provider's Copy followed by a Delete of the source.
+// Providers without an atomic rename use it to implement StorageProvider.Move.
+func MoveViaCopy(ctx context.Context, provider StorageProvider, srcKey, dstKey string) error {
+	if _, err := provider.Copy(ctx, srcKey, dstKey); err != nil {
+		return fmt.Errorf("failed to move %s to %s: %w", srcKey, dstKey, err)
+	}
+	if err := provider.Delete(ctx, srcKey); err != nil {
+		return &PartialMoveError{SrcKey: srcKey, DstKey: dstKey, Err: err}
+	}
+	return nil
+}
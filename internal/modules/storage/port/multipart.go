@@ -0,0 +1,34 @@
+package port
+
+import (
+	"context"
+	"io"
+)
+
+// Multipart upload limits shared by S3-compatible backends.
+const (
+	MultipartMinPartSize = 5 << 20 // Every part except the last must be at least 5 MiB
+	MultipartMaxParts    = 10000   // Part numbers run from 1 to MultipartMaxParts
+)
+
+// CompletedPart identifies an uploaded part of a multipart upload.
+type CompletedPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
+// MultipartProvider is implemented by storage providers that support client-driven
+// multipart uploads. Callers type-assert a StorageProvider to check for support.
+type MultipartProvider interface {
+	// InitiateMultipartUpload starts a multipart upload for key and returns its upload ID.
+	InitiateMultipartUpload(ctx context.Context, key string, opts *UploadOptions) (string, error)
+
+	// UploadPart uploads one part of size bytes. Re-uploading a part number replaces it.
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int, reader io.Reader, size int64) (*CompletedPart, error)
+
+	// CompleteMultipartUpload assembles parts, ordered by part number, into the final object.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) (*FileObject, error)
+
+	// AbortMultipartUpload discards an upload and any parts already stored.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
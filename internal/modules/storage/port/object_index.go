@@ -0,0 +1,27 @@
+package port
+
+import "context"
+
+// IndexedObject is an object recorded by a provider whose backend cannot look objects
+// up by key, together with the provider-specific handles needed to reach it again.
+type IndexedObject struct {
+	FileObject
+	Refs map[string]string `json:"refs"` // Provider-specific handles, e.g. message and file IDs
+}
+
+// ObjectIndex maps object keys to the records of providers that cannot search their
+// backend by key. Keys are namespaced per provider type.
+type ObjectIndex interface {
+	// Put records obj under obj.Key, replacing any previous record.
+	Put(ctx context.Context, providerType StorageProviderType, obj *IndexedObject) error
+
+	// Get returns the record for key, or nil if there is none.
+	Get(ctx context.Context, providerType StorageProviderType, key string) (*IndexedObject, error)
+
+	// Delete removes the record for key. Deleting a missing record succeeds.
+	Delete(ctx context.Context, providerType StorageProviderType, key string) error
+
+	// List returns up to limit records whose keys start with prefix, in key order,
+	// starting after the key startAfter (from the beginning when empty).
+	List(ctx context.Context, providerType StorageProviderType, prefix, startAfter string, limit int) ([]*IndexedObject, error)
+}
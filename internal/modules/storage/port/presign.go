@@ -0,0 +1,37 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// PresignedUploadProvider is implemented by storage providers that can issue URLs
+// allowing clients to upload an object directly, without streaming it through the service.
+type PresignedUploadProvider interface {
+	// GetSignedUploadURL returns a URL accepting a single HTTP PUT of the object at key.
+	// When opts sets a ContentType, providers that sign headers require the client to send it.
+	GetSignedUploadURL(ctx context.Context, key string, duration time.Duration, opts *UploadOptions) (string, error)
+}
+
+// PostConditions restricts what a browser may upload with a presigned POST form.
+type PostConditions struct {
+	Expires           time.Duration // Validity of the form
+	ContentType       string        // Exact Content-Type required, if set
+	ContentTypePrefix string        // Required Content-Type prefix (e.g. "image/"), if set and ContentType is empty
+	MinContentLength  int64         // Smallest accepted upload in bytes
+	MaxContentLength  int64         // Largest accepted upload in bytes; 0 disables the size check
+}
+
+// PostPolicy is a presigned HTML form upload: the browser POSTs multipart/form-data to URL
+// with Fields followed by the file in a "file" field.
+type PostPolicy struct {
+	URL       string            `json:"url"`
+	Fields    map[string]string `json:"fields"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+// PresignedPostProvider is implemented by storage providers that support presigned POST policies.
+type PresignedPostProvider interface {
+	// GetPresignedPostPolicy returns a form that uploads the object at key, subject to conditions.
+	GetPresignedPostPolicy(ctx context.Context, key string, conditions PostConditions) (*PostPolicy, error)
+}
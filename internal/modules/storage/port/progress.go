@@ -0,0 +1,62 @@
+package port
+
+import "io"
+
+// TrackProgress wraps reader so opts.ProgressFn, if set, hears how many of total bytes
+// have been read. Providers call it at the start of Upload; opts may be nil.
+func (o *UploadOptions) TrackProgress(reader io.Reader, total int64) io.Reader {
+	if o == nil {
+		return reader
+	}
+	return ProgressReader(reader, total, o.ProgressFn)
+}
+
+// ProgressReader wraps reader so fn is called with the bytes read so far after every
+// read. Seekable readers stay seekable, and seeking moves the reported count with the
+// position, so a retried upload reports from where it starts again. A nil fn leaves
+// reader as is.
+func ProgressReader(reader io.Reader, total int64, fn func(bytesWritten, total int64)) io.Reader {
+	if fn == nil {
+		return reader
+	}
+	progress := &progressReader{r: reader, total: total, fn: fn}
+	seeker, ok := reader.(io.Seeker)
+	if !ok {
+		return progress
+	}
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return progress
+	}
+	return &progressReadSeeker{progressReader: progress, seeker: seeker, start: start}
+}
+
+type progressReader struct {
+	r     io.Reader
+	total int64
+	read  int64
+	fn    func(bytesWritten, total int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.read += int64(n)
+		p.fn(p.read, p.total)
+	}
+	return n, err
+}
+
+type progressReadSeeker struct {
+	*progressReader
+	seeker io.Seeker
+	start  int64 // Position of the reader when wrapped, reported as 0 bytes
+}
+
+func (p *progressReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := p.seeker.Seek(offset, whence)
+	if err == nil {
+		p.read = max(pos-p.start, 0)
+	}
+	return pos, err
+}
@@ -0,0 +1,40 @@
+package port
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidRange is returned by DownloadRange when start and end do not form a valid byte range.
+var ErrInvalidRange = errors.New("invalid byte range")
+
+// HTTPRange validates an inclusive byte range and formats it as an HTTP Range header value.
+// A negative end reads through the end of the object.
+func HTTPRange(start, end int64) (string, error) {
+	if start < 0 || (end >= 0 && end < start) {
+		return "", fmt.Errorf("%w: %d-%d", ErrInvalidRange, start, end)
+	}
+	if end < 0 {
+		return fmt.Sprintf("bytes=%d-", start), nil
+	}
+	return fmt.Sprintf("bytes=%d-%d", start, end), nil
+}
+
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// SliceReader skips to start in a full-object stream and limits it to the inclusive range,
+// for backends that cannot serve ranges themselves. The returned reader closes rc.
+func SliceReader(rc io.ReadCloser, start, end int64) (io.ReadCloser, error) {
+	if _, err := io.CopyN(io.Discard, rc, start); err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("failed to skip to offset %d: %w", start, err)
+	}
+	if end < 0 {
+		return rc, nil
+	}
+	return limitedReadCloser{Reader: io.LimitReader(rc, end-start+1), Closer: rc}, nil
+}
@@ -0,0 +1,59 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Errors returned by SignedURLVerifier.VerifySignedURL.
+var (
+	ErrSignedURLExpired = errors.New("signed URL has expired")
+	ErrSignedURLInvalid = errors.New("signed URL signature is invalid")
+)
+
+// Signed URL validity used when a provider's config does not set one.
+const (
+	DefaultSignedURLExpiry    = time.Hour
+	DefaultMaxSignedURLExpiry = 7 * 24 * time.Hour
+)
+
+// SignedURLVerifier is implemented by providers whose signed URLs are served by this
+// service rather than by the storage backend, so the service must check them itself.
+type SignedURLVerifier interface {
+	// VerifySignedURL checks the expires and signature query parameters of a signed URL for key.
+	VerifySignedURL(key string, expires int64, signature string) error
+}
+
+// LocalFileServer is implemented by providers that keep objects as files on this host and
+// whose signed URLs this service serves itself, under the path of their base URL.
+type LocalFileServer interface {
+	SignedURLVerifier
+	// SignedURLBase returns the base URL the provider's signed URLs are issued under
+	SignedURLBase() string
+	// LocalPath returns the path of the file holding key, rejecting keys that would
+	// resolve outside the provider's storage root with ErrInvalidKey
+	LocalPath(key string) (string, error)
+}
+
+// SignedURLDefaulter is implemented by providers with a configured signed URL policy:
+// a default validity and a maximum that longer requests are shortened to.
+type SignedURLDefaulter interface {
+	// SignedURLExpiry resolves a requested validity: zero or less means the default, and
+	// anything above the maximum is clamped to it.
+	SignedURLExpiry(requested time.Duration) time.Duration
+	// GetSignedURLDefault returns a signed URL for key valid for the default expiry.
+	GetSignedURLDefault(ctx context.Context, key string) (string, error)
+}
+
+// SignedURLExpiry resolves the validity of a signed URL issued by provider, applying its
+// policy when it has one and the package defaults otherwise.
+func SignedURLExpiry(provider StorageProvider, requested time.Duration) time.Duration {
+	if defaulter, ok := As[SignedURLDefaulter](provider); ok {
+		return defaulter.SignedURLExpiry(requested)
+	}
+	if requested <= 0 {
+		return DefaultSignedURLExpiry
+	}
+	return min(requested, DefaultMaxSignedURLExpiry)
+}
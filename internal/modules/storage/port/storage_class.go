@@ -0,0 +1,11 @@
+package port
+
+import "context"
+
+// StorageClassProvider is implemented by storage providers that can move objects
+// between storage tiers after upload, e.g. to archive old media cheaply.
+type StorageClassProvider interface {
+	// ChangeStorageClass moves the object at key to class. Valid classes depend on the
+	// provider; an unknown class is rejected before the backend is called.
+	ChangeStorageClass(ctx context.Context, key, class string) error
+}
@@ -2,25 +2,65 @@ package port
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 )
 
+// ErrObjectNotFound is wrapped by provider errors when the requested object does not exist.
+// Check for it with errors.Is rather than matching error messages.
+var ErrObjectNotFound = errors.New("not found")
+
+// ErrInvalidKey is wrapped by provider errors when a key cannot name an object, such as
+// a local key that would resolve outside the storage root.
+var ErrInvalidKey = errors.New("invalid object key")
+
 // StorageProviderType defines the type of adapters provider.
 type StorageProviderType string
 
 const (
-	ProviderS3           StorageProviderType = "s3"            // Amazon S3, DigitalOcean Spaces, etc.
+	ProviderS3           StorageProviderType = "s3"            // Amazon S3 and other S3-compatible services
 	ProviderCloudflareR2 StorageProviderType = "cloudflare_r2" // Cloudflare R2 is S3-compatible
 	ProviderLocal        StorageProviderType = "local"
-	ProviderFirebase     StorageProviderType = "firebase"  // Firebase Storage
-	ProviderAzure        StorageProviderType = "azure"     // Azure Blob Storage
-	ProviderDiscord      StorageProviderType = "discord"   // Discord channel storage
-	ProviderScaleway     StorageProviderType = "scaleway"  // Scaleway Object Storage (S3-compatible)
-	ProviderBackBlaze    StorageProviderType = "backblaze" // Backblaze B2 Cloud Storage
-	ProviderMinIO        StorageProviderType = "minio"     // MinIO Object Storage (S3-compatible)
+	ProviderFirebase     StorageProviderType = "firebase"     // Firebase Storage
+	ProviderAzure        StorageProviderType = "azure"        // Azure Blob Storage
+	ProviderDiscord      StorageProviderType = "discord"      // Discord channel storage
+	ProviderScaleway     StorageProviderType = "scaleway"     // Scaleway Object Storage (S3-compatible)
+	ProviderBackBlaze    StorageProviderType = "backblaze"    // Backblaze B2 Cloud Storage
+	ProviderMinIO        StorageProviderType = "minio"        // MinIO Object Storage (S3-compatible)
+	ProviderOSS          StorageProviderType = "oss"          // Alibaba Cloud Object Storage Service
+	ProviderTelegram     StorageProviderType = "telegram"     // Telegram chat storage
+	ProviderDigitalOcean StorageProviderType = "digitalocean" // DigitalOcean Spaces (S3-compatible)
+	ProviderWasabi       StorageProviderType = "wasabi"       // Wasabi Hot Cloud Storage (S3-compatible)
 )
 
+// SupportedProviderTypes lists every concrete provider type the factory can build.
+var SupportedProviderTypes = []StorageProviderType{
+	ProviderS3,
+	ProviderCloudflareR2,
+	ProviderLocal,
+	ProviderFirebase,
+	ProviderAzure,
+	ProviderDiscord,
+	ProviderScaleway,
+	ProviderBackBlaze,
+	ProviderMinIO,
+	ProviderOSS,
+	ProviderTelegram,
+	ProviderDigitalOcean,
+	ProviderWasabi,
+}
+
+// IsSupportedProviderType reports whether providerType is a concrete, buildable provider type.
+func IsSupportedProviderType(providerType StorageProviderType) bool {
+	for _, supported := range SupportedProviderTypes {
+		if providerType == supported {
+			return true
+		}
+	}
+	return false
+}
+
 // FileObject represents a file stored in the adapters.
 type FileObject struct {
 	Key          string              `json:"key"`           // Unique identifier for the file in the adapters (e.g., path/to/file.jpg)
@@ -35,9 +75,45 @@ type FileObject struct {
 
 // UploadOptions provides options for uploading a file.
 type UploadOptions struct {
-	ContentType string            // MIME type of the file
-	Metadata    map[string]string // Custom metadata for the file
-	ACL         string            // Access Control List (e.g., "public-read", "private") - specific to provider
+	ContentType          string            // MIME type of the file
+	Metadata             map[string]string // Custom metadata for the file
+	ACL                  string            // Access Control List (e.g., "public-read", "private") - specific to provider
+	ServerSideEncryption string            // Server-side encryption algorithm (e.g., "AES256", "aws:kms"); S3 only
+	SSEKMSKeyID          string            // KMS key for "aws:kms" encryption; S3 only
+	StorageClass         string            // Storage tier (e.g., "STANDARD_IA", "GLACIER"); S3 only, ignored elsewhere
+
+	ProgressFn func(bytesWritten, total int64) // Optional: called as the provider reads the upload; total is the size passed to Upload
+}
+
+// RequestsEncryption reports whether opts, which may be nil, asks for server-side encryption.
+// Providers that cannot encrypt store the object as usual and log a warning.
+func (o *UploadOptions) RequestsEncryption() bool {
+	return o != nil && (o.ServerSideEncryption != "" || o.SSEKMSKeyID != "")
+}
+
+// DefaultListMaxKeys is the page size used by ListObjects when ListOptions.MaxKeys is not set.
+const DefaultListMaxKeys = 1000
+
+// ListOptions controls paging for ListObjects.
+type ListOptions struct {
+	MaxKeys           int    // Maximum objects per page (default: DefaultListMaxKeys)
+	ContinuationToken string // Token returned by a previous ListObjects call; empty starts from the beginning
+}
+
+// MaxKeysOrDefault returns the effective page size for opts, which may be nil.
+func (o *ListOptions) MaxKeysOrDefault() int {
+	if o == nil || o.MaxKeys <= 0 {
+		return DefaultListMaxKeys
+	}
+	return o.MaxKeys
+}
+
+// Token returns the continuation token of opts, which may be nil.
+func (o *ListOptions) Token() string {
+	if o == nil {
+		return ""
+	}
+	return o.ContinuationToken
 }
 
 // StorageProvider defines the interface for a adapters provider.
@@ -64,6 +140,10 @@ type StorageProvider interface {
 	// Delete removes a file from the adapters.
 	Delete(ctx context.Context, key string) error
 
+	// Exists reports whether an object is stored at key. A missing object is (false, nil);
+	// an error means existence could not be determined.
+	Exists(ctx context.Context, key string) (bool, error)
+
 	// GetObject retrieves file information (metadata) without downloading the content.
 	GetObject(ctx context.Context, key string) (*FileObject, error)
 
@@ -71,11 +151,83 @@ type StorageProvider interface {
 	// Returns an io.ReadCloser that needs to be closed by the caller.
 	Download(ctx context.Context, key string) (io.ReadCloser, *FileObject, error)
 
+	// DownloadRange downloads the inclusive byte range start..end of a file; a negative
+	// end reads to the end. The returned FileObject describes the whole object.
+	DownloadRange(ctx context.Context, key string, start, end int64) (io.ReadCloser, *FileObject, error)
+
+	// Copy duplicates srcKey to dstKey, server-side where the provider supports it,
+	// and returns the metadata of the new object.
+	Copy(ctx context.Context, srcKey, dstKey string) (*FileObject, error)
+
+	// Move renames srcKey to dstKey. If the copy succeeds but the source cannot be
+	// removed, a *PartialMoveError naming both keys is returned.
+	Move(ctx context.Context, srcKey, dstKey string) error
+
+	// ListObjects returns one page of objects whose keys start with prefix.
+	// The returned token is passed back in opts.ContinuationToken to fetch the next page;
+	// it is empty when there are no more objects.
+	ListObjects(ctx context.Context, prefix string, opts *ListOptions) ([]*FileObject, string, error)
+
 	// ProviderType returns the type of the adapters provider.
 	ProviderType() StorageProviderType
 }
 
 // StorageFactory defines the interface for a factory that creates StorageProvider instances.
+// Providers are created per storage backend: the default backend of each provider type,
+// named after the type, and the named backends in storage.backends.
 type StorageFactory interface {
-	CreateProvider(providerType StorageProviderType) (StorageProvider, error)
+	// CreateProviderByName returns the provider of a backend, by backend name or configured
+	// alias. Providers are built once and shared, so they must be safe for concurrent use.
+	CreateProviderByName(name string) (StorageProvider, error)
+
+	// Invalidate drops the cached provider of a backend or alias so the next
+	// CreateProviderByName call rebuilds it, e.g. after its configuration was reloaded.
+	Invalidate(name string)
+
+	// ResolveBackendName maps a configured alias to its backend name.
+	// Names that are not aliases are returned unchanged.
+	ResolveBackendName(name string) string
+
+	// BackendType returns the provider type of a backend or alias, and false when no
+	// backend has that name.
+	BackendType(name string) (StorageProviderType, bool)
+
+	// Aliases returns the configured client-facing aliases keyed by alias name, each
+	// mapped to a backend name.
+	Aliases() map[string]string
+
+	// CircuitState returns the circuit breaker state of a backend ("closed", "open" or
+	// "half-open"), or an empty string when circuit breakers are disabled.
+	CircuitState(name string) string
+
+	// Enabled reports whether a backend or alias is usable in this deployment.
+	// CreateProviderByName fails for backends that are not.
+	Enabled(name string) bool
+
+	// EnabledBackends returns the names of the usable backends: the default backends
+	// first, then the named backends in configuration order.
+	EnabledBackends() []string
+}
+
+// ErrCircuitOpen is wrapped by calls rejected without reaching a provider because its
+// circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ProviderHealth is the last observed health of a provider.
+type ProviderHealth struct {
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// UploadGate decides whether uploads to a provider should be accepted based on
+// cached health, so requests to a known-bad backend can be rejected before the
+// body is read.
+type UploadGate interface {
+	// AllowUpload reports whether uploads to providerName (a backend or alias) are accepted.
+	// When they are not, retryAfter is how long the client should wait before retrying.
+	AllowUpload(providerName string) (allowed bool, retryAfter time.Duration)
+
+	// Health returns the cached health of a backend, if it has been checked.
+	Health(backendName string) (ProviderHealth, bool)
 }
@@ -0,0 +1,17 @@
+package port
+
+import "context"
+
+// MaxObjectTags is the most tags S3, MinIO and Azure accept on a single object.
+const MaxObjectTags = 10
+
+// TaggableProvider is implemented by storage providers that support key/value object
+// tags. Unlike metadata, tags can be changed without rewriting the object and can be
+// matched by lifecycle rules and server-side filters.
+type TaggableProvider interface {
+	// SetTags replaces all tags of the object at key with tags.
+	SetTags(ctx context.Context, key string, tags map[string]string) error
+
+	// GetTags returns the tags of the object at key; an untagged object has an empty map.
+	GetTags(ctx context.Context, key string) (map[string]string, error)
+}
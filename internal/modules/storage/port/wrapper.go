@@ -0,0 +1,41 @@
+package port
+
+// ProviderWrapper is implemented by decorators that wrap another StorageProvider,
+// such as a retrying provider. Optional capabilities like MultipartProvider are only
+// implemented by the wrapped provider, so callers look them up with As.
+type ProviderWrapper interface {
+	Unwrap() StorageProvider
+}
+
+// As returns the first provider in the decorator chain of provider, starting with
+// provider itself, that implements T.
+func As[T any](provider StorageProvider) (T, bool) {
+	for provider != nil {
+		if target, ok := provider.(T); ok {
+			return target, true
+		}
+		wrapper, ok := provider.(ProviderWrapper)
+		if !ok {
+			break
+		}
+		provider = wrapper.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// NamedBackend is implemented by the providers StorageFactory returns, naming the
+// storage backend they were built for.
+type NamedBackend interface {
+	BackendName() string
+}
+
+// BackendName returns the name of the storage backend provider belongs to, which is
+// stored with media to find the provider again. Providers without a backend name are
+// named after their type, like the default backend of each type.
+func BackendName(provider StorageProvider) string {
+	if named, ok := As[NamedBackend](provider); ok {
+		return named.BackendName()
+	}
+	return string(provider.ProviderType())
+}
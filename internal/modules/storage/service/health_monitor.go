@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	logger "github.com/lugondev/go-log"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/storage/port"
+)
+
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 10 * time.Second
+	// healthStaleAfter is how many missed intervals make a cached result too old to gate on.
+	healthStaleAfter = 3
+)
+
+// HealthMonitor periodically checks every gated provider in the background and
+// caches the results for the upload gate.
+type HealthMonitor struct {
+	factory    port.StorageFactory
+	logger     logger.Logger
+	cfg        config.HealthGateConfig
+	interval   time.Duration
+	timeout    time.Duration
+	retryAfter time.Duration
+
+	mu     sync.RWMutex
+	health map[string]port.ProviderHealth // Keyed by backend name
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ port.UploadGate = (*HealthMonitor)(nil)
+
+// NewHealthMonitor creates a HealthMonitor. Call Start to begin background checks.
+func NewHealthMonitor(factory port.StorageFactory, appLogger logger.Logger, cfg config.HealthGateConfig) *HealthMonitor {
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	retryAfter := time.Duration(cfg.RetryAfterSeconds) * time.Second
+	if retryAfter <= 0 {
+		retryAfter = interval
+	}
+
+	return &HealthMonitor{
+		factory:    factory,
+		logger:     appLogger.WithFields(map[string]any{"component": "HealthMonitor"}),
+		cfg:        cfg,
+		interval:   interval,
+		timeout:    timeout,
+		retryAfter: retryAfter,
+		health:     make(map[string]port.ProviderHealth),
+	}
+}
+
+// Start launches the background check loop. It is a no-op when no provider is gated.
+func (m *HealthMonitor) Start() {
+	if len(m.gatedProviders()) == 0 || m.stop != nil {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+
+		m.checkAll()
+		for {
+			select {
+			case <-ticker.C:
+				m.checkAll()
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+	m.logger.Info(context.Background(), "Provider health monitor started", map[string]any{"interval": m.interval.String()})
+}
+
+// Stop ends the background loop and waits for an in-flight round to finish.
+func (m *HealthMonitor) Stop() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+	m.stop = nil
+}
+
+// AllowUpload implements port.UploadGate. Providers that are not gated, have not
+// been checked yet, or whose last result is stale are always allowed.
+func (m *HealthMonitor) AllowUpload(providerName string) (bool, time.Duration) {
+	backendName := string(port.ProviderLocal) // Matches the media service default
+	if providerName != "" {
+		backendName = m.factory.ResolveBackendName(providerName)
+	}
+	if !m.gated(backendName) {
+		return true, 0
+	}
+
+	health, ok := m.Health(backendName)
+	if !ok || health.Healthy || time.Since(health.CheckedAt) > healthStaleAfter*m.interval {
+		return true, 0
+	}
+	return false, m.retryAfter
+}
+
+// Health implements port.UploadGate.
+func (m *HealthMonitor) Health(backendName string) (port.ProviderHealth, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	health, ok := m.health[backendName]
+	return health, ok
+}
+
+// gated reports whether the gate applies to a backend; per-backend settings override Enabled.
+func (m *HealthMonitor) gated(backendName string) bool {
+	if enabled, ok := m.cfg.Providers[backendName]; ok {
+		return enabled
+	}
+	return m.cfg.Enabled
+}
+
+func (m *HealthMonitor) gatedProviders() []string {
+	var backends []string
+	for _, backendName := range m.factory.EnabledBackends() {
+		if m.gated(backendName) {
+			backends = append(backends, backendName)
+		}
+	}
+	return backends
+}
+
+// checkAll checks every gated provider concurrently and records the results.
+func (m *HealthMonitor) checkAll() {
+	var wg sync.WaitGroup
+	for _, backendName := range m.gatedProviders() {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			m.record(name, m.check(name))
+		}(backendName)
+	}
+	wg.Wait()
+}
+
+func (m *HealthMonitor) check(backendName string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	provider, err := m.factory.CreateProviderByName(backendName)
+	if err != nil {
+		return err
+	}
+	return provider.CheckHealth(ctx)
+}
+
+// record stores a check result, logging only when a provider changes state.
+func (m *HealthMonitor) record(backendName string, err error) {
+	health := port.ProviderHealth{Healthy: err == nil, CheckedAt: time.Now()}
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	previous, seen := m.health[backendName]
+	m.health[backendName] = health
+	m.mu.Unlock()
+
+	if seen && previous.Healthy == health.Healthy {
+		return
+	}
+	if health.Healthy {
+		m.logger.Info(context.Background(), "Storage provider is healthy", map[string]any{"provider": backendName})
+	} else {
+		m.logger.Warn(context.Background(), "Storage provider is unhealthy; uploads will be rejected", map[string]any{"provider": backendName, "error": health.Error})
+	}
+}
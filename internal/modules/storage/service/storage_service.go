@@ -3,8 +3,12 @@ package service
 import (
 	"context"
 	"sync"
+	"time"
 
 	logger "github.com/lugondev/go-log"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
 	"github.com/lugondev/m3-storage/internal/modules/storage/domain"
 	"github.com/lugondev/m3-storage/internal/modules/storage/dto"
 	"github.com/lugondev/m3-storage/internal/modules/storage/port"
@@ -14,20 +18,36 @@ import (
 // StorageService defines the interface for storage business logic
 type StorageService interface {
 	CheckHealth(ctx context.Context, req *dto.HealthCheckRequest) (*dto.HealthCheckResponse, error)
-	CheckHealthAll(ctx context.Context) (*dto.HealthCheckAllResponse, error)
+	// CheckHealthAll checks every provider, serving results cached within the TTL unless fresh is set.
+	CheckHealthAll(ctx context.Context, fresh bool) (*dto.HealthCheckAllResponse, error)
 	ListProviders(ctx context.Context) (*dto.ListProvidersResponse, error)
 }
 
+const defaultHealthCacheTTL = 30 * time.Second
+
 type storageService struct {
 	factory port.StorageFactory
 	logger  logger.Logger
+
+	healthTTL   time.Duration // Zero disables caching of CheckHealthAll
+	healthMu    sync.RWMutex
+	healthAll   *dto.HealthCheckAllResponse
+	healthGroup singleflight.Group // Collapses concurrent refreshes into one round of checks
 }
 
 // NewStorageService creates a new instance of StorageService
-func NewStorageService(factory port.StorageFactory, logger logger.Logger) StorageService {
+func NewStorageService(factory port.StorageFactory, logger logger.Logger, cfg config.StorageConfig) StorageService {
+	healthTTL := time.Duration(cfg.HealthCacheTTLSeconds) * time.Second
+	if cfg.HealthCacheTTLSeconds == 0 {
+		healthTTL = defaultHealthCacheTTL
+	} else if healthTTL < 0 {
+		healthTTL = 0
+	}
+
 	return &storageService{
-		factory: factory,
-		logger:  logger.WithFields(map[string]any{"component": "StorageService"}),
+		factory:   factory,
+		logger:    logger.WithFields(map[string]any{"component": "StorageService"}),
+		healthTTL: healthTTL,
 	}
 }
 
@@ -37,19 +57,17 @@ func (s *storageService) CheckHealth(ctx context.Context, req *dto.HealthCheckRe
 		return nil, errors.NewBadRequestError("provider_type is required")
 	}
 
-	// Convert to domain type
-	domainProviderType := domain.StorageProviderType(req.ProviderType)
+	// Resolve configured aliases to the backend name
+	backendName := s.factory.ResolveBackendName(req.ProviderType)
 
-	// Validate provider type
-	if !s.isValidProviderType(domainProviderType) {
+	// Validate backend name
+	if _, ok := s.factory.BackendType(backendName); !ok {
 		return nil, errors.NewBadRequestError("invalid provider type")
 	}
 
-	// Convert back to port type for factory (adapter layer)
-	portProviderType := port.StorageProviderType(req.ProviderType)
-	provider, err := s.factory.CreateProvider(portProviderType)
+	provider, err := s.factory.CreateProviderByName(backendName)
 	if err != nil {
-		s.logger.Errorf(ctx, "Failed to create storage provider", map[string]any{"error": err, "provider_type": domainProviderType})
+		s.logger.Errorf(ctx, "Failed to create storage provider", map[string]any{"error": err, "backend": backendName})
 		return nil, errors.NewBadRequestError("invalid provider type")
 	}
 
@@ -59,46 +77,64 @@ func (s *storageService) CheckHealth(ctx context.Context, req *dto.HealthCheckRe
 		return &dto.HealthCheckResponse{
 			Status:  "error",
 			Message: err.Error(),
+			Circuit: s.factory.CircuitState(backendName),
 		}, nil
 	}
 
 	return &dto.HealthCheckResponse{
-		Status: "healthy",
+		Status:  "healthy",
+		Circuit: s.factory.CircuitState(backendName),
 	}, nil
 }
 
-// CheckHealthAll checks the health of all configured storage providers
-func (s *storageService) CheckHealthAll(ctx context.Context) (*dto.HealthCheckAllResponse, error) {
+// CheckHealthAll returns the health of all configured storage providers. Results younger
+// than the cache TTL are reused unless fresh is set, so frequent polling does not hit
+// every provider on each request.
+func (s *storageService) CheckHealthAll(ctx context.Context, fresh bool) (*dto.HealthCheckAllResponse, error) {
+	if !fresh && s.healthTTL > 0 {
+		s.healthMu.RLock()
+		cached := s.healthAll
+		s.healthMu.RUnlock()
+		if cached != nil && time.Since(cached.CheckedAt) < s.healthTTL {
+			return cached, nil
+		}
+	}
+
+	result, err, _ := s.healthGroup.Do("all", func() (any, error) {
+		// The checks are shared with concurrent callers, so one caller leaving must not cancel them
+		response := s.checkHealthAll(context.WithoutCancel(ctx))
+		s.healthMu.Lock()
+		s.healthAll = response
+		s.healthMu.Unlock()
+		return response, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*dto.HealthCheckAllResponse), nil
+}
+
+// checkHealthAll checks every enabled provider concurrently.
+func (s *storageService) checkHealthAll(ctx context.Context) *dto.HealthCheckAllResponse {
+	checkedAt := time.Now()
 	results := make(map[string]dto.HealthCheckResponse)
 	var mutex sync.Mutex
 	var wg sync.WaitGroup
 
-	// List of all provider types to check (using domain types)
-	providers := []domain.StorageProviderType{
-		domain.ProviderS3,
-		domain.ProviderCloudflareR2,
-		domain.ProviderLocal,
-		domain.ProviderFirebase,
-		domain.ProviderAzure,
-		domain.ProviderDiscord,
-		domain.ProviderScaleway,
-		domain.ProviderBackBlaze,
-		domain.ProviderMinIO,
-	}
-
-	for _, providerType := range providers {
+	// Backends that are disabled or not configured would only report errors
+	for _, backendName := range s.factory.EnabledBackends() {
 		wg.Add(1)
-		go func(pType domain.StorageProviderType) {
+		go func(name string) {
 			defer wg.Done()
 
 			req := &dto.HealthCheckRequest{
-				ProviderType: string(pType),
+				ProviderType: name,
 			}
 
 			response, err := s.CheckHealth(ctx, req)
 			if err != nil {
 				mutex.Lock()
-				results[string(pType)] = dto.HealthCheckResponse{
+				results[name] = dto.HealthCheckResponse{
 					Status:  "error",
 					Message: err.Error(),
 				}
@@ -107,21 +143,23 @@ func (s *storageService) CheckHealthAll(ctx context.Context) (*dto.HealthCheckAl
 			}
 
 			mutex.Lock()
-			results[string(pType)] = *response
+			results[name] = *response
 			mutex.Unlock()
-		}(providerType)
+		}(backendName)
 	}
 
 	wg.Wait()
 
 	return &dto.HealthCheckAllResponse{
 		Providers: results,
-	}, nil
+		CheckedAt: checkedAt,
+	}
 }
 
-// ListProviders returns a list of all available storage providers
+// ListProviders returns the storage backends usable in this deployment, one entry per
+// backend with the description of its provider type
 func (s *storageService) ListProviders(ctx context.Context) (*dto.ListProvidersResponse, error) {
-	providers := []dto.ProviderInfo{
+	providerTypes := []dto.ProviderInfo{
 		{
 			Type:        string(domain.ProviderS3),
 			Name:        "Amazon S3",
@@ -162,36 +200,49 @@ func (s *storageService) ListProviders(ctx context.Context) (*dto.ListProvidersR
 			Name:        "Backblaze B2",
 			Description: "Backblaze B2 Cloud Storage",
 		},
+		{
+			Type:        string(domain.ProviderDigitalOcean),
+			Name:        "DigitalOcean Spaces",
+			Description: "DigitalOcean Spaces Object Storage",
+		},
+		{
+			Type:        string(domain.ProviderWasabi),
+			Name:        "Wasabi",
+			Description: "Wasabi Hot Cloud Storage",
+		},
 		{
 			Type:        string(domain.ProviderMinIO),
 			Name:        "MinIO Object Storage",
 			Description: "MinIO High Performance Object Storage",
 		},
+		{
+			Type:        string(domain.ProviderOSS),
+			Name:        "Alibaba Cloud OSS",
+			Description: "Alibaba Cloud Object Storage Service",
+		},
+		{
+			Type:        string(domain.ProviderTelegram),
+			Name:        "Telegram",
+			Description: "Telegram chat document storage",
+		},
 	}
 
+	infos := make(map[string]dto.ProviderInfo, len(providerTypes))
+	for _, info := range providerTypes {
+		infos[info.Type] = info
+	}
+	var providers []dto.ProviderInfo
+	for _, backendName := range s.factory.EnabledBackends() {
+		providerType, _ := s.factory.BackendType(backendName)
+		info := infos[string(providerType)]
+		info.Backend = backendName
+		providers = append(providers, info)
+	}
+
+	aliases := s.factory.Aliases()
+
 	return &dto.ListProvidersResponse{
 		Providers: providers,
+		Aliases:   aliases,
 	}, nil
 }
-
-// isValidProviderType validates if the provider type is supported
-func (s *storageService) isValidProviderType(providerType domain.StorageProviderType) bool {
-	validTypes := []domain.StorageProviderType{
-		domain.ProviderS3,
-		domain.ProviderCloudflareR2,
-		domain.ProviderLocal,
-		domain.ProviderFirebase,
-		domain.ProviderAzure,
-		domain.ProviderDiscord,
-		domain.ProviderScaleway,
-		domain.ProviderBackBlaze,
-		domain.ProviderMinIO,
-	}
-
-	for _, validType := range validTypes {
-		if providerType == validType {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DeliveryStatus is where a webhook delivery stands.
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"   // Waiting for its next attempt
+	DeliveryDelivered DeliveryStatus = "delivered" // Acknowledged by the subscriber with a 2xx response
+	DeliveryFailed    DeliveryStatus = "failed"    // Given up after the last attempt
+)
+
+// Event is the JSON body POSTed to subscribers. Its ID is the same for every subscriber
+// and every attempt, so receivers can drop events delivered more than once.
+type Event struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivery is an event on its way to one subscriber. Deliveries are stored before they
+// are sent, so those not acknowledged yet survive restarts.
+type Delivery struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primary_key;"`
+	EventID       uuid.UUID      `gorm:"type:uuid;index"`
+	EventType     string         `gorm:"type:varchar(64)"`
+	URL           string         `gorm:"type:varchar(500)"` // Subscriber URL; its secret is looked up in the configuration
+	Payload       string         `gorm:"type:text"`         // JSON encoded Event, signed and sent as is
+	Status        DeliveryStatus `gorm:"type:varchar(20);index:idx_webhook_deliveries_due,priority:1"`
+	Attempts      int            `gorm:"default:0"`
+	NextAttemptAt time.Time      `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LastError     string         `gorm:"type:text"`
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time `gorm:"index"`
+}
+
+// TableName specifies the table name for the Delivery model.
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	logger "github.com/lugondev/go-log"
+	"gorm.io/gorm"
+
+	"github.com/lugondev/m3-storage/internal/infra/config"
+	"github.com/lugondev/m3-storage/internal/modules/webhook/domain"
+	"github.com/lugondev/m3-storage/internal/shared/clock"
+)
+
+// Headers sent with every delivery
+const (
+	HeaderEvent     = "X-M3-Event"     // Event type
+	HeaderDelivery  = "X-M3-Delivery"  // Delivery ID, the same on every attempt
+	HeaderTimestamp = "X-M3-Timestamp" // Unix time of the attempt, covered by the signature
+	HeaderSignature = "X-M3-Signature" // "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>"
+)
+
+const (
+	defaultMaxAttempts  = 10
+	defaultBaseDelay    = 30 * time.Second
+	defaultMaxDelay     = time.Hour
+	defaultTimeout      = 10 * time.Second
+	defaultWorkers      = 4
+	defaultPollInterval = 5 * time.Second
+	defaultRetention    = 7 * 24 * time.Hour
+
+	// leaseMargin is added to the request timeout while a delivery is being sent. A
+	// delivery still claimed after that, because its server stopped, is sent again.
+	leaseMargin = time.Minute
+	// cleanupInterval is how often finished deliveries past their retention are removed
+	cleanupInterval = time.Hour
+	// maxErrorBody bounds how much of a failed response is kept as the delivery's error
+	maxErrorBody = 512
+	userAgent    = "m3-storage-webhooks"
+)
+
+// errUnsubscribed fails deliveries to a URL removed from the configuration since
+var errUnsubscribed = errors.New("subscriber is no longer configured")
+
+// Dispatcher stores storage events as webhook deliveries, one per subscriber, and sends
+// them in the background. A delivery is retried with exponential backoff until the
+// subscriber answers with a 2xx status or it runs out of attempts, so subscribers get
+// every event at least once.
+type Dispatcher struct {
+	db           *gorm.DB
+	logger       logger.Logger
+	client       *http.Client
+	clock        clock.Clock
+	subscribers  []config.WebhookSubscriber
+	secrets      map[string]string // Subscriber URL -> secret
+	maxAttempts  int
+	baseDelay    time.Duration
+	maxDelay     time.Duration
+	timeout      time.Duration
+	workers      int
+	pollInterval time.Duration
+	retention    time.Duration
+	lastCleanup  time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewDispatcher creates a Dispatcher for the subscribers of cfg, which may subscribe to
+// the given event types. It publishes nothing when webhooks are disabled. Call Start to
+// begin sending.
+func NewDispatcher(db *gorm.DB, appLogger logger.Logger, cfg config.WebhookConfig, events []string, clk clock.Clock) (*Dispatcher, error) {
+	d := &Dispatcher{
+		db:           db,
+		logger:       appLogger.WithFields(map[string]any{"component": "WebhookDispatcher"}),
+		clock:        clk,
+		secrets:      make(map[string]string),
+		maxAttempts:  cfg.MaxAttempts,
+		baseDelay:    time.Duration(cfg.BaseDelaySeconds) * time.Second,
+		maxDelay:     time.Duration(cfg.MaxDelaySeconds) * time.Second,
+		timeout:      time.Duration(cfg.TimeoutSeconds) * time.Second,
+		workers:      cfg.Workers,
+		pollInterval: time.Duration(cfg.PollIntervalSeconds) * time.Second,
+		retention:    time.Duration(cfg.RetentionSeconds) * time.Second,
+		wake:         make(chan struct{}, 1),
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = defaultMaxAttempts
+	}
+	if d.baseDelay <= 0 {
+		d.baseDelay = defaultBaseDelay
+	}
+	if d.maxDelay <= 0 {
+		d.maxDelay = defaultMaxDelay
+	}
+	if d.timeout <= 0 {
+		d.timeout = defaultTimeout
+	}
+	if d.workers <= 0 {
+		d.workers = defaultWorkers
+	}
+	if d.pollInterval <= 0 {
+		d.pollInterval = defaultPollInterval
+	}
+	if d.retention <= 0 {
+		d.retention = defaultRetention
+	}
+	d.client = &http.Client{
+		Timeout: d.timeout,
+		// A redirect is not an acknowledgement, and following it would resend the event elsewhere
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	if !cfg.Enabled {
+		return d, nil
+	}
+	for _, subscriber := range cfg.Subscribers {
+		for _, event := range subscriber.Events {
+			if !slices.Contains(events, event) {
+				return nil, fmt.Errorf("webhook subscriber %s: unknown event %q", subscriber.URL, event)
+			}
+		}
+		d.secrets[subscriber.URL] = subscriber.Secret
+	}
+	d.subscribers = cfg.Subscribers
+	return d, nil
+}
+
+// Publish stores a delivery of the event for each subscriber of eventType and wakes the
+// sender. Storing does not depend on ctx being live, as the event has already happened.
+func (d *Dispatcher) Publish(ctx context.Context, eventType string, data any) error {
+	var urls []string
+	for _, subscriber := range d.subscribers {
+		if len(subscriber.Events) == 0 || slices.Contains(subscriber.Events, eventType) {
+			urls = append(urls, subscriber.URL)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+	now := d.clock.Now()
+	event := domain.Event{ID: uuid.New(), Type: eventType, CreatedAt: now.UTC(), Data: encoded}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	deliveries := make([]*domain.Delivery, 0, len(urls))
+	for _, url := range urls {
+		deliveries = append(deliveries, &domain.Delivery{
+			ID:            uuid.New(),
+			EventID:       event.ID,
+			EventType:     eventType,
+			URL:           url,
+			Payload:       string(payload),
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: now,
+		})
+	}
+	if err := d.db.WithContext(context.WithoutCancel(ctx)).Create(&deliveries).Error; err != nil {
+		return fmt.Errorf("failed to store webhook deliveries: %w", err)
+	}
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start launches the sender. It is a no-op when there are no subscribers. Deliveries
+// left pending by an earlier run are sent first.
+func (d *Dispatcher) Start() {
+	if len(d.subscribers) == 0 || d.stop != nil {
+		return
+	}
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.pollInterval)
+		defer ticker.Stop()
+
+		ctx := context.Background()
+		for {
+			d.dispatch(ctx)
+			d.cleanup(ctx)
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			case <-d.stop:
+				return
+			}
+		}
+	}()
+	d.logger.Info(context.Background(), "Webhook dispatcher started", map[string]any{"subscribers": len(d.subscribers)})
+}
+
+// Stop ends the sender once the deliveries being sent have finished. Pending deliveries
+// stay stored and are sent after the next Start.
+func (d *Dispatcher) Stop() {
+	if d.stop == nil {
+		return
+	}
+	close(d.stop)
+	<-d.done
+	d.stop = nil
+}
+
+// dispatch sends the deliveries that are due, up to workers at once, until none is left
+// or the dispatcher stops.
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	for {
+		deliveries, err := d.claim(ctx)
+		if err != nil {
+			d.logger.Error(ctx, "Failed to load due webhook deliveries", map[string]any{"error": err})
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < d.workers {
+			return
+		}
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+	}
+}
+
+// claim takes up to workers due deliveries and moves their next attempt past the time
+// sending them may take, so other instances sharing the database skip them meanwhile.
+func (d *Dispatcher) claim(ctx context.Context) ([]*domain.Delivery, error) {
+	now := d.clock.Now()
+	var deliveries []*domain.Delivery
+	err := d.db.WithContext(ctx).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		now.Add(d.timeout+leaseMargin), now, domain.DeliveryPending, now, d.workers,
+	).Scan(&deliveries).Error
+	return deliveries, err
+}
+
+// deliver makes one attempt at a delivery and records its outcome.
+func (d *Dispatcher) deliver(ctx context.Context, delivery *domain.Delivery) {
+	secret, subscribed := d.secrets[delivery.URL]
+	err := errUnsubscribed
+	if subscribed {
+		err = d.send(ctx, delivery, secret)
+	}
+
+	now := d.clock.Now()
+	delivery.Attempts++
+	updates := map[string]any{"attempts": delivery.Attempts, "updated_at": now}
+	fields := map[string]any{"deliveryID": delivery.ID.String(), "event": delivery.EventType, "url": delivery.URL, "attempts": delivery.Attempts}
+	switch {
+	case err == nil:
+		updates["status"] = domain.DeliveryDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case !subscribed || delivery.Attempts >= d.maxAttempts:
+		updates["status"] = domain.DeliveryFailed
+		updates["last_error"] = err.Error()
+		fields["error"] = err.Error()
+		d.logger.Error(ctx, "Webhook delivery failed for good", fields)
+	default:
+		next := now.Add(d.backoff(delivery.Attempts))
+		updates["next_attempt_at"] = next
+		updates["last_error"] = err.Error()
+		fields["error"], fields["nextAttemptAt"] = err.Error(), next
+		d.logger.Warn(ctx, "Webhook delivery attempt failed", fields)
+	}
+	if err := d.db.WithContext(ctx).Model(&domain.Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		// The lease runs out and the delivery is sent again
+		d.logger.Error(ctx, "Failed to record webhook delivery attempt", map[string]any{"error": err, "deliveryID": delivery.ID.String()})
+	}
+}
+
+// send POSTs the delivery's payload, signed with secret. Only a 2xx response counts as
+// delivered.
+func (d *Dispatcher) send(ctx context.Context, delivery *domain.Delivery, secret string) error {
+	timestamp := strconv.FormatInt(d.clock.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, strings.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, signature(secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if text := strings.TrimSpace(string(body)); text != "" {
+		return fmt.Errorf("subscriber answered %s: %s", resp.Status, text)
+	}
+	return fmt.Errorf("subscriber answered %s", resp.Status)
+}
+
+// backoff returns the delay before the attempt after the given number of failed ones:
+// the base delay, doubled after each failure up to the maximum, less up to a fifth at
+// random so retries of many deliveries spread out.
+func (d *Dispatcher) backoff(failures int) time.Duration {
+	delay := d.maxDelay
+	if failures <= 30 {
+		if next := d.baseDelay << (failures - 1); next > 0 && next < delay {
+			delay = next
+		}
+	}
+	return delay - rand.N(delay/5+1)
+}
+
+// cleanup removes delivered and failed deliveries past their retention, at most once
+// per cleanupInterval.
+func (d *Dispatcher) cleanup(ctx context.Context) {
+	now := d.clock.Now()
+	if now.Sub(d.lastCleanup) < cleanupInterval {
+		return
+	}
+	d.lastCleanup = now
+
+	result := d.db.WithContext(ctx).
+		Where("status <> ? AND updated_at < ?", domain.DeliveryPending, now.Add(-d.retention)).
+		Delete(&domain.Delivery{})
+	if result.Error != nil {
+		d.logger.Error(ctx, "Failed to remove old webhook deliveries", map[string]any{"error": result.Error})
+		return
+	}
+	if result.RowsAffected > 0 {
+		d.logger.Info(ctx, "Removed old webhook deliveries", map[string]any{"count": result.RowsAffected})
+	}
+}
+
+// signature returns the X-M3-Signature value of a payload sent at timestamp.
+func signature(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + payload))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
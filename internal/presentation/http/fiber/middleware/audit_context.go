@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"context"
+
+	appDomain "github.com/lugondev/m3-storage/internal/modules/app/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditContext returns the request's context carrying the client IP address and user
+// agent, for recording the request's actions in the audit log
+func AuditContext(c *fiber.Ctx) context.Context {
+	return appDomain.WithAuditClient(c.Context(), appDomain.AuditClient{
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	})
+}
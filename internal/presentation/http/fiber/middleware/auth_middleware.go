@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"strings"
+	"time"
 
 	"github.com/lugondev/m3-storage/internal/infra/jwt"
 	"github.com/lugondev/m3-storage/internal/shared/constants"
@@ -13,14 +15,22 @@ import (
 	"github.com/google/uuid"
 )
 
+// TokenRevocationChecker reports when a user's tokens were last revoked
+type TokenRevocationChecker interface {
+	RevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, bool, error)
+}
+
 type AuthMiddleware struct {
-	jwtService *jwt.JWTService
+	jwtService  *jwt.JWTService
+	revocations TokenRevocationChecker
 }
 
-// NewAuthMiddleware creates a new instance of AuthMiddleware.
-func NewAuthMiddleware(jwtService *jwt.JWTService) *AuthMiddleware {
+// NewAuthMiddleware creates a new instance of AuthMiddleware. Access tokens issued before
+// their user's tokens were revoked are rejected; revocations may be nil to skip the check.
+func NewAuthMiddleware(jwtService *jwt.JWTService, revocations TokenRevocationChecker) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwtService: jwtService,
+		jwtService:  jwtService,
+		revocations: revocations,
 	}
 }
 
@@ -48,6 +58,10 @@ func (m *AuthMiddleware) RequireAuth() fiber.Handler {
 			return errors.NewUnauthorizedError("invalid token type: expected access token")
 		}
 
+		if err := m.checkRevoked(c.Context(), claims); err != nil {
+			return err
+		}
+
 		// Store validated claims in context for later use
 		c.Locals(constants.UserClaimsKey, claims)
 		c.Locals(constants.UserIDKey, claims.Subject)
@@ -56,6 +70,44 @@ func (m *AuthMiddleware) RequireAuth() fiber.Handler {
 	}
 }
 
+// RequireRole middleware lets only users whose access token carries role through. It
+// must run after RequireAuth. Roles are read from the token, so a role change applies
+// once the user's current access token expires.
+func (m *AuthMiddleware) RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, err := GetUserClaims(c)
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(claims.Roles, role) {
+			return errors.NewForbiddenError("role " + role + " required")
+		}
+
+		return c.Next()
+	}
+}
+
+// checkRevoked rejects tokens issued no later than their user's last token revocation
+func (m *AuthMiddleware) checkRevoked(ctx context.Context, claims *jwt.JWTClaims) error {
+	if m.revocations == nil || claims.IssuedAt == nil {
+		return nil
+	}
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return errors.NewUnauthorizedError("invalid access token subject")
+	}
+
+	revokedAt, ok, err := m.revocations.RevokedAt(ctx, userID)
+	if err != nil {
+		return errors.WrapError(err, fiber.StatusServiceUnavailable, "failed to check token revocation")
+	}
+	if ok && !claims.IssuedAt.After(revokedAt) {
+		return errors.NewUnauthorizedError("access token has been revoked")
+	}
+	return nil
+}
+
 // extractToken gets the JWT token from the Authorization header
 func (m *AuthMiddleware) extractToken(c *fiber.Ctx) string {
 	authHeader := c.Get("Authorization")
@@ -72,9 +72,9 @@ func SetupMiddleware(app *fiber.App, cfg config.Config, i18nBundle *i18n.Bundle,
 	corsCfg := cors.Config{
 		AllowOrigins:     cfg.App.Origins,
 		AllowCredentials: cfg.App.Origins != "*",
-		AllowMethods:     "GET,POST,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID, traceparent",
-		ExposeHeaders:    "Authorization",
+		AllowMethods:     "GET,HEAD,POST,PUT,DELETE,PATCH,OPTIONS",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID, traceparent, Tus-Resumable, Upload-Length, Upload-Offset, Upload-Metadata",
+		ExposeHeaders:    "Authorization, Location, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size, Upload-Length, Upload-Offset, Media-Id",
 	}
 	app.Use(cors.New(corsCfg))
 
@@ -99,6 +99,7 @@ func ErrorHandler(log customLogger.Logger) fiber.ErrorHandler {
 	return func(c *fiber.Ctx, err error) error {
 		code := fiber.StatusInternalServerError
 		message := "Internal Server Error"
+		var details map[string]any
 
 		if e, ok := err.(*fiber.Error); ok {
 			code = e.Code
@@ -108,6 +109,7 @@ func ErrorHandler(log customLogger.Logger) fiber.ErrorHandler {
 		if e, ok := err.(*errors.Error); ok {
 			code = e.StatusCode // Use StatusCode instead of Code
 			message = TranslatorTranslate(c, fmt.Sprintf("error_%s", e.Code), e.Message)
+			details = e.Details
 		}
 
 		log.Error(c.UserContext(), "Request error", map[string]any{
@@ -127,6 +129,9 @@ func ErrorHandler(log customLogger.Logger) fiber.ErrorHandler {
 			"code":       code,
 			"request_id": c.Get(fiber.HeaderXRequestID),
 		}
+		if len(details) > 0 {
+			response["details"] = details
+		}
 
 		return c.Status(code).JSON(response)
 	}
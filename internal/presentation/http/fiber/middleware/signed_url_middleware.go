@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// SignedURLMiddleware admits requests only when their expires and signature query
+// parameters are a valid, unexpired signature for the object key in the wildcard path
+// segment. Anything else is rejected with 403.
+func SignedURLMiddleware(verifier storagePort.SignedURLVerifier) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := url.PathUnescape(c.Params("*"))
+		if err != nil || key == "" {
+			return errors.NewForbiddenError("invalid signed URL")
+		}
+		expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil {
+			return errors.NewForbiddenError("signed URL is missing a valid expires parameter")
+		}
+
+		if err := verifier.VerifySignedURL(key, expires, c.Query("signature")); err != nil {
+			if errors.Is(err, storagePort.ErrSignedURLExpired) {
+				return errors.NewForbiddenError("signed URL has expired")
+			}
+			return errors.NewForbiddenError("invalid signed URL")
+		}
+		return c.Next()
+	}
+}
@@ -1,9 +1,16 @@
 package router
 
 import (
+	"net/url"
+	"strings"
+
+	appHandler "github.com/lugondev/m3-storage/internal/modules/app/handler"
+	authDomain "github.com/lugondev/m3-storage/internal/modules/auth/domain"
 	authHandler "github.com/lugondev/m3-storage/internal/modules/auth/handler"
 	mediaHandler "github.com/lugondev/m3-storage/internal/modules/media/handler"
+	s3Handler "github.com/lugondev/m3-storage/internal/modules/s3api/handler"
 	storageHandler "github.com/lugondev/m3-storage/internal/modules/storage/handler"
+	storagePort "github.com/lugondev/m3-storage/internal/modules/storage/port"
 	"github.com/lugondev/m3-storage/internal/presentation/http/fiber/middleware"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,8 +21,15 @@ import (
 type RouterConfig struct {
 	AuthMw         *middleware.AuthMiddleware
 	AuthHandler    *authHandler.AuthHandler
+	AdminHandler   *authHandler.AdminHandler
+	AuditHandler   *appHandler.AuditHandler
+	AvatarHandler  *authHandler.AvatarHandler
+	AccountHandler *authHandler.AccountHandler
 	MediaHandler   *mediaHandler.MediaHandler
 	StorageHandler *storageHandler.StorageHandler
+
+	// Signed local file URLs are served under the path of LocalFiles' base URL when it is set
+	LocalFiles storagePort.LocalFileServer
 }
 
 // RegisterRoutes centralizes all API route registrations following DDD principles.
@@ -24,13 +38,23 @@ func RegisterRoutes(app *fiber.App, config *RouterConfig) {
 	// Infrastructure routes (non-domain specific)
 	registerInfrastructureRoutes(app)
 
+	// Token verification keys live at the well-known path other services look them up at
+	app.Get("/.well-known/jwks.json", config.AuthHandler.JWKS)
+
 	// API versioning - follows DDD by keeping domain routes versioned
 	v1 := app.Group("/api/v1")
 
 	// Register domain-specific route groups
-	registerAuthRoutes(v1, config.AuthHandler)
+	registerAuthRoutes(v1, config.AuthMw, config.AuthHandler, config.AvatarHandler, config.AccountHandler)
 	registerMediaRoutes(v1, config.AuthMw, config.MediaHandler)
 	registerStorageRoutes(v1, config.StorageHandler)
+	registerAdminRoutes(v1, config.AuthMw, config.AdminHandler, config.AuditHandler, config.MediaHandler)
+
+	// Share links are unversioned and short so they can be pasted anywhere
+	app.Get("/s/:token", config.MediaHandler.OpenMediaShare)
+
+	// Signed URLs for local storage are unversioned: their path comes from the configured base URL
+	registerLocalFileRoutes(app, config.LocalFiles, config.MediaHandler)
 }
 
 // registerInfrastructureRoutes handles non-domain specific routes
@@ -40,21 +64,28 @@ func registerInfrastructureRoutes(app *fiber.App) {
 }
 
 // registerAuthRoutes handles all authentication domain routes
-func registerAuthRoutes(api fiber.Router, handler *authHandler.AuthHandler) {
+func registerAuthRoutes(api fiber.Router, authMw *middleware.AuthMiddleware, handler *authHandler.AuthHandler, avatarHandler *authHandler.AvatarHandler, accountHandler *authHandler.AccountHandler) {
 	authRoutes := api.Group("/auth")
 
 	// Public authentication routes (no auth required)
 	authRoutes.Post("/register", handler.Register)
 	authRoutes.Post("/login", handler.Login)
 	authRoutes.Post("/refresh", handler.RefreshToken)
+	authRoutes.Post("/revoke", handler.RevokeRefreshToken)
 	authRoutes.Post("/forgot-password", handler.ForgotPassword)
+	authRoutes.Post("/reset-password", handler.ResetPassword)
+	authRoutes.Post("/verify-email", handler.VerifyEmail)
+	authRoutes.Post("/resend-verification", handler.ResendVerification)
 
 	// Protected authentication routes (auth required)
-	// These will be handled by middleware in the handler level for now
-	authRoutes.Get("/profile", handler.GetProfile)
-	authRoutes.Put("/profile", handler.UpdateProfile)
-	authRoutes.Post("/change-password", handler.ChangePassword)
-	authRoutes.Post("/logout", handler.Logout)
+	authRoutes.Get("/profile", authMw.RequireAuth(), handler.GetProfile)
+	authRoutes.Put("/profile", authMw.RequireAuth(), handler.UpdateProfile)
+	authRoutes.Post("/avatar", authMw.RequireAuth(), avatarHandler.UploadAvatar)
+	authRoutes.Post("/change-password", authMw.RequireAuth(), handler.ChangePassword)
+	authRoutes.Post("/change-email", authMw.RequireAuth(), handler.ChangeEmail)
+	authRoutes.Post("/logout", authMw.RequireAuth(), handler.Logout)
+	authRoutes.Get("/account-status", authMw.RequireAuth(), handler.AccountStatus)
+	authRoutes.Delete("/account", authMw.RequireAuth(), accountHandler.DeleteAccount)
 }
 
 // registerMediaRoutes handles all media domain routes
@@ -63,15 +94,59 @@ func registerMediaRoutes(api fiber.Router, authMw *middleware.AuthMiddleware, ha
 	mediaRoutes := api.Group("/media")
 	// Media upload operations - core domain functionality
 	mediaRoutes.Post("/upload", authMw.RequireAuth(), handler.UploadFile)
+	mediaRoutes.Post("/upload/batch", authMw.RequireAuth(), handler.BatchUploadFiles)
+	mediaRoutes.Post("/upload/presign", authMw.RequireAuth(), handler.PresignUpload)
+	mediaRoutes.Post("/upload/multipart", authMw.RequireAuth(), handler.InitiateMultipartUpload)
+	mediaRoutes.Get("/upload/multipart", authMw.RequireAuth(), handler.ListMultipartUploads)
+	mediaRoutes.Get("/upload/multipart/:uploadId", authMw.RequireAuth(), handler.GetMultipartUpload)
+	mediaRoutes.Get("/upload/multipart/:uploadId/progress", authMw.RequireAuth(), handler.StreamUploadProgress)
+	mediaRoutes.Put("/upload/multipart/:uploadId/parts/:partNumber", authMw.RequireAuth(), handler.UploadPart)
+	mediaRoutes.Post("/upload/multipart/:uploadId/complete", authMw.RequireAuth(), handler.CompleteMultipartUpload)
+	mediaRoutes.Delete("/upload/multipart/:uploadId", authMw.RequireAuth(), handler.AbortMultipartUpload)
+
+	// Resumable uploads over the tus protocol; OPTIONS is unauthenticated for capability discovery
+	mediaRoutes.Options("/tus", handler.TusOptions)
+	mediaRoutes.Post("/tus", authMw.RequireAuth(), handler.CreateTusUpload)
+	mediaRoutes.Head("/tus/:uploadId", authMw.RequireAuth(), handler.HeadTusUpload)
+	mediaRoutes.Patch("/tus/:uploadId", authMw.RequireAuth(), handler.PatchTusUpload)
+	mediaRoutes.Delete("/tus/:uploadId", authMw.RequireAuth(), handler.DeleteTusUpload)
 
 	// TODO: Add other media operations following RESTful patterns
 	mediaRoutes.Get("/", authMw.RequireAuth(), handler.ListMedia)
+	mediaRoutes.Get("/usage", authMw.RequireAuth(), handler.GetMediaUsage)
+	mediaRoutes.Get("/export", authMw.RequireAuth(), handler.ExportMedia)
+	mediaRoutes.Post("/batch-delete", authMw.RequireAuth(), handler.BatchDeleteMedia)
+	mediaRoutes.Post("/download-zip", authMw.RequireAuth(), handler.DownloadZip)
+
+	// Folders - registered before /:id so "folders" is not taken for a media ID
+	mediaRoutes.Post("/folders", authMw.RequireAuth(), handler.CreateFolder)
+	mediaRoutes.Get("/folders", authMw.RequireAuth(), handler.ListFolders)
+	mediaRoutes.Get("/folders/:folderId", authMw.RequireAuth(), handler.GetFolder)
+	mediaRoutes.Put("/folders/:folderId", authMw.RequireAuth(), handler.RenameFolder)
+	mediaRoutes.Post("/folders/:folderId/move", authMw.RequireAuth(), handler.MoveFolder)
+	mediaRoutes.Delete("/folders/:folderId", authMw.RequireAuth(), handler.DeleteFolder)
+
 	mediaRoutes.Get("/:id", authMw.RequireAuth(), handler.GetMedia)
 	mediaRoutes.Get("/:id/file", authMw.RequireAuth(), handler.ServeLocalFile)
+	mediaRoutes.Get("/:id/download", authMw.RequireAuth(), handler.DownloadMedia)
+	mediaRoutes.Get("/:id/thumbnail", authMw.RequireAuth(), handler.ServeThumbnail)
+	mediaRoutes.Get("/:id/renditions/:name", authMw.RequireAuth(), handler.ServeRendition)
+	mediaRoutes.Get("/:id/resize", authMw.RequireAuth(), handler.ResizeImage)
+	mediaRoutes.Get("/:id/signed-url", authMw.RequireAuth(), handler.GetSignedURL)
+	mediaRoutes.Post("/:id/confirm", authMw.RequireAuth(), handler.ConfirmUpload)
+	mediaRoutes.Put("/:id/folder", authMw.RequireAuth(), handler.MoveMedia)
+	mediaRoutes.Post("/:id/tags", authMw.RequireAuth(), handler.AddMediaTags)
+	mediaRoutes.Delete("/:id/tags/:tag", authMw.RequireAuth(), handler.RemoveMediaTag)
+	mediaRoutes.Post("/:id/share", authMw.RequireAuth(), handler.CreateMediaShare)
+	mediaRoutes.Get("/:id/shares", authMw.RequireAuth(), handler.ListMediaShares)
+	mediaRoutes.Delete("/:id/shares/:shareId", authMw.RequireAuth(), handler.RevokeMediaShare)
+	mediaRoutes.Patch("/:id", authMw.RequireAuth(), handler.UpdateMedia)
 	mediaRoutes.Delete("/:id", authMw.RequireAuth(), handler.DeleteMedia)
 
 	// Public routes - no authentication required
 	mediaRoutes.Get("/public/:id/file", handler.ServePublicLocalFile)
+	mediaRoutes.Get("/public/:id/sprite.jpg", handler.ServeVideoSprite)
+	mediaRoutes.Get("/public/:id/sprite.vtt", handler.ServeVideoSpriteVTT)
 }
 
 // registerStorageRoutes handles storage-related routes
@@ -81,3 +156,59 @@ func registerStorageRoutes(api fiber.Router, handler *storageHandler.StorageHand
 	storageRoutes.Get("/health", handler.CheckHealth)
 	storageRoutes.Get("/health/all", handler.CheckHealthAll)
 }
+
+// registerAdminRoutes handles operational routes that act across users
+func registerAdminRoutes(api fiber.Router, authMw *middleware.AuthMiddleware, userHandler *authHandler.AdminHandler, auditHandler *appHandler.AuditHandler, handler *mediaHandler.MediaHandler) {
+	adminRoutes := api.Group("/admin", authMw.RequireAuth(), authMw.RequireRole(string(authDomain.UserRoleAdmin)))
+	adminRoutes.Get("/users", userHandler.ListUsers)
+	adminRoutes.Patch("/users/:id", userHandler.UpdateUser)
+	adminRoutes.Delete("/users/:id", userHandler.DeleteUser)
+	adminRoutes.Put("/users/:id/role", userHandler.UpdateUserRole)
+	adminRoutes.Get("/users/:id/quota", userHandler.GetUserQuota)
+	adminRoutes.Put("/users/:id/quota", userHandler.UpdateUserQuota)
+	adminRoutes.Get("/audit-logs", auditHandler.ListAuditLogs)
+	adminRoutes.Post("/media/refresh-metadata", handler.RefreshMetadata)
+	adminRoutes.Post("/media/migrations", handler.StartMediaMigration)
+	adminRoutes.Get("/media/migrations/:jobId", handler.GetMediaMigration)
+	adminRoutes.Delete("/media/migrations/:jobId", handler.CancelMediaMigration)
+	adminRoutes.Post("/media/usage/reconcile", handler.StartUsageReconcile)
+	adminRoutes.Get("/media/usage/reconcile/:jobId", handler.GetUsageReconcile)
+	adminRoutes.Delete("/media/usage/reconcile/:jobId", handler.CancelUsageReconcile)
+	adminRoutes.Post("/media/usage/:userId/recalculate", handler.RecalculateUsage)
+}
+
+// registerLocalFileRoutes serves local storage files addressed by signed URLs
+func registerLocalFileRoutes(app *fiber.App, files storagePort.LocalFileServer, handler *mediaHandler.MediaHandler) {
+	if files == nil || files.SignedURLBase() == "" {
+		return
+	}
+	parsed, err := url.Parse(files.SignedURLBase())
+	if err != nil {
+		return
+	}
+	basePath := strings.TrimSuffix(parsed.Path, "/")
+	if basePath == "" {
+		// Serving signed files from the root would shadow every other route
+		return
+	}
+	app.Get(basePath+"/*", middleware.SignedURLMiddleware(files), handler.ServeSignedLocalFile(files))
+}
+
+// RegisterS3Routes registers the S3-compatible API on its own app. Requests are
+// path-style (/{bucket}/{key}); HEAD routes come first because GET routes also match HEAD.
+func RegisterS3Routes(app *fiber.App, handler *s3Handler.S3Handler) {
+	app.Use(handler.Authenticate)
+
+	app.Get("/", handler.ListBuckets)
+	app.Head("/:bucket", handler.HeadBucket)
+	app.Get("/:bucket", handler.GetBucket)
+	app.Put("/:bucket", handler.PutBucket)
+
+	app.Head("/:bucket/*", handler.HeadObject)
+	app.Get("/:bucket/*", handler.GetObject)
+	app.Put("/:bucket/*", handler.PutObject)
+	app.Delete("/:bucket/*", handler.DeleteObject)
+
+	// Multipart uploads, DeleteObjects and bucket management
+	app.All("/*", handler.NotImplemented)
+}
@@ -18,10 +18,11 @@ type ErrorResponse struct {
 
 // Error represents a custom error with additional context
 type Error struct {
-	StatusCode int    `json:"status_code"`
-	Code       string `json:"code"`
-	Message    string `json:"message"`
-	err        error  // Internal error for wrapping
+	StatusCode int            `json:"status_code"`
+	Code       string         `json:"code"`
+	Message    string         `json:"message"`
+	Details    map[string]any `json:"details,omitempty"` // Extra fields returned to the client, e.g. when a lock ends
+	err        error          // Internal error for wrapping
 }
 
 // NewError creates a new Error instance
@@ -68,6 +69,7 @@ func (e *Error) WithError(err error) *Error {
 		StatusCode: e.StatusCode,
 		Code:       e.Code,
 		Message:    e.Message,
+		Details:    e.Details,
 		err:        err,
 	}
 }
@@ -78,6 +80,18 @@ func (e *Error) WithMessage(message string) *Error {
 		StatusCode: e.StatusCode,
 		Code:       e.Code,
 		Message:    message,
+		Details:    e.Details,
+		err:        e.err,
+	}
+}
+
+// WithDetails creates a new error carrying details for the client
+func (e *Error) WithDetails(details map[string]any) *Error {
+	return &Error{
+		StatusCode: e.StatusCode,
+		Code:       e.Code,
+		Message:    e.Message,
+		Details:    details,
 		err:        e.err,
 	}
 }
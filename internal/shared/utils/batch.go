@@ -0,0 +1,49 @@
+package utils
+
+// BatchItemStatus is the outcome of one item in a batch operation.
+type BatchItemStatus string
+
+const (
+	BatchItemSucceeded BatchItemStatus = "succeeded"
+	BatchItemFailed    BatchItemStatus = "failed"
+)
+
+// BatchItem is the result for a single item of a batch operation.
+// Exactly one of Data or Error is set.
+type BatchItem[T any] struct {
+	ID     string          `json:"id"`
+	Status BatchItemStatus `json:"status"`
+	Data   *T              `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// BatchSummary counts the outcomes of a batch operation.
+type BatchSummary struct {
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// BatchResult is the response shape for batch endpoints. A failed item never
+// fails the whole batch; clients inspect each item's status instead.
+// It is not safe for concurrent use; collect results first, then add them in order.
+type BatchResult[T any] struct {
+	Results []BatchItem[T] `json:"results"`
+	Summary BatchSummary   `json:"summary"`
+}
+
+// NewBatchResult creates an empty BatchResult with room for size items.
+func NewBatchResult[T any](size int) *BatchResult[T] {
+	return &BatchResult[T]{Results: make([]BatchItem[T], 0, size)}
+}
+
+// Succeed records a successful item.
+func (r *BatchResult[T]) Succeed(id string, data T) {
+	r.Results = append(r.Results, BatchItem[T]{ID: id, Status: BatchItemSucceeded, Data: &data})
+	r.Summary.Succeeded++
+}
+
+// Fail records a failed item.
+func (r *BatchResult[T]) Fail(id string, err error) {
+	r.Results = append(r.Results, BatchItem[T]{ID: id, Status: BatchItemFailed, Error: err.Error()})
+	r.Summary.Failed++
+}
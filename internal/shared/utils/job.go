@@ -0,0 +1,57 @@
+package utils
+
+import "time"
+
+// JobStatus is the lifecycle state of a background job.
+type JobStatus string
+
+const (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobCancelled JobStatus = "cancelled"
+	JobFailed    JobStatus = "failed"
+)
+
+// JobProgress reports how far a background job has got. ETA is a linear estimate
+// from the rate so far and is only set while the job is running.
+type JobProgress struct {
+	Status     JobStatus  `json:"status"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	ETA        *time.Time `json:"eta,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// NewJobProgress starts tracking a running job of total items.
+func NewJobProgress(total int64, now time.Time) JobProgress {
+	return JobProgress{Status: JobRunning, Total: total, StartedAt: now}
+}
+
+// Advance records n more processed items and refreshes the ETA.
+func (p *JobProgress) Advance(n int64, now time.Time) {
+	p.Processed += n
+	if p.Processed <= 0 || p.Processed >= p.Total {
+		p.ETA = nil
+		return
+	}
+	perItem := now.Sub(p.StartedAt) / time.Duration(p.Processed)
+	eta := now.Add(perItem * time.Duration(p.Total-p.Processed))
+	p.ETA = &eta
+}
+
+// Finish ends the job with status; err, if any, is recorded as the failure reason.
+func (p *JobProgress) Finish(status JobStatus, err error, now time.Time) {
+	p.Status = status
+	p.FinishedAt = &now
+	p.ETA = nil
+	if err != nil {
+		p.Error = err.Error()
+	}
+}
+
+// Done reports whether the job has stopped.
+func (p *JobProgress) Done() bool {
+	return p.Status != JobRunning
+}
@@ -18,6 +18,21 @@ type Pagination struct {
 	HasNext     bool  `json:"has_next"`
 }
 
+// CursorQuery represents the query parameters for cursor (keyset) pagination. An empty
+// cursor requests the first page.
+type CursorQuery struct {
+	Cursor   string `query:"cursor" json:"cursor"`
+	PageSize int    `query:"page_size" json:"page_size"`
+}
+
+// CursorPagination represents the metadata of a page listed by cursor. NextCursor
+// requests the following page and is empty on the last one.
+type CursorPagination struct {
+	PageSize   int    `json:"page_size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+}
+
 const (
 	DefaultPage     = 1
 	DefaultPageSize = 10
@@ -60,3 +75,12 @@ func NewPagination(query PaginationQuery, totalItems int64) Pagination {
 		HasNext:     query.Page < totalPages,
 	}
 }
+
+// ValidateAndSetDefaults validates and sets default values for cursor pagination parameters.
+func (q *CursorQuery) ValidateAndSetDefaults() {
+	if q.PageSize < 1 {
+		q.PageSize = DefaultPageSize
+	} else if q.PageSize > MaxPageSize {
+		q.PageSize = MaxPageSize
+	}
+}
@@ -26,6 +26,26 @@ func ParseTime(value string, layout string) (time.Time, error) {
 	return time.Parse(layout, value)
 }
 
+// ParseFilterTime parses a time bound of a list filter: an RFC 3339 time or a YYYY-MM-DD
+// date (UTC). With endOfDay, a date stands for the start of the next day, so an exclusive
+// bound includes the whole day. An empty value is no bound.
+func ParseFilterTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfDay {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
 // FormatTime formats a time.Time using the specified layout.
 func FormatTime(t time.Time, layout string) string {
 	return t.Format(layout)
@@ -0,0 +1,84 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+// DefaultPasswordMinLength is the shortest password accepted when no minimum is configured
+const DefaultPasswordMinLength = 8
+
+// commonPasswords are passwords found at the top of every leaked password list. They are
+// compared in lower case.
+var commonPasswords = map[string]struct{}{
+	"123456": {}, "12345678": {}, "123456789": {}, "1234567890": {}, "12345": {}, "1234567": {},
+	"111111": {}, "000000": {}, "123123": {}, "654321": {}, "666666": {}, "888888": {},
+	"password": {}, "password1": {}, "password123": {}, "passw0rd": {}, "p@ssw0rd": {},
+	"qwerty": {}, "qwerty123": {}, "qwertyuiop": {}, "1q2w3e4r": {}, "1qaz2wsx": {}, "asdfghjkl": {},
+	"abc123": {}, "abcd1234": {}, "letmein": {}, "welcome": {}, "welcome1": {}, "iloveyou": {},
+	"admin": {}, "admin123": {}, "administrator": {}, "login": {}, "master": {}, "secret": {},
+	"monkey": {}, "dragon": {}, "football": {}, "baseball": {}, "sunshine": {}, "princess": {},
+	"trustno1": {}, "superman": {}, "changeme": {}, "starwars": {}, "whatever": {},
+}
+
+// PasswordPolicy decides which passwords users may choose. The zero value only enforces
+// DefaultPasswordMinLength and rejects common passwords.
+type PasswordPolicy struct {
+	MinLength     int  // Minimum length in characters; 0 means DefaultPasswordMinLength
+	RequireUpper  bool // At least one upper case letter
+	RequireLower  bool // At least one lower case letter
+	RequireDigit  bool // At least one digit
+	RequireSymbol bool // At least one character that is not a letter, digit or space
+	AllowCommon   bool // Accept passwords from the common password list
+}
+
+// Check returns an ErrValidation naming the first rule password breaks, in details
+// under "rule", or nil when password satisfies the policy.
+func (p PasswordPolicy) Check(password string) error {
+	minLength := p.MinLength
+	if minLength <= 0 {
+		minLength = DefaultPasswordMinLength
+	}
+	if utf8.RuneCountInString(password) < minLength {
+		return passwordError("min_length", fmt.Sprintf("password must be at least %d characters", minLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	switch {
+	case p.RequireUpper && !upper:
+		return passwordError("upper", "password must contain an upper case letter")
+	case p.RequireLower && !lower:
+		return passwordError("lower", "password must contain a lower case letter")
+	case p.RequireDigit && !digit:
+		return passwordError("digit", "password must contain a digit")
+	case p.RequireSymbol && !symbol:
+		return passwordError("symbol", "password must contain a symbol")
+	}
+
+	if !p.AllowCommon {
+		if _, common := commonPasswords[strings.ToLower(password)]; common {
+			return passwordError("common", "password is too common")
+		}
+	}
+	return nil
+}
+
+func passwordError(rule, message string) error {
+	return errors.ErrValidation.WithMessage(message).WithDetails(map[string]any{"rule": rule})
+}
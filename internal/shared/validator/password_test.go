@@ -0,0 +1,52 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/lugondev/m3-storage/internal/shared/errors"
+)
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	strict := PasswordPolicy{MinLength: 10, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantRule string // Rule named in the error details; empty when the password is accepted
+	}{
+		{"common word", PasswordPolicy{}, "password", "common"},
+		{"common digits", PasswordPolicy{}, "12345678", "common"},
+		{"common in other case", PasswordPolicy{}, "PassWord123", "common"},
+		{"too short by default", PasswordPolicy{}, "k7#vQ2", "min_length"},
+		{"length counts characters, not bytes", PasswordPolicy{}, "ñøçåßé", "min_length"},
+		{"long enough by default", PasswordPolicy{}, "correct horse battery", ""},
+		{"common when allowed", PasswordPolicy{AllowCommon: true}, "password", ""},
+		{"short for the minimum", strict, "Ab1!efgh", "min_length"},
+		{"no upper case", strict, "abcdefg1!x", "upper"},
+		{"no lower case", strict, "ABCDEFG1!X", "lower"},
+		{"no digit", strict, "Abcdefgh!x", "digit"},
+		{"no symbol", strict, "Abcdefgh1x", "symbol"},
+		{"every class", strict, "Abcdefg1!x", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.password)
+			if tt.wantRule == "" {
+				if err != nil {
+					t.Fatalf("Check(%q) = %v, want accepted", tt.password, err)
+				}
+				return
+			}
+
+			appErr, ok := errors.As(err)
+			if !ok || appErr.Code != errors.ErrValidation.Code {
+				t.Fatalf("Check(%q) = %v, want a validation error", tt.password, err)
+			}
+			if rule := appErr.Details["rule"]; rule != tt.wantRule {
+				t.Errorf("Check(%q) broke rule %v, want %q", tt.password, rule, tt.wantRule)
+			}
+		})
+	}
+}